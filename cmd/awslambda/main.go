@@ -137,7 +137,15 @@ func startIO() {
 		dkio.WithDataway(config.Cfg.Dataway),
 		dkio.WithCompactAt(c.MaxCacheCount),
 		dkio.WithFilters(c.Filters),
+		dkio.WithRateLimit(c.RateLimit),
+		dkio.WithDownsample(c.Downsample),
+		dkio.WithTransforms(c.Transforms),
+		dkio.WithSchemaCheck(c.SchemaCheck),
+		dkio.WithPointTrace(c.PointTrace),
+		dkio.WithPanicReport(c.PanicReport),
+		dkio.WithSinks(c.Sinks),
 		dkio.WithCompactWorkers(c.CompactWorkers),
+		dkio.WithBatch(c.Batch),
 		dkio.WithRecorder(config.Cfg.Recorder),
 		dkio.WithCompactInterval(c.CompactInterval),
 		dkio.WithCompactor(false),
@@ -27,6 +27,7 @@ import (
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/dnswatcher"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/election"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/gitrepo"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/goroutine"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/httpapi"
 	dkio "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/dataway"
@@ -223,7 +224,15 @@ func startIO() {
 		dkio.WithDataway(config.Cfg.Dataway),
 		dkio.WithCompactAt(c.MaxCacheCount),
 		dkio.WithFilters(c.Filters),
+		dkio.WithRateLimit(c.RateLimit),
+		dkio.WithDownsample(c.Downsample),
+		dkio.WithTransforms(c.Transforms),
+		dkio.WithSchemaCheck(c.SchemaCheck),
+		dkio.WithPointTrace(c.PointTrace),
+		dkio.WithPanicReport(c.PanicReport),
+		dkio.WithSinks(c.Sinks),
 		dkio.WithCompactWorkers(c.CompactWorkers),
+		dkio.WithBatch(c.Batch),
 		dkio.WithRecorder(config.Cfg.Recorder),
 		dkio.WithRemoteJob(config.Cfg.RemoteJob, config.Cfg.Dataway),
 		dkio.WithAvailableCPUs(datakit.AvailableCPUs),
@@ -286,6 +295,8 @@ func doRun() error {
 		go gc(du)
 	}
 
+	goroutine.StartWatchdog(config.Cfg.GoroutineWatchdog, datakit.Exit.Wait())
+
 	cpuLimit := getCurrentCPULimits()
 	l.Infof("get limited cpu cores: %f", cpuLimit)
 	if cpuLimit > 1.0 {
@@ -61,7 +61,7 @@ require (
 	github.com/opencontainers/runtime-spec v1.0.3-0.20210326190908-1c3f411f0417
 	github.com/openzipkin/zipkin-go v0.2.2
 	github.com/ory/dockertest/v3 v3.9.1
-	github.com/oschwald/geoip2-golang v1.9.0 // indirect
+	github.com/oschwald/geoip2-golang v1.9.0
 	github.com/pkg/sftp v1.11.0
 	github.com/prometheus-operator/prometheus-operator/pkg/client v0.51.2
 	github.com/prometheus/client_golang v1.16.0
@@ -306,7 +306,7 @@ require (
 	go.etcd.io/etcd/client/v3 v3.5.6 // indirect
 	go.mongodb.org/mongo-driver v1.10.2
 	go.uber.org/multierr v1.9.0
-	golang.org/x/arch v0.3.0 // indirect
+	golang.org/x/arch v0.3.0
 	golang.org/x/mod v0.13.0 // indirect
 	golang.org/x/oauth2 v0.5.0 // indirect
 	golang.org/x/sync v0.4.0 // indirect
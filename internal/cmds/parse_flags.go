@@ -238,6 +238,7 @@ var (
 	flagToolParseKVFile         = fsTool.String("parse-kv-file", "", "parse input conf file with kv replaced")
 	flagToolKVFile              = fsTool.String("kv-file", "", "specify the kv file path")
 	flagToolRemoveApmAutoInject = fsTool.Bool("remove-apm-auto-inject", false, "remove apm-auto-inject")
+	flagToolReplayOverflow      = fsTool.Bool("replay-overflow", false, "replay data within the WAL overflow object store to dataway")
 
 	flagToolChangeDockerContainersRuntime = fsTool.String("change-docker-containers-runtime", "",
 		"change the runtime of the created container, the value is runc or dk-runc")
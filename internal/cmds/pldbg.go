@@ -218,23 +218,46 @@ func plScriptTmpStore(category point.Category) (*manager.ScriptStore, map[string
 		ns := manager.NSDefault
 		plPath := filepath.Join(datakit.InstallDir, "pipeline")
 		scripts, _ := manager.ReadWorkspaceScripts(plPath)
-		errs[ns] = store.UpdateScriptsWithNS(ns, scripts[category], nil)
+		errs[ns] = updateTmpStore(store, ns, category, scripts)
 	}
 	{ // gitrepo
 		ns := manager.NSGitRepo
 		plPath := filepath.Join(datakit.GitReposRepoFullPath, "pipeline")
 		scripts, _ := manager.ReadWorkspaceScripts(plPath)
-		errs[ns] = store.UpdateScriptsWithNS(ns, scripts[category], nil)
+		errs[ns] = updateTmpStore(store, ns, category, scripts)
 	}
 	{ // remote
 		ns := manager.NSRemote
 		plPath := filepath.Join(datakit.PipelineRemoteDir, plremote.GetConentFileName())
 		if tarMap, err := targzutil.ReadTarToMap(plPath); err == nil {
-			allCategory := plremote.ConvertContentMapToThreeMap(tarMap)
-			scripts := allCategory[category.String()]
-			errs[ns] = store.UpdateScriptsWithNS(ns, scripts, nil)
+			scripts := map[point.Category]map[string]string{}
+			for cat, arr := range plremote.ConvertContentMapToThreeMap(tarMap) {
+				scripts[point.CatString(cat)] = arr
+			}
+			errs[ns] = updateTmpStore(store, ns, category, scripts)
 		}
 	}
 
 	return store, errs
 }
+
+// updateTmpStore update the scripts of the category with imports expanded, the
+// scripts failed to expand are returned within the errors, and the compile
+// errors are positioned on the scripts imported.
+func updateTmpStore(store *manager.ScriptStore, ns string, category point.Category,
+	scripts map[point.Category]map[string]string,
+) map[string]error {
+	expanded, expandErrs := plval.ExpandImports(ns, scripts)
+
+	errs := store.UpdateScriptsWithNS(ns, expanded[category], nil)
+	if errs == nil {
+		errs = map[string]error{}
+	}
+	plval.RewriteErrors(ns, category, errs)
+
+	for name, err := range expandErrs[category] {
+		errs[name] = err
+	}
+
+	return errs
+}
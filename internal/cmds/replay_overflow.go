@@ -0,0 +1,30 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package cmds
+
+import (
+	"github.com/dustin/go-humanize"
+
+	cp "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/colorprint"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/config"
+)
+
+// replayOverflow upload the data within the WAL overflow object store to dataway.
+func replayOverflow() error {
+	dw := config.Cfg.Dataway
+	if err := dw.Init(); err != nil {
+		return err
+	}
+
+	nbytes := 0
+	n, err := dw.ReplayOverflow(func(key string, size int) {
+		nbytes += size
+		cp.Output("> Replayed %q(%s)\n", key, humanize.Bytes(uint64(size)))
+	})
+
+	cp.Infof("Total replayed %d objects(%s)\n", n, humanize.Bytes(uint64(nbytes)))
+	return err
+}
@@ -140,6 +140,14 @@ func runToolFlags() error {
 		cp.Output("%s", replacedData)
 		os.Exit(0)
 
+	case *flagToolReplayOverflow:
+		tryLoadMainCfg()
+		if err := replayOverflow(); err != nil {
+			cp.Errorf("[E] replay WAL overflow failed: %s\n", err.Error())
+			os.Exit(-1)
+		}
+		os.Exit(0)
+
 	case *flagToolRemoveApmAutoInject:
 		// cleanup apm inject
 		if err := apmInj.Uninstall(
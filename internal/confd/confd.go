@@ -502,8 +502,7 @@ func getPipelineData() {
 	l.Debug("before set pipelines from confd ")
 
 	if m, ok := plval.GetManager(); ok && m != nil {
-		m.LoadScriptsFromWorkspace(
-			manager.NSConfd, datakit.ConfdPipelineDir, nil)
+		plval.LoadScriptsFromWorkspace(m, manager.NSConfd, datakit.ConfdPipelineDir)
 	}
 }
 
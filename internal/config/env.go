@@ -20,6 +20,8 @@ import (
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/dataway"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/filter"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/ptrace"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline/plval"
 )
 
 func (c *Config) loadConfdEnvs() {
@@ -247,6 +249,15 @@ func (c *Config) loadPipelineEnvs() {
 		}
 	}
 
+	if v := datakit.GetEnv("ENV_PIPELINE_REMOTE_SOURCE"); v != "" {
+		var rs plval.RemoteSourceCfg
+		if err := json.Unmarshal([]byte(v), &rs); err != nil {
+			l.Errorf("unmarshal `ENV_PIPELINE_REMOTE_SOURCE` failed: %s", err.Error())
+		} else {
+			c.Pipeline.RemoteSource = &rs
+		}
+	}
+
 	if v := datakit.GetEnv("ENV_PIPELINE_DISABLE_HTTP_REQUEST_FUNC"); v != "" {
 		var err error
 		c.Pipeline.DisableHTTPRequestFunc, err = strconv.ParseBool(v)
@@ -315,6 +326,28 @@ func (c *Config) loadDatawayEnvs() {
 		c.Dataway.GZip = false
 	}
 
+	if v := datakit.GetEnv("ENV_DATAWAY_COMPRESSION"); v != "" {
+		l.Infof("ENV_DATAWAY_COMPRESSION set to %q", v)
+		c.Dataway.Compression = v
+	}
+
+	if v := datakit.GetEnv("ENV_DATAWAY_GZIP_LEVEL"); v != "" {
+		if n, err := strconv.Atoi(v); err != nil {
+			l.Warnf("invalid env key ENV_DATAWAY_GZIP_LEVEL, value %s, err: %s ignored", v, err)
+		} else {
+			c.Dataway.GZipLevel = n
+		}
+	}
+
+	if v := datakit.GetEnv("ENV_DATAWAY_ROUTES"); v != "" {
+		var routes map[string][]int
+		if err := json.Unmarshal([]byte(v), &routes); err != nil {
+			l.Warnf("invalid env key ENV_DATAWAY_ROUTES, value %s, err: %s ignored", v, err)
+		} else {
+			c.Dataway.Routes = routes
+		}
+	}
+
 	if v := datakit.GetEnv("ENV_DATAWAY_MAX_RAW_BODY_SIZE"); v != "" {
 		value, err := strconv.ParseInt(v, 10, 64)
 		if err != nil {
@@ -480,6 +513,36 @@ func (c *Config) loadDatawayEnvs() {
 				c.Dataway.WAL.FailCacheCleanInterval = x
 			}
 		}
+
+		if v := datakit.GetEnv("ENV_DATAWAY_WAL_DROP_POLICY"); v != "" {
+			c.Dataway.WAL.DropPolicy = v
+		}
+
+		if v := datakit.GetEnv("ENV_DATAWAY_WAL_CATEGORIES"); v != "" {
+			var cats map[string]*dataway.WALCategoryConf
+			if err := json.Unmarshal([]byte(v), &cats); err != nil {
+				l.Warnf("invalid ENV_DATAWAY_WAL_CATEGORIES, expect JSON, got %s: %s, ignored", v, err)
+			} else {
+				c.Dataway.WAL.Categories = cats
+			}
+		}
+
+		if v := datakit.GetEnv("ENV_DATAWAY_WAL_MAX_INFLIGHT"); v != "" {
+			if x, err := strconv.ParseInt(v, 10, 64); err != nil {
+				l.Warnf("invalid ENV_DATAWAY_WAL_MAX_INFLIGHT, expect int, got %s, ignored", v)
+			} else {
+				c.Dataway.WAL.MaxInflight = int(x)
+			}
+		}
+
+		if v := datakit.GetEnv("ENV_DATAWAY_WAL_OVERFLOW"); v != "" {
+			var of dataway.OverflowConf
+			if err := json.Unmarshal([]byte(v), &of); err != nil {
+				l.Warnf("invalid ENV_DATAWAY_WAL_OVERFLOW, expect JSON, got %s: %s, ignored", v, err)
+			} else {
+				c.Dataway.WAL.Overflow = &of
+			}
+		}
 	} else {
 		l.Errorf("WAL not set, should not been here")
 	}
@@ -609,6 +672,42 @@ func (c *Config) loadIOEnvs() {
 		}
 	}
 
+	if v := datakit.GetEnv("ENV_IO_BATCH_MAX_BYTES"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			l.Warnf("invalid env key ENV_IO_BATCH_MAX_BYTES, value %s, err: %s ignored", v, err)
+		} else {
+			if c.IO.Batch == nil {
+				c.IO.Batch = &io.BatchConf{}
+			}
+			c.IO.Batch.MaxBytes = int(n)
+		}
+	}
+
+	if v := datakit.GetEnv("ENV_IO_BATCH_MAX_LATENCY"); v != "" {
+		du, err := time.ParseDuration(v)
+		if err != nil {
+			l.Warnf("invalid env key ENV_IO_BATCH_MAX_LATENCY, value %s, err: %s ignored", v, err)
+		} else {
+			if c.IO.Batch == nil {
+				c.IO.Batch = &io.BatchConf{}
+			}
+			c.IO.Batch.MaxLatency = du
+		}
+	}
+
+	if v := datakit.GetEnv("ENV_IO_BATCH_CATEGORIES"); v != "" {
+		var x map[string]*io.BatchRule
+		if err := json.Unmarshal([]byte(v), &x); err != nil {
+			l.Warnf("invalid env key ENV_IO_BATCH_CATEGORIES, value %s, err: %s ignored", v, err)
+		} else {
+			if c.IO.Batch == nil {
+				c.IO.Batch = &io.BatchConf{}
+			}
+			c.IO.Batch.Categories = x
+		}
+	}
+
 	if v := datakit.GetEnv("ENV_IO_FEED_CHAN_SIZE"); v != "" {
 		n, err := strconv.ParseInt(v, 10, 64)
 		if err != nil {
@@ -619,6 +718,80 @@ func (c *Config) loadIOEnvs() {
 		}
 	}
 
+	if v := datakit.GetEnv("ENV_IO_RATE_LIMIT"); v != "" {
+		var x io.RateLimitConf
+		if err := json.Unmarshal([]byte(v), &x); err != nil {
+			l.Warnf("invalid env key ENV_IO_RATE_LIMIT, value %s, err: %s ignored", v, err)
+		} else {
+			c.IO.RateLimit = &x
+		}
+	}
+
+	if v := datakit.GetEnv("ENV_IO_TRANSFORMS"); v != "" {
+		var x []*io.TransformRule
+		if err := json.Unmarshal([]byte(v), &x); err != nil {
+			l.Warnf("invalid env key ENV_IO_TRANSFORMS, value %s, err: %s ignored", v, err)
+		} else {
+			c.IO.Transforms = x
+		}
+	}
+
+	if v := datakit.GetEnv("ENV_IO_SCHEMA_CHECK"); v != "" {
+		var x io.SchemaCheckConf
+		if err := json.Unmarshal([]byte(v), &x); err != nil {
+			l.Warnf("invalid env key ENV_IO_SCHEMA_CHECK, value %s, err: %s ignored", v, err)
+		} else {
+			c.IO.SchemaCheck = &x
+		}
+	}
+
+	if v := datakit.GetEnv("ENV_IO_POINT_TRACE"); v != "" {
+		var x ptrace.Conf
+		if err := json.Unmarshal([]byte(v), &x); err != nil {
+			l.Warnf("invalid env key ENV_IO_POINT_TRACE, value %s, err: %s ignored", v, err)
+		} else {
+			c.IO.PointTrace = &x
+		}
+	}
+
+	if v := datakit.GetEnv("ENV_IO_PANIC_REPORT"); v != "" {
+		var x io.PanicReportConf
+		if err := json.Unmarshal([]byte(v), &x); err != nil {
+			l.Warnf("invalid env key ENV_IO_PANIC_REPORT, value %s, err: %s ignored", v, err)
+		} else {
+			c.IO.PanicReport = &x
+		}
+	}
+
+	if v := datakit.GetEnv("ENV_IO_SINKS"); v != "" {
+		var x io.SinkConf
+		if err := json.Unmarshal([]byte(v), &x); err != nil {
+			l.Warnf("invalid env key ENV_IO_SINKS, value %s, err: %s ignored", v, err)
+		} else {
+			c.IO.Sinks = &x
+		}
+	}
+
+	if v := datakit.GetEnv("ENV_IO_DOWNSAMPLE_WINDOW"); v != "" {
+		du, err := time.ParseDuration(v)
+		if err != nil {
+			l.Warnf("invalid env key ENV_IO_DOWNSAMPLE_WINDOW, value %s, err: %s ignored", v, err)
+		} else {
+			if c.IO.Downsample == nil {
+				c.IO.Downsample = &io.DownsampleConf{}
+			}
+			c.IO.Downsample.Window = du
+		}
+	}
+
+	// not overwrite the method configured if the env not set
+	if v := datakit.GetEnv("ENV_IO_DOWNSAMPLE_METHOD"); v != "" {
+		if c.IO.Downsample == nil {
+			c.IO.Downsample = &io.DownsampleConf{}
+		}
+		c.IO.Downsample.Method = v
+	}
+
 	// filters
 	if v := datakit.GetEnv("ENV_IO_FILTERS"); v != "" {
 		var x map[string]filter.FilterConditions
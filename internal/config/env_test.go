@@ -13,6 +13,7 @@ import (
 
 	"github.com/GuanceCloud/cliutils/pipeline/offload"
 	"github.com/stretchr/testify/assert"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/dataway"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/filter"
 )
@@ -222,6 +223,21 @@ func TestLoadEnv(t *testing.T) {
 			}(),
 		},
 
+		{
+			name: "test-ENV_IO_DOWNSAMPLE",
+			envs: map[string]string{
+				"ENV_IO_DOWNSAMPLE_WINDOW": "30s",
+				"ENV_IO_DOWNSAMPLE_METHOD": "mean",
+			},
+			expect: func() *Config {
+				cfg := DefaultConfig()
+				cfg.IO.Downsample = &io.DownsampleConf{
+					DownsampleRule: io.DownsampleRule{Window: 30 * time.Second, Method: "mean"},
+				}
+				return cfg
+			}(),
+		},
+
 		{
 			name: "test-ENV_IO_FILTERS-with-bad-json",
 			envs: map[string]string{
@@ -15,6 +15,7 @@ import (
 	"github.com/GuanceCloud/cliutils/tracer"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/datakit"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/election"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/goroutine"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/dataway"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/operator"
@@ -56,6 +57,9 @@ type Config struct {
 	EnablePProf bool   `toml:"enable_pprof"`
 	PProfListen string `toml:"pprof_listen"`
 
+	// watchdog on the long-running goroutines
+	GoroutineWatchdog *goroutine.WatchdogConf `toml:"goroutine_watchdog,omitempty"`
+
 	// confd config
 	Confds []*ConfdCfg `toml:"confds"`
 
@@ -355,6 +355,28 @@ func SetLog() {
 
 // G create a goroutine group, with namespace datakit.
 func G(name string) *goroutine.Group {
+	return newG(goroutine.Option{Name: name})
+}
+
+// GStop create a goroutine group like G, the context passed to the goroutines
+// is canceled on global exit or any of the stops closed(such as the semStop
+// of the input), so the goroutines only need to select on ctx.Done().
+func GStop(name string, stops ...*cliutils.Sem) *goroutine.Group {
+	opt := goroutine.Option{
+		Name:   name,
+		StopOn: []<-chan interface{}{Exit.Wait()},
+	}
+
+	for _, s := range stops {
+		if s != nil {
+			opt.StopOn = append(opt.StopOn, s.Wait())
+		}
+	}
+
+	return newG(opt)
+}
+
+func newG(opt goroutine.Option) *goroutine.Group {
 	panicCb := func(b []byte) bool {
 		l.Errorf("recover panic: %s", string(b))
 		select {
@@ -365,12 +387,11 @@ func G(name string) *goroutine.Group {
 		}
 	}
 
-	g := goroutine.NewGroup(goroutine.Option{
-		Name:         name,
-		PanicTimes:   6,
-		PanicCb:      panicCb,
-		PanicTimeout: 10 * time.Millisecond,
-	})
+	opt.PanicTimes = 6
+	opt.PanicCb = panicCb
+	opt.PanicTimeout = 10 * time.Millisecond
+
+	g := goroutine.NewGroup(opt)
 	var mu sync.Mutex
 	mu.Lock()
 	goroutines = append(goroutines, g)
@@ -28,6 +28,15 @@ default_enabled_inputs = [
 enable_pprof = true
 pprof_listen = "localhost:6060" # pprof listen
 
+# goroutine_watchdog: flag the goroutines running longer than the threshold
+# of the goroutine group, such as the inputs stuck on hung external commands.
+#[goroutine_watchdog]
+#  enable = true
+#  dump_stack = false # log the stack of the flagged goroutine
+#  thresholds = { inputs_smart = "5m" }
+#  wait_threshold = "10m" # report the group Wait() blocked without progress, disabled if not set
+#  wait_thresholds = { inputs_smart = "30m" }
+
 # protect_mode: bool, default false
 # When protect_mode eanbled, we can set radical collect parameters, these may cause Datakit
 # collect data more frequently.
@@ -78,6 +87,17 @@ ulimit = 64000
   # How often to sync remote pipeline
   remote_pull_interval = "1m"
 
+  # Sync the remote pipelines from git repo instead of dataway(source = "git"),
+  # and the scripts under local_dir always override the pulled ones.
+  #[pipeline.remote_source]
+  #  source    = "dataway"
+  #  local_dir = ""
+  #  git_url    = "git@github.com:path/to/repository.git"
+  #  git_branch = "master"
+  #  git_path   = ""  # sub dir of the scripts within the repo
+  #  git_ssh_private_key_path     = "/Users/username/.ssh/id_rsa"
+  #  git_ssh_private_key_password = ""
+
   #
   # reftab configures
   #
@@ -157,6 +177,16 @@ ulimit = 64000
   flush_workers   = 0 # default to (cpu_core * 2)
   flush_interval  = "10s"
 
+  # Batch the points on payload bytes and latency instead of the fixed
+  # max_cache_count and flush_interval: flush once the approximate payload
+  # bytes reached max_bytes, or the first cached point waited for max_latency.
+  #[io.batch]
+  #  max_bytes   = 1048576
+  #  max_latency = "10s"
+  #  [io.batch.categories.logging]
+  #    max_bytes   = 4194304
+  #    max_latency = "5s"
+
   # Queue size of feed.
   feed_chan_size = 1
 
@@ -181,6 +211,76 @@ ulimit = 64000
   #    "{ service = re("abc.*") AND some_tag CONTAIN ['def_.*'] }",
   #  ]
 
+  # Limit the points and bytes fed per second on category or input name,
+  # the exceeded feeding are dropped and the input got back-pressure error.
+  #[io.rate_limit.categories.logging]
+  #  points_per_sec = 10000
+  #  bytes_per_sec  = 10485760
+  #[io.rate_limit.inputs.logfwd]
+  #  points_per_sec = 1000
+
+  # Merge the metric points of the same time series within the window into
+  # single point, the method is last/mean/max. The rule on input overwrite the
+  # global rule, and method "none" disable the downsample on the input.
+  #[io.downsample]
+  #  window = "10s"
+  #  method = "last"
+  #  [io.downsample.inputs.cpu]
+  #    window = "30s"
+  #    method = "mean"
+
+  # Transform the points of the matched measurements(glob) before uploading:
+  # drop the point on the tag value(glob), rename fields, multiply numeric
+  # fields by the factor and add static tags.
+  #[[io.transforms]]
+  #  measurements = ["nginx*"]
+  #  category = "metric"
+  #  drop_by_tags = { env = "test*" }
+  #  rename_fields = { req_ms = "req_time" }
+  #  unit_conversions = { req_time = 0.001 }
+  #  add_tags = { team = "infra" }
+
+  # Check the field types of the points before uploading, the field of type
+  # (int/float/string/bool) other than the type seen first on the measurement
+  # is logged, and converted to the first type(or dropped) if coerce enabled.
+  #[io.schema_check]
+  #  enable = true
+  #  coerce = false
+  #  max_fields = 100000
+
+  # Trace the delivery path(feed/pipeline/queue/WAL/upload) of the matched
+  # points for debugging, the traces are listed by API /v1/point/trace. It's
+  # costly and should not be enabled for long.
+  #[io.point_trace]
+  #  enable = true
+  #  max_traces = 1000
+  #  [[io.point_trace.rules]]
+  #    category = "logging"
+  #    measurement = "nginx"
+  #    tags = { host = "web-01" }
+
+  # Upload the panics recovered within Datakit as logging(or keyevent), so the
+  # crashes are visible within the workspace.
+  #[io.panic_report]
+  #  enable = true
+  #  category = "logging"
+  #  max_stack = 4096
+
+  # Write the points of the categories into Kafka besides dataway, the topic
+  # is topic_prefix + category name, and the message key is the host tag.
+  #[[io.sinks.kafka]]
+  #  addrs = ["localhost:9092"]
+  #  categories = ["logging"]
+  #  topic_prefix = "datakit_"
+  #  encoding = "line-protocol" # or json
+  #  sasl_mechanism = "" # PLAIN/SCRAM-SHA-256/SCRAM-SHA-512
+
+  # Export metric/logging/tracing to OpenTelemetry backend by OTLP/gRPC.
+  #[[io.sinks.otlp]]
+  #  endpoint = "localhost:4317"
+  #  categories = ["metric", "logging", "tracing"]
+  #  insecure = true
+
 [recorder]
   enabled = false
   #path = "/path/to/point-data/dir"
@@ -239,8 +339,20 @@ ulimit = 64000
   # do NOT disable gzip or your get large network payload.
   gzip = true
 
+  # Compression codec(gzip/zstd) and the gzip level(1~9, 0 for default). The
+  # zstd body fallback to gzip on the dataway not supporting zstd.
+  #compression = "gzip"
+  #gzip_level  = 0
+
   max_raw_body_size = 1048576 # max body size(before gizp) in bytes
 
+  # Route categories to part of the urls, the value is the index of the urls,
+  # categories not routed are sent to all urls.
+  #[dataway.routes]
+  #  logging  = [0]
+  #  metric   = [0, 1]
+  #  security = [2]
+
   # Customer tag or field keys that will extract from exist points
   # to build the X-Global-Tags HTTP header value.
   global_customer_keys = []
@@ -261,6 +373,28 @@ ulimit = 64000
     #workers = 4          # flush workers on WAL(default to CPU limited cores)
     #mem_cap = 4          # in-memory queue capacity(default to CPU limited cores)
     #fail_cache_clean_interval = "30s" # duration for clean fail uploaded data
+    #drop_policy = "drop_new"          # drop new(drop_new) or old(drop_old) data if the disk queue is full
+    #max_inflight = 0                  # max concurrent uploading of all categories, sent by category priority if exceeded(0: no limit)
+
+    # overwrite the capacity and drop policy on the category, and replay the
+    # data in order(no mem-queue and fail-cache) after dataway recovered
+    #[dataway.wal.categories.logging]
+    #  max_capacity_gb = 4.0
+    #  drop_policy     = "drop_old"
+    #  ordered         = true
+    #  priority        = 0 # higher sent first under max_inflight(default: keyevent/object/custom_object 2, metric 1, others 0)
+
+    # upload the data to S3-compatible object store(AWS S3/MinIO/...) if the
+    # disk queue is full(only for drop_new policy), replay them with
+    # "datakit tool --replay-overflow"
+    #[dataway.wal.overflow]
+    #  endpoint   = "http://minio:9000"
+    #  region     = "us-east-1"
+    #  bucket     = "datakit-wal"
+    #  prefix     = ""  # default to datakit/<hostname>
+    #  access_key = ""
+    #  secret_key = ""
+    #  timeout    = "30s"
 
 
 ################################################
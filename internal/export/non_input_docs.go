@@ -205,6 +205,30 @@ func envDataway() []*inputs.ENVInfo {
 			DescZh:  "设置上传时的 point 数据编码（可选列表：`v1` 即行协议，`v2` 即 Protobuf）",
 		},
 
+		{
+			ENVName: "ENV_DATAWAY_COMPRESSION",
+			Type:    doc.String,
+			Default: "gzip",
+			Desc:    "Set the compression of the upload body, `gzip/zstd`, see [here](datakit-conf.md#dataway-compression)",
+			DescZh:  "设置上传数据的压缩方式，`gzip/zstd`，参见[这里](datakit-conf.md#dataway-compression)",
+		},
+
+		{
+			ENVName: "ENV_DATAWAY_GZIP_LEVEL",
+			Type:    doc.Int,
+			Example: "`6`",
+			Desc:    "Set the gzip level(1~9) of the upload body",
+			DescZh:  "设置上传数据的 gzip 压缩级别（1~9）",
+		},
+
+		{
+			ENVName: "ENV_DATAWAY_ROUTES",
+			Type:    doc.JSON,
+			Example: "`{\"logging\": [0], \"metric\": [0, 1]}`",
+			Desc:    "Route categories to part of the Dataway URLs by the URL index, see [here](datakit-conf.md#dataway-routes)",
+			DescZh:  "按 Dataway 地址的序号将各个数据分类发送到部分地址，参见[这里](datakit-conf.md#dataway-routes)",
+		},
+
 		{
 			ENVName: "ENV_DATAWAY_TLS_INSECURE",
 			Type:    doc.Boolean,
@@ -262,6 +286,36 @@ func envDataway() []*inputs.ENVInfo {
 			Desc:    "Set WAL fail-cache clean interval, default `30s`[:octicons-tag-24: Version-1.62.0](changelog.md#cl-1.62.0)",
 			DescZh:  "设置 WAL 失败队列的重试间隔，默认 `30s` [:octicons-tag-24: Version-1.62.0](changelog.md#cl-1.62.0)",
 		},
+
+		{
+			ENVName: "ENV_DATAWAY_WAL_DROP_POLICY",
+			Type:    doc.String,
+			Desc:    "Set the drop policy when WAL disk cache is full, `drop_new`(default) or `drop_old`",
+			DescZh:  "设置 WAL 磁盘队列满时的丢弃策略，`drop_new`（默认）或 `drop_old`",
+		},
+
+		{
+			ENVName: "ENV_DATAWAY_WAL_CATEGORIES",
+			Type:    doc.JSON,
+			Example: "`{\"logging\":{\"max_capacity_gb\":4,\"drop_policy\":\"drop_old\",\"ordered\":true}}`",
+			Desc:    "Set WAL capacity, drop policy, ordered replay and upload priority on each category, see [here](datakit-conf.md#dataway-wal)",
+			DescZh:  "按数据类型设置 WAL 的磁盘大小、丢弃策略、是否按序重传以及上传优先级，参见[这里](datakit-conf.md#dataway-wal)",
+		},
+
+		{
+			ENVName: "ENV_DATAWAY_WAL_MAX_INFLIGHT",
+			Type:    doc.Int,
+			Desc:    "Set the max concurrent uploading bodies of all categories, the waiting ones are sent by category priority, see [here](datakit-conf.md#dataway-wal-priority)",
+			DescZh:  "设置所有数据类型同时上传的最大请求数，排队的请求按数据类型优先级发送，参见[这里](datakit-conf.md#dataway-wal-priority)",
+		},
+
+		{
+			ENVName: "ENV_DATAWAY_WAL_OVERFLOW",
+			Type:    doc.JSON,
+			Example: "`{\"endpoint\":\"http://minio:9000\",\"bucket\":\"datakit-wal\",\"access_key\":\"<AK>\",\"secret_key\":\"<SK>\"}`",
+			Desc:    "Set the S3-compatible object store that WAL data uploaded to once the disk queue is full, see [here](datakit-conf.md#dataway-wal-overflow)",
+			DescZh:  "设置 WAL 磁盘队列满时数据转存的 S3 兼容对象存储，参见[这里](datakit-conf.md#dataway-wal-overflow)",
+		},
 	}
 
 	for idx := range infos {
@@ -568,6 +622,30 @@ func envIO() []*inputs.ENVInfo {
 			DescZh:  "设置 compactor worker 数，默认为 CPU 配额核心数 x 2 [:octicons-tag-24: Version-1.5.9](changelog.md#cl-1.5.9)",
 		},
 
+		{
+			ENVName: "ENV_IO_BATCH_MAX_BYTES",
+			Type:    doc.Int,
+			Example: "`1048576`",
+			Desc:    "Flush the cached points once the approximate payload bytes reached, see [here](datakit-conf.md#io-batch)",
+			DescZh:  "缓存数据的近似字节数达到该值时上传，参见[这里](datakit-conf.md#io-batch)",
+		},
+
+		{
+			ENVName: "ENV_IO_BATCH_MAX_LATENCY",
+			Type:    doc.TimeDuration,
+			Example: "`5s`",
+			Desc:    "Flush the cached points once the first cached point waited for the duration, see [here](datakit-conf.md#io-batch)",
+			DescZh:  "首个缓存的数据点等待该时长后上传，参见[这里](datakit-conf.md#io-batch)",
+		},
+
+		{
+			ENVName: "ENV_IO_BATCH_CATEGORIES",
+			Type:    doc.JSON,
+			Example: "`{\"logging\":{\"max_bytes\":4194304}}`",
+			Desc:    "Set batch payload bytes on each category, see [here](datakit-conf.md#io-batch)",
+			DescZh:  "按数据类型设置批量上传的字节数，参见[这里](datakit-conf.md#io-batch)",
+		},
+
 		{
 			ENVName: "ENV_IO_MAX_CACHE_COUNT",
 			Type:    doc.Int,
@@ -576,6 +654,70 @@ func envIO() []*inputs.ENVInfo {
 			DescZh:  "Compact 缓存的点数",
 		},
 
+		{
+			ENVName: "ENV_IO_RATE_LIMIT",
+			Type:    doc.JSON,
+			Example: "`{\"categories\":{\"logging\":{\"points_per_sec\":10000}},\"inputs\":{\"logfwd\":{\"bytes_per_sec\":1048576}}}`",
+			Desc:    "Set feeding rate limits on category and input, see [here](datakit-conf.md#io-rate-limit)",
+			DescZh:  "设置数据类型以及采集器的写入限速，参见[这里](datakit-conf.md#io-rate-limit)",
+		},
+
+		{
+			ENVName: "ENV_IO_TRANSFORMS",
+			Type:    doc.JSON,
+			Example: "`[{\"measurements\":[\"nginx*\"],\"drop_by_tags\":{\"env\":\"test*\"},\"add_tags\":{\"team\":\"infra\"}}]`",
+			Desc:    "Set transform rules on points before uploading, see [here](datakit-conf.md#io-transforms)",
+			DescZh:  "设置数据上传前的变换规则，参见[这里](datakit-conf.md#io-transforms)",
+		},
+
+		{
+			ENVName: "ENV_IO_SCHEMA_CHECK",
+			Type:    doc.JSON,
+			Example: "`{\"enable\":true,\"coerce\":true}`",
+			Desc:    "Check field type conflicts on points before uploading, see [here](datakit-conf.md#io-schema-check)",
+			DescZh:  "数据上传前检查字段类型冲突，参见[这里](datakit-conf.md#io-schema-check)",
+		},
+
+		{
+			ENVName: "ENV_IO_POINT_TRACE",
+			Type:    doc.JSON,
+			Example: "`{\"enable\":true,\"rules\":[{\"measurement\":\"nginx\",\"tags\":{\"host\":\"web-01\"}}]}`",
+			Desc:    "Trace the delivery path of the matched points for debugging, see [here](datakit-conf.md#io-point-trace)",
+			DescZh:  "追踪匹配数据点的发送路径，用于调试，参见[这里](datakit-conf.md#io-point-trace)",
+		},
+
+		{
+			ENVName: "ENV_IO_PANIC_REPORT",
+			Type:    doc.JSON,
+			Example: "`{\"enable\":true,\"category\":\"keyevent\"}`",
+			Desc:    "Upload the recovered panics of Datakit as logging or key events, see [here](datakit-conf.md#io-panic-report)",
+			DescZh:  "将 Datakit 中恢复的 panic 作为日志或事件上传，参见[这里](datakit-conf.md#io-panic-report)",
+		},
+
+		{
+			ENVName: "ENV_IO_SINKS",
+			Type:    doc.JSON,
+			Example: "`{\"kafka\":[{\"addrs\":[\"kafka:9092\"],\"categories\":[\"logging\"]}]}`",
+			Desc:    "Set extra outputs such as [Kafka](datakit-conf.md#io-sink-kafka) or [OTLP](datakit-conf.md#io-sink-otlp) besides Dataway",
+			DescZh:  "设置 Dataway 之外的额外输出，如 [Kafka](datakit-conf.md#io-sink-kafka) 或 [OTLP](datakit-conf.md#io-sink-otlp)",
+		},
+
+		{
+			ENVName: "ENV_IO_DOWNSAMPLE_WINDOW",
+			Type:    doc.TimeDuration,
+			Example: "`10s`",
+			Desc:    "Downsample metric points of all inputs within the window, see [here](datakit-conf.md#io-downsample)",
+			DescZh:  "在该时间窗口内对所有采集器的指标数据降采样，参见[这里](datakit-conf.md#io-downsample)",
+		},
+
+		{
+			ENVName: "ENV_IO_DOWNSAMPLE_METHOD",
+			Type:    doc.String,
+			Default: "last",
+			Desc:    "Downsample method, `last/mean/max`",
+			DescZh:  "降采样方式，`last/mean/max`",
+		},
+
 		{
 			ENVName: "~~ENV_IO_ENABLE_CACHE~~",
 			Type:    doc.Boolean,
@@ -669,6 +811,7 @@ func envOthers() []*inputs.ENVInfo {
 		{ENVName: "ENV_ULIMIT", Type: doc.Int, Desc: "Specify the maximum number of open files for Datakit", DescZh: "指定 Datakit 最大的可打开文件数"},
 		{ENVName: "ENV_PIPELINE_OFFLOAD_RECEIVER", Type: doc.String, Default: "`datakit-http`", Desc: "Set offload receiver", DescZh: "设置 Offload 目标接收器的类型"},
 		{ENVName: "ENV_PIPELINE_OFFLOAD_ADDRESSES", Type: doc.List, Example: "`http://aaa:123,http://1.2.3.4:1234`", Desc: "Set offload addresses", DescZh: "设置 Offload 目标地址"},
+		{ENVName: "ENV_PIPELINE_REMOTE_SOURCE", Type: doc.JSON, Example: "`{\"source\":\"git\",\"git_url\":\"https://github.com/path/to/repo.git\",\"local_dir\":\"/data/pipeline\"}`", Desc: "Sync the remote pipelines from git repo, or override them by the scripts under local dir, see [here](datakit-conf.md#pipeline-remote-source)", DescZh: "从 Git 仓库同步远程 Pipeline，或用本地目录下的脚本覆盖远程 Pipeline，参见[这里](datakit-conf.md#pipeline-remote-source)"},
 		{ENVName: "ENV_PIPELINE_DISABLE_APPEND_RUN_INFO", Type: doc.Boolean, Default: "`false`", Desc: "Disable appending the Pipeline run info", DescZh: "禁用追加 Pipeline 运行信息"},
 		{ENVName: "ENV_CRYPTO_AES_KEY", Type: doc.String, Example: "`0123456789abcdef`", Desc: "The crypto key(len 16)", DescZh: "AES 加解密的 key 长度是 16"},
 		{ENVName: "ENV_CRYPTO_AES_KEY_FILE", Type: doc.String, Example: "`/usr/local/datakit/enc4mysql`", Desc: "File path for storing AES encryption and decryption key", DescZh: "AES 加解密的 key 存放的文件路径"},
@@ -0,0 +1,159 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package goroutine
+
+import (
+	"math"
+	"reflect"
+	"runtime"
+	"sync"
+
+	p8s "github.com/prometheus/client_golang/prometheus"
+)
+
+// funcGroups map the entry PC of the functions passed to Go()/Every() to the
+// group name, so the sampled allocations within the heap profile can be
+// attributed to the groups by their stacks.
+var funcGroups sync.Map
+
+func registerFunc(f any, group string) {
+	pc := reflect.ValueOf(f).Pointer()
+	if v, ok := funcGroups.Load(pc); !ok || v.(string) != group {
+		funcGroups.Store(pc, group)
+	}
+}
+
+type allocStat struct {
+	allocBytes, allocObjects int64
+	inuseBytes               int64
+}
+
+// allocCollector collect the heap allocations of each group from the heap
+// profile(sampled on runtime.MemProfileRate). The allocation is attributed to
+// the group whose function is nearest to the allocation within the stack. The
+// allocations within goroutines not started by groups are not counted.
+type allocCollector struct {
+	mtx    sync.Mutex
+	recs   []runtime.MemProfileRecord
+	frames map[uintptr]uintptr // PC -> entry PC of the function
+
+	allocBytes, allocObjects, inuseBytes *p8s.Desc
+}
+
+func newAllocCollector() *allocCollector {
+	return &allocCollector{
+		frames: map[uintptr]uintptr{},
+
+		allocBytes: p8s.NewDesc("datakit_goroutine_alloc_bytes_total",
+			"Heap allocated bytes of the goroutine group(sampled)", []string{"name"}, nil),
+		allocObjects: p8s.NewDesc("datakit_goroutine_alloc_objects_total",
+			"Heap allocated objects of the goroutine group(sampled)", []string{"name"}, nil),
+		inuseBytes: p8s.NewDesc("datakit_goroutine_heap_inuse_bytes",
+			"Heap in-use bytes allocated by the goroutine group(sampled)", []string{"name"}, nil),
+	}
+}
+
+func (c *allocCollector) Describe(ch chan<- *p8s.Desc) {
+	ch <- c.allocBytes
+	ch <- c.allocObjects
+	ch <- c.inuseBytes
+}
+
+func (c *allocCollector) Collect(ch chan<- p8s.Metric) {
+	for name, s := range c.stats() {
+		ch <- p8s.MustNewConstMetric(c.allocBytes, p8s.CounterValue, float64(s.allocBytes), name)
+		ch <- p8s.MustNewConstMetric(c.allocObjects, p8s.CounterValue, float64(s.allocObjects), name)
+		ch <- p8s.MustNewConstMetric(c.inuseBytes, p8s.GaugeValue, float64(s.inuseBytes), name)
+	}
+}
+
+// profile get the heap profile records(as of the most recently GC).
+func (c *allocCollector) profile() []runtime.MemProfileRecord {
+	n, _ := runtime.MemProfile(nil, true)
+	for {
+		if cap(c.recs) < n {
+			c.recs = make([]runtime.MemProfileRecord, n+50) //nolint:gomnd
+		}
+
+		var ok bool
+		if n, ok = runtime.MemProfile(c.recs[:cap(c.recs)], true); ok {
+			return c.recs[:n]
+		}
+	}
+}
+
+func (c *allocCollector) entry(pc uintptr) uintptr {
+	if e, ok := c.frames[pc]; ok {
+		return e
+	}
+
+	var e uintptr
+	if fn := runtime.FuncForPC(pc - 1); fn != nil { // pc is the return address
+		e = fn.Entry()
+	}
+
+	c.frames[pc] = e
+	return e
+}
+
+func (c *allocCollector) group(stack []uintptr) (string, bool) {
+	for _, pc := range stack { // from the leaf
+		if v, ok := funcGroups.Load(c.entry(pc)); ok {
+			return v.(string), true
+		}
+	}
+
+	return "", false
+}
+
+func (c *allocCollector) stats() map[string]*allocStat {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	res := map[string]*allocStat{}
+	rate := int64(runtime.MemProfileRate)
+
+	for i := range c.profile() {
+		r := &c.recs[i]
+
+		name, ok := c.group(r.Stack())
+		if !ok {
+			continue
+		}
+
+		s, ok := res[name]
+		if !ok {
+			s = &allocStat{}
+			res[name] = s
+		}
+
+		objs, bytes := scaleHeapSample(r.AllocObjects, r.AllocBytes, rate)
+		_, inuse := scaleHeapSample(r.InUseObjects(), r.InUseBytes(), rate)
+
+		s.allocObjects += objs
+		s.allocBytes += bytes
+		s.inuseBytes += inuse
+	}
+
+	return res
+}
+
+// scaleHeapSample scale the sampled allocations to the estimated actual
+// allocations, same as the pprof heap profile.
+func scaleHeapSample(count, size, rate int64) (int64, int64) {
+	if count == 0 || size == 0 {
+		return 0, 0
+	}
+
+	if rate <= 1 {
+		return count, size
+	}
+
+	avgSize := float64(size) / float64(count)
+	scale := 1 / (1 - math.Exp(-avgSize/float64(rate)))
+
+	return int64(float64(count) * scale), int64(float64(size) * scale)
+}
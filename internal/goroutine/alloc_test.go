@@ -0,0 +1,69 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package goroutine
+
+import (
+	"context"
+	"runtime"
+	"testing"
+
+	"github.com/GuanceCloud/cliutils/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var allocSink [][]byte
+
+func TestAllocCollector(t *testing.T) {
+	rate := runtime.MemProfileRate
+	runtime.MemProfileRate = 1
+	t.Cleanup(func() { runtime.MemProfileRate = rate })
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(newAllocCollector())
+
+	allocBytes := func(name string) float64 {
+		runtime.GC() // the heap profile updated on GC
+		mfs, err := reg.Gather()
+		require.NoError(t, err)
+		return metrics.GetMetricOnLabels(mfs, "datakit_goroutine_alloc_bytes_total", name).GetCounter().GetValue()
+	}
+
+	before := allocBytes("test_alloc")
+
+	g := NewGroup(Option{Name: "test_alloc"})
+	g.Go(func(ctx context.Context) error {
+		for i := 0; i < 100; i++ {
+			allocSink = append(allocSink, make([]byte, 1024))
+		}
+		return nil
+	})
+	require.NoError(t, g.Wait())
+
+	assert.GreaterOrEqual(t, allocBytes("test_alloc")-before, 100.0*1024)
+
+	mfs, err := reg.Gather()
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, metrics.GetMetricOnLabels(mfs, "datakit_goroutine_heap_inuse_bytes", "test_alloc").GetGauge().GetValue(), 100.0*1024)
+
+	allocSink = nil
+}
+
+func TestScaleHeapSample(t *testing.T) {
+	n, size := scaleHeapSample(0, 0, 512*1024)
+	assert.Zero(t, n)
+	assert.Zero(t, size)
+
+	n, size = scaleHeapSample(10, 1024, 1)
+	assert.Equal(t, int64(10), n)
+	assert.Equal(t, int64(1024), size)
+
+	// small objects sampled rarely, scaled up
+	n, size = scaleHeapSample(1, 64, 512*1024)
+	assert.Greater(t, n, int64(1000))
+	assert.Greater(t, size, int64(64*1000))
+}
@@ -0,0 +1,126 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package goroutine
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// EveryOption configure the scheduling of Group.Every().
+type EveryOption func(*schedule)
+
+// WithAlign align the ticks to the multiple of the interval(such as 10:00:00,
+// 10:00:10, ... on interval 10s), so the collected points of different hosts
+// have the same time.
+func WithAlign() EveryOption {
+	return func(s *schedule) { s.align = true }
+}
+
+// WithJitter delay each run randomly within [0, max), to avoid the burst
+// requests to the same service from lots of hosts. The tick time passed to
+// the function not affected.
+func WithJitter(max time.Duration) EveryOption {
+	return func(s *schedule) { s.jitter = max }
+}
+
+// WithImmediate run the function once on start, before the first tick.
+func WithImmediate() EveryOption {
+	return func(s *schedule) { s.immediate = true }
+}
+
+type schedule struct {
+	interval  time.Duration
+	align     bool
+	jitter    time.Duration
+	immediate bool
+
+	last time.Time // the last tick
+	rand func(n int64) int64
+}
+
+// next get the next tick after now, the ticks missed(the function run longer
+// than the interval) are coalesced into the next one.
+func (s *schedule) next(now time.Time) (tick time.Time, delay time.Duration, missed int) {
+	switch {
+	case s.last.IsZero() && s.align:
+		tick = now.Truncate(s.interval).Add(s.interval)
+	case s.last.IsZero():
+		tick = now.Add(s.interval)
+	default:
+		tick = s.last.Add(s.interval)
+		if !tick.After(now) {
+			n := int(now.Sub(tick)/s.interval) + 1
+			tick = tick.Add(time.Duration(n) * s.interval)
+			missed = n
+		}
+	}
+
+	s.last = tick
+	delay = tick.Sub(now)
+
+	if s.jitter > 0 {
+		delay += time.Duration(s.rand(int64(s.jitter)))
+	}
+
+	return tick, delay, missed
+}
+
+// Every run f on each tick of the interval within a new goroutine of the
+// group, until the context of the group canceled(see Option.StopOn). The tick
+// passed to f is the scheduled time, which can be used as the point time.
+//
+// If f run longer than the interval, the missed ticks are skipped(and counted
+// in metric datakit_goroutine_missed_ticks_total), the next run is on the
+// first tick after f done.
+func (g *Group) Every(interval time.Duration, f func(ctx context.Context, tick time.Time), opts ...EveryOption) {
+	if interval <= 0 {
+		panic("goroutine: Every interval must great than 0")
+	}
+
+	s := &schedule{
+		interval: interval,
+		rand:     rand.Int63n, //nolint:gosec
+	}
+
+	for _, opt := range opts {
+		if opt != nil {
+			opt(s)
+		}
+	}
+
+	// the allocations are attributed to the group by f, not the loop shared by
+	// all the groups.
+	registerFunc(f, g.name)
+
+	g.spawn(func(ctx context.Context) error {
+		if s.immediate {
+			f(ctx, time.Now())
+		}
+
+		timer := time.NewTimer(0)
+		defer timer.Stop()
+		<-timer.C
+
+		for {
+			tick, delay, missed := s.next(time.Now())
+			if missed > 0 {
+				goroutineMissedTicksVec.WithLabelValues(g.name).Add(float64(missed))
+			}
+
+			timer.Reset(delay)
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-timer.C:
+				g.Progress()
+				f(ctx, tick)
+			}
+		}
+	})
+}
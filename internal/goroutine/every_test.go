@@ -0,0 +1,100 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package goroutine
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedule(t *testing.T) {
+	now := time.Date(2026, 10, 14, 10, 0, 3, 0, time.UTC)
+
+	t.Run("align", func(t *testing.T) {
+		s := &schedule{interval: 10 * time.Second, align: true}
+
+		tick, delay, missed := s.next(now)
+		assert.Equal(t, time.Date(2026, 10, 14, 10, 0, 10, 0, time.UTC), tick)
+		assert.Equal(t, 7*time.Second, delay)
+		assert.Equal(t, 0, missed)
+
+		// f done within interval
+		tick, delay, missed = s.next(tick.Add(time.Second))
+		assert.Equal(t, time.Date(2026, 10, 14, 10, 0, 20, 0, time.UTC), tick)
+		assert.Equal(t, 9*time.Second, delay)
+		assert.Equal(t, 0, missed)
+
+		// f run 25s, the ticks on 10:00:30 and 10:00:40 missed
+		tick, delay, missed = s.next(tick.Add(25 * time.Second))
+		assert.Equal(t, time.Date(2026, 10, 14, 10, 0, 50, 0, time.UTC), tick)
+		assert.Equal(t, 5*time.Second, delay)
+		assert.Equal(t, 2, missed)
+	})
+
+	t.Run("not-align", func(t *testing.T) {
+		s := &schedule{interval: 10 * time.Second}
+
+		tick, delay, _ := s.next(now)
+		assert.Equal(t, now.Add(10*time.Second), tick)
+		assert.Equal(t, 10*time.Second, delay)
+
+		tick, _, _ = s.next(tick.Add(100 * time.Millisecond))
+		assert.Equal(t, now.Add(20*time.Second), tick)
+	})
+
+	t.Run("jitter", func(t *testing.T) {
+		s := &schedule{
+			interval: 10 * time.Second,
+			align:    true,
+			jitter:   time.Second,
+			rand:     func(n int64) int64 { return n / 2 },
+		}
+
+		tick, delay, _ := s.next(now)
+		assert.Equal(t, time.Date(2026, 10, 14, 10, 0, 10, 0, time.UTC), tick) // tick not jittered
+		assert.Equal(t, 7*time.Second+500*time.Millisecond, delay)
+	})
+}
+
+func TestEvery(t *testing.T) {
+	stop := make(chan interface{})
+	g := NewGroup(Option{Name: "test_every", StopOn: []<-chan interface{}{stop}})
+
+	var (
+		mtx   sync.Mutex
+		ticks []time.Time
+	)
+
+	g.Every(10*time.Millisecond, func(ctx context.Context, tick time.Time) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		ticks = append(ticks, tick)
+	}, WithImmediate(), WithAlign(), WithJitter(time.Millisecond))
+
+	require.Eventually(t, func() bool {
+		mtx.Lock()
+		defer mtx.Unlock()
+		return len(ticks) >= 4
+	}, time.Second, time.Millisecond)
+
+	close(stop)
+	assert.NoError(t, g.Wait())
+
+	mtx.Lock()
+	defer mtx.Unlock()
+
+	for i := 1; i < len(ticks); i++ { // ticks[0] is the immediate run
+		assert.Truef(t, ticks[i].Truncate(10*time.Millisecond).Equal(ticks[i]), "tick %s not aligned", ticks[i])
+		assert.True(t, ticks[i].After(ticks[i-1]))
+	}
+
+	assert.Panics(t, func() { g.Every(0, nil) })
+}
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/GuanceCloud/cliutils/logger"
@@ -44,6 +45,27 @@ type Group struct {
 	errOnce    sync.Once
 	workerOnce sync.Once
 	panicTimes int8 // max panic times
+
+	// concurrency limit, see Option.MaxConcurrent.
+	limitMtx      sync.Mutex
+	maxConcurrent int
+	maxQueued     int
+	running       int
+	queue         []func(ctx context.Context) error
+
+	stuckThreshold time.Duration
+
+	// see Option.StopOn, the channels are watched only if any goroutine of
+	// the group running.
+	stopOn    []<-chan interface{}
+	stop      func() // cancel the context
+	watchMtx  sync.Mutex
+	watchDone chan struct{} // close to release the watchers
+
+	// for the stalled Wait() detection, see StalledWaits().
+	creation []uintptr    // stack of NewGroup()
+	pending  atomic.Int64 // goroutines not done
+	progress atomic.Int64 // unix nano of the latest goroutine started or done
 }
 
 // Option provides the setup of a group.
@@ -52,6 +74,22 @@ type Option struct {
 	PanicCb      func([]byte) bool
 	PanicTimes   int8
 	PanicTimeout time.Duration
+
+	// MaxConcurrent limit the running goroutines of the group, the exceeded
+	// ones are queued until running goroutines finished. At most MaxQueued
+	// goroutines are queued, the others are rejected(not run).
+	MaxConcurrent int
+	MaxQueued     int
+
+	// StuckThreshold is the max running duration of the goroutines, the
+	// goroutine running longer is flagged by the watchdog(if started).
+	StuckThreshold time.Duration
+
+	// StopOn cancel the context passed to the goroutines once any of the
+	// channels closed, such as datakit.Exit and the semStop of the input.
+	// The context is only canceled by the channels, not by Wait(), so the
+	// group could be reused after Wait().
+	StopOn []<-chan interface{}
 }
 
 // NewGroup create a custom group.
@@ -67,6 +105,18 @@ func NewGroup(option Option) *Group {
 		panicCb:      option.PanicCb,
 		panicTimes:   option.PanicTimes,
 		panicTimeout: option.PanicTimeout,
+
+		maxConcurrent: option.MaxConcurrent,
+		maxQueued:     option.MaxQueued,
+
+		stuckThreshold: option.StuckThreshold,
+
+		creation: callers(),
+	}
+
+	if len(option.StopOn) > 0 {
+		g.ctx, g.stop = context.WithCancel(context.Background())
+		g.stopOn = option.StopOn
 	}
 
 	if g.panicCb == nil {
@@ -117,6 +167,8 @@ func (g *Group) do(f func(ctx context.Context) error) {
 		start = time.Now()
 	)
 
+	g.progress.Store(start.UnixNano())
+
 	run = func() {
 		defer func() {
 			if r := recover(); r != nil {
@@ -134,6 +186,8 @@ func (g *Group) do(f func(ctx context.Context) error) {
 					isPanicRetry = g.panicCb(buf)
 				}
 
+				reportPanic(g.name, r, buf)
+
 				if isPanicRetry && panicTimes > 0 {
 					panicTimes--
 
@@ -169,10 +223,10 @@ func (g *Group) do(f func(ctx context.Context) error) {
 				})
 			}
 
-			g.wg.Done()
+			g.done()
 		}()
 
-		err = f(ctx)
+		err = g.call(ctx, f)
 	}
 
 	run()
@@ -201,7 +255,15 @@ func (g *Group) GOMAXPROCS(n int) {
 // The first call to return a non-nil error cancels the group; its error will be
 // returned by Wait.
 func (g *Group) Go(f func(ctx context.Context) error) {
+	registerFunc(f, g.name)
+	g.spawn(f)
+}
+
+func (g *Group) spawn(f func(ctx context.Context) error) {
 	g.wg.Add(1)
+	if g.pending.Add(1) == 1 {
+		g.watchStopOn()
+	}
 
 	goroutineCounterVec.WithLabelValues(g.name).Inc()
 
@@ -215,12 +277,113 @@ func (g *Group) Go(f func(ctx context.Context) error) {
 		return
 	}
 
+	if g.maxConcurrent > 0 {
+		g.schedule(f)
+		return
+	}
+
 	go g.do(f)
 }
 
+// schedule run f if running goroutines under the limit, or queue it.
+func (g *Group) schedule(f func(ctx context.Context) error) {
+	g.limitMtx.Lock()
+
+	if g.running < g.maxConcurrent {
+		g.running++
+		g.limitMtx.Unlock()
+
+		go g.runLimited(f)
+		return
+	}
+
+	if len(g.queue) < g.maxQueued {
+		g.queue = append(g.queue, f)
+		g.limitMtx.Unlock()
+
+		goroutineQueuedVec.WithLabelValues(g.name).Inc()
+		return
+	}
+
+	g.limitMtx.Unlock()
+
+	log.Warnf("goroutine group %s reach max concurrent %d and max queued %d, rejected",
+		g.name, g.maxConcurrent, g.maxQueued)
+
+	goroutineRejectedVec.WithLabelValues(g.name).Inc()
+	goroutineCounterVec.WithLabelValues(g.name).Dec()
+	g.done()
+}
+
+func (g *Group) done() {
+	g.Progress()
+	if g.pending.Add(-1) == 0 {
+		g.watchStopOn()
+	}
+	g.wg.Done()
+}
+
+// watchStopOn start the watchers on the StopOn channels if any goroutine
+// pending, or release them if all goroutines done, so the watchers not leaked
+// even if the group never Wait(). It's called on the pending goroutines
+// changed from 0 to 1 or 1 to 0, and checks the latest pending under the lock,
+// because the pending may changed again before the lock.
+func (g *Group) watchStopOn() {
+	if len(g.stopOn) == 0 {
+		return
+	}
+
+	g.watchMtx.Lock()
+	defer g.watchMtx.Unlock()
+
+	pending := g.pending.Load() > 0
+
+	switch {
+	case pending && g.watchDone == nil:
+		done := make(chan struct{})
+		g.watchDone = done
+
+		for _, ch := range g.stopOn {
+			go func(ch <-chan interface{}) {
+				select {
+				case <-ch:
+					g.stop()
+				case <-g.ctx.Done(): // canceled on other channel
+				case <-done: // all goroutines done
+				}
+			}(ch)
+		}
+
+	case !pending && g.watchDone != nil:
+		close(g.watchDone)
+		g.watchDone = nil
+	}
+}
+
+// runLimited run f and then the queued ones, until the queue is empty.
+func (g *Group) runLimited(f func(ctx context.Context) error) {
+	for f != nil {
+		g.do(f)
+
+		g.limitMtx.Lock()
+		if len(g.queue) > 0 {
+			f = g.queue[0]
+			g.queue[0] = nil
+			g.queue = g.queue[1:]
+			goroutineQueuedVec.WithLabelValues(g.name).Dec()
+		} else {
+			f = nil
+			g.running--
+		}
+		g.limitMtx.Unlock()
+	}
+}
+
 // Wait blocks until all function calls from the Go method have returned, then
 // returns the first non-nil error (if any) from them.
 func (g *Group) Wait() error {
+	w := addWaiting(g)
+
 	if g.ch != nil {
 		for _, f := range g.chs {
 			g.ch <- f
@@ -228,6 +391,8 @@ func (g *Group) Wait() error {
 	}
 
 	g.wg.Wait()
+	removeWaiting(w)
+
 	if g.ch != nil {
 		close(g.ch) // let all receiver exit
 	}
@@ -242,3 +407,21 @@ func (g *Group) Wait() error {
 func (g *Group) Name() string {
 	return g.name
 }
+
+// Context get the context passed to the goroutines of the group.
+func (g *Group) Context() context.Context {
+	if g.ctx == nil {
+		return context.Background()
+	}
+
+	// the StopOn channels not watched if no goroutine running.
+	for _, ch := range g.stopOn {
+		select {
+		case <-ch:
+			g.stop()
+		default:
+		}
+	}
+
+	return g.ctx
+}
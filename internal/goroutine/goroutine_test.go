@@ -8,6 +8,9 @@ package goroutine
 import (
 	"context"
 	"errors"
+	"runtime/pprof"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -114,6 +117,254 @@ func TestNormal(t *testing.T) {
 	})
 }
 
+func TestPanicReporter(t *testing.T) {
+	var (
+		groups []string
+		values []any
+		stacks [][]byte
+	)
+
+	SetPanicReporter(func(group string, r any, stack []byte) {
+		groups = append(groups, group)
+		values = append(values, r)
+		stacks = append(stacks, stack)
+	})
+	t.Cleanup(func() { SetPanicReporter(nil) })
+
+	g := NewGroup(Option{Name: "inputs_test", PanicTimes: 2})
+	g.Go(func(ctx context.Context) error {
+		panic("panic error")
+	})
+
+	assert.Error(t, g.Wait())
+	assert.Equal(t, []string{"inputs_test", "inputs_test"}, groups) // retried once
+	assert.Equal(t, "panic error", values[0])
+	assert.Contains(t, string(stacks[0]), "goroutine")
+
+	SetPanicReporter(nil)
+	g = NewGroup(Option{Name: "inputs_test"})
+	g.Go(func(ctx context.Context) error {
+		panic("panic error")
+	})
+	assert.Error(t, g.Wait())
+	assert.Len(t, groups, 2)
+}
+
+func TestMaxConcurrent(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(goroutineQueuedVec, goroutineRejectedVec)
+
+	t.Run("queued", func(t *testing.T) {
+		g := NewGroup(Option{Name: "test_queued", MaxConcurrent: 2, MaxQueued: 10})
+
+		var (
+			mtx           sync.Mutex
+			running, peak int
+			done          int
+		)
+
+		for i := 0; i < 10; i++ {
+			g.Go(func(ctx context.Context) error {
+				mtx.Lock()
+				running++
+				if running > peak {
+					peak = running
+				}
+				mtx.Unlock()
+
+				time.Sleep(10 * time.Millisecond)
+
+				mtx.Lock()
+				running--
+				done++
+				mtx.Unlock()
+				return nil
+			})
+		}
+
+		assert.NoError(t, g.Wait())
+		assert.Equal(t, 10, done)
+		assert.Equal(t, 2, peak)
+
+		// the runner release the slot after the last goroutine done.
+		require.Eventually(t, func() bool {
+			g.limitMtx.Lock()
+			defer g.limitMtx.Unlock()
+			return g.running == 0 && len(g.queue) == 0
+		}, time.Second, time.Millisecond)
+
+		mfs, err := reg.Gather()
+		require.NoError(t, err)
+		assert.Equal(t, 0.0, metrics.GetMetricOnLabels(mfs, "datakit_goroutine_queued", "test_queued").GetGauge().GetValue())
+		assert.Nil(t, metrics.GetMetricOnLabels(mfs, "datakit_goroutine_rejected_total", "test_queued"))
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		rejected := func() float64 {
+			mfs, err := reg.Gather()
+			require.NoError(t, err)
+			return metrics.GetMetricOnLabels(mfs, "datakit_goroutine_rejected_total", "test_rejected").GetCounter().GetValue()
+		}
+
+		before := rejected()
+		g := NewGroup(Option{Name: "test_rejected", MaxConcurrent: 1, MaxQueued: 1})
+
+		var (
+			block = make(chan struct{})
+			done  int32
+		)
+
+		for i := 0; i < 4; i++ {
+			g.Go(func(ctx context.Context) error {
+				<-block
+				atomic.AddInt32(&done, 1)
+				return nil
+			})
+		}
+
+		close(block)
+		assert.NoError(t, g.Wait())
+		assert.Equal(t, int32(2), atomic.LoadInt32(&done))
+		assert.Equal(t, 2.0, rejected()-before)
+	})
+
+	t.Run("panic-within-limit", func(t *testing.T) {
+		g := NewGroup(Option{
+			Name:          "test_panic",
+			MaxConcurrent: 1,
+			MaxQueued:     2,
+			PanicCb:       func([]byte) bool { return false },
+		})
+
+		ok := false
+		g.Go(func(ctx context.Context) error { panic("panic error") })
+		g.Go(func(ctx context.Context) error { ok = true; return nil })
+
+		assert.Error(t, g.Wait())
+		assert.True(t, ok)
+	})
+}
+
+func TestStopOn(t *testing.T) {
+	t.Run("stop", func(t *testing.T) {
+		exit, stop := make(chan interface{}), make(chan interface{})
+		g := NewGroup(Option{Name: "test_stop", StopOn: []<-chan interface{}{exit, stop}})
+
+		for i := 0; i < 3; i++ {
+			g.Go(func(ctx context.Context) error {
+				<-ctx.Done()
+				return nil
+			})
+		}
+
+		close(stop)
+		assert.NoError(t, g.Wait())
+		assert.Error(t, g.Context().Err())
+	})
+
+	t.Run("error-not-cancel", func(t *testing.T) {
+		exit := make(chan interface{})
+		defer close(exit)
+
+		g := NewGroup(Option{Name: "test_stop", StopOn: []<-chan interface{}{exit}})
+
+		g.Go(func(ctx context.Context) error {
+			return errors.New("err")
+		})
+
+		var ctxErr error
+		g.Go(func(ctx context.Context) error {
+			time.Sleep(10 * time.Millisecond)
+			ctxErr = ctx.Err()
+			return nil
+		})
+
+		assert.EqualError(t, g.Wait(), "err")
+		assert.NoError(t, ctxErr) // not canceled by the error of the other goroutine
+
+		// not canceled by Wait(), the group reused
+		assert.NoError(t, g.Context().Err())
+
+		ctxErr = errors.New("not run")
+		g.Go(func(ctx context.Context) error {
+			ctxErr = ctx.Err()
+			return nil
+		})
+		assert.EqualError(t, g.Wait(), "err")
+		assert.NoError(t, ctxErr)
+	})
+
+	t.Run("no-wait", func(t *testing.T) {
+		exit, release := make(chan interface{}), make(chan struct{})
+		g := NewGroup(Option{Name: "test_stop", StopOn: []<-chan interface{}{exit}})
+
+		watching := func() bool {
+			g.watchMtx.Lock()
+			defer g.watchMtx.Unlock()
+			return g.watchDone != nil
+		}
+
+		g.Go(func(ctx context.Context) error {
+			<-release
+			return nil
+		})
+		assert.True(t, watching())
+
+		// watchers released on all goroutines done, without Wait()
+		close(release)
+		require.Eventually(t, func() bool { return !watching() }, time.Second, time.Millisecond)
+
+		close(exit)
+		assert.Error(t, g.Context().Err())
+
+		var ctxErr error
+		g.Go(func(ctx context.Context) error {
+			ctxErr = ctx.Err()
+			return nil
+		})
+		assert.NoError(t, g.Wait())
+		assert.Error(t, ctxErr)
+	})
+
+	t.Run("no-stop", func(t *testing.T) {
+		g := NewGroup(Option{Name: "test_stop"})
+		assert.NoError(t, g.Context().Err())
+		g.Go(TaskOk)
+		assert.NoError(t, g.Wait())
+	})
+}
+
+func TestPProfLabels(t *testing.T) {
+	get := func(g *Group) map[string]string {
+		labels := map[string]string{}
+		g.Go(func(ctx context.Context) error {
+			pprof.ForLabels(ctx, func(k, v string) bool {
+				labels[k] = v
+				return true
+			})
+			return nil
+		})
+		require.NoError(t, g.Wait())
+		return labels
+	}
+
+	assert.Equal(t, map[string]string{"group": "inputs_mysql", "input": "mysql"},
+		get(NewGroup(Option{Name: GetInputName("mysql")})))
+
+	assert.Equal(t, map[string]string{"group": "io_compactor"},
+		get(NewGroup(Option{Name: "io_compactor"})))
+
+	// the labels are inherited by the context within the goroutine.
+	g := NewGroup(Option{Name: "inputs_redis"})
+	g.Go(func(ctx context.Context) error {
+		v, ok := pprof.Label(ctx, "input")
+		assert.True(t, ok)
+		assert.Equal(t, "redis", v)
+		return nil
+	})
+	assert.NoError(t, g.Wait())
+}
+
 func TestGOMAXPROCS(t *testing.T) {
 	sleep1s := func(ctx context.Context) error {
 		time.Sleep(time.Second)
@@ -0,0 +1,46 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package goroutine
+
+import (
+	"context"
+	"runtime/pprof"
+	"strings"
+)
+
+const (
+	labelGroup = "group"
+	labelInput = "input"
+)
+
+// pprofLabels get the pprof labels of the group, the input label is added if
+// the group belongs to an input(named by GetInputName()).
+func (g *Group) pprofLabels() []string {
+	labels := []string{labelGroup, g.name}
+
+	if prefix := GetInputName(""); strings.HasPrefix(g.name, prefix) && len(g.name) > len(prefix) {
+		labels = append(labels, labelInput, g.name[len(prefix):])
+	}
+
+	return labels
+}
+
+// call run f with the pprof labels of the group, so the CPU/heap profiles
+// collected from datakit attribute the cost to the groups and inputs. The
+// labels are inherited by the goroutines created within f, and bounded by
+// the group names(no per-goroutine value).
+func (g *Group) call(ctx context.Context, f func(ctx context.Context) error) error {
+	if done := g.watch(); done != nil {
+		defer done()
+	}
+
+	var err error
+	pprof.Do(ctx, pprof.Labels(g.pprofLabels()...), func(ctx context.Context) {
+		err = f(ctx)
+	})
+
+	return err
+}
@@ -16,9 +16,14 @@ var (
 
 	goroutineStoppedVec,
 	goroutineRecoverVec,
-	goroutineCrashedVec *p8s.CounterVec
+	goroutineCrashedVec,
+	goroutineRejectedVec,
+	goroutineStuckTotalVec,
+	goroutineMissedTicksVec *p8s.CounterVec
 
-	goroutineCounterVec *p8s.GaugeVec
+	goroutineCounterVec,
+	goroutineQueuedVec,
+	goroutineStuckVec *p8s.GaugeVec
 )
 
 func metricsSetup() {
@@ -70,6 +75,66 @@ func metricsSetup() {
 		},
 	)
 
+	goroutineQueuedVec = p8s.NewGaugeVec(
+		p8s.GaugeOpts{
+			Namespace: "datakit",
+			Subsystem: "goroutine",
+			Name:      "queued",
+			Help:      "Queued goroutines count that wait for the concurrency limit",
+		},
+		[]string{
+			"name",
+		},
+	)
+
+	goroutineRejectedVec = p8s.NewCounterVec(
+		p8s.CounterOpts{
+			Namespace: "datakit",
+			Subsystem: "goroutine",
+			Name:      "rejected_total",
+			Help:      "Rejected goroutines count on the concurrency limit and the queue full",
+		},
+		[]string{
+			"name",
+		},
+	)
+
+	goroutineStuckVec = p8s.NewGaugeVec(
+		p8s.GaugeOpts{
+			Namespace: "datakit",
+			Subsystem: "goroutine",
+			Name:      "stuck",
+			Help:      "Running goroutines count that exceeded the stuck threshold",
+		},
+		[]string{
+			"name",
+		},
+	)
+
+	goroutineStuckTotalVec = p8s.NewCounterVec(
+		p8s.CounterOpts{
+			Namespace: "datakit",
+			Subsystem: "goroutine",
+			Name:      "stuck_total",
+			Help:      "Goroutines count that flagged as stuck by the watchdog",
+		},
+		[]string{
+			"name",
+		},
+	)
+
+	goroutineMissedTicksVec = p8s.NewCounterVec(
+		p8s.CounterOpts{
+			Namespace: "datakit",
+			Subsystem: "goroutine",
+			Name:      "missed_ticks_total",
+			Help:      "Missed ticks count of the scheduled tasks that run longer than the interval",
+		},
+		[]string{
+			"name",
+		},
+	)
+
 	goroutineGroups = p8s.NewGauge(
 		p8s.GaugeOpts{
 			Namespace: "datakit",
@@ -104,6 +169,13 @@ func metricsSetup() {
 		goroutineStoppedVec,
 		goroutineRecoverVec,
 		goroutineCrashedVec,
+		goroutineQueuedVec,
+		goroutineRejectedVec,
+		goroutineStuckVec,
+		goroutineStuckTotalVec,
+		goroutineMissedTicksVec,
+		newAllocCollector(),
+		newWaitCollector(),
 	)
 }
 
@@ -0,0 +1,28 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package goroutine
+
+import (
+	"sync/atomic"
+)
+
+// PanicReporter is called on each panic recovered within the groups, r is
+// the recovered value and stack is the (truncated) stack of the goroutine.
+type PanicReporter func(group string, r any, stack []byte)
+
+var panicReporter atomic.Value
+
+// SetPanicReporter set the reporter on the recovered panics, such as feed
+// them to the workspace. Set nil to disable the reporting.
+func SetPanicReporter(fn PanicReporter) {
+	panicReporter.Store(fn)
+}
+
+func reportPanic(group string, r any, stack []byte) {
+	if fn, ok := panicReporter.Load().(PanicReporter); ok && fn != nil {
+		fn(group, r, stack)
+	}
+}
@@ -0,0 +1,190 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package goroutine
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	p8s "github.com/prometheus/client_golang/prometheus"
+)
+
+const maxCreationFrames = 8
+
+var (
+	waitThreshold  atomic.Int64                             // see WatchdogConf.WaitThreshold
+	waitThresholds atomic.Pointer[map[string]time.Duration] // see WatchdogConf.WaitThresholds
+
+	waitingMtx sync.Mutex
+	waitings   = map[*waiting]struct{}{}
+)
+
+// waiting is a blocking Wait() of the group, the group may be waited within
+// multiple goroutines.
+type waiting struct {
+	g     *Group
+	since time.Time
+}
+
+func addWaiting(g *Group) *waiting {
+	w := &waiting{g: g, since: time.Now()}
+
+	waitingMtx.Lock()
+	defer waitingMtx.Unlock()
+	waitings[w] = struct{}{}
+
+	return w
+}
+
+func removeWaiting(w *waiting) {
+	waitingMtx.Lock()
+	defer waitingMtx.Unlock()
+	delete(waitings, w)
+}
+
+// Progress mark the goroutines of the group making progress, the
+// long-running goroutines(such as the loop on the collect interval) should call
+// it, or the blocking Wait() on them are reported as stalled.
+func (g *Group) Progress() {
+	g.progress.Store(time.Now().UnixNano())
+}
+
+// callers get the stack of the caller of NewGroup().
+func callers() []uintptr {
+	pcs := make([]uintptr, maxCreationFrames)
+	return pcs[:runtime.Callers(3, pcs)] // skip runtime.Callers, callers and NewGroup
+}
+
+// StalledWait is a Wait() of the group blocked longer than the threshold,
+// while none of the goroutines of the group started or done within the
+// threshold, it's likely the goroutines deadlocked or starved.
+type StalledWait struct {
+	Group        string    `json:"group"`
+	WaitSince    time.Time `json:"wait_since"`
+	LastProgress time.Time `json:"last_progress"`
+	Pending      int64     `json:"pending"` // goroutines not done
+	CreatedAt    []string  `json:"created_at"`
+
+	creation []uintptr
+}
+
+// getWaitThreshold get the threshold of the group, 0 if the detection disabled
+// on the group.
+func getWaitThreshold(group string) time.Duration {
+	if m := waitThresholds.Load(); m != nil {
+		if x, ok := (*m)[group]; ok {
+			return x
+		}
+	}
+
+	return time.Duration(waitThreshold.Load())
+}
+
+// StalledWaits list the stalled Wait() of all groups, the ones blocked longest
+// first.
+func StalledWaits() []*StalledWait {
+	return stalledWaits(time.Now(), getWaitThreshold)
+}
+
+func stalledWaits(now time.Time, thresholdOf func(group string) time.Duration) []*StalledWait {
+	var res []*StalledWait
+
+	waitingMtx.Lock()
+	for w := range waitings {
+		threshold := thresholdOf(w.g.name)
+		if threshold <= 0 {
+			continue
+		}
+
+		last := w.since
+		if p := w.g.progress.Load(); p > last.UnixNano() {
+			last = time.Unix(0, p)
+		}
+
+		if now.Sub(last) <= threshold {
+			continue
+		}
+
+		x := &StalledWait{
+			Group:     w.g.name,
+			WaitSince: w.since,
+			Pending:   w.g.pending.Load(),
+			creation:  w.g.creation,
+		}
+
+		if p := w.g.progress.Load(); p > 0 {
+			x.LastProgress = time.Unix(0, p)
+		}
+
+		res = append(res, x)
+	}
+	waitingMtx.Unlock()
+
+	sort.Slice(res, func(i, j int) bool { return res[i].WaitSince.Before(res[j].WaitSince) })
+
+	for _, x := range res {
+		x.CreatedAt = creationSites(x.creation)
+	}
+
+	return res
+}
+
+// creationSites resolve the stack of NewGroup() to `func file:line`.
+func creationSites(pcs []uintptr) []string {
+	if len(pcs) == 0 {
+		return nil
+	}
+
+	var (
+		res    []string
+		frames = runtime.CallersFrames(pcs)
+	)
+
+	for {
+		f, more := frames.Next()
+		if !strings.HasPrefix(f.Function, "runtime.") {
+			res = append(res, fmt.Sprintf("%s %s:%d", f.Function, f.File, f.Line))
+		}
+
+		if !more {
+			break
+		}
+	}
+
+	return res
+}
+
+// waitCollector export the count of the stalled Wait() on each group.
+type waitCollector struct {
+	stalled *p8s.Desc
+}
+
+func newWaitCollector() *waitCollector {
+	return &waitCollector{
+		stalled: p8s.NewDesc("datakit_goroutine_wait_stalled",
+			"Blocking Wait() count of the goroutine group that no progress within the threshold", []string{"name"}, nil),
+	}
+}
+
+func (c *waitCollector) Describe(ch chan<- *p8s.Desc) {
+	ch <- c.stalled
+}
+
+func (c *waitCollector) Collect(ch chan<- p8s.Metric) {
+	n := map[string]int{}
+	for _, x := range StalledWaits() {
+		n[x.Group]++
+	}
+
+	for name, v := range n {
+		ch <- p8s.MustNewConstMetric(c.stalled, p8s.GaugeValue, float64(v), name)
+	}
+}
@@ -0,0 +1,104 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package goroutine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stalledOf(now time.Time, group string) (res []*StalledWait) {
+	for _, x := range stalledWaits(now, func(string) time.Duration { return time.Minute }) {
+		if x.Group == group {
+			res = append(res, x)
+		}
+	}
+	return res
+}
+
+func TestStalledWait(t *testing.T) {
+	var (
+		block = make(chan struct{})
+		g     = NewGroup(Option{Name: "test_stalled"})
+		waitc = make(chan error)
+	)
+
+	g.Go(func(ctx context.Context) error {
+		<-block
+		return nil
+	})
+
+	go func() { waitc <- g.Wait() }()
+
+	require.Eventually(t, func() bool {
+		waitingMtx.Lock()
+		defer waitingMtx.Unlock()
+		for w := range waitings {
+			if w.g == g {
+				return true
+			}
+		}
+		return false
+	}, time.Second, time.Millisecond)
+
+	assert.Empty(t, stalledOf(time.Now(), "test_stalled"))
+
+	stalled := stalledOf(time.Now().Add(2*time.Minute), "test_stalled")
+	require.Len(t, stalled, 1)
+	assert.Equal(t, int64(1), stalled[0].Pending)
+	assert.False(t, stalled[0].LastProgress.IsZero())
+	require.NotEmpty(t, stalled[0].CreatedAt)
+	assert.Contains(t, stalled[0].CreatedAt[0], "TestStalledWait")
+
+	// progress reported
+	time.Sleep(time.Millisecond)
+	p := time.Now()
+	g.Progress()
+	assert.Empty(t, stalledOf(p.Add(30*time.Second), "test_stalled"))
+	assert.Len(t, stalledOf(p.Add(2*time.Minute), "test_stalled"), 1)
+
+	// metric
+	waitThreshold.Store(int64(time.Nanosecond))
+	t.Cleanup(func() { waitThreshold.Store(0) })
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(newWaitCollector())
+	time.Sleep(time.Millisecond)
+
+	mfs, err := reg.Gather()
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, metrics.GetMetricOnLabels(mfs, "datakit_goroutine_wait_stalled", "test_stalled").GetGauge().GetValue())
+
+	ofGroup := func() (res []*StalledWait) {
+		for _, x := range StalledWaits() {
+			if x.Group == "test_stalled" {
+				res = append(res, x)
+			}
+		}
+		return res
+	}
+
+	// threshold of the group
+	t.Cleanup(func() { waitThresholds.Store(nil) })
+	waitThresholds.Store(&map[string]time.Duration{"test_stalled": 0})
+	assert.Empty(t, ofGroup())
+
+	waitThreshold.Store(0) // disabled by default
+	assert.Empty(t, ofGroup())
+
+	waitThresholds.Store(&map[string]time.Duration{"test_stalled": time.Nanosecond})
+	assert.Len(t, ofGroup(), 1)
+
+	close(block)
+	require.NoError(t, <-waitc)
+	assert.Empty(t, stalledOf(time.Now().Add(2*time.Minute), "test_stalled"))
+}
@@ -0,0 +1,249 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package goroutine
+
+import (
+	"bytes"
+	"runtime"
+	"runtime/pprof"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const watchdogInterval = 10 * time.Second
+
+// WatchdogConf configure the watchdog on the long-running goroutines.
+type WatchdogConf struct {
+	Enable bool `toml:"enable"`
+
+	// DumpStack log the stack of the goroutine once it's flagged.
+	DumpStack bool `toml:"dump_stack"`
+
+	// Thresholds is the max running duration on group name, it overwrite the
+	// Option.StuckThreshold of the group.
+	Thresholds map[string]time.Duration `toml:"thresholds"`
+
+	// WaitThreshold is the duration the Wait() blocked without any progress
+	// of the goroutines, before the group reported as stalled. The detection
+	// is disabled if not set, it works even if the watchdog not enabled.
+	WaitThreshold time.Duration `toml:"wait_threshold"`
+
+	// WaitThresholds overwrite the WaitThreshold on group name, 0 disable the
+	// detection on the group.
+	WaitThresholds map[string]time.Duration `toml:"wait_thresholds"`
+}
+
+type watched struct {
+	id        int64
+	gid       int64 // runtime ID of the goroutine
+	group     string
+	start     time.Time
+	threshold time.Duration
+	stuck     bool
+}
+
+// watchdog flag the goroutines of the group running longer than the
+// threshold. The runtime IDs of the watched goroutines are recorded, so we
+// can find their stacks within the goroutine profile.
+type watchdog struct {
+	mtx        sync.Mutex
+	seq        int64
+	running    map[int64]*watched
+	dumpStack  bool
+	thresholds map[string]time.Duration
+}
+
+var defWatchdog atomic.Pointer[watchdog]
+
+func newWatchdog(c *WatchdogConf) *watchdog {
+	return &watchdog{
+		running:    map[int64]*watched{},
+		dumpStack:  c.DumpStack,
+		thresholds: c.Thresholds,
+	}
+}
+
+// StartWatchdog start the watchdog until exit closed.
+func StartWatchdog(c *WatchdogConf, exit <-chan interface{}) {
+	if c != nil {
+		waitThreshold.Store(int64(c.WaitThreshold))
+		if len(c.WaitThresholds) > 0 {
+			waitThresholds.Store(&c.WaitThresholds)
+		}
+	}
+
+	if c == nil || !c.Enable {
+		return
+	}
+
+	w := newWatchdog(c)
+	defWatchdog.Store(w)
+
+	log.Infof("goroutine watchdog started, thresholds: %+#v", c.Thresholds)
+
+	go func() {
+		tick := time.NewTicker(watchdogInterval)
+		defer tick.Stop()
+
+		for {
+			select {
+			case <-tick.C:
+				w.check(time.Now())
+			case <-exit:
+				defWatchdog.Store(nil)
+				log.Info("goroutine watchdog exit")
+				return
+			}
+		}
+	}()
+}
+
+func (w *watchdog) threshold(g *Group) time.Duration {
+	if x, ok := w.thresholds[g.name]; ok {
+		return x
+	}
+
+	return g.stuckThreshold
+}
+
+func (w *watchdog) add(group string, threshold time.Duration) *watched {
+	gid := curGoroutineID()
+
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	w.seq++
+	x := &watched{
+		id:        w.seq,
+		gid:       gid,
+		group:     group,
+		start:     time.Now(),
+		threshold: threshold,
+	}
+	w.running[x.id] = x
+
+	return x
+}
+
+func (w *watchdog) remove(x *watched) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	delete(w.running, x.id)
+	if x.stuck {
+		goroutineStuckVec.WithLabelValues(x.group).Dec()
+	}
+}
+
+// check flag the goroutines exceeded the threshold, each goroutine flagged
+// only once.
+func (w *watchdog) check(now time.Time) []*watched {
+	var stuck []*watched
+
+	w.mtx.Lock()
+	for _, x := range w.running {
+		if x.stuck || now.Sub(x.start) <= x.threshold {
+			continue
+		}
+
+		x.stuck = true
+		goroutineStuckVec.WithLabelValues(x.group).Inc()
+		goroutineStuckTotalVec.WithLabelValues(x.group).Inc()
+
+		// copy it, the running goroutine may removed after unlock.
+		c := *x
+		stuck = append(stuck, &c)
+	}
+	w.mtx.Unlock()
+
+	if len(stuck) == 0 {
+		return nil
+	}
+
+	var stacks map[int64][]byte
+	if w.dumpStack {
+		stacks = goroutineStacks()
+	}
+
+	for _, x := range stuck {
+		if stack, ok := stacks[x.gid]; ok {
+			log.Warnf("goroutine %d of group %s running for %s(threshold %s), stack:\n%s",
+				x.gid, x.group, now.Sub(x.start), x.threshold, string(stack))
+		} else {
+			log.Warnf("goroutine %d of group %s running for %s(threshold %s)",
+				x.gid, x.group, now.Sub(x.start), x.threshold)
+		}
+	}
+
+	return stuck
+}
+
+// goroutineStacks get the stacks of all goroutines on the runtime ID from the
+// goroutine profile, the stacks within the profile(debug=2) are like:
+//
+//	goroutine 12 [chan receive]:
+//	os/exec.(*Cmd).Wait(0xc000120000)
+//		/usr/local/go/src/os/exec/exec.go:897 +0x4e
+//	...
+func goroutineStacks() map[int64][]byte {
+	var buf bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&buf, 2); err != nil {
+		log.Warnf("pprof.WriteTo: %s, ignored", err)
+		return nil
+	}
+
+	res := map[int64][]byte{}
+	for _, rec := range bytes.Split(buf.Bytes(), []byte("\n\n")) {
+		if id := parseGoroutineID(rec); id > 0 {
+			res[id] = rec
+		}
+	}
+
+	return res
+}
+
+// curGoroutineID get the runtime ID of the current goroutine from its stack.
+func curGoroutineID() int64 {
+	buf := make([]byte, 64)
+	return parseGoroutineID(buf[:runtime.Stack(buf, false)])
+}
+
+// parseGoroutineID get the ID from the stack header like `goroutine 12 [running]:`,
+// 0 returned if not found.
+func parseGoroutineID(stack []byte) int64 {
+	prefix := []byte("goroutine ")
+	if !bytes.HasPrefix(stack, prefix) {
+		return 0
+	}
+
+	stack = stack[len(prefix):]
+	if i := bytes.IndexByte(stack, ' '); i > 0 {
+		if id, err := strconv.ParseInt(string(stack[:i]), 10, 64); err == nil {
+			return id
+		}
+	}
+
+	return 0
+}
+
+// watch add the goroutine to the watchdog if the group watched, the returned
+// done should be called after the goroutine exit.
+func (g *Group) watch() (done func()) {
+	w := defWatchdog.Load()
+	if w == nil {
+		return nil
+	}
+
+	threshold := w.threshold(g)
+	if threshold <= 0 {
+		return nil
+	}
+
+	x := w.add(g.name, threshold)
+	return func() { w.remove(x) }
+}
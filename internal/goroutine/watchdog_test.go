@@ -0,0 +1,121 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package goroutine
+
+import (
+	"context"
+	"runtime/pprof"
+	"testing"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchdog(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(goroutineStuckVec, goroutineStuckTotalVec)
+
+	stuckTotal := func() float64 {
+		mfs, err := reg.Gather()
+		require.NoError(t, err)
+		return metrics.GetMetricOnLabels(mfs, "datakit_goroutine_stuck_total", "test_stuck").GetCounter().GetValue()
+	}
+	before := stuckTotal()
+
+	w := newWatchdog(&WatchdogConf{
+		DumpStack:  true,
+		Thresholds: map[string]time.Duration{"test_override": time.Hour},
+	})
+	defWatchdog.Store(w)
+	t.Cleanup(func() { defWatchdog.Store(nil) })
+
+	var (
+		block   = make(chan struct{})
+		started = make(chan struct{}, 3)
+	)
+
+	blocking := func(ctx context.Context) error {
+		started <- struct{}{}
+		<-block
+		return nil
+	}
+
+	g1 := NewGroup(Option{Name: "test_stuck", StuckThreshold: time.Millisecond})
+	g2 := NewGroup(Option{Name: "test_override", StuckThreshold: time.Millisecond})
+	g3 := NewGroup(Option{Name: "test_unwatched"})
+
+	g1.Go(blocking)
+	g2.Go(blocking)
+	g3.Go(blocking)
+
+	for i := 0; i < 3; i++ {
+		<-started
+	}
+
+	w.mtx.Lock()
+	assert.Len(t, w.running, 2) // g3 not watched
+	w.mtx.Unlock()
+
+	stuck := w.check(time.Now().Add(time.Second))
+	require.Len(t, stuck, 1)
+	assert.Equal(t, "test_stuck", stuck[0].group)
+
+	// flagged only once
+	assert.Len(t, w.check(time.Now().Add(time.Second)), 0)
+
+	stacks := goroutineStacks()
+	require.Contains(t, stacks, stuck[0].gid)
+	assert.Contains(t, string(stacks[stuck[0].gid]), "TestWatchdog")
+
+	mfs, err := reg.Gather()
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, metrics.GetMetricOnLabels(mfs, "datakit_goroutine_stuck", "test_stuck").GetGauge().GetValue())
+	assert.Equal(t, 1.0, stuckTotal()-before)
+
+	close(block)
+	require.NoError(t, g1.Wait())
+	require.NoError(t, g2.Wait())
+	require.NoError(t, g3.Wait())
+
+	w.mtx.Lock()
+	assert.Empty(t, w.running)
+	w.mtx.Unlock()
+
+	mfs, err = reg.Gather()
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, metrics.GetMetricOnLabels(mfs, "datakit_goroutine_stuck", "test_stuck").GetGauge().GetValue())
+}
+
+func TestWatchdogLabels(t *testing.T) {
+	defWatchdog.Store(newWatchdog(&WatchdogConf{}))
+	t.Cleanup(func() { defWatchdog.Store(nil) })
+
+	var (
+		labels = map[string]string{}
+		gid    int64
+	)
+
+	g := NewGroup(Option{Name: "test_labels", StuckThreshold: time.Hour})
+	g.Go(func(ctx context.Context) error {
+		pprof.ForLabels(ctx, func(k, v string) bool {
+			labels[k] = v
+			return true
+		})
+		gid = curGoroutineID()
+		return nil
+	})
+	require.NoError(t, g.Wait())
+
+	// no per-goroutine label on the watched goroutines
+	assert.Equal(t, map[string]string{"group": "test_labels"}, labels)
+	assert.Greater(t, gid, int64(0))
+
+	assert.Equal(t, int64(12), parseGoroutineID([]byte("goroutine 12 [chan receive]:\nmain.main()")))
+	assert.Zero(t, parseGoroutineID([]byte("# labels: {}")))
+}
@@ -0,0 +1,18 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package httpapi
+
+import (
+	"net/http"
+
+	dkio "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io"
+)
+
+// apiFeedStats list the feed statistics of all inputs, or only the input
+// specified by query parameter `input`.
+func apiFeedStats(_ http.ResponseWriter, req *http.Request, _ ...interface{}) (interface{}, error) {
+	return dkio.FeedStats(req.URL.Query().Get("input")), nil
+}
@@ -0,0 +1,18 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package httpapi
+
+import (
+	"net/http"
+
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/goroutine"
+)
+
+// apiGoroutineStalled list the goroutine groups whose Wait() blocked without
+// progress, the likely deadlocked or starved ones.
+func apiGoroutineStalled(_ http.ResponseWriter, _ *http.Request, _ ...interface{}) (interface{}, error) {
+	return goroutine.StalledWaits(), nil
+}
@@ -42,6 +42,9 @@ const (
 
 	// 由于日志采集器最大允许 32 MB，此处限制为 32 MB, 行协议暂设与日志大小相同.
 	DataByteSizeLimit = 32 * 1024 * 1024
+
+	// script name of the plain text script if script_name not set.
+	defaultDebugScriptName = "debug"
 )
 
 // request body.
@@ -50,6 +53,10 @@ type pipelineDebugRequest struct {
 	ScriptName string                       `json:"script_name"`
 	Category   string                       `json:"category"`
 
+	// Script is the plain text(not base64 encoded) script to debug, the
+	// pipeline ignored if set.
+	Script string `json:"script"`
+
 	Data      []string `json:"data"`
 	DataType  string   `json:"data_type"`
 	Multiline string   `json:"multiline"`
@@ -76,10 +83,15 @@ type PlRetPoint struct {
 }
 
 type pipelineResult struct {
+	// Line is the index of the sample within the request data, -1 for the
+	// points created by the aggregation buckets.
+	Line int `json:"line"`
+
 	Point       *PlRetPoint   `json:"point"`
 	CreatePoint []*PlRetPoint `json:"create_point"`
 
-	RunError *errchain.PlError `json:"run_error"`
+	RunError    *errchain.PlError `json:"run_error"`
+	DecodeError string            `json:"decode_error,omitempty"`
 }
 
 func apiPipelineDebugHandler(w http.ResponseWriter, req *http.Request, whatever ...interface{}) (interface{}, error) {
@@ -104,27 +116,10 @@ func apiPipelineDebugHandler(w http.ResponseWriter, req *http.Request, whatever
 		return nil, err
 	}
 
-	// get all base64 encoded scripts for the current category
-	if len(reqBody.Pipeline) == 0 ||
-		len(reqBody.Pipeline[reqBody.Category]) == 0 {
-		err := uhttp.Error(ErrInvalidPipeline, "invalid pipeline")
-		l.Errorf("[%s] %s", tid, err.Error())
-		return nil, err
-	}
-
-	scriptContent := reqBody.Pipeline[reqBody.Category]
-	if _, ok := scriptContent[reqBody.ScriptName]; !ok {
-		err := uhttp.Error(ErrInvalidPipeline, "invalid pipeline")
-		l.Errorf("[%s] %s", tid, err.Error())
-		return nil, err
-	}
-
-	// decode pipeline script content
-	// script name will automatically add the file suffix
-	scriptContent, err = decodePipeline(scriptContent)
+	scriptContent, err := debugScripts(&reqBody)
 	if err != nil {
 		l.Errorf("[%s] %s", tid, err.Error())
-		return nil, uhttp.Error(ErrInvalidData, err.Error())
+		return nil, err
 	}
 
 	var gtags [][2]string
@@ -157,61 +152,34 @@ func apiPipelineDebugHandler(w http.ResponseWriter, req *http.Request, whatever
 		}, nil
 	}
 
-	// decode log or line protocol data
-	// conv data to point
-	ptName := pointName(category.String(), reqBody.ScriptName)
-	pts, err := decodeDataAndConv2Point(category, ptName, &reqBody)
-	if err != nil {
-		l.Errorf("[%s] %s", tid, err.Error())
-		return nil, uhttp.Error(ErrInvalidData, err.Error())
-	}
+	// decode log or line protocol data line by line, conv data to point
+	dec := newPlDebugDecoder(category, pointName(category.String(), reqBody.ScriptName), &reqBody)
+	defer dec.close()
 
-	var runResult []pipelineResult
+	var (
+		runResult []pipelineResult
+		benchPt   *point.Point
+	)
 
 	start := time.Now()
 
-	for _, pt := range pts {
-		// run pipeline
-		plpt := ptinput.PtWrap(category, pt)
-		err := plRunner.Run(plpt, newPlTestSingal(), nil)
-
+	for idx, line := range reqBody.Data {
+		pts, err := dec.decode(line)
 		if err != nil {
-			plerr, ok := err.(*errchain.PlError) //nolint:errorlint
-			if !ok {
-				plerr = errchain.NewErr(reqBody.ScriptName+".p", token.LnColPos{
-					Pos: 0,
-					Ln:  1,
-					Col: 1,
-				}, err.Error())
-			}
-
+			// the sample not decoded, the other samples still debugged
 			runResult = append(runResult, pipelineResult{
-				RunError: plerr,
+				Line:        idx,
+				DecodeError: err.Error(),
 			})
-		} else {
-			var cPts []*PlRetPoint
-			for _, pt := range plpt.GetSubPoint() {
-				cPts = append(cPts, &PlRetPoint{
-					Dropped: pt.Dropped(),
-					Name:    pt.GetPtName(),
-					Tags:    pt.Tags(),
-					Fields:  pt.Fields(),
-					Time:    pt.PtTime().Unix(),
-					TimeNS:  int64(pt.PtTime().Nanosecond()),
-				})
-			}
+			continue
+		}
 
-			runResult = append(runResult, pipelineResult{
-				Point: &PlRetPoint{
-					Dropped: plpt.Dropped(),
-					Name:    plpt.GetPtName(),
-					Tags:    plpt.Tags(),
-					Fields:  plpt.Fields(),
-					Time:    plpt.PtTime().Unix(),
-					TimeNS:  int64(plpt.PtTime().Nanosecond()),
-				},
-				CreatePoint: cPts,
-			})
+		if benchPt == nil && len(pts) > 0 {
+			benchPt = pts[0]
+		}
+
+		for _, pt := range pts {
+			runResult = append(runResult, runDebugPipeline(plRunner, category, idx, pt, reqBody.ScriptName))
 		}
 	}
 	buks.StopAllBukScanner()
@@ -219,6 +187,7 @@ func apiPipelineDebugHandler(w http.ResponseWriter, req *http.Request, whatever
 	defer ptsLi.Unlock()
 	for _, pt := range ptsLi.d {
 		runResult = append(runResult, pipelineResult{
+			Line: -1,
 			Point: &PlRetPoint{
 				Name:   pt.Name(),
 				Tags:   pt.MapTags(),
@@ -230,8 +199,8 @@ func apiPipelineDebugHandler(w http.ResponseWriter, req *http.Request, whatever
 	}
 
 	var benchmarkInfo string
-	if reqBody.Benchmark && len(pts) > 0 {
-		benchmarkInfo = benchPipeline(plRunner, category, pts[0])
+	if reqBody.Benchmark && benchPt != nil {
+		benchmarkInfo = benchPipeline(plRunner, category, benchPt)
 	}
 
 	return &pipelineDebugResponse{
@@ -241,6 +210,81 @@ func apiPipelineDebugHandler(w http.ResponseWriter, req *http.Request, whatever
 	}, nil
 }
 
+// debugScripts get the decoded scripts of the category from the request, the
+// script name will automatically add the file suffix.
+func debugScripts(req *pipelineDebugRequest) (map[string]string, error) {
+	if req.Script != "" {
+		if req.ScriptName == "" {
+			req.ScriptName = defaultDebugScriptName
+		}
+
+		if len(req.Script) > PipelineScriptByteSizeLimit {
+			return nil, uhttp.Error(ErrInvalidData, "script size exceeds 1MB limit")
+		}
+
+		return map[string]string{req.ScriptName + ".p": req.Script}, nil
+	}
+
+	// get all base64 encoded scripts for the current category
+	scriptContent := req.Pipeline[req.Category]
+	if _, ok := scriptContent[req.ScriptName]; !ok {
+		return nil, uhttp.Error(ErrInvalidPipeline, "invalid pipeline")
+	}
+
+	scripts, err := decodePipeline(scriptContent)
+	if err != nil {
+		return nil, uhttp.Error(ErrInvalidData, err.Error())
+	}
+
+	return scripts, nil
+}
+
+func runDebugPipeline(plRunner *manager.PlScript, category point.Category,
+	line int, pt *point.Point, scriptName string,
+) pipelineResult {
+	plpt := ptinput.PtWrap(category, pt)
+	if err := plRunner.Run(plpt, newPlTestSingal(), nil); err != nil {
+		plerr, ok := err.(*errchain.PlError) //nolint:errorlint
+		if !ok {
+			plerr = errchain.NewErr(scriptName+".p", token.LnColPos{
+				Pos: 0,
+				Ln:  1,
+				Col: 1,
+			}, err.Error())
+		}
+
+		return pipelineResult{
+			Line:     line,
+			RunError: plerr,
+		}
+	}
+
+	var cPts []*PlRetPoint
+	for _, pt := range plpt.GetSubPoint() {
+		cPts = append(cPts, &PlRetPoint{
+			Dropped: pt.Dropped(),
+			Name:    pt.GetPtName(),
+			Tags:    pt.Tags(),
+			Fields:  pt.Fields(),
+			Time:    pt.PtTime().Unix(),
+			TimeNS:  int64(pt.PtTime().Nanosecond()),
+		})
+	}
+
+	return pipelineResult{
+		Line: line,
+		Point: &PlRetPoint{
+			Dropped: plpt.Dropped(),
+			Name:    plpt.GetPtName(),
+			Tags:    plpt.Tags(),
+			Fields:  plpt.Fields(),
+			Time:    plpt.PtTime().Unix(),
+			TimeNS:  int64(plpt.PtTime().Nanosecond()),
+		},
+		CreatePoint: cPts,
+	}
+}
+
 func parsePipeline(category point.Category, scriptName string,
 	scripts map[string]string, buks *plmap.AggBuckets,
 ) (*manager.PlScript, error) {
@@ -304,19 +348,29 @@ func decodePipeline(scripts map[string]string) (map[string]string, error) {
 	return decodedScripts, nil
 }
 
-func decodeDataAndConv2Point(category point.Category, name string, req *pipelineDebugRequest) ([]*point.Point, error) {
-	if req == nil {
-		return nil, nil
+// plDebugDecoder decode the base64 encoded sample into points.
+type plDebugDecoder struct {
+	category    point.Category
+	name        string
+	encode      string
+	messageOnly bool
+	opts        []point.Option
+	dec         *point.Decoder
+}
+
+func newPlDebugDecoder(category point.Category, name string, req *pipelineDebugRequest) *plDebugDecoder {
+	d := &plDebugDecoder{
+		category: category,
+		name:     name,
+		encode:   req.Encode,
 	}
-	result := []*point.Point{}
 
-	var messageOnly bool
 	var enc point.Encoding
 	switch {
 	case strings.Contains(req.DataType, "text/plain"):
-		messageOnly = true
+		d.messageOnly = true
 	case req.DataType == "" && category == point.Logging:
-		messageOnly = true
+		d.messageOnly = true
 	default:
 		enc = point.HTTPContentType(req.DataType)
 	}
@@ -328,47 +382,46 @@ func decodeDataAndConv2Point(category point.Category, name string, req *pipeline
 		decOpts = append(decOpts, point.WithDecEncoding(enc))
 	}
 
-	dec := point.GetDecoder(decOpts...)
-	defer point.PutDecoder(dec)
+	d.dec = point.GetDecoder(decOpts...)
 
-	for _, line := range req.Data {
-		data, err := b64dec(line)
+	switch category { //nolint:exhaustive
+	case point.Logging:
+		d.opts = point.DefaultLoggingOptions()
+	case point.Metric:
+		d.opts = point.DefaultMetricOptions()
+	default:
+		d.opts = point.CommonLoggingOptions()
+	}
+
+	return d
+}
+
+func (d *plDebugDecoder) decode(line string) ([]*point.Point, error) {
+	data, err := b64dec(line)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) > DataByteSizeLimit {
+		return nil, fmt.Errorf("data size exceeds 32MB limit")
+	}
+
+	if d.messageOnly {
+		data, err := iconv(data, d.encode)
 		if err != nil {
 			return nil, err
 		}
+		kvs := point.NewKVs(map[string]interface{}{
+			pipeline.FieldMessage: string(data),
+		})
+		return []*point.Point{point.NewPointV2(d.name, kvs, d.opts...)}, nil
+	}
 
-		if len(data) > DataByteSizeLimit {
-			return nil, fmt.Errorf("data size exceeds 32MB limit")
-		}
-		var opts []point.Option
-		switch category { //nolint:exhaustive
-		case point.Logging:
-			opts = point.DefaultLoggingOptions()
-		case point.Metric:
-			opts = point.DefaultMetricOptions()
-		default:
-			opts = point.CommonLoggingOptions()
-		}
+	return d.dec.Decode(data, d.opts...)
+}
 
-		if messageOnly {
-			data, err := iconv(data, req.Encode)
-			if err != nil {
-				return nil, err
-			}
-			kvs := point.NewKVs(map[string]interface{}{
-				pipeline.FieldMessage: string(data),
-			})
-			pt := point.NewPointV2(name, kvs, opts...)
-			result = append(result, pt)
-		} else {
-			pts, err := dec.Decode(data, opts...)
-			if err != nil {
-				return nil, err
-			}
-			result = append(result, pts...)
-		}
-	}
-	return result, nil
+func (d *plDebugDecoder) close() {
+	point.PutDecoder(d.dec)
 }
 
 func b64dec(cnt string) ([]byte, error) {
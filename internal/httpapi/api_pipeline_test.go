@@ -70,16 +70,22 @@ func TestGetDecodeData(t *testing.T) {
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			pts, err := decodeDataAndConv2Point(point.Logging, "a", &pipelineDebugRequest{
-				Encode:   tc.in.Encode,
-				DataType: tc.in.DataType,
-				Data:     tc.in.Data,
-			})
-
-			var r []string
-			for _, pt := range pts {
-				fields := pt.InfluxFields()
-				r = append(r, fields["message"].(string))
+			dec := newPlDebugDecoder(point.Logging, "a", tc.in)
+			defer dec.close()
+
+			var (
+				r   []string
+				err error
+			)
+			for _, line := range tc.in.Data {
+				var pts []*point.Point
+				if pts, err = dec.decode(line); err != nil {
+					break
+				}
+				for _, pt := range pts {
+					fields := pt.InfluxFields()
+					r = append(r, fields["message"].(string))
+				}
 			}
 			assert.Equal(t, tc.expectError, err, "getDecodeData found error: %v", err)
 			assert.Equal(t, tc.expectData, r, "getDecodeData not equal")
@@ -297,6 +303,55 @@ func TestApiDebugPipelineHandler(t *testing.T) {
 	}
 }
 
+func TestApiDebugPipelinePerLine(t *testing.T) {
+	in := &pipelineDebugRequest{
+		Category: "logging",
+		Script:   `grok(_, "%{WORD:result} %{INT:code}")`,
+		DataType: "text/plain",
+		Data: []string{
+			base64.StdEncoding.EncodeToString([]byte("ok 200")),
+			"not-base64-data",
+			base64.StdEncoding.EncodeToString([]byte("fail 500")),
+		},
+	}
+
+	bys, err := json.Marshal(in)
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/pipeline/debug", bytes.NewReader(bys))
+	data, err := apiPipelineDebugHandler(httptest.NewRecorder(), req)
+	assert.NoError(t, err)
+
+	resp, ok := data.(*pipelineDebugResponse)
+	assert.True(t, ok)
+	assert.Empty(t, resp.PlErrors)
+	assert.Len(t, resp.PLResults, 3)
+
+	for idx, r := range resp.PLResults {
+		assert.Equal(t, idx, r.Line)
+	}
+
+	assert.Equal(t, defaultDebugScriptName, resp.PLResults[0].Point.Name)
+	assert.Equal(t, "ok", resp.PLResults[0].Point.Fields["result"])
+	assert.Equal(t, "200", resp.PLResults[0].Point.Fields["code"])
+
+	assert.Nil(t, resp.PLResults[1].Point)
+	assert.NotEmpty(t, resp.PLResults[1].DecodeError)
+
+	assert.Equal(t, "fail", resp.PLResults[2].Point.Fields["result"])
+	assert.Equal(t, "500", resp.PLResults[2].Point.Fields["code"])
+
+	// the script not compiled
+	in.Script = `grok(_, `
+	bys, err = json.Marshal(in)
+	assert.NoError(t, err)
+
+	req = httptest.NewRequest(http.MethodPost, "/v1/pipeline/debug", bytes.NewReader(bys))
+	data, err = apiPipelineDebugHandler(httptest.NewRecorder(), req)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, data.(*pipelineDebugResponse).PlErrors)
+}
+
 type Client struct {
 	url string
 }
@@ -0,0 +1,38 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package httpapi
+
+import (
+	"net/http"
+	"strconv"
+
+	uhttp "github.com/GuanceCloud/cliutils/network/http"
+
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/ptrace"
+)
+
+// apiPointTrace list the latest point delivery traces, filtered by query
+// parameter `measurement` and limited by `limit`.
+func apiPointTrace(_ http.ResponseWriter, req *http.Request, _ ...interface{}) (interface{}, error) {
+	if !ptrace.Enabled() {
+		return nil, ErrPointTraceDisabled
+	}
+
+	var (
+		q     = req.URL.Query()
+		limit = 0
+	)
+
+	if x := q.Get("limit"); x != "" {
+		n, err := strconv.Atoi(x)
+		if err != nil || n < 0 {
+			return nil, uhttp.Errorf(ErrInvalidRequest, "invalid limit %q", x)
+		}
+		limit = n
+	}
+
+	return ptrace.Traces(q.Get("measurement"), limit), nil
+}
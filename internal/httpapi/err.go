@@ -42,6 +42,7 @@ var (
 
 	ErrPublicAccessDisabled = newErr(errors.New("public access disabled"), http.StatusForbidden)
 	ErrReachLimit           = newErr(errors.New("reach max API limit"), http.StatusTooManyRequests)
+	ErrPointTraceDisabled   = newErr(errors.New("point trace disabled"), http.StatusNotFound)
 
 	ErrInvalidJSON = newErr(errors.New("invalid JSON"), http.StatusBadRequest)
 
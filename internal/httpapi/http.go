@@ -224,6 +224,9 @@ func setupRouter(hs *httpServerConf) *gin.Engine {
 	router.POST("/v1/pipeline/debug", RawHTTPWrapper(reqLimiter, apiPipelineDebugHandler))
 
 	router.POST("/v1/lasterror", RawHTTPWrapper(reqLimiter, apiPutLastError, dkio.DefaultFeeder()))
+	router.GET("/v1/feed/stats", RawHTTPWrapper(reqLimiter, apiFeedStats))
+	router.GET("/v1/point/trace", RawHTTPWrapper(reqLimiter, apiPointTrace))
+	router.GET("/v1/goroutine/stalled", RawHTTPWrapper(reqLimiter, apiGoroutineStalled))
 	router.GET("/restart", RawHTTPWrapper(reqLimiter, apiRestart, apiRestartImpl{conf: hs}))
 
 	router.GET("/metrics", ginLimiter(reqLimiter), metrics.HTTPGinHandler(promhttp.HandlerOpts{}))
@@ -648,12 +651,12 @@ func ReloadDataKit(ctx context.Context) error {
 				if m, ok := plval.GetManager(); ok && m != nil {
 					// git
 					if config.GitHasEnabled() {
-						m.LoadScriptsFromWorkspace(manager.NSGitRepo,
-							filepath.Join(datakit.GitReposRepoFullPath, "pipeline"), nil)
+						plval.LoadScriptsFromWorkspace(m, manager.NSGitRepo,
+							filepath.Join(datakit.GitReposRepoFullPath, "pipeline"))
 					}
 					// local
 					plPath := filepath.Join(datakit.InstallDir, "pipeline")
-					m.LoadScriptsFromWorkspace(manager.NSDefault, plPath, nil)
+					plval.LoadScriptsFromWorkspace(m, manager.NSDefault, plPath)
 				}
 
 			case 4:
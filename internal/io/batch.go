@@ -0,0 +1,65 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package io
+
+import (
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+)
+
+const (
+	batchFlushBytes    = "bytes"
+	batchFlushLatency  = "latency"
+	batchFlushCount    = "count"
+	batchFlushInterval = "interval"
+	batchFlushExit     = "exit"
+)
+
+// BatchRule flush the cached points once the payload bytes reached, or the
+// first cached point waited for the latency.
+type BatchRule struct {
+	MaxBytes   int           `toml:"max_bytes" json:"max_bytes"`
+	MaxLatency time.Duration `toml:"max_latency" json:"max_latency"`
+}
+
+// BatchConf configure the batching of the points before uploading, instead of
+// the fixed flush interval and max cache count. The rule on category overwrite
+// the global rule, the zero values inherit the global ones.
+type BatchConf struct {
+	BatchRule
+
+	Categories map[string]*BatchRule `toml:"categories" json:"categories"`
+}
+
+// rule get the batch rule of the category, nil if batching not configured.
+// The latency default to the flush interval.
+func (c *BatchConf) rule(cat point.Category, flushInterval time.Duration) *BatchRule {
+	if c == nil {
+		return nil
+	}
+
+	r := c.BatchRule
+	if x, ok := c.Categories[cat.String()]; ok && x != nil {
+		if x.MaxBytes > 0 {
+			r.MaxBytes = x.MaxBytes
+		}
+
+		if x.MaxLatency > 0 {
+			r.MaxLatency = x.MaxLatency
+		}
+	}
+
+	if r.MaxBytes <= 0 && r.MaxLatency <= 0 {
+		return nil
+	}
+
+	if r.MaxLatency <= 0 {
+		r.MaxLatency = flushInterval
+	}
+
+	return &r
+}
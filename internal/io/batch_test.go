@@ -0,0 +1,112 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package io
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/metrics"
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/dataway"
+)
+
+type countDataway struct {
+	writes int
+}
+
+func (dw *countDataway) Write(...dataway.WriteOption) error {
+	dw.writes++
+	return nil
+}
+
+func (*countDataway) Pull(string) ([]byte, error) { return nil, nil }
+
+func TestBatchRule(t *testing.T) {
+	var c *BatchConf
+	assert.Nil(t, c.rule(point.Logging, time.Second))
+	assert.Nil(t, (&BatchConf{}).rule(point.Logging, time.Second))
+
+	c = &BatchConf{
+		BatchRule: BatchRule{MaxBytes: 1024},
+		Categories: map[string]*BatchRule{
+			"logging": {MaxBytes: 4096, MaxLatency: time.Second},
+			"tracing": {MaxLatency: 3 * time.Second},
+		},
+	}
+
+	assert.Equal(t, &BatchRule{MaxBytes: 1024, MaxLatency: 10 * time.Second}, c.rule(point.Metric, 10*time.Second))
+	assert.Equal(t, &BatchRule{MaxBytes: 4096, MaxLatency: time.Second}, c.rule(point.Logging, 10*time.Second))
+	assert.Equal(t, &BatchRule{MaxBytes: 1024, MaxLatency: 3 * time.Second}, c.rule(point.Tracing, 10*time.Second))
+
+	// latency only
+	c = &BatchConf{Categories: map[string]*BatchRule{"logging": {MaxLatency: time.Second}}}
+	assert.Equal(t, &BatchRule{MaxLatency: time.Second}, c.rule(point.Logging, 10*time.Second))
+	assert.Nil(t, c.rule(point.Metric, 10*time.Second))
+}
+
+func TestBatchCompact(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(batchPtsVec, batchBytesVec)
+	t.Cleanup(MetricsReset)
+
+	pts := point.RandPoints(10)
+	size := 0
+	for _, pt := range pts {
+		size += pt.Size()
+	}
+
+	dw := &countDataway{}
+	x := &dkIO{dw: dw, compactAt: 3, flushInterval: time.Second}
+	c := &compactor{
+		category: point.Logging,
+		batch:    &BatchRule{MaxBytes: size * 2, MaxLatency: time.Second},
+	}
+
+	feed := func(c *compactor) {
+		fo := GetFeedOption()
+		fo.cat = point.Logging
+		fo.pts = pts
+		x.cacheData(c, fo, true)
+	}
+
+	// the max cache count not applied on batching with max bytes
+	feed(c)
+	assert.Equal(t, 0, dw.writes)
+	assert.Equal(t, size, c.bytes)
+	assert.Len(t, c.points, 10)
+
+	feed(c)
+	assert.Equal(t, 1, dw.writes)
+	assert.Equal(t, 0, c.bytes)
+	assert.Empty(t, c.points)
+
+	mfs, err := reg.Gather()
+	require.NoError(t, err)
+
+	m := metrics.GetMetricOnLabels(mfs, "datakit_io_batch_bytes", "logging", batchFlushBytes)
+	require.NotNil(t, m)
+	assert.Equal(t, uint64(1), m.GetSummary().GetSampleCount())
+	assert.Equal(t, float64(size*2), m.GetSummary().GetSampleSum())
+
+	m = metrics.GetMetricOnLabels(mfs, "datakit_io_batch_points", "logging", batchFlushBytes)
+	require.NotNil(t, m)
+	assert.Equal(t, 20.0, m.GetSummary().GetSampleSum())
+
+	t.Run("count-without-batch", func(t *testing.T) {
+		dw.writes = 0
+		c := &compactor{category: point.Logging, compactTicker: time.NewTicker(time.Second)}
+		defer c.compactTicker.Stop()
+
+		feed(c)
+		assert.Equal(t, 1, dw.writes)
+		assert.Equal(t, 0, c.bytes) // bytes not counted
+	})
+}
@@ -13,6 +13,7 @@ import (
 
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/datakit"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/dataway"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/ptrace"
 )
 
 type compactor struct {
@@ -20,6 +21,9 @@ type compactor struct {
 	compactTicker *time.Ticker
 	points        []*point.Point
 	lastCompact   time.Time
+
+	batch *BatchRule // nil for the fixed flush interval
+	bytes int        // approximate payload bytes of the points
 }
 
 func (x *dkIO) runCompactor(cat point.Category) {
@@ -29,19 +33,24 @@ func (x *dkIO) runCompactor(cat point.Category) {
 	}
 
 	c := &compactor{
-		compactTicker: time.NewTicker(x.flushInterval),
-		category:      cat,
+		category: cat,
 	}
 
-	defer c.compactTicker.Stop()
-
 	if cat == point.DynamicDWCategory {
 		// NOTE: 目前只有拨测的数据会将数据打到 dynamicDatawayPts 中，而拨测数据
 		// 是写日志，故将 category 设置为 logging
 		c.category = point.Logging
 	}
 
-	log.Infof("run compactor on %s", c.category)
+	interval, tickReason := x.flushInterval, batchFlushInterval
+	if c.batch = x.batch.rule(c.category, x.flushInterval); c.batch != nil {
+		interval, tickReason = c.batch.MaxLatency, batchFlushLatency
+	}
+
+	c.compactTicker = time.NewTicker(interval)
+	defer c.compactTicker.Stop()
+
+	log.Infof("run compactor on %s, batch: %+#v", c.category, c.batch)
 	for {
 		select {
 		case d := <-r:
@@ -51,15 +60,15 @@ func (x *dkIO) runCompactor(cat point.Category) {
 		case <-c.compactTicker.C:
 			if len(c.points) > 0 {
 				log.Debugf("on tick(%s) to compact %s(%d points), last compact %s ago...",
-					x.flushInterval, c.category, len(c.points), time.Since(c.lastCompact))
-				x.compact(c)
+					interval, c.category, len(c.points), time.Since(c.lastCompact))
+				x.compact(c, tickReason)
 			}
 
 		case <-datakit.Exit.Wait():
 			if len(c.points) > 0 {
 				log.Debugf("on tick(%s) to compact %s(%d points), last compact %s ago...",
-					x.flushInterval, c.category, len(c.points), time.Since(c.lastCompact))
-				x.compact(c)
+					interval, c.category, len(c.points), time.Since(c.lastCompact))
+				x.compact(c, batchFlushExit)
 			}
 			return
 		}
@@ -80,14 +89,39 @@ func (x *dkIO) cacheData(c *compactor, d *feedOption, tryClean bool) {
 	log.Debugf("get iodata(%d points) from %s|%s", len(d.pts), d.cat, d.input)
 
 	x.recordPoints(d)
+	ptrace.Record(d.pts, ptrace.StageQueue, fmt.Sprintf("cached on %s from %s", c.category, d.input))
+
+	if c.batch != nil {
+		// the latency counted from the first cached point
+		if len(c.points) == 0 && c.compactTicker != nil {
+			c.compactTicker.Reset(c.batch.MaxLatency)
+		}
+
+		for _, pt := range d.pts {
+			c.bytes += pt.Size()
+		}
+	}
+
 	c.points = append(c.points, d.pts...)
 
 	queuePtsVec.WithLabelValues(d.cat.String()).Add(float64(len(d.pts)))
 
-	if tryClean && x.compactAt > 0 && len(c.points) > x.compactAt {
-		x.compact(c)
-		// reset compact ticker to prevent small packages
-		c.compactTicker.Reset(x.flushInterval)
+	if !tryClean {
+		return
+	}
+
+	switch {
+	case c.batch != nil && c.batch.MaxBytes > 0:
+		if c.bytes >= c.batch.MaxBytes {
+			x.compact(c, batchFlushBytes)
+		}
+
+	case x.compactAt > 0 && len(c.points) > x.compactAt:
+		x.compact(c, batchFlushCount)
+		if c.batch == nil {
+			// reset compact ticker to prevent small packages
+			c.compactTicker.Reset(x.flushInterval)
+		}
 	}
 }
 
@@ -103,23 +137,32 @@ func (x *dkIO) recordPoints(d *feedOption) {
 	}
 }
 
-func (x *dkIO) compact(c *compactor) {
+func (x *dkIO) compact(c *compactor, reason string) {
 	c.lastCompact = time.Now()
 
-	npts := float64(len(c.points))
+	npts, nbytes := float64(len(c.points)), float64(c.bytes)
 	defer func() {
 		flushVec.WithLabelValues(c.category.String()).Inc()
 		queuePtsVec.WithLabelValues(c.category.String()).Sub(npts)
+		batchPtsVec.WithLabelValues(c.category.String(), reason).Observe(npts)
+		if c.batch != nil {
+			batchBytesVec.WithLabelValues(c.category.String(), reason).Observe(nbytes)
+		}
 	}()
 
+	ptrace.Record(c.points, ptrace.StageFlush, fmt.Sprintf("flush %d points on %s", len(c.points), reason))
+
 	if err := x.doCompact(c.points, c.category); err != nil {
 		log.Warnf("post %d points to %s failed: %s, ignored", len(c.points), c.category, err)
+		ptrace.Release(c.points, ptrace.StageFlush, fmt.Sprintf("write failed: %s", err))
 	}
 
 	// I think here is the best position to put back these points.
+	ptrace.Release(c.points, ptrace.StageFlush, "not encoded")
 	datakit.PutbackPoints(c.points...)
 
 	c.points = c.points[:0] // clear
+	c.bytes = 0
 }
 
 func (x *dkIO) doCompact(points []*point.Point, cat point.Category, dynamicURL ...string) error {
@@ -131,6 +174,8 @@ func (x *dkIO) doCompact(points []*point.Point, cat point.Category, dynamicURL .
 		return nil
 	}
 
+	x.writeSinks(cat, points)
+
 	opts := []dataway.WriteOption{
 		dataway.WithPoints(points),
 		// max cache size(in memory) upload as a batch
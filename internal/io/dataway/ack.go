@@ -0,0 +1,199 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package dataway
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// HeaderBatchID is the UUID of the points batch, all bodies split from
+	// the batch share the same ID.
+	HeaderBatchID = "X-Batch-ID"
+	// HeaderBatchSeq is the sequence number of the body within the batch.
+	HeaderBatchSeq = "X-Batch-Seq"
+
+	ackLogFile        = "acked"
+	defaultAckLogKeys = 10000
+
+	// the written keys survive the crash of datakit without fsync, the fsync
+	// is only for the crash of the OS, so the keys are synced in interval.
+	ackSyncInterval = time.Second
+)
+
+// batchKey is the idempotency key of the body, empty if the body has no batch
+// ID, such as the bodies cached by older datakit.
+func (b *body) batchKey() string {
+	var id, seq string
+	for _, h := range b.headers() {
+		switch h.Key {
+		case HeaderBatchID:
+			id = h.Value
+		case HeaderBatchSeq:
+			seq = h.Value
+		}
+	}
+
+	if id == "" {
+		return ""
+	}
+
+	return id + ":" + seq
+}
+
+// ackLog record the keys of the disk-queue bodies that have been sent. The
+// position of the disk queue only updated after the body sent, if datakit
+// crashed in between, the body is read again after restart, and the ack log
+// is used to skip the re-sending.
+//
+// Only the latest keys are kept, the log file is compacted once it's twice
+// of the kept keys.
+type ackLog struct {
+	mtx   sync.Mutex
+	path  string
+	fd    *os.File
+	max   int
+	keys  map[string]struct{}
+	order []string
+	lines int
+
+	syncInterval time.Duration
+	lastSync     time.Time
+}
+
+func openAckLog(path string, max int) (*ackLog, error) {
+	a := &ackLog{
+		path:         path,
+		max:          max,
+		keys:         map[string]struct{}{},
+		syncInterval: ackSyncInterval,
+	}
+
+	if data, err := os.ReadFile(filepath.Clean(path)); err == nil {
+		for _, k := range strings.Split(string(data), "\n") {
+			if k != "" {
+				a.add(k)
+				a.lines++
+			}
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	fd, err := os.OpenFile(filepath.Clean(path), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	a.fd = fd
+
+	return a, nil
+}
+
+func (a *ackLog) add(k string) {
+	if _, ok := a.keys[k]; ok {
+		return
+	}
+
+	a.keys[k] = struct{}{}
+	a.order = append(a.order, k)
+
+	if len(a.order) > a.max {
+		delete(a.keys, a.order[0])
+		a.order = a.order[1:]
+	}
+}
+
+func (a *ackLog) acked(k string) bool {
+	if a == nil || k == "" {
+		return false
+	}
+
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	_, ok := a.keys[k]
+	return ok
+}
+
+// ack record the key, the keys are synced to disk at most once per
+// syncInterval.
+func (a *ackLog) ack(k string) error {
+	if a == nil || k == "" {
+		return nil
+	}
+
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	a.add(k)
+
+	if a.lines+1 >= 2*a.max {
+		return a.compact()
+	}
+
+	if _, err := a.fd.WriteString(k + "\n"); err != nil {
+		return err
+	}
+	a.lines++
+
+	if now := time.Now(); now.Sub(a.lastSync) >= a.syncInterval {
+		a.lastSync = now
+		return a.fd.Sync()
+	}
+
+	return nil
+}
+
+// compact rewrite the log with the kept keys.
+func (a *ackLog) compact() error {
+	tmp := a.path + ".tmp"
+	fd, err := os.OpenFile(filepath.Clean(tmp), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(fd)
+	for _, k := range a.order {
+		if _, err := w.WriteString(k + "\n"); err != nil {
+			fd.Close() //nolint:errcheck,gosec
+			return err
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		fd.Close() //nolint:errcheck,gosec
+		return err
+	}
+
+	if err := fd.Sync(); err != nil {
+		fd.Close() //nolint:errcheck,gosec
+		return err
+	}
+
+	if err := fd.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, a.path); err != nil {
+		return fmt.Errorf("rename ack log: %w", err)
+	}
+
+	a.fd.Close() //nolint:errcheck,gosec
+	if a.fd, err = os.OpenFile(filepath.Clean(a.path), os.O_WRONLY|os.O_APPEND, 0o600); err != nil {
+		return err
+	}
+
+	a.lines = len(a.order)
+	a.lastSync = time.Now() // synced on compacted
+	return nil
+}
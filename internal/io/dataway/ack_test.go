@@ -0,0 +1,77 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package dataway
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	T "testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAckLog(t *T.T) {
+	path := filepath.Join(t.TempDir(), ackLogFile)
+
+	a, err := openAckLog(path, 3)
+	require.NoError(t, err)
+
+	assert.False(t, a.acked("k1"))
+	assert.False(t, a.acked(""))
+
+	for i := 1; i <= 5; i++ {
+		require.NoError(t, a.ack(fmt.Sprintf("k%d", i)))
+	}
+
+	// only latest 3 keys kept
+	assert.False(t, a.acked("k2"))
+	for _, k := range []string{"k3", "k4", "k5"} {
+		assert.True(t, a.acked(k))
+	}
+	assert.Equal(t, 5, a.lines)
+
+	// compacted on twice of the kept keys
+	require.NoError(t, a.ack("k6"))
+	assert.Equal(t, 3, a.lines)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "k4\nk5\nk6\n", string(data))
+
+	// reload after restart
+	require.NoError(t, a.ack("k7"))
+	a, err = openAckLog(path, 3)
+	require.NoError(t, err)
+	assert.True(t, a.acked("k7"))
+	assert.False(t, a.acked("k4"))
+
+	var nilLog *ackLog
+	assert.False(t, nilLog.acked("k7"))
+	assert.NoError(t, nilLog.ack("k7"))
+}
+
+func TestAckLogSyncInterval(t *T.T) {
+	path := filepath.Join(t.TempDir(), ackLogFile)
+
+	a, err := openAckLog(path, 100)
+	require.NoError(t, err)
+	a.syncInterval = time.Hour
+
+	require.NoError(t, a.ack("k1")) // the first ack synced
+	synced := a.lastSync
+	assert.False(t, synced.IsZero())
+
+	require.NoError(t, a.ack("k2"))
+	assert.Equal(t, synced, a.lastSync) // not synced within the interval
+
+	// written without sync
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "k1\nk2\n", string(data))
+}
@@ -8,10 +8,14 @@ package dataway
 import (
 	"fmt"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/GuanceCloud/cliutils/point"
+	"github.com/google/uuid"
+
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/ptrace"
 )
 
 type (
@@ -27,6 +31,7 @@ const (
 
 	gzipRaw    gzipFlag = 0
 	gzipSet    gzipFlag = 1
+	zstdSet    gzipFlag = 2 // compressed with zstd instead of gzip
 	gzipNotSet gzipFlag = -1
 
 	bufOnwerOthers bufOnwer = 0
@@ -229,6 +234,9 @@ func (w *writer) buildPointsBody() error {
 		}
 	}()
 
+	batchID := uuid.NewString()
+	ptrace.Encode(w.points, batchID)
+
 	for {
 		var (
 			compactStart = time.Now()
@@ -254,16 +262,22 @@ func (w *writer) buildPointsBody() error {
 		b.CacheData.Payload = encodeBytes
 
 		if w.gzipDuringBuildBody {
-			gz := getZipper()
-			defer putZipper(gz)
+			flag := w.gzip
+			if flag != zstdSet {
+				flag = gzipSet
+			}
 
-			if zbuf, err := gz.zip(b.buf()); err != nil {
-				l.Errorf("gzip: %s", err.Error())
+			zipper := &compressor{}
+			defer zipper.put()
+
+			if zbuf, err := zipper.zip(flag, b.buf()); err != nil {
+				l.Errorf("%s: %s", contentEncoding(flag), err.Error())
 				return err
 			} else {
 				ncopy := copy(b.sendBuf, zbuf)
 				l.Debugf("copy %d(origin: %d) zipped bytes to buf", ncopy, len(b.buf()))
 				b.CacheData.Payload = b.sendBuf[:ncopy]
+				b.gzon = flag
 			}
 		}
 
@@ -276,6 +290,12 @@ func (w *writer) buildPointsBody() error {
 			b.CacheData.Headers = append(b.CacheData.Headers, &HTTPHeader{Key: k, Value: v})
 		}
 
+		// the batch ID and sequence are kept within WAL, so the body retried
+		// (even after restart) is sent with the same ID.
+		b.CacheData.Headers = append(b.CacheData.Headers,
+			&HTTPHeader{Key: HeaderBatchID, Value: batchID},
+			&HTTPHeader{Key: HeaderBatchSeq, Value: strconv.Itoa(parts)})
+
 		buildBodyCostVec.WithLabelValues(
 			b.cat().String(),
 			w.httpEncoding.String(),
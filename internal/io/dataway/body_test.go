@@ -553,13 +553,17 @@ func TestBodyCacheData(t *T.T) {
 
 			t.Logf("body: %s", newBody.pretty())
 
-			assert.Len(t, newBody.headers(), 2)
+			assert.Len(t, newBody.headers(), 4) // with batch ID and seq
 			for _, h := range newBody.headers() {
 				switch h.Key {
 				case "header-1":
 					assert.Equal(t, `value-1`, h.Value)
 				case "header-2":
 					assert.Equal(t, `value-2`, h.Value)
+				case HeaderBatchID:
+					assert.Len(t, h.Value, 36)
+				case HeaderBatchSeq:
+					assert.Equal(t, `0`, h.Value)
 				default:
 					assert.Truef(t, false, "should not been here")
 				}
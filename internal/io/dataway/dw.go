@@ -17,6 +17,7 @@ import (
 
 	"github.com/GuanceCloud/cliutils/logger"
 	"github.com/GuanceCloud/cliutils/point"
+	gzip "github.com/klauspost/compress/gzip"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/datakit"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/git"
 )
@@ -142,6 +143,10 @@ type Dataway struct {
 	IdleTimeout time.Duration `toml:"idle_timeout"`
 
 	GZip bool `toml:"gzip"`
+	// Compression codec(gzip/zstd) used if gzip enabled, the zstd body are
+	// transcoded into gzip on endpoints not supporting zstd.
+	Compression string `toml:"compression"`
+	GZipLevel   int    `toml:"gzip_level"` // 1(best speed) ~ 9(best compression), 0 for default
 
 	EnableHTTPTrace    bool `toml:"enable_httptrace"`
 	EnableSinker       bool `toml:"enable_sinker"`
@@ -150,10 +155,19 @@ type Dataway struct {
 	GlobalCustomerKeys []string `toml:"global_customer_keys"`
 	WAL                *WALConf `toml:"wal"`
 
-	eps []*endPoint
+	// Routes send the category to part of the URLs, the value is the index of
+	// the URLs, such as {"logging": [0], "metric": [0, 1]}. Categories not
+	// routed are sent to all URLs.
+	Routes map[string][]int `toml:"routes,omitempty"`
 
-	walq    map[point.Category]*WALQueue
-	walFail *WALQueue
+	eps    []*endPoint
+	routes map[point.Category][]*endPoint
+
+	walq     map[point.Category]*WALQueue
+	walFail  *WALQueue
+	overflow *overflowStore
+	acks     *ackLog
+	gate     *priorityGate
 
 	locker     sync.RWMutex
 	dnsCachers []*dnsCacher
@@ -204,6 +218,37 @@ func (dw *Dataway) Init(opts ...DWOption) error {
 	return nil
 }
 
+func (dw *Dataway) checkCompression() error {
+	switch dw.Compression {
+	case "", CompressionGZip, CompressionZstd:
+	default:
+		return fmt.Errorf("invalid compression %q, only gzip/zstd supported", dw.Compression)
+	}
+
+	switch {
+	case dw.GZipLevel == 0:
+		gzipLevel = gzip.DefaultCompression
+	case dw.GZipLevel >= gzip.BestSpeed && dw.GZipLevel <= gzip.BestCompression:
+		gzipLevel = dw.GZipLevel
+	default:
+		return fmt.Errorf("invalid gzip_level %d, should be 1~9", dw.GZipLevel)
+	}
+
+	return nil
+}
+
+// gzipFlag get the compression of the body.
+func (dw *Dataway) gzipFlag() gzipFlag {
+	switch {
+	case !dw.GZip:
+		return gzipNotSet
+	case dw.Compression == CompressionZstd:
+		return zstdSet
+	default:
+		return gzipSet
+	}
+}
+
 func (dw *Dataway) String() string {
 	arr := []string{fmt.Sprintf("dataways: [%s]", strings.Join(dw.URLs, ","))}
 
@@ -257,6 +302,10 @@ func (dw *Dataway) doInit() error {
 
 	dw.contentEncoding = point.EncodingStr(dw.ContentEncoding)
 
+	if err := dw.checkCompression(); err != nil {
+		return err
+	}
+
 	// set default raw body size to 10MB
 	if dw.MaxRawBodySize == 0 {
 		dw.MaxRawBodySize = DefaultMaxRawBodySize
@@ -324,10 +373,18 @@ func (dw *Dataway) doInit() error {
 		dw.addDNSCache(ep.host)
 	}
 
+	if err := dw.setupRoutes(); err != nil {
+		return err
+	}
+
 	if dw.WAL.Path == "" {
 		dw.WAL.Path = filepath.Join(datakit.CacheDir, "dw-wal")
 	}
 
+	if err := dw.setupOverflow(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -9,6 +9,7 @@ import (
 	T "testing"
 	"time"
 
+	gzip "github.com/klauspost/compress/gzip"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -62,3 +63,29 @@ func TestDWInit(t *T.T) {
 		assert.Equal(t, 1, dw.MaxRetryCount)
 	})
 }
+
+func TestCheckCompression(t *T.T) {
+	dw := NewDefaultDataway()
+	assert.NoError(t, dw.checkCompression())
+	assert.Equal(t, gzipSet, dw.gzipFlag())
+
+	dw.Compression = CompressionZstd
+	dw.GZipLevel = 9
+	assert.NoError(t, dw.checkCompression())
+	assert.Equal(t, zstdSet, dw.gzipFlag())
+	assert.Equal(t, 9, gzipLevel)
+
+	dw.GZip = false
+	assert.Equal(t, gzipNotSet, dw.gzipFlag())
+
+	dw.GZipLevel = 10
+	assert.Error(t, dw.checkCompression())
+
+	dw.GZipLevel = 0
+	dw.Compression = "lz4"
+	assert.Error(t, dw.checkCompression())
+
+	dw.Compression = ""
+	assert.NoError(t, dw.checkCompression())
+	assert.Equal(t, gzip.DefaultCompression, gzipLevel)
+}
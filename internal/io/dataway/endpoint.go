@@ -7,6 +7,7 @@ package dataway
 
 import (
 	"bytes"
+	"crypto/md5" //nolint:gosec
 	"crypto/tls"
 	"fmt"
 	"io"
@@ -49,6 +50,13 @@ type endPoint struct {
 
 	insecureSkipVerify,
 	httpTrace bool
+
+	// the endpoint responded 415 on zstd body, we send gzip body instead.
+	zstdUnsupported atomic.Bool
+
+	// fail-cache of the endpoint under multiple endpoints, so the failed
+	// body only retried on the failed endpoint.
+	failCache *WALQueue
 }
 
 func (ep *endPoint) String() string {
@@ -56,6 +64,11 @@ func (ep *endPoint) String() string {
 		ep.host, ep.token, strings.Join(ep.apis, ","))
 }
 
+// failCacheDir get the fail-cache dir name of the endpoint.
+func (ep *endPoint) failCacheDir() string {
+	return fmt.Sprintf("fc-%x", md5.Sum([]byte(ep.scheme+"://"+ep.host+"?token="+ep.token))) //nolint:gosec
+}
+
 type endPointOption func(*endPoint)
 
 func withAPIs(arr []string) endPointOption {
@@ -302,18 +315,36 @@ func (ep *endPoint) writePointData(w *writer, b *body) error {
 		}
 	}()
 
-	l.Debugf("post %d bytes to %s...", len(b.buf()), requrl)
-	req, err := http.NewRequest("POST", requrl, bytes.NewBuffer(b.buf()))
+	payload, encoding := b.buf(), contentEncoding(b.gzon)
+	if b.gzon == gzipNotSet && w.gzip == gzipSet {
+		encoding = CompressionGZip
+	}
+
+	if encoding == CompressionZstd && ep.zstdUnsupported.Load() {
+		gz := getZipper()
+		defer putZipper(gz)
+
+		zbuf, err := zstdToGzip(gz, payload)
+		if err != nil {
+			l.Errorf("transcode zstd body to gzip: %s", err)
+			return err
+		}
+
+		payload, encoding = zbuf, CompressionGZip
+	}
+
+	l.Debugf("post %d bytes to %s...", len(payload), requrl)
+	req, err := http.NewRequest("POST", requrl, bytes.NewBuffer(payload))
 	if err != nil {
 		l.Error("new request to %s: %s", requrl, err)
 		return err
 	}
 
 	req.Header.Set("X-Points", fmt.Sprintf("%d", b.npts()))
-	req.Header.Set("Content-Length", fmt.Sprintf("%d", len(b.buf())))
+	req.Header.Set("Content-Length", fmt.Sprintf("%d", len(payload)))
 	req.Header.Set("Content-Type", b.enc().HTTPContentType())
-	if w.gzip == 1 {
-		req.Header.Set("Content-Encoding", "gzip")
+	if encoding != "" {
+		req.Header.Set("Content-Encoding", encoding)
 	}
 
 	// Common HTTP headers appended, such as User-Agent, X-Global-Tags
@@ -351,9 +382,15 @@ func (ep *endPoint) writePointData(w *writer, b *body) error {
 		return err
 	}
 
+	if resp.StatusCode == http.StatusUnsupportedMediaType && encoding == CompressionZstd {
+		l.Warnf("zstd not supported on %s, fallback to gzip", requrl)
+		ep.zstdUnsupported.Store(true)
+		return ep.writePointData(w, b)
+	}
+
 	switch resp.StatusCode / 100 {
 	case 2:
-		l.Debugf("post %d bytes to %s ok(gz: %v)", len(b.buf()), requrl, w.gzip)
+		l.Debugf("post %d bytes to %s ok(encoding: %q)", len(payload), requrl, encoding)
 
 		// Send data ok, it means the error `beyond-usage` error is cleared by kodo server,
 		// we have to clear the hint in monitor too.
@@ -7,6 +7,7 @@ package dataway
 
 import (
 	"bufio"
+	"compress/gzip"
 	"fmt"
 	"io"
 	"net/http"
@@ -320,6 +321,59 @@ test-2 f1=1i,f2=false 123
 	})
 }
 
+func TestEndpointZstdFallback(t *T.T) {
+	raw := []byte(`test-1 f1=1i,f2=false 123
+`)
+
+	var encodings []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encodings = append(encodings, r.Header.Get("Content-Encoding"))
+
+		if r.Header.Get("Content-Encoding") == CompressionZstd {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+
+		gz, err := gzip.NewReader(r.Body)
+		require.NoError(t, err)
+
+		body, err := io.ReadAll(gz)
+		require.NoError(t, err)
+		assert.Equal(t, raw, body)
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	ep, err := newEndpoint(fmt.Sprintf("%s?token=abc", ts.URL), withAPIs([]string{datakit.Metric}))
+	require.NoError(t, err)
+
+	w := getWriter(WithGzip(zstdSet), WithCategory(point.Metric))
+	defer putWriter(w)
+
+	zipper := &compressor{}
+	defer zipper.put()
+
+	for i := 0; i < 2; i++ {
+		zbuf, err := zipper.zip(zstdSet, raw)
+		require.NoError(t, err)
+
+		b := getNewBufferBody(withNewBuffer(1024))
+		b.CacheData.Payload = zbuf
+		b.CacheData.Category = int32(point.Metric)
+		b.CacheData.PayloadType = int32(point.LineProtocol)
+		b.CacheData.Pts = 1
+		b.gzon = zstdSet
+
+		assert.NoError(t, ep.writePointData(w, b))
+		putBody(b)
+	}
+
+	// the 415 only responded once, then always gzip
+	assert.True(t, ep.zstdUnsupported.Load())
+	assert.Equal(t, []string{CompressionZstd, CompressionGZip, CompressionGZip}, encodings)
+}
+
 func TestRetryGetBodyNil(t *T.T) {
 	bodyText := `观测云提供快速实现系统可观测的解决方案，满足云、云原生、应用和业务上的监测需求。
 通过自定义监测方案，实现实时可交互仪表板、高效观测基础设施、全链路应用性能可观测等功能，保障系统稳定性`
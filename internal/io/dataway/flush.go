@@ -9,10 +9,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/GuanceCloud/cliutils/point"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/datakit"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/ptrace"
 )
 
 type flusher struct {
@@ -46,6 +48,13 @@ func (dw *Dataway) StartFlushWorkers() error {
 		}
 	}
 
+	if dw.overflow != nil {
+		datakit.G("dw-overflow").Go(func(_ context.Context) error {
+			dw.overflow.run(datakit.Exit.Wait())
+			return nil
+		})
+	}
+
 	// start wal-queue flush workers on each category
 	for cat := range dw.walq {
 		n := dw.WAL.Workers
@@ -63,6 +72,10 @@ func (dw *Dataway) StartFlushWorkers() error {
 			n = 1
 		}
 
+		if n > 0 && dw.walq[cat].ordered {
+			n = 1 // multiple workers will break the order of the data
+		}
+
 		l.Infof("start %d flush workers on %s...", n, cat.Alias())
 		worker(cat, n)
 	}
@@ -114,6 +127,11 @@ func (f *flusher) start() {
 			}
 
 		default: // get from WAL queue(form chan or diskcache)
+			if f.wal.ordered {
+				f.orderedFlush()
+				continue
+			}
+
 			b, err := f.wal.Get(withReusableBuffer(f.sendBuf, f.marshalBuf))
 			if err != nil {
 				l.Warnf("Get() from wal-queue: %s, ignored", err)
@@ -132,15 +150,48 @@ func (f *flusher) start() {
 	}
 }
 
-func (f *flusher) do(b *body, opts ...WriteOption) error {
-	gzOn := gzipNotSet
-	if f.dw.GZip {
-		gzOn = gzipSet
+// orderedFlush send the head of the disk queue, the head is kept in the
+// disk queue until it's been sent, then the next Get() will retry it.
+func (f *flusher) orderedFlush() {
+	var (
+		got bool
+		err = f.wal.DiskGet(func(b *body) error {
+			got = true
+
+			var ( // @b will reset within f.do(), we cache it's meta for metric update.
+				cat  = b.cat()
+				npts = b.npts()
+			)
+
+			if err := f.do(b, WithNoFailCache(true)); err != nil {
+				return err
+			}
+
+			walPointCounterVec.WithLabelValues(cat.Alias(), "D").Add(float64(npts))
+			return nil
+		}, withReusableBuffer(f.sendBuf, f.marshalBuf))
+	)
+
+	switch {
+	case err != nil:
+		l.Warnf("ordered flush on %s: %s, retry after %s", f.cat.Alias(), err, f.dw.WAL.FailCacheCleanInterval)
+		sleepOrExit(f.dw.WAL.FailCacheCleanInterval)
+	case !got: // sleep when there is nothing to flush.
+		time.Sleep(time.Second)
 	}
+}
+
+func sleepOrExit(du time.Duration) {
+	select {
+	case <-datakit.Exit.Wait():
+	case <-time.After(du):
+	}
+}
 
+func (f *flusher) do(b *body, opts ...WriteOption) error {
 	w := getWriter(
 		WithHTTPEncoding(b.enc()),
-		WithGzip(gzOn),
+		WithGzip(f.dw.gzipFlag()),
 		// cache all data into fail-cache
 		WithCacheAll(true),
 		WithCategory(b.cat()),
@@ -167,22 +218,23 @@ func (dw *Dataway) doFlush(w *writer, b *body, opts ...WriteOption) error {
 		isGzip = "T" // fail-cache always gzipped before HTTP POST
 	}
 
-	if dw.GZip && !w.cacheClean { // under cacheClean, all body has been gzipped during previous POST
+	if flag := dw.gzipFlag(); flag != gzipNotSet && !w.cacheClean { // under cacheClean, all body has been gzipped during previous POST
 		var (
 			zstart = time.Now()
-			gz     = getZipper()
+			zipper = &compressor{}
 		)
 
 		isGzip = "T"
-		defer putZipper(gz)
+		defer zipper.put()
 
-		if zbuf, err := gz.zip(b.buf()); err != nil {
-			l.Errorf("gzip: %s", err.Error())
+		if zbuf, err := zipper.zip(flag, b.buf()); err != nil {
+			l.Errorf("%s: %s", contentEncoding(flag), err.Error())
 			return err
 		} else {
 			ncopy := copy(b.sendBuf, zbuf)
 			l.Debugf("copy %d(origin: %d) zipped bytes to buf", ncopy, len(b.buf()))
 			b.CacheData.Payload = b.sendBuf[:ncopy]
+			b.gzon = flag
 		}
 
 		buildBodyCostVec.WithLabelValues(
@@ -204,11 +256,20 @@ func (dw *Dataway) doFlush(w *writer, b *body, opts ...WriteOption) error {
 		putBody(b)
 	}()
 
-	for _, ep := range dw.eps {
+	eps := dw.categoryEndpoints(b.cat())
+	if w.ep != nil {
+		eps = []*endPoint{w.ep}
+	}
+
+	release := dw.gate.acquire(b.cat(), dw.WAL.priority(b.cat()))
+	defer release()
+
+	for _, ep := range eps {
 		if err := ep.writePointData(w, b); err != nil {
 			// 4xx error do not cache data.
 			if errors.Is(err, errWritePoints4XX) {
 				writeDropPointsCounterVec.WithLabelValues(w.category.String(), err.Error()).Add(float64(b.npts()))
+				traceBody(b, ptrace.StageUpload, fmt.Sprintf("dropped on %s: %s", ep.host, err))
 				continue // current endpoint POST 4xx ignored, but other endpoint maybe ok.
 			}
 
@@ -217,9 +278,16 @@ func (dw *Dataway) doFlush(w *writer, b *body, opts ...WriteOption) error {
 			// For a exist failed-cache, we do not need to re-cache it.
 			// and make it fail, the diskcache will rollback and Get() the same data again.
 			if w.cacheClean {
+				traceBody(b, ptrace.StageUpload, fmt.Sprintf("retry fail-cache on %s failed: %s", ep.host, err))
 				return fmt.Errorf("clean fail-cache failed: %w", err)
 			}
 
+			// Same as fail-cache, body on ordered WAL will rollback.
+			if w.noFailCache {
+				traceBody(b, ptrace.StageUpload, fmt.Sprintf("ordered flush on %s failed: %s", ep.host, err))
+				return fmt.Errorf("flush ordered WAL failed: %w", err)
+			}
+
 			//nolint:exhaustive
 			switch b.cat() {
 			case point.Metric, // these categories are not default cached.
@@ -231,33 +299,80 @@ func (dw *Dataway) doFlush(w *writer, b *body, opts ...WriteOption) error {
 				if !w.cacheAll {
 					writeDropPointsCounterVec.WithLabelValues(w.category.String(), err.Error()).Add(float64(b.npts()))
 					l.Warnf("drop %d pts on %s, not cached", b.npts, w.category)
+					traceBody(b, ptrace.StageUpload, fmt.Sprintf("dropped on %s(not cached): %s", ep.host, err))
 					continue
 				}
 
 			default: // other categories are default cached.
 			}
 
-			if err := dw.dumpFailCache(b); err != nil {
+			if err := dw.dumpFailCache(ep, b); err != nil {
 				l.Errorf("dumpFailCache %v pts on %s: %s", b.npts, w.category, err)
+				traceBody(b, ptrace.StageUpload, fmt.Sprintf("failed on %s and dropped, dump fail-cache: %s", ep.host, err))
 			} else {
 				l.Debugf("dumping %q to failcache ok", b)
+				traceBody(b, ptrace.StageUpload, fmt.Sprintf("failed on %s: %s, put to fail-cache", ep.host, err))
 			}
+		} else {
+			traceBody(b, ptrace.StageUpload, fmt.Sprintf("sent to %s", ep.host))
 		}
 	}
 
 	return nil
 }
 
-func (dw *Dataway) dumpFailCache(b *body) error {
+// traceBody record the event on the traced points within the body.
+func traceBody(b *body, stage, msg string) {
+	if ptrace.Enabled() {
+		ptrace.Batch(b.batchKey(), stage, msg)
+	}
+}
+
+func (dw *Dataway) dumpFailCache(ep *endPoint, b *body) error {
+	q := dw.walFail
+	if ep.failCache != nil {
+		q = ep.failCache
+	}
+
 	if x, err := b.dump(); err != nil {
 		return err
 	} else {
-		return dw.walFail.disk.Put(x) // directly put dumpped body to disk-queue, not mem-queue.
+		return dw.putCache(q.disk, b, x) // directly put dumpped body to disk-queue, not mem-queue.
 	}
 }
 
+// cleanFailCache retry the shared fail-cache on all routed endpoints, and
+// the fail-cache of each endpoint only on the endpoint, so one failed
+// endpoint do not block others.
 func (f *flusher) cleanFailCache() error {
-	return f.dw.walFail.DiskGet(func(b *body) error {
+	var errs []string
+	if err := f.doCleanFailCache(f.dw.walFail); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	done := map[*WALQueue]bool{}
+	for _, ep := range f.dw.eps {
+		if ep.failCache == nil || done[ep.failCache] {
+			continue
+		}
+
+		done[ep.failCache] = true
+		if err := f.doCleanFailCache(ep.failCache, withEndpoint(ep)); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", ep.host, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+func (f *flusher) doCleanFailCache(q *WALQueue, opts ...WriteOption) error {
+	opts = append(opts, WithCacheClean(true), WithHTTPHeader("X-Fail-Cache-Retry", "1"))
+
+	return q.DiskGet(func(b *body) error {
 		l.Debugf("clean body %q", b)
 
 		var ( // @b will reset within f.do(), we cache it's meta for metric update.
@@ -265,7 +380,7 @@ func (f *flusher) cleanFailCache() error {
 			size = len(b.buf())
 		)
 
-		if err := f.do(b, WithCacheClean(true), WithHTTPHeader("X-Fail-Cache-Retry", "1")); err != nil {
+		if err := f.do(b, opts...); err != nil {
 			return err
 		}
 
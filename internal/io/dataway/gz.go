@@ -7,17 +7,38 @@ package dataway
 
 import (
 	bytes "bytes"
+	"fmt"
 	"sync"
 
 	gzip "github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
 )
 
-var zippool sync.Pool
+const (
+	CompressionGZip = "gzip"
+	CompressionZstd = "zstd"
+)
+
+var (
+	zippool sync.Pool
+
+	// gzipLevel set on dataway init, before any zipper got.
+	gzipLevel = gzip.DefaultCompression
+
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+	zstdErr     error
+	zstdBufPool sync.Pool
+)
 
 func getZipper() *gzipWriter {
 	if x := zippool.Get(); x == nil {
 		buf := bytes.Buffer{}
-		w := gzip.NewWriter(&buf)
+		w, err := gzip.NewWriterLevel(&buf, gzipLevel)
+		if err != nil { // level checked on dataway init, should not been here
+			w = gzip.NewWriter(&buf)
+		}
 		return &gzipWriter{buf: &buf, w: w}
 	} else {
 		return x.(*gzipWriter)
@@ -62,7 +83,108 @@ func isGzip(data []byte) gzipFlag {
 	// See: https://stackoverflow.com/a/6059342/342348
 	if data[0] == 0x1f && data[1] == 0x8b {
 		return gzipSet
-	} else {
-		return gzipRaw
+	}
+
+	// zstd frame magic number 0xFD2FB528 in little-endian
+	if len(data) >= 4 && data[0] == 0x28 && data[1] == 0xb5 && data[2] == 0x2f && data[3] == 0xfd {
+		return zstdSet
+	}
+
+	return gzipRaw
+}
+
+func initZstd() error {
+	zstdOnce.Do(func() {
+		if zstdEncoder, zstdErr = zstd.NewWriter(nil); zstdErr != nil {
+			return
+		}
+
+		zstdDecoder, zstdErr = zstd.NewReader(nil)
+	})
+
+	return zstdErr
+}
+
+type zstdWriter struct {
+	buf []byte
+}
+
+func getZstdWriter() *zstdWriter {
+	if x := zstdBufPool.Get(); x != nil {
+		return x.(*zstdWriter)
+	}
+
+	return &zstdWriter{}
+}
+
+func putZstdWriter(z *zstdWriter) {
+	if z != nil {
+		z.buf = z.buf[:0]
+		zstdBufPool.Put(z)
+	}
+}
+
+func (z *zstdWriter) zip(data []byte) ([]byte, error) {
+	if err := initZstd(); err != nil {
+		return nil, err
+	}
+
+	z.buf = zstdEncoder.EncodeAll(data, z.buf[:0])
+	return z.buf, nil
+}
+
+// compressor compress the body payload with gzip or zstd.
+type compressor struct {
+	gz *gzipWriter
+	zw *zstdWriter
+}
+
+func (c *compressor) zip(flag gzipFlag, data []byte) ([]byte, error) {
+	switch flag { //nolint:exhaustive
+	case gzipSet:
+		if c.gz == nil {
+			c.gz = getZipper()
+		}
+		return c.gz.zip(data)
+
+	case zstdSet:
+		if c.zw == nil {
+			c.zw = getZstdWriter()
+		}
+		return c.zw.zip(data)
+
+	default:
+		return nil, fmt.Errorf("invalid compress flag %d", flag)
+	}
+}
+
+func (c *compressor) put() {
+	putZipper(c.gz)
+	putZstdWriter(c.zw)
+}
+
+// zstdToGzip transcode the zstd payload into gzip for endpoints not
+// supporting zstd, the returned buffer belongs to gz.
+func zstdToGzip(gz *gzipWriter, data []byte) ([]byte, error) {
+	if err := initZstd(); err != nil {
+		return nil, err
+	}
+
+	raw, err := zstdDecoder.DecodeAll(data, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return gz.zip(raw)
+}
+
+func contentEncoding(flag gzipFlag) string {
+	switch flag { //nolint:exhaustive
+	case gzipSet:
+		return CompressionGZip
+	case zstdSet:
+		return CompressionZstd
+	default:
+		return ""
 	}
 }
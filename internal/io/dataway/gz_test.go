@@ -336,3 +336,56 @@ func TestRatio(t *T.T) {
 		t.Logf("ratio: %.04f", float64(len(buf.Bytes()))/float64(len(arr[0])))
 	})
 }
+
+func TestCompressor(t *T.T) {
+	r := point.NewRander()
+	pts := r.Rand(100)
+
+	enc := point.GetEncoder(point.WithEncEncoding(point.Protobuf))
+	defer point.PutEncoder(enc)
+
+	arr, err := enc.Encode(pts)
+	assert.NoError(t, err)
+	raw := arr[0]
+
+	zipper := &compressor{}
+	defer zipper.put()
+
+	t.Run("zstd", func(t *T.T) {
+		zbuf, err := zipper.zip(zstdSet, raw)
+		assert.NoError(t, err)
+		assert.Equal(t, zstdSet, isGzip(zbuf))
+		assert.Equal(t, CompressionZstd, contentEncoding(zstdSet))
+
+		t.Logf("raw data: %d bytes, zstd: %d", len(raw), len(zbuf))
+
+		// transcode into gzip
+		gz := getZipper()
+		defer putZipper(gz)
+
+		gzBytes, err := zstdToGzip(gz, zbuf)
+		assert.NoError(t, err)
+		assert.Equal(t, gzipSet, isGzip(gzBytes))
+
+		gogz, err := gzip.NewReader(bytes.NewBuffer(gzBytes))
+		assert.NoError(t, err)
+
+		unzipBuf := bytes.NewBuffer(nil)
+		_, err = io.Copy(unzipBuf, gogz)
+		assert.NoError(t, err)
+		assert.Equal(t, raw, unzipBuf.Bytes())
+	})
+
+	t.Run("gzip", func(t *T.T) {
+		zbuf, err := zipper.zip(gzipSet, raw)
+		assert.NoError(t, err)
+		assert.Equal(t, gzipSet, isGzip(zbuf))
+		assert.Equal(t, CompressionGZip, contentEncoding(gzipSet))
+	})
+
+	t.Run("invalid", func(t *T.T) {
+		_, err := zipper.zip(gzipRaw, raw)
+		assert.Error(t, err)
+		assert.Equal(t, "", contentEncoding(gzipNotSet))
+	})
+}
@@ -21,6 +21,22 @@ var (
 		[]string{"category"},
 	)
 
+	priorityWaitVec = prometheus.NewSummaryVec(
+		prometheus.SummaryOpts{
+			Namespace: "datakit",
+			Subsystem: "io",
+			Name:      "dataway_priority_wait_seconds",
+			Help:      "Time waiting for the upload slot under max_inflight",
+
+			Objectives: map[float64]float64{
+				0.5:  0.05,
+				0.9:  0.01,
+				0.99: 0.001,
+			},
+		},
+		[]string{"category"},
+	)
+
 	walQueueMemLenVec = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: "datakit",
@@ -273,6 +289,7 @@ func Metrics() []prometheus.Collector {
 		groupedRequestVec,
 		flushFailCacheVec,
 		walQueueMemLenVec,
+		priorityWaitVec,
 	}
 }
 
@@ -289,6 +306,7 @@ func metricsReset() {
 	httpRetry.Reset()
 	flushFailCacheVec.Reset()
 	walQueueMemLenVec.Reset()
+	priorityWaitVec.Reset()
 	buildBodyCostVec.Reset()
 	buildBodyBatchBytesVec.Reset()
 	buildBodyBatchPointsVec.Reset()
@@ -310,6 +328,7 @@ func doRegister() {
 
 		flushFailCacheVec,
 		walQueueMemLenVec,
+		priorityWaitVec,
 		httpRetry,
 		buildBodyCostVec,
 		buildBodyBatchBytesVec,
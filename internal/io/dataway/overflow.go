@@ -0,0 +1,360 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package dataway
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/diskcache"
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+	gzip "github.com/klauspost/compress/gzip"
+
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/datakit"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/ptrace"
+)
+
+const (
+	defaultOverflowRegion  = "us-east-1"
+	defaultOverflowTimeout = 30 * time.Second
+	overflowObjectExt      = ".pb.gz"
+
+	// overflowQueueSize is the max bodies waiting to upload, the body
+	// dropped if the queue is full.
+	overflowQueueSize = 64
+)
+
+// OverflowConf is the S3-compatible object store(such as AWS S3 or MinIO)
+// that the bodies are uploaded to once the WAL or the fail-cache is full, so
+// no data dropped during long WAN outage. Uploaded bodies are replayed by
+// command `datakit tool --replay-overflow`.
+type OverflowConf struct {
+	// Endpoint of the object store, such as http://minio:9000, the bucket is
+	// accessed in path-style(endpoint/bucket/key).
+	Endpoint  string        `toml:"endpoint" json:"endpoint"`
+	Region    string        `toml:"region" json:"region"`
+	Bucket    string        `toml:"bucket" json:"bucket"`
+	Prefix    string        `toml:"prefix" json:"prefix"` // default datakit/<hostname>
+	AccessKey string        `toml:"access_key" json:"access_key"`
+	SecretKey string        `toml:"secret_key" json:"secret_key"`
+	Timeout   time.Duration `toml:"timeout" json:"timeout"`
+}
+
+type overflowBody struct {
+	cat    point.Category
+	npts   int32
+	dumped []byte
+}
+
+type overflowStore struct {
+	cfg    *OverflowConf
+	cli    *http.Client
+	signer *v4.Signer
+	seq    int64
+	queue  chan *overflowBody
+}
+
+func newOverflowStore(c *OverflowConf) (*overflowStore, error) {
+	if c.Bucket == "" {
+		return nil, fmt.Errorf("bucket of WAL overflow not set")
+	}
+
+	if _, err := url.Parse(c.Endpoint); err != nil {
+		return nil, fmt.Errorf("invalid WAL overflow endpoint %q: %w", c.Endpoint, err)
+	}
+
+	if c.Region == "" {
+		c.Region = defaultOverflowRegion
+	}
+
+	if c.Prefix == "" {
+		c.Prefix = "datakit/" + datakit.DatakitHostName
+	}
+	c.Prefix = strings.Trim(c.Prefix, "/")
+
+	if c.Timeout <= 0 {
+		c.Timeout = defaultOverflowTimeout
+	}
+
+	return &overflowStore{
+		cfg:   c,
+		cli:   &http.Client{Timeout: c.Timeout},
+		queue: make(chan *overflowBody, overflowQueueSize),
+		signer: v4.NewSigner(credentials.NewStaticCredentials(c.AccessKey, c.SecretKey, ""),
+			func(s *v4.Signer) {
+				s.DisableURIPathEscaping = true // required by S3
+			}),
+	}, nil
+}
+
+func (s *overflowStore) do(method, key string, query url.Values, data []byte) ([]byte, error) {
+	u := strings.TrimRight(s.cfg.Endpoint, "/") + "/" + s.cfg.Bucket + "/" + key
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	// the same seeker for the request and signing, so the Content-Length set
+	// and the body rewound after hashed.
+	body := bytes.NewReader(data)
+	req, err := http.NewRequest(method, u, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.signer.Sign(req, body, "s3", s.cfg.Region, time.Now()); err != nil {
+		return nil, fmt.Errorf("sign request: %w", err)
+	}
+
+	resp, err := s.cli.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	res, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("%s %s: %s: %s", method, key, resp.Status, res)
+	}
+
+	return res, nil
+}
+
+// objectKey of the body, the unix-nano time keeps objects listed in the
+// order they are uploaded.
+func (s *overflowStore) objectKey(cat point.Category) string {
+	return fmt.Sprintf("%s/%s/%019d-%d%s", s.cfg.Prefix, cat.String(),
+		time.Now().UnixNano(), atomic.AddInt64(&s.seq, 1), overflowObjectExt)
+}
+
+// put upload the dumped body in gzip.
+func (s *overflowStore) put(cat point.Category, dumped []byte) error {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(dumped); err != nil {
+		return err
+	}
+
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	_, err := s.do(http.MethodPut, s.objectKey(cat), nil, buf.Bytes())
+	return err
+}
+
+// enqueue the copy of the dumped body to upload, false if the queue is full.
+func (s *overflowStore) enqueue(cat point.Category, npts int32, dumped []byte) bool {
+	if len(s.queue) == cap(s.queue) {
+		return false
+	}
+
+	select {
+	case s.queue <- &overflowBody{cat: cat, npts: npts, dumped: append([]byte(nil), dumped...)}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *overflowStore) upload(x *overflowBody) {
+	if err := s.put(x.cat, x.dumped); err != nil {
+		l.Warnf("overflow %d %s points failed: %s, dropped", x.npts, x.cat, err)
+		walPointCounterVec.WithLabelValues(x.cat.Alias(), "overflow_failed").Add(float64(x.npts))
+		return
+	}
+
+	walPointCounterVec.WithLabelValues(x.cat.Alias(), "overflow").Add(float64(x.npts))
+}
+
+// run upload the queued bodies until exit.
+func (s *overflowStore) run(exit <-chan interface{}) {
+	for {
+		select {
+		case x := <-s.queue:
+			s.upload(x)
+		case <-exit:
+			if n := len(s.queue); n > 0 {
+				l.Warnf("WAL overflow exit, %d bodies not uploaded", n)
+			}
+			return
+		}
+	}
+}
+
+func (s *overflowStore) get(key string) ([]byte, error) {
+	data, err := s.do(http.MethodGet, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close() //nolint:errcheck
+
+	return io.ReadAll(zr)
+}
+
+func (s *overflowStore) delete(key string) error {
+	_, err := s.do(http.MethodDelete, key, nil, nil)
+	return err
+}
+
+type listBucketResult struct {
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+}
+
+// list all object keys under the prefix in lexicographical(uploaded) order.
+func (s *overflowStore) list() ([]string, error) {
+	var (
+		keys  []string
+		token string
+	)
+
+	for {
+		query := url.Values{
+			"list-type": []string{"2"},
+			"prefix":    []string{s.cfg.Prefix + "/"},
+		}
+
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		data, err := s.do(http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var res listBucketResult
+		if err := xml.Unmarshal(data, &res); err != nil {
+			return nil, fmt.Errorf("invalid list objects result: %w", err)
+		}
+
+		for _, c := range res.Contents {
+			if path.Ext(c.Key) == ".gz" {
+				keys = append(keys, c.Key)
+			}
+		}
+
+		if !res.IsTruncated || res.NextContinuationToken == "" {
+			return keys, nil
+		}
+
+		token = res.NextContinuationToken
+	}
+}
+
+func (dw *Dataway) setupOverflow() error {
+	if dw.WAL.Overflow == nil || dw.WAL.Overflow.Endpoint == "" {
+		return nil
+	}
+
+	s, err := newOverflowStore(dw.WAL.Overflow)
+	if err != nil {
+		return err
+	}
+
+	dw.overflow = s
+	l.Infof("WAL overflow to bucket %s on %s, prefix %s", s.cfg.Bucket, s.cfg.Endpoint, s.cfg.Prefix)
+
+	return nil
+}
+
+// putCache put the dumped body to the disk queue, the body is queued to
+// upload to the overflow store if the disk queue is full, so the flushing
+// is not blocked by the uploading.
+func (dw *Dataway) putCache(q Cache, b *body, x []byte) error {
+	err := q.Put(x)
+	if err == nil || dw.overflow == nil || !errors.Is(err, diskcache.ErrCacheFull) {
+		return err
+	}
+
+	if !dw.overflow.enqueue(b.cat(), b.npts(), x) {
+		return fmt.Errorf("WAL full and overflow queue full: %w", err)
+	}
+
+	traceBody(b, ptrace.StageWAL, "WAL full, queued to overflow")
+	return nil
+}
+
+// ReplayOverflow upload the bodies within the overflow store to the dataway
+// in the order they are uploaded(on each category), the replayed objects are
+// deleted. The replay stopped on the first failure, so the left objects can be
+// replayed later.
+func (dw *Dataway) ReplayOverflow(progress func(key string, size int)) (int, error) {
+	if dw.overflow == nil {
+		return 0, fmt.Errorf("WAL overflow not configured")
+	}
+
+	keys, err := dw.overflow.list()
+	if err != nil {
+		return 0, fmt.Errorf("list overflow objects: %w", err)
+	}
+
+	f := dw.newFlusher(point.UnknownCategory)
+
+	n := 0
+	for _, key := range keys {
+		data, err := dw.overflow.get(key)
+		if err != nil {
+			return n, fmt.Errorf("get %s: %w", key, err)
+		}
+
+		b := getReuseBufferBody(withReusableBuffer(f.sendBuf, f.marshalBuf))
+		if err := b.loadCache(data); err != nil {
+			putBody(b)
+			l.Warnf("invalid overflow object %s: %s, ignored", key, err)
+			continue
+		}
+
+		b.from = walFromDisk
+		b.gzon = isGzip(b.buf())
+		size := len(data)
+
+		// bodies from the fail-cache are compressed already, and the failed
+		// body should not cached again.
+		opt := WithNoFailCache(true)
+		if b.gzon != gzipRaw {
+			opt = WithCacheClean(true)
+		}
+
+		if err := f.do(b, opt, WithHTTPHeader("X-Overflow-Replay", "1")); err != nil {
+			return n, fmt.Errorf("replay %s: %w", key, err)
+		}
+
+		if err := dw.overflow.delete(key); err != nil {
+			return n, fmt.Errorf("delete %s: %w", key, err)
+		}
+
+		n++
+		if progress != nil {
+			progress(key, size)
+		}
+	}
+
+	return n, nil
+}
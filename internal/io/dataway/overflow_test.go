@@ -0,0 +1,266 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package dataway
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	T "testing"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/diskcache"
+	"github.com/GuanceCloud/cliutils/metrics"
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fullCache struct{}
+
+func (fullCache) BufGet([]byte, diskcache.Fn) error { return diskcache.ErrNoData }
+func (fullCache) Put([]byte) error                  { return diskcache.ErrCacheFull }
+func (fullCache) Size() int64                       { return 0 }
+func (fullCache) Close() error                      { return nil }
+
+// mockS3 is a S3-compatible bucket in memory, only 1 object listed per page.
+type mockS3 struct {
+	mtx       sync.Mutex
+	objects   map[string][]byte
+	authErr   string
+	noLength  int
+	putFailed bool
+}
+
+func (s *mockS3) count() int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return len(s.objects)
+}
+
+func (s *mockS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if auth := r.Header.Get("Authorization"); !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=ak/") ||
+		r.Header.Get("X-Amz-Content-Sha256") == "" {
+		s.authErr = auth
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	key := strings.TrimPrefix(r.URL.Path, "/bucket/")
+
+	switch r.Method {
+	case http.MethodPut:
+		if s.putFailed {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		data, _ := io.ReadAll(r.Body)
+		if r.ContentLength != int64(len(data)) {
+			s.noLength++
+		}
+		s.objects[key] = data
+
+	case http.MethodDelete:
+		delete(s.objects, key)
+
+	case http.MethodGet:
+		if key != "" {
+			data, ok := s.objects[key]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(data) //nolint:errcheck
+			return
+		}
+
+		var keys []string
+		for k := range s.objects {
+			if strings.HasPrefix(k, r.URL.Query().Get("prefix")) && k > r.URL.Query().Get("continuation-token") {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		res := listBucketResult{IsTruncated: len(keys) > 1}
+		if len(keys) > 0 {
+			res.Contents = append(res.Contents, struct {
+				Key string `xml:"Key"`
+			}{Key: keys[0]})
+			res.NextContinuationToken = keys[0]
+		}
+
+		data, _ := xml.Marshal(res)
+		w.Write(data) //nolint:errcheck
+	}
+}
+
+func TestOverflow(t *T.T) {
+	t.Cleanup(metricsReset)
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(Metrics()...)
+
+	s3 := &mockS3{objects: map[string][]byte{}}
+	s3Srv := httptest.NewServer(s3)
+	defer s3Srv.Close()
+
+	var (
+		mtx      sync.Mutex
+		fail     = true
+		received int
+		replayed int
+	)
+
+	dwSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mtx.Lock()
+		defer mtx.Unlock()
+
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		received++
+		if r.Header.Get("X-Overflow-Replay") == "1" {
+			replayed++
+		}
+	}))
+	defer dwSrv.Close()
+
+	dw := NewDefaultDataway()
+	dw.URLs = []string{fmt.Sprintf("%s?token=tkn_some", dwSrv.URL)}
+	dw.WAL.Path = t.TempDir()
+	dw.WAL.Overflow = &OverflowConf{
+		Endpoint:  s3Srv.URL,
+		Bucket:    "bucket",
+		Prefix:    "/test/",
+		AccessKey: "ak",
+		SecretKey: "sk",
+	}
+
+	require.NoError(t, dw.Init())
+	require.NoError(t, dw.setupWAL())
+	assert.Equal(t, "test", dw.WAL.Overflow.Prefix)
+
+	exit := make(chan interface{})
+	defer close(exit)
+	go dw.overflow.run(exit)
+
+	// WAL and fail-cache are full
+	cat := point.Logging
+	dw.walq[cat] = &WALQueue{disk: fullCache{}, dw: dw}
+	dw.walFail = &WALQueue{disk: fullCache{}, dw: dw}
+
+	for i := 0; i < 3; i++ {
+		w := getWriter(WithPoints(point.RandPoints(10)),
+			WithCategory(cat),
+			WithBodyCallback(dw.enqueueBody),
+			WithHTTPEncoding(dw.contentEncoding))
+		require.NoError(t, w.buildPointsBody())
+		putWriter(w)
+	}
+
+	// fail-cache full on dataway failure
+	f := dw.newFlusher(cat)
+	b := getReuseBufferBody(withReusableBuffer(f.sendBuf, f.marshalBuf))
+	b.CacheData.Payload = []byte("some data")
+	b.CacheData.Category = int32(cat)
+	b.CacheData.PayloadType = int32(point.Protobuf)
+	b.CacheData.Pts = 1
+	require.NoError(t, f.do(b))
+
+	require.Eventually(t, func() bool { return s3.count() == 4 }, 5*time.Second, 10*time.Millisecond)
+
+	s3.mtx.Lock()
+	assert.Empty(t, s3.authErr)
+	assert.Zero(t, s3.noLength)
+	assert.Len(t, s3.objects, 4)
+	for k := range s3.objects {
+		assert.True(t, strings.HasPrefix(k, "test/logging/"), k)
+	}
+	s3.mtx.Unlock()
+
+	// replay failed on dataway still down, nothing deleted
+	n, err := dw.ReplayOverflow(nil)
+	assert.Error(t, err)
+	assert.Equal(t, 0, n)
+	assert.Len(t, s3.objects, 4)
+
+	mtx.Lock()
+	fail = false
+	mtx.Unlock()
+
+	var keys []string
+	n, err = dw.ReplayOverflow(func(key string, size int) {
+		keys = append(keys, key)
+		assert.Greater(t, size, 0)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 4, n)
+	assert.True(t, sort.StringsAreSorted(keys))
+	assert.Empty(t, s3.objects)
+	assert.Equal(t, 4, received)
+	assert.Equal(t, 4, replayed)
+
+	t.Run("upload-failed", func(t *T.T) {
+		s3.mtx.Lock()
+		s3.putFailed = true
+		s3.mtx.Unlock()
+
+		b := &body{CacheData: CacheData{Category: int32(cat), Pts: 1}}
+		require.NoError(t, dw.putCache(fullCache{}, b, []byte("some data")))
+
+		require.Eventually(t, func() bool {
+			mfs, err := reg.Gather()
+			require.NoError(t, err)
+			m := metrics.GetMetricOnLabels(mfs, "datakit_io_wal_point_total", cat.Alias(), "overflow_failed")
+			return m != nil && m.GetCounter().GetValue() == 1
+		}, 5*time.Second, 10*time.Millisecond)
+
+		assert.Zero(t, s3.count())
+	})
+
+	t.Run("queue-full", func(t *T.T) {
+		s, err := newOverflowStore(&OverflowConf{Endpoint: s3Srv.URL, Bucket: "bucket"})
+		require.NoError(t, err)
+
+		data := []byte("some data")
+		for i := 0; i < overflowQueueSize; i++ {
+			require.True(t, s.enqueue(cat, 1, data))
+		}
+		assert.False(t, s.enqueue(cat, 1, data))
+
+		dw := &Dataway{overflow: s}
+		b := &body{CacheData: CacheData{Category: int32(cat), Pts: 1}}
+		assert.ErrorIs(t, dw.putCache(fullCache{}, b, data), diskcache.ErrCacheFull)
+
+		// the queued body is a copy
+		data[0] = 'x'
+		assert.Equal(t, "some data", string((<-s.queue).dumped))
+	})
+
+	t.Run("not-configured", func(t *T.T) {
+		dw := NewDefaultDataway()
+		require.NoError(t, dw.Init())
+
+		_, err := dw.ReplayOverflow(nil)
+		assert.Error(t, err)
+
+		dw.WAL.Overflow = &OverflowConf{Endpoint: s3Srv.URL}
+		assert.Error(t, dw.Init()) // bucket required
+	})
+}
@@ -0,0 +1,115 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package dataway
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+)
+
+// defaultPriority is the upload priority of the category, the small but
+// high-value categories are sent before the bulk ones(logging/tracing/...).
+func defaultPriority(cat point.Category) int {
+	switch cat { //nolint:exhaustive
+	case point.KeyEvent, point.Object, point.CustomObject:
+		return 2
+	case point.Metric, point.MetricDeprecated:
+		return 1
+	default:
+		return 0
+	}
+}
+
+type priorityWaiter struct {
+	prio int
+	seq  uint64
+	ch   chan struct{}
+}
+
+// priorityWaiters is a heap that higher priority first, and FIFO on the
+// same priority.
+type priorityWaiters []*priorityWaiter
+
+func (x priorityWaiters) Len() int { return len(x) }
+func (x priorityWaiters) Less(i, j int) bool {
+	if x[i].prio != x[j].prio {
+		return x[i].prio > x[j].prio
+	}
+	return x[i].seq < x[j].seq
+}
+func (x priorityWaiters) Swap(i, j int) { x[i], x[j] = x[j], x[i] }
+func (x *priorityWaiters) Push(v any)   { *x = append(*x, v.(*priorityWaiter)) }
+func (x *priorityWaiters) Pop() any {
+	old := *x
+	n := len(old)
+	v := old[n-1]
+	old[n-1] = nil
+	*x = old[:n-1]
+	return v
+}
+
+// priorityGate limit the concurrent uploading bodies of all categories. If
+// all slots are busy(the bandwidth is constrained), the free slot is granted
+// to the waiting body with the highest priority.
+type priorityGate struct {
+	mtx      sync.Mutex
+	max      int
+	inflight int
+	seq      uint64
+	waiters  priorityWaiters
+}
+
+func newPriorityGate(max int) *priorityGate {
+	if max <= 0 {
+		return nil
+	}
+
+	return &priorityGate{max: max}
+}
+
+// acquire wait for a upload slot, the returned release must be called after
+// the upload done.
+func (g *priorityGate) acquire(cat point.Category, prio int) (release func()) {
+	if g == nil {
+		return func() {}
+	}
+
+	start := time.Now()
+	defer func() {
+		priorityWaitVec.WithLabelValues(cat.Alias()).Observe(float64(time.Since(start)) / float64(time.Second))
+	}()
+
+	g.mtx.Lock()
+	if g.inflight < g.max && len(g.waiters) == 0 {
+		g.inflight++
+		g.mtx.Unlock()
+		return g.release
+	}
+
+	g.seq++
+	w := &priorityWaiter{prio: prio, seq: g.seq, ch: make(chan struct{})}
+	heap.Push(&g.waiters, w)
+	g.mtx.Unlock()
+
+	<-w.ch // the slot is transferred to us by release()
+	return g.release
+}
+
+func (g *priorityGate) release() {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+
+	if len(g.waiters) > 0 {
+		w := heap.Pop(&g.waiters).(*priorityWaiter)
+		close(w.ch)
+		return
+	}
+
+	g.inflight--
+}
@@ -0,0 +1,99 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package dataway
+
+import (
+	"sync"
+	T "testing"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPriorityGate(t *T.T) {
+	t.Run("preempt", func(t *T.T) {
+		g := newPriorityGate(1)
+
+		release := g.acquire(point.Logging, 0) // the backlog uploading
+
+		var (
+			mtx   sync.Mutex
+			order []point.Category
+			wg    sync.WaitGroup
+		)
+
+		for i, cat := range []point.Category{point.Logging, point.Logging, point.KeyEvent, point.Metric, point.Object} {
+			wg.Add(1)
+			go func(cat point.Category) {
+				defer wg.Done()
+				r := g.acquire(cat, defaultPriority(cat))
+
+				mtx.Lock()
+				order = append(order, cat)
+				mtx.Unlock()
+
+				r()
+			}(cat)
+
+			// wait the body queued, so the FIFO order on the same priority kept.
+			require.Eventually(t, func() bool {
+				g.mtx.Lock()
+				defer g.mtx.Unlock()
+				return len(g.waiters) == i+1
+			}, time.Second, time.Millisecond)
+		}
+
+		release()
+		wg.Wait()
+
+		assert.Equal(t, []point.Category{
+			point.KeyEvent,
+			point.Object,
+			point.Metric,
+			point.Logging,
+			point.Logging,
+		}, order)
+
+		assert.Equal(t, 0, g.inflight)
+		assert.Empty(t, g.waiters)
+	})
+
+	t.Run("no-wait-under-limit", func(t *T.T) {
+		g := newPriorityGate(2)
+		r1 := g.acquire(point.Logging, 0)
+		r2 := g.acquire(point.Logging, 0)
+		assert.Equal(t, 2, g.inflight)
+		r1()
+		r2()
+		assert.Equal(t, 0, g.inflight)
+	})
+
+	t.Run("nil", func(t *T.T) {
+		g := newPriorityGate(0)
+		assert.Nil(t, g)
+		g.acquire(point.Logging, 0)()
+	})
+}
+
+func TestWALPriority(t *T.T) {
+	c := &WALConf{
+		Categories: map[string]*WALCategoryConf{
+			"logging": {Priority: 3},
+			"object":  {MaxCapacityGB: 1},
+		},
+	}
+
+	assert.Equal(t, 3, c.priority(point.Logging))
+	assert.Equal(t, 2, c.priority(point.Object))
+	assert.Equal(t, 2, c.priority(point.KeyEvent))
+	assert.Equal(t, 1, c.priority(point.Metric))
+	assert.Equal(t, 0, c.priority(point.Tracing))
+
+	var nilConf *WALConf
+	assert.Equal(t, 1, nilConf.priority(point.Metric))
+}
@@ -0,0 +1,59 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package dataway
+
+import (
+	"fmt"
+
+	"github.com/GuanceCloud/cliutils/point"
+)
+
+// setupRoutes map the categories to the endpoints configured in Routes.
+func (dw *Dataway) setupRoutes() error {
+	dw.routes = map[point.Category][]*endPoint{}
+
+	for k, idxs := range dw.Routes {
+		cat := point.CatString(k)
+		if cat == point.UnknownCategory || cat == point.DynamicDWCategory {
+			return fmt.Errorf("invalid category %q on dataway routes", k)
+		}
+
+		if len(idxs) == 0 {
+			return fmt.Errorf("no URL routed on category %q", k)
+		}
+
+		var (
+			eps  []*endPoint
+			seen = map[int]bool{}
+		)
+
+		for _, idx := range idxs {
+			if idx < 0 || idx >= len(dw.eps) {
+				return fmt.Errorf("invalid URL index %d on category %q, only %d URLs configured", idx, k, len(dw.eps))
+			}
+
+			if !seen[idx] {
+				seen[idx] = true
+				eps = append(eps, dw.eps[idx])
+			}
+		}
+
+		l.Infof("route %s to %d URLs: %v", k, len(eps), idxs)
+		dw.routes[cat] = eps
+	}
+
+	return nil
+}
+
+// categoryEndpoints get the endpoints of the category, categories not routed
+// are sent to all endpoints.
+func (dw *Dataway) categoryEndpoints(cat point.Category) []*endPoint {
+	if eps, ok := dw.routes[cat]; ok {
+		return eps
+	}
+
+	return dw.eps
+}
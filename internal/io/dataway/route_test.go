@@ -0,0 +1,139 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package dataway
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	T "testing"
+
+	"github.com/GuanceCloud/cliutils/diskcache"
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetupRoutes(t *T.T) {
+	for name, routes := range map[string]map[string][]int{
+		"invalid-category": {"no-such-category": {0}},
+		"invalid-index":    {"logging": {2}},
+		"negative-index":   {"logging": {-1}},
+		"empty-index":      {"logging": {}},
+	} {
+		t.Run(name, func(t *T.T) {
+			dw := NewDefaultDataway()
+			dw.Routes = routes
+			assert.Error(t, dw.Init(WithURLs("http://host-1:9528?token=tkn_1", "http://host-2:9528?token=tkn_2")))
+		})
+	}
+
+	t.Run("ok", func(t *T.T) {
+		dw := NewDefaultDataway()
+		dw.Routes = map[string][]int{"logging": {1}, "metric": {0, 1, 1}}
+		require.NoError(t, dw.Init(WithURLs("http://host-1:9528?token=tkn_1", "http://host-2:9528?token=tkn_2")))
+
+		assert.Equal(t, []*endPoint{dw.eps[1]}, dw.categoryEndpoints(point.Logging))
+		assert.Equal(t, []*endPoint{dw.eps[0], dw.eps[1]}, dw.categoryEndpoints(point.Metric))
+		assert.Equal(t, dw.eps, dw.categoryEndpoints(point.Object))
+	})
+}
+
+type routeServer struct {
+	*httptest.Server
+
+	mtx  sync.Mutex
+	fail atomic.Bool
+	hits map[string]int
+}
+
+func newRouteServer() *routeServer {
+	s := &routeServer{hits: map[string]int{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mtx.Lock()
+		s.hits[r.URL.Path]++
+		s.mtx.Unlock()
+
+		if s.fail.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	return s
+}
+
+func (s *routeServer) hit(cat point.Category) int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.hits[cat.URL()]
+}
+
+func TestRouteFanOut(t *T.T) {
+	s1, s2 := newRouteServer(), newRouteServer()
+	defer s1.Close()
+	defer s2.Close()
+
+	s2.fail.Store(true)
+
+	dw := NewDefaultDataway()
+	dw.WAL.Path = t.TempDir()
+	dw.MaxRetryCount = 1
+	dw.Routes = map[string][]int{"logging": {0}, "metric": {0, 1}}
+
+	require.NoError(t, dw.Init(WithURLs(s1.URL, s2.URL)))
+	require.NoError(t, dw.setupWAL())
+	require.NotNil(t, dw.eps[0].failCache)
+	require.NotNil(t, dw.eps[1].failCache)
+
+	write := func(cat point.Category) {
+		w := getWriter(WithPoints(point.RandPoints(10)),
+			WithCategory(cat),
+			WithCacheAll(true),
+			WithBodyCallback(func(w *writer, b *body) error {
+				return dw.doFlush(w, b)
+			}),
+			WithHTTPEncoding(dw.contentEncoding))
+		defer putWriter(w)
+
+		require.NoError(t, w.buildPointsBody())
+	}
+
+	write(point.Logging)
+	write(point.Metric)
+
+	assert.Equal(t, 1, s1.hit(point.Logging))
+	assert.Equal(t, 1, s1.hit(point.Metric))
+	assert.Equal(t, 0, s2.hit(point.Logging))
+	assert.Equal(t, 1, s2.hit(point.Metric))
+
+	size := func(q *WALQueue) int64 {
+		dc := q.disk.(*diskcache.DiskCache)
+		require.NoError(t, dc.Rotate())
+		return dc.Size()
+	}
+
+	// only the failed endpoint cached the body
+	assert.Zero(t, size(dw.walFail))
+	assert.Zero(t, size(dw.eps[0].failCache))
+	assert.NotZero(t, size(dw.eps[1].failCache))
+
+	f := dw.newFlusher(point.Metric)
+
+	assert.Error(t, f.cleanFailCache()) // still fail on s2
+	assert.Equal(t, 2, s2.hit(point.Metric))
+
+	s2.fail.Store(false)
+	assert.NoError(t, f.cleanFailCache())
+	assert.Equal(t, 3, s2.hit(point.Metric))
+	assert.Equal(t, 1, s1.hit(point.Metric)) // not retried on succeeded endpoint
+
+	// fail-cache cleaned
+	assert.NoError(t, f.cleanFailCache())
+	assert.Equal(t, 3, s2.hit(point.Metric))
+}
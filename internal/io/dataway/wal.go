@@ -7,11 +7,14 @@ package dataway
 
 import (
 	"errors"
+	"fmt"
 	"path/filepath"
 	"time"
 
 	"github.com/GuanceCloud/cliutils/diskcache"
 	"github.com/GuanceCloud/cliutils/point"
+
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/ptrace"
 )
 
 var defaultRotateAt = 3 * time.Second
@@ -26,18 +29,118 @@ type Cache interface {
 	Close() error
 }
 
+const (
+	// WALDropNew drop the new-coming data when the disk queue is full, the default policy.
+	WALDropNew = "drop_new"
+	// WALDropOld drop the oldest data file when the disk queue is full.
+	WALDropOld = "drop_old"
+)
+
 type WALConf struct {
 	MaxCapacityGB          float64       `toml:"max_capacity_gb"`
 	Workers                int           `toml:"workers"`
 	MemCap                 int           `toml:"mem_cap,omitempty"`
 	Path                   string        `toml:"path,omitempty"`
 	FailCacheCleanInterval time.Duration `toml:"fail_cache_clean_interval"`
+	DropPolicy             string        `toml:"drop_policy,omitempty"`
+
+	// Categories overwrite the capacity and the drop policy on the category, the
+	// key is the category name, such as logging/metric/tracing.
+	Categories map[string]*WALCategoryConf `toml:"categories,omitempty"`
+
+	// Overflow upload bodies to the object store once the disk queue is full.
+	Overflow *OverflowConf `toml:"overflow,omitempty"`
+
+	// MaxInflight limit the concurrent uploading bodies of all categories, the
+	// waiting bodies are sent by the priority of their category. 0 means no limit.
+	MaxInflight int `toml:"max_inflight,omitempty"`
+}
+
+// WALCategoryConf is the WAL configure on specific category.
+type WALCategoryConf struct {
+	MaxCapacityGB float64 `toml:"max_capacity_gb" json:"max_capacity_gb"`
+	DropPolicy    string  `toml:"drop_policy,omitempty" json:"drop_policy"`
+
+	// Ordered disable the mem-queue and the fail-cache on the category: all
+	// bodies are dumped to disk, and the flush worker retry the head of the
+	// disk queue until it's been sent, so the data replayed in the same order
+	// as they are fed after the dataway recovered.
+	Ordered bool `toml:"ordered,omitempty" json:"ordered"`
+
+	// Priority overwrite the upload priority of the category under
+	// max_inflight, higher priority sent first.
+	Priority int `toml:"priority,omitempty" json:"priority"`
+}
+
+// categoryConf get capacity(in bytes), drop policy and ordered-or-not on the category.
+func (c *WALConf) categoryConf(cat point.Category) (capacity int64, filoDrop, ordered bool) {
+	capGB, policy := c.MaxCapacityGB, c.DropPolicy
+
+	if cc, ok := c.Categories[cat.String()]; ok && cc != nil {
+		if cc.MaxCapacityGB > 0 {
+			capGB = cc.MaxCapacityGB
+		}
+
+		if cc.DropPolicy != "" {
+			policy = cc.DropPolicy
+		}
+
+		ordered = cc.Ordered
+	}
+
+	return int64(capGB * float64(1<<30)), policy != WALDropOld, ordered
+}
+
+// priority get the upload priority of the category.
+func (c *WALConf) priority(cat point.Category) int {
+	if c == nil {
+		return defaultPriority(cat)
+	}
+
+	if cc, ok := c.Categories[cat.String()]; ok && cc != nil && cc.Priority != 0 {
+		return cc.Priority
+	}
+
+	return defaultPriority(cat)
+}
+
+func (c *WALConf) check() error {
+	if err := checkDropPolicy(c.DropPolicy); err != nil {
+		return err
+	}
+
+	for k, cc := range c.Categories {
+		if point.CatString(k) == point.UnknownCategory {
+			return fmt.Errorf("invalid WAL category %q", k)
+		}
+
+		if cc == nil {
+			continue
+		}
+
+		if err := checkDropPolicy(cc.DropPolicy); err != nil {
+			return fmt.Errorf("WAL category %q: %w", k, err)
+		}
+	}
+
+	return nil
+}
+
+func checkDropPolicy(p string) error {
+	switch p {
+	case "", WALDropNew, WALDropOld:
+		return nil
+	default:
+		return fmt.Errorf("invalid WAL drop policy %q, expect %s or %s", p, WALDropNew, WALDropOld)
+	}
 }
 
 type WALQueue struct {
 	disk Cache
 	mem  chan *body
 	dw   *Dataway // back-ref to dataway configures
+
+	ordered bool
 }
 
 func NewWAL(dw *Dataway, c Cache) *WALQueue {
@@ -68,6 +171,7 @@ func (q *WALQueue) Put(b *body) error {
 	select {
 	case q.mem <- b: // @b will reuse by flush worker
 		walPointCounterVec.WithLabelValues(b.cat().Alias(), "M").Add(float64(b.npts()))
+		traceBody(b, ptrace.StageWAL, "put to mem queue")
 		return nil
 	default: // pass: put b into disk WAL
 	}
@@ -85,10 +189,13 @@ func (q *WALQueue) Put(b *body) error {
 
 	if x, err := b.dump(); err != nil {
 		putStatus = "drop"
+		traceBody(b, ptrace.StageWAL, fmt.Sprintf("dropped: %s", err))
 		return err
 	} else {
-		if err := q.disk.Put(x); err != nil {
+		traceBody(b, ptrace.StageWAL, "put to disk queue")
+		if err := q.dw.putCache(q.disk, b, x); err != nil {
 			putStatus = "drop"
+			traceBody(b, ptrace.StageWAL, fmt.Sprintf("dropped: %s", err))
 			return err
 		} else {
 			// NOTE: do not set putStatus here, we'll update walPointCounterVec during Get().
@@ -169,10 +276,29 @@ func (q *WALQueue) DiskGet(fn walBodyCallback, opts ...bodyOpt) error {
 
 		b.from = walFromDisk
 		b.gzon = isGzip(b.buf())
+
+		// @b will reset within fn(), we cache it's meta for ack.
+		var (
+			key  = b.batchKey()
+			cat  = b.cat()
+			npts = b.npts()
+		)
+
+		if q.dw.acks.acked(key) { // sent before datakit restarted
+			l.Infof("body %s on %s sent before, skipped", key, cat.Alias())
+			walPointCounterVec.WithLabelValues(cat.Alias(), "skip").Add(float64(npts))
+			ptrace.Batch(key, ptrace.StageUpload, "sent before restart, skipped")
+			putBody(b)
+			return nil
+		}
+
 		if err := fn(b); err != nil {
 			l.Warnf("walBodyCallback: %s, we try again, ignored", err)
 			return err
 		} else {
+			if err := q.dw.acks.ack(key); err != nil {
+				l.Warnf("ack body %s: %s, ignored", key, err)
+			}
 			return nil
 		}
 	}); err != nil {
@@ -186,37 +312,87 @@ func (q *WALQueue) DiskGet(fn walBodyCallback, opts ...bodyOpt) error {
 	return nil
 }
 
-func (dw *Dataway) doSetupWAL(cacheDir string) (*WALQueue, error) {
+func (dw *Dataway) doSetupWAL(cacheDir string, capacity int64, filoDrop bool) (Cache, error) {
 	dc, err := diskcache.Open(
 		diskcache.WithPath(cacheDir),
 		diskcache.WithNoLock(true),            // disable .lock file checking
-		diskcache.WithFILODrop(true),          // drop new data if cache full, no matter normal WAL or fail-cache WAL.
+		diskcache.WithFILODrop(filoDrop),      // drop new data if cache full by default, no matter normal WAL or fail-cache WAL.
 		diskcache.WithWakeup(defaultRotateAt), // short wakeup on wal queue
-		diskcache.WithCapacity(int64(dw.WAL.MaxCapacityGB*float64(1<<30))),
+		diskcache.WithCapacity(capacity),
 	)
 	if err != nil {
-		l.Errorf("NewWALCache %s with capacity %f GB: %s", cacheDir, dw.WAL.MaxCapacityGB, err.Error())
+		l.Errorf("NewWALCache %s with capacity %d bytes: %s", cacheDir, capacity, err.Error())
 		return nil, err
 	}
 
-	l.Infof("diskcache.New ok(%q) of %fGiB", cacheDir, dw.WAL.MaxCapacityGB)
+	l.Infof("diskcache.New ok(%q) of %fGiB, FILO drop: %v", cacheDir, float64(capacity)/float64(1<<30), filoDrop)
 
-	return NewWAL(dw, dc), nil
+	return dc, nil
 }
 
 func (dw *Dataway) setupWAL() error {
+	if err := dw.WAL.check(); err != nil {
+		return err
+	}
+
 	for _, cat := range point.AllCategories() {
-		if wal, err := dw.doSetupWAL(filepath.Join(dw.WAL.Path, cat.String())); err != nil {
+		capacity, filoDrop, ordered := dw.WAL.categoryConf(cat)
+
+		dc, err := dw.doSetupWAL(filepath.Join(dw.WAL.Path, cat.String()), capacity, filoDrop)
+		if err != nil {
 			return err
+		}
+
+		if ordered {
+			l.Infof("WAL on %s is ordered, mem-queue and fail-cache disabled", cat.Alias())
+			dw.walq[cat] = &WALQueue{disk: dc, dw: dw, ordered: true} // no mem-queue under ordered WAL
 		} else {
-			dw.walq[cat] = wal
+			dw.walq[cat] = NewWAL(dw, dc)
 		}
 	}
 
-	if wal, err := dw.doSetupWAL(filepath.Join(dw.WAL.Path, "fc")); err != nil {
+	dw.gate = newPriorityGate(dw.WAL.MaxInflight)
+
+	if acks, err := openAckLog(filepath.Join(dw.WAL.Path, ackLogFile), defaultAckLogKeys); err != nil {
+		return err
+	} else {
+		dw.acks = acks
+	}
+
+	capacity, filoDrop, _ := dw.WAL.categoryConf(point.UnknownCategory)
+	if dc, err := dw.doSetupWAL(filepath.Join(dw.WAL.Path, "fc"), capacity, filoDrop); err != nil {
 		return err
 	} else {
-		dw.walFail = wal
+		dw.walFail = NewWAL(dw, dc)
+	}
+
+	if len(dw.eps) > 1 { // independent fail-cache on each endpoint
+		dirs := map[string]bool{}
+		for _, ep := range dw.eps {
+			dirs[ep.failCacheDir()] = true
+		}
+
+		// the configured capacity is split across the endpoints, so the
+		// disk usage not grows with the URLs
+		capacity /= int64(len(dirs))
+
+		queues := map[string]*WALQueue{}
+		for _, ep := range dw.eps {
+			dir := ep.failCacheDir()
+			if q, ok := queues[dir]; ok { // same URL configured more than once
+				ep.failCache = q
+				continue
+			}
+
+			dc, err := dw.doSetupWAL(filepath.Join(dw.WAL.Path, dir), capacity, filoDrop)
+			if err != nil {
+				return err
+			}
+
+			ep.failCache = NewWAL(dw, dc)
+			queues[dir] = ep.failCache
+		}
 	}
+
 	return nil
 }
@@ -6,9 +6,17 @@
 package dataway
 
 import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
 	T "testing"
 	"time"
 
+	uhttp "github.com/GuanceCloud/cliutils/network/http"
+
 	"github.com/GuanceCloud/cliutils/diskcache"
 	"github.com/GuanceCloud/cliutils/point"
 	"github.com/stretchr/testify/assert"
@@ -174,3 +182,201 @@ func TestWALLoad(t *T.T) {
 		assert.NoError(t, err)
 	})
 }
+
+func TestWALCategoryConf(t *T.T) {
+	t.Run(`default`, func(t *T.T) {
+		c := &WALConf{MaxCapacityGB: 2.0}
+		capacity, filoDrop, ordered := c.categoryConf(point.Logging)
+		assert.Equal(t, int64(2<<30), capacity)
+		assert.True(t, filoDrop)
+		assert.False(t, ordered)
+		assert.NoError(t, c.check())
+	})
+
+	t.Run(`overwrite`, func(t *T.T) {
+		c := &WALConf{
+			MaxCapacityGB: 2.0,
+			DropPolicy:    WALDropOld,
+			Categories: map[string]*WALCategoryConf{
+				"logging": {MaxCapacityGB: 0.5, DropPolicy: WALDropNew, Ordered: true},
+				"metric":  {},
+			},
+		}
+		assert.NoError(t, c.check())
+
+		capacity, filoDrop, ordered := c.categoryConf(point.Logging)
+		assert.Equal(t, int64(1<<29), capacity)
+		assert.True(t, filoDrop)
+		assert.True(t, ordered)
+
+		capacity, filoDrop, ordered = c.categoryConf(point.Metric)
+		assert.Equal(t, int64(2<<30), capacity)
+		assert.False(t, filoDrop)
+		assert.False(t, ordered)
+	})
+
+	t.Run(`invalid`, func(t *T.T) {
+		assert.Error(t, (&WALConf{DropPolicy: "drop_all"}).check())
+		assert.Error(t, (&WALConf{Categories: map[string]*WALCategoryConf{"no-such-category": {}}}).check())
+		assert.Error(t, (&WALConf{Categories: map[string]*WALCategoryConf{"logging": {DropPolicy: "drop_all"}}}).check())
+	})
+}
+
+func TestOrderedWAL(t *T.T) {
+	var (
+		mtx      sync.Mutex
+		failures = 2
+		got      []string
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mtx.Lock()
+		defer mtx.Unlock()
+
+		if failures > 0 { // dataway outage
+			failures--
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			body, err = uhttp.Unzip(body)
+			require.NoError(t, err)
+		}
+
+		dec := point.GetDecoder(point.WithDecEncoding(point.HTTPContentType(r.Header.Get("Content-Type"))))
+		defer point.PutDecoder(dec)
+
+		pts, err := dec.Decode(body)
+		require.NoError(t, err)
+		for _, pt := range pts {
+			got = append(got, pt.Name())
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	dw := NewDefaultDataway()
+	dw.WAL.Path = t.TempDir()
+	dw.WAL.FailCacheCleanInterval = 10 * time.Millisecond
+	dw.WAL.Categories = map[string]*WALCategoryConf{"logging": {Ordered: true}}
+	dw.RetryDelay = 10 * time.Millisecond
+
+	require.NoError(t, dw.Init(WithURLs(fmt.Sprintf("%s?token=tkn_xxxxxxxxxxxxxxxxxxx", ts.URL))))
+	require.NoError(t, dw.setupWAL())
+
+	cat := point.Logging
+	q := dw.walq[cat]
+	require.True(t, q.ordered)
+	assert.Nil(t, q.mem)
+
+	var names []string
+	for i := 0; i < 3; i++ {
+		name := fmt.Sprintf("m%d", i)
+		names = append(names, name)
+
+		pt := point.NewPointV2(name, point.NewKVs(map[string]any{"f": i}), point.WithTime(time.Now()))
+		w := getWriter(WithPoints([]*point.Point{pt}),
+			WithCategory(cat),
+			WithBodyCallback(dw.enqueueBody),
+			WithHTTPEncoding(dw.contentEncoding))
+		require.NoError(t, w.buildPointsBody())
+		putWriter(w)
+	}
+
+	assert.NoError(t, q.disk.(*diskcache.DiskCache).Rotate()) // force rotate
+
+	f := dw.newFlusher(cat)
+	for i := 0; i < 10; i++ {
+		f.orderedFlush()
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+
+	assert.Equal(t, names, got)
+	assert.Zero(t, dw.walFail.disk.Size(), "ordered WAL should not use fail-cache")
+}
+
+func TestReplayProtection(t *T.T) {
+	var (
+		mtx sync.Mutex
+		ids []string
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		ids = append(ids, r.Header.Get(HeaderBatchID)+":"+r.Header.Get(HeaderBatchSeq))
+	}))
+	defer ts.Close()
+
+	walPath := t.TempDir()
+	cat := point.Logging
+
+	newDW := func() *Dataway {
+		dw := NewDefaultDataway()
+		dw.URLs = []string{fmt.Sprintf("%s?token=tkn_some", ts.URL)}
+		dw.WAL.Path = walPath
+		dw.WAL.Categories = map[string]*WALCategoryConf{"logging": {Ordered: true}}
+
+		require.NoError(t, dw.Init())
+		require.NoError(t, dw.setupWAL())
+		return dw
+	}
+
+	dw := newDW()
+
+	// keep the dumped body, so we can put it again as if the disk queue
+	// position not updated before datakit crashed.
+	var raw []byte
+	w := getWriter(WithPoints(point.RandPoints(10)),
+		WithCategory(cat),
+		WithHTTPEncoding(dw.contentEncoding),
+		WithBodyCallback(func(w *writer, b *body) error {
+			x, err := b.dump()
+			require.NoError(t, err)
+			raw = append([]byte{}, x...)
+			return dw.enqueueBody(w, b)
+		}))
+	require.NoError(t, w.buildPointsBody())
+	putWriter(w)
+
+	flush := func(dw *Dataway) {
+		dc := dw.walq[cat].disk.(*diskcache.DiskCache)
+		require.NoError(t, dc.Rotate())
+		dw.newFlusher(cat).orderedFlush()
+	}
+
+	flush(dw)
+	require.Len(t, ids, 1)
+	assert.True(t, strings.HasSuffix(ids[0], ":0"))
+	assert.Len(t, ids[0], 36+2) // UUID + ":0"
+
+	// the same body replayed
+	require.NoError(t, dw.walq[cat].disk.Put(raw))
+	flush(dw)
+	assert.Len(t, ids, 1)
+
+	// restarted
+	dw = newDW()
+	require.NoError(t, dw.walq[cat].disk.Put(raw))
+	flush(dw)
+	assert.Len(t, ids, 1)
+
+	// bodies of new batch are sent
+	w = getWriter(WithPoints(point.RandPoints(10)),
+		WithCategory(cat),
+		WithHTTPEncoding(dw.contentEncoding),
+		WithBodyCallback(dw.enqueueBody))
+	require.NoError(t, w.buildPointsBody())
+	putWriter(w)
+
+	flush(dw)
+	require.Len(t, ids, 2)
+	assert.NotEqual(t, ids[0], ids[1])
+}
@@ -49,6 +49,14 @@ func WithCacheClean(on bool) WriteOption {
 	}
 }
 
+// WithNoFailCache disable dump body to fail-cache on sending failure, and
+// the failure returned to caller.
+func WithNoFailCache(on bool) WriteOption {
+	return func(w *writer) {
+		w.noFailCache = on
+	}
+}
+
 func WithGzip(on gzipFlag) WriteOption {
 	return func(w *writer) {
 		w.gzip = on
@@ -93,9 +101,17 @@ func WithNoWAL(on bool) WriteOption {
 	}
 }
 
+// withEndpoint only send the body to the endpoint.
+func withEndpoint(ep *endPoint) WriteOption {
+	return func(w *writer) {
+		w.ep = ep
+	}
+}
+
 type writer struct {
 	category   point.Category
 	dynamicURL string
+	ep         *endPoint
 
 	points []*point.Point
 
@@ -108,6 +124,7 @@ type writer struct {
 	gzip gzipFlag
 	cacheClean,
 	cacheAll,
+	noFailCache,
 	noWAL,
 	gzipDuringBuildBody bool
 
@@ -119,10 +136,12 @@ type writer struct {
 func (w *writer) reset() {
 	w.category = point.UnknownCategory
 	w.dynamicURL = ""
+	w.ep = nil
 	w.points = w.points[:0]
 	w.gzip = gzipNotSet
 	w.cacheClean = false
 	w.cacheAll = false
+	w.noFailCache = false
 	w.noWAL = false
 	w.gzipDuringBuildBody = false
 	w.batchBytesSize = defaultBatchSize
@@ -161,16 +180,11 @@ func (dw *Dataway) groupPoints(ptg *ptGrouper,
 }
 
 func (dw *Dataway) Write(opts ...WriteOption) error {
-	gzOn := gzipNotSet
-	if dw.GZip {
-		gzOn = gzipSet
-	}
-
 	w := getWriter(
 		// set content encoding(protobuf/line-protocol/json)
 		WithHTTPEncoding(dw.contentEncoding),
-		// setup gzip on or off
-		WithGzip(gzOn),
+		// setup gzip(or zstd) on or off
+		WithGzip(dw.gzipFlag()),
 		// set raw body size limit
 		WithMaxBodyCap(dw.MaxRawBodySize),
 	)
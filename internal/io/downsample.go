@@ -0,0 +1,327 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package io
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/datakit"
+)
+
+const (
+	DownsampleLast = "last"
+	DownsampleMean = "mean"
+	DownsampleMax  = "max"
+	DownsampleNone = "none" // disable the global downsample on the input
+)
+
+// DownsampleRule merge all metric points of the same time series(measurement
+// and tags) within the window into single point.
+type DownsampleRule struct {
+	Window time.Duration `toml:"window"`
+	Method string        `toml:"method"` // last/mean/max, default last
+}
+
+func (r *DownsampleRule) enabled() bool {
+	return r != nil && r.Window > 0 && r.Method != DownsampleNone
+}
+
+// DownsampleConf configure the downsample on metric points, the global rule
+// applied on all inputs, and the rule on input name overwrite the global rule.
+type DownsampleConf struct {
+	DownsampleRule
+
+	Inputs map[string]*DownsampleRule `toml:"inputs"`
+}
+
+func checkDownsampleMethod(m string) error {
+	switch m {
+	case "", DownsampleLast, DownsampleMean, DownsampleMax, DownsampleNone:
+		return nil
+	default:
+		return fmt.Errorf("invalid downsample method %q", m)
+	}
+}
+
+type dsField struct {
+	last, max any
+	maxf, sum float64
+	count     int
+	numeric   bool
+	mixed     bool // values of different types, the mean is float
+}
+
+func sameType(a, b any) bool {
+	switch a.(type) {
+	case int64:
+		_, ok := b.(int64)
+		return ok
+	case uint64:
+		_, ok := b.(uint64)
+		return ok
+	case float64:
+		_, ok := b.(float64)
+		return ok
+	default:
+		return false
+	}
+}
+
+func (f *dsField) add(v any) {
+	if f.count > 0 && !sameType(f.last, v) {
+		f.mixed = true
+	}
+	f.last = v
+
+	var x float64
+	switch n := v.(type) {
+	case int64:
+		x = float64(n)
+	case uint64:
+		x = float64(n)
+	case float64:
+		x = n
+	default: // non-numeric fields always keep the last value
+		f.numeric = false
+		return
+	}
+
+	if f.count == 0 || x > f.maxf {
+		f.maxf, f.max = x, v
+	}
+	f.sum += x
+	f.count++
+}
+
+func (f *dsField) value(method string) any {
+	if !f.numeric {
+		return f.last
+	}
+
+	switch method {
+	case DownsampleMean:
+		mean := f.sum / float64(f.count)
+		if f.mixed {
+			return mean
+		}
+
+		// keep the type of the field, integers are rounded
+		switch f.last.(type) {
+		case int64:
+			return int64(math.Round(mean))
+		case uint64:
+			return uint64(math.Round(mean))
+		default:
+			return mean
+		}
+	case DownsampleMax:
+		return f.max
+	default:
+		return f.last
+	}
+}
+
+type dsSeries struct {
+	input,
+	name,
+	method string
+	tags point.KVs
+
+	keys   []string // keep the order of the fields
+	fields map[string]*dsField
+
+	ptTime,
+	due time.Time
+}
+
+func (s *dsSeries) add(pt *point.Point) {
+	for _, kv := range pt.Fields() {
+		f, ok := s.fields[kv.Key]
+		if !ok {
+			f = &dsField{numeric: true}
+			s.fields[kv.Key] = f
+			s.keys = append(s.keys, kv.Key)
+		}
+		f.add(kv.Raw())
+	}
+
+	if t := pt.Time(); t.After(s.ptTime) {
+		s.ptTime = t
+	}
+}
+
+func (s *dsSeries) point() *point.Point {
+	kvs := make(point.KVs, 0, len(s.tags)+len(s.keys))
+	kvs = append(kvs, s.tags...)
+
+	for _, k := range s.keys {
+		kvs = kvs.Add(k, s.fields[k].value(s.method), false, false)
+	}
+
+	return point.NewPointV2(s.name, kvs, append(point.DefaultMetricOptions(), point.WithTime(s.ptTime))...)
+}
+
+type downsampler struct {
+	mtx sync.Mutex
+
+	global *DownsampleRule
+	inputs map[string]*DownsampleRule
+
+	series map[string]*dsSeries // key is input name and hash of the time series
+}
+
+func newDownsampler(c *DownsampleConf) *downsampler {
+	if c == nil {
+		return nil
+	}
+
+	d := &downsampler{
+		inputs: map[string]*DownsampleRule{},
+		series: map[string]*dsSeries{},
+	}
+
+	if err := checkDownsampleMethod(c.Method); err != nil {
+		log.Warnf("%s on global downsample, ignored", err)
+	} else if c.enabled() {
+		d.global = &DownsampleRule{Window: c.Window, Method: c.Method}
+	}
+
+	enabled := d.global != nil
+	for k, r := range c.Inputs {
+		if r == nil {
+			continue
+		}
+
+		if err := checkDownsampleMethod(r.Method); err != nil {
+			log.Warnf("%s on downsample of input %q, ignored", err, k)
+			continue
+		}
+
+		d.inputs[k] = r
+		enabled = enabled || r.enabled()
+	}
+
+	if !enabled {
+		return nil
+	}
+
+	return d
+}
+
+// rule get the downsample rule of the input, nil if not downsampled.
+func (d *downsampler) rule(input string) *DownsampleRule {
+	r, ok := d.inputs[input]
+	if !ok {
+		r = d.global
+	}
+
+	if !r.enabled() {
+		return nil
+	}
+
+	return r
+}
+
+// add merge the points into the time series, return false if the input not
+// downsampled.
+func (d *downsampler) add(input string, pts []*point.Point, now time.Time) bool {
+	r := d.rule(input)
+	if r == nil {
+		return false
+	}
+
+	method := r.Method
+	if method == "" {
+		method = DownsampleLast
+	}
+
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	for _, pt := range pts {
+		key := input + "/" + pt.MD5()
+
+		s, ok := d.series[key]
+		if !ok {
+			s = &dsSeries{
+				input:  input,
+				name:   pt.Name(),
+				method: method,
+				tags:   pt.Tags(),
+				fields: map[string]*dsField{},
+				due:    now.Add(r.Window),
+			}
+			d.series[key] = s
+		}
+
+		s.add(pt)
+	}
+
+	inputsDownsampledPtsVec.WithLabelValues(input).Add(float64(len(pts)))
+	return true
+}
+
+// flush get downsampled points grouped by input name on the due time series,
+// all time series flushed if force.
+func (d *downsampler) flush(now time.Time, force bool) map[string][]*point.Point {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	var res map[string][]*point.Point
+	for k, s := range d.series {
+		if !force && now.Before(s.due) {
+			continue
+		}
+
+		if res == nil {
+			res = map[string][]*point.Point{}
+		}
+
+		res[s.input] = append(res[s.input], s.point())
+		delete(d.series, k)
+	}
+
+	return res
+}
+
+func (x *dkIO) writeDownsampled(res map[string][]*point.Point) {
+	for input, pts := range res {
+		fo := GetFeedOption()
+		fo.input = input
+		fo.cat = point.Metric
+		fo.pts = pts
+
+		if err := x.fo.Write(fo); err != nil {
+			log.Warnf("write %d downsampled points of %q: %s, ignored", len(pts), input, err)
+		}
+	}
+}
+
+func (x *dkIO) startDownsample() {
+	g := datakit.G("io/downsample")
+	g.Go(func(_ context.Context) error {
+		tick := time.NewTicker(time.Second)
+		defer tick.Stop()
+
+		for {
+			select {
+			case <-datakit.Exit.Wait():
+				x.writeDownsampled(x.downsampler.flush(time.Now(), true))
+				log.Info("downsample exit")
+				return nil
+
+			case now := <-tick.C:
+				x.writeDownsampled(x.downsampler.flush(now, false))
+			}
+		}
+	})
+}
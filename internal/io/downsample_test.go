@@ -0,0 +1,105 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package io
+
+import (
+	T "testing"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownsampler(t *T.T) {
+	t.Run(`disabled`, func(t *T.T) {
+		assert.Nil(t, newDownsampler(nil))
+		assert.Nil(t, newDownsampler(&DownsampleConf{}))
+		assert.Nil(t, newDownsampler(&DownsampleConf{
+			DownsampleRule: DownsampleRule{Window: time.Second, Method: "min"},
+		}))
+	})
+
+	t.Run(`rule`, func(t *T.T) {
+		d := newDownsampler(&DownsampleConf{
+			DownsampleRule: DownsampleRule{Window: 10 * time.Second},
+			Inputs: map[string]*DownsampleRule{
+				"cpu": {Window: 30 * time.Second, Method: DownsampleMean},
+				"mem": {Method: DownsampleNone},
+			},
+		})
+		require.NotNil(t, d)
+
+		assert.Equal(t, 10*time.Second, d.rule("disk").Window)
+		assert.Equal(t, DownsampleMean, d.rule("cpu").Method)
+		assert.Nil(t, d.rule("mem"))
+	})
+
+	ptAt := func(tn time.Time, host string, f1 int64, f2 float64, b bool) *point.Point {
+		kvs := point.NewTags(map[string]string{"host": host})
+		kvs = append(kvs, point.NewKVs(map[string]any{"f1": f1, "f2": f2, "b": b})...)
+		return point.NewPointV2("m", kvs, append(point.DefaultMetricOptions(), point.WithTime(tn))...)
+	}
+
+	for _, tc := range []struct {
+		method string
+		f1, f2 any
+	}{
+		{DownsampleLast, int64(2), 1.0},
+		{DownsampleMean, int64(4), 2.0},
+		{DownsampleMax, int64(8), 3.0},
+	} {
+		t.Run(tc.method, func(t *T.T) {
+			d := newDownsampler(&DownsampleConf{
+				DownsampleRule: DownsampleRule{Window: 10 * time.Second, Method: tc.method},
+			})
+			require.NotNil(t, d)
+
+			now := time.Now()
+			tn := now.Add(-time.Hour)
+			assert.True(t, d.add("cpu", []*point.Point{
+				ptAt(tn, "h1", 2, 2.0, true),
+				ptAt(tn.Add(time.Second), "h1", 8, 3.0, true),
+				ptAt(tn.Add(2*time.Second), "h1", 2, 1.0, false),
+				ptAt(tn, "h2", 1, 1.0, true),
+			}, now))
+
+			assert.Empty(t, d.flush(now.Add(time.Second), false)) // not due
+
+			res := d.flush(now.Add(10*time.Second), false)
+			require.Len(t, res["cpu"], 2)
+
+			for _, pt := range res["cpu"] {
+				if pt.Get("host") != "h1" {
+					continue
+				}
+
+				assert.Equal(t, "m", pt.Name())
+				assert.Equal(t, tc.f1, pt.Get("f1"))
+				assert.Equal(t, tc.f2, pt.Get("f2"))
+				assert.Equal(t, false, pt.Get("b"))
+				assert.Equal(t, tn.Add(2*time.Second).UnixNano(), pt.Time().UnixNano())
+			}
+
+			assert.Empty(t, d.flush(now.Add(time.Minute), true))
+		})
+	}
+}
+
+func TestDownsampleMeanType(t *T.T) {
+	mean := func(vals ...any) any {
+		f := &dsField{numeric: true}
+		for _, v := range vals {
+			f.add(v)
+		}
+		return f.value(DownsampleMean)
+	}
+
+	assert.Equal(t, int64(2), mean(int64(1), int64(2)))
+	assert.Equal(t, uint64(3), mean(uint64(3), uint64(2), uint64(3)))
+	assert.Equal(t, 1.5, mean(1.0, 2.0))
+	assert.Equal(t, 1.5, mean(int64(1), 2.0)) // mixed types
+}
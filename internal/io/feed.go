@@ -19,6 +19,7 @@ import (
 
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/datakit"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/filter"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/ptrace"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/metrics"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline/plval"
@@ -160,6 +161,7 @@ func (f *ioFeeder) Feed(name string, category point.Category, pts []*point.Point
 	inputsFeedVec.WithLabelValues(name, category.String()).Inc()
 	inputsFeedPtsVec.WithLabelValues(name, category.String()).Observe(float64(len(pts)))
 	inputsLastFeedVec.WithLabelValues(name, category.String()).Set(float64(time.Now().Unix()))
+	defFeedStats.record(name, category, pts, time.Now())
 
 	fo := GetFeedOption()
 	fo.input = name
@@ -230,6 +232,7 @@ func (f *ioFeeder) FeedV2(cat point.Category, pts []*point.Point, opts ...FeedOp
 	inputsFeedVec.WithLabelValues(fo.input, cat.String()).Inc()
 	inputsFeedPtsVec.WithLabelValues(fo.input, cat.String()).Observe(float64(len(pts)))
 	inputsLastFeedVec.WithLabelValues(fo.input, cat.String()).Set(float64(time.Now().Unix()))
+	defFeedStats.record(fo.input, cat, pts, time.Now())
 
 	if globalTagger.Updated() {
 		globalTagger.UpdateVersion()
@@ -352,14 +355,43 @@ func (x *dkIO) doFeed(opt *feedOption) error {
 	}
 	log.Debugf("io feed %s on %s", opt.input, opt.cat.String())
 
+	// both Feed() and FeedV2() are limited here.
+	if x.limiter != nil {
+		if e := x.limiter.allow(opt.input, opt.cat, opt.pts); e != nil {
+			inputsRateLimitedPtsVec.WithLabelValues(opt.input, opt.cat.String(), e.Limit).Add(float64(len(opt.pts)))
+			PutFeedOption(opt)
+			return e
+		}
+	}
+
+	traced := ptrace.Feed(opt.input, opt.cat, opt.pts)
+
 	after, plCreate, offl, err := beforeFeed(opt)
 	if err != nil {
+		traced.Drop(ptrace.StagePipeline, err.Error())
 		return err
 	}
 
 	filtered := len(opt.pts) - len(after) - offl
 
 	opt.pts = after
+	traced.Keep(opt.pts, ptrace.StagePipeline, "dropped(or offloaded) by pipeline or filter")
+
+	if x.transformer != nil {
+		opt.pts = x.transformer.transform(opt.input, opt.cat, opt.pts)
+		traced.Keep(opt.pts, ptrace.StageTransform, "dropped by transform rule")
+	}
+
+	if x.schema != nil {
+		x.schema.check(opt.input, opt.cat, opt.pts)
+	}
+
+	// downsampled points are written to output after the window.
+	if opt.cat == point.Metric && !opt.syncSend && x.downsampler != nil &&
+		x.downsampler.add(opt.input, opt.pts, time.Now()) {
+		traced.Drop(ptrace.StageDownsample, "aggregated by downsampler")
+		opt.pts = nil
+	}
 
 	if filtered >= 0 {
 		inputsFilteredPtsVec.WithLabelValues(
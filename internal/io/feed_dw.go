@@ -11,6 +11,7 @@ import (
 
 	"github.com/GuanceCloud/cliutils/point"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/datakit"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/ptrace"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/metrics"
 )
 
@@ -39,10 +40,11 @@ func writeLastError(err string, opts ...metrics.LastErrorOption) {
 		}
 	}
 
-	catStr := point.SUnknownCategory
+	cat := point.UnknownCategory
 	if len(le.Categories) == 1 {
-		catStr = le.Categories[0].String()
+		cat = le.Categories[0]
 	}
+	catStr := cat.String()
 
 	// make feed/last-feed entry for that source with category
 	// they must be real input, we need to take them out for latter
@@ -50,6 +52,12 @@ func writeLastError(err string, opts ...metrics.LastErrorOption) {
 	inputsFeedVec.WithLabelValues(le.Source, catStr).Inc()
 	inputsLastFeedVec.WithLabelValues(le.Source, catStr).Set(float64(time.Now().Unix()))
 
+	input := le.Source
+	if input == "" {
+		input = le.Input
+	}
+	defFeedStats.lastError(input, cat, err, time.Now())
+
 	metrics.ErrCountVec.WithLabelValues(le.Source, catStr).Inc()
 	metrics.LastErrVec.WithLabelValues(le.Input, le.Source, catStr, err).Set(float64(time.Now().Unix()))
 }
@@ -61,10 +69,13 @@ func (fo *datawayOutput) Write(data *feedOption) error {
 
 	if data.syncSend {
 		defIO.recordPoints(data)
+		ptrace.Record(data.pts, ptrace.StageFlush, "sync send")
 		err := defIO.doCompact(data.pts, data.cat)
 		if err != nil {
 			log.Warnf("post %d points to %s failed: %s, ignored", len(data.pts), data.cat, err)
+			ptrace.Release(data.pts, ptrace.StageFlush, fmt.Sprintf("write failed: %s", err))
 		}
+		ptrace.Release(data.pts, ptrace.StageFlush, "not encoded")
 		datakit.PutbackPoints(data.pts...)
 		return err
 	}
@@ -84,6 +95,7 @@ func (fo *datawayOutput) Write(data *feedOption) error {
 		return nil
 	case <-datakit.Exit.Wait():
 		log.Warnf("%s/%s feed skipped on global exit", data.cat, data.input)
+		ptrace.Release(data.pts, ptrace.StageQueue, "skipped on global exit")
 		return fmt.Errorf("feed on global exit")
 	}
 }
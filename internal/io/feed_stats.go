@@ -0,0 +1,244 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package io
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/GuanceCloud/cliutils/point"
+)
+
+const (
+	feedRateWindow      = 10 * time.Second
+	previewMaxStringLen = 256
+	redactedValue       = "******"
+
+	// sizes and preview of the points are taken on the first feed and every
+	// feedSampleInterval feeds after, to keep the feed path cheap.
+	feedSampleInterval = 10
+)
+
+// keys contain these words are redacted on the point preview.
+var redactKeys = []string{"password", "passwd", "pwd", "secret", "token", "auth", "cookie", "credential"}
+
+// PointPreview is the redacted copy of the point.
+type PointPreview struct {
+	Name   string            `json:"name"`
+	Tags   map[string]string `json:"tags"`
+	Fields map[string]any    `json:"fields"`
+	Time   time.Time         `json:"time"`
+}
+
+// InputFeedStat is the feed statistics of the input on the category.
+type InputFeedStat struct {
+	Input    string `json:"input"`
+	Category string `json:"category"`
+
+	Feeds        int64   `json:"feeds"`
+	Points       int64   `json:"points"`
+	Bytes        int64   `json:"bytes"` // estimated on the sampled feeds
+	PointsPerSec float64 `json:"points_per_sec"`
+
+	LastFeed      time.Time `json:"last_feed"`
+	LastError     string    `json:"last_error,omitempty"`
+	LastErrorTime time.Time `json:"last_error_time"`
+
+	LastPoint *PointPreview `json:"last_point,omitempty"` // of the last sampled feed
+
+	// points within current rate window
+	winStart  time.Time
+	winPoints int64
+}
+
+func (s *InputFeedStat) rate(now time.Time) float64 {
+	if elapsed := now.Sub(s.winStart); elapsed >= feedRateWindow {
+		return float64(s.winPoints) / elapsed.Seconds()
+	}
+
+	return s.PointsPerSec
+}
+
+// feedStat is the InputFeedStat with its own lock, so feeds of different
+// inputs do not contend with each other.
+type feedStat struct {
+	mtx sync.Mutex
+	InputFeedStat
+
+	sampledPoints,
+	sampledBytes int64
+}
+
+type feedStats struct {
+	stats sync.Map // key is input/category, value is *feedStat
+}
+
+var defFeedStats = &feedStats{}
+
+func (fs *feedStats) get(input string, cat point.Category) *feedStat {
+	k := input + "/" + cat.String()
+	if v, ok := fs.stats.Load(k); ok {
+		return v.(*feedStat)
+	}
+
+	v, _ := fs.stats.LoadOrStore(k, &feedStat{InputFeedStat: InputFeedStat{Input: input, Category: cat.String()}})
+	return v.(*feedStat)
+}
+
+func (fs *feedStats) record(input string, cat point.Category, pts []*point.Point, now time.Time) {
+	if len(pts) == 0 {
+		return
+	}
+
+	s := fs.get(input, cat)
+
+	s.mtx.Lock()
+	s.Feeds++
+	s.Points += int64(len(pts))
+	s.LastFeed = now
+
+	if s.winStart.IsZero() {
+		s.winStart = now
+	}
+
+	if now.Sub(s.winStart) >= feedRateWindow {
+		s.PointsPerSec = s.rate(now)
+		s.winStart, s.winPoints = now, 0
+	}
+	s.winPoints += int64(len(pts))
+
+	sample := (s.Feeds-1)%feedSampleInterval == 0
+	s.mtx.Unlock()
+
+	if !sample {
+		return
+	}
+
+	var bytes int64
+	for _, pt := range pts {
+		bytes += int64(pt.Size())
+	}
+	preview := previewPoint(pts[len(pts)-1])
+
+	s.mtx.Lock()
+	s.sampledPoints += int64(len(pts))
+	s.sampledBytes += bytes
+	s.LastPoint = preview
+	s.mtx.Unlock()
+}
+
+func (fs *feedStats) lastError(input string, cat point.Category, err string, now time.Time) {
+	s := fs.get(input, cat)
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.LastError = err
+	s.LastErrorTime = now
+}
+
+func (fs *feedStats) list(input string, now time.Time) []*InputFeedStat {
+	var res []*InputFeedStat
+
+	fs.stats.Range(func(_, v any) bool {
+		s := v.(*feedStat)
+
+		s.mtx.Lock()
+		defer s.mtx.Unlock()
+
+		if input != "" && s.Input != input {
+			return true
+		}
+
+		x := s.InputFeedStat
+		x.PointsPerSec = s.rate(now)
+		if s.sampledPoints > 0 {
+			// average size of the sampled points on all the points
+			x.Bytes = int64(float64(s.sampledBytes) / float64(s.sampledPoints) * float64(s.Points))
+		}
+		res = append(res, &x)
+		return true
+	})
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Input == res[j].Input {
+			return res[i].Category < res[j].Category
+		}
+		return res[i].Input < res[j].Input
+	})
+
+	return res
+}
+
+// FeedStats list feed statistics of all inputs, or the input if not empty.
+func FeedStats(input string) []*InputFeedStat {
+	return defFeedStats.list(input, time.Now())
+}
+
+func redactKey(k string) bool {
+	k = strings.ToLower(k)
+	for _, x := range redactKeys {
+		if strings.Contains(k, x) {
+			return true
+		}
+	}
+	return false
+}
+
+// previewString truncate the string to at most previewMaxStringLen bytes,
+// on the rune boundary.
+func previewString(s string) string {
+	if len(s) <= previewMaxStringLen {
+		return s
+	}
+
+	n := previewMaxStringLen
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "..."
+}
+
+// previewPoint copy the point with sensitive values redacted and long strings
+// truncated.
+func previewPoint(pt *point.Point) *PointPreview {
+	p := &PointPreview{
+		Name:   pt.Name(),
+		Tags:   map[string]string{},
+		Fields: map[string]any{},
+		Time:   pt.Time(),
+	}
+
+	for _, kv := range pt.KVs() {
+		if kv.IsTag {
+			if redactKey(kv.Key) {
+				p.Tags[kv.Key] = redactedValue
+			} else {
+				p.Tags[kv.Key] = previewString(kv.GetS())
+			}
+			continue
+		}
+
+		switch v := kv.Raw().(type) {
+		case string:
+			if redactKey(kv.Key) {
+				p.Fields[kv.Key] = redactedValue
+			} else {
+				p.Fields[kv.Key] = previewString(v)
+			}
+		case []byte:
+			p.Fields[kv.Key] = fmt.Sprintf("<%d bytes>", len(v))
+		default:
+			p.Fields[kv.Key] = v
+		}
+	}
+
+	return p
+}
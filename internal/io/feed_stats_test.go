@@ -0,0 +1,99 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package io
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeedStats(t *testing.T) {
+	fs := &feedStats{}
+	now := time.Now()
+
+	newPt := func(msg string) *point.Point {
+		return point.NewPointV2("nginx", append(point.NewTags(map[string]string{"host": "h1", "auth_token": "abc"}),
+			point.NewKVs(map[string]any{"message": msg, "db_password": "123", "cost": int64(3)})...),
+			point.DefaultLoggingOptions()...)
+	}
+
+	fs.record("nginx", point.Logging, []*point.Point{newPt("a"), newPt(strings.Repeat("x", 1000))}, now)
+	fs.record("nginx", point.Logging, []*point.Point{newPt("b")}, now.Add(time.Second))
+	fs.record("cpu", point.Metric, point.RandPoints(10), now)
+	fs.record("cpu", point.Metric, nil, now)
+	fs.lastError("cpu", point.Metric, "collect failed", now)
+
+	res := fs.list("", now.Add(time.Second))
+	require.Len(t, res, 2)
+	assert.Equal(t, "cpu", res[0].Input)
+	assert.Equal(t, "collect failed", res[0].LastError)
+	assert.Equal(t, int64(1), res[0].Feeds)
+
+	s := res[1]
+	assert.Equal(t, "nginx", s.Input)
+	assert.Equal(t, "logging", s.Category)
+	assert.Equal(t, int64(2), s.Feeds)
+	assert.Equal(t, int64(3), s.Points)
+	assert.Greater(t, s.Bytes, int64(0))
+	assert.Equal(t, now.Add(time.Second), s.LastFeed)
+	assert.Empty(t, s.LastError)
+	assert.Zero(t, s.PointsPerSec) // rate window not reached
+
+	// last point of the sampled feed, redacted and truncated
+	require.NotNil(t, s.LastPoint)
+	assert.Equal(t, "h1", s.LastPoint.Tags["host"])
+	assert.Equal(t, redactedValue, s.LastPoint.Tags["auth_token"])
+	assert.Equal(t, redactedValue, s.LastPoint.Fields["db_password"])
+	assert.Equal(t, int64(3), s.LastPoint.Fields["cost"])
+	assert.Len(t, s.LastPoint.Fields["message"], previewMaxStringLen+3)
+
+	// rate on the window
+	res = fs.list("nginx", now.Add(feedRateWindow))
+	require.Len(t, res, 1)
+	assert.InDelta(t, 0.3, res[0].PointsPerSec, 0.001)
+
+	fs.record("nginx", point.Logging, []*point.Point{newPt("c")}, now.Add(2*feedRateWindow))
+	res = fs.list("nginx", now.Add(2*feedRateWindow))
+	assert.InDelta(t, 0.15, res[0].PointsPerSec, 0.001)
+}
+
+func TestFeedStatsSampling(t *testing.T) {
+	fs := &feedStats{}
+	now := time.Now()
+
+	pts := point.RandPoints(2)
+	var size int64
+	for _, pt := range pts {
+		size += int64(pt.Size())
+	}
+
+	for i := 0; i < feedSampleInterval+1; i++ {
+		fs.record("cpu", point.Metric, pts, now)
+	}
+
+	s := fs.get("cpu", point.Metric)
+	assert.Equal(t, int64(2*2), s.sampledPoints) // the 1st and the 11th feed
+	assert.Equal(t, 2*size, s.sampledBytes)
+
+	res := fs.list("cpu", now)
+	require.Len(t, res, 1)
+	assert.Equal(t, int64(2*(feedSampleInterval+1)), res[0].Points)
+	assert.Equal(t, size*(feedSampleInterval+1), res[0].Bytes)
+}
+
+func TestPreviewString(t *testing.T) {
+	assert.Equal(t, "abc", previewString("abc"))
+
+	s := previewString(strings.Repeat("x", previewMaxStringLen-1) + "中文")
+	assert.Equal(t, strings.Repeat("x", previewMaxStringLen-1)+"...", s)
+	assert.True(t, utf8.ValidString(s))
+}
@@ -47,6 +47,13 @@ type dkIO struct {
 
 	fo FeederOutputer
 
+	limiter     *rateLimiter
+	downsampler *downsampler
+	transformer *transformer
+	schema      *schemaChecker
+	batch       *BatchConf
+	sinks       []*sinkRoute
+
 	// fcs           map[string]failcache.Cache
 	remoteManager *remotejob.Manager
 	lock          sync.RWMutex
@@ -132,6 +139,14 @@ func (x *dkIO) start() {
 			}
 		}
 	}
+	if x.downsampler != nil && x.fo != nil {
+		x.startDownsample()
+	}
+
+	if len(x.sinks) > 0 {
+		x.startSinks()
+	}
+
 	log.Infof("remote_job x.remotemanager %v", x.remoteManager == nil)
 	if x.remoteManager != nil {
 		g := datakit.G("io/remote_job")
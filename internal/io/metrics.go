@@ -16,9 +16,19 @@ import (
 var (
 	inputsFeedVec,
 	flushVec,
-	inputsFilteredPtsVec *prometheus.CounterVec
+	inputsFilteredPtsVec,
+	inputsRateLimitedPtsVec,
+	inputsDownsampledPtsVec *prometheus.CounterVec
+	inputsTransformDroppedPtsVec,
+	inputsFieldTypeConflictVec *prometheus.CounterVec
+
+	sinkPtsVec,
+	sinkErrorVec,
+	sinkDroppedVec *prometheus.CounterVec
 
 	feedCost,
+	batchPtsVec,
+	batchBytesVec,
 	inputsFeedPtsVec,
 	inputsCollectLatencyVec *prometheus.SummaryVec
 
@@ -65,6 +75,44 @@ func metricsSetup() {
 		},
 	)
 
+	batchPtsVec = prometheus.NewSummaryVec(
+		prometheus.SummaryOpts{
+			Namespace: "datakit",
+			Subsystem: "io",
+			Name:      "batch_points",
+			Help:      "Points of each flushed batch, reason is bytes/latency/count/interval/exit",
+
+			Objectives: map[float64]float64{
+				0.5:  0.05,
+				0.9:  0.01,
+				0.99: 0.001,
+			},
+		},
+		[]string{
+			"category",
+			"reason",
+		},
+	)
+
+	batchBytesVec = prometheus.NewSummaryVec(
+		prometheus.SummaryOpts{
+			Namespace: "datakit",
+			Subsystem: "io",
+			Name:      "batch_bytes",
+			Help:      "Approximate payload bytes of each flushed batch(only with batching configured)",
+
+			Objectives: map[float64]float64{
+				0.5:  0.05,
+				0.9:  0.01,
+				0.99: 0.001,
+			},
+		},
+		[]string{
+			"category",
+			"reason",
+		},
+	)
+
 	inputsFeedPtsVec = prometheus.NewSummaryVec(
 		prometheus.SummaryOpts{
 			Namespace: "datakit",
@@ -128,6 +176,98 @@ func metricsSetup() {
 		},
 	)
 
+	inputsRateLimitedPtsVec = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "datakit",
+			Subsystem: "io",
+			Name:      "input_rate_limited_point_total",
+			Help:      "Input points dropped by the rate limit",
+		},
+		[]string{
+			"name",
+			"category",
+			"limit",
+		},
+	)
+
+	inputsDownsampledPtsVec = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "datakit",
+			Subsystem: "io",
+			Name:      "input_downsampled_point_total",
+			Help:      "Input metric points merged by the downsample",
+		},
+		[]string{
+			"name",
+		},
+	)
+
+	inputsTransformDroppedPtsVec = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "datakit",
+			Subsystem: "io",
+			Name:      "input_transform_dropped_point_total",
+			Help:      "Input points dropped by the transform rules",
+		},
+		[]string{
+			"name",
+			"category",
+		},
+	)
+
+	inputsFieldTypeConflictVec = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "datakit",
+			Subsystem: "io",
+			Name:      "input_field_type_conflict_total",
+			Help:      "Input fields conflicted with the type seen first, action is detected/coerced/dropped",
+		},
+		[]string{
+			"name",
+			"category",
+			"action",
+		},
+	)
+
+	sinkPtsVec = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "datakit",
+			Subsystem: "io",
+			Name:      "sink_point_total",
+			Help:      "Points written to the sinks",
+		},
+		[]string{
+			"sink",
+			"category",
+		},
+	)
+
+	sinkErrorVec = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "datakit",
+			Subsystem: "io",
+			Name:      "sink_error_total",
+			Help:      "Failed writes on the sinks",
+		},
+		[]string{
+			"sink",
+			"category",
+		},
+	)
+
+	sinkDroppedVec = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "datakit",
+			Subsystem: "io",
+			Name:      "sink_dropped_point_total",
+			Help:      "Points dropped on the queue of the sinks full",
+		},
+		[]string{
+			"sink",
+			"category",
+		},
+	)
+
 	inputsFeedVec = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Namespace: "datakit",
@@ -211,6 +351,13 @@ func Metrics() []prometheus.Collector {
 		inputsFeedVec,
 		inputsFeedPtsVec,
 		inputsFilteredPtsVec,
+		inputsRateLimitedPtsVec,
+		inputsDownsampledPtsVec,
+		inputsTransformDroppedPtsVec,
+		inputsFieldTypeConflictVec,
+		sinkPtsVec,
+		sinkErrorVec,
+		sinkDroppedVec,
 		inputsLastFeedVec,
 		inputsCollectLatencyVec,
 		queuePtsVec,
@@ -219,6 +366,8 @@ func Metrics() []prometheus.Collector {
 		flushVec,
 		flushWorkersVec,
 		feedCost,
+		batchPtsVec,
+		batchBytesVec,
 	}
 }
 
@@ -226,6 +375,13 @@ func MetricsReset() {
 	inputsFeedVec.Reset()
 	inputsFeedPtsVec.Reset()
 	inputsFilteredPtsVec.Reset()
+	inputsRateLimitedPtsVec.Reset()
+	inputsDownsampledPtsVec.Reset()
+	inputsTransformDroppedPtsVec.Reset()
+	inputsFieldTypeConflictVec.Reset()
+	sinkPtsVec.Reset()
+	sinkErrorVec.Reset()
+	sinkDroppedVec.Reset()
 
 	inputsCollectLatencyVec.Reset()
 
@@ -235,6 +391,8 @@ func MetricsReset() {
 	ioChanLen.Reset()
 	flushVec.Reset()
 	flushWorkersVec.Reset()
+	batchPtsVec.Reset()
+	batchBytesVec.Reset()
 }
 
 // A CollectorStatus used to describe a input's status.
@@ -8,8 +8,10 @@ package io
 import (
 	"time"
 
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/goroutine"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/dataway"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/filter"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/ptrace"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/recorder"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/remotejob"
 )
@@ -87,6 +89,68 @@ func WithFilters(filters map[string]filter.FilterConditions) IOOption {
 	}
 }
 
+// WithRateLimit set rate limits on feeding.
+func WithRateLimit(c *RateLimitConf) IOOption {
+	return func(x *dkIO) {
+		x.limiter = newRateLimiter(c)
+	}
+}
+
+// WithDownsample set downsample on metric points.
+func WithDownsample(c *DownsampleConf) IOOption {
+	return func(x *dkIO) {
+		x.downsampler = newDownsampler(c)
+	}
+}
+
+// WithTransforms set transform rules on points before uploading.
+func WithTransforms(rules []*TransformRule) IOOption {
+	return func(x *dkIO) {
+		x.transformer = newTransformer(rules)
+	}
+}
+
+// WithBatch set batching on payload bytes and latency instead of the fixed
+// flush interval.
+func WithBatch(c *BatchConf) IOOption {
+	return func(x *dkIO) {
+		x.batch = c
+	}
+}
+
+// WithSchemaCheck set field type check on points before uploading.
+func WithSchemaCheck(c *SchemaCheckConf) IOOption {
+	return func(x *dkIO) {
+		x.schema = newSchemaChecker(c)
+	}
+}
+
+// WithPointTrace set tracing on the delivery path of the matched points.
+func WithPointTrace(c *ptrace.Conf) IOOption {
+	return func(_ *dkIO) {
+		ptrace.Setup(c)
+	}
+}
+
+// WithPanicReport set feeding the panics recovered within goroutine groups.
+func WithPanicReport(c *PanicReportConf) IOOption {
+	return func(_ *dkIO) {
+		var fn goroutine.PanicReporter
+		if r := newPanicReport(c); r != nil {
+			fn = r.report
+		}
+
+		goroutine.SetPanicReporter(fn)
+	}
+}
+
+// WithSinks set extra outputs of the points besides dataway.
+func WithSinks(c *SinkConf) IOOption {
+	return func(x *dkIO) {
+		x.sinks = newSinks(c)
+	}
+}
+
 // WithCompactWorkers set IO flush workers.
 func WithCompactWorkers(n int) IOOption {
 	return func(x *dkIO) {
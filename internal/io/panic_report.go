@@ -0,0 +1,141 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package io
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/goroutine"
+)
+
+const (
+	panicReportSource   = "datakit_panic"
+	panicReportInterval = time.Minute
+	defaultPanicStack   = 4096
+)
+
+// PanicReportConf configure feeding the panics recovered within the goroutine
+// groups as points, so the crashes are visible within the workspace.
+type PanicReportConf struct {
+	Enable bool `toml:"enable" json:"enable"`
+
+	// Category is logging(default) or keyevent.
+	Category string `toml:"category" json:"category"`
+
+	// MaxStack is the max bytes of the stack within the point.
+	MaxStack int `toml:"max_stack" json:"max_stack"`
+}
+
+// panicReport build the points on the recovered panics. Within the
+// interval, only the first panic of the group is reported, the others are
+// counted as suppressed and reported with the next one.
+type panicReport struct {
+	mtx      sync.Mutex
+	cat      point.Category
+	maxStack int
+	feeder   Feeder
+
+	last       map[string]time.Time
+	suppressed map[string]int
+}
+
+func newPanicReport(c *PanicReportConf) *panicReport {
+	if c == nil || !c.Enable {
+		return nil
+	}
+
+	r := &panicReport{
+		cat:        point.Logging,
+		maxStack:   c.MaxStack,
+		last:       map[string]time.Time{},
+		suppressed: map[string]int{},
+	}
+
+	if point.CatString(c.Category) == point.KeyEvent {
+		r.cat = point.KeyEvent
+	}
+
+	if r.maxStack <= 0 {
+		r.maxStack = defaultPanicStack
+	}
+
+	return r
+}
+
+// panicInput get the input name from the group name such as inputs_mysql.
+func panicInput(group string) string {
+	return strings.TrimPrefix(group, goroutine.GetInputName(""))
+}
+
+func (r *panicReport) build(group string, x any, stack []byte, now time.Time) *point.Point {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if t, ok := r.last[group]; ok && now.Sub(t) < panicReportInterval {
+		r.suppressed[group]++
+		return nil
+	}
+
+	suppressed := r.suppressed[group]
+	r.last[group] = now
+	delete(r.suppressed, group)
+
+	if len(stack) > r.maxStack {
+		stack = stack[:r.maxStack]
+	}
+
+	var (
+		kvs   point.KVs
+		title = fmt.Sprintf("Goroutine group %s panic: %v", group, x)
+		msg   = title + "\n" + string(stack)
+	)
+
+	kvs = kvs.AddTag("group", group).
+		AddTag("service", "datakit").
+		Add("panic", fmt.Sprintf("%v", x), false, false).
+		Add("suppressed", suppressed, false, false)
+
+	if strings.HasPrefix(group, goroutine.GetInputName("")) {
+		kvs = kvs.AddTag("input", panicInput(group))
+	}
+
+	if r.cat == point.KeyEvent {
+		kvs = kvs.Add("df_title", title, false, false).
+			Add("df_message", msg, false, false).
+			Add("df_status", "error", false, false)
+	} else {
+		kvs = kvs.Add("message", msg, false, false).
+			Add("status", "error", false, false)
+	}
+
+	return point.NewPointV2(panicReportSource, kvs,
+		append(point.DefaultLoggingOptions(), point.WithTime(now))...)
+}
+
+// report is the goroutine.PanicReporter.
+func (r *panicReport) report(group string, x any, stack []byte) {
+	pt := r.build(group, x, stack, time.Now())
+	if pt == nil {
+		return
+	}
+
+	f := r.feeder
+	if f == nil {
+		f = DefaultFeeder()
+	}
+
+	// the panic may come from the goroutines of io, do not block it on the
+	// feeding.
+	go func() {
+		if err := f.FeedV2(r.cat, []*point.Point{pt}, WithInputName(panicReportSource)); err != nil {
+			log.Warnf("feed panic of %s: %s, ignored", group, err)
+		}
+	}()
+}
@@ -0,0 +1,78 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package io
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPanicReport(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		assert.Nil(t, newPanicReport(nil))
+		assert.Nil(t, newPanicReport(&PanicReportConf{}))
+	})
+
+	t.Run("logging", func(t *testing.T) {
+		r := newPanicReport(&PanicReportConf{Enable: true, MaxStack: 16})
+		now := time.Now()
+
+		pt := r.build("inputs_mysql", errors.New("nil pointer"), []byte(strings.Repeat("x", 100)), now)
+		require.NotNil(t, pt)
+
+		assert.Equal(t, panicReportSource, pt.Name())
+		assert.Equal(t, "inputs_mysql", pt.GetTag("group"))
+		assert.Equal(t, "mysql", pt.GetTag("input"))
+		assert.Equal(t, "nil pointer", pt.Get("panic"))
+		assert.Equal(t, "error", pt.Get("status"))
+		assert.Equal(t, "Goroutine group inputs_mysql panic: nil pointer\n"+strings.Repeat("x", 16), pt.Get("message"))
+		assert.Equal(t, int64(0), pt.Get("suppressed"))
+
+		// suppressed within the interval
+		assert.Nil(t, r.build("inputs_mysql", "panic", nil, now.Add(time.Second)))
+		assert.Nil(t, r.build("inputs_mysql", "panic", nil, now.Add(2*time.Second)))
+
+		// other groups not affected
+		pt = r.build("io_compactor", "panic", nil, now.Add(time.Second))
+		require.NotNil(t, pt)
+		assert.Equal(t, "", pt.GetTag("input"))
+
+		pt = r.build("inputs_mysql", "panic", nil, now.Add(panicReportInterval))
+		require.NotNil(t, pt)
+		assert.Equal(t, int64(2), pt.Get("suppressed"))
+	})
+
+	t.Run("keyevent", func(t *testing.T) {
+		r := newPanicReport(&PanicReportConf{Enable: true, Category: "keyevent"})
+		assert.Equal(t, point.KeyEvent, r.cat)
+		assert.Equal(t, defaultPanicStack, r.maxStack)
+
+		pt := r.build("inputs_redis", "panic", []byte("stack"), time.Now())
+		require.NotNil(t, pt)
+		assert.Equal(t, "Goroutine group inputs_redis panic: panic", pt.Get("df_title"))
+		assert.Equal(t, "Goroutine group inputs_redis panic: panic\nstack", pt.Get("df_message"))
+		assert.Equal(t, "error", pt.Get("df_status"))
+		assert.Nil(t, pt.Get("message"))
+	})
+
+	t.Run("report", func(t *testing.T) {
+		f := NewMockedFeeder()
+		r := newPanicReport(&PanicReportConf{Enable: true})
+		r.feeder = f
+
+		r.report("inputs_mysql", "panic", []byte("stack"))
+		pts, err := f.AnyPoints(time.Second)
+		require.NoError(t, err)
+		require.Len(t, pts, 1)
+		assert.Equal(t, "mysql", pts[0].GetTag("input"))
+	})
+}
@@ -0,0 +1,206 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package io
+
+import (
+	"crypto/md5" //nolint:gosec
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+)
+
+const (
+	pipelineAuditSource = "pipeline_remote"
+
+	pipelineChangeScripts  = "pipeline"
+	pipelineChangeDefault  = "default_pipeline"
+	pipelineChangeRelation = "relation"
+)
+
+var defPipelineAudit = &pipelineAudit{}
+
+// pipelineAudit compare the remote pipelines pulled with the ones pulled last
+// time, and report the changes as key events, so the changes of the parsing
+// behavior can be correlated with the remote pipeline edits.
+//
+// The scripts pulled before datakit started are unknown, so the scripts in the
+// first update after started are all reported as added.
+type pipelineAudit struct {
+	mtx    sync.Mutex
+	feeder Feeder
+
+	digests    map[string]string // cat/name -> md5 of the script
+	defaults   map[string]string // cat -> name
+	relations  map[string]string // cat/source -> name
+	updateTime int64
+	relationTS int64
+}
+
+type pipelineChanges struct {
+	added, updated, deleted []string
+}
+
+func (c *pipelineChanges) empty() bool {
+	return len(c.added)+len(c.updated)+len(c.deleted) == 0
+}
+
+func (c *pipelineChanges) message() string {
+	var arr []string
+	for _, x := range []struct {
+		action string
+		list   []string
+	}{
+		{"added", c.added},
+		{"updated", c.updated},
+		{"deleted", c.deleted},
+	} {
+		if len(x.list) > 0 {
+			arr = append(arr, x.action+": "+strings.Join(x.list, ", "))
+		}
+	}
+
+	return strings.Join(arr, "; ")
+}
+
+// diffPipelineMap compare the maps, the updated one is formatted as
+// `key: old -> new` if showValue enabled.
+func diffPipelineMap(old, curr map[string]string, showValue bool) *pipelineChanges {
+	c := &pipelineChanges{}
+
+	for k, v := range curr {
+		o, ok := old[k]
+		switch {
+		case !ok:
+			if showValue {
+				k += ": " + v
+			}
+			c.added = append(c.added, k)
+		case o != v:
+			if showValue {
+				k += ": " + o + " -> " + v
+			}
+			c.updated = append(c.updated, k)
+		}
+	}
+
+	for k, v := range old {
+		if _, ok := curr[k]; !ok {
+			if showValue {
+				k += ": " + v
+			}
+			c.deleted = append(c.deleted, k)
+		}
+	}
+
+	sort.Strings(c.added)
+	sort.Strings(c.updated)
+	sort.Strings(c.deleted)
+
+	return c
+}
+
+func newPipelineAuditEvent(change, title string, c *pipelineChanges, oldTS, newTS int64, now time.Time) *point.Point {
+	var kvs point.KVs
+	kvs = kvs.AddTag("source", pipelineAuditSource).
+		AddTag("change", change).
+		Add("df_title", title, false, false).
+		Add("df_message", fmt.Sprintf("%s(update time %d -> %d), %s", title, oldTS, newTS, c.message()), false, false).
+		Add("df_status", "info", false, false).
+		Add("old_update_time", oldTS, false, false).
+		Add("new_update_time", newTS, false, false).
+		Add("added", strings.Join(c.added, ","), false, false).
+		Add("updated", strings.Join(c.updated, ","), false, false).
+		Add("deleted", strings.Join(c.deleted, ","), false, false)
+
+	return point.NewPointV2(pipelineAuditSource, kvs,
+		append(point.DefaultLoggingOptions(), point.WithTime(now))...)
+}
+
+// check the parsed pull result and build events on the changes. The scripts
+// and default pipelines are changed only on new update time, and the relations
+// on new relation update time(-1 for no relation).
+func (a *pipelineAudit) check(files, relation map[point.Category]map[string]string,
+	dft map[point.Category]string, ut, relationTS int64, now time.Time,
+) []*point.Point {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	var pts []*point.Point
+
+	if ut > 0 && ut != a.updateTime {
+		digests := map[string]string{}
+		defaults := map[string]string{}
+
+		if ut != pipelineDeleteAll {
+			for cat, scripts := range files {
+				for name, script := range scripts {
+					digests[cat.String()+"/"+name] = fmt.Sprintf("%x", md5.Sum([]byte(script))) //nolint:gosec
+				}
+			}
+
+			for cat, name := range dft {
+				defaults[cat.String()] = name
+			}
+		}
+
+		if c := diffPipelineMap(a.digests, digests, false); !c.empty() {
+			pts = append(pts, newPipelineAuditEvent(pipelineChangeScripts,
+				"Remote pipelines updated", c, a.updateTime, ut, now))
+		}
+
+		if c := diffPipelineMap(a.defaults, defaults, true); !c.empty() {
+			pts = append(pts, newPipelineAuditEvent(pipelineChangeDefault,
+				"Default pipelines updated", c, a.updateTime, ut, now))
+		}
+
+		a.digests, a.defaults, a.updateTime = digests, defaults, ut
+	}
+
+	if relationTS != -1 && relationTS != a.relationTS {
+		relations := map[string]string{}
+		for cat, m := range relation {
+			for source, name := range m {
+				relations[cat.String()+"/"+source] = name
+			}
+		}
+
+		if c := diffPipelineMap(a.relations, relations, true); !c.empty() {
+			pts = append(pts, newPipelineAuditEvent(pipelineChangeRelation,
+				"Pipeline relations updated", c, a.relationTS, relationTS, now))
+		}
+
+		a.relations, a.relationTS = relations, relationTS
+	}
+
+	return pts
+}
+
+// auditPipelinePull feed key events on changes of the pulled remote pipelines.
+func auditPipelinePull(files, relation map[point.Category]map[string]string,
+	dft map[point.Category]string, ut, relationTS int64,
+) {
+	pts := defPipelineAudit.check(files, relation, dft, ut, relationTS, time.Now())
+	if len(pts) == 0 {
+		return
+	}
+
+	for _, pt := range pts {
+		log.Infof("%s", pt.Get("df_message"))
+	}
+
+	f := defPipelineAudit.feeder
+	if f == nil {
+		f = DefaultFeeder()
+	}
+
+	if err := f.FeedV2(point.KeyEvent, pts, WithInputName(pipelineAuditSource)); err != nil {
+		log.Warnf("feed pipeline change events: %s, ignored", err)
+	}
+}
@@ -0,0 +1,100 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package io
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPipelineAudit(t *testing.T) {
+	var (
+		a   = &pipelineAudit{}
+		now = time.Now()
+	)
+
+	get := func(pts []*point.Point, change string) *point.Point {
+		for _, pt := range pts {
+			if pt.GetTag("change") == change {
+				return pt
+			}
+		}
+		return nil
+	}
+
+	files := map[point.Category]map[string]string{
+		point.Logging: {"nginx.p": "script-1", "redis.p": "script-1"},
+	}
+	relation := map[point.Category]map[string]string{
+		point.Logging: {"nginx": "nginx.p"},
+	}
+	dft := map[point.Category]string{point.Logging: "nginx.p"}
+
+	pts := a.check(files, relation, dft, 100, 10, now)
+	require.Len(t, pts, 3)
+
+	pt := get(pts, pipelineChangeScripts)
+	require.NotNil(t, pt)
+	assert.Equal(t, "logging/nginx.p,logging/redis.p", pt.Get("added"))
+	assert.Equal(t, int64(0), pt.Get("old_update_time"))
+	assert.Equal(t, int64(100), pt.Get("new_update_time"))
+	assert.Equal(t, "info", pt.Get("df_status"))
+	assert.Equal(t, "logging: nginx.p", get(pts, pipelineChangeDefault).Get("added"))
+	assert.Equal(t, "logging/nginx: nginx.p", get(pts, pipelineChangeRelation).Get("added"))
+
+	// up to date
+	assert.Empty(t, a.check(nil, nil, nil, 100, 10, now))
+	assert.Empty(t, a.check(nil, nil, nil, 100, -1, now))
+
+	// script updated and deleted, relation changed
+	files = map[point.Category]map[string]string{
+		point.Logging: {"nginx.p": "script-2"},
+		point.Metric:  {"cpu.p": "script-1"},
+	}
+	relation = map[point.Category]map[string]string{
+		point.Logging: {"nginx": "redis.p"},
+	}
+
+	pts = a.check(files, relation, dft, 200, 20, now)
+	require.Len(t, pts, 2)
+
+	pt = get(pts, pipelineChangeScripts)
+	assert.Equal(t, "metric/cpu.p", pt.Get("added"))
+	assert.Equal(t, "logging/nginx.p", pt.Get("updated"))
+	assert.Equal(t, "logging/redis.p", pt.Get("deleted"))
+	assert.Equal(t, int64(100), pt.Get("old_update_time"))
+	assert.Contains(t, pt.Get("df_message"), "update time 100 -> 200")
+
+	pt = get(pts, pipelineChangeRelation)
+	assert.Equal(t, "logging/nginx: nginx.p -> redis.p", pt.Get("updated"))
+	assert.Equal(t, int64(10), pt.Get("old_update_time"))
+	assert.Equal(t, int64(20), pt.Get("new_update_time"))
+
+	// all deleted
+	pts = a.check(nil, nil, nil, pipelineDeleteAll, -1, now)
+	require.Len(t, pts, 2)
+	assert.Equal(t, "logging/nginx.p,metric/cpu.p", get(pts, pipelineChangeScripts).Get("deleted"))
+	assert.Equal(t, "logging: nginx.p", get(pts, pipelineChangeDefault).Get("deleted"))
+
+	t.Run("feed", func(t *testing.T) {
+		f := NewMockedFeeder()
+		defPipelineAudit = &pipelineAudit{feeder: f}
+		defer func() { defPipelineAudit = &pipelineAudit{} }()
+
+		auditPipelinePull(map[point.Category]map[string]string{
+			point.Logging: {"nginx.p": "script"},
+		}, nil, nil, 100, -1)
+
+		pts, err := f.NPoints(1, time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, pipelineAuditSource, pts[0].Name())
+		assert.Equal(t, "logging/nginx.p", pts[0].Get("added"))
+	})
+}
@@ -13,6 +13,10 @@ import (
 )
 
 // PullPipeline returns name/text, updateTime, err.
+//
+// The scripts are pulled from dataway by default, if git source or local
+// override dir configured(see SetPipelineRemoteSource), the scripts synced from
+// git or pulled from dataway are overridden by the local scripts.
 func PullPipeline(ts, relaTS int64) (mFiles, plRelation map[point.Category]map[string]string,
 	defaultPl map[point.Category]string, updateTime int64, relationTS int64, err error,
 ) {
@@ -23,6 +27,10 @@ func PullPipeline(ts, relaTS int64) (mFiles, plRelation map[point.Category]map[s
 			}
 		}
 	}()
+
+	if plSource.enabled() {
+		return plSource.pull(ts, relaTS)
+	}
 	pulledStruct, err := defPipelinePullMock.getPipelinePull(ts, relaTS)
 	if err != nil {
 		return nil, nil, nil, 0, 0, err
@@ -33,6 +41,9 @@ func PullPipeline(ts, relaTS int64) (mFiles, plRelation map[point.Category]map[s
 	}
 
 	mFiles, plRelation, defaultPl, updateTime, relationTS, err = parsePipelinePullStruct(pulledStruct)
+	if err == nil {
+		auditPipelinePull(mFiles, plRelation, defaultPl, updateTime, relationTS)
+	}
 	return
 }
 
@@ -0,0 +1,319 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package io
+
+import (
+	"context"
+	"crypto/md5" //nolint:gosec
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	plmanager "github.com/GuanceCloud/cliutils/pipeline/manager"
+	"github.com/GuanceCloud/cliutils/point"
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	ssh2 "golang.org/x/crypto/ssh"
+
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/datakit"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline/plval"
+)
+
+const (
+	// same as the pipeline remote: update time 1 means all scripts deleted.
+	pipelineDeleteAll = 1
+
+	pipelineDefaultScriptFile = "default.json"
+	pipelineGitTimeout        = 30 * time.Second
+)
+
+var plSource = &pipelineSource{}
+
+// pipelineSource merge the remote pipelines pulled from dataway or synced
+// from git with the local override scripts.
+type pipelineSource struct {
+	mtx sync.Mutex
+	cfg *plval.RemoteSourceCfg
+
+	// full scripts pulled from dataway last time, dataway only return the
+	// changed scripts on new update time, so we keep them for merging.
+	dwFiles      map[point.Category]map[string]string
+	dwDefault    map[point.Category]string
+	dwUpdateTime int64
+
+	gitDir string
+
+	// digest and update time of the merged scripts returned last time
+	digest     string
+	updateTime int64
+}
+
+// SetPipelineRemoteSource set the source of the remote pipelines.
+func SetPipelineRemoteSource(c *plval.RemoteSourceCfg) {
+	plSource.mtx.Lock()
+	defer plSource.mtx.Unlock()
+
+	plSource.cfg = c
+	if c != nil && c.Source == plval.RemoteSourceGit {
+		plSource.gitDir = filepath.Join(datakit.PipelineRemoteDir, "git",
+			fmt.Sprintf("%x", md5.Sum([]byte(c.GitURL+"#"+c.GitBranch)))) //nolint:gosec
+	}
+}
+
+func (s *pipelineSource) enabled() bool {
+	return s.cfg != nil && (s.cfg.Source == plval.RemoteSourceGit || s.cfg.LocalDir != "")
+}
+
+func (s *pipelineSource) pull(ts, relaTS int64) (mFiles, plRelation map[point.Category]map[string]string,
+	defaultPl map[point.Category]string, updateTime int64, relationTS int64, err error,
+) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	relationTS = -1 // no relation on git source
+
+	switch s.cfg.Source {
+	case plval.RemoteSourceGit:
+		if mFiles, defaultPl, err = s.pullGit(); err != nil {
+			return nil, nil, nil, 0, 0, err
+		}
+
+	default:
+		var (
+			pulled *pullPipelineReturn
+			files  map[point.Category]map[string]string
+			dft    map[point.Category]string
+			ut     int64
+		)
+
+		if pulled, err = defPipelinePullMock.getPipelinePull(s.dwUpdateTime, relaTS); err != nil {
+			return nil, nil, nil, 0, 0, err
+		}
+
+		if pulled == nil {
+			return nil, nil, nil, 0, 0, fmt.Errorf("got nil")
+		}
+
+		if files, plRelation, dft, ut, relationTS, err = parsePipelinePullStruct(pulled); err != nil {
+			return nil, nil, nil, 0, 0, err
+		}
+
+		auditPipelinePull(files, plRelation, dft, ut, relationTS)
+
+		if ut > 0 && ut != s.dwUpdateTime {
+			if ut == pipelineDeleteAll {
+				s.dwFiles, s.dwDefault = nil, nil
+			} else {
+				s.dwFiles, s.dwDefault = files, dft
+			}
+			s.dwUpdateTime = ut
+		}
+
+		mFiles, defaultPl = s.dwFiles, s.dwDefault
+	}
+
+	if s.cfg.LocalDir != "" {
+		files, dft := readPipelineDir(s.cfg.LocalDir)
+		mFiles, defaultPl = overridePipelines(mFiles, defaultPl, files, dft)
+	}
+
+	digest := pipelinesDigest(mFiles, defaultPl)
+
+	switch {
+	case ts != 0 && ts == s.updateTime && digest == s.digest:
+		return nil, plRelation, nil, ts, relationTS, nil // up to date
+
+	case len(mFiles) == 0 && len(defaultPl) == 0:
+		updateTime = pipelineDeleteAll
+
+	default:
+		if updateTime = time.Now().Unix(); updateTime <= s.updateTime {
+			updateTime = s.updateTime + 1
+		}
+	}
+
+	log.Infof("pipeline remote source %q(local dir %q) updated: %d", s.cfg.Source, s.cfg.LocalDir, updateTime)
+
+	s.digest, s.updateTime = digest, updateTime
+	return mFiles, plRelation, defaultPl, updateTime, relationTS, nil
+}
+
+func (s *pipelineSource) pullGit() (map[point.Category]map[string]string, map[point.Category]string, error) {
+	if err := gitSync(s.gitDir, s.cfg); err != nil {
+		return nil, nil, fmt.Errorf("git sync %s: %w", s.cfg.GitURL, err)
+	}
+
+	files, dft := readPipelineDir(filepath.Join(s.gitDir, s.cfg.GitPath))
+	return files, dft, nil
+}
+
+// readPipelineDir read scripts of the same layout as the pipeline dir, and the
+// default scripts from default.json, such as {"logging": "nginx.p"}.
+func readPipelineDir(dir string) (map[point.Category]map[string]string, map[point.Category]string) {
+	files, _ := plmanager.ReadWorkspaceScripts(dir)
+	for cat, scripts := range files {
+		if len(scripts) == 0 {
+			delete(files, cat)
+		}
+	}
+
+	var dft map[point.Category]string
+	if data, err := os.ReadFile(filepath.Clean(filepath.Join(dir, pipelineDefaultScriptFile))); err == nil {
+		var x map[string]string
+		if err := json.Unmarshal(data, &x); err != nil {
+			log.Warnf("invalid %s under %s: %s, ignored", pipelineDefaultScriptFile, dir, err)
+		} else {
+			dft = map[point.Category]string{}
+			for k, v := range x {
+				dft[point.CatString(k)] = v
+			}
+		}
+	}
+
+	return files, dft
+}
+
+// overridePipelines merge the override scripts into the pulled scripts, the
+// pulled scripts are not modified.
+func overridePipelines(files map[point.Category]map[string]string, dft map[point.Category]string,
+	overrideFiles map[point.Category]map[string]string, overrideDft map[point.Category]string,
+) (map[point.Category]map[string]string, map[point.Category]string) {
+	mFiles := map[point.Category]map[string]string{}
+	for _, m := range []map[point.Category]map[string]string{files, overrideFiles} {
+		for cat, scripts := range m {
+			if len(scripts) == 0 {
+				continue
+			}
+
+			if _, ok := mFiles[cat]; !ok {
+				mFiles[cat] = map[string]string{}
+			}
+
+			for name, script := range scripts {
+				mFiles[cat][name] = script
+			}
+		}
+	}
+
+	defaultPl := map[point.Category]string{}
+	for _, m := range []map[point.Category]string{dft, overrideDft} {
+		for cat, name := range m {
+			defaultPl[cat] = name
+		}
+	}
+
+	return mFiles, defaultPl
+}
+
+func pipelinesDigest(files map[point.Category]map[string]string, dft map[point.Category]string) string {
+	var arr []string
+	for cat, scripts := range files {
+		for name, script := range scripts {
+			arr = append(arr, cat.String()+"/"+name+"\n"+script)
+		}
+	}
+
+	for cat, name := range dft {
+		arr = append(arr, pipelineDefaultScriptFile+"/"+cat.String()+"\n"+name)
+	}
+
+	sort.Strings(arr)
+
+	return fmt.Sprintf("%x", md5.Sum([]byte(strings.Join(arr, "\n")))) //nolint:gosec
+}
+
+func gitAuth(c *plval.RemoteSourceCfg) (transport.AuthMethod, error) {
+	switch lower := strings.ToLower(c.GitURL); {
+	case strings.HasPrefix(lower, "http"):
+		u, err := url.Parse(c.GitURL)
+		if err != nil {
+			return nil, err
+		}
+
+		if u.User == nil || u.User.Username() == "" {
+			return nil, nil
+		}
+
+		password, _ := u.User.Password()
+		return &http.BasicAuth{Username: u.User.Username(), Password: password}, nil
+
+	case strings.HasPrefix(lower, "ssh://"), strings.HasPrefix(lower, "git@"):
+
+	default: // local repo, such as file:///path/to/repo
+		return nil, nil
+	}
+
+	if c.GitSSHPrivateKeyPath == "" {
+		return nil, fmt.Errorf("ssh private key required on git url %q", c.GitURL)
+	}
+
+	keys, err := ssh.NewPublicKeysFromFile("git", c.GitSSHPrivateKeyPath, c.GitSSHPrivateKeyPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	keys.HostKeyCallback = ssh2.InsecureIgnoreHostKey() //nolint:gosec
+	return keys, nil
+}
+
+// gitSync clone the repo into dir, or pull the repo if cloned.
+func gitSync(dir string, c *plval.RemoteSourceCfg) error {
+	auth, err := gitAuth(c)
+	if err != nil {
+		return err
+	}
+
+	var ref plumbing.ReferenceName
+	if c.GitBranch != "" {
+		ref = plumbing.NewBranchReferenceName(c.GitBranch)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), pipelineGitTimeout)
+	defer cancel()
+
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		if err := os.RemoveAll(dir); err != nil {
+			return err
+		}
+
+		_, err = git.PlainCloneContext(ctx, dir, false, &git.CloneOptions{
+			URL:           c.GitURL,
+			Auth:          auth,
+			RemoteName:    "origin",
+			ReferenceName: ref,
+			SingleBranch:  true,
+		})
+		return err
+	}
+
+	w, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+
+	if err := w.PullContext(ctx, &git.PullOptions{
+		RemoteName:    "origin",
+		ReferenceName: ref,
+		SingleBranch:  true,
+		Auth:          auth,
+		Force:         true,
+	}); err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,402 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+// Package ptrace trace the delivery path of the matched points through
+// io(feed/pipeline/queue) and dataway(encode/WAL/upload), for diagnosing the
+// points that dropped silently.
+package ptrace
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+)
+
+const (
+	defaultMaxTraces = 1000
+	maxEvents        = 100
+)
+
+// Stages of the point delivery.
+const (
+	StageFeed       = "feed"
+	StagePipeline   = "pipeline"
+	StageTransform  = "transform"
+	StageDownsample = "downsample"
+	StageQueue      = "queue"
+	StageFlush      = "flush"
+	StageEncode     = "encode"
+	StageWAL        = "wal"
+	StageUpload     = "upload"
+)
+
+// Rule match the points to trace, the empty category and measurement match
+// any, and all the tags should be matched.
+type Rule struct {
+	Category    string            `toml:"category" json:"category"`
+	Measurement string            `toml:"measurement" json:"measurement"`
+	Tags        map[string]string `toml:"tags" json:"tags"`
+}
+
+func (r *Rule) match(cat point.Category, pt *point.Point) bool {
+	if r.Category != "" && point.CatString(r.Category) != cat {
+		return false
+	}
+
+	if r.Measurement != "" && r.Measurement != pt.Name() {
+		return false
+	}
+
+	for k, v := range r.Tags {
+		if pt.GetTag(k) != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Conf configure the point tracing, it's a debug mode and should not enabled
+// on production for long.
+type Conf struct {
+	Enable    bool    `toml:"enable" json:"enable"`
+	MaxTraces int     `toml:"max_traces" json:"max_traces"`
+	Rules     []*Rule `toml:"rules" json:"rules"`
+}
+
+// Event is a step of the point through the stages.
+type Event struct {
+	Time    time.Time `json:"time"`
+	Stage   string    `json:"stage"`
+	Message string    `json:"message,omitempty"`
+}
+
+// Trace is the delivery path of a point.
+type Trace struct {
+	ID          int64             `json:"id"`
+	Input       string            `json:"input"`
+	Category    string            `json:"category"`
+	Measurement string            `json:"measurement"`
+	Tags        map[string]string `json:"tags"`
+	Time        time.Time         `json:"time"` // the point time
+
+	// BatchID is the X-Batch-ID of the upload bodies, the point is encoded
+	// into one of the bodies.
+	BatchID string `json:"batch_id,omitempty"`
+
+	Events        []*Event `json:"events"`
+	DroppedEvents int      `json:"dropped_events,omitempty"`
+
+	pt *point.Point
+}
+
+func (t *Trace) add(stage, msg string, now time.Time) {
+	if len(t.Events) >= maxEvents {
+		t.DroppedEvents++
+		return
+	}
+
+	t.Events = append(t.Events, &Event{Time: now, Stage: stage, Message: msg})
+}
+
+type tracer struct {
+	mtx   sync.Mutex
+	rules []*Rule
+	max   int
+	seq   int64
+
+	traces  []*Trace
+	byPoint map[*point.Point]*Trace
+	byBatch map[string][]*Trace
+}
+
+// defTracer is nil if tracing disabled.
+var defTracer *tracer
+
+// Setup enable the tracing on c, or disable it on nil c.
+func Setup(c *Conf) {
+	if c == nil || !c.Enable || len(c.Rules) == 0 {
+		defTracer = nil
+		return
+	}
+
+	t := &tracer{
+		rules:   c.Rules,
+		max:     c.MaxTraces,
+		byPoint: map[*point.Point]*Trace{},
+		byBatch: map[string][]*Trace{},
+	}
+
+	if t.max <= 0 {
+		t.max = defaultMaxTraces
+	}
+
+	defTracer = t
+}
+
+// Enabled check if the tracing enabled.
+func Enabled() bool {
+	return defTracer != nil
+}
+
+func (x *tracer) matched(cat point.Category, pt *point.Point) bool {
+	for _, r := range x.rules {
+		if r != nil && r.match(cat, pt) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// evict remove the oldest traces that exceed the max traces.
+func (x *tracer) evict() {
+	for len(x.traces) > x.max {
+		t := x.traces[0]
+		x.traces[0] = nil
+		x.traces = x.traces[1:]
+
+		if x.byPoint[t.pt] == t {
+			delete(x.byPoint, t.pt)
+		}
+
+		if t.BatchID != "" {
+			arr := x.byBatch[t.BatchID]
+			for i := range arr {
+				if arr[i] == t {
+					arr = append(arr[:i], arr[i+1:]...)
+					break
+				}
+			}
+
+			if len(arr) == 0 {
+				delete(x.byBatch, t.BatchID)
+			} else {
+				x.byBatch[t.BatchID] = arr
+			}
+		}
+	}
+}
+
+// Feeding is the points traced during the feed.
+type Feeding struct {
+	pts []*point.Point
+}
+
+// Feed start tracing the matched points, nil returned if nothing matched.
+func Feed(input string, cat point.Category, pts []*point.Point) *Feeding {
+	x := defTracer
+	if x == nil {
+		return nil
+	}
+
+	var f *Feeding
+	now := time.Now()
+
+	x.mtx.Lock()
+	defer x.mtx.Unlock()
+
+	for _, pt := range pts {
+		if pt == nil || !x.matched(cat, pt) {
+			continue
+		}
+
+		if _, ok := x.byPoint[pt]; ok { // fed again
+			continue
+		}
+
+		x.seq++
+		t := &Trace{
+			ID:          x.seq,
+			Input:       input,
+			Category:    cat.String(),
+			Measurement: pt.Name(),
+			Tags:        pt.MapTags(),
+			Time:        pt.Time(),
+			pt:          pt,
+		}
+		t.add(StageFeed, fmt.Sprintf("fed by %s", input), now)
+
+		x.traces = append(x.traces, t)
+		x.byPoint[pt] = t
+
+		if f == nil {
+			f = &Feeding{}
+		}
+		f.pts = append(f.pts, pt)
+	}
+
+	x.evict()
+	return f
+}
+
+// Keep record the traced points not within pts as dropped on stage.
+func (f *Feeding) Keep(pts []*point.Point, stage, msg string) {
+	x := defTracer
+	if f == nil || x == nil {
+		return
+	}
+
+	kept := make(map[*point.Point]struct{}, len(pts))
+	for _, pt := range pts {
+		kept[pt] = struct{}{}
+	}
+
+	var (
+		left []*point.Point
+		now  = time.Now()
+	)
+
+	x.mtx.Lock()
+	defer x.mtx.Unlock()
+
+	for _, pt := range f.pts {
+		if _, ok := kept[pt]; ok {
+			left = append(left, pt)
+			continue
+		}
+
+		if t, ok := x.byPoint[pt]; ok {
+			t.add(stage, msg, now)
+			delete(x.byPoint, pt)
+		}
+	}
+
+	f.pts = left
+}
+
+// Drop record all the traced points dropped on stage.
+func (f *Feeding) Drop(stage, msg string) {
+	if f == nil {
+		return
+	}
+
+	Release(f.pts, stage, msg)
+	f.pts = nil
+}
+
+// Record add the event to the traced points within pts.
+func Record(pts []*point.Point, stage, msg string) {
+	x := defTracer
+	if x == nil {
+		return
+	}
+
+	now := time.Now()
+
+	x.mtx.Lock()
+	defer x.mtx.Unlock()
+
+	for _, pt := range pts {
+		if t, ok := x.byPoint[pt]; ok {
+			t.add(stage, msg, now)
+		}
+	}
+}
+
+// Release stop tracing the points(if still traced), the points may put back
+// to the point pool and reused, so we can't trace them by pointer any more.
+func Release(pts []*point.Point, stage, msg string) {
+	x := defTracer
+	if x == nil {
+		return
+	}
+
+	now := time.Now()
+
+	x.mtx.Lock()
+	defer x.mtx.Unlock()
+
+	for _, pt := range pts {
+		if t, ok := x.byPoint[pt]; ok {
+			t.add(stage, msg, now)
+			delete(x.byPoint, pt)
+		}
+	}
+}
+
+// Encode switch the traced points within pts to trace by the batch ID of the
+// upload bodies.
+func Encode(pts []*point.Point, batchID string) {
+	x := defTracer
+	if x == nil {
+		return
+	}
+
+	now := time.Now()
+
+	x.mtx.Lock()
+	defer x.mtx.Unlock()
+
+	for _, pt := range pts {
+		if t, ok := x.byPoint[pt]; ok {
+			t.add(StageEncode, fmt.Sprintf("encoded within batch %s", batchID), now)
+			t.BatchID = batchID
+			x.byBatch[batchID] = append(x.byBatch[batchID], t)
+			delete(x.byPoint, pt)
+		}
+	}
+}
+
+// Batch add the event to the traced points within the batch of the body key
+// (in form of <batch-id>:<seq>).
+func Batch(key, stage, msg string) {
+	x := defTracer
+	if x == nil || key == "" {
+		return
+	}
+
+	id, seq := key, ""
+	if i := strings.LastIndexByte(key, ':'); i > 0 {
+		id, seq = key[:i], key[i+1:]
+	}
+
+	now := time.Now()
+
+	x.mtx.Lock()
+	defer x.mtx.Unlock()
+
+	for _, t := range x.byBatch[id] {
+		t.add(stage, fmt.Sprintf("body %s: %s", seq, msg), now)
+	}
+}
+
+// Traces list the latest traces match the measurement(if not empty), newest
+// first.
+func Traces(measurement string, limit int) []*Trace {
+	x := defTracer
+	if x == nil {
+		return nil
+	}
+
+	x.mtx.Lock()
+	defer x.mtx.Unlock()
+
+	res := []*Trace{}
+	for i := len(x.traces) - 1; i >= 0; i-- {
+		t := x.traces[i]
+		if measurement != "" && t.Measurement != measurement {
+			continue
+		}
+
+		res = append(res, t.copy())
+		if limit > 0 && len(res) >= limit {
+			break
+		}
+	}
+
+	return res
+}
+
+func (t *Trace) copy() *Trace {
+	c := *t
+	c.Events = append([]*Event{}, t.Events...)
+	c.pt = nil
+
+	return &c
+}
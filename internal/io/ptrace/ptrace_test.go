@@ -0,0 +1,143 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package ptrace
+
+import (
+	T "testing"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPoint(name, host string) *point.Point {
+	return point.NewPointV2(name,
+		point.NewTags(map[string]string{"host": host}).AddV2("message", "x", true),
+		point.DefaultLoggingOptions()...)
+}
+
+func stages(t *Trace) (res []string) {
+	for _, e := range t.Events {
+		res = append(res, e.Stage)
+	}
+	return res
+}
+
+func TestPointTrace(t *T.T) {
+	t.Run("disabled", func(t *T.T) {
+		Setup(nil)
+		t.Cleanup(func() { Setup(nil) })
+
+		assert.False(t, Enabled())
+		f := Feed("test", point.Logging, []*point.Point{newPoint("nginx", "web-01")})
+		assert.Nil(t, f)
+
+		f.Keep(nil, StagePipeline, "")
+		f.Drop(StageDownsample, "")
+		assert.Nil(t, Traces("", 0))
+
+		Setup(&Conf{Enable: true}) // no rules
+		assert.False(t, Enabled())
+	})
+
+	t.Run("delivered", func(t *T.T) {
+		Setup(&Conf{Enable: true, Rules: []*Rule{
+			{Category: "logging", Measurement: "nginx", Tags: map[string]string{"host": "web-01"}},
+		}})
+		t.Cleanup(func() { Setup(nil) })
+		require.True(t, Enabled())
+
+		pts := []*point.Point{
+			newPoint("nginx", "web-01"),
+			newPoint("nginx", "web-02"),
+			newPoint("mysql", "web-01"),
+		}
+
+		assert.Nil(t, Feed("test", point.Metric, pts)) // category not matched
+
+		f := Feed("test", point.Logging, pts)
+		require.NotNil(t, f)
+		f.Keep(pts, StagePipeline, "dropped by pipeline")
+
+		Record(pts, StageQueue, "cached")
+		Encode(pts, "batch-1")
+		Record(pts, StageFlush, "not traced by point after encoded")
+		Batch("batch-1:0", StageWAL, "put to mem queue")
+		Batch("batch-1:0", StageUpload, "sent to localhost")
+		Batch("batch-2:0", StageUpload, "sent to localhost")
+
+		traces := Traces("", 0)
+		require.Len(t, traces, 1)
+
+		tr := traces[0]
+		assert.Equal(t, "nginx", tr.Measurement)
+		assert.Equal(t, "logging", tr.Category)
+		assert.Equal(t, "batch-1", tr.BatchID)
+		assert.Equal(t, []string{StageFeed, StageQueue, StageEncode, StageWAL, StageUpload}, stages(tr))
+		assert.Equal(t, "body 0: sent to localhost", tr.Events[4].Message)
+
+		assert.Len(t, Traces("mysql", 0), 0)
+	})
+
+	t.Run("dropped", func(t *T.T) {
+		Setup(&Conf{Enable: true, Rules: []*Rule{{Measurement: "nginx"}}})
+		t.Cleanup(func() { Setup(nil) })
+
+		pts := []*point.Point{newPoint("nginx", "web-01"), newPoint("nginx", "web-02")}
+
+		f := Feed("test", point.Logging, pts)
+		f.Keep(pts[:1], StagePipeline, "dropped by pipeline")
+		f.Drop(StageDownsample, "aggregated")
+
+		// the released points not traced any more
+		Record(pts, StageQueue, "cached")
+
+		traces := Traces("nginx", 0)
+		require.Len(t, traces, 2)
+
+		// newest first
+		assert.Equal(t, []string{StageFeed, StagePipeline}, stages(traces[0]))
+		assert.Equal(t, []string{StageFeed, StageDownsample}, stages(traces[1]))
+		assert.Equal(t, "web-02", traces[0].Tags["host"])
+	})
+
+	t.Run("evict", func(t *T.T) {
+		Setup(&Conf{Enable: true, MaxTraces: 3, Rules: []*Rule{{}}})
+		t.Cleanup(func() { Setup(nil) })
+
+		var pts []*point.Point
+		for i := 0; i < 5; i++ {
+			pt := newPoint("nginx", "web-01")
+			pts = append(pts, pt)
+			Feed("test", point.Logging, []*point.Point{pt})
+			Encode([]*point.Point{pt}, "batch-1")
+		}
+
+		traces := Traces("", 2)
+		require.Len(t, traces, 2)
+		assert.Equal(t, int64(5), traces[0].ID)
+		assert.Equal(t, int64(4), traces[1].ID)
+
+		assert.Len(t, defTracer.traces, 3)
+		assert.Len(t, defTracer.byBatch["batch-1"], 3)
+		assert.Empty(t, defTracer.byPoint)
+	})
+
+	t.Run("max-events", func(t *T.T) {
+		Setup(&Conf{Enable: true, Rules: []*Rule{{}}})
+		t.Cleanup(func() { Setup(nil) })
+
+		pts := []*point.Point{newPoint("nginx", "web-01")}
+		Feed("test", point.Logging, pts)
+		for i := 0; i < maxEvents+10; i++ {
+			Record(pts, StageQueue, "")
+		}
+
+		tr := Traces("", 0)[0]
+		assert.Len(t, tr.Events, maxEvents)
+		assert.Equal(t, 11, tr.DroppedEvents)
+	})
+}
@@ -0,0 +1,231 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package io
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+)
+
+const (
+	limitPoints = "points"
+	limitBytes  = "bytes"
+)
+
+// RateLimit limit the points and bytes(size of the points) fed per second,
+// 0 means no limit.
+type RateLimit struct {
+	PointsPerSec float64 `toml:"points_per_sec" json:"points_per_sec"`
+	BytesPerSec  float64 `toml:"bytes_per_sec" json:"bytes_per_sec"`
+}
+
+// RateLimitConf configure rate limits on category(the key is category name,
+// such as logging/metric) and on input name.
+type RateLimitConf struct {
+	Categories map[string]*RateLimit `toml:"categories" json:"categories"`
+	Inputs     map[string]*RateLimit `toml:"inputs" json:"inputs"`
+}
+
+// errBackpressure returned by feeding when exceeded the rate limit, the points
+// of the feeding are dropped. Inputs handle it the same as other feeding
+// errors, the RetryAfter is only for the error message.
+type errBackpressure struct {
+	Input    string
+	Category point.Category
+	Limit    string // which limit reached: points or bytes
+	Scope    string // the limit on category or on input
+
+	RetryAfter time.Duration
+}
+
+func (e *errBackpressure) Error() string {
+	return fmt.Sprintf("backpressure on %s/%s: %s limit reached on %s, retry after %s",
+		e.Category.String(), e.Input, e.Limit, e.Scope, e.RetryAfter)
+}
+
+// tokenBucket is a token bucket refilled by rate per second, and the burst is
+// the rate. Different from golang.org/x/time/rate, the tokens can be borrowed
+// as long as it is positive, so a single feeding larger than the rate still
+// passed, and the following feedings wait for the debt.
+type tokenBucket struct {
+	rate,
+	tokens float64
+	last time.Time
+}
+
+func newTokenBucket(rate float64, now time.Time) *tokenBucket {
+	if rate <= 0 {
+		return nil
+	}
+
+	return &tokenBucket{rate: rate, tokens: rate, last: now}
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += b.rate * elapsed.Seconds()
+		if b.tokens > b.rate {
+			b.tokens = b.rate
+		}
+	}
+	b.last = now
+}
+
+// wait return how long to wait for available tokens, 0 if tokens available.
+func (b *tokenBucket) wait() time.Duration {
+	if b.tokens > 0 {
+		return 0
+	}
+
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+type limiter struct {
+	scope string
+	points,
+	bytes *tokenBucket
+}
+
+func newLimiter(scope string, rl *RateLimit, now time.Time) *limiter {
+	if rl == nil || (rl.PointsPerSec <= 0 && rl.BytesPerSec <= 0) {
+		return nil
+	}
+
+	return &limiter{
+		scope:  scope,
+		points: newTokenBucket(rl.PointsPerSec, now),
+		bytes:  newTokenBucket(rl.BytesPerSec, now),
+	}
+}
+
+// check return the reached limit and the wait duration, empty limit if passed.
+func (l *limiter) check(now time.Time) (string, time.Duration) {
+	if l.points != nil {
+		l.points.refill(now)
+		if du := l.points.wait(); du > 0 {
+			return limitPoints, du
+		}
+	}
+
+	if l.bytes != nil {
+		l.bytes.refill(now)
+		if du := l.bytes.wait(); du > 0 {
+			return limitBytes, du
+		}
+	}
+
+	return "", 0
+}
+
+func (l *limiter) take(npts, nbytes int) {
+	if l.points != nil {
+		l.points.tokens -= float64(npts)
+	}
+
+	if l.bytes != nil {
+		l.bytes.tokens -= float64(nbytes)
+	}
+}
+
+type rateLimiter struct {
+	mtx sync.Mutex
+
+	categories map[point.Category]*limiter
+	inputs     map[string]*limiter
+}
+
+func newRateLimiter(c *RateLimitConf) *rateLimiter {
+	if c == nil {
+		return nil
+	}
+
+	var (
+		now = time.Now()
+		rl  = &rateLimiter{
+			categories: map[point.Category]*limiter{},
+			inputs:     map[string]*limiter{},
+		}
+	)
+
+	for k, v := range c.Categories {
+		cat := point.CatString(k)
+		if cat == point.UnknownCategory {
+			log.Warnf("invalid category %q on rate limit, ignored", k)
+			continue
+		}
+
+		if l := newLimiter("category "+k, v, now); l != nil {
+			rl.categories[cat] = l
+		}
+	}
+
+	for k, v := range c.Inputs {
+		if l := newLimiter("input "+k, v, now); l != nil {
+			rl.inputs[k] = l
+		}
+	}
+
+	if len(rl.categories) == 0 && len(rl.inputs) == 0 {
+		return nil
+	}
+
+	return rl
+}
+
+// allow check the feeding on limits of both the input and the category, the
+// tokens are taken only if all limits passed.
+func (rl *rateLimiter) allow(input string, cat point.Category, pts []*point.Point) *errBackpressure {
+	var (
+		now = time.Now()
+		arr = make([]*limiter, 0, 2)
+	)
+
+	if l, ok := rl.inputs[input]; ok {
+		arr = append(arr, l)
+	}
+
+	if l, ok := rl.categories[cat]; ok {
+		arr = append(arr, l)
+	}
+
+	if len(arr) == 0 {
+		return nil
+	}
+
+	rl.mtx.Lock()
+	defer rl.mtx.Unlock()
+
+	for _, l := range arr {
+		if limit, du := l.check(now); limit != "" {
+			return &errBackpressure{
+				Input:      input,
+				Category:   cat,
+				Limit:      limit,
+				Scope:      l.scope,
+				RetryAfter: du,
+			}
+		}
+	}
+
+	nbytes := 0
+	for _, l := range arr {
+		if l.bytes != nil {
+			for _, pt := range pts {
+				nbytes += pt.Size()
+			}
+			break
+		}
+	}
+
+	for _, l := range arr {
+		l.take(len(pts), nbytes)
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package io
+
+import (
+	"errors"
+	T "testing"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenBucket(t *T.T) {
+	now := time.Now()
+	b := newTokenBucket(10, now)
+
+	assert.Zero(t, b.wait())
+
+	// borrow more than the burst
+	b.tokens -= 25
+	assert.Equal(t, 1600*time.Millisecond, b.wait())
+
+	b.refill(now.Add(time.Second))
+	assert.Equal(t, -5.0, b.tokens)
+
+	b.refill(now.Add(time.Minute)) // no more than the burst
+	assert.Equal(t, 10.0, b.tokens)
+
+	assert.Nil(t, newTokenBucket(0, now))
+}
+
+func TestRateLimiter(t *T.T) {
+	t.Run(`no-limit`, func(t *T.T) {
+		assert.Nil(t, newRateLimiter(nil))
+		assert.Nil(t, newRateLimiter(&RateLimitConf{
+			Categories: map[string]*RateLimit{"no-such-category": {PointsPerSec: 1}, "logging": {}},
+		}))
+	})
+
+	t.Run(`points`, func(t *T.T) {
+		rl := newRateLimiter(&RateLimitConf{
+			Categories: map[string]*RateLimit{"logging": {PointsPerSec: 5}},
+		})
+		require.NotNil(t, rl)
+
+		pts := point.RandPoints(10)
+
+		assert.Nil(t, rl.allow("logfwd", point.Logging, pts)) // borrow the tokens
+		assert.Nil(t, rl.allow("cpu", point.Metric, pts))     // no limit on metric
+
+		e := rl.allow("logfwd", point.Logging, pts)
+		require.NotNil(t, e)
+		assert.Equal(t, limitPoints, e.Limit)
+		assert.Equal(t, "category logging", e.Scope)
+		assert.True(t, e.RetryAfter > 0 && e.RetryAfter <= 1200*time.Millisecond)
+
+		var err error = e
+		var bp *errBackpressure
+		assert.True(t, errors.As(err, &bp))
+		t.Logf("%s", err)
+	})
+
+	t.Run(`input-and-category`, func(t *T.T) {
+		rl := newRateLimiter(&RateLimitConf{
+			Categories: map[string]*RateLimit{"logging": {PointsPerSec: 100}},
+			Inputs:     map[string]*RateLimit{"logfwd": {BytesPerSec: 1}},
+		})
+		require.NotNil(t, rl)
+
+		pts := point.RandPoints(10)
+		assert.Nil(t, rl.allow("logfwd", point.Logging, pts))
+
+		e := rl.allow("logfwd", point.Logging, pts)
+		require.NotNil(t, e)
+		assert.Equal(t, limitBytes, e.Limit)
+		assert.Equal(t, "input logfwd", e.Scope)
+
+		// the rejected feeding not taken tokens on category
+		assert.InDelta(t, 90.0, rl.categories[point.Logging].points.tokens, 1)
+
+		// other input not limited
+		assert.Nil(t, rl.allow("nginx", point.Logging, pts))
+	})
+}
+
+func TestFeedRateLimited(t *T.T) {
+	old := defIO.limiter
+	defer func() { defIO.limiter = old }()
+
+	defIO.limiter = newRateLimiter(&RateLimitConf{
+		Categories: map[string]*RateLimit{"logging": {PointsPerSec: 5}},
+	})
+	require.NotNil(t, defIO.limiter)
+
+	f := &ioFeeder{}
+	var bp *errBackpressure
+
+	t.Run(`feed`, func(t *T.T) {
+		assert.NoError(t, f.Feed("logfwd", point.Logging, point.RandPoints(10)))
+
+		err := f.Feed("logfwd", point.Logging, point.RandPoints(10))
+		require.True(t, errors.As(err, &bp), "got %v", err)
+		assert.Equal(t, "category logging", bp.Scope)
+
+		assert.NoError(t, f.Feed("cpu", point.Metric, point.RandPoints(10)))
+	})
+
+	t.Run(`feed-v2`, func(t *T.T) {
+		err := f.FeedV2(point.Logging, point.RandPoints(10), WithInputName("logfwd"))
+		require.True(t, errors.As(err, &bp), "got %v", err)
+		assert.Equal(t, limitPoints, bp.Limit)
+	})
+}
@@ -0,0 +1,219 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package io
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+
+	"github.com/GuanceCloud/cliutils/point"
+)
+
+const (
+	defaultSchemaMaxFields = 100000
+
+	fieldTypeInt    = "int"
+	fieldTypeFloat  = "float"
+	fieldTypeString = "string"
+	fieldTypeBool   = "bool"
+
+	schemaConflictDetected = "detected"
+	schemaConflictCoerced  = "coerced"
+	schemaConflictDropped  = "dropped"
+)
+
+// SchemaCheckConf configure the field type check on points before uploading.
+// The type of each field(int/float/string/bool) on the measurement is the type
+// seen first, and the fields of other types on later points are conflicts,
+// which are usually rejected by the backend.
+type SchemaCheckConf struct {
+	Enable bool `toml:"enable" json:"enable"`
+
+	// Convert the conflicted field to the type seen first, the field that
+	// unable to convert is dropped. If disabled, the conflicts are only logged.
+	Coerce bool `toml:"coerce" json:"coerce"`
+
+	// Max fields remembered, the fields of new measurements are not checked
+	// if exceeded, default 100000.
+	MaxFields int `toml:"max_fields" json:"max_fields"`
+}
+
+type schemaField struct {
+	typ    string
+	input  string              // the input that the type first seen from
+	warned map[string]struct{} // conflicted types already logged
+}
+
+type schemaChecker struct {
+	mtx       sync.Mutex
+	coerce    bool
+	maxFields int
+	fields    map[string]*schemaField // key is category/measurement/field
+}
+
+func newSchemaChecker(c *SchemaCheckConf) *schemaChecker {
+	if c == nil || !c.Enable {
+		return nil
+	}
+
+	sc := &schemaChecker{
+		coerce:    c.Coerce,
+		maxFields: c.MaxFields,
+		fields:    map[string]*schemaField{},
+	}
+
+	if sc.maxFields <= 0 {
+		sc.maxFields = defaultSchemaMaxFields
+	}
+
+	return sc
+}
+
+func fieldType(v any) string {
+	switch v.(type) {
+	case int64, uint64:
+		return fieldTypeInt
+	case float64:
+		return fieldTypeFloat
+	case string:
+		return fieldTypeString
+	case bool:
+		return fieldTypeBool
+	default: // such as []byte and arrays not checked
+		return ""
+	}
+}
+
+// coerceField convert the value to the type, ok is false if unable to convert.
+func coerceField(v any, typ string) (any, bool) {
+	switch typ {
+	case fieldTypeString:
+		return fmt.Sprintf("%v", v), true
+
+	case fieldTypeFloat:
+		switch x := v.(type) {
+		case int64:
+			return float64(x), true
+		case uint64:
+			return float64(x), true
+		case string:
+			if f, err := strconv.ParseFloat(x, 64); err == nil {
+				return f, true
+			}
+		}
+
+	case fieldTypeInt:
+		switch x := v.(type) {
+		case float64: // only the integral float converted, or we lost the fraction
+			if x == math.Trunc(x) && x >= math.MinInt64 && x < math.MaxInt64 {
+				return int64(x), true
+			}
+		case string:
+			if i, err := strconv.ParseInt(x, 10, 64); err == nil {
+				return i, true
+			}
+		}
+
+	case fieldTypeBool:
+		if x, ok := v.(string); ok {
+			if b, err := strconv.ParseBool(x); err == nil {
+				return b, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// check the field types of the points, the conflicted fields are logged, and
+// coerced or dropped if coerce enabled.
+func (sc *schemaChecker) check(input string, cat point.Category, pts []*point.Point) {
+	sc.mtx.Lock()
+	defer sc.mtx.Unlock()
+
+	type conflict struct {
+		key string
+		val any
+		sf  *schemaField
+	}
+
+	var (
+		catStr    = cat.String()
+		conflicts []conflict
+		counts    = map[string]int{}
+	)
+
+	for _, pt := range pts {
+		conflicts = conflicts[:0]
+
+		for _, kv := range pt.KVs() {
+			if kv.IsTag {
+				continue
+			}
+
+			v := kv.Raw()
+			typ := fieldType(v)
+			if typ == "" {
+				continue
+			}
+
+			k := catStr + "/" + pt.Name() + "/" + kv.Key
+			sf, ok := sc.fields[k]
+			if !ok {
+				if len(sc.fields) < sc.maxFields {
+					sc.fields[k] = &schemaField{typ: typ, input: input}
+				}
+				continue
+			}
+
+			if sf.typ == typ {
+				continue
+			}
+
+			if _, ok := sf.warned[typ]; !ok {
+				if sf.warned == nil {
+					sf.warned = map[string]struct{}{}
+				}
+				sf.warned[typ] = struct{}{}
+
+				log.Warnw("field type conflict",
+					"input", input,
+					"category", catStr,
+					"measurement", pt.Name(),
+					"field", kv.Key,
+					"type", typ,
+					"expected_type", sf.typ,
+					"expected_type_input", sf.input,
+					"value", v)
+			}
+
+			conflicts = append(conflicts, conflict{key: kv.Key, val: v, sf: sf})
+		}
+
+		if !sc.coerce {
+			counts[schemaConflictDetected] += len(conflicts)
+			continue
+		}
+
+		for _, c := range conflicts {
+			if x, ok := coerceField(c.val, c.sf.typ); ok {
+				pt.MustAdd(c.key, x)
+				counts[schemaConflictCoerced]++
+			} else {
+				pt.Del(c.key)
+				counts[schemaConflictDropped]++
+			}
+		}
+	}
+
+	for action, n := range counts {
+		if n > 0 {
+			inputsFieldTypeConflictVec.WithLabelValues(input, catStr, action).Add(float64(n))
+		}
+	}
+}
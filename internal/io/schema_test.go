@@ -0,0 +1,108 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package io
+
+import (
+	"testing"
+
+	"github.com/GuanceCloud/cliutils/metrics"
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaCheck(t *testing.T) {
+	newPt := func(name string, fields map[string]any) *point.Point {
+		return point.NewPointV2(name, point.NewKVs(fields), point.DefaultLoggingOptions()...)
+	}
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(inputsFieldTypeConflictVec)
+
+	conflicts := func(input, action string) float64 {
+		mfs, err := reg.Gather()
+		require.NoError(t, err)
+
+		m := metrics.GetMetricOnLabels(mfs, "datakit_io_input_field_type_conflict_total", action, "metric", input)
+		require.NotNil(t, m)
+		return m.GetCounter().GetValue()
+	}
+
+	assert.Nil(t, newSchemaChecker(nil))
+	assert.Nil(t, newSchemaChecker(&SchemaCheckConf{Coerce: true}))
+
+	t.Run("detect", func(t *testing.T) {
+		MetricsReset()
+
+		sc := newSchemaChecker(&SchemaCheckConf{Enable: true})
+		require.NotNil(t, sc)
+		assert.Equal(t, defaultSchemaMaxFields, sc.maxFields)
+
+		sc.check("cpu", point.Metric, []*point.Point{newPt("cpu", map[string]any{"usage": 1.5, "cores": int64(4)})})
+		pts := []*point.Point{
+			newPt("cpu", map[string]any{"usage": int64(2), "cores": uint64(8)}),
+			newPt("cpu", map[string]any{"usage": "3"}),
+		}
+		sc.check("other", point.Metric, pts)
+
+		// not coerced
+		assert.Equal(t, int64(2), pts[0].Get("usage"))
+		assert.Equal(t, "3", pts[1].Get("usage"))
+		assert.Len(t, sc.fields["metric/cpu/usage"].warned, 2)
+		assert.Equal(t, "cpu", sc.fields["metric/cpu/usage"].input)
+
+		assert.Equal(t, 2.0, conflicts("other", schemaConflictDetected))
+
+		// the same field on different category not conflicted
+		pt := newPt("cpu", map[string]any{"usage": "x"})
+		sc.check("cpu", point.Logging, []*point.Point{pt})
+		assert.Equal(t, "x", pt.Get("usage"))
+	})
+
+	t.Run("coerce", func(t *testing.T) {
+		MetricsReset()
+
+		sc := newSchemaChecker(&SchemaCheckConf{Enable: true, Coerce: true})
+		sc.check("m", point.Metric, []*point.Point{newPt("m", map[string]any{
+			"f": 1.5, "i": int64(1), "s": "a", "b": true,
+		})})
+
+		pts := []*point.Point{
+			newPt("m", map[string]any{"f": int64(2), "i": 3.0, "s": int64(4), "b": "true"}),
+			newPt("m", map[string]any{"f": "2.5", "i": "5", "s": false, "b": "yes"}),
+			newPt("m", map[string]any{"f": true, "i": 3.5}),
+		}
+		sc.check("m", point.Metric, pts)
+
+		assert.Equal(t, 2.0, pts[0].Get("f"))
+		assert.Equal(t, int64(3), pts[0].Get("i"))
+		assert.Equal(t, "4", pts[0].Get("s"))
+		assert.Equal(t, true, pts[0].Get("b"))
+
+		assert.Equal(t, 2.5, pts[1].Get("f"))
+		assert.Equal(t, int64(5), pts[1].Get("i"))
+		assert.Equal(t, "false", pts[1].Get("s"))
+		assert.Nil(t, pts[1].Get("b"))
+
+		assert.Nil(t, pts[2].Get("f"))
+		assert.Nil(t, pts[2].Get("i"))
+
+		assert.Equal(t, 7.0, conflicts("m", schemaConflictCoerced))
+		assert.Equal(t, 3.0, conflicts("m", schemaConflictDropped))
+	})
+
+	t.Run("max-fields", func(t *testing.T) {
+		sc := newSchemaChecker(&SchemaCheckConf{Enable: true, Coerce: true, MaxFields: 1})
+		sc.check("m", point.Metric, []*point.Point{newPt("m", map[string]any{"a": int64(1)})})
+		sc.check("m", point.Metric, []*point.Point{newPt("n", map[string]any{"a": int64(1)})})
+
+		pt := newPt("n", map[string]any{"a": "x"})
+		sc.check("m", point.Metric, []*point.Point{pt})
+		assert.Equal(t, "x", pt.Get("a"))
+		assert.Len(t, sc.fields, 1)
+	})
+}
@@ -0,0 +1,172 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package io
+
+import (
+	"context"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/gogo/protobuf/proto"
+
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/datakit"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/sink"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/sink/kafka"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/sink/otlp"
+)
+
+// SinkConf configure the extra outputs of the points, points are still
+// uploaded to dataway.
+type SinkConf struct {
+	Kafka []*kafka.Config `toml:"kafka" json:"kafka"`
+	OTLP  []*otlp.Config  `toml:"otlp" json:"otlp"`
+}
+
+// sinkQueueSize is the max batches queued on each sink, batches are dropped
+// if the sink can't keep up with.
+const sinkQueueSize = 64
+
+type sinkBatch struct {
+	cat point.Category
+	pts []*point.Point
+}
+
+type sinkRoute struct {
+	sink.Sink
+	categories map[point.Category]bool // empty for all categories
+	queue      chan *sinkBatch
+}
+
+func (r *sinkRoute) accept(cat point.Category) bool {
+	return len(r.categories) == 0 || r.categories[cat]
+}
+
+func newSinkRoute(s sink.Sink) *sinkRoute {
+	r := &sinkRoute{
+		Sink:       s,
+		categories: map[point.Category]bool{},
+		queue:      make(chan *sinkBatch, sinkQueueSize),
+	}
+	for _, cat := range s.Categories() {
+		r.categories[cat] = true
+	}
+	return r
+}
+
+func newSinks(c *SinkConf) (res []*sinkRoute) {
+	if c == nil {
+		return nil
+	}
+
+	for _, kc := range c.Kafka {
+		if kc == nil {
+			continue
+		}
+
+		s, err := kafka.New(kc)
+		if err != nil {
+			log.Errorf("setup Kafka sink on %v: %s, ignored", kc.Addrs, err)
+			continue
+		}
+
+		res = append(res, newSinkRoute(s))
+	}
+
+	for _, oc := range c.OTLP {
+		if oc == nil {
+			continue
+		}
+
+		s, err := otlp.New(oc)
+		if err != nil {
+			log.Errorf("setup OTLP sink on %s: %s, ignored", oc.Endpoint, err)
+			continue
+		}
+
+		res = append(res, newSinkRoute(s))
+	}
+
+	return res
+}
+
+// clonePoints deep copy the points, the points flushed are put back to the
+// pool before the sink written them.
+func clonePoints(pts []*point.Point) []*point.Point {
+	res := make([]*point.Point, 0, len(pts))
+	for _, pt := range pts {
+		pb, ok := proto.Clone(pt.PBPoint()).(*point.PBPoint)
+		if !ok {
+			continue
+		}
+		res = append(res, point.FromPB(pb))
+	}
+
+	return res
+}
+
+// enqueue the copy of the points to the sink, the points dropped if the
+// queue is full.
+func (r *sinkRoute) enqueue(cat point.Category, pts []*point.Point) {
+	if len(r.queue) < cap(r.queue) {
+		select {
+		case r.queue <- &sinkBatch{cat: cat, pts: clonePoints(pts)}:
+			return
+		default:
+		}
+	}
+
+	log.Warnf("queue of sink %s full, drop %d points", r.Name(), len(pts))
+	sinkDroppedVec.WithLabelValues(r.Name(), cat.String()).Add(float64(len(pts)))
+}
+
+func (r *sinkRoute) write(b *sinkBatch) {
+	if err := r.Write(b.cat, b.pts); err != nil {
+		log.Warnf("write %d points to sink %s: %s, ignored", len(b.pts), r.Name(), err)
+		sinkErrorVec.WithLabelValues(r.Name(), b.cat.String()).Inc()
+		return
+	}
+
+	sinkPtsVec.WithLabelValues(r.Name(), b.cat.String()).Add(float64(len(b.pts)))
+}
+
+// run write the queued points to the sink until exit, the sink closed on exit.
+func (r *sinkRoute) run(exit <-chan interface{}) {
+	defer func() {
+		if err := r.Close(); err != nil {
+			log.Warnf("close sink %s: %s, ignored", r.Name(), err)
+		}
+	}()
+
+	for {
+		select {
+		case b := <-r.queue:
+			r.write(b)
+		case <-exit:
+			log.Infof("sink %s exit, %d batches not written", r.Name(), len(r.queue))
+			return
+		}
+	}
+}
+
+// writeSinks queue the points to all sinks accepted the category, the sinks
+// written asynchronously and do not affect uploading to dataway.
+func (x *dkIO) writeSinks(cat point.Category, pts []*point.Point) {
+	for _, s := range x.sinks {
+		if s.accept(cat) {
+			s.enqueue(cat, pts)
+		}
+	}
+}
+
+func (x *dkIO) startSinks() {
+	g := datakit.G("io/sink")
+	for _, s := range x.sinks {
+		s := s
+		g.Go(func(_ context.Context) error {
+			s.run(datakit.Exit.Wait())
+			return nil
+		})
+	}
+}
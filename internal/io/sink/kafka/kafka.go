@@ -0,0 +1,249 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+// Package kafka implements sink that write points to Kafka.
+package kafka
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/logger"
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/IBM/sarama"
+
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/datakit"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/sink"
+	dknet "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/net"
+)
+
+const (
+	EncodingLineProtocol = "line-protocol"
+	EncodingJSON         = "json"
+
+	defaultTopicPrefix = "datakit_"
+	defaultTimeout     = 10 * time.Second
+)
+
+var (
+	_ sink.Sink = (*Sink)(nil)
+
+	l = logger.DefaultSLogger("kafka-sink")
+)
+
+// Config of the Kafka sink.
+type Config struct {
+	Addrs        []string `toml:"addrs" json:"addrs"`
+	KafkaVersion string   `toml:"kafka_version" json:"kafka_version"`
+
+	// Categories sent to Kafka, empty for all categories.
+	Categories []string `toml:"categories" json:"categories"`
+
+	// Topic of each category is topic_prefix + category name(such as
+	// datakit_logging), or set in topics by category name.
+	TopicPrefix string            `toml:"topic_prefix" json:"topic_prefix"`
+	Topics      map[string]string `toml:"topics" json:"topics"`
+
+	// Message encoding of each point, line-protocol(default) or json.
+	Encoding string        `toml:"encoding" json:"encoding"`
+	Timeout  time.Duration `toml:"timeout" json:"timeout"`
+
+	// SASL mechanism PLAIN/SCRAM-SHA-256/SCRAM-SHA-512, empty to disable SASL.
+	SASLMechanism string `toml:"sasl_mechanism" json:"sasl_mechanism"`
+	SASLUsername  string `toml:"sasl_username" json:"sasl_username"`
+	SASLPassword  string `toml:"sasl_password" json:"sasl_password"`
+
+	TLS *dknet.TLSClientConfig `toml:"tls" json:"tls"`
+}
+
+// Sink write each point as a Kafka message, the message key is the host
+// of the point, so points on the same host are in the same partition.
+type Sink struct {
+	name       string
+	cfg        *Config
+	categories []point.Category
+	producer   sarama.SyncProducer
+}
+
+// New create Kafka sink and connect to the brokers.
+func New(c *Config) (*Sink, error) {
+	l = logger.SLogger("kafka-sink")
+
+	sc, err := c.saramaConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := newSink(c)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.producer, err = sarama.NewSyncProducer(c.Addrs, sc); err != nil {
+		return nil, fmt.Errorf("new Kafka producer on %v: %w", c.Addrs, err)
+	}
+
+	l.Infof("Kafka sink on %v started", c.Addrs)
+	return s, nil
+}
+
+func newSink(c *Config) (*Sink, error) {
+	if len(c.Addrs) == 0 {
+		return nil, fmt.Errorf("Kafka addrs not set")
+	}
+
+	switch c.Encoding {
+	case "":
+		c.Encoding = EncodingLineProtocol
+	case EncodingLineProtocol, EncodingJSON:
+	default:
+		return nil, fmt.Errorf("invalid encoding %q, only %s/%s supported", c.Encoding, EncodingLineProtocol, EncodingJSON)
+	}
+
+	if c.TopicPrefix == "" {
+		c.TopicPrefix = defaultTopicPrefix
+	}
+
+	cats, err := sink.ParseCategories(c.Categories)
+	if err != nil {
+		return nil, err
+	}
+
+	for k := range c.Topics {
+		if point.CatString(k) == point.UnknownCategory {
+			return nil, fmt.Errorf("invalid category %q on topics", k)
+		}
+	}
+
+	return &Sink{
+		name:       "kafka/" + strings.Join(c.Addrs, ","),
+		cfg:        c,
+		categories: cats,
+	}, nil
+}
+
+func (c *Config) saramaConfig() (*sarama.Config, error) {
+	sc := sarama.NewConfig()
+	sc.ClientID = "datakit-" + datakit.DatakitHostName
+	sc.Producer.Return.Successes = true // required on sync producer
+	sc.Producer.RequiredAcks = sarama.WaitForLocal
+	sc.Producer.Partitioner = sarama.NewHashPartitioner
+
+	sc.Net.DialTimeout = defaultTimeout
+	if c.Timeout > 0 {
+		sc.Net.DialTimeout = c.Timeout
+		sc.Producer.Timeout = c.Timeout
+	}
+	sc.Net.ReadTimeout = sc.Net.DialTimeout
+	sc.Net.WriteTimeout = sc.Net.DialTimeout
+
+	if c.KafkaVersion != "" {
+		v, err := sarama.ParseKafkaVersion(c.KafkaVersion)
+		if err != nil {
+			return nil, err
+		}
+		sc.Version = v
+	}
+
+	switch mechanism := strings.ToUpper(c.SASLMechanism); mechanism {
+	case "":
+	case sarama.SASLTypePlaintext, sarama.SASLTypeSCRAMSHA256, sarama.SASLTypeSCRAMSHA512:
+		sc.Net.SASL.Enable = true
+		sc.Net.SASL.Mechanism = sarama.SASLMechanism(mechanism)
+		sc.Net.SASL.User = c.SASLUsername
+		sc.Net.SASL.Password = c.SASLPassword
+		sc.Net.SASL.Version = sarama.SASLHandshakeV1
+
+		if mechanism != sarama.SASLTypePlaintext {
+			sc.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+				return newSCRAMClient(mechanism)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("invalid SASL mechanism %q", c.SASLMechanism)
+	}
+
+	if c.TLS != nil {
+		tc, err := c.TLS.TLSConfigWithBase64()
+		if err != nil {
+			return nil, fmt.Errorf("invalid TLS config: %w", err)
+		}
+
+		sc.Net.TLS.Enable = true
+		sc.Net.TLS.Config = tc
+	}
+
+	return sc, nil
+}
+
+func (s *Sink) Name() string {
+	return s.name
+}
+
+func (s *Sink) Categories() []point.Category {
+	return s.categories
+}
+
+func (s *Sink) topic(cat point.Category) string {
+	if t, ok := s.cfg.Topics[cat.String()]; ok && t != "" {
+		return t
+	}
+
+	return s.cfg.TopicPrefix + cat.String()
+}
+
+func (s *Sink) encode(pt *point.Point) ([]byte, error) {
+	if s.cfg.Encoding == EncodingJSON {
+		return json.Marshal(pt)
+	}
+
+	return []byte(pt.LineProto()), nil
+}
+
+func (s *Sink) Write(cat point.Category, pts []*point.Point) error {
+	if len(pts) == 0 {
+		return nil
+	}
+
+	var (
+		topic = s.topic(cat)
+		msgs  = make([]*sarama.ProducerMessage, 0, len(pts))
+	)
+
+	for _, pt := range pts {
+		data, err := s.encode(pt)
+		if err != nil {
+			l.Warnf("encode point %q: %s, ignored", pt.Name(), err)
+			continue
+		}
+
+		host := pt.GetTag("host")
+		if host == "" {
+			host = datakit.DatakitHostName
+		}
+
+		msgs = append(msgs, &sarama.ProducerMessage{
+			Topic: topic,
+			Key:   sarama.StringEncoder(host),
+			Value: sarama.ByteEncoder(data),
+		})
+	}
+
+	if err := s.producer.SendMessages(msgs); err != nil {
+		return fmt.Errorf("send %d messages to topic %s: %w", len(msgs), topic, err)
+	}
+
+	return nil
+}
+
+func (s *Sink) Close() error {
+	if s.producer == nil {
+		return nil
+	}
+
+	return s.producer.Close()
+}
@@ -0,0 +1,123 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package kafka
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	T "testing"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	dknet "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/net"
+)
+
+func TestNewSink(t *T.T) {
+	t.Run("invalid", func(t *T.T) {
+		for name, c := range map[string]*Config{
+			"no-addrs":         {},
+			"invalid-encoding": {Addrs: []string{"localhost:9092"}, Encoding: "csv"},
+			"invalid-category": {Addrs: []string{"localhost:9092"}, Categories: []string{"no-such"}},
+			"invalid-topic":    {Addrs: []string{"localhost:9092"}, Topics: map[string]string{"no-such": "x"}},
+		} {
+			_, err := newSink(c)
+			assert.Error(t, err, name)
+		}
+	})
+
+	t.Run("topic", func(t *T.T) {
+		s, err := newSink(&Config{
+			Addrs:      []string{"localhost:9092"},
+			Categories: []string{"logging", "metric"},
+			Topics:     map[string]string{"logging": "app-logs"},
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, []point.Category{point.Logging, point.Metric}, s.Categories())
+		assert.Equal(t, "app-logs", s.topic(point.Logging))
+		assert.Equal(t, "datakit_metric", s.topic(point.Metric))
+		assert.Equal(t, EncodingLineProtocol, s.cfg.Encoding)
+	})
+}
+
+func TestSaramaConfig(t *T.T) {
+	sc, err := (&Config{SASLMechanism: "scram-sha-512", SASLUsername: "u", SASLPassword: "p"}).saramaConfig()
+	require.NoError(t, err)
+	assert.True(t, sc.Net.SASL.Enable)
+	assert.Equal(t, sarama.SASLMechanism(sarama.SASLTypeSCRAMSHA512), sc.Net.SASL.Mechanism)
+	assert.NotNil(t, sc.Net.SASL.SCRAMClientGeneratorFunc)
+	assert.False(t, sc.Net.TLS.Enable)
+
+	sc, err = (&Config{SASLMechanism: "PLAIN", TLS: &dknet.TLSClientConfig{InsecureSkipVerify: true}}).saramaConfig()
+	require.NoError(t, err)
+	assert.Nil(t, sc.Net.SASL.SCRAMClientGeneratorFunc)
+	assert.True(t, sc.Net.TLS.Enable)
+	assert.True(t, sc.Net.TLS.Config.InsecureSkipVerify)
+
+	_, err = (&Config{SASLMechanism: "GSSAPI"}).saramaConfig()
+	assert.Error(t, err)
+
+	_, err = (&Config{KafkaVersion: "x.y"}).saramaConfig()
+	assert.Error(t, err)
+}
+
+func TestWrite(t *T.T) {
+	pts := []*point.Point{
+		point.NewPointV2("nginx", point.NewTags(map[string]string{"host": "web-1"}).
+			AddV2("message", "hello", true), point.DefaultLoggingOptions()...),
+		point.NewPointV2("nginx", point.NewKVs(map[string]any{"message": "world"}), point.DefaultLoggingOptions()...),
+	}
+
+	t.Run("line-protocol", func(t *T.T) {
+		s, err := newSink(&Config{Addrs: []string{"localhost:9092"}})
+		require.NoError(t, err)
+
+		p := mocks.NewSyncProducer(t, nil)
+		s.producer = p
+
+		var keys []string
+		for range pts {
+			p.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+				assert.Equal(t, "datakit_logging", msg.Topic)
+
+				k, _ := msg.Key.Encode()
+				keys = append(keys, string(k))
+
+				v, _ := msg.Value.Encode()
+				if !strings.HasPrefix(string(v), "nginx") {
+					return errors.New("not line-protocol")
+				}
+				return nil
+			})
+		}
+
+		require.NoError(t, s.Write(point.Logging, pts))
+		assert.Equal(t, "web-1", keys[0])
+		assert.NotEmpty(t, keys[1]) // default to hostname
+		assert.NoError(t, s.Close())
+	})
+
+	t.Run("json-and-fail", func(t *T.T) {
+		s, err := newSink(&Config{Addrs: []string{"localhost:9092"}, Encoding: EncodingJSON})
+		require.NoError(t, err)
+
+		p := mocks.NewSyncProducer(t, nil)
+		s.producer = p
+
+		p.ExpectSendMessageWithCheckerFunctionAndSucceed(func(v []byte) error {
+			return json.Unmarshal(v, &map[string]any{})
+		})
+		p.ExpectSendMessageAndFail(sarama.ErrOutOfBrokers)
+
+		assert.Error(t, s.Write(point.Logging, pts))
+		assert.NoError(t, s.Close())
+	})
+}
@@ -0,0 +1,45 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package kafka
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+
+	"github.com/IBM/sarama"
+	"github.com/xdg-go/scram"
+)
+
+type scramClient struct {
+	*scram.Client
+	*scram.ClientConversation
+	scram.HashGeneratorFcn
+}
+
+func newSCRAMClient(mechanism string) *scramClient {
+	if mechanism == sarama.SASLTypeSCRAMSHA512 {
+		return &scramClient{HashGeneratorFcn: sha512.New}
+	}
+
+	return &scramClient{HashGeneratorFcn: sha256.New}
+}
+
+func (x *scramClient) Begin(userName, password, authzID string) (err error) {
+	x.Client, err = x.HashGeneratorFcn.NewClient(userName, password, authzID)
+	if err != nil {
+		return err
+	}
+	x.ClientConversation = x.Client.NewConversation()
+	return nil
+}
+
+func (x *scramClient) Step(challenge string) (string, error) {
+	return x.ClientConversation.Step(challenge)
+}
+
+func (x *scramClient) Done() bool {
+	return x.ClientConversation.Done()
+}
@@ -0,0 +1,303 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package otlp
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"sort"
+	"strconv"
+
+	"github.com/GuanceCloud/cliutils/point"
+	common "github.com/GuanceCloud/tracing-protos/opentelemetry-gen-go/common/v1"
+	logs "github.com/GuanceCloud/tracing-protos/opentelemetry-gen-go/logs/v1"
+	metrics "github.com/GuanceCloud/tracing-protos/opentelemetry-gen-go/metrics/v1"
+	resource "github.com/GuanceCloud/tracing-protos/opentelemetry-gen-go/resource/v1"
+	trace "github.com/GuanceCloud/tracing-protos/opentelemetry-gen-go/trace/v1"
+
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/datakit"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/git"
+)
+
+// Keys of the points, same as the keys in package trace(which can not be
+// imported here for import cycle).
+const (
+	keySource    = "source"
+	keyStatus    = "status"
+	keyMessage   = "message"
+	keyService   = "service"
+	keyOperation = "operation"
+	keySpanType  = "span_type"
+	keyTraceID   = "trace_id"
+	keySpanID    = "span_id"
+	keyParentID  = "parent_id"
+	keyResource  = "resource"
+	keyStart     = "start"    // in microsecond
+	keyDuration  = "duration" // in microsecond
+
+	spanTypeEntry = "entry"
+	spanTypeExit  = "exit"
+	statusErr     = "error"
+	statusOK      = "ok"
+
+	scopeName = "datakit"
+)
+
+func strValue(s string) *common.AnyValue {
+	return &common.AnyValue{Value: &common.AnyValue_StringValue{StringValue: s}}
+}
+
+func anyValue(v any) *common.AnyValue {
+	switch x := v.(type) {
+	case string:
+		return strValue(x)
+	case int64:
+		return &common.AnyValue{Value: &common.AnyValue_IntValue{IntValue: x}}
+	case uint64:
+		return &common.AnyValue{Value: &common.AnyValue_IntValue{IntValue: int64(x)}}
+	case float64:
+		return &common.AnyValue{Value: &common.AnyValue_DoubleValue{DoubleValue: x}}
+	case bool:
+		return &common.AnyValue{Value: &common.AnyValue_BoolValue{BoolValue: x}}
+	case []byte:
+		return &common.AnyValue{Value: &common.AnyValue_BytesValue{BytesValue: x}}
+	default:
+		return nil
+	}
+}
+
+// attributes convert the tags(and fields if withFields) of the point into
+// attributes, keys in skip are ignored.
+func attributes(pt *point.Point, withFields bool, skip ...string) []*common.KeyValue {
+	var attrs []*common.KeyValue
+
+	for _, kv := range pt.KVs() {
+		if !kv.IsTag && !withFields {
+			continue
+		}
+
+		if contains(skip, kv.Key) {
+			continue
+		}
+
+		if v := anyValue(kv.Raw()); v != nil {
+			attrs = append(attrs, &common.KeyValue{Key: kv.Key, Value: v})
+		}
+	}
+
+	return attrs
+}
+
+func contains(arr []string, s string) bool {
+	for _, x := range arr {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+func newResource(service string) *resource.Resource {
+	attrs := []*common.KeyValue{
+		{Key: "host.name", Value: strValue(datakit.DatakitHostName)},
+	}
+
+	if service != "" {
+		attrs = append(attrs, &common.KeyValue{Key: "service.name", Value: strValue(service)})
+	}
+
+	return &resource.Resource{Attributes: attrs}
+}
+
+func newScope() *common.InstrumentationScope {
+	return &common.InstrumentationScope{Name: scopeName, Version: git.Version}
+}
+
+// toMetrics convert each numeric(and bool) field of the points into a gauge
+// named as measurement_field, the tags are the attributes.
+func toMetrics(pts []*point.Point) *metrics.ResourceMetrics {
+	var arr []*metrics.Metric
+
+	for _, pt := range pts {
+		var (
+			attrs = attributes(pt, false)
+			ts    = uint64(pt.Time().UnixNano())
+		)
+
+		for _, kv := range pt.Fields() {
+			dp := &metrics.NumberDataPoint{Attributes: attrs, TimeUnixNano: ts}
+
+			switch x := kv.Raw().(type) {
+			case int64:
+				dp.Value = &metrics.NumberDataPoint_AsInt{AsInt: x}
+			case uint64:
+				dp.Value = &metrics.NumberDataPoint_AsInt{AsInt: int64(x)}
+			case float64:
+				dp.Value = &metrics.NumberDataPoint_AsDouble{AsDouble: x}
+			case bool:
+				var n int64
+				if x {
+					n = 1
+				}
+				dp.Value = &metrics.NumberDataPoint_AsInt{AsInt: n}
+			default: // non-numeric fields ignored
+				continue
+			}
+
+			arr = append(arr, &metrics.Metric{
+				Name: pt.Name() + "_" + kv.Key,
+				Data: &metrics.Metric_Gauge{Gauge: &metrics.Gauge{DataPoints: []*metrics.NumberDataPoint{dp}}},
+			})
+		}
+	}
+
+	return &metrics.ResourceMetrics{
+		Resource:     newResource(""),
+		ScopeMetrics: []*metrics.ScopeMetrics{{Scope: newScope(), Metrics: arr}},
+	}
+}
+
+// toLogs convert the points into log records, the message field is the body
+// and the status is the severity text.
+func toLogs(pts []*point.Point) *logs.ResourceLogs {
+	arr := make([]*logs.LogRecord, 0, len(pts))
+
+	for _, pt := range pts {
+		r := &logs.LogRecord{
+			TimeUnixNano: uint64(pt.Time().UnixNano()),
+			Attributes: append([]*common.KeyValue{{Key: keySource, Value: strValue(pt.Name())}},
+				attributes(pt, true, keyMessage, keyStatus, keySource)...),
+		}
+
+		if msg, ok := pt.Get(keyMessage).(string); ok {
+			r.Body = strValue(msg)
+		}
+
+		if status := pt.GetTag(keyStatus); status != "" {
+			r.SeverityText = status
+		} else if status, ok := pt.Get(keyStatus).(string); ok {
+			r.SeverityText = status
+		}
+
+		arr = append(arr, r)
+	}
+
+	return &logs.ResourceLogs{
+		Resource:  newResource(""),
+		ScopeLogs: []*logs.ScopeLogs{{Scope: newScope(), LogRecords: arr}},
+	}
+}
+
+// toID convert the trace/span ID into n bytes, the ID may be hex(such as
+// from OpenTelemetry) or decimal(such as from ddtrace).
+func toID(s string, n int) []byte {
+	if s == "" || s == "0" {
+		return nil
+	}
+
+	if len(s) == 2*n {
+		if b, err := hex.DecodeString(s); err == nil {
+			return b
+		}
+	}
+
+	b := make([]byte, n)
+	if u, err := strconv.ParseUint(s, 10, 64); err == nil {
+		binary.BigEndian.PutUint64(b[n-8:], u)
+		return b
+	}
+
+	if x, err := hex.DecodeString(s); err == nil && len(x) <= n {
+		copy(b[n-len(x):], x)
+		return b
+	}
+
+	return nil
+}
+
+func toInt(v any) int64 {
+	switch x := v.(type) {
+	case int64:
+		return x
+	case uint64:
+		return int64(x)
+	case float64:
+		return int64(x)
+	default:
+		return 0
+	}
+}
+
+func toSpan(pt *point.Point) *trace.Span {
+	name := pt.GetTag(keyOperation)
+	if name == "" {
+		name, _ = pt.Get(keyResource).(string)
+	}
+
+	str := func(k string) string {
+		s, _ := pt.Get(k).(string)
+		return s
+	}
+
+	span := &trace.Span{
+		TraceId:      toID(str(keyTraceID), 16),
+		SpanId:       toID(str(keySpanID), 8),
+		ParentSpanId: toID(str(keyParentID), 8),
+		Name:         name,
+		Kind:         trace.Span_SPAN_KIND_INTERNAL,
+		Attributes: attributes(pt, true, keyService, keyOperation, keySpanType, keyStatus, keyMessage,
+			keyTraceID, keySpanID, keyParentID, keyStart, keyDuration),
+		Status: &trace.Status{},
+	}
+
+	switch pt.GetTag(keySpanType) {
+	case spanTypeEntry:
+		span.Kind = trace.Span_SPAN_KIND_SERVER
+	case spanTypeExit:
+		span.Kind = trace.Span_SPAN_KIND_CLIENT
+	}
+
+	switch pt.GetTag(keyStatus) {
+	case statusErr:
+		span.Status.Code = trace.Status_STATUS_CODE_ERROR
+	case statusOK:
+		span.Status.Code = trace.Status_STATUS_CODE_OK
+	}
+
+	if start := toInt(pt.Get(keyStart)); start > 0 {
+		span.StartTimeUnixNano = uint64(start) * 1000
+	} else {
+		span.StartTimeUnixNano = uint64(pt.Time().UnixNano())
+	}
+	span.EndTimeUnixNano = span.StartTimeUnixNano + uint64(toInt(pt.Get(keyDuration)))*1000
+
+	return span
+}
+
+// toSpans convert the points into spans grouped by the service.
+func toSpans(pts []*point.Point) []*trace.ResourceSpans {
+	services := map[string][]*trace.Span{}
+	for _, pt := range pts {
+		svc := pt.GetTag(keyService)
+		services[svc] = append(services[svc], toSpan(pt))
+	}
+
+	names := make([]string, 0, len(services))
+	for svc := range services {
+		names = append(names, svc)
+	}
+	sort.Strings(names)
+
+	res := make([]*trace.ResourceSpans, 0, len(names))
+	for _, svc := range names {
+		res = append(res, &trace.ResourceSpans{
+			Resource:   newResource(svc),
+			ScopeSpans: []*trace.ScopeSpans{{Scope: newScope(), Spans: services[svc]}},
+		})
+	}
+
+	return res
+}
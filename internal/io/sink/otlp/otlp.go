@@ -0,0 +1,211 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+// Package otlp implements sink that export points to OpenTelemetry backends
+// by OTLP/gRPC.
+package otlp
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/logger"
+	"github.com/GuanceCloud/cliutils/point"
+	collogs "github.com/GuanceCloud/tracing-protos/opentelemetry-gen-go/collector/logs/v1"
+	colmetrics "github.com/GuanceCloud/tracing-protos/opentelemetry-gen-go/collector/metrics/v1"
+	coltrace "github.com/GuanceCloud/tracing-protos/opentelemetry-gen-go/collector/trace/v1"
+	logs "github.com/GuanceCloud/tracing-protos/opentelemetry-gen-go/logs/v1"
+	metrics "github.com/GuanceCloud/tracing-protos/opentelemetry-gen-go/metrics/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/metadata"
+
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/sink"
+	dknet "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/net"
+)
+
+const defaultTimeout = 10 * time.Second
+
+var (
+	_ sink.Sink = (*Sink)(nil)
+
+	l = logger.DefaultSLogger("otlp-sink")
+
+	supportedCategories = []point.Category{point.Metric, point.Logging, point.Tracing}
+)
+
+// Config of the OTLP/gRPC sink.
+type Config struct {
+	// Endpoint of the OTLP/gRPC receiver, such as localhost:4317.
+	Endpoint string `toml:"endpoint" json:"endpoint"`
+
+	// Categories exported, only metric/logging/tracing supported, empty for all of them.
+	Categories []string `toml:"categories" json:"categories"`
+
+	// HTTP headers(gRPC metadata) such as the auth token of the backend.
+	Headers map[string]string `toml:"headers" json:"headers"`
+
+	Compression string        `toml:"compression" json:"compression"` // gzip or empty
+	Timeout     time.Duration `toml:"timeout" json:"timeout"`
+
+	// Insecure disable TLS on the connection, or TLS enabled with the TLS config.
+	Insecure bool                   `toml:"insecure" json:"insecure"`
+	TLS      *dknet.TLSClientConfig `toml:"tls" json:"tls"`
+}
+
+// Sink export the metric as gauges, the logging as log records and the
+// tracing as spans.
+type Sink struct {
+	cfg        *Config
+	categories []point.Category
+
+	conn    *grpc.ClientConn
+	metrics colmetrics.MetricsServiceClient
+	logs    collogs.LogsServiceClient
+	trace   coltrace.TraceServiceClient
+}
+
+// New create OTLP sink, the connection established lazily.
+func New(c *Config) (*Sink, error) {
+	l = logger.SLogger("otlp-sink")
+
+	if c.Endpoint == "" {
+		return nil, fmt.Errorf("OTLP endpoint not set")
+	}
+
+	if c.Timeout <= 0 {
+		c.Timeout = defaultTimeout
+	}
+
+	s := &Sink{cfg: c}
+
+	cats, err := sink.ParseCategories(c.Categories)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, cat := range cats {
+		if !supported(cat) {
+			return nil, fmt.Errorf("category %s not supported on OTLP, only metric/logging/tracing", cat)
+		}
+	}
+
+	if s.categories = cats; len(cats) == 0 {
+		s.categories = supportedCategories
+	}
+
+	opts, err := c.dialOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	if s.conn, err = grpc.Dial(c.Endpoint, opts...); err != nil {
+		return nil, fmt.Errorf("dial OTLP endpoint %s: %w", c.Endpoint, err)
+	}
+
+	s.metrics = colmetrics.NewMetricsServiceClient(s.conn)
+	s.logs = collogs.NewLogsServiceClient(s.conn)
+	s.trace = coltrace.NewTraceServiceClient(s.conn)
+
+	l.Infof("OTLP sink on %s started", c.Endpoint)
+	return s, nil
+}
+
+func supported(cat point.Category) bool {
+	for _, x := range supportedCategories {
+		if x == cat {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Config) dialOptions() ([]grpc.DialOption, error) {
+	var opts []grpc.DialOption
+
+	switch c.Compression {
+	case "":
+	case gzip.Name:
+		opts = append(opts, grpc.WithDefaultCallOptions(grpc.UseCompressor(gzip.Name)))
+	default:
+		return nil, fmt.Errorf("invalid compression %q, only gzip supported", c.Compression)
+	}
+
+	if c.Insecure {
+		return append(opts, grpc.WithTransportCredentials(insecure.NewCredentials())), nil
+	}
+
+	tlsConfig := &dknet.TLSClientConfig{}
+	if c.TLS != nil {
+		tlsConfig = c.TLS
+	}
+
+	tc, err := tlsConfig.TLSConfigWithBase64()
+	if err != nil {
+		return nil, fmt.Errorf("invalid TLS config: %w", err)
+	}
+
+	return append(opts, grpc.WithTransportCredentials(credentials.NewTLS(tc))), nil
+}
+
+func (s *Sink) Name() string {
+	return "otlp/" + s.cfg.Endpoint
+}
+
+func (s *Sink) Categories() []point.Category {
+	return s.categories
+}
+
+// Write export the points, the export blocked up to the timeout configured,
+// which only blocks the queue of the sink.
+func (s *Sink) Write(cat point.Category, pts []*point.Point) error {
+	if len(pts) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.Timeout)
+	defer cancel()
+
+	if len(s.cfg.Headers) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, metadata.New(s.cfg.Headers))
+	}
+
+	var err error
+
+	//nolint:exhaustive
+	switch cat {
+	case point.Metric:
+		_, err = s.metrics.Export(ctx, &colmetrics.ExportMetricsServiceRequest{
+			ResourceMetrics: []*metrics.ResourceMetrics{toMetrics(pts)},
+		})
+	case point.Logging:
+		_, err = s.logs.Export(ctx, &collogs.ExportLogsServiceRequest{
+			ResourceLogs: []*logs.ResourceLogs{toLogs(pts)},
+		})
+	case point.Tracing:
+		_, err = s.trace.Export(ctx, &coltrace.ExportTraceServiceRequest{
+			ResourceSpans: toSpans(pts),
+		})
+	default:
+		return fmt.Errorf("category %s not supported on OTLP", cat)
+	}
+
+	if err != nil {
+		return fmt.Errorf("export %d %s points: %w", len(pts), cat, err)
+	}
+
+	return nil
+}
+
+func (s *Sink) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+
+	return s.conn.Close()
+}
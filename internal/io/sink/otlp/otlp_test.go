@@ -0,0 +1,230 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package otlp
+
+import (
+	"context"
+	"net"
+	"sync"
+	T "testing"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	collogs "github.com/GuanceCloud/tracing-protos/opentelemetry-gen-go/collector/logs/v1"
+	colmetrics "github.com/GuanceCloud/tracing-protos/opentelemetry-gen-go/collector/metrics/v1"
+	coltrace "github.com/GuanceCloud/tracing-protos/opentelemetry-gen-go/collector/trace/v1"
+	metrics "github.com/GuanceCloud/tracing-protos/opentelemetry-gen-go/metrics/v1"
+	trace "github.com/GuanceCloud/tracing-protos/opentelemetry-gen-go/trace/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestToID(t *T.T) {
+	assert.Equal(t, []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x30, 0x39}, toID("12345", 16))
+	assert.Equal(t, []byte{0xab, 0xcd, 0, 0, 0, 0, 0, 0x01}, toID("abcd000000000001", 8))
+	assert.Equal(t, []byte{0, 0, 0, 0, 0, 0, 0xab, 0xcd}, toID("abcd", 8))
+	assert.Nil(t, toID("0", 8))
+	assert.Nil(t, toID("not-id", 8))
+}
+
+func TestConvert(t *T.T) {
+	now := time.Unix(1700000000, 0)
+
+	t.Run("metric", func(t *T.T) {
+		pt := point.NewPointV2("cpu", append(point.NewTags(map[string]string{"host": "h1"}),
+			point.NewKVs(map[string]any{"usage": 1.5, "cores": int64(4), "ok": true})...),
+			append(point.DefaultMetricOptions(), point.WithTime(now))...)
+
+		rm := toMetrics([]*point.Point{pt})
+		arr := rm.ScopeMetrics[0].Metrics
+		require.Len(t, arr, 3)
+
+		got := map[string]any{}
+		for _, m := range arr {
+			dp := m.GetGauge().DataPoints[0]
+			assert.Equal(t, uint64(now.UnixNano()), dp.TimeUnixNano)
+			assert.Equal(t, "host", dp.Attributes[0].Key)
+
+			switch v := dp.Value.(type) {
+			case *metrics.NumberDataPoint_AsDouble:
+				got[m.Name] = v.AsDouble
+			case *metrics.NumberDataPoint_AsInt:
+				got[m.Name] = v.AsInt
+			}
+		}
+
+		assert.Equal(t, map[string]any{"cpu_usage": 1.5, "cpu_cores": int64(4), "cpu_ok": int64(1)}, got)
+	})
+
+	t.Run("logging", func(t *T.T) {
+		pt := point.NewPointV2("nginx", append(point.NewTags(map[string]string{"status": "error"}),
+			point.NewKVs(map[string]any{"message": "oops", "cost": int64(3)})...),
+			append(point.DefaultLoggingOptions(), point.WithTime(now))...)
+
+		rl := toLogs([]*point.Point{pt})
+		r := rl.ScopeLogs[0].LogRecords[0]
+
+		assert.Equal(t, "oops", r.Body.GetStringValue())
+		assert.Equal(t, "error", r.SeverityText)
+
+		attrs := map[string]any{}
+		for _, kv := range r.Attributes {
+			attrs[kv.Key] = kv.Value.Value
+		}
+		assert.Contains(t, attrs, "source")
+		assert.Contains(t, attrs, "cost")
+		assert.NotContains(t, attrs, "message")
+	})
+
+	t.Run("tracing", func(t *T.T) {
+		newSpan := func(svc, spanID string) *point.Point {
+			return point.NewPointV2("ddtrace", append(point.NewTags(map[string]string{
+				"service":   svc,
+				"operation": "GET /",
+				"span_type": "entry",
+				"status":    "error",
+			}), point.NewKVs(map[string]any{
+				"trace_id": "123",
+				"span_id":  spanID,
+				"start":    int64(1700000000000000),
+				"duration": int64(1500),
+				"message":  "{}",
+			})...), point.CommonLoggingOptions()...)
+		}
+
+		res := toSpans([]*point.Point{newSpan("b", "1"), newSpan("a", "2"), newSpan("a", "3")})
+		require.Len(t, res, 2)
+		assert.Equal(t, "a", res[0].Resource.Attributes[1].Value.GetStringValue())
+		require.Len(t, res[0].ScopeSpans[0].Spans, 2)
+
+		span := res[1].ScopeSpans[0].Spans[0]
+		assert.Equal(t, "GET /", span.Name)
+		assert.Equal(t, trace.Span_SPAN_KIND_SERVER, span.Kind)
+		assert.Equal(t, trace.Status_STATUS_CODE_ERROR, span.Status.Code)
+		assert.Equal(t, uint64(1700000000000000000), span.StartTimeUnixNano)
+		assert.Equal(t, uint64(1500000), span.EndTimeUnixNano-span.StartTimeUnixNano)
+		assert.Len(t, span.TraceId, 16)
+		assert.Len(t, span.SpanId, 8)
+		assert.Nil(t, span.ParentSpanId)
+		assert.Empty(t, span.Attributes)
+	})
+}
+
+type mockCollector struct {
+	colmetrics.UnimplementedMetricsServiceServer
+	collogs.UnimplementedLogsServiceServer
+	coltrace.UnimplementedTraceServiceServer
+
+	mtx     sync.Mutex
+	metrics int
+	logs    int
+	spans   int
+	token   string
+}
+
+func (c *mockCollector) auth(ctx context.Context) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get("token")) > 0 {
+		c.token = md.Get("token")[0]
+	}
+}
+
+func (c *mockCollector) Export(ctx context.Context,
+	req *colmetrics.ExportMetricsServiceRequest,
+) (*colmetrics.ExportMetricsServiceResponse, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.auth(ctx)
+	for _, rm := range req.ResourceMetrics {
+		for _, sm := range rm.ScopeMetrics {
+			c.metrics += len(sm.Metrics)
+		}
+	}
+	return &colmetrics.ExportMetricsServiceResponse{}, nil
+}
+
+type mockLogsCollector struct{ *mockCollector }
+
+func (c mockLogsCollector) Export(ctx context.Context, req *collogs.ExportLogsServiceRequest) (*collogs.ExportLogsServiceResponse, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	for _, rl := range req.ResourceLogs {
+		for _, sl := range rl.ScopeLogs {
+			c.logs += len(sl.LogRecords)
+		}
+	}
+	return &collogs.ExportLogsServiceResponse{}, nil
+}
+
+type mockTraceCollector struct{ *mockCollector }
+
+func (c mockTraceCollector) Export(ctx context.Context, req *coltrace.ExportTraceServiceRequest) (*coltrace.ExportTraceServiceResponse, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	for _, rs := range req.ResourceSpans {
+		for _, ss := range rs.ScopeSpans {
+			c.spans += len(ss.Spans)
+		}
+	}
+	return &coltrace.ExportTraceServiceResponse{}, nil
+}
+
+func TestSink(t *T.T) {
+	t.Run("invalid", func(t *T.T) {
+		_, err := New(&Config{})
+		assert.Error(t, err)
+
+		_, err = New(&Config{Endpoint: "localhost:4317", Categories: []string{"object"}})
+		assert.Error(t, err)
+
+		_, err = New(&Config{Endpoint: "localhost:4317", Compression: "zstd"})
+		assert.Error(t, err)
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	mc := &mockCollector{}
+	srv := grpc.NewServer()
+	colmetrics.RegisterMetricsServiceServer(srv, mc)
+	collogs.RegisterLogsServiceServer(srv, mockLogsCollector{mc})
+	coltrace.RegisterTraceServiceServer(srv, mockTraceCollector{mc})
+
+	go srv.Serve(ln) //nolint:errcheck
+	defer srv.Stop()
+
+	s, err := New(&Config{
+		Endpoint:    ln.Addr().String(),
+		Insecure:    true,
+		Compression: "gzip",
+		Headers:     map[string]string{"token": "tkn_xxx"},
+	})
+	require.NoError(t, err)
+	defer s.Close() //nolint:errcheck
+
+	assert.Equal(t, supportedCategories, s.Categories())
+
+	metric := point.NewPointV2("cpu", point.NewKVs(map[string]any{"usage": 1.5, "idle": 98.5}), point.DefaultMetricOptions()...)
+	logging := point.NewPointV2("nginx", point.NewKVs(map[string]any{"message": "hello"}), point.DefaultLoggingOptions()...)
+	span := point.NewPointV2("ddtrace", point.NewKVs(map[string]any{"trace_id": "1", "span_id": "2"}), point.CommonLoggingOptions()...)
+
+	require.NoError(t, s.Write(point.Metric, []*point.Point{metric}))
+	require.NoError(t, s.Write(point.Logging, []*point.Point{logging, logging}))
+	require.NoError(t, s.Write(point.Tracing, []*point.Point{span}))
+	assert.Error(t, s.Write(point.Object, []*point.Point{metric}))
+
+	mc.mtx.Lock()
+	defer mc.mtx.Unlock()
+
+	assert.Equal(t, 2, mc.metrics)
+	assert.Equal(t, 2, mc.logs)
+	assert.Equal(t, 1, mc.spans)
+	assert.Equal(t, "tkn_xxx", mc.token)
+}
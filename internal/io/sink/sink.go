@@ -0,0 +1,44 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+// Package sink defines extra outputs of the points besides dataway.
+package sink
+
+import (
+	"fmt"
+
+	"github.com/GuanceCloud/cliutils/point"
+)
+
+// Sink write points to extra output such as Kafka.
+type Sink interface {
+	// Name of the sink, used in logging and metrics.
+	Name() string
+
+	// Categories the sink accepted, empty for all categories.
+	Categories() []point.Category
+
+	// Write the points of the category. Write() is called on the queue of
+	// the sink apart from uploading to dataway, the points are copies of the
+	// flushed points and owned by the sink.
+	Write(cat point.Category, pts []*point.Point) error
+
+	// Close called on Datakit exit.
+	Close() error
+}
+
+// ParseCategories convert category names into categories.
+func ParseCategories(arr []string) ([]point.Category, error) {
+	var cats []point.Category
+	for _, x := range arr {
+		cat := point.CatString(x)
+		if cat == point.UnknownCategory {
+			return nil, fmt.Errorf("invalid category %q", x)
+		}
+		cats = append(cats, cat)
+	}
+
+	return cats, nil
+}
@@ -0,0 +1,168 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package io
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/metrics"
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockSink blocked on Write until released, such as OTLP export on
+// unreachable endpoint.
+type blockSink struct {
+	mockSink
+	release chan struct{}
+}
+
+func (s *blockSink) Write(cat point.Category, pts []*point.Point) error {
+	<-s.release
+	return s.mockSink.Write(cat, pts)
+}
+
+type mockSink struct {
+	cats   []point.Category
+	err    error
+	got    map[point.Category]int
+	closed bool
+}
+
+func (s *mockSink) Name() string                 { return "mock" }
+func (s *mockSink) Categories() []point.Category { return s.cats }
+func (s *mockSink) Close() error                 { s.closed = true; return nil }
+
+func (s *mockSink) Write(cat point.Category, pts []*point.Point) error {
+	if s.err != nil {
+		return s.err
+	}
+
+	s.got[cat] += len(pts)
+	return nil
+}
+
+func TestWriteSinks(t *testing.T) {
+	t.Cleanup(MetricsReset)
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(Metrics()...)
+
+	all := &mockSink{got: map[point.Category]int{}}
+	logging := &mockSink{cats: []point.Category{point.Logging}, got: map[point.Category]int{}}
+	fail := &mockSink{err: errors.New("mocked error")}
+
+	x := &dkIO{sinks: []*sinkRoute{newSinkRoute(all), newSinkRoute(logging), newSinkRoute(fail)}}
+
+	x.writeSinks(point.Logging, point.RandPoints(3))
+	x.writeSinks(point.Metric, point.RandPoints(2))
+
+	for _, r := range x.sinks {
+		for len(r.queue) > 0 {
+			r.write(<-r.queue)
+		}
+	}
+
+	assert.Equal(t, map[point.Category]int{point.Logging: 3, point.Metric: 2}, all.got)
+	assert.Equal(t, map[point.Category]int{point.Logging: 3}, logging.got)
+
+	mfs, err := reg.Gather()
+	require.NoError(t, err)
+
+	m := metrics.GetMetricOnLabels(mfs, "datakit_io_sink_error_total", point.Metric.String(), "mock")
+	require.NotNil(t, m)
+	assert.Equal(t, 1.0, m.GetCounter().GetValue())
+
+	assert.Empty(t, newSinks(nil))
+	assert.Empty(t, newSinks(&SinkConf{}))
+}
+
+func TestSinkQueue(t *testing.T) {
+	t.Cleanup(MetricsReset)
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(Metrics()...)
+
+	t.Run("clone", func(t *testing.T) {
+		pts := point.RandPoints(2)
+		res := clonePoints(pts)
+		require.Len(t, res, 2)
+
+		for i := range pts {
+			assert.NotSame(t, pts[i], res[i])
+			assert.Equal(t, pts[i].LineProto(), res[i].LineProto())
+		}
+
+		// the copies not affected by the points reused
+		pts[0].Reset()
+		assert.NotEmpty(t, res[0].Name())
+	})
+
+	t.Run("drop-on-full", func(t *testing.T) {
+		r := newSinkRoute(&mockSink{got: map[point.Category]int{}})
+		for i := 0; i < sinkQueueSize+2; i++ {
+			r.enqueue(point.Logging, point.RandPoints(3))
+		}
+
+		assert.Len(t, r.queue, sinkQueueSize)
+		mfs, err := reg.Gather()
+		require.NoError(t, err)
+
+		m := metrics.GetMetricOnLabels(mfs, "datakit_io_sink_dropped_point_total", point.Logging.String(), "mock")
+		require.NotNil(t, m)
+		assert.Equal(t, 6.0, m.GetCounter().GetValue())
+	})
+
+	t.Run("slow-sink", func(t *testing.T) {
+		slow := &blockSink{mockSink: mockSink{got: map[point.Category]int{}}, release: make(chan struct{})}
+		x := &dkIO{sinks: []*sinkRoute{newSinkRoute(slow)}}
+
+		exit := make(chan interface{})
+		done := make(chan struct{})
+		go func() {
+			x.sinks[0].run(exit)
+			close(done)
+		}()
+
+		// flushing not blocked by the sink
+		start := time.Now()
+		for i := 0; i < 3; i++ {
+			x.writeSinks(point.Tracing, point.RandPoints(2))
+		}
+		assert.Less(t, time.Since(start), time.Second)
+
+		close(slow.release)
+		assert.Eventually(t, func() bool { return len(x.sinks[0].queue) == 0 }, time.Second, time.Millisecond)
+
+		close(exit)
+		<-done
+		assert.Equal(t, 6, slow.got[point.Tracing])
+	})
+
+	t.Run("close-on-exit", func(t *testing.T) {
+		s := &mockSink{got: map[point.Category]int{}}
+		r := newSinkRoute(s)
+
+		exit := make(chan interface{})
+		done := make(chan struct{})
+		go func() {
+			r.run(exit)
+			close(done)
+		}()
+
+		r.enqueue(point.Logging, point.RandPoints(3))
+		assert.Eventually(t, func() bool { return len(r.queue) == 0 }, time.Second, time.Millisecond)
+
+		close(exit)
+		<-done
+		assert.True(t, s.closed)
+		assert.Equal(t, 3, s.got[point.Logging])
+	})
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/filter"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/ptrace"
 )
 
 // IOConf configure io module in datakit.conf.
@@ -22,5 +23,15 @@ type IOConf struct {
 	CompactInterval time.Duration `toml:"flush_interval"`
 	CompactWorkers  int           `toml:"flush_workers"`
 
+	Batch *BatchConf `toml:"batch,omitempty"`
+
 	Filters map[string]filter.FilterConditions `toml:"filters"`
+
+	RateLimit   *RateLimitConf   `toml:"rate_limit,omitempty"`
+	Downsample  *DownsampleConf  `toml:"downsample,omitempty"`
+	Transforms  []*TransformRule `toml:"transforms,omitempty"`
+	SchemaCheck *SchemaCheckConf `toml:"schema_check,omitempty"`
+	PointTrace  *ptrace.Conf     `toml:"point_trace,omitempty"`
+	PanicReport *PanicReportConf `toml:"panic_report,omitempty"`
+	Sinks       *SinkConf        `toml:"sinks,omitempty"`
 }
@@ -0,0 +1,203 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package io
+
+import (
+	"math"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/gobwas/glob"
+)
+
+// TransformRule transform the points of the matched measurements before
+// uploading. The rule applied in order of: drop by tags, rename fields, unit
+// conversions and add tags.
+type TransformRule struct {
+	// Glob patterns of the measurement(the source of logging), empty matches all.
+	Measurements []string `toml:"measurements" json:"measurements"`
+	// Category name such as metric/logging, empty matches all.
+	Category string `toml:"category" json:"category"`
+
+	// Drop the point if any of the tag matched, the value is glob pattern.
+	DropByTags map[string]string `toml:"drop_by_tags" json:"drop_by_tags"`
+	// Rename the field from the key to the value.
+	RenameFields map[string]string `toml:"rename_fields" json:"rename_fields"`
+	// Multiply the numeric field by the factor, such as 0.001 for ms to s.
+	UnitConversions map[string]float64 `toml:"unit_conversions" json:"unit_conversions"`
+	// Add the static tags, the exist tags are overwritten.
+	AddTags map[string]string `toml:"add_tags" json:"add_tags"`
+}
+
+type transformRule struct {
+	*TransformRule
+
+	cat          point.Category
+	measurements []glob.Glob
+	dropByTags   map[string]glob.Glob
+}
+
+func (r *transformRule) match(cat point.Category, name string) bool {
+	if r.cat != point.UnknownCategory && r.cat != cat {
+		return false
+	}
+
+	if len(r.measurements) == 0 {
+		return true
+	}
+
+	for _, g := range r.measurements {
+		if g.Match(name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *transformRule) drop(pt *point.Point) bool {
+	for k, g := range r.dropByTags {
+		if kv := pt.KVs().Get(k); kv != nil && kv.IsTag && g.Match(kv.GetS()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *transformRule) apply(pt *point.Point) {
+	for from, to := range r.RenameFields {
+		if kv := pt.KVs().Get(from); kv != nil && !kv.IsTag {
+			v := kv.Raw()
+			pt.Del(from)
+			pt.MustAdd(to, v)
+		}
+	}
+
+	for k, factor := range r.UnitConversions {
+		kv := pt.KVs().Get(k)
+		if kv == nil || kv.IsTag {
+			continue
+		}
+
+		// integers keep the type on integral factor, such as 1024 for KiB to B
+		integral := factor == math.Trunc(factor)
+
+		switch v := kv.Raw().(type) {
+		case int64:
+			if integral {
+				pt.MustAdd(k, v*int64(factor))
+			} else {
+				pt.MustAdd(k, float64(v)*factor)
+			}
+		case uint64:
+			if integral && factor >= 0 {
+				pt.MustAdd(k, v*uint64(factor))
+			} else {
+				pt.MustAdd(k, float64(v)*factor)
+			}
+		case float64:
+			pt.MustAdd(k, v*factor)
+		default: // non-numeric fields not converted
+		}
+	}
+
+	for k, v := range r.AddTags {
+		pt.MustAddTag(k, v)
+	}
+}
+
+type transformer struct {
+	rules []*transformRule
+}
+
+func newTransformer(rules []*TransformRule) *transformer {
+	t := &transformer{}
+
+	for idx, r := range rules {
+		if r == nil {
+			continue
+		}
+
+		tr := &transformRule{
+			TransformRule: r,
+			dropByTags:    map[string]glob.Glob{},
+		}
+
+		if r.Category != "" {
+			if tr.cat = point.CatString(r.Category); tr.cat == point.UnknownCategory {
+				log.Warnf("invalid category %q on transform rule %d, ignored", r.Category, idx)
+				continue
+			}
+		}
+
+		valid := true
+		for _, m := range r.Measurements {
+			g, err := glob.Compile(m)
+			if err != nil {
+				log.Warnf("invalid measurement %q on transform rule %d: %s, ignored", m, idx, err)
+				valid = false
+				break
+			}
+			tr.measurements = append(tr.measurements, g)
+		}
+
+		for k, v := range r.DropByTags {
+			g, err := glob.Compile(v)
+			if err != nil {
+				log.Warnf("invalid tag %s=%q on transform rule %d: %s, ignored", k, v, idx, err)
+				valid = false
+				break
+			}
+			tr.dropByTags[k] = g
+		}
+
+		if valid {
+			t.rules = append(t.rules, tr)
+		}
+	}
+
+	if len(t.rules) == 0 {
+		return nil
+	}
+
+	return t
+}
+
+// transform apply the rules on the points, the dropped points are removed.
+func (t *transformer) transform(input string, cat point.Category, pts []*point.Point) []*point.Point {
+	var (
+		res     = pts[:0]
+		dropped = 0
+	)
+
+	for _, pt := range pts {
+		keep := true
+		for _, r := range t.rules {
+			if !r.match(cat, pt.Name()) {
+				continue
+			}
+
+			if r.drop(pt) {
+				keep = false
+				break
+			}
+
+			r.apply(pt)
+		}
+
+		if keep {
+			res = append(res, pt)
+		} else {
+			dropped++
+		}
+	}
+
+	if dropped > 0 {
+		inputsTransformDroppedPtsVec.WithLabelValues(input, cat.String()).Add(float64(dropped))
+	}
+
+	return res
+}
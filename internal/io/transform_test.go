@@ -0,0 +1,93 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package io
+
+import (
+	"testing"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransform(t *testing.T) {
+	newPt := func(name string, tags map[string]string, fields map[string]any) *point.Point {
+		return point.NewPointV2(name, append(point.NewTags(tags), point.NewKVs(fields)...), point.DefaultMetricOptions()...)
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		assert.Nil(t, newTransformer(nil))
+		assert.Nil(t, newTransformer([]*TransformRule{
+			{Category: "no-such-category"},
+			{Measurements: []string{"[abc"}},
+			nil,
+		}))
+	})
+
+	t.Run("rules", func(t *testing.T) {
+		tf := newTransformer([]*TransformRule{
+			{
+				Measurements:    []string{"nginx*"},
+				Category:        "metric",
+				DropByTags:      map[string]string{"env": "test*"},
+				RenameFields:    map[string]string{"req_ms": "req_time"},
+				UnitConversions: map[string]float64{"req_time": 0.001, "name": 2},
+				AddTags:         map[string]string{"team": "infra", "env": "prod"},
+			},
+			{
+				AddTags: map[string]string{"cluster": "c1"},
+			},
+		})
+		require.NotNil(t, tf)
+
+		pts := []*point.Point{
+			newPt("nginx_req", map[string]string{"env": "dev"}, map[string]any{"req_ms": int64(1500), "ok": true}),
+			newPt("nginx_req", map[string]string{"env": "testing"}, map[string]any{"req_ms": int64(10)}),
+			newPt("redis", map[string]string{"env": "test"}, map[string]any{"req_ms": int64(10)}),
+		}
+
+		res := tf.transform("nginx", point.Metric, pts)
+		require.Len(t, res, 2)
+
+		pt := res[0]
+		assert.Nil(t, pt.Get("req_ms"))
+		assert.Equal(t, 1.5, pt.Get("req_time"))
+		assert.Equal(t, true, pt.Get("ok"))
+		assert.Equal(t, "infra", pt.GetTag("team"))
+		assert.Equal(t, "prod", pt.GetTag("env"))
+		assert.Equal(t, "c1", pt.GetTag("cluster"))
+
+		// not matched on the first rule
+		pt = res[1]
+		assert.Equal(t, "redis", pt.Name())
+		assert.Equal(t, int64(10), pt.Get("req_ms"))
+		assert.Equal(t, "test", pt.GetTag("env"))
+		assert.Equal(t, "", pt.GetTag("team"))
+		assert.Equal(t, "c1", pt.GetTag("cluster"))
+
+		// category not matched
+		res = tf.transform("nginx", point.Logging, []*point.Point{
+			newPt("nginx_req", map[string]string{"env": "test"}, map[string]any{"req_ms": int64(10)}),
+		})
+		require.Len(t, res, 1)
+		assert.Equal(t, int64(10), res[0].Get("req_ms"))
+	})
+
+	t.Run("integral-factor", func(t *testing.T) {
+		tf := newTransformer([]*TransformRule{
+			{UnitConversions: map[string]float64{"mem_kb": 1024, "hits": 2, "ratio": 100}},
+		})
+		require.NotNil(t, tf)
+
+		res := tf.transform("mem", point.Metric, []*point.Point{
+			newPt("mem", nil, map[string]any{"mem_kb": int64(3), "hits": uint64(5), "ratio": 0.5}),
+		})
+		require.Len(t, res, 1)
+		assert.Equal(t, int64(3072), res[0].Get("mem_kb"))
+		assert.Equal(t, uint64(10), res[0].Get("hits"))
+		assert.Equal(t, 50.0, res[0].Get("ratio"))
+	})
+}
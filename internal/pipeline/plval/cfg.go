@@ -41,6 +41,7 @@ type PipelineCfg struct {
 	Offload                *offload.OffloadConfig `toml:"offload"`
 	EnableDebugFields      bool                   `toml:"-"`
 	DefaultPipeline        map[string]string      `toml:"default_pipeline"`
+	RemoteSource           *RemoteSourceCfg       `toml:"remote_source,omitempty"`
 
 	DisableHTTPRequestFunc        bool     `toml:"disable_http_request_func"`
 	HTTPRequestHostWhitelist      []string `toml:"http_request_host_whitelist"`
@@ -50,6 +51,26 @@ type PipelineCfg struct {
 	DeprecatedDisableAppendRunInfo bool `toml:"disable_append_run_info"`
 }
 
+const (
+	RemoteSourceDataway = "dataway"
+	RemoteSourceGit     = "git"
+)
+
+// RemoteSourceCfg configure where the remote pipelines come from. The scripts
+// are pulled from dataway(default) or synced from the git repo, and the scripts
+// under the local dir always override the pulled scripts with the same category
+// and name.
+type RemoteSourceCfg struct {
+	Source   string `toml:"source" json:"source"`
+	LocalDir string `toml:"local_dir" json:"local_dir"`
+
+	GitURL                   string `toml:"git_url" json:"git_url"`
+	GitBranch                string `toml:"git_branch" json:"git_branch"`
+	GitPath                  string `toml:"git_path" json:"git_path"` // sub dir of the scripts within the repo
+	GitSSHPrivateKeyPath     string `toml:"git_ssh_private_key_path" json:"git_ssh_private_key_path"`
+	GitSSHPrivateKeyPassword string `toml:"git_ssh_private_key_password" json:"git_ssh_private_key_password"`
+}
+
 // InitIPdb init ipdb instance.
 func InitIPdb(dataDir string, pipelineCfg *PipelineCfg) (ipdb.IPdb, error) {
 	var ipdbInstance ipdb.IPdb // get ip location and isp
@@ -0,0 +1,344 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package plval
+
+import (
+	"crypto/md5" //nolint:gosec
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	plmanager "github.com/GuanceCloud/cliutils/pipeline/manager"
+	"github.com/GuanceCloud/cliutils/pipeline/ptinput/funcs"
+	"github.com/GuanceCloud/cliutils/point"
+	plengine "github.com/GuanceCloud/platypus/pkg/engine"
+	"github.com/GuanceCloud/platypus/pkg/errchain"
+)
+
+// importRe match the import statement such as `import "common.p"` or
+// `import "metric/common.p"`, the statement must be the only statement on the line.
+var importRe = regexp.MustCompile(`^\s*import\s+"([^"]+)"\s*(?:#.*)?$`)
+
+type scriptKey struct {
+	cat  point.Category
+	name string
+}
+
+func (k scriptKey) String() string {
+	return k.cat.String() + "/" + k.name
+}
+
+// lineOrigin is the script and line number a line of the expanded script
+// comes from, delta is the offset of the line on the original script minus
+// the offset on the expanded script.
+type lineOrigin struct {
+	key   scriptKey
+	ln    int
+	delta int
+}
+
+// importCacheEntry is the expanded script and md5 of the script itself
+// and all scripts imported, lines is the origin of each line of the expanded
+// script, nil if nothing imported.
+type importCacheEntry struct {
+	deps     map[scriptKey]string
+	expanded string
+	lines    []lineOrigin
+}
+
+type importCache struct {
+	mtx     sync.Mutex
+	entries map[string]map[scriptKey]*importCacheEntry // key is namespace
+}
+
+var defImportCache = &importCache{entries: map[string]map[scriptKey]*importCacheEntry{}}
+
+type importer struct {
+	scripts map[point.Category]map[string]string
+	digests map[scriptKey]string
+}
+
+func (imp *importer) source(k scriptKey) (string, bool) {
+	s, ok := imp.scripts[k.cat][k.name]
+	return s, ok
+}
+
+func (imp *importer) digest(k scriptKey) string {
+	if d, ok := imp.digests[k]; ok {
+		return d
+	}
+
+	s, ok := imp.source(k)
+	if !ok {
+		return ""
+	}
+
+	d := fmt.Sprintf("%x", md5.Sum([]byte(s))) //nolint:gosec
+	imp.digests[k] = d
+	return d
+}
+
+// resolve the imported name, the name without category is under the same
+// category of the importing script.
+func (imp *importer) resolve(from scriptKey, name string) (scriptKey, error) {
+	k := scriptKey{cat: from.cat, name: name}
+
+	if idx := strings.Index(name, "/"); idx > 0 {
+		cat := point.CatString(name[:idx])
+		if cat == point.UnknownCategory {
+			return k, fmt.Errorf("invalid category on import %q", name)
+		}
+		k = scriptKey{cat: cat, name: name[idx+1:]}
+	}
+
+	if _, ok := imp.source(k); !ok {
+		return k, fmt.Errorf("import %q not found", name)
+	}
+
+	return k, nil
+}
+
+func (imp *importer) valid(e *importCacheEntry) bool {
+	for k, d := range e.deps {
+		if imp.digest(k) != d {
+			return false
+		}
+	}
+	return true
+}
+
+// expand replace the import statements of the script with the imported
+// scripts recursively, each script imported only once.
+func (imp *importer) expand(k scriptKey) (*importCacheEntry, error) {
+	e := &importCacheEntry{deps: map[scriptKey]string{}}
+
+	var sb strings.Builder
+	if err := imp.doExpand(k, nil, e, &sb); err != nil {
+		return nil, err
+	}
+
+	if len(e.deps) == 1 { // nothing imported, keep the script as it is
+		e.expanded, _ = imp.source(k)
+		e.lines = nil
+	} else {
+		e.expanded = sb.String()
+	}
+
+	return e, nil
+}
+
+func (imp *importer) doExpand(k scriptKey, path []scriptKey, e *importCacheEntry, sb *strings.Builder) error {
+	path = append(path, k)
+	e.deps[k] = imp.digest(k)
+
+	src, _ := imp.source(k)
+	off := 0
+	for ln, line := range strings.SplitAfter(src, "\n") {
+		lineOff := off
+		off += len(line)
+
+		m := importRe.FindStringSubmatch(strings.TrimRight(line, "\r\n"))
+		if m == nil {
+			if line != "" {
+				e.lines = append(e.lines, lineOrigin{key: k, ln: ln + 1, delta: lineOff - sb.Len()})
+				sb.WriteString(line)
+			}
+			continue
+		}
+
+		dep, err := imp.resolve(k, m[1])
+		if err != nil {
+			return fmt.Errorf("%s: %w", k, err)
+		}
+
+		for _, x := range path {
+			if x == dep {
+				arr := make([]string, 0, len(path)+1)
+				for _, p := range append(path, dep) {
+					arr = append(arr, p.String())
+				}
+				return fmt.Errorf("import cycle: %s", strings.Join(arr, " -> "))
+			}
+		}
+
+		if _, ok := e.deps[dep]; ok { // already imported
+			continue
+		}
+
+		if err := imp.doExpand(dep, path, e, sb); err != nil {
+			return err
+		}
+
+		if !strings.HasSuffix(sb.String(), "\n") {
+			sb.WriteString("\n")
+		}
+	}
+
+	return nil
+}
+
+// ExpandImports expand the import statements of the scripts on the namespace,
+// the scripts failed to expand(import not found or import cycle) are removed
+// from the result and the errors returned.
+//
+// The expanded scripts are cached, the cache of a script is invalidated once
+// itself or any of the scripts imported changed, such as on pulling the updated
+// remote scripts.
+func ExpandImports(ns string, scripts map[point.Category]map[string]string) (
+	map[point.Category]map[string]string, map[point.Category]map[string]error,
+) {
+	defImportCache.mtx.Lock()
+	defer defImportCache.mtx.Unlock()
+
+	var (
+		imp     = &importer{scripts: scripts, digests: map[scriptKey]string{}}
+		cached  = defImportCache.entries[ns]
+		entries = map[scriptKey]*importCacheEntry{}
+		res     = map[point.Category]map[string]string{}
+		errs    = map[point.Category]map[string]error{}
+	)
+
+	for cat, arr := range scripts {
+		res[cat] = map[string]string{}
+
+		for name := range arr {
+			k := scriptKey{cat: cat, name: name}
+
+			e := cached[k]
+			if e == nil || !imp.valid(e) {
+				old := e
+
+				var err error
+				if e, err = imp.expand(k); err != nil {
+					if _, ok := errs[cat]; !ok {
+						errs[cat] = map[string]error{}
+					}
+					errs[cat][name] = err
+					continue
+				}
+
+				if old != nil && old.deps[k] == imp.digest(k) {
+					l.Infof("pipeline %s(namespace %s) reloaded on imported scripts changed", k, ns)
+				}
+			}
+
+			entries[k] = e
+			res[cat][name] = e.expanded
+		}
+	}
+
+	defImportCache.entries[ns] = entries
+
+	return res, errs
+}
+
+// RewriteErrors rewrite the positions of the compile errors of the expanded
+// scripts under the category back to the scripts imported and their lines,
+// the errors of scripts without imports are kept as they are.
+func RewriteErrors(ns string, cat point.Category, errs map[string]error) {
+	defImportCache.mtx.Lock()
+	defer defImportCache.mtx.Unlock()
+
+	for name, err := range errs {
+		e := defImportCache.entries[ns][scriptKey{cat: cat, name: name}]
+		if e == nil || e.lines == nil {
+			continue
+		}
+
+		var plErr *errchain.PlError
+		if !errors.As(err, &plErr) {
+			continue
+		}
+
+		plErr = plErr.Copy()
+		for i, pos := range plErr.PosChain {
+			if pos.File != name || pos.Ln < 1 || pos.Ln > len(e.lines) {
+				continue
+			}
+
+			o := e.lines[pos.Ln-1]
+			pos.Ln = o.ln
+			pos.Pos += o.delta
+			switch o.key.cat {
+			case cat:
+				pos.File = o.key.name
+			default:
+				pos.File = o.key.String()
+			}
+			plErr.PosChain[i] = pos
+		}
+
+		errs[name] = plErr
+	}
+}
+
+// compileErrors compile the expanded scripts of the categories which have
+// scripts imported others, and return the errors of the importing scripts
+// rewritten to the scripts imported.
+func compileErrors(ns string, expanded map[point.Category]map[string]string) map[point.Category]map[string]error {
+	defImportCache.mtx.Lock()
+	importing := map[point.Category]map[string]bool{}
+	for k, e := range defImportCache.entries[ns] {
+		if e.lines != nil {
+			if _, ok := importing[k.cat]; !ok {
+				importing[k.cat] = map[string]bool{}
+			}
+			importing[k.cat][k.name] = true
+		}
+	}
+	defImportCache.mtx.Unlock()
+
+	res := map[point.Category]map[string]error{}
+	for cat, names := range importing {
+		// compile all scripts of the category for the `use` statements
+		_, errs := plengine.ParseScript(expanded[cat], funcs.FuncsMap, funcs.FuncsCheckMap)
+		for name := range errs {
+			if !names[name] {
+				delete(errs, name)
+			}
+		}
+
+		if len(errs) > 0 {
+			RewriteErrors(ns, cat, errs)
+			res[cat] = errs
+		}
+	}
+
+	return res
+}
+
+// LoadScripts expand the imports of the scripts and load them into the manager
+// on the namespace, scripts failed to expand are not loaded.
+func LoadScripts(m *plmanager.Manager, ns string, scripts map[point.Category]map[string]string) {
+	expanded, errs := ExpandImports(ns, scripts)
+	for cat, arr := range errs {
+		for name, err := range arr {
+			l.Warnf("pipeline %s/%s(namespace %s) not loaded: %s", cat, name, ns, err)
+		}
+	}
+
+	// the compile errors recorded by the manager are positioned on the
+	// expanded scripts, log them with the positions of the scripts imported.
+	for cat, arr := range compileErrors(ns, expanded) {
+		for name, err := range arr {
+			l.Warnf("pipeline %s/%s(namespace %s) compile failed: %s", cat, name, ns, err)
+		}
+	}
+
+	m.LoadScripts(ns, expanded, nil)
+}
+
+// LoadScriptsFromWorkspace load the scripts under plPath with imports expanded.
+func LoadScriptsFromWorkspace(m *plmanager.Manager, ns, plPath string) {
+	if plPath == "" {
+		return
+	}
+
+	scripts, _ := plmanager.ReadWorkspaceScripts(plPath)
+	LoadScripts(m, ns, scripts)
+}
@@ -0,0 +1,199 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package plval
+
+import (
+	"testing"
+	"time"
+
+	plmanager "github.com/GuanceCloud/cliutils/pipeline/manager"
+	"github.com/GuanceCloud/cliutils/pipeline/ptinput"
+	"github.com/GuanceCloud/cliutils/pipeline/ptinput/funcs"
+	"github.com/GuanceCloud/cliutils/point"
+	plengine "github.com/GuanceCloud/platypus/pkg/engine"
+	"github.com/GuanceCloud/platypus/pkg/errchain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandImports(t *testing.T) {
+	t.Run("expand", func(t *testing.T) {
+		scripts := map[point.Category]map[string]string{
+			point.Logging: {
+				"common.p": `add_pattern("_ts", "%{NUMBER:ts}")`,
+				"base.p":   "import \"common.p\"\nadd_pattern(\"_lvl\", \"%{WORD:lvl}\")\n",
+				"nginx.p":  "import \"base.p\"\nimport \"common.p\" # imported by base.p already\nimport \"metric/m.p\"\ngrok(_, \"%{_ts} %{_lvl}\")\n",
+				"plain.p":  `drop_key(a)`,
+			},
+			point.Metric: {
+				"m.p": `set_tag(from_metric, "1")`,
+			},
+		}
+
+		res, errs := ExpandImports("test-expand", scripts)
+		assert.Empty(t, errs)
+
+		assert.Equal(t, "add_pattern(\"_ts\", \"%{NUMBER:ts}\")\n"+
+			"add_pattern(\"_lvl\", \"%{WORD:lvl}\")\n"+
+			"set_tag(from_metric, \"1\")\n"+
+			"grok(_, \"%{_ts} %{_lvl}\")\n", res[point.Logging]["nginx.p"])
+		assert.Equal(t, `drop_key(a)`, res[point.Logging]["plain.p"])
+
+		// the expanded script works with the imported patterns
+		scs, compileErrs := plmanager.NewScripts(res[point.Logging], nil, "", point.Logging)
+		require.Empty(t, compileErrs)
+
+		pt := point.NewPointV2("nginx", point.NewKVs(map[string]any{"message": "1700000000 INFO"}), point.DefaultLoggingOptions()...)
+		plpt := ptinput.WrapPoint(point.Logging, pt)
+		require.NoError(t, scs["nginx.p"].Run(plpt, nil, nil))
+
+		fields := plpt.Fields()
+		assert.Equal(t, "1700000000", fields["ts"])
+		assert.Equal(t, "INFO", fields["lvl"])
+		assert.Equal(t, "1", plpt.Tags()["from_metric"])
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		scripts := map[point.Category]map[string]string{
+			point.Logging: {
+				"a.p":       `import "b.p"`,
+				"b.p":       `import "c.p"`,
+				"c.p":       `import "a.p"`,
+				"self.p":    `import "self.p"`,
+				"missing.p": `import "no-such.p"`,
+				"bad-cat.p": `import "no-such-category/x.p"`,
+				"ok.p":      "# import \"a.p\" commented\nimport \"d.p\"",
+				"d.p":       `drop()`,
+			},
+		}
+
+		res, errs := ExpandImports("test-errors", scripts)
+		require.Len(t, errs[point.Logging], 6)
+
+		assert.EqualError(t, errs[point.Logging]["a.p"], "import cycle: logging/a.p -> logging/b.p -> logging/c.p -> logging/a.p")
+		assert.EqualError(t, errs[point.Logging]["self.p"], "import cycle: logging/self.p -> logging/self.p")
+		assert.EqualError(t, errs[point.Logging]["missing.p"], `logging/missing.p: import "no-such.p" not found`)
+		assert.Contains(t, errs[point.Logging]["bad-cat.p"].Error(), "invalid category")
+
+		assert.Len(t, res[point.Logging], 2)
+		assert.Equal(t, "# import \"a.p\" commented\ndrop()\n", res[point.Logging]["ok.p"])
+	})
+
+	t.Run("cache-invalidation", func(t *testing.T) {
+		ns := "test-cache"
+		scripts := map[point.Category]map[string]string{
+			point.Logging: {
+				"common.p": `add_key(v, 1)`,
+				"nginx.p":  `import "common.p"`,
+				"redis.p":  `add_key(x, 1)`,
+			},
+		}
+
+		res, _ := ExpandImports(ns, scripts)
+		assert.Equal(t, "add_key(v, 1)\n", res[point.Logging]["nginx.p"])
+
+		nginx := defImportCache.entries[ns][scriptKey{point.Logging, "nginx.p"}]
+		redis := defImportCache.entries[ns][scriptKey{point.Logging, "redis.p"}]
+
+		// library updated on pulling, the importer re-expanded
+		scripts[point.Logging]["common.p"] = `add_key(v, 2)`
+		res, _ = ExpandImports(ns, scripts)
+		assert.Equal(t, "add_key(v, 2)\n", res[point.Logging]["nginx.p"])
+		assert.NotSame(t, nginx, defImportCache.entries[ns][scriptKey{point.Logging, "nginx.p"}])
+		assert.Same(t, redis, defImportCache.entries[ns][scriptKey{point.Logging, "redis.p"}])
+
+		// library removed
+		delete(scripts[point.Logging], "common.p")
+		res, errs := ExpandImports(ns, scripts)
+		assert.NotContains(t, res[point.Logging], "nginx.p")
+		assert.Error(t, errs[point.Logging]["nginx.p"])
+		assert.Len(t, defImportCache.entries[ns], 1)
+	})
+}
+
+func TestRewriteErrors(t *testing.T) {
+	ns := "test-rewrite"
+	scripts := map[point.Category]map[string]string{
+		point.Logging: {
+			"common.p": "add_key(a, 1)\nno_such_func(b)\n",
+			"nginx.p":  "import \"common.p\"\nadd_key(nginx, 1)",
+			"redis.p":  "add_key(x, 1)\nno_such_func(y)",
+			"mysql.p":  "import \"common.p\"\nadd_key(mysql, 1)\nadd_key(z, 1)",
+			"pg.p":     "import \"metric/lib.p\"\nadd_key(pg, 1)",
+		},
+		point.Metric: {
+			"lib.p": "add_key(m, 1)\nno_such_func(n)",
+		},
+	}
+
+	expanded, expandErrs := ExpandImports(ns, scripts)
+	require.Empty(t, expandErrs)
+
+	e := defImportCache.entries[ns][scriptKey{point.Logging, "mysql.p"}]
+	require.Len(t, e.lines, 4)
+	assert.Equal(t, lineOrigin{key: scriptKey{point.Logging, "mysql.p"}, ln: 3, delta: 36 - 48},
+		e.lines[3])
+
+	_, errs := plengine.ParseScript(expanded[point.Logging], funcs.FuncsMap, funcs.FuncsCheckMap)
+	RewriteErrors(ns, point.Logging, errs)
+
+	pos := func(name string) errchain.Position {
+		var plErr *errchain.PlError
+		require.ErrorAs(t, errs[name], &plErr)
+		require.NotEmpty(t, plErr.PosChain)
+		return plErr.PosChain[0]
+	}
+
+	// error on the script imported
+	assert.Equal(t, "common.p", pos("nginx.p").File)
+	assert.Equal(t, 2, pos("nginx.p").Ln)
+	assert.Equal(t, pos("common.p"), pos("nginx.p"))
+
+	// imported from other category
+	assert.Equal(t, "metric/lib.p", pos("pg.p").File)
+	assert.Equal(t, 2, pos("pg.p").Ln)
+
+	// not imported, kept as it is
+	assert.Equal(t, "redis.p", pos("redis.p").File)
+	assert.Equal(t, 2, pos("redis.p").Ln)
+}
+
+func TestLoadScripts(t *testing.T) {
+	m := plmanager.NewManager(plmanager.NewManagerCfg(nil, nil))
+
+	LoadScripts(m, plmanager.NSRemote, map[point.Category]map[string]string{
+		point.Logging: {
+			"common.p": `add_key(lib, "v1")`,
+			"nginx.p":  "import \"common.p\"\nadd_key(nginx, 1)",
+			"bad.p":    `import "no-such.p"`,
+		},
+	})
+
+	run := func(name string) map[string]any {
+		sc, ok := m.QueryScript(point.Logging, name)
+		require.True(t, ok)
+
+		plpt := ptinput.WrapPoint(point.Logging, point.NewPointV2("nginx",
+			point.NewKVs(map[string]any{"message": "x"}), point.WithTime(time.Now())))
+		require.NoError(t, sc.Run(plpt, nil, nil))
+		return plpt.Fields()
+	}
+
+	assert.Equal(t, "v1", run("nginx.p")["lib"])
+
+	_, ok := m.QueryScript(point.Logging, "bad.p", struct{}{})
+	assert.False(t, ok)
+
+	// pulled again with the library updated
+	LoadScripts(m, plmanager.NSRemote, map[point.Category]map[string]string{
+		point.Logging: {
+			"common.p": `add_key(lib, "v2")`,
+			"nginx.p":  "import \"common.p\"\nadd_key(nginx, 1)",
+		},
+	})
+
+	assert.Equal(t, "v2", run("nginx.p")["lib"])
+}
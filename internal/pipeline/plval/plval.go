@@ -20,6 +20,7 @@ import (
 	"github.com/GuanceCloud/cliutils/pipeline/ptinput/ipdb"
 	"github.com/GuanceCloud/cliutils/pipeline/ptinput/plmap"
 	"github.com/GuanceCloud/cliutils/pipeline/ptinput/refertable"
+	plstats "github.com/GuanceCloud/cliutils/pipeline/stats"
 	"github.com/GuanceCloud/cliutils/point"
 	"github.com/GuanceCloud/platypus/pkg/ast"
 	"github.com/GuanceCloud/platypus/pkg/engine/runtime"
@@ -154,7 +155,8 @@ func InitPlVal(cfg *PipelineCfg, upFn plmap.UploadFunc, gTags map[string]string,
 
 	// init script manager
 	managerIns := plmanager.NewManager(plmanager.NewManagerCfg(upFn, gTagsLi))
-	plmanager.InitStore(managerIns, installDir, nil)
+	plstats.InitLog()
+	LoadScriptsFromWorkspace(managerIns, plmanager.NSDefault, filepath.Join(installDir, "pipeline"))
 	SetManager(managerIns)
 
 	// init ipdb
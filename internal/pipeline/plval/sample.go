@@ -0,0 +1,255 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package plval
+
+import (
+	_ "embed"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/pipeline/ptinput"
+	"github.com/GuanceCloud/cliutils/pipeline/ptinput/funcs"
+	"github.com/GuanceCloud/platypus/pkg/ast"
+	"github.com/GuanceCloud/platypus/pkg/engine/runtime"
+	"github.com/GuanceCloud/platypus/pkg/errchain"
+)
+
+var (
+	//go:embed md/sample.md
+	docSample string
+	//go:embed md/sample.en.md
+	docSampleEN string
+	//go:embed md/rate_limit.md
+	docRateLimit string
+	//go:embed md/rate_limit.en.md
+	docRateLimitEN string
+)
+
+// sample() and rate_limit() keep their state within the engine, the state
+// is per category, source(the name of the point), script and the position of
+// the call within the script. So the same script used by different sources
+// are controlled separately.
+var (
+	_samplers = &samplers{
+		credits: map[string]float64{},
+	}
+
+	_rateLimiters = &rateLimiters{
+		windows: map[string]*rateWindow{},
+		now:     time.Now,
+	}
+)
+
+func init() { //nolint:gochecknoinits
+	// overwrite the builtin sample(), which is a time-based random.
+	funcs.FuncsMap["sample"] = Sample
+	funcs.FuncsCheckMap["sample"] = SampleChecking
+
+	funcs.FuncsMap["rate_limit"] = RateLimit
+	funcs.FuncsCheckMap["rate_limit"] = RateLimitChecking
+
+	funcs.PipelineFunctionDocs["sample()"] = &funcs.PLDoc{
+		Doc:        docSample,
+		FnCategory: map[string][]string{"zh-CN": {"采样"}},
+	}
+	funcs.PipelineFunctionDocsEN["sample()"] = &funcs.PLDoc{
+		Doc:        docSampleEN,
+		FnCategory: map[string][]string{"en-US": {"Sample"}},
+	}
+	funcs.PipelineFunctionDocs["rate_limit()"] = &funcs.PLDoc{
+		Doc:        docRateLimit,
+		FnCategory: map[string][]string{"zh-CN": {"采样"}},
+	}
+	funcs.PipelineFunctionDocsEN["rate_limit()"] = &funcs.PLDoc{
+		Doc:        docRateLimitEN,
+		FnCategory: map[string][]string{"en-US": {"Sample"}},
+	}
+}
+
+type samplers struct {
+	mtx     sync.Mutex
+	credits map[string]float64
+}
+
+// keep accumulate the rate on key, and keep the point once the credit reach 1,
+// so exactly rate of the points are kept. The first point always kept unless
+// the rate is 0.
+func (s *samplers) keep(key string, rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	credit, ok := s.credits[key]
+	if !ok {
+		credit = 1
+	} else {
+		credit += rate
+	}
+
+	if credit >= 1 {
+		s.credits[key] = credit - 1
+		return true
+	}
+
+	s.credits[key] = credit
+	return false
+}
+
+type rateWindow struct {
+	sec int64
+	n   int64
+}
+
+type rateLimiters struct {
+	mtx     sync.Mutex
+	windows map[string]*rateWindow
+	now     func() time.Time
+}
+
+// allow check if there are less than limit points passed within current second.
+func (r *rateLimiters) allow(key string, limit int64) bool {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	sec := r.now().Unix()
+
+	w, ok := r.windows[key]
+	if !ok {
+		w = &rateWindow{sec: sec}
+		r.windows[key] = w
+	}
+
+	if w.sec != sec {
+		w.sec = sec
+		w.n = 0
+	}
+
+	if w.n >= limit {
+		return false
+	}
+
+	w.n++
+	return true
+}
+
+func stateKey(ctx *runtime.Task, funcExpr *ast.CallExpr) string {
+	cat, source := "unknown", ""
+	if pt, ok := ctx.InData().(ptinput.PlInputPt); ok && pt != nil {
+		cat = pt.Category().String()
+		source = pt.GetPtName()
+	}
+
+	return fmt.Sprintf("%s/%s/%s:%d:%d", cat, source, ctx.Name(), funcExpr.NamePos.Ln, funcExpr.NamePos.Col)
+}
+
+// checkNumberArg check the only argument of fn, and return the value if it's a
+// number literal.
+func checkNumberArg(ctx *runtime.Task, funcExpr *ast.CallExpr) (float64, bool, *errchain.PlError) {
+	if len(funcExpr.Param) != 1 {
+		return 0, false, runtime.NewRunError(ctx, fmt.Sprintf(
+			"func %s expects 1 arg", funcExpr.Name), funcExpr.NamePos)
+	}
+
+	switch p := funcExpr.Param[0]; p.NodeType { //nolint:exhaustive
+	case ast.TypeIntegerLiteral:
+		return float64(p.IntegerLiteral().Val), true, nil
+	case ast.TypeFloatLiteral:
+		return p.FloatLiteral().Val, true, nil
+	case ast.TypeStringLiteral, ast.TypeBoolLiteral, ast.TypeNilLiteral,
+		ast.TypeListLiteral, ast.TypeMapLiteral:
+		return 0, false, runtime.NewRunError(ctx, fmt.Sprintf(
+			"expect number, got %s", p.NodeType), p.StartPos())
+	default:
+		return 0, false, nil
+	}
+}
+
+func numberArg(ctx *runtime.Task, funcExpr *ast.CallExpr) (float64, *errchain.PlError) {
+	v, dtype, err := runtime.RunStmt(ctx, funcExpr.Param[0])
+	if err != nil {
+		return 0, err
+	}
+
+	switch dtype { //nolint:exhaustive
+	case ast.Int:
+		if x, ok := v.(int64); ok {
+			return float64(x), nil
+		}
+	case ast.Float:
+		if x, ok := v.(float64); ok {
+			return x, nil
+		}
+	}
+
+	return 0, runtime.NewRunError(ctx, fmt.Sprintf(
+		"func %s expects number, got %s", funcExpr.Name, dtype), funcExpr.Param[0].StartPos())
+}
+
+func SampleChecking(ctx *runtime.Task, funcExpr *ast.CallExpr) *errchain.PlError {
+	rate, isLiteral, err := checkNumberArg(ctx, funcExpr)
+	if err != nil {
+		return err
+	}
+
+	if isLiteral && (rate < 0 || rate > 1) {
+		return runtime.NewRunError(ctx,
+			"sampling rate should be in the range [0, 1]", funcExpr.Param[0].StartPos())
+	}
+
+	return nil
+}
+
+// Sample return true on rate of the calls.
+func Sample(ctx *runtime.Task, funcExpr *ast.CallExpr) *errchain.PlError {
+	rate, err := numberArg(ctx, funcExpr)
+	if err != nil {
+		return err
+	}
+
+	if rate < 0 || rate > 1 {
+		l.Warnf("sampling rate %f out of range [0, 1], ignored", rate)
+		ctx.Regs.ReturnAppend(true, ast.Bool)
+		return nil
+	}
+
+	ctx.Regs.ReturnAppend(_samplers.keep(stateKey(ctx, funcExpr), rate), ast.Bool)
+	return nil
+}
+
+func RateLimitChecking(ctx *runtime.Task, funcExpr *ast.CallExpr) *errchain.PlError {
+	n, isLiteral, err := checkNumberArg(ctx, funcExpr)
+	if err != nil {
+		return err
+	}
+
+	if isLiteral && n < 0 {
+		return runtime.NewRunError(ctx,
+			"rate limit should not be negative", funcExpr.Param[0].StartPos())
+	}
+
+	return nil
+}
+
+// RateLimit return true if less than n calls within current second.
+func RateLimit(ctx *runtime.Task, funcExpr *ast.CallExpr) *errchain.PlError {
+	n, err := numberArg(ctx, funcExpr)
+	if err != nil {
+		return err
+	}
+
+	if n < 0 {
+		l.Warnf("rate limit %f is negative, ignored", n)
+		ctx.Regs.ReturnAppend(true, ast.Bool)
+		return nil
+	}
+
+	ctx.Regs.ReturnAppend(_rateLimiters.allow(stateKey(ctx, funcExpr), int64(n)), ast.Bool)
+	return nil
+}
@@ -0,0 +1,109 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package plval
+
+import (
+	"testing"
+	"time"
+
+	plmanager "github.com/GuanceCloud/cliutils/pipeline/manager"
+	"github.com/GuanceCloud/cliutils/pipeline/ptinput"
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSampleAndRateLimit(t *testing.T) {
+	scs, errs := plmanager.NewScripts(map[string]string{
+		"sample.p": "if !sample(0.25) {\n  drop()\n}",
+		"rate.p":   "if !rate_limit(3) {\n  drop()\n}",
+		"both.p":   "if !sample(0.5) {\n  drop()\n}\nif !rate_limit(1) {\n  drop()\n}",
+		"zero.p":   "if !sample(0) {\n  drop()\n}",
+	}, nil, "", point.Logging)
+	require.Empty(t, errs)
+
+	source := "test"
+	run := func(name string, n int) (kept int) {
+		for i := 0; i < n; i++ {
+			pt := point.NewPointV2(source, point.NewKVs(map[string]any{"message": "x"}), point.DefaultLoggingOptions()...)
+			plpt := ptinput.WrapPoint(point.Logging, pt)
+			require.NoError(t, scs[name].Run(plpt, nil, nil))
+			if !plpt.Dropped() {
+				kept++
+			}
+		}
+		return kept
+	}
+
+	now := time.Unix(1700000000, 0)
+	_rateLimiters.now = func() time.Time { return now }
+	t.Cleanup(func() { _rateLimiters.now = time.Now })
+
+	t.Run("sample", func(t *testing.T) {
+		assert.Equal(t, 3, run("sample.p", 12)) // 1st, 5th and 9th kept
+		assert.Equal(t, 4, run("sample.p", 16))
+	})
+
+	t.Run("rate-limit", func(t *testing.T) {
+		assert.Equal(t, 3, run("rate.p", 10))
+		assert.Equal(t, 0, run("rate.p", 10))
+
+		now = now.Add(time.Second)
+		assert.Equal(t, 3, run("rate.p", 10))
+	})
+
+	t.Run("state-per-call", func(t *testing.T) {
+		now = now.Add(time.Second)
+		assert.Equal(t, 1, run("both.p", 10))
+
+		// the script name is part of the state
+		assert.Equal(t, 3, run("rate.p", 5))
+
+		// the source is part of the state
+		source = "other"
+		assert.Equal(t, 3, run("rate.p", 5))
+		source = "test"
+	})
+
+	t.Run("zero-rate", func(t *testing.T) {
+		assert.Equal(t, 0, run("zero.p", 10))
+	})
+
+	t.Run("check", func(t *testing.T) {
+		for _, s := range []string{
+			"sample(1.5)",
+			"sample(-1)",
+			`sample("0.1")`,
+			"sample()",
+			"rate_limit(-1)",
+			"rate_limit(1, 2)",
+		} {
+			_, errs := plmanager.NewScripts(map[string]string{"x.p": s}, nil, "", point.Logging)
+			assert.Lenf(t, errs, 1, "script %q", s)
+		}
+
+		_, errs := plmanager.NewScripts(map[string]string{"x.p": "r = 0.1\nif sample(r * 2) && rate_limit(10.5) {\n  add_key(kept, true)\n}"},
+			nil, "", point.Logging)
+		assert.Empty(t, errs)
+	})
+
+	t.Run("non-literal", func(t *testing.T) {
+		scs, errs := plmanager.NewScripts(map[string]string{
+			"x.p": "r = 0.5\nif sample(r) {\n  add_key(kept, true)\n}",
+		}, nil, "", point.Logging)
+		require.Empty(t, errs)
+
+		kept := 0
+		for i := 0; i < 4; i++ {
+			plpt := ptinput.WrapPoint(point.Logging, point.NewPointV2("test", nil, point.DefaultLoggingOptions()...))
+			require.NoError(t, scs["x.p"].Run(plpt, nil, nil))
+			if plpt.Fields()["kept"] == true {
+				kept++
+			}
+		}
+		assert.Equal(t, 2, kept)
+	})
+}
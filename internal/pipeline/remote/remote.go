@@ -53,6 +53,10 @@ type pipelineRemoteConfig struct {
 func StartPipelineRemote(urls []string) {
 	runPipelineRemote.Do(func() {
 		l = logger.SLogger(pipelineRemoteName)
+		if config.Cfg.Pipeline != nil {
+			io.SetPipelineRemoteSource(config.Cfg.Pipeline.RemoteSource)
+		}
+
 		if runner, err := pullMain(urls, &pipelineRemoteImpl{}); err != nil {
 			l.Error(err)
 			return
@@ -247,7 +251,7 @@ func doPull(pathConfig, pathRelation, siteURL string, ipr IPipelineRemote) error
 			managerWkr.UpdateDefaultScript(nil)
 			if m, ok := plval.GetManager(); ok && m != nil {
 				// cleanup all remote scripts
-				m.LoadScripts(plmanager.NSRemote, nil, nil)
+				plval.LoadScripts(m, plmanager.NSRemote, nil)
 			}
 
 			// remove lcoal files
@@ -455,5 +459,5 @@ func loadContentPipeline(in map[point.Category]map[string]string) {
 	for cat, val := range in {
 		inS[cat] = val
 	}
-	managerWkr.LoadScripts(plmanager.NSRemote, inS, nil)
+	plval.LoadScripts(managerWkr, plmanager.NSRemote, inS)
 }
@@ -128,38 +128,170 @@ FN_KPROBE(tcp_close)
     return 0;
 }
 
+// ------------------------ uprobe: openssl ---------------
+
 FN_UPROBE(SSL_set_fd)
 {
+    void *ssl_ctx = (void *)PT_REGS_PARM1(ctx);
+    __s32 fd = (__s32)PT_REGS_PARM2(ctx);
+    if (ssl_ctx == NULL || fd < 3)
+    {
+        return 0;
+    }
+
+    init_ssl_sockfd(ssl_ctx, fd);
     return 0;
 }
 
-FN_UPROBE(BIO_new_socket)
+FN_UPROBE(SSL_read)
 {
+    put_ssl_args((void *)PT_REGS_PARM1(ctx), (void *)PT_REGS_PARM2(ctx),
+                 NULL, &bpfmap_ssl_read_args);
     return 0;
 }
 
-FN_URETPROBE(BIO_new_socket)
+FN_URETPROBE(SSL_read)
 {
+    read_ssl_data(ctx, &bpfmap_ssl_read_args, (__s32)PT_REGS_RC(ctx),
+                  MSG_READ, P_USR_SSL_READ);
     return 0;
 }
 
-FN_UPROBE(SSL_read)
+FN_UPROBE(SSL_read_ex)
 {
+    put_ssl_args((void *)PT_REGS_PARM1(ctx), (void *)PT_REGS_PARM2(ctx),
+                 (size_t *)PT_REGS_PARM4(ctx), &bpfmap_ssl_read_args);
     return 0;
 }
 
-FN_URETPROBE(SSL_read)
+FN_URETPROBE(SSL_read_ex)
 {
+    read_ssl_data(ctx, &bpfmap_ssl_read_args, (__s32)PT_REGS_RC(ctx),
+                  MSG_READ, P_USR_SSL_READ);
     return 0;
 }
 
 FN_UPROBE(SSL_write)
 {
+    put_ssl_args((void *)PT_REGS_PARM1(ctx), (void *)PT_REGS_PARM2(ctx),
+                 NULL, &bpfmap_ssl_write_args);
+    return 0;
+}
+
+FN_URETPROBE(SSL_write)
+{
+    read_ssl_data(ctx, &bpfmap_ssl_write_args, (__s32)PT_REGS_RC(ctx),
+                  MSG_WRITE, P_USR_SSL_WRITE);
+    return 0;
+}
+
+FN_UPROBE(SSL_write_ex)
+{
+    put_ssl_args((void *)PT_REGS_PARM1(ctx), (void *)PT_REGS_PARM2(ctx),
+                 (size_t *)PT_REGS_PARM4(ctx), &bpfmap_ssl_write_args);
+    return 0;
+}
+
+FN_URETPROBE(SSL_write_ex)
+{
+    read_ssl_data(ctx, &bpfmap_ssl_write_args, (__s32)PT_REGS_RC(ctx),
+                  MSG_WRITE, P_USR_SSL_WRITE);
     return 0;
 }
 
 FN_UPROBE(SSL_shutdown)
 {
+    void *ssl_ctx = (void *)PT_REGS_PARM1(ctx);
+    bpf_map_delete_elem(&bpfmap_ssl_ctx_sockfd, &ssl_ctx);
+    return 0;
+}
+
+// ------------------------ uprobe: go crypto/tls ---------------
+// Only the binaries built with the register-based calling convention are
+// supported (amd64 go1.17+, arm64 go1.18+).
+
+// func (c *Conn) Write(b []byte) (int, error)
+SEC("uprobe/gotls_write")
+int uprobe__gotls_write(struct pt_regs *ctx)
+{
+    void *tls_conn = (void *)PT_GO_REGS_PARAM1(ctx);
+    void *buf = (void *)PT_GO_REGS_PARAM2(ctx);
+    __s64 len = (__s64)PT_GO_REGS_PARAM3(ctx);
+
+    if (tls_conn == NULL || len <= 0)
+    {
+        return 0;
+    }
+
+    __u64 pid_tgid = bpf_get_current_pid_tgid();
+    syscall_rw_arg_t arg = {
+        .ts = bpf_ktime_get_ns(),
+    };
+
+    if (!init_tls_rw_args(&arg, pid_tgid, read_gotls_conn_fd(tls_conn), buf, MSG_WRITE))
+    {
+        return 0;
+    }
+
+    // the plaintext is written completely or an error is returned,
+    // it's uploaded before encrypted
+    read_tls_data(ctx, &arg, pid_tgid, len, P_USR_SSL_WRITE);
+    return 0;
+}
+
+// func (c *Conn) Read(b []byte) (int, error)
+SEC("uprobe/gotls_read")
+int uprobe__gotls_read(struct pt_regs *ctx)
+{
+    void *tls_conn = (void *)PT_GO_REGS_PARAM1(ctx);
+    void *buf = (void *)PT_GO_REGS_PARAM2(ctx);
+
+    if (tls_conn == NULL)
+    {
+        return 0;
+    }
+
+    __u64 pid_tgid = bpf_get_current_pid_tgid();
+    syscall_rw_arg_t arg = {
+        .ts = bpf_ktime_get_ns(),
+    };
+
+    if (!init_tls_rw_args(&arg, pid_tgid, read_gotls_conn_fd(tls_conn), buf, MSG_READ))
+    {
+        return 0;
+    }
+
+    // goroutines may be migrated to other threads when blocked
+    gotls_rw_key_t key = {
+        .pid = pid_tgid >> 32,
+        .goroutine = (__u64)PT_GO_REGS_GOROUTINE(ctx),
+    };
+
+    bpf_map_update_elem(&bpfmap_gotls_read_args, &key, &arg, BPF_ANY);
+    return 0;
+}
+
+// uretprobe is not used since the goroutine stack may be moved,
+// this uprobe is attached to each RET instruction of the function.
+SEC("uprobe/gotls_read_ret")
+int uprobe__gotls_read_ret(struct pt_regs *ctx)
+{
+    __u64 pid_tgid = bpf_get_current_pid_tgid();
+    gotls_rw_key_t key = {
+        .pid = pid_tgid >> 32,
+        .goroutine = (__u64)PT_GO_REGS_GOROUTINE(ctx),
+    };
+
+    syscall_rw_arg_t *arg = bpf_map_lookup_elem(&bpfmap_gotls_read_args, &key);
+    if (arg == NULL)
+    {
+        return 0;
+    }
+
+    __s64 n = (__s64)PT_GO_REGS_PARAM1(ctx);
+    read_tls_data(ctx, arg, pid_tgid, n, P_USR_SSL_READ);
+
+    bpf_map_delete_elem(&bpfmap_gotls_read_args, &key);
     return 0;
 }
 
@@ -32,16 +32,18 @@ static __always_inline network_events_t *get_net_events()
 
 BPF_PERF_EVENT_MAP(mp_upload_netwrk_events)
 
-BPF_HASH_MAP(bpfmap_ssl_read_args, __u64, ssl_read_args_t, 2048)
+BPF_HASH_MAP(bpfmap_ssl_read_args, __u64, ssl_rw_args_t, 2048)
 
-BPF_HASH_MAP(bpfmap_bio_new_socket_args, __u64, __u32, 2048) // k: pid_tgid v: sockfd
+BPF_HASH_MAP(bpfmap_ssl_write_args, __u64, ssl_rw_args_t, 2048)
 
 BPF_HASH_MAP(bpfmap_ssl_ctx_sockfd, void *, __u64, 2048)
 
-BPF_HASH_MAP(bpfmap_ssl_bio_fd, void *, __u32, 2048)
-
+// k: pid_tgid v: ssl ctx, the fd of the ssl ctx is found by the syscalls
+// called in SSL_read or SSL_write
 BPF_HASH_MAP(bpfmap_ssl_pidtgid_ctx, __u64, void *, 2048)
 
+BPF_HASH_MAP(bpfmap_gotls_read_args, gotls_rw_key_t, syscall_rw_arg_t, 2048)
+
 BPF_HASH_MAP(bpfmap_syscall_sendfile_arg, __u64, syscall_sendfile_arg_t, 2048)
 
 BPF_HASH_MAP(mp_protocol_filter, void *, __u8, 65536)
@@ -133,20 +133,20 @@ typedef struct
     __u8 payload[L7_BUFFER_SIZE];
 } net_event_t;
 
-typedef struct ssl_read_args
+typedef struct ssl_rw_args
 {
     void *ctx;
     void *buf;
-    __s32 num;
-    __s32 _pad0;
-
-    __u32 copied_seq;
-    __u32 write_seq;
-
-    void *skt;
-
+    size_t *bytes; // SSL_read_ex, SSL_write_ex
     __u64 ts;
-} ssl_read_args_t;
+} ssl_rw_args_t;
+
+typedef struct gotls_rw_key
+{
+    __u32 pid;
+    __u32 _pad0;
+    __u64 goroutine;
+} gotls_rw_key_t;
 
 typedef struct syscall_rw_arg
 {
@@ -388,11 +388,22 @@ static __always_inline int get_sk(struct socket *skt,
     return 0;
 }
 
-static __always_inline void init_ssl_sockfd(void *ssl_ctx, __u32 fd)
+static __always_inline void init_ssl_sockfd(void *ssl_ctx, __u64 fd)
 {
     bpf_map_update_elem(&bpfmap_ssl_ctx_sockfd, &ssl_ctx, &fd, BPF_ANY);
 }
 
+// update_ssl_sockfd records the fd of the ssl ctx if the syscall is called
+// by SSL_read or SSL_write.
+static __always_inline void update_ssl_sockfd(__u64 pid_tgid, __u64 fd)
+{
+    void **ssl_ctx = bpf_map_lookup_elem(&bpfmap_ssl_pidtgid_ctx, &pid_tgid);
+    if (ssl_ctx != NULL && *ssl_ctx != NULL)
+    {
+        init_ssl_sockfd(*ssl_ctx, fd);
+    }
+}
+
 static __always_inline bool get_sk_with_typ(struct socket *skt, struct sock **sk_ptr, enum sock_type sk_type)
 {
     enum sock_type typ = 0;
@@ -491,6 +502,8 @@ static __always_inline bool put_rw_args(tp_syscall_rw_args_t *ctx, void *bpf_map
         return false;
     }
 
+    update_ssl_sockfd(pid_tgid, ctx->fd);
+
     syscall_rw_arg_t arg = {
         .buf = ctx->buf,
         .fd = ctx->fd,
@@ -558,6 +571,8 @@ static __always_inline bool put_rw_v_args(tp_syscall_rw_v_args_t *ctx, void *bpf
         return false;
     }
 
+    update_ssl_sockfd(pid_tgid, ctx->fd);
+
     syscall_rw_v_arg_t arg = {
         .fd = ctx->fd,
         .vec = ctx->vec,
@@ -689,4 +704,206 @@ cleanup:
     del_rw_v_args(bpf_map, &pid_tgid);
 }
 
+// init_tls_rw_args finds the socket of the fd read or written by the
+// TLS library, false returned if the socket is not traced.
+static __always_inline bool init_tls_rw_args(syscall_rw_arg_t *arg, __u64 pid_tgid,
+                                             __u64 fd, void *buf, enum MSG_RW rw)
+{
+    if (fd < 3 || buf == NULL)
+    {
+        return false;
+    }
+
+    struct task_struct *task = bpf_get_current_task();
+    struct socket *skt = get_socket_from_fd(task, fd);
+    if (skt == NULL)
+    {
+        return false;
+    }
+
+    struct sock *sk = NULL;
+    if (!get_sk_with_typ(skt, &sk, SOCK_STREAM))
+    {
+        return false;
+    }
+
+    if (!net_filtered(pid_tgid, sk))
+    {
+        return false;
+    }
+
+    arg->fd = fd;
+    arg->buf = buf;
+    arg->skt = skt;
+
+    switch (rw)
+    {
+    case MSG_READ:
+        arg->tcp_seq = read_copied_seq(sk);
+        break;
+    case MSG_WRITE:
+        arg->tcp_seq = read_write_seq(sk);
+        break;
+    }
+
+    return true;
+}
+
+// read_tls_data uploads the plaintext read or written by the TLS library,
+// the data is decoded by the same parsers as the syscall data.
+static __always_inline void read_tls_data(struct pt_regs *ctx, syscall_rw_arg_t *arg,
+                                          __u64 pid_tgid, __s64 size, tp_syscalls_fn_t fn)
+{
+    if (size <= 0)
+    {
+        return;
+    }
+
+    net_data_t *dst = get_net_data_percpu();
+    if (dst == NULL)
+    {
+        return;
+    }
+
+    struct sock *sk = NULL;
+    if (!get_sk_with_typ(arg->skt, &sk, SOCK_STREAM))
+    {
+        return;
+    }
+
+    if (get_sk_inf(sk, &dst->meta.sk_inf, 1) == 0)
+    {
+        return;
+    }
+
+    dst->meta.ts = arg->ts;
+    dst->meta.ts_tail = bpf_ktime_get_ns();
+    dst->meta.tid_utid = pid_tgid << 32;
+    dst->meta.tcp_seq = arg->tcp_seq;
+    dst->meta.func_id = fn;
+    dst->meta.original_size = size;
+
+    __u64 *goid = bpf_map_lookup_elem(&bmap_tid2goid, &pid_tgid);
+    if (goid != NULL)
+    {
+        dst->meta.tid_utid |= *goid;
+    }
+
+    read_netwrk_data(dst, arg->buf, size);
+
+    try_upload_net_events(ctx, dst);
+}
+
+static __always_inline void put_ssl_args(void *ssl_ctx, void *buf, size_t *bytes, void *bpf_map)
+{
+    if (ssl_ctx == NULL || buf == NULL)
+    {
+        return;
+    }
+
+    __u64 pid_tgid = bpf_get_current_pid_tgid();
+    __u32 pid = pid_tgid >> 32;
+    if (need_filter_proc(&pid))
+    {
+        return;
+    }
+
+    ssl_rw_args_t arg = {
+        .ctx = ssl_ctx,
+        .buf = buf,
+        .bytes = bytes,
+        .ts = bpf_ktime_get_ns(),
+    };
+
+    bpf_map_update_elem(bpf_map, &pid_tgid, &arg, BPF_ANY);
+    bpf_map_update_elem(&bpfmap_ssl_pidtgid_ctx, &pid_tgid, &ssl_ctx, BPF_ANY);
+}
+
+// read_ssl_data uploads the data on the return of SSL_read(_ex) or
+// SSL_write(_ex), ret is the returned value of the function.
+static __always_inline void read_ssl_data(struct pt_regs *ctx, void *bpf_map, __s32 ret,
+                                          enum MSG_RW rw, tp_syscalls_fn_t fn)
+{
+    __u64 pid_tgid = bpf_get_current_pid_tgid();
+
+    ssl_rw_args_t *ssl_args = bpf_map_lookup_elem(bpf_map, &pid_tgid);
+    if (ssl_args == NULL)
+    {
+        goto cleanup;
+    }
+
+    __s64 size = ret;
+    if (ssl_args->bytes != NULL)
+    {
+        // SSL_read_ex and SSL_write_ex return 1 on success
+        if (ret != 1)
+        {
+            goto cleanup;
+        }
+        size_t bytes = 0;
+        bpf_probe_read(&bytes, sizeof(bytes), ssl_args->bytes);
+        size = bytes;
+    }
+
+    if (size <= 0)
+    {
+        goto cleanup;
+    }
+
+    void *ssl_ctx = ssl_args->ctx;
+    __u64 *fd = bpf_map_lookup_elem(&bpfmap_ssl_ctx_sockfd, &ssl_ctx);
+    if (fd == NULL)
+    {
+        goto cleanup;
+    }
+
+    syscall_rw_arg_t arg = {
+        .ts = ssl_args->ts,
+    };
+
+    if (!init_tls_rw_args(&arg, pid_tgid, *fd, ssl_args->buf, rw))
+    {
+        goto cleanup;
+    }
+
+    read_tls_data(ctx, &arg, pid_tgid, size, fn);
+
+cleanup:
+    bpf_map_delete_elem(bpf_map, &pid_tgid);
+    bpf_map_delete_elem(&bpfmap_ssl_pidtgid_ctx, &pid_tgid);
+}
+
+// The layout of crypto/tls.Conn and net.TCPConn, the first field of
+// tls.Conn is the net.Conn interface, the data pointer of the interface
+// points to net.TCPConn whose first field is *net.netFD, and the Sysfd of
+// poll.FD follows poll.fdMutex.
+#define GOTLS_CONN_IFACE_DATA_OFFSET 8
+#define GO_NETFD_SYSFD_OFFSET 16
+
+static __always_inline __u64 read_gotls_conn_fd(void *tls_conn)
+{
+    void *tcp_conn = NULL;
+    bpf_probe_read(&tcp_conn, sizeof(tcp_conn), (__u8 *)tls_conn + GOTLS_CONN_IFACE_DATA_OFFSET);
+    if (tcp_conn == NULL)
+    {
+        return 0;
+    }
+
+    void *netfd = NULL;
+    bpf_probe_read(&netfd, sizeof(netfd), tcp_conn);
+    if (netfd == NULL)
+    {
+        return 0;
+    }
+
+    __s64 fd = 0;
+    bpf_probe_read(&fd, sizeof(fd), (__u8 *)netfd + GO_NETFD_SYSFD_OFFSET);
+    if (fd < 0)
+    {
+        return 0;
+    }
+
+    return fd;
+}
+
 #endif // !__L7_UTILS_
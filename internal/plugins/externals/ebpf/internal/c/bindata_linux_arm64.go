@@ -46,3 +46,11 @@ func OffsetTCPSeqBin() ([]byte, error) {
 func BashHistoryBin() ([]byte, error) {
 	return binData.ReadFile("elf/linux_arm64/bash_history.o")
 }
+
+func ExecSnoopBin() ([]byte, error) {
+	return binData.ReadFile("elf/linux_arm64/execsnoop.o")
+}
+
+func ProfilingBin() ([]byte, error) {
+	return binData.ReadFile("elf/linux_arm64/profiling.o")
+}
@@ -48,3 +48,11 @@ func OffsetTCPSeqBin() ([]byte, error) {
 func BashHistoryBin() ([]byte, error) {
 	return notImplemented()
 }
+
+func ExecSnoopBin() ([]byte, error) {
+	return notImplemented()
+}
+
+func ProfilingBin() ([]byte, error) {
+	return notImplemented()
+}
@@ -39,6 +39,8 @@ static int (*bpf_map_delete_elem)(void *map, const void *key) =
 	(void *)BPF_FUNC_map_delete_elem;
 static int (*bpf_probe_read)(void *dst, int size, const void *unsafe_ptr) =
 	(void *)BPF_FUNC_probe_read;
+static int (*bpf_probe_read_str)(void *dst, int size, const void *unsafe_ptr) =
+	(void *)BPF_FUNC_probe_read_str;
 static unsigned long long (*bpf_ktime_get_ns)(void) =
 	(void *)BPF_FUNC_ktime_get_ns;
 static int (*bpf_trace_printk)(const char *fmt, int fmt_size, ...) =
@@ -74,6 +76,8 @@ static int (*bpf_probe_write_user)(void *dst, const void *src, int size) =
 	(void *)BPF_FUNC_probe_write_user;
 static unsigned long long (*bpf_get_prandom_u32)(void) =
 	(void *)BPF_FUNC_get_prandom_u32;
+static unsigned long long (*bpf_get_current_cgroup_id)(void) =
+	(void *)BPF_FUNC_get_current_cgroup_id;
 
 /* llvm builtin functions that eBPF C program may use to
  * emit BPF_LD_ABS and BPF_LD_IND instructions
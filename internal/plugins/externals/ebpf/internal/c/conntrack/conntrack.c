@@ -110,16 +110,14 @@ static __always_inline int get_nf_tuple_info(struct nf_conn *ct, struct nf_origi
     bpf_probe_read(&value->dst_port, sizeof(__u16), &reply->dst.u);
     swap_u16(&value->dst_port);
 
-    // filter without DNAPT
+    // filter without DNAPT and SNAPT, the SNAPT tuples are used to find
+    // the real client of the masqueraded connections
     if (((__u64 *)(conn->dst_ip))[0] == ((__u64 *)(value->src_ip))[0] &&
         ((__u64 *)(conn->dst_ip) + 1)[0] == ((__u64 *)(value->src_ip) + 1)[0] &&
-        conn->dst_port == value->src_port 
-
-        // ignore SNAPT
-        // ((__u64 *)(conn->src_ip))[0] == ((__u64 *)(value->dst_ip))[0] &&
-        // ((__u64 *)(conn->src_ip) + 1)[0] == ((__u64 *)(value->dst_ip) + 1)[0] &&
-        // conn->src_port == value->dst_port
-    )
+        conn->dst_port == value->src_port &&
+        ((__u64 *)(conn->src_ip))[0] == ((__u64 *)(value->dst_ip))[0] &&
+        ((__u64 *)(conn->src_ip) + 1)[0] == ((__u64 *)(value->dst_ip) + 1)[0] &&
+        conn->src_port == value->dst_port)
     {
         return 1;
     }
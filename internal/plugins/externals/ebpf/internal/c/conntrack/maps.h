@@ -19,7 +19,11 @@ static __always_inline void do_dnapt(struct connection_info *conn , __u32 *dst_n
     key.netns = conn->netns;
 
     struct nf_reply_tuple *reply = (struct nf_reply_tuple *)bpf_map_lookup_elem(&bpfmap_conntrack_tuple, &key);
-    if (reply != NULL)
+    // the SNAPT only tuples are skipped
+    if (reply != NULL &&
+        (((__u64 *)(key.dst_ip))[0] != ((__u64 *)(reply->src_ip))[0] ||
+         ((__u64 *)(key.dst_ip) + 1)[0] != ((__u64 *)(reply->src_ip) + 1)[0] ||
+         key.dst_port != reply->src_port))
     {
         __builtin_memcpy(dst_nat_addr, reply->src_ip, sizeof(__u32[4]));
         *dst_nat_port = reply->src_port;
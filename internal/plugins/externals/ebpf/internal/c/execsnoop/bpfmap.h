@@ -0,0 +1,15 @@
+#ifndef __EXECSNOOP_BPFMAP_H__
+#define __EXECSNOOP_BPFMAP_H__
+
+#include "bpf_helpers.h"
+#include "execsnoop.h"
+
+BPF_PERF_EVENT_MAP(bpfmap_exec_event)
+
+// the event is larger than the stack of the bpf program
+BPF_PERCPU_ARRAY(bpfmap_exec_event_buf, exec_event_t)
+
+// pid_tgid -> the arguments read on entering execve, sent on exiting
+BPF_HASH_MAP(bpfmap_exec_pending, __u64, exec_event_t, 10240)
+
+#endif // !__EXECSNOOP_BPFMAP_H__
@@ -0,0 +1,95 @@
+#include <uapi/linux/ptrace.h>
+
+#include "bpf_helpers.h"
+#include "common.h"
+#include "execsnoop.h"
+#include "bpfmap.h"
+
+FN_TP_SYSCALL(sys_enter_execve, struct tp_sys_enter_execve_args *ctx)
+{
+    __u32 index = 0;
+    exec_event_t *event = bpf_map_lookup_elem(&bpfmap_exec_event_buf, &index);
+    if (event == NULL)
+    {
+        return 0;
+    }
+
+    __u64 pid_tgid = bpf_get_current_pid_tgid();
+
+    event->pid_tgid = pid_tgid;
+    event->uid_gid = bpf_get_current_uid_gid();
+    event->ts = bpf_ktime_get_ns();
+    event->type = EXEC_EVENT_EXECVE;
+    event->retval = 0;
+    event->argc = 0;
+    bpf_get_current_comm(&event->comm, sizeof(event->comm));
+
+    event->filename[0] = 0;
+    bpf_probe_read_str(&event->filename, sizeof(event->filename), ctx->filename);
+
+#pragma unroll
+    for (int i = 0; i < EXEC_ARGV_NUM; i++)
+    {
+        const char *arg = NULL;
+        bpf_probe_read(&arg, sizeof(arg), &ctx->argv[i]);
+        if (arg == NULL)
+        {
+            break;
+        }
+        event->argv[i][0] = 0;
+        bpf_probe_read_str(&event->argv[i], EXEC_ARG_LEN, arg);
+        event->argc++;
+    }
+
+    bpf_map_update_elem(&bpfmap_exec_pending, &pid_tgid, event, BPF_ANY);
+    return 0;
+}
+
+FN_TP_SYSCALL(sys_exit_execve, struct tp_sys_exit_execve_args *ctx)
+{
+    __u64 pid_tgid = bpf_get_current_pid_tgid();
+
+    exec_event_t *event = bpf_map_lookup_elem(&bpfmap_exec_pending, &pid_tgid);
+    if (event == NULL)
+    {
+        return 0;
+    }
+
+    event->retval = ctx->ret;
+    // the comm is changed to the new program if succeeded
+    bpf_get_current_comm(&event->comm, sizeof(event->comm));
+
+    __u64 cpu = bpf_get_smp_processor_id();
+    bpf_perf_event_output(ctx, &bpfmap_exec_event, cpu, event, sizeof(exec_event_t));
+
+    bpf_map_delete_elem(&bpfmap_exec_pending, &pid_tgid);
+    return 0;
+}
+
+SEC("uretprobe/readline")
+int uretprobe__audit_readline(struct pt_regs *ctx)
+{
+    void *ret = (void *)PT_REGS_RC(ctx);
+    if (ret == NULL)
+    {
+        return 0;
+    }
+
+    bash_line_event_t event = {0};
+
+    event.pid_tgid = bpf_get_current_pid_tgid();
+    event.uid_gid = bpf_get_current_uid_gid();
+    event.ts = bpf_ktime_get_ns();
+    event.type = EXEC_EVENT_BASH;
+    bpf_get_current_comm(&event.comm, sizeof(event.comm));
+    bpf_probe_read_str(&event.line, sizeof(event.line), ret);
+
+    __u64 cpu = bpf_get_smp_processor_id();
+    bpf_perf_event_output(ctx, &bpfmap_exec_event, cpu, &event, sizeof(event));
+    return 0;
+}
+
+char _license[] SEC("license") = "GPL";
+// this number will be interpreted by eBPF elf-loader
+// to set the current running kernel version
+__u32 _version SEC("version") = 0xFFFFFFFE;
@@ -0,0 +1,74 @@
+#ifndef __EXECSNOOP_H__
+#define __EXECSNOOP_H__
+
+#include <linux/types.h>
+
+#define KERNEL_TASK_COMM_LEN 16
+
+#define EXEC_FILENAME_LEN 128
+
+// the first EXEC_ARGV_NUM arguments are captured, each is truncated to
+// EXEC_ARG_LEN bytes
+#define EXEC_ARGV_NUM 16
+#define EXEC_ARG_LEN 64
+
+#define BASH_LINE_LEN 128
+
+enum exec_event_type
+{
+    EXEC_EVENT_EXECVE = 1,
+    EXEC_EVENT_BASH = 2,
+};
+
+typedef struct exec_event
+{
+    __u64 pid_tgid;
+    __u64 uid_gid;
+    __u64 ts;
+
+    __u32 type;
+    __s32 retval;
+    __u32 argc;
+    __u32 _pad0;
+
+    __u8 comm[KERNEL_TASK_COMM_LEN];
+    __u8 filename[EXEC_FILENAME_LEN];
+    __u8 argv[EXEC_ARGV_NUM][EXEC_ARG_LEN];
+} exec_event_t;
+
+typedef struct bash_line_event
+{
+    __u64 pid_tgid;
+    __u64 uid_gid;
+    __u64 ts;
+
+    __u32 type;
+    __u32 _pad0;
+
+    __u8 comm[KERNEL_TASK_COMM_LEN];
+    __u8 line[BASH_LINE_LEN];
+} bash_line_event_t;
+
+struct tp_sys_enter_execve_args
+{
+    __u64 _common;
+
+    __s32 _syscall_nr;
+    __u32 _pad0;
+
+    const char *filename;     // offset:16, size:8
+    const char *const *argv;  // offset:24, size:8
+    const char *const *envp;  // offset:32, size:8
+};
+
+struct tp_sys_exit_execve_args
+{
+    __u64 _common;
+
+    __s32 _syscall_nr;
+    __u32 _pad0;
+
+    __s64 ret; // offset:16, size:8(signed)
+};
+
+#endif // !__EXECSNOOP_H__
@@ -67,6 +67,20 @@ struct bpf_map_def SEC("maps/bpf_map_tmp_udprecvmsg") bpf_map_tmp_udprecvmsg = {
     .max_entries = 1024,
 };
 
+struct bpf_map_def SEC("maps/bpf_map_tmp_pingrecvmsg") bpf_map_tmp_pingrecvmsg = {
+    .type = BPF_MAP_TYPE_HASH,
+    .key_size = sizeof(__u64),
+    .value_size = sizeof(struct udp_revcmsg_tmp),
+    .max_entries = 1024,
+};
+
+struct bpf_map_def SEC("maps/bpfmap_icmp_echo_stats") bpfmap_icmp_echo_stats = {
+    .type = BPF_MAP_TYPE_HASH,
+    .key_size = sizeof(struct icmp_echo_key),
+    .value_size = sizeof(struct icmp_echo_stats),
+    .max_entries = 16384,
+};
+
 // Temporarily store the pid_tgid(key, u64) and sockfd(value, u32) when sockfd_lookup_light is called.
 struct bpf_map_def SEC("maps/bpfmap_tmp_sockfdlookuplight") bpfmap_tmp_sockfdlookuplight = {
     .type = BPF_MAP_TYPE_HASH,
@@ -265,4 +279,37 @@ static __always_inline void remove_from_conn_map(struct connection_info conn_inf
     bpf_map_delete_elem(&bpfmap_conn_stats, &conn_info);
 }
 
+// The rtt is the time between the last request and the first reply after it.
+static __always_inline void update_icmp_echo_stats(struct icmp_echo_key *key, size_t sent_bytes, size_t recv_bytes, u64 ts)
+{
+    struct icmp_echo_stats empty = {0};
+    bpf_map_update_elem(&bpfmap_icmp_echo_stats, key, &empty, BPF_NOEXIST);
+    struct icmp_echo_stats *val = bpf_map_lookup_elem(&bpfmap_icmp_echo_stats, key);
+    if (val == NULL)
+    {
+        return;
+    }
+
+    if (sent_bytes > 0)
+    {
+        __sync_fetch_and_add(&val->requests, 1);
+        __sync_fetch_and_add(&val->sent_bytes, sent_bytes);
+        val->last_request = ts;
+    }
+
+    if (recv_bytes > 0)
+    {
+        __sync_fetch_and_add(&val->replies, 1);
+        __sync_fetch_and_add(&val->recv_bytes, recv_bytes);
+        if (val->last_request > 0 && ts > val->last_request)
+        {
+            __sync_fetch_and_add(&val->rtt_total, ts - val->last_request);
+            __sync_fetch_and_add(&val->rtt_count, 1);
+            val->last_request = 0;
+        }
+    }
+
+    val->timestamp = ts;
+}
+
 #endif // !__BPFMAP_H
@@ -96,6 +96,29 @@ struct connection_closed_info
     struct connection_tcp_stats conn_tcp_stats;
 };
 
+// key of bpf map icmp_echo_stats, the echo requests sent by the ping sockets
+struct icmp_echo_key
+{
+    __be32 daddr[4]; // peer ip address
+    __u32 pid;
+    __u32 netns;
+    __u32 meta; // L3 protocol only
+};
+
+struct icmp_echo_stats
+{
+    __u64 requests;
+    __u64 replies;
+    __u64 sent_bytes;
+    __u64 recv_bytes;
+
+    __u64 rtt_total; // ns
+    __u64 rtt_count;
+
+    __u64 last_request; // ktime of the request waiting for the reply
+    __u64 timestamp;
+};
+
 struct port_bind
 {
     __u32 netns;
@@ -738,6 +738,157 @@ clear:
     return 0;
 }
 
+// ===============================================
+// ICMP echo of the ping sockets(SOCK_DGRAM, IPPROTO_ICMP/IPPROTO_ICMPV6),
+// the peer address is read from the msg_name of sendmsg and recvmsg.
+
+static __always_inline int read_icmp_echo_key(struct sock *sk, struct msghdr *msg,
+                                              struct icmp_echo_key *key, __u64 pid_tgid)
+{
+    struct sockaddr *ska = NULL;
+    if (msg == NULL)
+    {
+        return -1;
+    }
+    bpf_probe_read(&ska, sizeof(struct sockaddr *), &msg->msg_name);
+    if (ska == NULL)
+    {
+        return -1;
+    }
+
+    key->netns = read_netns(sk);
+    key->pid = pid_tgid >> 32;
+
+    unsigned short family = AF_UNSPEC;
+    bpf_probe_read(&family, sizeof(family), &ska->sa_family);
+    if (family == AF_INET)
+    {
+        key->meta = CONN_L3_IPv4;
+        bpf_probe_read(key->daddr + 3, sizeof(__be32), &(((struct sockaddr_in *)ska)->sin_addr.s_addr));
+        if (key->daddr[3] == 0)
+        {
+            return -1;
+        }
+    }
+    else if (family == AF_INET6)
+    {
+        key->meta = CONN_L3_IPv6;
+        bpf_probe_read(key->daddr, sizeof(__u32) * 4, &(((struct sockaddr_in6 *)ska)->sin6_addr));
+        if ((key->daddr[0] | key->daddr[1] | key->daddr[2] | key->daddr[3]) == 0)
+        {
+            return -1;
+        }
+    }
+    else
+    {
+        return -1;
+    }
+
+    return 0;
+}
+
+static __always_inline int handle_ping_sendmsg(struct sock *sk, struct msghdr *msg, size_t len)
+{
+    if (len == 0)
+    {
+        return 0;
+    }
+
+    __u64 pid_tgid = bpf_get_current_pid_tgid();
+    struct icmp_echo_key key = {};
+    if (read_icmp_echo_key(sk, msg, &key, pid_tgid) != 0)
+    {
+        return 0;
+    }
+
+    update_icmp_echo_stats(&key, len, 0, bpf_ktime_get_ns());
+    return 0;
+}
+
+// https://elixir.bootlin.com/linux/v4.0/source/net/ipv4/ping.c#L695
+SEC("kprobe/ping_v4_sendmsg")
+int kprobe__ping_v4_sendmsg(struct pt_regs *ctx)
+{
+    if (pre_kernel_4_1_0() == 0)
+    {
+        return handle_ping_sendmsg((struct sock *)PT_REGS_PARM1(ctx),
+                                   (struct msghdr *)PT_REGS_PARM2(ctx), (size_t)PT_REGS_PARM3(ctx));
+    }
+    return handle_ping_sendmsg((struct sock *)PT_REGS_PARM2(ctx),
+                               (struct msghdr *)PT_REGS_PARM3(ctx), (size_t)PT_REGS_PARM4(ctx));
+}
+
+SEC("kprobe/ping_v6_sendmsg")
+int kprobe__ping_v6_sendmsg(struct pt_regs *ctx)
+{
+    if (pre_kernel_4_1_0() == 0)
+    {
+        return handle_ping_sendmsg((struct sock *)PT_REGS_PARM1(ctx),
+                                   (struct msghdr *)PT_REGS_PARM2(ctx), (size_t)PT_REGS_PARM3(ctx));
+    }
+    return handle_ping_sendmsg((struct sock *)PT_REGS_PARM2(ctx),
+                               (struct msghdr *)PT_REGS_PARM3(ctx), (size_t)PT_REGS_PARM4(ctx));
+}
+
+SEC("kprobe/ping_recvmsg")
+int kprobe__ping_recvmsg(struct pt_regs *ctx)
+{
+    __u64 pid_tgid = bpf_get_current_pid_tgid();
+    struct udp_revcmsg_tmp rcvd = {
+        .sk = NULL,
+        .msg = NULL,
+    };
+
+    if (pre_kernel_4_1_0() == 0)
+    {
+        rcvd.sk = (struct sock *)PT_REGS_PARM1(ctx);
+        rcvd.msg = (struct msghdr *)PT_REGS_PARM2(ctx);
+    }
+    else
+    {
+        rcvd.sk = (struct sock *)PT_REGS_PARM2(ctx);
+        rcvd.msg = (struct msghdr *)PT_REGS_PARM3(ctx);
+    }
+
+    if (rcvd.sk == NULL || rcvd.msg == NULL)
+    {
+        return 0;
+    }
+    bpf_map_update_elem(&bpf_map_tmp_pingrecvmsg, &pid_tgid, &rcvd, BPF_ANY);
+    return 0;
+}
+
+SEC("kretprobe/ping_recvmsg")
+int kretprobe__ping_recvmsg(struct pt_regs *ctx)
+{
+    __u64 pid_tgid = bpf_get_current_pid_tgid();
+    int copied = (int)PT_REGS_RC(ctx);
+
+    struct udp_revcmsg_tmp *rcvd = bpf_map_lookup_elem(&bpf_map_tmp_pingrecvmsg, &pid_tgid);
+    if (rcvd == NULL)
+    {
+        return 0;
+    }
+
+    struct udp_revcmsg_tmp r = {};
+    bpf_probe_read(&r, sizeof(r), rcvd);
+    bpf_map_delete_elem(&bpf_map_tmp_pingrecvmsg, &pid_tgid);
+
+    if (copied <= 0)
+    {
+        return 0;
+    }
+
+    struct icmp_echo_key key = {};
+    if (read_icmp_echo_key(r.sk, r.msg, &key, pid_tgid) != 0)
+    {
+        return 0;
+    }
+
+    update_icmp_echo_stats(&key, 0, copied, bpf_ktime_get_ns());
+    return 0;
+}
+
 char _license[] SEC("license") = "GPL";
 // this number will be interpreted by eBPF(Cilium) elf-loader
 // to set the current running kernel version
@@ -0,0 +1,23 @@
+#ifndef __PROFILING_BPFMAP_H__
+#define __PROFILING_BPFMAP_H__
+
+#include "bpf_helpers.h"
+#include "profiling.h"
+
+struct bpf_map_def SEC("maps/bpfmap_prof_stacks") bpfmap_prof_stacks = {
+    .type = BPF_MAP_TYPE_STACK_TRACE,
+    .key_size = sizeof(__u32),
+    .value_size = PROF_STACK_DEPTH * sizeof(__u64),
+    .max_entries = 16384,
+};
+
+// {pid, stack ids, cgroup id, comm} -> {cpu samples, off-cpu time}
+BPF_HASH_MAP(bpfmap_prof_counts, prof_key_t, prof_value_t, 65536);
+
+// tid -> the time and the stack when the task was switched out
+BPF_HASH_MAP(bpfmap_prof_offcpu_start, __u32, prof_offcpu_start_t, 65536);
+
+// cgroup id -> 1, used by the allow list or the deny list
+BPF_HASH_MAP(bpfmap_prof_cgroup_filter, __u64, __u8, 4096);
+
+#endif // !__PROFILING_BPFMAP_H__
@@ -0,0 +1,142 @@
+#include <uapi/linux/bpf.h>
+#include <uapi/linux/bpf_perf_event.h>
+
+#include "bpf_helpers.h"
+#include "load_const.h"
+#include "profiling.h"
+#include "bpfmap.h"
+
+static __always_inline __u64 load_self_pid()
+{
+    __u64 var = 0;
+    LOAD_OFFSET("profiling_self_pid", var);
+    return var;
+}
+
+static __always_inline __u64 load_cgroup_filter()
+{
+    __u64 var = 0;
+    LOAD_OFFSET("profiling_cgroup_filter", var);
+    return var;
+}
+
+static __always_inline int cgroup_filtered(__u64 cgroup_id)
+{
+    __u64 mode = load_cgroup_filter();
+    if (mode == PROF_CGROUP_FILTER_NONE)
+    {
+        return 0;
+    }
+
+    __u8 *v = bpf_map_lookup_elem(&bpfmap_prof_cgroup_filter, &cgroup_id);
+    if (mode == PROF_CGROUP_FILTER_ALLOW)
+    {
+        return v == NULL;
+    }
+    return v != NULL;
+}
+
+// init_key sets the key of the current task, returns 0 if the task is profiled.
+static __always_inline int init_key(void *ctx, prof_key_t *key)
+{
+    __u64 pid_tgid = bpf_get_current_pid_tgid();
+    __u32 pid = pid_tgid >> 32;
+
+    // idle task or datakit-ebpf
+    if (pid == 0 || pid == load_self_pid())
+    {
+        return -1;
+    }
+
+    __u64 cgroup_id = bpf_get_current_cgroup_id();
+    if (cgroup_filtered(cgroup_id))
+    {
+        return -1;
+    }
+
+    key->pid = pid;
+    key->cgroup_id = cgroup_id;
+    key->user_stack_id = bpf_get_stackid(ctx, &bpfmap_prof_stacks, BPF_F_USER_STACK);
+    key->kern_stack_id = bpf_get_stackid(ctx, &bpfmap_prof_stacks, 0);
+    bpf_get_current_comm(&key->comm, sizeof(key->comm));
+
+    return 0;
+}
+
+static __always_inline void add_value(prof_key_t *key, __u64 samples, __u64 off_cpu_ns)
+{
+    prof_value_t *v = bpf_map_lookup_elem(&bpfmap_prof_counts, key);
+    if (v == NULL)
+    {
+        prof_value_t init = {0};
+        bpf_map_update_elem(&bpfmap_prof_counts, key, &init, BPF_NOEXIST);
+        v = bpf_map_lookup_elem(&bpfmap_prof_counts, key);
+        if (v == NULL)
+        {
+            return;
+        }
+    }
+
+    if (samples != 0)
+    {
+        __sync_fetch_and_add(&v->cpu_samples, samples);
+    }
+    if (off_cpu_ns != 0)
+    {
+        __sync_fetch_and_add(&v->off_cpu_ns, off_cpu_ns);
+    }
+}
+
+SEC("perf_event/cpu_clock")
+int perf_event__cpu_clock(struct bpf_perf_event_data *ctx)
+{
+    prof_key_t key = {0};
+    if (init_key(ctx, &key) != 0)
+    {
+        return 0;
+    }
+
+    add_value(&key, 1, 0);
+    return 0;
+}
+
+// sched_switch is called in the context of the prev task, the stack of the
+// prev is recorded when it is switched out, and the off-cpu time is added
+// when it is switched in again.
+SEC("tracepoint/sched/sched_switch")
+int tracepoint__sched_switch(struct tp_sched_switch_args *ctx)
+{
+    __u64 ts = bpf_ktime_get_ns();
+
+    __u32 prev_tid = ctx->prev_pid;
+    if (prev_tid != 0)
+    {
+        prof_offcpu_start_t start = {0};
+        if (init_key(ctx, &start.key) == 0)
+        {
+            start.ts = ts;
+            bpf_map_update_elem(&bpfmap_prof_offcpu_start, &prev_tid, &start, BPF_ANY);
+        }
+    }
+
+    __u32 next_tid = ctx->next_pid;
+    prof_offcpu_start_t *start = bpf_map_lookup_elem(&bpfmap_prof_offcpu_start, &next_tid);
+    if (start == NULL)
+    {
+        return 0;
+    }
+
+    if (ts > start->ts)
+    {
+        prof_key_t key = start->key;
+        add_value(&key, 0, ts - start->ts);
+    }
+    bpf_map_delete_elem(&bpfmap_prof_offcpu_start, &next_tid);
+
+    return 0;
+}
+
+char _license[] SEC("license") = "GPL";
+// this number will be interpreted by eBPF elf-loader
+// to set the current running kernel version
+__u32 _version SEC("version") = 0xFFFFFFFE;
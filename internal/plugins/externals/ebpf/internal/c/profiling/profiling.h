@@ -0,0 +1,54 @@
+#ifndef __PROFILING_H__
+#define __PROFILING_H__
+
+#include <linux/types.h>
+
+#define KERNEL_TASK_COMM_LEN 16
+
+// PERF_MAX_STACK_DEPTH
+#define PROF_STACK_DEPTH 127
+
+enum prof_cgroup_filter
+{
+    PROF_CGROUP_FILTER_NONE = 0,
+    PROF_CGROUP_FILTER_ALLOW = 1,
+    PROF_CGROUP_FILTER_DENY = 2,
+};
+
+typedef struct prof_key
+{
+    __u32 pid;
+    __s32 user_stack_id;
+    __s32 kern_stack_id;
+    __u32 _pad0;
+    __u64 cgroup_id;
+    __u8 comm[KERNEL_TASK_COMM_LEN];
+} prof_key_t;
+
+typedef struct prof_value
+{
+    __u64 cpu_samples;
+    __u64 off_cpu_ns;
+} prof_value_t;
+
+// the task was switched out at ts
+typedef struct prof_offcpu_start
+{
+    __u64 ts;
+    prof_key_t key;
+} prof_offcpu_start_t;
+
+struct tp_sched_switch_args
+{
+    __u64 _common;
+
+    __u8 prev_comm[16];
+    __s32 prev_pid;
+    __s32 prev_prio;
+    __s64 prev_state;
+    __u8 next_comm[16];
+    __s32 next_pid;
+    __s32 next_prio;
+};
+
+#endif // !__PROFILING_H__
@@ -26,3 +26,13 @@ func TestEnv(t *testing.T) {
 
 	t.Log(fl)
 }
+
+func TestEnvInputNetFilter(t *testing.T) {
+	v := "dst_port == 6379"
+	t.Setenv(EnvInputNetlogNetFilter, v)
+
+	fl := Flag{}
+	readEnv(&fl)
+
+	assert.Equal(t, v, fl.BPFNetLog.NetFilter)
+}
@@ -0,0 +1,64 @@
+//go:build linux
+// +build linux
+
+package run
+
+import (
+	"fmt"
+	"strings"
+
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/internal/exporter"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/internal/kfeature"
+)
+
+// applyKernelFeatures disables the enabled plugins not supported by the kernel
+// and degrades the others by the self-test report, the disabled plugins are
+// reported to DataKit as the last error. It returns false if no plugin
+// disabled.
+func applyKernelFeatures(r *kfeature.Report, fl *Flag) bool {
+	plugins := []struct {
+		name    string
+		enabled *bool
+	}{
+		{inputNameNet, &enableEbpfNet},
+		{pluginNameTracing, &enableTrace},
+		{pluginNameConntrack, &enableEbpfConntrack},
+		{inputNameBash, &enableEbpfBash},
+		{pluginNameProfiling, &enableProfiling},
+		{pluginNameExec, &enableExec},
+	}
+
+	var errs []string
+	for _, p := range plugins {
+		if !*p.enabled {
+			continue
+		}
+
+		m := r.Mode(p.name)
+		switch m.Mode {
+		case kfeature.ModeDisabled:
+			*p.enabled = false
+			errs = append(errs, fmt.Sprintf("%s disabled: %s", p.name, strings.Join(m.Reasons, ", ")))
+		case kfeature.ModeDegraded:
+			log.Warnf("%s degraded: %s", p.name, strings.Join(m.Reasons, ", "))
+		}
+	}
+
+	if enableProfiling && fl.Profiling.OffCPU && !r.Supported(kfeature.FeatureTracepoint) {
+		fl.Profiling.OffCPU = false
+	}
+
+	if len(errs) == 0 {
+		return false
+	}
+
+	errStr := strings.Join(errs, "; ")
+	log.Error(errStr)
+	if err := exporter.FeedLastError(exporter.ExternalLastErr{
+		Input:      inputName,
+		ErrContent: errStr,
+	}); err != nil {
+		log.Error(err)
+	}
+	return true
+}
@@ -0,0 +1,39 @@
+//go:build linux
+// +build linux
+
+package run
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/internal/kfeature"
+)
+
+func TestApplyKernelFeatures(t *testing.T) {
+	defer func(net, trace, profiling bool) {
+		enableEbpfNet, enableTrace, enableProfiling = net, trace, profiling
+	}(enableEbpfNet, enableTrace, enableProfiling)
+
+	r := &kfeature.Report{
+		Features: []kfeature.Feature{
+			{Name: kfeature.FeatureTracepoint, Supported: false},
+		},
+		Plugins: []kfeature.PluginMode{
+			{Plugin: inputNameNet, Mode: kfeature.ModeDegraded, Reasons: []string{"btf not supported"}},
+			{Plugin: pluginNameProfiling, Mode: kfeature.ModeDegraded, Reasons: []string{"tracepoint not supported"}},
+		},
+	}
+
+	enableEbpfNet, enableTrace, enableProfiling = true, false, true
+	fl := &Flag{Profiling: FlagProfiling{OffCPU: true}}
+	assert.False(t, applyKernelFeatures(r, fl))
+	assert.True(t, enableEbpfNet)
+	assert.True(t, enableProfiling)
+	assert.False(t, fl.Profiling.OffCPU)
+
+	r.Plugins[0] = kfeature.PluginMode{Plugin: inputNameNet, Mode: kfeature.ModeDisabled, Reasons: []string{"kprobe not supported"}}
+	assert.True(t, applyKernelFeatures(r, fl))
+	assert.False(t, enableEbpfNet)
+	assert.True(t, enableProfiling)
+}
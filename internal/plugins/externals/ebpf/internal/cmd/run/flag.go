@@ -17,6 +17,10 @@ var (
 	EnvBearerTokenPath = "K8S_BEARER_TOKEN_PATH"
 
 	EnvNetlogNetFilter = "NETLOG_NET_FILTER"
+
+	// EnvInputNetlogNetFilter is set by DataKit instead of DKE_NETLOG_NET_FILTER,
+	// so that the changed filter is sent by the reload message.
+	EnvInputNetlogNetFilter = "ENV_INPUT_EBPF_NETLOG_NET_FILTER"
 )
 
 type Flag struct {
@@ -42,6 +46,8 @@ type Flag struct {
 	EBPFNet       FlagNet       `toml:"ebpf_net"`
 	EBPFTrace     FlagTrace     `toml:"ebpf_trace"`
 	BPFNetLog     FlagBPFNetLog `toml:"bpf_netlog"`
+	Profiling     FlagProfiling `toml:"ebpf_profiling"`
+	Exec          FlagExec      `toml:"ebpf_exec"`
 	ResourceLimit FlagResLimit  `toml:"resource_limit"`
 
 	Sampling FlagSampling `toml:"sampling"`
@@ -58,6 +64,10 @@ type FlagNet struct {
 
 	EphemeralPort int32 `toml:"ephemeral_port"`
 	IPv6Disabled  bool  `toml:"ipv6_diabled"`
+
+	UDPDisabled     bool   `toml:"udp_disabled"`
+	FlowIdleTimeout string `toml:"flow_idle_timeout"`
+	SvcHistogram    bool   `toml:"svc_histogram"`
 }
 
 type FlagBPFNetLog struct {
@@ -67,6 +77,21 @@ type FlagBPFNetLog struct {
 	NetFilter      string   `toml:"net_filter"`
 }
 
+type FlagProfiling struct {
+	SampleRate     int      `toml:"sample_rate"`
+	OffCPU         bool     `toml:"off_cpu"`
+	CgroupEnabled  []string `toml:"cgroup_enabled"`
+	CgroupDisabled []string `toml:"cgroup_disabled"`
+}
+
+// FlagExec filters the audited command executions, all are audited if the
+// lists are empty.
+type FlagExec struct {
+	Users        []string `toml:"users"`
+	Binaries     []string `toml:"binaries"`
+	BashDisabled bool     `toml:"bash_disabled"`
+}
+
 type FlagTrace struct {
 	TraceServer         string   `toml:"trace_server"`
 	TraceAllProc        bool     `toml:"trace_all_proc"`
@@ -77,6 +102,15 @@ type FlagTrace struct {
 	TraceNameBlacklist  []string `toml:"trace_name_blacklist"`
 	TraceProtoBlacklist []string `toml:"trace_proto_blacklist"`
 	ConvTraceToDD       bool     `toml:"conv_trace_to_dd"`
+
+	Payload FlagTracePayload `toml:"payload"`
+}
+
+// FlagTracePayload is the payload sampling of the traced calls.
+type FlagTracePayload struct {
+	SamplingRate float64 `toml:"sampling_rate"`
+	MaxSize      int     `toml:"max_size"`
+	MaxPerMin    int     `toml:"max_per_min"`
 }
 
 type FlagK8s struct {
@@ -103,6 +137,10 @@ func readEnv(flag *Flag) {
 		}
 		key := env[:i]
 		key = strings.TrimSpace(key)
+		if key == EnvInputNetlogNetFilter {
+			flag.BPFNetLog.NetFilter = strings.TrimSpace(env[i+1:])
+			continue
+		}
 		if strings.HasPrefix(key, EnvPrefix) {
 			key = strings.TrimPrefix(key, EnvPrefix)
 		} else {
@@ -0,0 +1,174 @@
+//go:build linux
+// +build linux
+
+package run
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/internal/exporter"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/internal/l4log"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/internal/l7flow"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/internal/l7flow/protodec"
+)
+
+const (
+	actionReload = "reload"
+
+	// envInputPrefix is the prefix of environment variables could be reloaded,
+	// such as ENV_INPUT_EBPF_NETLOG_NET_FILTER.
+	envInputPrefix = "ENV_INPUT_"
+
+	maxControlMessageSize = 1 << 20
+)
+
+// controlMessage is sent by DataKit to datakit-ebpf by stdin, one JSON per line.
+// Same as struct 'controlMessage' in internal/plugins/inputs/external/control.go.
+type controlMessage struct {
+	Action string   `json:"action"`
+	Args   []string `json:"args"`
+	Envs   []string `json:"envs"`
+}
+
+// reloader applies the filter rules of the reloaded config without reloading
+// the BPF programs, they are the sampling rate, the traced protocols, the payload
+// sampling and the netlog blacklist. Other options are applied after restarted.
+type reloader struct {
+	cur       Flag
+	apiTracer *l7flow.APIFlowTracer
+}
+
+// watchControl reads control messages from r until EOF. If datakit-ebpf is not
+// started by DataKit with the control channel, such as stdin is /dev/null, it
+// returns at once.
+func watchControl(r io.Reader, rl *reloader) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 4096), maxControlMessageSize)
+
+	for sc.Scan() {
+		var msg controlMessage
+		if err := json.Unmarshal(sc.Bytes(), &msg); err != nil {
+			log.Warnf("invalid control message: %s, ignored", err.Error())
+			continue
+		}
+
+		log.Infof("control message %q received", msg.Action)
+		if msg.Action != actionReload {
+			log.Warnf("unknown control action %q, ignored", msg.Action)
+			continue
+		}
+
+		opt, err := parseReloadFlag(&msg)
+		if err != nil {
+			log.Errorf("parse reloaded args failed: %s, ignored", err.Error())
+			continue
+		}
+		rl.reload(opt)
+	}
+
+	if err := sc.Err(); err != nil {
+		log.Warnf("read control channel failed: %s", err.Error())
+	}
+}
+
+// parseReloadFlag parses args of the reload message the same as the run
+// command. Environment variables of ENV_INPUT_* are replaced by envs of the
+// message, other environment variables are not changed.
+func parseReloadFlag(msg *controlMessage) (*Flag, error) {
+	var opt Flag
+	var cfgFilePath string
+
+	fs := pflag.NewFlagSet("run", pflag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	bindFlags(fs, &opt, &cfgFilePath)
+
+	args := msg.Args
+	if len(args) > 0 && args[0] == "run" {
+		args = args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	for _, kv := range os.Environ() {
+		if k, _, ok := strings.Cut(kv, "="); ok && strings.HasPrefix(k, envInputPrefix) {
+			if err := os.Unsetenv(k); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, kv := range msg.Envs {
+		if k, v, ok := strings.Cut(kv, "="); ok && strings.HasPrefix(k, envInputPrefix) {
+			if err := os.Setenv(k, v); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return mergeOption(&cfgFilePath, &opt)
+}
+
+func (rl *reloader) reload(opt *Flag) {
+	cur := &rl.cur
+
+	if !reflect.DeepEqual(restartOptions(cur), restartOptions(opt)) {
+		log.Warn("options other than the sampling rate, trace protocols, payload sampling and netlog filter changed," +
+			" applied after restarted")
+	}
+
+	if opt.Sampling != cur.Sampling {
+		exporter.SetSampling(opt.Sampling.Rate, opt.Sampling.RatePtsPerMinute)
+		cur.Sampling = opt.Sampling
+	}
+
+	if rl.apiTracer != nil &&
+		(!reflect.DeepEqual(opt.EBPFTrace.TraceProtoList, cur.EBPFTrace.TraceProtoList) ||
+			!reflect.DeepEqual(opt.EBPFTrace.TraceProtoBlacklist, cur.EBPFTrace.TraceProtoBlacklist)) {
+		if ignored := rl.apiTracer.SetProtos(l7Protos(opt)); len(ignored) > 0 {
+			log.Warnf("protocols %s not enabled on started, applied after restarted",
+				protoNames(ignored))
+		}
+		cur.EBPFTrace.TraceProtoList = opt.EBPFTrace.TraceProtoList
+		cur.EBPFTrace.TraceProtoBlacklist = opt.EBPFTrace.TraceProtoBlacklist
+	}
+
+	if opt.EBPFTrace.Payload != cur.EBPFTrace.Payload {
+		setPayloadSampling(&opt.EBPFTrace.Payload)
+		cur.EBPFTrace.Payload = opt.EBPFTrace.Payload
+	}
+
+	if enableBpfNetlog && opt.BPFNetLog.NetFilter != cur.BPFNetLog.NetFilter {
+		if err := l4log.SetBlacklist(opt.BPFNetLog.NetFilter); err != nil {
+			log.Errorf("reload netlog filter failed: %s, the previous one kept", err.Error())
+		} else {
+			cur.BPFNetLog.NetFilter = opt.BPFNetLog.NetFilter
+			log.Infof("transport blacklist reloaded: \n\n%s\n", opt.BPFNetLog.NetFilter)
+		}
+	}
+}
+
+// restartOptions returns the flag without the options applied by reloading.
+func restartOptions(fl *Flag) Flag {
+	v := *fl
+	v.Sampling = FlagSampling{}
+	v.EBPFTrace.TraceProtoList = nil
+	v.EBPFTrace.TraceProtoBlacklist = nil
+	v.EBPFTrace.Payload = FlagTracePayload{}
+	v.BPFNetLog.NetFilter = ""
+	return v
+}
+
+func protoNames(protos []protodec.L7Protocol) string {
+	var arr []string
+	for _, p := range protos {
+		arr = append(arr, p.StringLower())
+	}
+	return strings.Join(arr, ",")
+}
@@ -0,0 +1,96 @@
+//go:build linux
+// +build linux
+
+package run
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReloadFlag(t *testing.T) {
+	t.Setenv(EnvInputNetlogNetFilter, "dst_port == 1")
+	t.Setenv("ENV_INPUT_EBPF_UNUSED", "1")
+	t.Setenv("LD_LIBRARY_PATH", "/usr/lib")
+	t.Setenv(EnvPrefix+EnvK8sURL, "https://kubernetes.default:443")
+
+	opt, err := parseReloadFlag(&controlMessage{
+		Action: actionReload,
+		Args: []string{
+			"run",
+			"--hostname", "host-a",
+			"--enabled", "ebpf-net,bpf-netlog",
+			"--trace-protos-blacklist", "mysql,redis",
+			"--sampling-rate", "0.50",
+		},
+		Envs: []string{
+			EnvInputNetlogNetFilter + "=dst_port == 6379",
+			"LD_LIBRARY_PATH=/opt",
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "host-a", opt.HostName)
+	assert.Equal(t, []string{"ebpf-net", "bpf-netlog"}, opt.Enabled)
+	assert.Equal(t, []string{"mysql", "redis"}, opt.EBPFTrace.TraceProtoBlacklist)
+	assert.Equal(t, "0.50", opt.Sampling.Rate)
+	assert.Equal(t, "dst_port == 6379", opt.BPFNetLog.NetFilter)
+	assert.Equal(t, "https://kubernetes.default:443", opt.K8sInfo.URL)
+
+	// only the ENV_INPUT_* are replaced
+	_, ok := os.LookupEnv("ENV_INPUT_EBPF_UNUSED")
+	assert.False(t, ok)
+	assert.Equal(t, "/usr/lib", os.Getenv("LD_LIBRARY_PATH"))
+
+	_, err = parseReloadFlag(&controlMessage{
+		Action: actionReload,
+		Args:   []string{"run", "--unknown-flag"},
+	})
+	assert.Error(t, err)
+}
+
+func TestReload(t *testing.T) {
+	parse := func(args ...string) *Flag {
+		opt, err := parseReloadFlag(&controlMessage{Action: actionReload, Args: args})
+		require.NoError(t, err)
+		return opt
+	}
+
+	defer func(v bool) { enableBpfNetlog = v }(enableBpfNetlog)
+	enableBpfNetlog = true
+
+	t.Setenv(EnvInputNetlogNetFilter, "")
+
+	cur := parse("run", "--enabled", "bpf-netlog", "--sampling-rate", "0.50")
+	rl := &reloader{cur: *cur}
+
+	// the filter rules are applied
+	opt := parse("run", "--enabled", "bpf-netlog", "--sampling-rate-ptsperminute", "1500")
+	opt.BPFNetLog.NetFilter = "dst_port == 6379"
+	assert.Equal(t, restartOptions(cur), restartOptions(opt))
+	rl.reload(opt)
+	assert.Equal(t, FlagSampling{RatePtsPerMinute: "1500"}, rl.cur.Sampling)
+	assert.Equal(t, "dst_port == 6379", rl.cur.BPFNetLog.NetFilter)
+
+	// the invalid filter is not applied
+	opt = parse("run", "--enabled", "bpf-netlog", "--sampling-rate-ptsperminute", "1500")
+	opt.BPFNetLog.NetFilter = "dst_port =="
+	rl.reload(opt)
+	assert.Equal(t, "dst_port == 6379", rl.cur.BPFNetLog.NetFilter)
+
+	// the payload sampling is applied
+	opt = parse("run", "--enabled", "bpf-netlog", "--sampling-rate-ptsperminute", "1500",
+		"--trace-payload-sampling-rate", "0.1", "--trace-payload-max-size", "128")
+	opt.BPFNetLog.NetFilter = "dst_port == 6379"
+	assert.Equal(t, restartOptions(&rl.cur), restartOptions(opt))
+	rl.reload(opt)
+	defer setPayloadSampling(&FlagTracePayload{})
+	assert.Equal(t, FlagTracePayload{SamplingRate: 0.1, MaxSize: 128, MaxPerMin: 60}, rl.cur.EBPFTrace.Payload)
+
+	// other options require restarting
+	opt = parse("run", "--enabled", "bpf-netlog,ebpf-net", "--sampling-rate-ptsperminute", "1500")
+	assert.NotEqual(t, restartOptions(&rl.cur), restartOptions(opt))
+}
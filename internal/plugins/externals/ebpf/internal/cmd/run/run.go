@@ -16,19 +16,25 @@ import (
 	manager "github.com/DataDog/ebpf-manager"
 	"github.com/GuanceCloud/cliutils/logger"
 	"github.com/cilium/ebpf"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/internal/bashhistory"
 	dkct "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/internal/conntrack"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/internal/dnsflow"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/internal/execsnoop"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/internal/exporter"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/internal/k8sinfo"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/internal/kfeature"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/internal/l4log"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/internal/l7flow"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/internal/l7flow/protodec"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/internal/netflow"
 	dkoffset "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/internal/offset"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/internal/profiling"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/internal/sysmonitor"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/pkg/dumpstd"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/pkg/stats"
 
 	// nolint:gosec
 	_ "net/http/pprof"
@@ -40,6 +46,8 @@ var (
 	enableBpfNetlog     = false
 	enableEbpfConntrack = false
 	enableTrace         = false
+	enableProfiling     = false
+	enableExec          = false
 
 	enableHTTPFlow    = false
 	enableHTTPFlowTLS = false
@@ -69,6 +77,8 @@ const (
 
 	pluginNameConntrack = "ebpf-conntrack"
 	pluginNameTracing   = "ebpf-trace"
+	pluginNameProfiling = "ebpf-profiling"
+	pluginNameExec      = "ebpf-exec"
 )
 
 // init opt, dkutil.DataKitAPIServer, datakitPostURL.
@@ -89,6 +99,10 @@ func parseFlags(opt *Flag) (*Flag, map[string]string, error) {
 			enableEbpfConntrack = true
 		case inputNameNetlog:
 			enableBpfNetlog = true
+		case pluginNameProfiling:
+			enableProfiling = true
+		case pluginNameExec:
+			enableExec = true
 		}
 	}
 
@@ -121,6 +135,8 @@ func parseFlags(opt *Flag) (*Flag, map[string]string, error) {
 
 	conv2ddID = opt.EBPFTrace.ConvTraceToDD
 
+	setPayloadSampling(&opt.EBPFTrace.Payload)
+
 	for _, item := range opt.Tags {
 		log.Info("set tag: ", item)
 
@@ -170,74 +186,107 @@ func NewRunCmd() *cobra.Command {
 		},
 	}
 
-	cmd.Flags().StringVar(&cfgFilePath, "config", "",
+	bindFlags(cmd.Flags(), &opt, &cfgFilePath)
+	_ = cmd.MarkFlagRequired("enabled")
+
+	return cmd
+}
+
+// bindFlags binds the command line flags to opt, it's also used to parse the
+// args of the reload message.
+func bindFlags(fs *pflag.FlagSet, opt *Flag, cfgFilePath *string) {
+	fs.StringVar(cfgFilePath, "config", "",
 		"set config file path")
-	cmd.Flags().StringVar(&opt.DataKitAPIServer, "datakit-apiserver", "0.0.0.0:9529",
+	fs.StringVar(&opt.DataKitAPIServer, "datakit-apiserver", "0.0.0.0:9529",
 		"set DataKit API server")
-	cmd.Flags().StringVar(&opt.EBPFTrace.TraceServer, "trace-server", "",
+	fs.StringVar(&opt.EBPFTrace.TraceServer, "trace-server", "",
 		"set eBPF trace generation server address")
 
-	cmd.Flags().StringVar(&opt.HostName, "hostname", "", "set host name")
-	cmd.Flags().StringVar(&opt.Interval, "interval", "60s", "set gather interval")
-	cmd.Flags().StringVar(&opt.PIDFile, "pidfile", "", "set pid file")
+	fs.StringVar(&opt.HostName, "hostname", "", "set host name")
+	fs.StringVar(&opt.Interval, "interval", "60s", "set gather interval")
+	fs.StringVar(&opt.PIDFile, "pidfile", "", "set pid file")
 
-	cmd.Flags().StringVar(&opt.Log, "log", "", "set log file path")
-	cmd.Flags().StringVar(&opt.LogLevel, "log-level", "info", "set log level")
+	fs.StringVar(&opt.Log, "log", "", "set log file path")
+	fs.StringVar(&opt.LogLevel, "log-level", "info", "set log level")
 
-	cmd.Flags().StringSliceVar(&opt.Tags, "tags", []string{}, "additional tags in 'a=b,c=d,...' format")
-	cmd.Flags().StringSliceVar(&opt.Enabled, "enabled", []string{}, "enabled plugins list in 'a,b,...' format")
+	fs.StringSliceVar(&opt.Tags, "tags", []string{}, "additional tags in 'a=b,c=d,...' format")
+	fs.StringSliceVar(&opt.Enabled, "enabled", []string{}, "enabled plugins list in 'a,b,...' format")
 
-	cmd.Flags().StringSliceVar(&opt.ContainerInfo.Endpoints, "container-endpoints", []string{}, "container endpoints list in 'a,b,...' format")
+	fs.StringSliceVar(&opt.ContainerInfo.Endpoints, "container-endpoints", []string{}, "container endpoints list in 'a,b,...' format")
 
-	cmd.Flags().BoolVar(&opt.BPFNetLog.EnableMetric, "netlog-metric", false, "netlog metric")
-	cmd.Flags().BoolVar(&opt.BPFNetLog.EnableLog, "netlog-log", false, "netlog log")
-	cmd.Flags().StringSliceVar(&opt.BPFNetLog.L7LogProtocols, "netlog-protocols", []string{"http"},
+	fs.BoolVar(&opt.BPFNetLog.EnableMetric, "netlog-metric", false, "netlog metric")
+	fs.BoolVar(&opt.BPFNetLog.EnableLog, "netlog-log", false, "netlog log")
+	fs.StringSliceVar(&opt.BPFNetLog.L7LogProtocols, "netlog-protocols", []string{"http"},
 		"netlog protocols list in 'a,b,...' format")
 
-	cmd.Flags().Int32Var(&opt.EBPFNet.EphemeralPort, "ephemeral_port", 0, "set ephemeral port")
-	cmd.Flags().Int32Var(&opt.EBPFNet.EphemeralPort, "ephemeral-port", 0, "set ephemeral port")
+	fs.Int32Var(&opt.EBPFNet.EphemeralPort, "ephemeral_port", 0, "set ephemeral port")
+	fs.Int32Var(&opt.EBPFNet.EphemeralPort, "ephemeral-port", 0, "set ephemeral port")
 
-	cmd.Flags().StringSliceVar(&opt.EBPFNet.L7NetDisabled, "l7net-disabled", []string{},
+	fs.StringSliceVar(&opt.EBPFNet.L7NetDisabled, "l7net-disabled", []string{},
 		"disabled sub plugins of epbf-net list in 'a,b,...' format")
-	cmd.Flags().StringSliceVar(&opt.EBPFNet.L7NetEnabled, "l7net-enabled", []string{},
+	fs.StringSliceVar(&opt.EBPFNet.L7NetEnabled, "l7net-enabled", []string{},
 		"enabled sub plugins of epbf-net list in 'a,b,...' format")
 
-	cmd.Flags().BoolVar(&opt.EBPFNet.IPv6Disabled, "ipv6-disabled", false, "ipv6 is not enabled on the system")
+	fs.BoolVar(&opt.EBPFNet.IPv6Disabled, "ipv6-disabled", false, "ipv6 is not enabled on the system")
+
+	fs.BoolVar(&opt.EBPFNet.UDPDisabled, "netflow-udp-disabled", false, "disable the netflow of udp")
+	fs.StringVar(&opt.EBPFNet.FlowIdleTimeout, "netflow-idle-timeout", "2m",
+		"the udp flows and icmp echo idle for the timeout are removed")
+	fs.BoolVar(&opt.EBPFNet.SvcHistogram, "netflow-svc-histogram", false,
+		"report the tcp rtt histogram and retransmits per k8s service and pod")
 
-	cmd.Flags().StringVar(&opt.PprofHost, "pprof-host", "", "set pprof host")
-	cmd.Flags().StringVar(&opt.PprofPort, "pprof-port", "", "set pprof port")
+	fs.StringVar(&opt.PprofHost, "pprof-host", "", "set pprof host")
+	fs.StringVar(&opt.PprofPort, "pprof-port", "", "set pprof port")
 
-	cmd.Flags().StringVar(&opt.Service, "service", "ebpf", "set service")
+	fs.StringVar(&opt.Service, "service", "ebpf", "set service")
 
-	cmd.Flags().BoolVar(&opt.EBPFTrace.TraceAllProc, "trace-allprocess", false, "trace all processes directly")
+	fs.BoolVar(&opt.EBPFTrace.TraceAllProc, "trace-allprocess", false, "trace all processes directly")
 
-	cmd.Flags().StringSliceVar(&opt.EBPFTrace.TraceProtoList, "trace-protos", []string{}, "trace specified protocols")
-	cmd.Flags().StringSliceVar(&opt.EBPFTrace.TraceProtoBlacklist, "trace-protos-blacklist", []string{}, "deny tracking specified protocols")
+	fs.StringSliceVar(&opt.EBPFTrace.TraceProtoList, "trace-protos", []string{}, "trace specified protocols")
+	fs.StringSliceVar(&opt.EBPFTrace.TraceProtoBlacklist, "trace-protos-blacklist", []string{}, "deny tracking specified protocols")
 
-	cmd.Flags().StringSliceVar(&opt.EBPFTrace.TraceEnvList, "trace-env-list", []string{},
+	fs.StringSliceVar(&opt.EBPFTrace.TraceEnvList, "trace-env-list", []string{},
 		"trace all processes containing any specified environment variable")
 
-	cmd.Flags().StringSliceVar(&opt.EBPFTrace.TraceNameList, "trace-name-list", []string{},
+	fs.StringSliceVar(&opt.EBPFTrace.TraceNameList, "trace-name-list", []string{},
 		"trace all processes containing any specified process names")
 
-	cmd.Flags().StringSliceVar(&opt.EBPFTrace.TraceEnvBlacklist, "trace-env-blacklist", []string{},
+	fs.StringSliceVar(&opt.EBPFTrace.TraceEnvBlacklist, "trace-env-blacklist", []string{},
 		"deny tracking any process containing any specified environment variable")
 
-	cmd.Flags().StringSliceVar(&opt.EBPFTrace.TraceNameBlacklist, "trace-name-blacklist", []string{},
+	fs.StringSliceVar(&opt.EBPFTrace.TraceNameBlacklist, "trace-name-blacklist", []string{},
 		"deny tracking any process containing any specified process names")
 
-	cmd.Flags().BoolVar(&opt.EBPFTrace.ConvTraceToDD, "conv-to-ddtrace", false, "conv trace id to ddtrace")
-
-	cmd.Flags().Float64Var(&opt.ResourceLimit.LimitCPU, "res-cpu", 0, "set max cpu resource limit")
-	cmd.Flags().StringVar(&opt.ResourceLimit.LimitMem, "res-mem", "", "set max memory resource limit")
-	cmd.Flags().StringVar(&opt.ResourceLimit.LimitBandwidth, "res-bandwidth", "", "set max bandwidth resource limit")
-
-	cmd.Flags().StringVar(&opt.Sampling.Rate, "sampling-rate", "", "sampling rate, from 0.01 to 1.00")
-	cmd.Flags().StringVar(&opt.Sampling.RatePtsPerMinute, "sampling-rate-ptsperminute", "",
+	fs.BoolVar(&opt.EBPFTrace.ConvTraceToDD, "conv-to-ddtrace", false, "conv trace id to ddtrace")
+
+	fs.Float64Var(&opt.EBPFTrace.Payload.SamplingRate, "trace-payload-sampling-rate", 0,
+		"the fraction of the traced calls attached with the payload samples, 0 to disable")
+	fs.IntVar(&opt.EBPFTrace.Payload.MaxSize, "trace-payload-max-size", protodec.DefaultPayloadMaxSize,
+		"the max size(byte) of the request or response payload sample")
+	fs.IntVar(&opt.EBPFTrace.Payload.MaxPerMin, "trace-payload-max-per-min", protodec.DefaultPayloadMaxPerMin,
+		"the max number of the payload samples per minute")
+
+	fs.IntVar(&opt.Profiling.SampleRate, "profiling-sample-rate", 99, "set the cpu sampling frequency(Hz) of ebpf-profiling")
+	fs.BoolVar(&opt.Profiling.OffCPU, "profiling-off-cpu", false, "enable the off-cpu profiling")
+	fs.StringSliceVar(&opt.Profiling.CgroupEnabled, "profiling-cgroup-enabled", []string{},
+		"profile the cgroups matching any specified path patterns")
+	fs.StringSliceVar(&opt.Profiling.CgroupDisabled, "profiling-cgroup-disabled", []string{},
+		"deny profiling the cgroups matching any specified path patterns")
+
+	fs.StringSliceVar(&opt.Exec.Users, "exec-users", []string{},
+		"audit the commands executed by the specified users(names or uids)")
+	fs.StringSliceVar(&opt.Exec.Binaries, "exec-binaries", []string{},
+		"audit the executions of the binaries matching any specified path or name patterns")
+	fs.BoolVar(&opt.Exec.BashDisabled, "exec-bash-disabled", false,
+		"disable auditing the command lines read by bash")
+
+	fs.Float64Var(&opt.ResourceLimit.LimitCPU, "res-cpu", 0, "set max cpu resource limit")
+	fs.StringVar(&opt.ResourceLimit.LimitMem, "res-mem", "", "set max memory resource limit")
+	fs.StringVar(&opt.ResourceLimit.LimitBandwidth, "res-bandwidth", "", "set max bandwidth resource limit")
+
+	fs.StringVar(&opt.Sampling.Rate, "sampling-rate", "", "sampling rate, from 0.01 to 1.00")
+	fs.StringVar(&opt.Sampling.RatePtsPerMinute, "sampling-rate-ptsperminute", "",
 		"samping rate(pts/min), recommended value is 1500")
-	_ = cmd.MarkFlagRequired("enabled")
-
-	return cmd
 }
 
 func mergeOption(cfgFilePath *string, opt *Flag) (*Flag, error) {
@@ -267,6 +316,7 @@ func mergeOption(cfgFilePath *string, opt *Flag) (*Flag, error) {
 //nolint:funlen
 func runCmd(cfgFile *string, fl *Flag) error {
 	_ = cfgFile
+	started := *fl
 	fl, gTags, err := parseFlags(fl)
 	if err != nil {
 		return err
@@ -306,6 +356,12 @@ func runCmd(cfgFile *string, fl *Flag) error {
 
 	initResLitmiter(fl, signaIterrrupt)
 
+	// the plugins not supported by the kernel are disabled instead of failing
+	// silently, the report is served by the debug server
+	features := kfeature.Probe()
+	http.Handle("/debug/ebpf/features", kfeature.Handler(features))
+	kernelDisabled := applyKernelFeatures(features, fl)
+
 	interval := time.Minute
 	if v, err := time.ParseDuration(fl.Interval); err == nil {
 		if v > interval {
@@ -332,6 +388,7 @@ func runCmd(cfgFile *string, fl *Flag) error {
 		l4log.SetK8sNetInfo(k8sinfo)
 	}
 
+	var apiTracer *l7flow.APIFlowTracer
 	if enableEbpfNet {
 		_ = fl.EBPFTrace.TraceAllProc
 
@@ -369,19 +426,10 @@ func runCmd(cfgFile *string, fl *Flag) error {
 			}
 		}
 
-		enableProtos := map[protodec.L7Protocol]struct{}{
-			protodec.ProtoHTTP: {},
-		}
 		if enableTrace && fl.EBPFTrace.TraceServer != "" {
-			protoLi := netproto(fl.EBPFTrace.TraceProtoList, fl.EBPFTrace.TraceProtoBlacklist)
-			var protoStr []string
-			for _, p := range protoLi {
-				enableProtos[p] = struct{}{}
-				protoStr = append(protoStr, p.StringLower())
-			}
 			log.Info("trace feature enabled")
-			log.Info("trace protocols: ", strings.Join(protoStr, ","))
 		}
+		enableProtos := l7Protos(fl)
 
 		log.Infof("service env: %v, env w: %v, b: %v, proc w: %v, b: %v",
 			envAssignAllowed, envWhitelist, envBlacklist, nameWhitelist, nameBlacklist)
@@ -415,6 +463,16 @@ func runCmd(cfgFile *string, fl *Flag) error {
 		netflow.SetEphemeralPortMin(fl.EBPFNet.EphemeralPort)
 		log.Infof("ephemeral port start from: %d",
 			fl.EBPFNet.EphemeralPort)
+
+		netflow.SetEnableUDP(!fl.EBPFNet.UDPDisabled)
+		if fl.EBPFNet.FlowIdleTimeout != "" {
+			if v, err := time.ParseDuration(fl.EBPFNet.FlowIdleTimeout); err == nil {
+				netflow.SetFlowIdleTimeout(v)
+			} else {
+				log.Warnf("parse netflow idle timeout failed: %s", err.Error())
+			}
+		}
+		netflow.SetEnableSvcHistogram(fl.EBPFNet.SvcHistogram)
 		offset, err := dkoffset.LoadOffset(InstallDir)
 		if err != nil {
 			offset = nil
@@ -527,6 +585,7 @@ func runCmd(cfgFile *string, fl *Flag) error {
 				l7flow.WithProtos(enableProtos),
 				l7flow.WithK8sNetInfo(k8sinfo),
 			)
+			apiTracer = tracer
 
 			if err := tracer.Run(ctx, constEditor, bmaps, enableHTTPFlowTLS, interval); err != nil {
 				log.Error(err)
@@ -569,7 +628,39 @@ func runCmd(cfgFile *string, fl *Flag) error {
 		)
 	}
 
-	if enableEbpfBash || enableEbpfNet || enableBpfNetlog {
+	if enableProfiling {
+		log.Info(" >>> datakit ebpf-profiling(ebpf) starting ...")
+		profiler := profiling.NewProfiler(
+			profiling.WithSelfPid(os.Getpid()),
+			profiling.WithTags(gTags),
+			profiling.WithSampleRate(fl.Profiling.SampleRate),
+			profiling.WithOffCPU(fl.Profiling.OffCPU),
+			profiling.WithCgroupFilter(fl.Profiling.CgroupEnabled, fl.Profiling.CgroupDisabled),
+		)
+		if err := profiler.Run(ctx, interval); err != nil {
+			return fmt.Errorf("run profiling: %w", err)
+		}
+	}
+
+	if enableExec {
+		log.Info(" >>> datakit ebpf-exec(ebpf) starting ...")
+		snoop := execsnoop.NewExecSnoop(
+			execsnoop.WithTags(gTags),
+			execsnoop.WithBash(!fl.Exec.BashDisabled),
+			execsnoop.WithFilter(fl.Exec.Users, fl.Exec.Binaries),
+		)
+		if err := snoop.Run(ctx, interval); err != nil {
+			return fmt.Errorf("run execsnoop: %w", err)
+		}
+	}
+
+	// DataKit sends the reloaded config by stdin, the filter rules are applied
+	// without reloading the BPF programs.
+	go watchControl(os.Stdin, &reloader{cur: started, apiTracer: apiTracer})
+
+	// keep running if the plugins disabled by the kernel features, avoid being
+	// restarted by DataKit again and again
+	if enableEbpfBash || enableEbpfNet || enableBpfNetlog || enableProfiling || enableExec || kernelDisabled {
 		<-signaIterrrupt
 	}
 
@@ -579,6 +670,33 @@ func runCmd(cfgFile *string, fl *Flag) error {
 	return nil
 }
 
+func setPayloadSampling(p *FlagTracePayload) {
+	protodec.SetPayloadSampling(p.SamplingRate, p.MaxSize, p.MaxPerMin)
+	if p.SamplingRate > 0 {
+		log.Infof("trace payload sampling enabled, rate %.2f, max size %d, max %d per minute",
+			p.SamplingRate, p.MaxSize, p.MaxPerMin)
+	}
+}
+
+// l7Protos returns the protocols decoded by the l7flow tracer, the http and
+// http2 are always decoded for the httpflow.
+func l7Protos(fl *Flag) map[protodec.L7Protocol]struct{} {
+	enableProtos := map[protodec.L7Protocol]struct{}{
+		protodec.ProtoHTTP:  {},
+		protodec.ProtoHTTP2: {},
+	}
+	if enableTrace && fl.EBPFTrace.TraceServer != "" {
+		protoLi := netproto(fl.EBPFTrace.TraceProtoList, fl.EBPFTrace.TraceProtoBlacklist)
+		var protoStr []string
+		for _, p := range protoLi {
+			enableProtos[p] = struct{}{}
+			protoStr = append(protoStr, p.StringLower())
+		}
+		log.Info("trace protocols: ", strings.Join(protoStr, ","))
+	}
+	return enableProtos
+}
+
 func netproto(protos []string, blacklist []string) []protodec.L7Protocol {
 	bk := map[protodec.L7Protocol]struct{}{}
 	for _, p := range blacklist {
@@ -607,6 +725,7 @@ func netproto(protos []string, blacklist []string) []protodec.L7Protocol {
 
 func openPprof(host, port string) {
 	if port != "" {
+		http.Handle("/metrics", promhttp.HandlerFor(stats.GetRegistry(), promhttp.HandlerOpts{}))
 		go func() {
 			var addr string
 			if host != "" {
@@ -647,6 +766,9 @@ func initLogger(log **logger.Logger, name, path, level string) error {
 	l7flow.Init(l)
 
 	bashhistory.SetLogger(l)
+	profiling.SetLogger(l)
+	execsnoop.SetLogger(l)
+	kfeature.SetLogger(l)
 
 	return nil
 }
@@ -0,0 +1,338 @@
+//go:build linux
+// +build linux
+
+package dnsflow
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/google/gopacket/layers"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/internal/k8sinfo"
+	dknetflow "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/internal/netflow"
+)
+
+const (
+	srcNameClient   = "dnsflow_client"
+	srcNameResolver = "dnsflow_resolver"
+	srcNameQuery    = "dnsflow_query"
+
+	// the resolving slower than it is counted as slow.
+	slowThreshold = 100 * time.Millisecond
+
+	// the top queries per client in a collection cycle.
+	topQueries = 10
+
+	// limit the memory used by the busy clients and resolvers.
+	maxQueriesPerClient = 1024
+	maxLatencySamples   = 4096
+)
+
+var latencyQuantiles = []struct {
+	field string
+	q     float64
+}{
+	{"latency_p50", 0.5},
+	{"latency_p90", 0.9},
+	{"latency_p99", 0.99},
+}
+
+type rcodeCount struct {
+	count    int
+	nxdomain int
+	servfail int
+	timeout  int
+}
+
+func (c *rcodeCount) add(stats *DNSStats) {
+	c.count++
+	switch {
+	case stats.Timeout:
+		c.timeout++
+	case stats.RCODE == int(layers.DNSResponseCodeNXDomain):
+		c.nxdomain++
+	case stats.RCODE == int(layers.DNSResponseCodeServFail):
+		c.servfail++
+	}
+}
+
+func (c *rcodeCount) errors() int {
+	return c.nxdomain + c.servfail + c.timeout
+}
+
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) * 100 / float64(total)
+}
+
+type anomalyIPKey struct {
+	ip     string
+	family string
+}
+
+type queryStats struct {
+	rcodeCount
+	latency int64
+}
+
+type clientStats struct {
+	rcodeCount
+	queries map[string]*queryStats
+}
+
+type resolverStats struct {
+	rcodeCount
+
+	slow       int
+	latencyMax int64
+
+	// reservoir sampling if the number of the responses exceeds maxLatencySamples
+	latency   []int64
+	responses int
+}
+
+func (r *resolverStats) observe(latency int64) {
+	r.responses++
+	if latency > r.latencyMax {
+		r.latencyMax = latency
+	}
+	if latency > int64(slowThreshold) {
+		r.slow++
+	}
+
+	if len(r.latency) < maxLatencySamples {
+		r.latency = append(r.latency, latency)
+	} else if i := rand.Intn(r.responses); i < maxLatencySamples { //nolint:gosec
+		r.latency[i] = latency
+	}
+}
+
+// quantile returns the latency at q by the nearest-rank method, the latency
+// must be sorted.
+func quantile(latency []int64, q float64) int64 {
+	if len(latency) == 0 {
+		return 0
+	}
+	i := int(math.Ceil(q*float64(len(latency)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return latency[i]
+}
+
+// anomalyAgg aggregates the NXDOMAIN/SERVFAIL/timeout rates per client, the
+// latency quantiles per resolver and the top queries per client, the metrics
+// are used for alerting rather than the raw dnsflow.
+type anomalyAgg struct {
+	clients   map[anomalyIPKey]*clientStats
+	resolvers map[anomalyIPKey]*resolverStats
+}
+
+func newAnomalyAgg() *anomalyAgg {
+	return &anomalyAgg{
+		clients:   map[anomalyIPKey]*clientStats{},
+		resolvers: map[anomalyIPKey]*resolverStats{},
+	}
+}
+
+func (agg *anomalyAgg) Append(dnsKey DNSQAKey, stats DNSStats) {
+	// the query not answered and not timed out
+	if !stats.Responded {
+		return
+	}
+
+	family := "IPv4"
+	if !dnsKey.IsV4 {
+		family = "IPv6"
+	}
+	if family == "IPv4" {
+		if dknetflow.ConnIPv4Type(dnsKey.ClientIP[3]) == "loopback" ||
+			dknetflow.ConnIPv4Type(dnsKey.ServerIP[3]) == "loopback" {
+			return
+		}
+	} else if dknetflow.ConnIPv6Type(dnsKey.ClientIP) == "loopback" ||
+		dknetflow.ConnIPv6Type(dnsKey.ServerIP) == "loopback" {
+		return
+	}
+	cliKey := anomalyIPKey{
+		ip:     dknetflow.U32BEToIP(dnsKey.ClientIP, !dnsKey.IsV4).String(),
+		family: family,
+	}
+	srvKey := anomalyIPKey{
+		ip:     dknetflow.U32BEToIP(dnsKey.ServerIP, !dnsKey.IsV4).String(),
+		family: family,
+	}
+
+	cli, ok := agg.clients[cliKey]
+	if !ok {
+		cli = &clientStats{queries: map[string]*queryStats{}}
+		agg.clients[cliKey] = cli
+	}
+	cli.add(&stats)
+
+	latency := stats.RespTime.Nanoseconds()
+	if stats.QName != "" {
+		q, ok := cli.queries[stats.QName]
+		if !ok && len(cli.queries) < maxQueriesPerClient {
+			q = &queryStats{}
+			cli.queries[stats.QName] = q
+		}
+		if q != nil {
+			q.add(&stats)
+			if !stats.Timeout {
+				q.latency += latency
+			}
+		}
+	}
+
+	srv, ok := agg.resolvers[srvKey]
+	if !ok {
+		srv = &resolverStats{}
+		agg.resolvers[srvKey] = srv
+	}
+	srv.add(&stats)
+	if !stats.Timeout {
+		srv.observe(latency)
+	}
+}
+
+func (agg *anomalyAgg) ToPoint(addTags map[string]string, k8sNetInfo *k8sinfo.K8sNetInfo) []*point.Point {
+	var result []*point.Point
+
+	pTime := time.Now()
+	opts := append(point.CommonLoggingOptions(), point.WithTime(pTime))
+
+	newPoint := func(name string, tags map[string]string, fields map[string]any) *point.Point {
+		for k, v := range addTags {
+			if _, ok := tags[k]; !ok {
+				tags[k] = v
+			}
+		}
+		kvs := point.NewTags(tags)
+		kvs = append(kvs, point.NewKVs(fields)...)
+		return point.NewPointV2(name, kvs, opts...)
+	}
+
+	for k, v := range agg.clients {
+		tags := addK8sPodTags(k8sNetInfo, "src_", k.ip, map[string]string{
+			"family": k.family,
+			"src_ip": k.ip,
+		})
+
+		result = append(result, newPoint(srcNameClient, tags, map[string]any{
+			"count":          v.count,
+			"nxdomain_count": v.nxdomain,
+			"servfail_count": v.servfail,
+			"timeout_count":  v.timeout,
+			"nxdomain_rate":  percent(v.nxdomain, v.count),
+			"servfail_rate":  percent(v.servfail, v.count),
+			"error_rate":     percent(v.errors(), v.count),
+		}))
+
+		for _, name := range topQueryNames(v.queries, topQueries) {
+			q := v.queries[name]
+			qTags := map[string]string{"qname": name}
+			for tk, tv := range tags {
+				qTags[tk] = tv
+			}
+
+			var latency int64
+			if n := q.count - q.timeout; n > 0 {
+				latency = q.latency / int64(n)
+			}
+			result = append(result, newPoint(srcNameQuery, qTags, map[string]any{
+				"count":          q.count,
+				"nxdomain_count": q.nxdomain,
+				"servfail_count": q.servfail,
+				"timeout_count":  q.timeout,
+				"latency":        latency,
+			}))
+		}
+	}
+
+	for k, v := range agg.resolvers {
+		tags := addK8sPodTags(k8sNetInfo, "dst_", k.ip, map[string]string{
+			"family": k.family,
+			"dst_ip": k.ip,
+		})
+
+		sort.Slice(v.latency, func(i, j int) bool {
+			return v.latency[i] < v.latency[j]
+		})
+		fields := map[string]any{
+			"count":          v.count,
+			"nxdomain_count": v.nxdomain,
+			"servfail_count": v.servfail,
+			"timeout_count":  v.timeout,
+			"slow_count":     v.slow,
+			"error_rate":     percent(v.errors(), v.count),
+			"timeout_rate":   percent(v.timeout, v.count),
+			"latency_max":    v.latencyMax,
+		}
+		for _, q := range latencyQuantiles {
+			fields[q.field] = quantile(v.latency, q.q)
+		}
+
+		result = append(result, newPoint(srcNameResolver, tags, fields))
+	}
+
+	return result
+}
+
+func (agg *anomalyAgg) Clean() {
+	agg.clients = map[anomalyIPKey]*clientStats{}
+	agg.resolvers = map[anomalyIPKey]*resolverStats{}
+}
+
+// topQueryNames returns at most n names ordered by the count, the name with
+// more errors comes first if the counts are equal.
+func topQueryNames(queries map[string]*queryStats, n int) []string {
+	names := make([]string, 0, len(queries))
+	for k := range queries {
+		names = append(names, k)
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		a, b := queries[names[i]], queries[names[j]]
+		if a.count != b.count {
+			return a.count > b.count
+		}
+		if a.errors() != b.errors() {
+			return a.errors() > b.errors()
+		}
+		return names[i] < names[j]
+	})
+
+	if len(names) > n {
+		names = names[:n]
+	}
+	return names
+}
+
+// addK8sPodTags adds the pod info of the ip with the prefix src_ or dst_, the
+// port is unknown after the aggregation, so the pods with the host network
+// are not matched.
+func addK8sPodTags(k8sNetInfo *k8sinfo.K8sNetInfo, prefix, ip string,
+	tags map[string]string,
+) map[string]string {
+	if k8sNetInfo == nil {
+		return tags
+	}
+
+	poName, svcName, ns, deployment, err := k8sNetInfo.QueryPodInfo(ip, math.MaxUint32, "udp")
+	if err != nil {
+		return tags
+	}
+
+	tags["sub_source"] = "K8s"
+	tags[prefix+"k8s_namespace"] = ns
+	tags[prefix+"k8s_pod_name"] = poName
+	tags[prefix+"k8s_service_name"] = svcName
+	tags[prefix+"k8s_deployment_name"] = deployment
+	return tags
+}
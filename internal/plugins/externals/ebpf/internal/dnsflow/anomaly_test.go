@@ -0,0 +1,93 @@
+//go:build linux
+// +build linux
+
+package dnsflow
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuantile(t *testing.T) {
+	assert.Equal(t, int64(0), quantile(nil, 0.5))
+
+	latency := []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	assert.Equal(t, int64(5), quantile(latency, 0.5))
+	assert.Equal(t, int64(9), quantile(latency, 0.9))
+	assert.Equal(t, int64(10), quantile(latency, 0.99))
+	assert.Equal(t, int64(1), quantile(latency, 0))
+}
+
+func TestAnomalyAgg(t *testing.T) {
+	// 10.0.0.2 -> 10.0.0.53
+	key := DNSQAKey{
+		IsUDP:    true,
+		IsV4:     true,
+		ClientIP: [4]uint32{0, 0, 0, 0x0200000a},
+		ServerIP: [4]uint32{0, 0, 0, 0x3500000a},
+	}
+
+	agg := newAnomalyAgg()
+	for i := 0; i < 6; i++ {
+		agg.Append(key, DNSStats{
+			Responded: true, QName: "a.example.com",
+			RespTime: time.Duration(i+1) * time.Millisecond,
+		})
+	}
+	for i := 0; i < 3; i++ {
+		agg.Append(key, DNSStats{
+			Responded: true, QName: "nx.example.com", RCODE: 3,
+			RespTime: 200 * time.Millisecond,
+		})
+	}
+	agg.Append(key, DNSStats{Responded: true, Timeout: true, RCODE: -1, QName: "b.example.com"})
+	// not answered yet
+	agg.Append(key, DNSStats{QName: "c.example.com"})
+
+	// loopback
+	agg.Append(DNSQAKey{
+		IsV4:     true,
+		ClientIP: [4]uint32{0, 0, 0, 0x0100007f},
+		ServerIP: [4]uint32{0, 0, 0, 0x3500007f},
+	}, DNSStats{Responded: true, QName: "a.example.com"})
+
+	pts := agg.ToPoint(map[string]string{"host": "h1"}, nil)
+	byName := map[string]int{}
+	for _, pt := range pts {
+		byName[pt.Name()]++
+		assert.Equal(t, "h1", pt.Get("host"))
+
+		switch pt.Name() {
+		case srcNameClient:
+			assert.Equal(t, "10.0.0.2", pt.Get("src_ip"))
+			assert.Equal(t, int64(10), pt.Get("count"))
+			assert.Equal(t, int64(3), pt.Get("nxdomain_count"))
+			assert.Equal(t, int64(1), pt.Get("timeout_count"))
+			assert.Equal(t, 30.0, pt.Get("nxdomain_rate"))
+			assert.Equal(t, 40.0, pt.Get("error_rate"))
+		case srcNameResolver:
+			assert.Equal(t, "10.0.0.53", pt.Get("dst_ip"))
+			assert.Equal(t, int64(3), pt.Get("slow_count"))
+			assert.Equal(t, 10.0, pt.Get("timeout_rate"))
+			assert.Equal(t, int64(5*time.Millisecond), pt.Get("latency_p50"))
+			assert.Equal(t, int64(200*time.Millisecond), pt.Get("latency_p99"))
+		}
+	}
+	assert.Equal(t, map[string]int{srcNameClient: 1, srcNameResolver: 1, srcNameQuery: 3}, byName)
+
+	agg.Clean()
+	assert.Empty(t, agg.ToPoint(nil, nil))
+}
+
+func TestTopQueryNames(t *testing.T) {
+	queries := map[string]*queryStats{
+		"a": {rcodeCount: rcodeCount{count: 1}},
+		"b": {rcodeCount: rcodeCount{count: 3}},
+		"c": {rcodeCount: rcodeCount{count: 1, nxdomain: 1}},
+		"d": {rcodeCount: rcodeCount{count: 2}},
+	}
+	assert.Equal(t, []string{"b", "d", "c"}, topQueryNames(queries, 3))
+	assert.Equal(t, []string{"b", "d", "c", "a"}, topQueryNames(queries, 10))
+}
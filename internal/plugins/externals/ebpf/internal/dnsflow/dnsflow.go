@@ -55,6 +55,7 @@ func (tracer *DNSFlowTracer) updateDNSStats(packetInfo *DNSPacketInfo, dnsRecord
 				Timeout:   false,
 				Responded: false,
 				RCODE:     -1,
+				QName:     packetInfo.QName,
 			}
 			return &stats
 		}
@@ -63,6 +64,9 @@ func (tracer *DNSFlowTracer) updateDNSStats(packetInfo *DNSPacketInfo, dnsRecord
 			stats.RespTime = packetInfo.TS.Sub(stats.TS)
 			stats.RCODE = int(packetInfo.RCODE)
 			stats.Timeout = false
+			if stats.QName == "" {
+				stats.QName = packetInfo.QName
+			}
 			delete(tracer.statsMap, packetInfo.Key)
 			if dnsRecord != nil && !stats.Responded {
 				stats.Responded = true
@@ -122,6 +126,7 @@ func (tracer *DNSFlowTracer) Run(ctx context.Context, tp *afpacket.TPacket,
 ) {
 	mCh := make(chan []*point.Point, 256)
 	agg := FlowAgg{}
+	anomaly := newAnomalyAgg()
 	go tracer.readPacket(ctx, tp)
 	go func() {
 		t := time.NewTicker(time.Second * 30)
@@ -134,10 +139,13 @@ func (tracer *DNSFlowTracer) Run(ctx context.Context, tp *afpacket.TPacket,
 					if err != nil {
 						l.Debug(err)
 					}
+					anomaly.Append(k, v)
 				}
 
 				pts := agg.ToPoint(gTag, k8sNetInfo)
+				pts = append(pts, anomaly.ToPoint(gTag, k8sNetInfo)...)
 				agg.Clean()
+				anomaly.Clean()
 				select {
 				case mCh <- pts:
 				default:
@@ -149,6 +157,7 @@ func (tracer *DNSFlowTracer) Run(ctx context.Context, tp *afpacket.TPacket,
 					if err != nil {
 						l.Debug(err)
 					}
+					anomaly.Append(pinfo.Key, *stats)
 				}
 			case <-ctx.Done():
 				return
@@ -93,6 +93,7 @@ type DNSStats struct {
 	RespTime  time.Duration
 	Timeout   bool
 	Responded bool
+	QName     string
 }
 
 type DNSQAKey struct {
@@ -110,6 +111,7 @@ type DNSPacketInfo struct {
 	QR      bool // query(false) response(true)
 	RCODE   uint8
 	TS      time.Time
+	QName   string
 	Answers []layers.DNSResourceRecord
 }
 
@@ -145,6 +147,9 @@ func ReadPacketInfoFromDNSParser(ts time.Time, dnsParser *DNSParser) (*DNSPacket
 			pinfo.QR = dnsParser.dns.QR
 			pinfo.RCODE = uint8(dnsParser.dns.ResponseCode)
 			pinfo.Answers = dnsParser.dns.Answers
+			if len(dnsParser.dns.Questions) > 0 {
+				pinfo.QName = string(dnsParser.dns.Questions[0].Name)
+			}
 			haveDNSLayer = true
 		case gopacket.LayerTypeDecodeFailure, gopacket.LayerTypeFragment,
 			gopacket.LayerTypePayload, gopacket.LayerTypeZero:
@@ -0,0 +1,412 @@
+//go:build linux
+// +build linux
+
+// Package execsnoop audits the command executions by eBPF, the execve
+// syscalls and the command lines read by bash are reported as security
+// events.
+package execsnoop
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"time"
+	"unsafe"
+
+	manager "github.com/DataDog/ebpf-manager"
+	"github.com/GuanceCloud/cliutils/logger"
+	"github.com/GuanceCloud/cliutils/point"
+	dkebpf "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/internal/c"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/internal/exporter"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/internal/sysmonitor"
+	"golang.org/x/sys/unix"
+)
+
+// #include "../c/execsnoop/execsnoop.h"
+import "C"
+
+type (
+	ExecEventC     C.struct_exec_event
+	BashLineEventC C.struct_bash_line_event
+)
+
+const (
+	srcNameM  = "exec_audit"
+	inputName = "ebpf-exec"
+
+	bpfMapExecEvent = "bpfmap_exec_event"
+
+	// same as enum 'exec_event_type' in
+	// internal/plugins/externals/ebpf/internal/c/execsnoop/execsnoop.h.
+	eventExecve = 1
+	eventBash   = 2
+
+	ruleExec = "exec"
+	ruleBash = "bash"
+
+	auditCategory = "system"
+	levelInfo     = "info"
+	levelWarn     = "warn"
+)
+
+var l = logger.DefaultSLogger("ebpf")
+
+func SetLogger(nl *logger.Logger) {
+	l = nl
+}
+
+type execEvent struct {
+	typ      uint32
+	pid      int
+	uid      uint32
+	retval   int32
+	comm     string
+	filename string
+	argv     []string
+	line     string
+}
+
+type ExecSnoop struct {
+	tags   map[string]string
+	bash   bool
+	filter *filter
+
+	// uid -> user name, the events are handled by one goroutine
+	users map[uint32]string
+
+	ch     chan *point.Point
+	stopCh chan struct{}
+}
+
+type Option func(s *ExecSnoop)
+
+func WithTags(tags map[string]string) Option {
+	return func(s *ExecSnoop) {
+		s.tags = tags
+	}
+}
+
+// WithBash enables auditing the command lines read by bash.
+func WithBash(on bool) Option {
+	return func(s *ExecSnoop) {
+		s.bash = on
+	}
+}
+
+// WithFilter sets the users (names or uids) and the binaries (glob patterns
+// of the path or the base name) audited, the binaries are not checked for
+// the bash command lines.
+func WithFilter(users, binaries []string) Option {
+	return func(s *ExecSnoop) {
+		s.filter = newFilter(users, binaries)
+	}
+}
+
+func NewExecSnoop(opts ...Option) *ExecSnoop {
+	s := &ExecSnoop{
+		tags:   map[string]string{},
+		users:  map[uint32]string{},
+		ch:     make(chan *point.Point, 64),
+		stopCh: make(chan struct{}),
+	}
+	for _, fn := range opts {
+		if fn != nil {
+			fn(s)
+		}
+	}
+	if s.filter == nil {
+		s.filter = newFilter(nil, nil)
+	}
+	return s
+}
+
+func NewExecSnoopManger(bash bool, handler func(cpu int, data []byte,
+	perfmap *manager.PerfMap, manager *manager.Manager),
+) (*manager.Manager, error) {
+	m := &manager.Manager{
+		Probes: []*manager.Probe{
+			{
+				ProbeIdentificationPair: manager.ProbeIdentificationPair{
+					EBPFFuncName: "tracepoint__sys_enter_execve",
+				},
+			},
+			{
+				ProbeIdentificationPair: manager.ProbeIdentificationPair{
+					EBPFFuncName: "tracepoint__sys_exit_execve",
+				},
+			},
+		},
+		PerfMaps: []*manager.PerfMap{
+			{
+				Map: manager.Map{
+					Name: bpfMapExecEvent,
+				},
+				PerfMapOptions: manager.PerfMapOptions{
+					PerfRingBufferSize: 32 * os.Getpagesize(),
+					DataHandler:        handler,
+				},
+			},
+		},
+	}
+	mOpts := manager.Options{
+		RLimit: &unix.Rlimit{
+			Cur: math.MaxUint64,
+			Max: math.MaxUint64,
+		},
+	}
+
+	var selectors []manager.ProbesSelector
+	for _, p := range m.Probes {
+		selectors = append(selectors, &manager.ProbeSelector{
+			ProbeIdentificationPair: p.ProbeIdentificationPair,
+		})
+	}
+
+	if bash {
+		readline := &manager.Probe{
+			ProbeIdentificationPair: manager.ProbeIdentificationPair{
+				EBPFFuncName: "uretprobe__audit_readline",
+			},
+			KProbeMaxActive: 128,
+			BinaryPath:      sysmonitor.HostRoot("bin/bash"),
+		}
+		m.Probes = append(m.Probes, readline)
+		// the execve is still audited if bash not installed
+		selectors = append(selectors, &manager.BestEffort{
+			Selectors: []manager.ProbesSelector{
+				&manager.ProbeSelector{ProbeIdentificationPair: readline.ProbeIdentificationPair},
+			},
+		})
+	} else {
+		mOpts.ExcludedFunctions = []string{"uretprobe__audit_readline"}
+	}
+	mOpts.ActivatedProbes = selectors
+
+	buf, err := dkebpf.ExecSnoopBin()
+	if err != nil {
+		return nil, fmt.Errorf("execsnoop.o: %w", err)
+	}
+	if err := m.InitWithOptions((bytes.NewReader(buf)), mOpts); err != nil {
+		return nil, fmt.Errorf("init execsnoop: %w", err)
+	}
+
+	return m, nil
+}
+
+func decodeEvent(data []byte) (*execEvent, bool) {
+	if len(data) < int(unsafe.Sizeof(BashLineEventC{})) {
+		return nil, false
+	}
+
+	// the events have the same header
+	head := (*BashLineEventC)(unsafe.Pointer(&data[0])) //nolint:gosec
+	ev := &execEvent{
+		typ: uint32(head._type),
+		pid: int(uint64(head.pid_tgid) >> 32),
+		uid: uint32(uint64(head.uid_gid)),
+	}
+
+	switch ev.typ {
+	case eventExecve:
+		if len(data) < int(unsafe.Sizeof(ExecEventC{})) {
+			return nil, false
+		}
+		eventC := (*ExecEventC)(unsafe.Pointer(&data[0])) //nolint:gosec
+
+		ev.retval = int32(eventC.retval)
+		ev.comm = unix.ByteSliceToString((*(*[C.KERNEL_TASK_COMM_LEN]byte)(unsafe.Pointer(&eventC.comm)))[:])      //nolint:gosec
+		ev.filename = unix.ByteSliceToString((*(*[C.EXEC_FILENAME_LEN]byte)(unsafe.Pointer(&eventC.filename)))[:]) //nolint:gosec
+
+		argv := (*[C.EXEC_ARGV_NUM][C.EXEC_ARG_LEN]byte)(unsafe.Pointer(&eventC.argv)) //nolint:gosec
+		for i := 0; i < int(eventC.argc) && i < len(argv); i++ {
+			ev.argv = append(ev.argv, unix.ByteSliceToString(argv[i][:]))
+		}
+	case eventBash:
+		ev.comm = unix.ByteSliceToString((*(*[C.KERNEL_TASK_COMM_LEN]byte)(unsafe.Pointer(&head.comm)))[:]) //nolint:gosec
+		ev.line = unix.ByteSliceToString((*(*[C.BASH_LINE_LEN]byte)(unsafe.Pointer(&head.line)))[:])        //nolint:gosec
+	default:
+		return nil, false
+	}
+
+	return ev, true
+}
+
+func (s *ExecSnoop) userName(uid uint32) string {
+	if v, ok := s.users[uid]; ok {
+		return v
+	}
+
+	var name string
+	if u, err := user.LookupId(strconv.FormatUint(uint64(uid), 10)); err == nil {
+		name = u.Username
+	} else {
+		l.Debug(err)
+	}
+	s.users[uid] = name
+	return name
+}
+
+// procPPid returns the parent pid of the process, or 0 if the process exited.
+func procPPid(pid int) int {
+	buf, err := os.ReadFile(sysmonitor.HostProc(strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return 0
+	}
+
+	// pid (comm) state ppid ...
+	i := bytes.LastIndexByte(buf, ')')
+	if i < 0 {
+		return 0
+	}
+	fields := strings.Fields(string(buf[i+1:]))
+	if len(fields) < 2 {
+		return 0
+	}
+	v, _ := strconv.Atoi(fields[1])
+	return v
+}
+
+// eventPoint converts the event to the security point, it returns nil if the
+// event is filtered.
+func (s *ExecSnoop) eventPoint(ev *execEvent, userName string, ppid int,
+	containerID string, pTime time.Time,
+) *point.Point {
+	if !s.filter.matchUser(ev.uid, userName) {
+		return nil
+	}
+	if ev.typ == eventExecve && !s.filter.matchBinary(ev.filename) {
+		return nil
+	}
+
+	u := userName
+	if u == "" {
+		u = strconv.FormatUint(uint64(ev.uid), 10)
+	}
+
+	var kvs point.KVs
+	kvs = kvs.AddTag("category", auditCategory).
+		AddTag("uid", strconv.FormatUint(uint64(ev.uid), 10)).
+		AddTag("user", u).
+		AddTag("process_name", ev.comm)
+	if containerID != "" {
+		kvs = kvs.AddTag("container_id", containerID)
+	}
+
+	var title, message string
+	switch ev.typ {
+	case eventExecve:
+		level := levelInfo
+		if ev.retval < 0 {
+			level = levelWarn
+		}
+		cmd := strings.Join(ev.argv, " ")
+		title = fmt.Sprintf("%s executed %s", u, ev.filename)
+		message = fmt.Sprintf("user `%s` executed `%s` in pid %d, retval %d", u, cmd, ev.pid, ev.retval)
+
+		kvs = kvs.AddTag("rule", ruleExec).
+			AddTag("level", level).
+			AddTag("binary", ev.filename).
+			Add("argv", cmd, false, false).
+			Add("argc", int64(len(ev.argv)), false, false).
+			Add("retval", int64(ev.retval), false, false)
+	case eventBash:
+		title = fmt.Sprintf("%s ran bash command", u)
+		message = fmt.Sprintf("user `%s` ran `%s` in bash pid %d", u, ev.line, ev.pid)
+
+		kvs = kvs.AddTag("rule", ruleBash).
+			AddTag("level", levelInfo).
+			Add("cmd", ev.line, false, false)
+	}
+
+	kvs = kvs.AddTag("title", title).
+		Add("message", message, false, false).
+		Add("pid", int64(ev.pid), false, false)
+	if ppid > 0 {
+		kvs = kvs.Add("ppid", int64(ppid), false, false)
+	}
+
+	// the existing keys are not overridden
+	for k, v := range s.tags {
+		kvs = kvs.AddTag(k, v)
+	}
+
+	return point.NewPointV2(srcNameM, kvs, append(
+		point.DefaultLoggingOptions(), point.WithTime(pTime))...)
+}
+
+func (s *ExecSnoop) eventCallBack(cpu int, data []byte,
+	perfmap *manager.PerfMap, manager *manager.Manager,
+) {
+	ev, ok := decodeEvent(data)
+	if !ok {
+		return
+	}
+	// the empty lines
+	if ev.typ == eventBash && strings.TrimSpace(ev.line) == "" {
+		return
+	}
+
+	pt := s.eventPoint(ev, s.userName(ev.uid), procPPid(ev.pid),
+		sysmonitor.ProcContainerID(ev.pid), time.Now())
+	if pt == nil {
+		return
+	}
+
+	select {
+	case <-s.stopCh:
+	case s.ch <- pt:
+	}
+}
+
+func (s *ExecSnoop) feedHandler(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	cache := []*point.Point{}
+	for {
+		select {
+		case <-ticker.C:
+			if len(cache) > 0 {
+				if err := exporter.FeedPoint(inputName, point.Security, cache); err != nil {
+					l.Error(err)
+				}
+				cache = make([]*point.Point, 0)
+			}
+		case pt := <-s.ch:
+			cache = append(cache, pt)
+			if len(cache) > 128 {
+				if err := exporter.FeedPoint(inputName, point.Security, cache); err != nil {
+					l.Error(err)
+				}
+				cache = make([]*point.Point, 0)
+			}
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+func (s *ExecSnoop) Run(ctx context.Context, interval time.Duration) error {
+	m, err := NewExecSnoopManger(s.bash, s.eventCallBack)
+	if err != nil {
+		return err
+	}
+	if err := m.Start(); err != nil {
+		return fmt.Errorf("start execsnoop: %w", err)
+	}
+
+	go s.feedHandler(interval)
+
+	go func() {
+		<-ctx.Done()
+		close(s.stopCh)
+		_ = m.Stop(manager.CleanAll)
+	}()
+
+	return nil
+}
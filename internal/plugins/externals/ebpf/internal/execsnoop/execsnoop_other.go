@@ -0,0 +1,5 @@
+//go:build !linux
+// +build !linux
+
+// Package execsnoop audits the command executions by eBPF
+package execsnoop
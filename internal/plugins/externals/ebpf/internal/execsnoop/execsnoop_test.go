@@ -0,0 +1,80 @@
+//go:build linux
+// +build linux
+
+package execsnoop
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilter(t *testing.T) {
+	f := newFilter(nil, nil)
+	assert.True(t, f.matchUser(1000, "alice"))
+	assert.True(t, f.matchBinary("/usr/bin/curl"))
+
+	f = newFilter([]string{"root", "1001"}, []string{"curl", "/usr/sbin/*"})
+	assert.True(t, f.matchUser(0, "root"))
+	assert.True(t, f.matchUser(1001, ""))
+	assert.False(t, f.matchUser(1000, "alice"))
+
+	assert.True(t, f.matchBinary("/usr/bin/curl"))
+	assert.True(t, f.matchBinary("/usr/sbin/sshd"))
+	assert.False(t, f.matchBinary("/usr/bin/wget"))
+}
+
+func TestProcPPid(t *testing.T) {
+	assert.Equal(t, os.Getppid(), procPPid(os.Getpid()))
+	assert.Equal(t, 0, procPPid(-1))
+}
+
+func TestEventPoint(t *testing.T) {
+	s := NewExecSnoop(
+		WithTags(map[string]string{"host": "h1", "rule": "x"}),
+		WithFilter([]string{"root"}, []string{"curl"}),
+	)
+
+	ev := &execEvent{
+		typ:      eventExecve,
+		pid:      100,
+		uid:      0,
+		retval:   -2,
+		comm:     "bash",
+		filename: "/usr/bin/curl",
+		argv:     []string{"curl", "-s", "http://a"},
+	}
+
+	pt := s.eventPoint(ev, "root", 1, "c1", time.Now())
+	if assert.NotNil(t, pt) {
+		assert.Equal(t, "exec_audit", pt.Name())
+		assert.Equal(t, "exec", pt.Get("rule"))
+		assert.Equal(t, "warn", pt.Get("level"))
+		assert.Equal(t, "root", pt.Get("user"))
+		assert.Equal(t, "c1", pt.Get("container_id"))
+		assert.Equal(t, "h1", pt.Get("host"))
+		assert.Equal(t, "curl -s http://a", pt.Get("argv"))
+		assert.Equal(t, int64(3), pt.Get("argc"))
+		assert.Equal(t, int64(1), pt.Get("ppid"))
+	}
+
+	// filtered by the binary
+	ev.filename = "/usr/bin/wget"
+	assert.Nil(t, s.eventPoint(ev, "root", 1, "", time.Now()))
+
+	// the binaries are not checked for bash
+	bash := &execEvent{typ: eventBash, pid: 101, comm: "bash", line: "ls -l"}
+	pt = s.eventPoint(bash, "root", 0, "", time.Now())
+	if assert.NotNil(t, pt) {
+		assert.Equal(t, "bash", pt.Get("rule"))
+		assert.Equal(t, "ls -l", pt.Get("cmd"))
+		assert.Nil(t, pt.Get("ppid"))
+		assert.Nil(t, pt.Get("container_id"))
+	}
+
+	// filtered by the user
+	bash.uid = 1000
+	assert.Nil(t, s.eventPoint(bash, "alice", 0, "", time.Now()))
+}
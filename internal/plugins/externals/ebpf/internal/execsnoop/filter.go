@@ -0,0 +1,67 @@
+//go:build linux
+// +build linux
+
+package execsnoop
+
+import (
+	"path"
+	"strconv"
+)
+
+// filter selects the events reported by the users and the binaries, all
+// events are reported if the lists are empty.
+type filter struct {
+	// user names or uids
+	users map[string]struct{}
+
+	// glob patterns matching the path or the base name of the binary
+	binaries []string
+}
+
+func newFilter(users, binaries []string) *filter {
+	f := &filter{}
+	for _, u := range users {
+		if u == "" {
+			continue
+		}
+		if f.users == nil {
+			f.users = map[string]struct{}{}
+		}
+		f.users[u] = struct{}{}
+	}
+	for _, b := range binaries {
+		if b != "" {
+			f.binaries = append(f.binaries, b)
+		}
+	}
+	return f
+}
+
+func (f *filter) matchUser(uid uint32, name string) bool {
+	if len(f.users) == 0 {
+		return true
+	}
+	if _, ok := f.users[strconv.FormatUint(uint64(uid), 10)]; ok {
+		return true
+	}
+	if _, ok := f.users[name]; ok && name != "" {
+		return true
+	}
+	return false
+}
+
+func (f *filter) matchBinary(filename string) bool {
+	if len(f.binaries) == 0 {
+		return true
+	}
+	base := path.Base(filename)
+	for _, pattern := range f.binaries {
+		if ok, _ := path.Match(pattern, filename); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, base); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -2,6 +2,9 @@ package exporter
 
 import (
 	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/GuanceCloud/cliutils/point"
@@ -25,6 +28,33 @@ func TestSampling(t *testing.T) {
 	assert.Equal(t, 20, len(newpts))
 }
 
+func TestSamplingReset(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s := newSampling(ctx, &cfg{})
+
+	var pts []*point.Point
+	for i := 1; i <= 100; i++ {
+		pt, _ := point.NewPoint("a", nil, map[string]any{"id": i})
+		pts = append(pts, pt)
+	}
+	assert.Equal(t, 100, len(s.sampling(point.Network.String(), pts)))
+
+	s.reset(&cfg{samplingRate: "0.5"})
+	assert.Equal(t, 50, len(s.sampling(point.Network.String(), pts)))
+	assert.False(t, s.autoStarted)
+
+	s.reset(&cfg{samplingRatePtsPerMin: "1500"})
+	assert.Equal(t, 100, len(s.sampling(point.Network.String(), pts)))
+	assert.Equal(t, 1500.0, s.ptsPerMinute[point.Network.String()])
+	assert.True(t, s.autoStarted)
+
+	s.reset(&cfg{})
+	assert.Empty(t, s.ptsPerMinute)
+	assert.Equal(t, 100, len(s.sampling(point.Network.String(), pts)))
+}
+
 func TestSamplingPtsLess1(t *testing.T) {
 	Init(context.Background())
 
@@ -51,3 +81,34 @@ func TestSamplingPtsLess1(t *testing.T) {
 	newpts := s.sampling(point.Network.String(), pts)
 	assert.Equal(t, 1, len(newpts))
 }
+
+func TestFeedProfile(t *testing.T) {
+	var event, prof []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, dkProfilingInput, r.URL.Path)
+		if !assert.NoError(t, r.ParseMultipartForm(1<<20)) {
+			return
+		}
+		read := func(name string) []byte {
+			f, _, err := r.FormFile(name)
+			if !assert.NoError(t, err) {
+				return nil
+			}
+			defer f.Close() //nolint:errcheck
+			b, _ := io.ReadAll(f)
+			return b
+		}
+		event = read("event")
+		prof = read("cpu.pprof")
+	}))
+	defer srv.Close()
+
+	old := defaultAPIServer
+	defaultAPIServer = srv.URL
+	defer func() { defaultAPIServer = old }()
+
+	assert.NoError(t, FeedProfile([]byte(`{"format":"pprof"}`),
+		map[string][]byte{"cpu.pprof": []byte("pprof")}))
+	assert.Equal(t, `{"format":"pprof"}`, string(event))
+	assert.Equal(t, "pprof", string(prof))
+}
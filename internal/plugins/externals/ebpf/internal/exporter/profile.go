@@ -0,0 +1,77 @@
+package exporter
+
+import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"sort"
+)
+
+const (
+	dkProfilingInput = "/profiling/v1/input"
+
+	profileEventFile = "event"
+	profileEventName = "event.json"
+)
+
+// FeedProfile posts the profile to DataKit in the multipart format, the event
+// is the metadata in JSON, and the attachments are keyed by the file names.
+func FeedProfile(event []byte, attachments map[string][]byte) error {
+	u, err := url.JoinPath(defaultAPIServer, dkProfilingInput)
+	if err != nil {
+		return fmt.Errorf("build url: %w", err)
+	}
+
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+
+	part, err := w.CreateFormFile(profileEventFile, profileEventName)
+	if err != nil {
+		return err
+	}
+	if _, err := part.Write(event); err != nil {
+		return err
+	}
+
+	files := make([]string, 0, len(attachments))
+	for f := range attachments {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+	for _, f := range files {
+		part, err := w.CreateFormFile(f, f)
+		if err != nil {
+			return err
+		}
+		if _, err := part.Write(attachments[f]); err != nil {
+			return err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	cli := http.DefaultClient
+	if globalSender != nil {
+		cli = globalSender.httpCli
+	}
+
+	req, err := http.NewRequest("POST", u, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+
+	resp, err := cli.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("url %s, http status code: %d", u, resp.StatusCode)
+	}
+	return nil
+}
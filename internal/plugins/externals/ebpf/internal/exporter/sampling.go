@@ -14,35 +14,59 @@ type sampling struct {
 	rate         map[string]float64
 	ptsPerMinute map[string]float64
 
+	ctx         context.Context
+	autoStarted bool
+
 	sync.RWMutex
 }
 
 func newSampling(ctx context.Context, c *cfg) *sampling {
+	s := &sampling{ctx: ctx}
+	s.reset(c)
+	return s
+}
+
+// SetSampling replaces the sampling rate of the senders, so that the reloaded
+// config is applied without restarting.
+func SetSampling(rate, ratePtsPerMin string) {
+	if globalSender == nil || globalSender.sampling == nil {
+		return
+	}
+
+	globalSender.sampling.reset(&cfg{
+		samplingRate:          rate,
+		samplingRatePtsPerMin: ratePtsPerMin,
+	})
+}
+
+func (s *sampling) reset(c *cfg) {
+	rate := map[string]float64{}
+	ptsPerMinute := map[string]float64{}
+
 	switch {
 	case c.samplingRate != "":
 		r, rmp := parseRate(
 			c.samplingRate, 0.3)
 		log.Infof("set samping rate %.2f", r)
-		return &sampling{
-			rate:         rmp,
-			ptsPerMinute: map[string]float64{},
-		}
+		rate = rmp
 	case c.samplingRatePtsPerMin != "":
 		r, rmp := parseRate(
 			c.samplingRatePtsPerMin, 1500)
 		log.Infof("set samping rate %.2f pts/min", r)
+		ptsPerMinute = rmp
+	}
 
-		s := &sampling{
-			rate:         map[string]float64{},
-			ptsPerMinute: rmp,
-		}
-		s.autoRate(ctx)
-		return s
-	default:
-		return &sampling{
-			rate:         map[string]float64{},
-			ptsPerMinute: map[string]float64{},
-		}
+	s.Lock()
+	s.rate = rate
+	s.ptsPerMinute = ptsPerMinute
+	startAuto := len(ptsPerMinute) > 0 && !s.autoStarted
+	if startAuto {
+		s.autoStarted = true
+	}
+	s.Unlock()
+
+	if startAuto {
+		s.autoRate(s.ctx)
 	}
 }
 
@@ -98,6 +122,10 @@ func (s *sampling) changeRate(lastStats map[string]float64) map[string]float64 {
 	defer s.Unlock()
 
 	curStats, _ := getTotalPtsMetric()
+	// the fixed rate is set by reloading
+	if len(s.ptsPerMinute) == 0 {
+		return curStats
+	}
 	rate := calRate(lastStats, curStats, s.ptsPerMinute)
 	lastStats = curStats
 	for cat, val := range rate {
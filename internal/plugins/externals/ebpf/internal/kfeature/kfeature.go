@@ -0,0 +1,354 @@
+//go:build linux
+// +build linux
+
+// Package kfeature probes the kernel features used by the eBPF plugins on
+// started, and decides the collection mode of each plugin by the fallback
+// matrix, so that the plugins are degraded or disabled on older kernels
+// instead of failing silently.
+package kfeature
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/logger"
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/asm"
+	"github.com/cilium/ebpf/btf"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/shirou/gopsutil/host"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/pkg/stats"
+	"golang.org/x/sys/unix"
+)
+
+var log = logger.DefaultSLogger("ebpf")
+
+func SetLogger(nl *logger.Logger) {
+	log = nl
+}
+
+const (
+	FeatureKprobe         = "kprobe"
+	FeatureTracepoint     = "tracepoint"
+	FeaturePerfEvent      = "perf_event"
+	FeaturePerfEventArray = "perf_event_array"
+	FeatureStackTrace     = "stack_trace"
+	FeatureBTF            = "btf"
+	FeatureRingBuf        = "ringbuf"
+	FeatureFEntry         = "fentry"
+)
+
+const (
+	ModeFull     = "full"
+	ModeDegraded = "degraded"
+	ModeDisabled = "disabled"
+)
+
+type Feature struct {
+	Name      string `json:"name"`
+	Supported bool   `json:"supported"`
+	Error     string `json:"error,omitempty"`
+}
+
+type PluginMode struct {
+	Plugin  string   `json:"plugin"`
+	Mode    string   `json:"mode"`
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// Report is the result of the self-test, it's served by the debug server.
+type Report struct {
+	KernelVersion string       `json:"kernel_version"`
+	ProbeTime     time.Time    `json:"probe_time"`
+	Features      []Feature    `json:"features"`
+	Plugins       []PluginMode `json:"plugins"`
+}
+
+// Supported returns true if the feature is supported by the kernel.
+func (r *Report) Supported(name string) bool {
+	for _, f := range r.Features {
+		if f.Name == name {
+			return f.Supported
+		}
+	}
+	return false
+}
+
+// Mode returns the collection mode of the plugin, the plugins not in the
+// matrix run in full mode.
+func (r *Report) Mode(plugin string) PluginMode {
+	for _, m := range r.Plugins {
+		if m.Plugin == plugin {
+			return m
+		}
+	}
+	return PluginMode{Plugin: plugin, Mode: ModeFull}
+}
+
+type fallback struct {
+	feature string
+	desc    string
+}
+
+// matrix lists the kernel features used by the plugins. The plugin is disabled
+// if any of the required features is not supported, and degraded if any of the
+// optional features is not supported.
+//
+// The ringbuf and fentry are not used by the plugins yet, the perf event array
+// and kprobe are used instead, they are probed for troubleshooting only.
+var matrix = []struct {
+	plugin   string
+	required []string
+	optional []fallback
+}{
+	{
+		plugin:   "ebpf-net",
+		required: []string{FeatureKprobe, FeaturePerfEventArray},
+		optional: []fallback{
+			{FeatureBTF, "the offsets of kernel structs are guessed at runtime"},
+		},
+	},
+	{
+		plugin:   "ebpf-trace",
+		required: []string{FeatureKprobe, FeaturePerfEventArray},
+	},
+	{
+		plugin:   "ebpf-conntrack",
+		required: []string{FeatureKprobe},
+	},
+	{
+		plugin:   "ebpf-bash",
+		required: []string{FeatureKprobe, FeaturePerfEventArray},
+	},
+	{
+		plugin:   "ebpf-exec",
+		required: []string{FeatureTracepoint, FeaturePerfEventArray},
+		optional: []fallback{
+			{FeatureKprobe, "the bash command lines are not audited"},
+		},
+	},
+	{
+		plugin:   "ebpf-profiling",
+		required: []string{FeaturePerfEvent, FeatureStackTrace},
+		optional: []fallback{
+			{FeatureTracepoint, "the off-cpu profiling is disabled"},
+		},
+	},
+}
+
+var (
+	// vmlinuxBTF is the raw BTF of the running kernel.
+	vmlinuxBTF = "/sys/kernel/btf/vmlinux"
+
+	// fentryTargets are attached by fentry programs in the self-test, the
+	// bpf_fentry_test* functions are for testing the BPF trampoline.
+	fentryTargets = []string{"bpf_fentry_test1", "tcp_sendmsg"}
+)
+
+var probers = []struct {
+	name string
+	fn   func() error
+}{
+	{FeatureKprobe, probeProg(ebpf.Kprobe)},
+	{FeatureTracepoint, probeProg(ebpf.TracePoint)},
+	{FeaturePerfEvent, probeProg(ebpf.PerfEvent)},
+	{FeaturePerfEventArray, probeMap(&ebpf.MapSpec{
+		Type: ebpf.PerfEventArray, KeySize: 4, ValueSize: 4, MaxEntries: 1,
+	})},
+	{FeatureStackTrace, probeMap(&ebpf.MapSpec{
+		Type: ebpf.StackTrace, KeySize: 4, ValueSize: 8, MaxEntries: 1,
+	})},
+	{FeatureRingBuf, probeMap(&ebpf.MapSpec{
+		Type: ebpf.RingBuf, MaxEntries: uint32(os.Getpagesize()),
+	})},
+	{FeatureBTF, probeBTF},
+	{FeatureFEntry, probeFEntry},
+}
+
+func minimalInsns() asm.Instructions {
+	return asm.Instructions{
+		asm.Mov.Imm(asm.R0, 0),
+		asm.Return(),
+	}
+}
+
+func probeProg(typ ebpf.ProgramType) func() error {
+	return func() error {
+		prog, err := ebpf.NewProgram(&ebpf.ProgramSpec{
+			Type:         typ,
+			Instructions: minimalInsns(),
+			License:      "GPL",
+		})
+		if err != nil {
+			return err
+		}
+		return prog.Close()
+	}
+}
+
+func probeMap(spec *ebpf.MapSpec) func() error {
+	return func() error {
+		m, err := ebpf.NewMap(spec)
+		if err != nil {
+			return err
+		}
+		return m.Close()
+	}
+}
+
+func probeBTF() error {
+	_, err := os.Stat(vmlinuxBTF)
+	return err
+}
+
+func probeFEntry() error {
+	if err := probeBTF(); err != nil {
+		return fmt.Errorf("btf not supported: %w", err)
+	}
+	// the kernel spec is loaded for finding the target, it's large and not
+	// used by the plugins
+	defer btf.FlushKernelSpec()
+
+	var errs []string
+	for _, fn := range fentryTargets {
+		prog, err := ebpf.NewProgram(&ebpf.ProgramSpec{
+			Type:         ebpf.Tracing,
+			AttachType:   ebpf.AttachTraceFEntry,
+			AttachTo:     fn,
+			Instructions: minimalInsns(),
+			License:      "GPL",
+		})
+		if err == nil {
+			return prog.Close()
+		}
+		errs = append(errs, fmt.Sprintf("%s: %s", fn, err.Error()))
+	}
+	return errors.New(strings.Join(errs, "; "))
+}
+
+// decide returns the collection mode of the plugins in the matrix by the
+// supported features.
+func decide(supported map[string]bool) []PluginMode {
+	var result []PluginMode
+	for _, v := range matrix {
+		m := PluginMode{Plugin: v.plugin, Mode: ModeFull}
+		for _, f := range v.required {
+			if !supported[f] {
+				m.Mode = ModeDisabled
+				m.Reasons = append(m.Reasons, fmt.Sprintf("%s not supported", f))
+			}
+		}
+		if m.Mode != ModeDisabled {
+			for _, f := range v.optional {
+				if !supported[f.feature] {
+					m.Mode = ModeDegraded
+					m.Reasons = append(m.Reasons, fmt.Sprintf("%s not supported, %s", f.feature, f.desc))
+				}
+			}
+		}
+		result = append(result, m)
+	}
+	return result
+}
+
+// Probe runs the self-test, the result is also exported by metrics.
+func Probe() *Report {
+	// the same as the options of the managers
+	if err := unix.Setrlimit(unix.RLIMIT_MEMLOCK, &unix.Rlimit{
+		Cur: math.MaxUint64,
+		Max: math.MaxUint64,
+	}); err != nil {
+		log.Debugf("set rlimit memlock: %s", err.Error())
+	}
+
+	r := &Report{ProbeTime: time.Now()}
+	if v, err := host.KernelVersion(); err == nil {
+		r.KernelVersion = v
+	} else {
+		log.Warnf("get kernel version: %s", err.Error())
+	}
+
+	supported := map[string]bool{}
+	for _, p := range probers {
+		f := Feature{Name: p.name, Supported: true}
+		if err := p.fn(); err != nil {
+			f.Supported = false
+			f.Error = err.Error()
+		}
+		supported[p.name] = f.Supported
+		r.Features = append(r.Features, f)
+	}
+	sort.Slice(r.Features, func(i, j int) bool {
+		return r.Features[i].Name < r.Features[j].Name
+	})
+
+	r.Plugins = decide(supported)
+
+	exportMetrics(r)
+	return r
+}
+
+var (
+	featureVec = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "dkebpf",
+			Subsystem: "kernel",
+			Name:      "feature_supported",
+			Help:      "Whether the kernel feature is supported, probed on started",
+		},
+		[]string{"feature"},
+	)
+
+	pluginModeVec = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "dkebpf",
+			Subsystem: "kernel",
+			Name:      "plugin_mode",
+			Help:      "The collection mode of the plugin decided by the kernel features, mode is full, degraded or disabled",
+		},
+		[]string{"plugin", "mode"},
+	)
+
+	metricsOnce sync.Once
+)
+
+func exportMetrics(r *Report) {
+	metricsOnce.Do(func() {
+		stats.MustRegister(featureVec)
+		stats.MustRegister(pluginModeVec)
+	})
+
+	featureVec.Reset()
+	for _, f := range r.Features {
+		v := 0.0
+		if f.Supported {
+			v = 1
+		}
+		featureVec.WithLabelValues(f.Name).Set(v)
+	}
+
+	pluginModeVec.Reset()
+	for _, m := range r.Plugins {
+		pluginModeVec.WithLabelValues(m.Plugin, m.Mode).Set(1)
+	}
+}
+
+// Handler serves the report in JSON.
+func Handler(r *Report) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(r); err != nil {
+			log.Warnf("write kernel features: %s", err.Error())
+		}
+	})
+}
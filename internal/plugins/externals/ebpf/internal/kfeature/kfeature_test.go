@@ -0,0 +1,88 @@
+//go:build linux
+// +build linux
+
+package kfeature
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecide(t *testing.T) {
+	all := map[string]bool{}
+	for _, p := range probers {
+		all[p.name] = true
+	}
+	for _, m := range decide(all) {
+		assert.Equal(t, ModeFull, m.Mode, m.Plugin)
+		assert.Empty(t, m.Reasons, m.Plugin)
+	}
+
+	// the kernel without BTF and tracepoint
+	supported := map[string]bool{
+		FeatureKprobe:         true,
+		FeaturePerfEventArray: true,
+		FeaturePerfEvent:      true,
+		FeatureStackTrace:     true,
+	}
+	r := &Report{Plugins: decide(supported)}
+	assert.Equal(t, ModeDegraded, r.Mode("ebpf-net").Mode)
+	assert.Equal(t, ModeFull, r.Mode("ebpf-trace").Mode)
+	assert.Equal(t, ModeDegraded, r.Mode("ebpf-profiling").Mode)
+	assert.Equal(t, []string{"tracepoint not supported, the off-cpu profiling is disabled"},
+		r.Mode("ebpf-profiling").Reasons)
+	assert.Equal(t, ModeFull, r.Mode("bpf-netlog").Mode)
+
+	// the required features take precedence
+	r = &Report{Plugins: decide(map[string]bool{FeaturePerfEvent: true})}
+	assert.Equal(t, ModeDisabled, r.Mode("ebpf-net").Mode)
+	assert.Equal(t, []string{"kprobe not supported", "perf_event_array not supported"},
+		r.Mode("ebpf-net").Reasons)
+	assert.Equal(t, ModeDisabled, r.Mode("ebpf-profiling").Mode)
+	assert.Equal(t, []string{"stack_trace not supported"}, r.Mode("ebpf-profiling").Reasons)
+}
+
+func TestProbe(t *testing.T) {
+	defer func(v []struct {
+		name string
+		fn   func() error
+	}) {
+		probers = v
+	}(probers)
+
+	unsupported := map[string]bool{FeatureBTF: true, FeatureFEntry: true, FeatureRingBuf: true}
+	for i, p := range probers {
+		if unsupported[p.name] {
+			probers[i].fn = func() error { return errors.New("not supported") }
+		} else {
+			probers[i].fn = func() error { return nil }
+		}
+	}
+
+	r := Probe()
+	assert.Len(t, r.Features, len(probers))
+	assert.True(t, r.Supported(FeatureKprobe))
+	assert.False(t, r.Supported(FeatureBTF))
+	assert.False(t, r.Supported("unknown"))
+	assert.Equal(t, ModeDegraded, r.Mode("ebpf-net").Mode)
+	assert.Equal(t, ModeFull, r.Mode("ebpf-profiling").Mode)
+
+	rec := httptest.NewRecorder()
+	Handler(r).ServeHTTP(rec, httptest.NewRequest("GET", "/debug/ebpf/features", nil))
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var got Report
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	assert.Equal(t, r.Features, got.Features)
+	assert.Equal(t, r.Plugins, got.Plugins)
+	for _, f := range got.Features {
+		if !f.Supported {
+			assert.Equal(t, "not supported", f.Error)
+		}
+	}
+}
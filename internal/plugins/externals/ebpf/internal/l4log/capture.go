@@ -12,7 +12,6 @@ import (
 	"time"
 
 	"github.com/GuanceCloud/cliutils/point"
-	"github.com/GuanceCloud/platypus/pkg/ast"
 	"github.com/google/gopacket"
 	"github.com/google/gopacket/afpacket"
 	"github.com/google/gopacket/layers"
@@ -118,14 +117,14 @@ type TCPConns struct {
 	stop chan struct{}
 
 	runtime   *filterRuntime
-	blacklist ast.Stmts
+	blacklist *netFilter
 	// upload event
 	// ch        chan []*point.Point
 	// cleanUpCh chan map[PktMeta]*PktValue
 }
 
 func NewTCPConns(gtags map[string]string, ctrID, nsUID string,
-	nameAddr [2]string, pr *portListen, bl ast.Stmts, runtime *filterRuntime,
+	nameAddr [2]string, pr *portListen, bl *netFilter, runtime *filterRuntime,
 ) *TCPConns {
 	tags := map[string]string{}
 
@@ -249,7 +248,7 @@ func (conns *TCPConns) update(txRx int8, k *PMeta, ln *PktTCPHdr, pktLen,
 		smac = ln.SrcMAC
 	}
 
-	if conns.runtime != nil && len(conns.blacklist) > 0 {
+	if blacklist := conns.blacklist.get(); conns.runtime != nil && len(blacklist) > 0 {
 		var sPod, dPod string
 		if k8sNetInfo != nil {
 			sPod = k8sNetInfo.QueryPodName(k.SrcIP, uint32(k.SrcPort), "tcp")
@@ -272,7 +271,7 @@ func (conns *TCPConns) update(txRx int8, k *PMeta, ln *PktTCPHdr, pktLen,
 			elem.ipDAddr = k.DstIP
 		}
 
-		if conns.runtime.runNetFilterDrop(conns.blacklist, elem) {
+		if conns.runtime.runNetFilterDrop(blacklist, elem) {
 			return
 		}
 	}
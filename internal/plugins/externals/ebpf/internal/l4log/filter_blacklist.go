@@ -156,6 +156,50 @@ type filterRuntime struct {
 	fnG *fnGroup
 }
 
+// netFilter is the blacklist shared by the connections of all the network
+// namespaces, it's replaced at runtime by SetBlacklist.
+type netFilter struct {
+	stmts ast.Stmts
+	sync.RWMutex
+}
+
+// _netBlacklist is used by the netlog monitor.
+var _netBlacklist = &netFilter{}
+
+// SetBlacklist replaces the blacklist of the running netlog monitor without
+// recapturing the packets, the previous blacklist is kept if failed.
+func SetBlacklist(blacklist string) error {
+	return _netBlacklist.set(&filterRuntime{fnG: _fnList}, blacklist)
+}
+
+func (f *netFilter) get() ast.Stmts {
+	if f == nil {
+		return nil
+	}
+
+	f.RLock()
+	defer f.RUnlock()
+	return f.stmts
+}
+
+func (f *netFilter) set(g *filterRuntime, blacklist string) error {
+	var stmts ast.Stmts
+	if blacklist != "" {
+		var err error
+		if stmts, err = parseFilter(blacklist); err != nil {
+			return fmt.Errorf("parse filter: %w", err)
+		}
+		if err := g.checkStmts(stmts, &netParams{}); err != nil {
+			return fmt.Errorf("check filter: %w", err)
+		}
+	}
+
+	f.Lock()
+	defer f.Unlock()
+	f.stmts = stmts
+	return nil
+}
+
 func (g *filterRuntime) checkStmts(stmts ast.Stmts, elem netElem) error {
 	for _, s := range stmts {
 		typ, err := g._checkStmt(s, elem)
@@ -204,3 +204,26 @@ func TestBlacklist(t *testing.T) {
 		assert.Equal(t, c.result, v)
 	}
 }
+
+func TestNetFilterSet(t *testing.T) {
+	runt := &filterRuntime{fnG: _fnList}
+	f := &netFilter{}
+	elem := &netParams{tcp: true, dPort: 6379}
+
+	assert.NoError(t, f.set(runt, `dst_port == 6379`))
+	assert.True(t, runt.runNetFilterDrop(f.get(), elem))
+
+	// the previous blacklist is kept if the rule is invalid
+	assert.Error(t, f.set(runt, `dst_port ==`))
+	assert.Error(t, f.set(runt, `dst_port`))
+	assert.True(t, runt.runNetFilterDrop(f.get(), elem))
+
+	assert.NoError(t, f.set(runt, `dst_port == 3306`))
+	assert.False(t, runt.runNetFilterDrop(f.get(), elem))
+
+	assert.NoError(t, f.set(runt, ""))
+	assert.Empty(t, f.get())
+
+	var nilFilter *netFilter
+	assert.Empty(t, nilFilter.get())
+}
@@ -247,6 +247,10 @@ type HTTP2Decoder struct {
 	hpackTxDec *hpack.Decoder
 	hpackRxDec *hpack.Decoder
 
+	// the HEADERS frame waiting for the CONTINUATION frames
+	hdrPending [2]*H2HeaderFrame
+	hdrFrag    [2][]byte
+
 	Reader bytes.Reader
 }
 
@@ -339,6 +343,13 @@ func HasHTTP2Magic(buf []byte) int {
 	return 0
 }
 
+func (h2dec *HTTP2Decoder) hpackDec(tx bool) *hpack.Decoder {
+	if tx {
+		return h2dec.hpackTxDec
+	}
+	return h2dec.hpackRxDec
+}
+
 func (h2dec *HTTP2Decoder) Decode(tx bool, buf []byte) (*H2Frames, error) {
 	h2dec.Reader.Reset(buf)
 
@@ -358,26 +369,58 @@ func (h2dec *HTTP2Decoder) Decode(tx bool, buf []byte) (*H2Frames, error) {
 			break
 		}
 
+		dir := 0
+		if tx {
+			dir = 1
+		}
+
 		switch fr := fr.(type) {
 		case *http2.HeadersFrame:
-			hdrFrg := fr.HeaderBlockFragment()
+			h2hdr := &H2HeaderFrame{}
+			h2hdr.FrameHeader = fr.FrameHeader
 
-			var hdec *hpack.Decoder
-			if tx {
-				hdec = h2dec.hpackTxDec
-			} else {
-				hdec = h2dec.hpackRxDec
+			if !fr.HeadersEnded() {
+				h2dec.hdrPending[dir] = h2hdr
+				h2dec.hdrFrag[dir] = append(h2dec.hdrFrag[dir][:0], fr.HeaderBlockFragment()...)
+				continue
 			}
-			headers, err := hdec.DecodeFull(hdrFrg)
+
+			headers, err := h2dec.hpackDec(tx).DecodeFull(fr.HeaderBlockFragment())
 			if err != nil {
 				return nil, err
 			}
+			h2hdr.Headers = headers
+			h2Rslt.Fr = append(h2Rslt.Fr, h2hdr)
 
-			h2hdr := &H2HeaderFrame{}
-			h2hdr.FrameHeader = fr.FrameHeader
+		case *http2.ContinuationFrame:
+			h2hdr := h2dec.hdrPending[dir]
+			if h2hdr == nil || h2hdr.StreamID != fr.StreamID {
+				continue
+			}
+			h2dec.hdrFrag[dir] = append(h2dec.hdrFrag[dir], fr.HeaderBlockFragment()...)
+			if !fr.HeadersEnded() {
+				continue
+			}
+
+			h2dec.hdrPending[dir] = nil
+			headers, err := h2dec.hpackDec(tx).DecodeFull(h2dec.hdrFrag[dir])
+			if err != nil {
+				return nil, err
+			}
 			h2hdr.Headers = headers
+			h2hdr.Length += fr.Length + 9
 			h2Rslt.Fr = append(h2Rslt.Fr, h2hdr)
 
+		case *http2.SettingsFrame:
+			// the table size is set by the decoding side, the encoder
+			// of the peer can use the dynamic table up to the size
+			if v, ok := fr.Value(http2.SettingHeaderTableSize); ok && !fr.IsAck() {
+				h2dec.hpackDec(!tx).SetAllowedMaxDynamicTableSize(v)
+			}
+			h2Rslt.Fr = append(h2Rslt.Fr, &H2OtherFrame{
+				FrameHeader: fr.Header(),
+			})
+
 		case *http2.DataFrame:
 			h2body := &H2DataFrame{
 				FrameHeader: fr.FrameHeader,
@@ -10,7 +10,6 @@ import (
 	"runtime"
 	"time"
 
-	"github.com/GuanceCloud/platypus/pkg/ast"
 	"github.com/google/gopacket/afpacket"
 	"github.com/vishvananda/netns"
 	cruntime "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/container/runtime"
@@ -91,7 +90,7 @@ type netlogMonitor struct {
 
 	gtags map[string]string
 
-	transportBlacklist ast.Stmts
+	transportBlacklist *netFilter
 	filterRuntime      *filterRuntime
 
 	portListen *portListen
@@ -104,19 +103,14 @@ func newNetlogMonitor(gtags map[string]string, blacklist string, fnG *fnGroup,
 		gtags:         gtags,
 		portListen:    &portListen{},
 		filterRuntime: &filterRuntime{fnG: fnG},
+
+		transportBlacklist: _netBlacklist,
 	}
 
 	if blacklist != "" {
-		stmts, err := parseFilter(blacklist)
-		if err != nil {
-			return nil, fmt.Errorf("parse filter: %w", err)
-		}
-		err = m.filterRuntime.checkStmts(stmts, &netParams{})
-		if err != nil {
-			return nil, fmt.Errorf("check filter: %w", err)
+		if err := m.transportBlacklist.set(m.filterRuntime, blacklist); err != nil {
+			return nil, err
 		}
-
-		m.transportBlacklist = stmts
 		log.Infof("transport blacklist: \n\n%s\n", blacklist)
 	}
 
@@ -54,6 +54,9 @@ const (
 	FieldBytesWritten = "bytes_written"
 	FieldBytesRead    = "bytes_read"
 
+	FieldReqPayload  = "req_payload"
+	FieldRespPayload = "resp_payload"
+
 	FieldHTTPRoute      = "http_route"
 	FieldHTTPMethod     = "http_method"
 	FieldHTTPStatusCode = "http_status_code"
@@ -83,6 +86,21 @@ const (
 	FieldPgsqlReqType     = "pgsql_request_type"
 	FieldPgsqlRespType    = "pgsql_response_type"
 
+	FieldMongoDB         = "mongodb_db"
+	FieldMongoCollection = "mongodb_collection"
+	FieldMongoErrCode    = "mongodb_err_code"
+	FieldMongoCodeName   = "mongodb_code_name"
+	FieldMongoErrMsg     = "mongodb_err_msg"
+
+	FieldKafkaAPIKey        = "kafka_api_key"
+	FieldKafkaAPIVersion    = "kafka_api_version"
+	FieldKafkaCorrelationID = "kafka_correlation_id"
+	FieldKafkaClientID      = "kafka_client_id"
+	FieldKafkaTopic         = "kafka_topic"
+	FieldKafkaPartition     = "kafka_partition"
+	FieldKafkaErrCode       = "kafka_err_code"
+	FieldKafkaErrMsg        = "kafka_err_msg"
+
 	FieldResourceType = "resource_type"
 	FieldStatusMsg    = "status_msg"
 	FieldErrMsg       = "err_msg"
@@ -192,6 +210,12 @@ func FnInOut(fn FnID) NICDirection {
 	}
 }
 
+// FnIsTLS reports whether the data is the plaintext captured by the uprobes
+// of the TLS library.
+func FnIsTLS(fn FnID) bool {
+	return fn == FnSSLWrite || fn == FnSSLRead
+}
+
 type NetwrkData struct {
 	Conn        ConnectionInfo `json:"conn"`
 	CaptureSize int            `json:"act_size"`
@@ -204,6 +228,9 @@ type NetwrkData struct {
 	Fn          FnID           `json:"fn_id"`
 	Payload     []byte         `json:"payload"`
 	SockPtr     uint64
+
+	// Truncated is true if the payload exceeded the hard cap of the buffer.
+	Truncated bool `json:"truncated"`
 }
 
 func (d NetwrkData) String() string {
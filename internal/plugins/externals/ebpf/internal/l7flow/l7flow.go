@@ -13,6 +13,7 @@ import (
 	"math"
 	"os"
 	"regexp"
+	"sync"
 	"time"
 	"unsafe"
 
@@ -27,6 +28,7 @@ import (
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/internal/l7flow/protodec"
 	dknetflow "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/internal/netflow"
 	sysmonitor "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/internal/sysmonitor"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/pkg/stats"
 	"golang.org/x/sys/unix"
 
 	expRand "golang.org/x/exp/rand"
@@ -64,8 +66,7 @@ const (
 
 var (
 	// libssl
-	RegexpLibSSL    = regexp.MustCompile(`libssl.so`)
-	RegexpLibCrypto = regexp.MustCompile(`libcrypto.so`)
+	RegexpLibSSL = regexp.MustCompile(`libssl.so`)
 
 	// TODO: guntls
 )
@@ -149,10 +150,17 @@ func newRandFunc() func() int64 {
 	}
 }
 
+var metricsOnce sync.Once
+
 func Init(nl *logger.Logger) {
 	log = nl
 	comm.Init(nl)
 	protodec.Init()
+
+	metricsOnce.Do(func() {
+		stats.MustRegister(payloadPoolVec)
+		stats.MustRegister(payloadTruncatedBytes)
+	})
 }
 
 var (
@@ -160,21 +168,26 @@ var (
 		"uprobe__SSL_read",
 		"uretprobe__SSL_read",
 		"uprobe__SSL_write",
+		"uretprobe__SSL_write",
+		"uprobe__SSL_read_ex",
+		"uretprobe__SSL_read_ex",
+		"uprobe__SSL_write_ex",
+		"uretprobe__SSL_write_ex",
 		"uprobe__SSL_shutdown",
 		"uprobe__SSL_set_fd",
-		"uprobe__SSL_set_bio",
-	}
-	libcryptoSection = []string{
-		"uprobe__BIO_new_socket",
-		"uretprobe__BIO_new_socket",
 	}
 )
 
+type uprobeMonitor interface {
+	ScanAndUpdate()
+	Monitor(ctx context.Context, scanInterval time.Duration)
+}
+
 type perferEventHandle func(CPU int, data []byte, perfmap *manager.PerfMap,
 	manager *manager.Manager)
 
 func NewHTTPFlowManger(constEditor []manager.ConstantEditor, bmaps map[string]*ebpf.Map,
-	bufHandler perferEventHandle, enableTLS bool) (*manager.Manager, *sysmonitor.UprobeRegister, error) {
+	bufHandler perferEventHandle, enableTLS bool, selfPid int) (*manager.Manager, []uprobeMonitor, error) {
 	randInnerID = newRandFunc()
 
 	m := &manager.Manager{
@@ -279,7 +292,7 @@ func NewHTTPFlowManger(constEditor []manager.ConstantEditor, bmaps map[string]*e
 		},
 	}
 
-	var r *sysmonitor.UprobeRegister
+	var monitors []uprobeMonitor
 	if enableTLS {
 		opensslRules := []sysmonitor.UprobeRegRule{
 			{
@@ -287,18 +300,13 @@ func NewHTTPFlowManger(constEditor []manager.ConstantEditor, bmaps map[string]*e
 				Register:   sysmonitor.NewRegisterFunc(m, libSSLSection),
 				UnRegister: sysmonitor.NewUnRegisterFunc(m, libSSLSection),
 			},
-			{
-				Re:         RegexpLibCrypto,
-				Register:   sysmonitor.NewRegisterFunc(m, libcryptoSection),
-				UnRegister: sysmonitor.NewUnRegisterFunc(m, libcryptoSection),
-			},
 		}
 
-		var err error
-		r, err = sysmonitor.NewUprobeDyncLibRegister(opensslRules)
+		r, err := sysmonitor.NewUprobeDyncLibRegister(opensslRules)
 		if err != nil {
 			return nil, nil, err
 		}
+		monitors = append(monitors, r, sysmonitor.NewGoTLSRegister(m, selfPid))
 	}
 
 	mOpts := manager.Options{
@@ -319,7 +327,7 @@ func NewHTTPFlowManger(constEditor []manager.ConstantEditor, bmaps map[string]*e
 		return nil, nil, err
 	}
 
-	return m, r, nil
+	return m, monitors, nil
 }
 
 type APIFlowTracer struct {
@@ -393,14 +401,20 @@ func NewAPIFlowTracer(ctx context.Context, opts ...APITracerOpt) *APIFlowTracer
 	}
 }
 
+// SetProtos applies the reloaded protocols without reloading the BPF programs,
+// the protocols not enabled on started are ignored and returned.
+func (tracer *APIFlowTracer) SetProtos(protos map[protodec.L7Protocol]struct{}) []protodec.L7Protocol {
+	return tracer.tracer.connWatcher.setProtos(protos)
+}
+
 const bpfMapProtocolFilter = "mp_protocol_filter"
 
 func (tracer *APIFlowTracer) Run(ctx context.Context, constEditor []manager.ConstantEditor,
 	bmaps map[string]*ebpf.Map, enableTLS bool, interval time.Duration) error {
 	go tracer.tracer.Start(ctx, interval)
 
-	bpfManger, r, err := NewHTTPFlowManger(constEditor, bmaps,
-		tracer.tracer.PerfEventHandle, enableTLS)
+	bpfManger, monitors, err := NewHTTPFlowManger(constEditor, bmaps,
+		tracer.tracer.PerfEventHandle, enableTLS, tracer.tracer.selfPid)
 	if err != nil {
 		return err
 	}
@@ -430,7 +444,7 @@ func (tracer *APIFlowTracer) Run(ctx context.Context, constEditor []manager.Cons
 
 	tracer.tracer.protocolFilter.setFn(fn)
 
-	if r != nil {
+	for _, r := range monitors {
 		r.ScanAndUpdate()
 		r.Monitor(ctx, time.Second*30)
 	}
@@ -111,6 +111,17 @@ func (netTrace *NetTrace) StreamHandle(tn int64, uniID CUniID, data *comm.Netwrk
 			continue
 		}
 
+		// the ciphertext read or written by the syscalls is dropped once
+		// the plaintext of the TLS connection captured
+		if comm.FnIsTLS(d.Fn) {
+			if !pipe.tls {
+				pipe.tls = true
+				pipe.detecTimes = 0
+			}
+		} else if pipe.tls {
+			continue
+		}
+
 		if pipe.Proto == protodec.ProtoUnknown {
 			if proto, dec, ok := netTrace.protoSet.ProtoDetector(d.Payload, d.CaptureSize); ok {
 				pipe.Proto = proto
@@ -120,10 +131,6 @@ func (netTrace *NetTrace) StreamHandle(tn int64, uniID CUniID, data *comm.Netwrk
 				} else {
 					pipe.Decoder = dec
 				}
-
-				if proto == protodec.ProtoHTTP2 {
-					continue
-				}
 			} else {
 				pipe.detecTimes++
 				continue
@@ -176,6 +183,7 @@ type FlowPipe struct {
 	Decoder    protodec.ProtoDecPipe
 	Proto      protodec.L7Protocol
 	detecTimes int
+	tls        bool
 
 	sort dataQueue
 
@@ -202,6 +210,27 @@ func (watcher *ConnWatcher) handle(tn int64, uniID CUniID, netdata *comm.NetwrkD
 	watcher.trace.StreamHandle(tn, uniID, netdata, watcher.aggPool)
 }
 
+// setProtos replaces the enabled protocols, it's applied to the connections
+// detected later. The protocols not in the protocol set are not detected,
+// they are ignored and returned.
+func (watcher *ConnWatcher) setProtos(protos map[protodec.L7Protocol]struct{}) []protodec.L7Protocol {
+	enabled := map[protodec.L7Protocol]struct{}{}
+	var ignored []protodec.L7Protocol
+	for p := range protos {
+		if watcher.trace.protoSet.Has(p) {
+			enabled[p] = struct{}{}
+		} else {
+			ignored = append(ignored, p)
+		}
+	}
+
+	watcher.Lock()
+	defer watcher.Unlock()
+	watcher.trace.enabledProto = enabled
+
+	return ignored
+}
+
 func (watcher *ConnWatcher) start(ctx context.Context) {
 	ticker := time.NewTicker(time.Second * 10)
 	tickerClean := time.NewTicker(time.Minute * 5)
@@ -384,18 +413,20 @@ func (tracer *Tracer) PerfEventHandle(cpu int, data []byte,
 		readMeta(&eventHdr, &netdata.Conn)
 		if int(eventHdr.rec.bytes) > 0 {
 			v := unsafe.Slice((*byte)(unsafe.Pointer(&data[curPayloadPos])), int(eventHdr.rec.bytes)) //nolint:gosec
-			netdata.Payload = append(netdata.Payload, v...)
+			appendPayload(netdata, v)
 		}
 
 		// pos must be calculated before the filter is run
 		pid := int(netdata.Conn.Pid)
 		if pid == tracer.selfPid {
+			putNetwrkData(netdata)
 			continue
 		}
 
 		if tracer.processFilter != nil {
 			if v, ok := tracer.processFilter.GetProcInfo(pid); ok {
 				if !v.Filtered() {
+					putNetwrkData(netdata)
 					continue
 				}
 				netdata.Conn.ProcessName = v.Name()
@@ -407,7 +438,7 @@ func (tracer *Tracer) PerfEventHandle(cpu int, data []byte,
 
 		netdata.Fn = comm.FnID(eventHdr.meta.func_id)
 		netdata.SockPtr = uint64(eventHdr.meta.sk_inf.skptr)
-		netdata.CaptureSize = int(eventHdr.rec.bytes)
+		netdata.CaptureSize = len(netdata.Payload)
 		netdata.FnCallSize = int(eventHdr.meta.original_size)
 		netdata.TCPSeq = uint32(eventHdr.meta.tcp_seq)
 		netdata.Thread = [2]int32{int32(eventHdr.meta.tid_utid >> 32), (int32(eventHdr.meta.tid_utid))}
@@ -548,6 +579,16 @@ func genPts(data []*protodec.ProtoData, conn *comm.ConnectionInfo) []*point.Poin
 			}
 		}
 
+		// the payload samples are correlated to the app trace by app_trace_id
+		if v.Meta.Payload.Sampled {
+			v.KVs = v.KVs.Add(comm.FieldReqPayload,
+				protodec.PrintablePayload(v.Meta.Payload.Req), false, true)
+			if v.Meta.Payload.Resp != nil {
+				v.KVs = v.KVs.Add(comm.FieldRespPayload,
+					protodec.PrintablePayload(v.Meta.Payload.Resp), false, true)
+			}
+		}
+
 		// service info
 		v.KVs = v.KVs.Add("source_type", "ebpf", false, true)
 		v.KVs = v.KVs.Add("process_name", conn.ProcessName, false, true)
@@ -9,6 +9,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/internal/l7flow/comm"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/internal/l7flow/protodec"
 )
 
 func TestSort(t *testing.T) {
@@ -84,3 +85,26 @@ func TestSort(t *testing.T) {
 		fn(li, rst, startPos)
 	})
 }
+
+func TestConnWatcherSetProtos(t *testing.T) {
+	protodec.Init()
+
+	watcher := &ConnWatcher{
+		trace: &NetTrace{
+			enabledProto: map[protodec.L7Protocol]struct{}{
+				protodec.ProtoHTTP:  {},
+				protodec.ProtoMySQL: {},
+			},
+			protoSet: protodec.SubProtoSet(protodec.ProtoHTTP, protodec.ProtoMySQL),
+		},
+	}
+
+	ignored := watcher.setProtos(map[protodec.L7Protocol]struct{}{
+		protodec.ProtoHTTP:  {},
+		protodec.ProtoRedis: {},
+	})
+	assert.Equal(t, []protodec.L7Protocol{protodec.ProtoRedis}, ignored)
+	assert.Equal(t, map[protodec.L7Protocol]struct{}{
+		protodec.ProtoHTTP: {},
+	}, watcher.trace.enabledProto)
+}
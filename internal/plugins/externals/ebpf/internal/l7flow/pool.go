@@ -4,8 +4,10 @@
 package l7flow
 
 import (
+	"strconv"
 	"sync"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/internal/l7flow/comm"
 )
 
@@ -17,47 +19,104 @@ const (
 	netDataSize1k  = 1024
 	netDataSize2k  = 2048
 	netDataSize4k  = 4096
+	netDataSize8k  = 8192
+	netDataSize16k = 16384
+
+	// netDataMaxSize is the hard cap of the payload, the exceeded part is
+	// dropped and the data is marked as truncated.
+	netDataMaxSize = netDataSize16k
 )
 
 var (
-	netwrksyncPool64  = newNetDataPool(netDataSize64)
-	netwrksyncPool128 = newNetDataPool(netDataSize128)
-	netwrksyncPool256 = newNetDataPool(netDataSize256)
-	netwrksyncPool512 = newNetDataPool(netDataSize512)
-	netwrksyncPool1k  = newNetDataPool(netDataSize1k)
-	netwrksyncPool2k  = newNetDataPool(netDataSize2k)
-	netwrksyncPool4k  = newNetDataPool(netDataSize4k)
-)
+	payloadPoolVec = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "dkebpf",
+			Subsystem: "l7flow",
+			Name:      "payload_pool_total",
+			Help:      "The number of payload buffers got from the pool, result is hit, miss or oversize",
+		},
+		[]string{"tier", "result"},
+	)
 
-func newNetDataPool(size int) *sync.Pool {
-	return &sync.Pool{
-		New: func() interface{} {
-			return &comm.NetwrkData{
-				Payload: make([]byte, 0, size),
-			}
+	payloadTruncatedBytes = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "dkebpf",
+			Subsystem: "l7flow",
+			Name:      "payload_truncated_bytes_total",
+			Help:      "The bytes of the payloads dropped by the hard cap",
 		},
+	)
+)
+
+type netDataPool struct {
+	size int
+	pool sync.Pool
+
+	hit, miss prometheus.Counter
+}
+
+func newNetDataPool(size int) *netDataPool {
+	tier := strconv.Itoa(size)
+	return &netDataPool{
+		size: size,
+		hit:  payloadPoolVec.WithLabelValues(tier, "hit"),
+		miss: payloadPoolVec.WithLabelValues(tier, "miss"),
+	}
+}
+
+func (p *netDataPool) get() *comm.NetwrkData {
+	if v, ok := p.pool.Get().(*comm.NetwrkData); ok {
+		p.hit.Inc()
+		return v
 	}
+
+	p.miss.Inc()
+	return &comm.NetwrkData{
+		Payload: make([]byte, 0, p.size),
+	}
+}
+
+func (p *netDataPool) put(data *comm.NetwrkData) {
+	p.pool.Put(data)
+}
+
+// netDataPools are sorted by the size.
+var netDataPools = []*netDataPool{
+	newNetDataPool(netDataSize64),
+	newNetDataPool(netDataSize128),
+	newNetDataPool(netDataSize256),
+	newNetDataPool(netDataSize512),
+	newNetDataPool(netDataSize1k),
+	newNetDataPool(netDataSize2k),
+	newNetDataPool(netDataSize4k),
+	newNetDataPool(netDataSize8k),
+	newNetDataPool(netDataSize16k),
 }
 
+var payloadOversize = payloadPoolVec.WithLabelValues(strconv.Itoa(netDataMaxSize), "oversize")
+
+// getNetwrkData returns the data whose payload capacity is not less than
+// bufLen, the buffer of netDataMaxSize is returned if bufLen exceeds it.
 func getNetwrkData(bufLen int) *comm.NetwrkData {
-	switch {
-	case bufLen <= netDataSize64:
-		return netwrksyncPool64.Get().(*comm.NetwrkData)
-	case bufLen <= netDataSize128:
-		return netwrksyncPool128.Get().(*comm.NetwrkData)
-	case bufLen <= netDataSize256:
-		return netwrksyncPool256.Get().(*comm.NetwrkData)
-	case bufLen <= netDataSize512:
-		return netwrksyncPool512.Get().(*comm.NetwrkData)
-	case bufLen <= netDataSize1k:
-		return netwrksyncPool1k.Get().(*comm.NetwrkData)
-	case bufLen <= netDataSize2k:
-		return netwrksyncPool2k.Get().(*comm.NetwrkData)
-	case bufLen <= netDataSize4k:
-		return netwrksyncPool4k.Get().(*comm.NetwrkData)
-	default:
-		return nil
+	for _, p := range netDataPools {
+		if bufLen <= p.size {
+			return p.get()
+		}
+	}
+
+	payloadOversize.Inc()
+	return netDataPools[len(netDataPools)-1].get()
+}
+
+// appendPayload copies the payload to the data, the exceeded part is
+// dropped.
+func appendPayload(data *comm.NetwrkData, payload []byte) {
+	if n := len(payload) - (netDataMaxSize - len(data.Payload)); n > 0 {
+		payloadTruncatedBytes.Add(float64(n))
+		payload = payload[:len(payload)-n]
+		data.Truncated = true
 	}
+	data.Payload = append(data.Payload, payload...)
 }
 
 func putNetwrkData(data *comm.NetwrkData) {
@@ -67,22 +126,16 @@ func putNetwrkData(data *comm.NetwrkData) {
 
 	data = resetNetwrkData(data)
 
-	switch {
-	case cap(data.Payload) <= netDataSize64:
-		netwrksyncPool64.Put(data)
-	case cap(data.Payload) <= netDataSize128:
-		netwrksyncPool128.Put(data)
-	case cap(data.Payload) <= netDataSize256:
-		netwrksyncPool256.Put(data)
-	case cap(data.Payload) <= netDataSize512:
-		netwrksyncPool512.Put(data)
-	case cap(data.Payload) <= netDataSize1k:
-		netwrksyncPool1k.Put(data)
-	case cap(data.Payload) <= netDataSize2k:
-		netwrksyncPool2k.Put(data)
-	case cap(data.Payload) <= netDataSize4k:
-		netwrksyncPool4k.Put(data)
-	default:
+	// the grown buffers are put into the pool of the largest size not
+	// greater than the capacity
+	c := cap(data.Payload)
+	for i := len(netDataPools) - 1; i >= 0; i-- {
+		if c >= netDataPools[i].size {
+			if c <= netDataMaxSize {
+				netDataPools[i].put(data)
+			}
+			return
+		}
 	}
 }
 
@@ -96,6 +149,7 @@ func resetNetwrkData(data *comm.NetwrkData) *comm.NetwrkData {
 	data.TSTail = 0
 	data.Index = 0
 	data.Fn = 0
+	data.Truncated = false
 	data.Payload = data.Payload[:0]
 
 	return data
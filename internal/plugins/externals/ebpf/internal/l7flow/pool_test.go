@@ -0,0 +1,62 @@
+//go:build linux
+// +build linux
+
+package l7flow
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+)
+
+func counterValue(c prometheus.Counter) float64 {
+	var m dto.Metric
+	if err := c.Write(&m); err != nil {
+		return 0
+	}
+	return m.GetCounter().GetValue()
+}
+
+func TestNetwrkDataPool(t *testing.T) {
+	t.Run("tier", func(t *testing.T) {
+		for _, c := range [][2]int{
+			{0, netDataSize64},
+			{100, netDataSize128},
+			{4096, netDataSize4k},
+			{4097, netDataSize8k},
+			{10000, netDataSize16k},
+			{100000, netDataSize16k},
+		} {
+			d := getNetwrkData(c[0])
+			assert.GreaterOrEqual(t, cap(d.Payload), c[1])
+			putNetwrkData(d)
+		}
+	})
+
+	t.Run("truncate", func(t *testing.T) {
+		d := getNetwrkData(netDataMaxSize + 100)
+		before := counterValue(payloadTruncatedBytes)
+
+		appendPayload(d, make([]byte, netDataMaxSize+100))
+		assert.True(t, d.Truncated)
+		assert.Len(t, d.Payload, netDataMaxSize)
+		assert.Equal(t, float64(100), counterValue(payloadTruncatedBytes)-before)
+
+		putNetwrkData(d)
+		d = getNetwrkData(netDataMaxSize)
+		assert.False(t, d.Truncated)
+		assert.Len(t, d.Payload, 0)
+	})
+
+	t.Run("miss", func(t *testing.T) {
+		miss := payloadPoolVec.WithLabelValues(strconv.Itoa(netDataSize8k), "miss")
+		before := counterValue(miss)
+		d1, d2 := getNetwrkData(netDataSize8k), getNetwrkData(netDataSize8k)
+		assert.GreaterOrEqual(t, counterValue(miss)-before, float64(1))
+		putNetwrkData(d1)
+		putNetwrkData(d2)
+	})
+}
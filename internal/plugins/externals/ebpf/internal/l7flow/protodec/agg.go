@@ -18,7 +18,8 @@ import (
 )
 
 type HTTPAggP struct {
-	data map[aggKey]*aggValue
+	proto L7Protocol
+	data  map[aggKey]*aggValue
 
 	sync.RWMutex
 }
@@ -34,6 +35,9 @@ type aggKey struct {
 	path        string
 	statusCode  int
 
+	// -1 if not gRPC
+	grpcStatus int
+
 	family    string
 	direction string
 
@@ -151,6 +155,12 @@ func (agg *HTTPAggP) Obs(conn *comm.ConnectionInfo, data *ProtoData) {
 	key.method = data.KVs.Get(comm.FieldHTTPMethod).GetS()
 	key.statusCode, _ = strconv.Atoi(data.KVs.Get(comm.FieldHTTPStatusCode).GetS())
 	key.httpVersion = data.KVs.Get(comm.FieldHTTPVersion).GetS()
+	key.grpcStatus = -1
+	if kv := data.KVs.Get(comm.FieldGRPCStatusCode); kv != nil {
+		if v, err := strconv.Atoi(kv.GetS()); err == nil {
+			key.grpcStatus = v
+		}
+	}
 	rcv := data.KVs.Get(comm.FieldBytesRead).GetI()
 	snd := data.KVs.Get(comm.FieldBytesWritten).GetI()
 
@@ -171,7 +181,7 @@ func (agg *HTTPAggP) Obs(conn *comm.ConnectionInfo, data *ProtoData) {
 }
 
 func (agg *HTTPAggP) Proto() L7Protocol {
-	return ProtoHTTP
+	return agg.proto
 }
 
 func (agg *HTTPAggP) Export(tags map[string]string, k8sInfo *k8sinfo.K8sNetInfo) []*point.Point {
@@ -199,7 +209,7 @@ func (agg *HTTPAggP) Cleanup() {
 }
 
 func newHTTPAggP(p L7Protocol) AggPool {
-	return &HTTPAggP{}
+	return &HTTPAggP{proto: p}
 }
 
 func kv2point(key *aggKey, value *aggValue, pTime time.Time,
@@ -266,6 +276,10 @@ func kv2point(key *aggKey, value *aggValue, pTime time.Time,
 		"count": value.count,
 	}
 
+	if key.grpcStatus >= 0 {
+		fields["grpc_status_code"] = key.grpcStatus
+	}
+
 	tags = netflow.AddK8sTags2Map(k8sNetInfo, &key.BaseKey, tags)
 
 	kvs := point.NewTags(tags)
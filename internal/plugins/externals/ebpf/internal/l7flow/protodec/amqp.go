@@ -201,6 +201,7 @@ func (dec *amqpDecPipe) decode(txRx comm.NICDirection, data *comm.NetwrkData,
 			}
 
 			inf.meta.ReqTCPSeq = data.TCPSeq
+			inf.meta.Payload.req(ProtoAMQP, data.Payload)
 			inf.dur[0] = data.TS
 			inf.ktime[0] = data.TSTail
 
@@ -208,6 +209,7 @@ func (dec *amqpDecPipe) decode(txRx comm.NICDirection, data *comm.NetwrkData,
 		case AMQPPacketResponse:
 			dec.inf.respMethod = name
 			dec.inf.meta.RespTCPSeq = data.TCPSeq
+			dec.inf.meta.Payload.resp(ProtoAMQP, data.Payload)
 			dec.inf.meta.Threads[1] = data.Thread
 
 			dec.inf.readyToExport = true
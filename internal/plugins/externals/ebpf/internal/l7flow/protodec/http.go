@@ -210,6 +210,7 @@ func (dec *httpDecPipe) Decode(txRx comm.NICDirection, data *comm.NetwrkData,
 		}
 
 		inf.meta.ReqTCPSeq = data.TCPSeq
+		inf.meta.Payload.req(ProtoHTTP, data.Payload)
 		inf.dur[0] = data.TS
 		inf.ktime[0] = data.TSTail
 
@@ -222,6 +223,7 @@ func (dec *httpDecPipe) Decode(txRx comm.NICDirection, data *comm.NetwrkData,
 	case resp:
 		dec.reqResp = 2
 		inf.meta.RespTCPSeq = data.TCPSeq
+		inf.meta.Payload.resp(ProtoHTTP, data.Payload)
 		inf.meta.Threads[1] = data.Thread
 
 		inf.statusCode = statusCode
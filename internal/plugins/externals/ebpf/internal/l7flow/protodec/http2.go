@@ -4,6 +4,7 @@
 package protodec
 
 import (
+	"encoding/binary"
 	"strconv"
 	"strings"
 
@@ -34,6 +35,7 @@ type h2Info struct {
 	grpcMessage    string
 	reqResp        int8
 	hFinished      bool
+	reset          bool
 	ts             int64
 }
 
@@ -44,6 +46,99 @@ type h2DecPipe struct {
 	proto      L7Protocol
 	isGRPC     bool
 	connClosed bool
+
+	// 0: ingress, 1: egress
+	stream [2]h2Stream
+}
+
+const (
+	h2FrameHeaderLen = 9
+	h2MaxPending     = 64 * 1024 // the max size of the frame buffered
+	h2Version        = "2.0"
+)
+
+// h2Stream reassembles the frames of one direction, the frames may be split
+// into multiple syscalls or a syscall may contain multiple frames.
+type h2Stream struct {
+	// the partial frame
+	buf []byte
+
+	// the remaining payload of the DATA frame, not buffered
+	skip int
+}
+
+// frames returns the frames in the payload, the DATA frames are not passed
+// to the decoder, so only the header of them is required. The lost is the
+// size of the data not captured, the stream is resynchronized at the next
+// payload if the lost data is not in the DATA frame.
+func (s *h2Stream) frames(dec *l4log.HTTP2Decoder, tx bool, payload []byte, lost int) []l4log.Frame {
+	var result []l4log.Frame
+
+	if s.skip > 0 {
+		n := s.skip
+		if n > len(payload) {
+			n = len(payload)
+		}
+		s.skip -= n
+		payload = payload[n:]
+	}
+
+	buf := payload
+	if len(s.buf) > 0 {
+		s.buf = append(s.buf, payload...)
+		buf = s.buf
+	}
+
+	for len(buf) >= h2FrameHeaderLen {
+		hdr := http2.FrameHeader{
+			Length:   uint32(buf[0])<<16 | uint32(buf[1])<<8 | uint32(buf[2]),
+			Type:     http2.FrameType(buf[3]),
+			Flags:    http2.Flags(buf[4]),
+			StreamID: binary.BigEndian.Uint32(buf[5:]) & (1<<31 - 1),
+		}
+		frLen := h2FrameHeaderLen + int(hdr.Length)
+
+		if hdr.Type == http2.FrameData {
+			result = append(result, &l4log.H2DataFrame{FrameHeader: hdr})
+			if len(buf) < frLen {
+				s.skip = frLen - len(buf)
+				buf = nil
+				break
+			}
+			buf = buf[frLen:]
+			continue
+		}
+
+		if len(buf) < frLen {
+			if frLen > h2MaxPending {
+				// not buffered, skip the remainder to keep the stream in sync
+				s.skip = frLen - len(buf)
+				buf = nil
+			}
+			break
+		}
+
+		if frs, err := dec.Decode(tx, buf[:frLen]); err != nil {
+			log.Debug(err)
+		} else {
+			result = append(result, frs.Fr...)
+		}
+		buf = buf[frLen:]
+	}
+
+	switch {
+	case lost > 0 && s.skip >= lost:
+		s.skip -= lost
+		s.buf = s.buf[:0]
+	case lost > 0:
+		s.skip = 0
+		s.buf = s.buf[:0]
+	default:
+		// buf may be a slice of s.buf
+		s.buf = append(s.buf[:0], buf...)
+	}
+
+	return result
 }
 
 func H2ProtoDetect(data []byte, actSize int) (L7Protocol, ProtoDecPipe, bool) {
@@ -74,18 +169,24 @@ func (dec *h2DecPipe) Decode(txRx comm.NICDirection, data *comm.NetwrkData,
 		return
 	}
 
-	frames, err := dec.dec.Decode(txRx == comm.NICDEgress, data.Payload)
-	if err != nil {
-		log.Debug(err)
-		return
+	payload := data.Payload
+	if n := l4log.HasHTTP2Magic(payload); n > 0 {
+		payload = payload[n:]
 	}
 
+	dir := 0
+	if txRx == comm.NICDEgress {
+		dir = 1
+	}
+	lost := data.FnCallSize - len(data.Payload)
+	frames := dec.stream[dir].frames(dec.dec, txRx == comm.NICDEgress, payload, lost)
+
 	seqOffset := data.TCPSeq
 
 	var elem *h2Info
 	var currentStreamID uint32
 	var tsSetted bool
-	for _, fr := range frames.Fr {
+	for _, fr := range frames {
 		frHdr := fr.Header()
 		frLen := frHdr.Length + 9
 		frBytesCount := 0
@@ -151,7 +252,8 @@ func (dec *h2DecPipe) Decode(txRx comm.NICDirection, data *comm.NetwrkData,
 						}
 					}
 				case "content-type":
-					if hdr.Value == "application/grpc" {
+					// application/grpc, application/grpc+proto, ...
+					if strings.HasPrefix(hdr.Value, "application/grpc") {
 						dec.isGRPC = true
 					}
 				case "grpc-status":
@@ -185,6 +287,17 @@ func (dec *h2DecPipe) Decode(txRx comm.NICDirection, data *comm.NetwrkData,
 			frBytesCount = int(frLen)
 		case *l4log.H2DataFrame:
 			frBytesCount = int(frLen)
+		case *l4log.H2RSTStreamFrame:
+			// the stream is canceled before the response ends
+			if elem.reqResp != 0 && !elem.hFinished {
+				if elem.reqResp == 1 {
+					elem.ktime[2] = data.TSTail
+					elem.dur[1] = data.TS
+					elem.reqResp = 2
+				}
+				elem.reset = true
+				elem.hFinished = true
+			}
 		}
 
 		if !tsSetted {
@@ -241,8 +354,10 @@ func (dec *h2DecPipe) Export(force bool) []*ProtoData {
 
 			kvs = kvs.Add(comm.FieldHTTPMethod, inf.method, false, true)
 			kvs = kvs.Add(comm.FieldHTTPRoute, inf.path, false, true)
+			kvs = kvs.Add(comm.FieldHTTPVersion, h2Version, false, true)
 			kvs = kvs.Add(comm.FieldHTTPStatusCode, strconv.Itoa(inf.statusCode), false, true)
-			kvs = kvs.Add(comm.FieldStatus, httpCode2Status(inf.statusCode), false, true)
+
+			status := httpCode2Status(inf.statusCode)
 			var proto L7Protocol
 			if dec.isGRPC {
 				proto = ProtoGRPC
@@ -250,9 +365,16 @@ func (dec *h2DecPipe) Export(force bool) []*ProtoData {
 					kvs = kvs.Add(comm.FieldGRPCMessage, inf.grpcMessage, false, true)
 				}
 				kvs = kvs.Add(comm.FieldGRPCStatusCode, strconv.Itoa(inf.grpcStatusCode), false, true)
+				if inf.grpcStatusCode != 0 {
+					status = "error"
+				}
 			} else {
 				proto = ProtoHTTP2
 			}
+			if inf.reset {
+				status = "error"
+			}
+			kvs = kvs.Add(comm.FieldStatus, status, false, true)
 
 			// 页面 span 上显示的是 `<opperation> <resource>`
 			kvs = kvs.Add(comm.FieldOperation, proto.String(), false, true)
@@ -0,0 +1,200 @@
+//go:build linux
+// +build linux
+
+package protodec
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/internal/l4log"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/internal/l7flow/comm"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+type h2Writer struct {
+	buf    bytes.Buffer
+	hbuf   bytes.Buffer
+	fr     *http2.Framer
+	encode *hpack.Encoder
+}
+
+func newH2Writer() *h2Writer {
+	w := &h2Writer{}
+	w.fr = http2.NewFramer(&w.buf, nil)
+	w.encode = hpack.NewEncoder(&w.hbuf)
+	return w
+}
+
+func (w *h2Writer) block(kvs ...string) []byte {
+	w.hbuf.Reset()
+	for i := 0; i+1 < len(kvs); i += 2 {
+		_ = w.encode.WriteField(hpack.HeaderField{Name: kvs[i], Value: kvs[i+1]})
+	}
+	return append([]byte{}, w.hbuf.Bytes()...)
+}
+
+// headers writes the HEADERS frame and the CONTINUATION frames if split > 0.
+func (w *h2Writer) headers(streamID uint32, endStream bool, split int, kvs ...string) {
+	blk := w.block(kvs...)
+	if split <= 0 || split >= len(blk) {
+		_ = w.fr.WriteHeaders(http2.HeadersFrameParam{
+			StreamID: streamID, BlockFragment: blk, EndHeaders: true, EndStream: endStream,
+		})
+		return
+	}
+	_ = w.fr.WriteHeaders(http2.HeadersFrameParam{
+		StreamID: streamID, BlockFragment: blk[:split], EndStream: endStream,
+	})
+	_ = w.fr.WriteContinuation(streamID, true, blk[split:])
+}
+
+func (w *h2Writer) bytes() []byte {
+	b := append([]byte{}, w.buf.Bytes()...)
+	w.buf.Reset()
+	return b
+}
+
+func TestH2DecPipe(t *testing.T) {
+	netData := func(payload []byte, fnCallSize int, ts uint64) *comm.NetwrkData {
+		return &comm.NetwrkData{
+			Conn:        comm.ConnectionInfo{Sport: 50000, Dport: 50051},
+			CaptureSize: len(payload),
+			FnCallSize:  fnCallSize,
+			TS:          ts,
+			TSTail:      ts,
+			Payload:     payload,
+		}
+	}
+
+	proto, dec, ok := H2ProtoDetect([]byte(l4log.H2MagicStr), len(l4log.H2MagicStr))
+	if !assert.True(t, ok) {
+		return
+	}
+	assert.Equal(t, ProtoHTTP2, proto)
+
+	cli, srv := newH2Writer(), newH2Writer()
+
+	// the preface, the request headers split into the CONTINUATION frame
+	_ = cli.fr.WriteSettings(http2.Setting{ID: http2.SettingHeaderTableSize, Val: 4096})
+	cli.headers(1, false, 8,
+		":method", "POST",
+		":scheme", "http",
+		":path", "/helloworld.Greeter/SayHello",
+		"content-type", "application/grpc+proto",
+	)
+	_ = cli.fr.WriteData(1, true, []byte("\x00\x00\x00\x00\x05hello"))
+	req0 := append([]byte(l4log.H2MagicStr), cli.bytes()...)
+
+	// the frames are split into two syscalls
+	dec.Decode(comm.NICDEgress, netData(req0[:40], 40, 100), 0, nil)
+	dec.Decode(comm.NICDEgress, netData(req0[40:], len(req0)-40, 110), 0, nil)
+
+	// the payload of the DATA frame is not fully captured
+	srv.headers(1, false, 0, ":status", "200", "content-type", "application/grpc")
+	_ = srv.fr.WriteData(1, false, make([]byte, 1000))
+	resp := srv.bytes()
+	dec.Decode(comm.NICDIngress, netData(resp[:len(resp)-900], len(resp), 300), 0, nil)
+
+	srv.headers(1, true, 0, "grpc-status", "5", "grpc-message", "not found")
+	trailers := srv.bytes()
+	dec.Decode(comm.NICDIngress, netData(trailers, len(trailers), 400), 0, nil)
+
+	// the stream reset by the server
+	cli.headers(3, true, 0,
+		":method", "POST",
+		":scheme", "http",
+		":path", "/helloworld.Greeter/SayHi",
+		"content-type", "application/grpc",
+	)
+	req := cli.bytes()
+	dec.Decode(comm.NICDEgress, netData(req, len(req), 500), 0, nil)
+	_ = srv.fr.WriteRSTStream(3, http2.ErrCodeCancel)
+	rst := srv.bytes()
+	dec.Decode(comm.NICDIngress, netData(rst, len(rst), 600), 0, nil)
+
+	data := dec.Export(true)
+	if !assert.Len(t, data, 2) {
+		return
+	}
+
+	assert.Equal(t, ProtoGRPC, data[0].L7Proto)
+	assert.Equal(t, comm.DOut, data[0].Direction)
+	assert.Equal(t, "POST /helloworld.Greeter/SayHello", data[0].KVs.Get(comm.FieldResource).GetS())
+	assert.Equal(t, "/helloworld.Greeter/SayHello", data[0].KVs.Get(comm.FieldHTTPRoute).GetS())
+	assert.Equal(t, "2.0", data[0].KVs.Get(comm.FieldHTTPVersion).GetS())
+	assert.Equal(t, "200", data[0].KVs.Get(comm.FieldHTTPStatusCode).GetS())
+	assert.Equal(t, "5", data[0].KVs.Get(comm.FieldGRPCStatusCode).GetS())
+	assert.Equal(t, "not found", data[0].KVs.Get(comm.FieldGRPCMessage).GetS())
+	assert.Equal(t, "error", data[0].KVs.Get(comm.FieldStatus).GetS())
+	assert.Equal(t, int64(190), data[0].Cost)
+	assert.Equal(t, int64(290), data[0].Duration)
+	assert.Equal(t, int64(len(resp)+len(trailers)), data[0].KVs.Get(comm.FieldBytesRead).GetI())
+	assert.Equal(t, int64(len(req0)-len(l4log.H2MagicStr)-15), data[0].KVs.Get(comm.FieldBytesWritten).GetI())
+
+	assert.Equal(t, "POST /helloworld.Greeter/SayHi", data[1].KVs.Get(comm.FieldResource).GetS())
+	assert.Equal(t, "error", data[1].KVs.Get(comm.FieldStatus).GetS())
+	assert.Equal(t, int64(100), data[1].Cost)
+}
+
+func TestH2StreamFrames(t *testing.T) {
+	w := newH2Writer()
+	w.headers(1, false, 0, ":method", "GET", ":path", "/")
+	_ = w.fr.WriteData(1, true, []byte("0123456789"))
+	w.headers(3, true, 0, ":method", "GET", ":path", "/a")
+	buf := w.bytes()
+
+	var s h2Stream
+	dec := l4log.NewH2Dec()
+
+	// byte by byte
+	var frames []l4log.Frame
+	for i := range buf {
+		frames = append(frames, s.frames(dec, true, buf[i:i+1], 0)...)
+	}
+	if assert.Len(t, frames, 3) {
+		assert.Equal(t, http2.FrameHeaders, frames[0].Header().Type)
+		assert.Equal(t, http2.FrameData, frames[1].Header().Type)
+		assert.Equal(t, uint32(10), frames[1].Header().Length)
+		assert.Equal(t, uint32(3), frames[2].Header().StreamID)
+	}
+	assert.Empty(t, s.buf)
+	assert.Zero(t, s.skip)
+
+	// the lost data is not in the DATA frame
+	w.headers(5, true, 0, ":method", "GET", ":path", "/b")
+	buf = w.bytes()
+	frames = s.frames(dec, true, buf[:5], len(buf)-5)
+	assert.Empty(t, frames)
+	assert.Empty(t, s.buf)
+}
+
+func TestH2StreamFramesOversized(t *testing.T) {
+	w := newH2Writer()
+	_ = w.fr.WriteRawFrame(http2.FrameType(0x20), 0, 1, bytes.Repeat([]byte{0x88}, h2MaxPending+100))
+	w.headers(3, true, 0, ":method", "GET", ":path", "/a")
+	buf := w.bytes()
+
+	var s h2Stream
+	dec := l4log.NewH2Dec()
+
+	var frames []l4log.Frame
+	for len(buf) > 0 {
+		n := 16 * 1024
+		if n > len(buf) {
+			n = len(buf)
+		}
+		frames = append(frames, s.frames(dec, true, buf[:n], 0)...)
+		buf = buf[n:]
+	}
+
+	// the oversized frame is skipped, the frame after it is still decoded
+	if assert.Len(t, frames, 1) {
+		assert.Equal(t, http2.FrameHeaders, frames[0].Header().Type)
+		assert.Equal(t, uint32(3), frames[0].Header().StreamID)
+	}
+	assert.Empty(t, s.buf)
+	assert.Zero(t, s.skip)
+}
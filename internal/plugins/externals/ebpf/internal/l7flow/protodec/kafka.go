@@ -0,0 +1,664 @@
+//go:build linux
+// +build linux
+
+package protodec
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/internal/l7flow/comm"
+)
+
+const (
+	// kafkaMaxMsgLen is the default socket.request.max.bytes of the broker.
+	kafkaMaxMsgLen = 100 * 1024 * 1024
+	// request header v0: api_key, api_version, correlation_id, client_id.
+	kafkaMinReqLen = 2 + 2 + 4 + 2
+	// kafkaMaxBuffered limits bytes buffered while the message is read by
+	// several syscalls, such as reading the size first.
+	kafkaMaxBuffered = 1024
+	// kafkaMaxPending limits requests in flight waiting for responses.
+	kafkaMaxPending = 64
+
+	kafkaAPIProduce     = 0
+	kafkaAPIFetch       = 1
+	kafkaAPIApiVersions = 18
+
+	// the response starts with error_code.
+	kafkaErrFirst = 1<<15 - 1
+	// no top-level error_code in the response.
+	kafkaNoErr = -1
+)
+
+var errKafkaShort = errors.New("kafka message too short")
+
+type kafkaAPI struct {
+	name       string
+	maxVersion int16
+	// first flexible version, which uses compact strings, compact arrays
+	// and tagged fields, -1 if not flexible.
+	flexible int16
+	// the top-level error_code of the response, preceded by throttle_time_ms
+	// since the version, kafkaErrFirst or kafkaNoErr.
+	throttle int16
+}
+
+var kafkaAPIs = map[int16]kafkaAPI{
+	0:  {"Produce", 11, 9, kafkaNoErr},
+	1:  {"Fetch", 16, 12, kafkaNoErr},
+	2:  {"ListOffsets", 8, 6, kafkaNoErr},
+	3:  {"Metadata", 12, 9, kafkaNoErr},
+	8:  {"OffsetCommit", 9, 8, kafkaNoErr},
+	9:  {"OffsetFetch", 9, 6, kafkaNoErr},
+	10: {"FindCoordinator", 5, 3, kafkaNoErr},
+	11: {"JoinGroup", 9, 6, 2},
+	12: {"Heartbeat", 4, 4, 1},
+	13: {"LeaveGroup", 5, 4, 1},
+	14: {"SyncGroup", 5, 4, 1},
+	15: {"DescribeGroups", 5, 5, kafkaNoErr},
+	16: {"ListGroups", 4, 3, 1},
+	17: {"SaslHandshake", 1, -1, kafkaErrFirst},
+	18: {"ApiVersions", 3, 3, kafkaErrFirst},
+	19: {"CreateTopics", 7, 5, kafkaNoErr},
+	20: {"DeleteTopics", 6, 4, kafkaNoErr},
+	22: {"InitProducerId", 4, 2, 0},
+	24: {"AddPartitionsToTxn", 4, 3, kafkaNoErr},
+	25: {"AddOffsetsToTxn", 3, 3, 0},
+	26: {"EndTxn", 3, 3, 0},
+	28: {"TxnOffsetCommit", 3, 3, kafkaNoErr},
+	32: {"DescribeConfigs", 4, 4, kafkaNoErr},
+	36: {"SaslAuthenticate", 2, 2, kafkaErrFirst},
+	37: {"CreatePartitions", 3, 2, kafkaNoErr},
+	60: {"DescribeCluster", 1, 0, 0},
+}
+
+func (a kafkaAPI) isFlexible(version int16) bool {
+	return a.flexible >= 0 && version >= a.flexible
+}
+
+var kafkaErrors = map[int16]string{
+	-1: "UNKNOWN_SERVER_ERROR",
+	1:  "OFFSET_OUT_OF_RANGE",
+	2:  "CORRUPT_MESSAGE",
+	3:  "UNKNOWN_TOPIC_OR_PARTITION",
+	5:  "LEADER_NOT_AVAILABLE",
+	6:  "NOT_LEADER_OR_FOLLOWER",
+	7:  "REQUEST_TIMED_OUT",
+	10: "MESSAGE_TOO_LARGE",
+	14: "COORDINATOR_LOAD_IN_PROGRESS",
+	15: "COORDINATOR_NOT_AVAILABLE",
+	16: "NOT_COORDINATOR",
+	17: "INVALID_TOPIC_EXCEPTION",
+	19: "NOT_ENOUGH_REPLICAS",
+	22: "ILLEGAL_GENERATION",
+	25: "UNKNOWN_MEMBER_ID",
+	27: "REBALANCE_IN_PROGRESS",
+	29: "TOPIC_AUTHORIZATION_FAILED",
+	30: "GROUP_AUTHORIZATION_FAILED",
+	31: "CLUSTER_AUTHORIZATION_FAILED",
+	35: "UNSUPPORTED_VERSION",
+	36: "TOPIC_ALREADY_EXISTS",
+	58: "SASL_AUTHENTICATION_FAILED",
+}
+
+// KafkaProtoDetect 只检测请求，api_key 和 api_version 需为已知的值.
+func KafkaProtoDetect(data []byte, actSize int) (L7Protocol, ProtoDecPipe, bool) {
+	inf := &kafkaInfo{}
+	if err := inf.parseRequest(data); err != nil {
+		return ProtoUnknown, nil, false
+	}
+	return ProtoKafka, newKafkaDecPipe(ProtoKafka), true
+}
+
+// kafkaReader reads the message, err is set once the message is truncated.
+type kafkaReader struct {
+	b   []byte
+	err error
+}
+
+func (r *kafkaReader) take(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || len(r.b) < n {
+		r.err = errKafkaShort
+		return nil
+	}
+	v := r.b[:n]
+	r.b = r.b[n:]
+	return v
+}
+
+func (r *kafkaReader) int8() int8 {
+	if v := r.take(1); v != nil {
+		return int8(v[0])
+	}
+	return 0
+}
+
+func (r *kafkaReader) int16() int16 {
+	if v := r.take(2); v != nil {
+		return int16(binary.BigEndian.Uint16(v))
+	}
+	return 0
+}
+
+func (r *kafkaReader) int32() int32 {
+	if v := r.take(4); v != nil {
+		return int32(binary.BigEndian.Uint32(v))
+	}
+	return 0
+}
+
+func (r *kafkaReader) uvarint() int {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.b)
+	if n <= 0 || v > kafkaMaxMsgLen {
+		r.err = errKafkaShort
+		return 0
+	}
+	r.b = r.b[n:]
+	return int(v)
+}
+
+// string reads the nullable string, the compact one in flexible versions.
+func (r *kafkaReader) string(flexible bool) string {
+	var n int
+	if flexible {
+		n = r.uvarint() - 1
+	} else {
+		n = int(r.int16())
+	}
+	if n < 0 || r.err != nil {
+		return ""
+	}
+	return string(r.take(n))
+}
+
+func (r *kafkaReader) arrayLen(flexible bool) int {
+	if flexible {
+		return r.uvarint() - 1
+	}
+	return int(r.int32())
+}
+
+func (r *kafkaReader) tags() {
+	n := r.uvarint()
+	for i := 0; i < n && r.err == nil; i++ {
+		r.uvarint()
+		r.take(r.uvarint())
+	}
+}
+
+type kafkaInfo struct {
+	meta ProtoMeta
+
+	size          int
+	apiKey        int16
+	apiVersion    int16
+	correlationID int32
+	clientID      string
+	topic         string
+	partition     int32
+	noResponse    bool
+
+	replied bool
+	errCode int16
+
+	reqBytes  int
+	respBytes int
+
+	ktime [4]uint64
+	ts    int64
+}
+
+func (k *kafkaInfo) api() kafkaAPI {
+	return kafkaAPIs[k.apiKey]
+}
+
+// parseRequest parses the request header, and the first topic and partition
+// of Produce and Fetch, the header must be captured.
+func (k *kafkaInfo) parseRequest(payload []byte) error {
+	r := &kafkaReader{b: payload}
+
+	size := int(r.int32())
+	if r.err != nil {
+		return r.err
+	}
+	if size < kafkaMinReqLen || size > kafkaMaxMsgLen {
+		return ErrUnknownProto
+	}
+
+	k.apiKey = r.int16()
+	k.apiVersion = r.int16()
+	k.correlationID = r.int32()
+	if r.err != nil {
+		return r.err
+	}
+
+	api, ok := kafkaAPIs[k.apiKey]
+	if !ok || k.apiVersion < 0 || k.apiVersion > api.maxVersion || k.correlationID < 0 {
+		return ErrUnknownProto
+	}
+
+	// client_id is never compact
+	k.clientID = r.string(false)
+	if r.err != nil {
+		return r.err
+	}
+	for _, c := range []byte(k.clientID) {
+		if c < 0x20 || c > 0x7e {
+			return ErrUnknownProto
+		}
+	}
+
+	k.size = size + 4
+	k.partition = -1
+
+	flexible := api.isFlexible(k.apiVersion)
+	if flexible {
+		r.tags()
+	}
+
+	switch k.apiKey {
+	case kafkaAPIProduce:
+		if k.apiVersion >= 3 {
+			r.string(flexible) // transactional_id
+		}
+		acks := r.int16()
+		r.int32() // timeout_ms
+		if r.err == nil && acks == 0 {
+			k.noResponse = true
+		}
+		k.readTopic(r, flexible, false)
+	case kafkaAPIFetch:
+		if k.apiVersion <= 14 {
+			r.int32() // replica_id
+		}
+		r.take(4 + 4) // max_wait_ms, min_bytes
+		if k.apiVersion >= 3 {
+			r.int32() // max_bytes
+		}
+		if k.apiVersion >= 4 {
+			r.int8() // isolation_level
+		}
+		if k.apiVersion >= 7 {
+			r.take(4 + 4) // session_id, session_epoch
+		}
+		// topic_id instead of the name since v13
+		k.readTopic(r, flexible, k.apiVersion >= 13)
+	}
+
+	return nil
+}
+
+// readTopic reads the first topic and partition of the topics array.
+func (k *kafkaInfo) readTopic(r *kafkaReader, flexible, topicID bool) {
+	if r.arrayLen(flexible) <= 0 || r.err != nil {
+		return
+	}
+
+	if topicID {
+		r.take(16)
+	} else {
+		k.topic = r.string(flexible)
+	}
+
+	if r.arrayLen(flexible) <= 0 {
+		return
+	}
+	if p := r.int32(); r.err == nil {
+		k.partition = p
+	}
+}
+
+// parseResponse parses the error code of the response, the correlation_id
+// has been checked.
+func (k *kafkaInfo) parseResponse(payload []byte) {
+	r := &kafkaReader{b: payload}
+	r.take(4 + 4) // size, correlation_id
+
+	api := k.api()
+	flexible := api.isFlexible(k.apiVersion)
+	// ApiVersions response always uses header v0
+	if flexible && k.apiKey != kafkaAPIApiVersions {
+		r.tags()
+	}
+
+	var code int16
+	switch k.apiKey {
+	case kafkaAPIProduce:
+		code = readPartitionErr(r, flexible, false)
+	case kafkaAPIFetch:
+		if k.apiVersion >= 1 {
+			r.int32() // throttle_time_ms
+		}
+		if k.apiVersion >= 7 {
+			code = r.int16()
+			r.int32() // session_id
+		}
+		if code == 0 {
+			code = readPartitionErr(r, flexible, k.apiVersion >= 13)
+		}
+	default:
+		switch {
+		case api.throttle == kafkaNoErr:
+		case api.throttle == kafkaErrFirst:
+			code = r.int16()
+		default:
+			if k.apiVersion >= api.throttle {
+				r.int32() // throttle_time_ms
+			}
+			code = r.int16()
+		}
+	}
+
+	if r.err == nil {
+		k.errCode = code
+	}
+}
+
+// readPartitionErr reads error_code of the first partition of the first topic.
+func readPartitionErr(r *kafkaReader, flexible, topicID bool) int16 {
+	if r.arrayLen(flexible) <= 0 || r.err != nil {
+		return 0
+	}
+	if topicID {
+		r.take(16)
+	} else {
+		r.string(flexible)
+	}
+
+	if r.arrayLen(flexible) <= 0 || r.err != nil {
+		return 0
+	}
+	r.int32() // partition index
+	code := r.int16()
+	if r.err != nil {
+		return 0
+	}
+	return code
+}
+
+func (k *kafkaInfo) resource() string {
+	if k.topic == "" {
+		return k.api().name
+	}
+	return k.api().name + " " + k.topic
+}
+
+func (k *kafkaInfo) status() int {
+	if k.errCode != 0 {
+		return ServerError
+	}
+	return StatusOK
+}
+
+// kafkaStream splits messages of one direction of the connection, a syscall
+// may contain several messages or a part of the message.
+type kafkaStream struct {
+	// bytes of the current message not read yet
+	remain int
+	cur    *kafkaInfo
+
+	buf      []byte
+	bufKTime uint64
+}
+
+// feed walks messages of the syscall, fn is called with the beginning of each
+// message and returns the info of it. The ktime of the last bytes of the
+// message is passed to done.
+func (s *kafkaStream) feed(data *comm.NetwrkData,
+	fn func(msg []byte, ktime uint64) *kafkaInfo, done func(inf *kafkaInfo, ktime uint64),
+) {
+	payload, size := data.Payload, data.FnCallSize
+	ktime := data.TSTail
+	if len(s.buf) > 0 {
+		payload = append(s.buf, payload...)
+		size += len(s.buf)
+		ktime = s.bufKTime
+		s.buf = nil
+	}
+	complete := len(payload) == size
+
+	offset := 0
+	if s.remain > 0 {
+		if s.remain > size {
+			s.remain -= size
+			return
+		}
+		offset = s.remain
+		s.remain = 0
+		if s.cur != nil {
+			done(s.cur, data.TSTail)
+			s.cur = nil
+		}
+	}
+
+	for offset < size {
+		// truncated by the capture, lost track of messages until the
+		// next message starts at the syscall
+		if offset >= len(payload) {
+			return
+		}
+
+		msg := payload[offset:]
+		if len(msg) < 4 {
+			if complete {
+				s.buf = append([]byte{}, msg...)
+				s.bufKTime = ktime
+			}
+			return
+		}
+
+		n := int(int32(binary.BigEndian.Uint32(msg))) + 4
+		if n < 8 || n > kafkaMaxMsgLen {
+			return
+		}
+
+		if offset+n > size && complete && size-offset < kafkaMaxBuffered {
+			// the rest is read by the next syscall
+			s.buf = append([]byte{}, msg...)
+			s.bufKTime = ktime
+			return
+		}
+
+		inf := fn(msg, ktime)
+		offset += n
+		if offset <= size {
+			if inf != nil {
+				done(inf, data.TSTail)
+			}
+		} else {
+			s.remain = offset - size
+			s.cur = inf
+		}
+	}
+}
+
+type kafkaDecPipe struct {
+	direction comm.Direcion
+
+	infCache     []*kafkaInfo
+	pending      []*kafkaInfo
+	req          kafkaStream
+	resp         kafkaStream
+	firstDstPort uint32
+	connClosed   bool
+}
+
+func (dec *kafkaDecPipe) Decode(txRx comm.NICDirection, data *comm.NetwrkData,
+	ts int64, thrTr threadTrace,
+) {
+	if len(data.Payload) == 0 {
+		return
+	}
+
+	// 第一次进decode的一定为Request,此时firstDstPort为server port
+	var port uint32
+	switch txRx { //nolint:exhaustive
+	case comm.NICDIngress:
+		port = data.Conn.Sport
+	case comm.NICDEgress:
+		port = data.Conn.Dport
+	default:
+		return
+	}
+	if dec.firstDstPort == 0 {
+		dec.firstDstPort = port
+	}
+
+	if port != dec.firstDstPort {
+		dec.resp.feed(data, dec.decodeResponse, func(inf *kafkaInfo, ktime uint64) {
+			inf.ktime[3] = ktime
+		})
+		return
+	}
+
+	if dec.direction == comm.DUnknown {
+		switch txRx { //nolint:exhaustive
+		case comm.NICDIngress:
+			dec.direction = comm.DIn
+		case comm.NICDEgress:
+			dec.direction = comm.DOut
+		}
+	}
+
+	dec.req.feed(data, func(msg []byte, ktime uint64) *kafkaInfo {
+		inf := &kafkaInfo{}
+		if err := inf.parseRequest(msg); err != nil {
+			log.Debugf("error occur when decode kafka request: %v", err)
+			return nil
+		}
+
+		if dec.direction == comm.DIn {
+			inf.meta.InnerID = thrTr.Insert(dec.direction, int32(data.Conn.Pid),
+				data.Thread, data.TSTail)
+		}
+
+		inf.ts = ts
+		inf.meta.Threads[0] = data.Thread
+		inf.meta.ReqTCPSeq = data.TCPSeq
+		inf.meta.Payload.req(ProtoKafka, msg)
+		inf.reqBytes = inf.size
+		inf.ktime[0] = ktime
+
+		if inf.noResponse {
+			dec.infCache = append(dec.infCache, inf)
+			return inf
+		}
+
+		if len(dec.pending) >= kafkaMaxPending {
+			dec.pending = dec.pending[1:]
+		}
+		dec.pending = append(dec.pending, inf)
+		return inf
+	}, func(inf *kafkaInfo, ktime uint64) {
+		inf.ktime[1] = ktime
+	})
+}
+
+// decodeResponse matches the response to the request by correlation_id,
+// requests before it are regarded as no response.
+func (dec *kafkaDecPipe) decodeResponse(msg []byte, ktime uint64) *kafkaInfo {
+	if len(msg) < 8 {
+		return nil
+	}
+	id := int32(binary.BigEndian.Uint32(msg[4:]))
+
+	for i, inf := range dec.pending {
+		if inf.correlationID != id {
+			continue
+		}
+		dec.pending = dec.pending[i+1:]
+
+		inf.replied = true
+		inf.respBytes = int(binary.BigEndian.Uint32(msg)) + 4
+		inf.ktime[2] = ktime
+		inf.parseResponse(msg)
+		inf.meta.Payload.resp(ProtoKafka, msg)
+		dec.infCache = append(dec.infCache, inf)
+		return inf
+	}
+
+	log.Debugf("kafka response of correlation_id %d not matched", id)
+	return nil
+}
+
+func (dec *kafkaDecPipe) Proto() L7Protocol {
+	return ProtoKafka
+}
+
+func (dec *kafkaDecPipe) Export(force bool) []*ProtoData {
+	if force {
+		dec.pending = nil
+		dec.req = kafkaStream{}
+		dec.resp = kafkaStream{}
+	}
+	var result []*ProtoData
+
+	cache := dec.infCache
+	dec.infCache = dec.infCache[:0]
+	for _, inf := range cache {
+		// the last response not read completely
+		if !force && inf == dec.resp.cur {
+			dec.infCache = append(dec.infCache, inf)
+			continue
+		}
+
+		kvs := make(point.KVs, 0, 20)
+
+		switch dec.direction { //nolint:exhaustive
+		case comm.DIn:
+			kvs = kvs.Add(comm.FieldBytesRead, int64(inf.reqBytes), false, true)
+			kvs = kvs.Add(comm.FieldBytesWritten, int64(inf.respBytes), false, true)
+		default:
+			kvs = kvs.Add(comm.FieldBytesRead, int64(inf.respBytes), false, true)
+			kvs = kvs.Add(comm.FieldBytesWritten, int64(inf.reqBytes), false, true)
+		}
+		kvs = kvs.Add(comm.FieldResource, inf.resource(), false, true)
+		kvs = kvs.Add(comm.FieldResourceType, inf.api().name, false, true)
+		kvs = kvs.Add(comm.FieldKafkaAPIKey, int64(inf.apiKey), false, true)
+		kvs = kvs.Add(comm.FieldKafkaAPIVersion, int64(inf.apiVersion), false, true)
+		kvs = kvs.Add(comm.FieldKafkaCorrelationID, int64(inf.correlationID), false, true)
+		kvs = kvs.Add(comm.FieldKafkaClientID, inf.clientID, false, true)
+		kvs = kvs.Add(comm.FieldKafkaTopic, inf.topic, false, true)
+		kvs = kvs.Add(comm.FieldKafkaPartition, int64(inf.partition), false, true)
+		kvs = kvs.Add(comm.FieldKafkaErrCode, int64(inf.errCode), false, true)
+		kvs = kvs.Add(comm.FieldKafkaErrMsg, kafkaErrors[inf.errCode], false, true)
+		kvs = kvs.Add(comm.FieldStatus, toStatus(inf.status()), false, true)
+		kvs = kvs.Add(comm.FieldStatusI, status2String(inf.status()), false, true)
+		kvs = kvs.Add(comm.FieldOperation, fmt.Sprintf("Kafka %s", inf.api().name), false, true)
+
+		var dur, cost int64
+		if inf.replied {
+			dur = int64(inf.ktime[3] - inf.ktime[0])
+			cost = int64(inf.ktime[2] - inf.ktime[1])
+		}
+		result = append(result, &ProtoData{
+			Meta:      inf.meta,
+			Time:      inf.ts,
+			KVs:       kvs,
+			Cost:      cost,
+			Duration:  dur,
+			Direction: dec.direction,
+			L7Proto:   ProtoKafka,
+			KTime:     inf.ktime[0],
+		})
+	}
+
+	return result
+}
+
+func (dec *kafkaDecPipe) ConnClose() {
+	dec.connClosed = true
+}
+
+func newKafkaDecPipe(L7Protocol) ProtoDecPipe {
+	return &kafkaDecPipe{}
+}
@@ -0,0 +1,176 @@
+//go:build linux
+// +build linux
+
+package protodec
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/internal/l7flow/comm"
+)
+
+type kafkaBuf []byte
+
+func (b kafkaBuf) i8(v int8) kafkaBuf { return append(b, byte(v)) }
+
+func (b kafkaBuf) i16(v int16) kafkaBuf { return binary.BigEndian.AppendUint16(b, uint16(v)) }
+
+func (b kafkaBuf) i32(v int32) kafkaBuf { return binary.BigEndian.AppendUint32(b, uint32(v)) }
+
+func (b kafkaBuf) i64(v int64) kafkaBuf { return binary.BigEndian.AppendUint64(b, uint64(v)) }
+
+func (b kafkaBuf) str(s string) kafkaBuf { return append(b.i16(int16(len(s))), s...) }
+
+func (b kafkaBuf) compactStr(s string) kafkaBuf {
+	return append(binary.AppendUvarint(b, uint64(len(s)+1)), s...)
+}
+
+func (b kafkaBuf) uvarint(v int) kafkaBuf { return binary.AppendUvarint(b, uint64(v)) }
+
+// msg prepends the size.
+func (b kafkaBuf) msg() []byte {
+	return append(kafkaBuf{}.i32(int32(len(b))), b...)
+}
+
+func kafkaReqHeader(apiKey, version int16, id int32, clientID string) kafkaBuf {
+	return kafkaBuf{}.i16(apiKey).i16(version).i32(id).str(clientID)
+}
+
+func kafkaProduceV7(id int32, topic string, partition int32, acks int16) []byte {
+	return kafkaReqHeader(kafkaAPIProduce, 7, id, "producer-1").
+		i16(-1).             // transactional_id
+		i16(acks).i32(3000). // acks, timeout_ms
+		i32(1).str(topic).
+		i32(1).i32(partition).i32(0).msg()
+}
+
+func kafkaFetchV11(id int32, topic string, partition int32) []byte {
+	return kafkaReqHeader(kafkaAPIFetch, 11, id, "consumer-1").
+		i32(-1).i32(500).i32(1).i32(52428800).i8(0).i32(0).i32(-1).
+		i32(1).str(topic).
+		i32(1).i32(partition).i32(-1).i64(42).i64(-1).i32(1048576).msg()
+}
+
+func TestKafkaProtoDetect(t *testing.T) {
+	t.Run("produce", func(t *testing.T) {
+		inf := &kafkaInfo{}
+		assert.NoError(t, inf.parseRequest(kafkaProduceV7(3, "orders", 2, 1)))
+		assert.Equal(t, "Produce orders", inf.resource())
+		assert.Equal(t, int32(2), inf.partition)
+		assert.Equal(t, "producer-1", inf.clientID)
+		assert.False(t, inf.noResponse)
+	})
+
+	t.Run("fetch", func(t *testing.T) {
+		inf := &kafkaInfo{}
+		assert.NoError(t, inf.parseRequest(kafkaFetchV11(9, "orders", 1)))
+		assert.Equal(t, "Fetch orders", inf.resource())
+		assert.Equal(t, int32(1), inf.partition)
+	})
+
+	t.Run("flexible produce", func(t *testing.T) {
+		msg := kafkaReqHeader(kafkaAPIProduce, 9, 5, "producer-1").
+			uvarint(0).        // tagged fields of the header
+			uvarint(0).        // transactional_id null
+			i16(-1).i32(3000). // acks, timeout_ms
+			uvarint(2).compactStr("orders").
+			uvarint(2).i32(4).msg()
+
+		proto, _, ok := KafkaProtoDetect(msg, len(msg))
+		assert.True(t, ok)
+		assert.Equal(t, ProtoKafka, proto)
+
+		inf := &kafkaInfo{}
+		assert.NoError(t, inf.parseRequest(msg))
+		assert.Equal(t, "orders", inf.topic)
+		assert.Equal(t, int32(4), inf.partition)
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		msg := kafkaProduceV7(3, "orders", 2, 1)
+		_, _, ok := KafkaProtoDetect(msg[:30], 30)
+		assert.True(t, ok)
+	})
+
+	t.Run("unknown api", func(t *testing.T) {
+		msg := kafkaReqHeader(1000, 0, 1, "x").msg()
+		_, _, ok := KafkaProtoDetect(msg, len(msg))
+		assert.False(t, ok)
+	})
+
+	t.Run("not kafka", func(t *testing.T) {
+		_, _, ok := KafkaProtoDetect([]byte("GET / HTTP/1.1\r\nHost: localhost\r\n\r\n"), 0)
+		assert.False(t, ok)
+	})
+}
+
+func TestKafkaDecPipe(t *testing.T) {
+	const (
+		cliPort = 50000
+		srvPort = 9092
+	)
+
+	dec := newKafkaDecPipe(ProtoKafka)
+	netData := func(payload []byte, ts uint64) *comm.NetwrkData {
+		return &comm.NetwrkData{
+			Conn:        comm.ConnectionInfo{Sport: cliPort, Dport: srvPort},
+			CaptureSize: len(payload),
+			FnCallSize:  len(payload),
+			TS:          ts,
+			TSTail:      ts,
+			Payload:     payload,
+		}
+	}
+
+	// pipelined requests written by one syscall
+	reqs := append(kafkaProduceV7(1, "orders", 0, 1), kafkaFetchV11(2, "events", 3)...)
+	dec.Decode(comm.NICDEgress, netData(reqs, 100), 0, nil)
+
+	// acks=0, no response
+	dec.Decode(comm.NICDEgress, netData(kafkaProduceV7(3, "logs", 0, 0), 150), 0, nil)
+
+	// produce response, the size read first
+	produceResp := kafkaBuf{}.i32(1).
+		i32(1).str("orders").
+		i32(1).i32(0).i16(0).i64(100).i64(-1).
+		i32(0).msg()
+	dec.Decode(comm.NICDIngress, netData(produceResp[:4], 300), 0, nil)
+	dec.Decode(comm.NICDIngress, netData(produceResp[4:], 310), 0, nil)
+
+	// fetch response, read by syscalls larger than kafkaMaxBuffered
+	fetchResp := kafkaBuf{}.i32(2).
+		i32(0).i16(0).i32(0).
+		i32(1).str("events").
+		i32(1).i32(3).i16(1)
+	fetchResp = append(fetchResp, make([]byte, 2*kafkaMaxBuffered)...)
+	fetchMsg := fetchResp.msg()
+	dec.Decode(comm.NICDIngress, netData(fetchMsg[:kafkaMaxBuffered+100], 400), 0, nil)
+
+	data := dec.Export(false)
+	if !assert.Len(t, data, 2) {
+		return
+	}
+	dec.Decode(comm.NICDIngress, netData(fetchMsg[kafkaMaxBuffered+100:], 500), 0, nil)
+	data = append(data, dec.Export(true)...)
+	if !assert.Len(t, data, 3) {
+		return
+	}
+
+	assert.Equal(t, "Produce logs", data[0].KVs.Get(comm.FieldResource).GetS())
+	assert.Equal(t, int64(0), data[0].Duration)
+
+	assert.Equal(t, "Produce orders", data[1].KVs.Get(comm.FieldResource).GetS())
+	assert.Equal(t, "ok", data[1].KVs.Get(comm.FieldStatus).GetS())
+	assert.Equal(t, int64(210), data[1].Duration)
+	assert.Equal(t, int64(len(produceResp)), data[1].KVs.Get(comm.FieldBytesRead).GetI())
+	assert.Equal(t, "Kafka Produce", data[1].KVs.Get(comm.FieldOperation).GetS())
+
+	assert.Equal(t, "events", data[2].KVs.Get(comm.FieldKafkaTopic).GetS())
+	assert.Equal(t, int64(3), data[2].KVs.Get(comm.FieldKafkaPartition).GetI())
+	assert.Equal(t, "error", data[2].KVs.Get(comm.FieldStatus).GetS())
+	assert.Equal(t, "OFFSET_OUT_OF_RANGE", data[2].KVs.Get(comm.FieldKafkaErrMsg).GetS())
+	assert.Equal(t, int64(400), data[2].Duration)
+	assert.Equal(t, int64(300), data[2].Cost)
+}
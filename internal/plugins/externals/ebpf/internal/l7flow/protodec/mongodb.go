@@ -0,0 +1,515 @@
+//go:build linux
+// +build linux
+
+package protodec
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/internal/l7flow/comm"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+const (
+	mongoHeaderLen = 16
+	mongoOpMsg     = 2013
+	// mongoMaxMsgLen is maxMessageSizeBytes of the MongoDB server.
+	mongoMaxMsgLen = 48 * 1000 * 1000
+	// mongoMaxBuffered limits bytes buffered while the response is read
+	// by several syscalls, such as reading the header first.
+	mongoMaxBuffered = 1024
+
+	mongoFlagChecksum   = 1
+	mongoFlagMoreToCome = 1 << 1
+	mongoFlagExhaust    = 1 << 16
+
+	mongoSectionBody = 0
+	mongoSectionSeq  = 1
+)
+
+var errMongoIncomplete = errors.New("mongodb message incomplete")
+
+// mongoCommands are commands accepted by the detector, the first element of
+// the request document is the command name.
+var mongoCommands = map[string]struct{}{
+	"find": {}, "insert": {}, "update": {}, "delete": {}, "findAndModify": {},
+	"aggregate": {}, "count": {}, "distinct": {}, "getMore": {}, "killCursors": {},
+	"bulkWrite": {}, "explain": {}, "mapReduce": {},
+	"create": {}, "drop": {}, "dropDatabase": {}, "collMod": {}, "renameCollection": {},
+	"createIndexes": {}, "dropIndexes": {}, "listCollections": {}, "listIndexes": {},
+	"listDatabases": {}, "dbStats": {}, "collStats": {}, "serverStatus": {}, "buildInfo": {},
+	"hello": {}, "isMaster": {}, "ismaster": {}, "ping": {}, "endSessions": {},
+	"saslStart": {}, "saslContinue": {}, "authenticate": {}, "logout": {},
+	"commitTransaction": {}, "abortTransaction": {}, "getLastError": {},
+}
+
+// MongoProtoDetect 只检测 OP_MSG 请求，请求的 responseTo 为 0，且 body 的首个
+// 元素为 MongoDB 命令.
+func MongoProtoDetect(data []byte, actSize int) (L7Protocol, ProtoDecPipe, bool) {
+	inf := &mongoInfo{}
+	if err := inf.parseRequest(data); err != nil {
+		return ProtoUnknown, nil, false
+	}
+	if _, ok := mongoCommands[inf.cmd]; !ok {
+		return ProtoUnknown, nil, false
+	}
+	return ProtoMongoDB, newMongoDecPipe(ProtoMongoDB), true
+}
+
+type mongoHeader struct {
+	length     int32
+	requestID  int32
+	responseTo int32
+	opCode     int32
+}
+
+func readMongoHeader(payload []byte) (*mongoHeader, error) {
+	if len(payload) < mongoHeaderLen {
+		return nil, errMongoIncomplete
+	}
+
+	h := &mongoHeader{
+		length:     int32(binary.LittleEndian.Uint32(payload[0:])),
+		requestID:  int32(binary.LittleEndian.Uint32(payload[4:])),
+		responseTo: int32(binary.LittleEndian.Uint32(payload[8:])),
+		opCode:     int32(binary.LittleEndian.Uint32(payload[12:])),
+	}
+
+	// header, flagBits, section kind and the minimal document
+	if h.length < mongoHeaderLen+4+1+5 || h.length > mongoMaxMsgLen {
+		return nil, ErrUnknownProto
+	}
+	if h.opCode != mongoOpMsg {
+		return nil, ErrUnknownProto
+	}
+	return h, nil
+}
+
+// readMongoBody returns flagBits and the body document of the OP_MSG, the
+// document may be truncated by the captured payload.
+func readMongoBody(h *mongoHeader, payload []byte) (uint32, []byte, error) {
+	end := int(h.length) - mongoHeaderLen
+	if len(payload) < 4 {
+		return 0, nil, errMongoIncomplete
+	}
+
+	flags := binary.LittleEndian.Uint32(payload)
+	if flags&^(mongoFlagChecksum|mongoFlagMoreToCome|mongoFlagExhaust) != 0 {
+		return 0, nil, ErrUnknownProto
+	}
+
+	// document sequences(kind 1) may be placed before the body
+	offset := 4
+	for {
+		if len(payload) < offset+1+4 {
+			return 0, nil, errMongoIncomplete
+		}
+
+		kind := payload[offset]
+		size := int(int32(binary.LittleEndian.Uint32(payload[offset+1:])))
+		switch kind {
+		case mongoSectionBody:
+			if size < 5 || offset+1+size > end {
+				return 0, nil, ErrUnknownProto
+			}
+			doc := payload[offset+1:]
+			if len(doc) > size {
+				doc = doc[:size]
+			}
+			return flags, doc, nil
+		case mongoSectionSeq:
+			if size < 4 || offset+1+size > end {
+				return 0, nil, ErrUnknownProto
+			}
+			offset += 1 + size
+		default:
+			return 0, nil, ErrUnknownProto
+		}
+	}
+}
+
+// rangeMongoDoc iterates top-level elements of the document until fn returns
+// false, elements truncated are ignored.
+func rangeMongoDoc(doc []byte, fn func(key string, val bsoncore.Value) bool) {
+	if len(doc) < 4 {
+		return
+	}
+
+	rem := doc[4:]
+	for len(rem) > 0 && rem[0] != 0x00 {
+		elem, next, ok := bsoncore.ReadElement(rem)
+		if !ok {
+			return
+		}
+		key, err := elem.KeyErr()
+		if err != nil {
+			return
+		}
+		val, err := elem.ValueErr()
+		if err != nil {
+			return
+		}
+		if !fn(key, val) {
+			return
+		}
+		rem = next
+	}
+}
+
+type mongoInfo struct {
+	meta ProtoMeta
+
+	requestID  int32
+	moreToCome bool
+
+	cmd        string
+	db         string
+	collection string
+
+	responding bool
+	replied    bool
+	okVal      int64
+	errCode    int64
+	codeName   string
+	errMsg     string
+	status     int
+
+	reqBytes  int
+	respBytes int
+
+	ktime [4]uint64
+	ts    int64
+	dur   [2]uint64
+}
+
+func (m *mongoInfo) parseRequest(payload []byte) error {
+	h, err := readMongoHeader(payload)
+	if err != nil {
+		return err
+	}
+	if h.responseTo != 0 {
+		return ErrUnknownProto
+	}
+
+	flags, doc, err := readMongoBody(h, payload[mongoHeaderLen:])
+	if err != nil {
+		return err
+	}
+
+	first := true
+	rangeMongoDoc(doc, func(key string, val bsoncore.Value) bool {
+		if first {
+			first = false
+			m.cmd = key
+			if s, ok := val.StringValueOK(); ok {
+				m.collection = s
+			}
+			return true
+		}
+
+		switch key {
+		case "$db":
+			m.db, _ = val.StringValueOK()
+		case "collection":
+			// getMore
+			if m.collection == "" {
+				m.collection, _ = val.StringValueOK()
+			}
+		}
+		return true
+	})
+
+	if !isMongoCmdName(m.cmd) {
+		return ErrUnknownProto
+	}
+
+	m.requestID = h.requestID
+	m.moreToCome = flags&mongoFlagMoreToCome != 0
+	return nil
+}
+
+func (m *mongoInfo) parseResponse(payload []byte) error {
+	h, err := readMongoHeader(payload)
+	if err != nil {
+		return err
+	}
+	if h.responseTo != m.requestID {
+		return ErrUnknownProto
+	}
+
+	_, doc, err := readMongoBody(h, payload[mongoHeaderLen:])
+	if err != nil {
+		return err
+	}
+
+	// ok is the last element of the succeeded reply, such as the reply of
+	// find, it's regarded as succeeded if not captured.
+	m.okVal = 1
+	rangeMongoDoc(doc, func(key string, val bsoncore.Value) bool {
+		switch key {
+		case "ok":
+			if v, ok := val.AsInt64OK(); ok {
+				m.okVal = v
+			} else if v, ok := val.BooleanOK(); ok && !v {
+				m.okVal = 0
+			}
+		case "errmsg":
+			m.errMsg, _ = val.StringValueOK()
+		case "code":
+			m.errCode, _ = val.AsInt64OK()
+		case "codeName":
+			m.codeName, _ = val.StringValueOK()
+		case "writeErrors":
+			// errors of insert, update and delete with ok: 1
+			if arr, ok := val.ArrayOK(); ok {
+				if v, err := arr.IndexErr(0); err == nil {
+					if d, ok := v.DocumentOK(); ok {
+						m.readWriteError(d)
+					}
+				}
+			}
+		case "writeConcernError":
+			if d, ok := val.DocumentOK(); ok {
+				m.readWriteError(d)
+			}
+		}
+		return true
+	})
+
+	if m.okVal == 0 || m.errCode != 0 {
+		m.status = ServerError
+	} else {
+		m.status = StatusOK
+	}
+	return nil
+}
+
+func (m *mongoInfo) readWriteError(doc bsoncore.Document) {
+	if m.errCode != 0 {
+		return
+	}
+	rangeMongoDoc(doc, func(key string, val bsoncore.Value) bool {
+		switch key {
+		case "code":
+			m.errCode, _ = val.AsInt64OK()
+		case "errmsg":
+			m.errMsg, _ = val.StringValueOK()
+		case "codeName":
+			m.codeName, _ = val.StringValueOK()
+		}
+		return true
+	})
+}
+
+// resource is like "find mydb.users".
+func (m *mongoInfo) resource() string {
+	ns := m.db
+	if m.collection != "" {
+		if ns != "" {
+			ns += "."
+		}
+		ns += m.collection
+	}
+	if ns == "" {
+		return m.cmd
+	}
+	return m.cmd + " " + ns
+}
+
+func isMongoCmdName(name string) bool {
+	if name == "" || len(name) > 32 {
+		return false
+	}
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+type mongoDecPipe struct {
+	direction comm.Direcion
+
+	infCache     []*mongoInfo
+	inf          *mongoInfo
+	respBuf      []byte
+	firstDstPort uint32
+	connClosed   bool
+}
+
+func (dec *mongoDecPipe) Decode(txRx comm.NICDirection, data *comm.NetwrkData,
+	ts int64, thrTr threadTrace,
+) {
+	if len(data.Payload) == 0 {
+		return
+	}
+
+	// 第一次进decode的一定为Request,此时firstDstPort为server port
+	var port uint32
+	switch txRx { //nolint:exhaustive
+	case comm.NICDIngress:
+		port = data.Conn.Sport
+	case comm.NICDEgress:
+		port = data.Conn.Dport
+	default:
+		return
+	}
+	if dec.firstDstPort == 0 {
+		dec.firstDstPort = port
+	}
+
+	if port == dec.firstDstPort {
+		dec.decodeRequest(txRx, data, ts, thrTr)
+	} else {
+		dec.decodeResponse(data)
+	}
+}
+
+func (dec *mongoDecPipe) decodeRequest(txRx comm.NICDirection, data *comm.NetwrkData,
+	ts int64, thrTr threadTrace,
+) {
+	inf := &mongoInfo{}
+	if err := inf.parseRequest(data.Payload); err != nil {
+		log.Debugf("error occur when decode mongodb request: %v", err)
+		return
+	}
+
+	if dec.inf != nil {
+		dec.infCache = append(dec.infCache, dec.inf)
+	}
+	dec.inf = inf
+	dec.respBuf = nil
+
+	if dec.direction == comm.DUnknown {
+		switch txRx { //nolint:exhaustive
+		case comm.NICDIngress:
+			dec.direction = comm.DIn
+		case comm.NICDEgress:
+			dec.direction = comm.DOut
+		}
+	}
+
+	if dec.direction == comm.DIn {
+		inf.meta.InnerID = thrTr.Insert(dec.direction, int32(data.Conn.Pid),
+			data.Thread, data.TSTail)
+	}
+
+	inf.ts = ts
+	inf.meta.Threads[0] = data.Thread
+	inf.meta.ReqTCPSeq = data.TCPSeq
+	inf.meta.Payload.req(ProtoMongoDB, data.Payload)
+	inf.reqBytes = data.FnCallSize
+	inf.dur[0] = data.TS
+	inf.ktime[0] = data.TSTail
+	inf.ktime[1] = data.TSTail
+}
+
+func (dec *mongoDecPipe) decodeResponse(data *comm.NetwrkData) {
+	inf := dec.inf
+	// no reply of the request with moreToCome, such as writes of w: 0
+	if inf == nil || inf.moreToCome {
+		return
+	}
+
+	if !inf.responding {
+		inf.responding = true
+		inf.meta.Threads[1] = data.Thread
+		inf.meta.RespTCPSeq = data.TCPSeq
+		inf.meta.Payload.resp(ProtoMongoDB, data.Payload)
+		inf.dur[1] = data.TS
+		inf.ktime[2] = data.TSTail
+	}
+	inf.respBytes += data.FnCallSize
+	inf.ktime[3] = data.TSTail
+
+	if inf.replied {
+		return
+	}
+
+	buf := append(dec.respBuf, data.Payload...)
+	err := inf.parseResponse(buf)
+	switch {
+	case err == nil:
+		inf.replied = true
+		dec.respBuf = nil
+	case errors.Is(err, errMongoIncomplete) &&
+		len(data.Payload) == data.FnCallSize && len(buf) < mongoMaxBuffered:
+		// the rest of the reply is read by the next syscall
+		dec.respBuf = buf
+	default:
+		log.Debugf("error occur when decode mongodb response: %v", err)
+		dec.respBuf = nil
+		inf.responding = false
+		inf.respBytes = 0
+	}
+}
+
+func (dec *mongoDecPipe) Proto() L7Protocol {
+	return ProtoMongoDB
+}
+
+func (dec *mongoDecPipe) Export(force bool) []*ProtoData {
+	if force {
+		dec.infCache = append(dec.infCache, dec.inf)
+		dec.inf = nil
+		dec.respBuf = nil
+	}
+	var result []*ProtoData
+
+	for _, inf := range dec.infCache {
+		if inf == nil || (!inf.replied && !inf.moreToCome) {
+			continue
+		}
+		kvs := make(point.KVs, 0, 20)
+
+		switch dec.direction { //nolint:exhaustive
+		case comm.DIn:
+			kvs = kvs.Add(comm.FieldBytesRead, int64(inf.reqBytes), false, true)
+			kvs = kvs.Add(comm.FieldBytesWritten, int64(inf.respBytes), false, true)
+		default:
+			kvs = kvs.Add(comm.FieldBytesRead, int64(inf.respBytes), false, true)
+			kvs = kvs.Add(comm.FieldBytesWritten, int64(inf.reqBytes), false, true)
+		}
+		kvs = kvs.Add(comm.FieldResource, inf.resource(), false, true)
+		kvs = kvs.Add(comm.FieldResourceType, inf.cmd, false, true)
+		kvs = kvs.Add(comm.FieldMongoDB, inf.db, false, true)
+		kvs = kvs.Add(comm.FieldMongoCollection, inf.collection, false, true)
+		kvs = kvs.Add(comm.FieldMongoErrCode, inf.errCode, false, true)
+		kvs = kvs.Add(comm.FieldMongoCodeName, inf.codeName, false, true)
+		kvs = kvs.Add(comm.FieldMongoErrMsg, inf.errMsg, false, true)
+		kvs = kvs.Add(comm.FieldStatus, toStatus(inf.status), false, true)
+		kvs = kvs.Add(comm.FieldStatusI, status2String(inf.status), false, true)
+		kvs = kvs.Add(comm.FieldOperation, fmt.Sprintf("MongoDB %s", inf.cmd), false, true)
+
+		var dur, cost int64
+		if inf.replied {
+			dur = int64(inf.ktime[3] - inf.ktime[0])
+			cost = int64(inf.ktime[2] - inf.ktime[1])
+		}
+		result = append(result, &ProtoData{
+			Meta:      inf.meta,
+			Time:      inf.ts,
+			KVs:       kvs,
+			Cost:      cost,
+			Duration:  dur,
+			Direction: dec.direction,
+			L7Proto:   ProtoMongoDB,
+			KTime:     inf.ktime[0],
+		})
+	}
+
+	dec.infCache = dec.infCache[:0]
+	return result
+}
+
+func (dec *mongoDecPipe) ConnClose() {
+	dec.connClosed = true
+}
+
+func newMongoDecPipe(L7Protocol) ProtoDecPipe {
+	return &mongoDecPipe{}
+}
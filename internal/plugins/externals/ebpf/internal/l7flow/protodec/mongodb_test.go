@@ -0,0 +1,149 @@
+//go:build linux
+// +build linux
+
+package protodec
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/internal/l7flow/comm"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+func mongoMsg(requestID, responseTo int32, flags uint32, doc []byte) []byte {
+	b := make([]byte, mongoHeaderLen+4)
+	binary.LittleEndian.PutUint32(b[4:], uint32(requestID))
+	binary.LittleEndian.PutUint32(b[8:], uint32(responseTo))
+	binary.LittleEndian.PutUint32(b[12:], mongoOpMsg)
+	binary.LittleEndian.PutUint32(b[16:], flags)
+	b = append(b, mongoSectionBody)
+	b = append(b, doc...)
+	binary.LittleEndian.PutUint32(b, uint32(len(b)))
+	return b
+}
+
+func mongoNetData(payload []byte, sport, dport uint32, ts uint64) *comm.NetwrkData {
+	return &comm.NetwrkData{
+		Conn:        comm.ConnectionInfo{Sport: sport, Dport: dport},
+		CaptureSize: len(payload),
+		FnCallSize:  len(payload),
+		TS:          ts,
+		TSTail:      ts,
+		Payload:     payload,
+	}
+}
+
+func TestMongoProtoDetect(t *testing.T) {
+	find := bsoncore.BuildDocument(nil,
+		bsoncore.AppendStringElement(nil, "find", "users"),
+		bsoncore.AppendDocumentElement(nil, "filter",
+			bsoncore.BuildDocument(nil, bsoncore.AppendStringElement(nil, "name", "x"))),
+		bsoncore.AppendStringElement(nil, "$db", "app"),
+	)
+
+	t.Run("find", func(t *testing.T) {
+		msg := mongoMsg(7, 0, 0, find)
+		proto, _, ok := MongoProtoDetect(msg, len(msg))
+		assert.True(t, ok)
+		assert.Equal(t, ProtoMongoDB, proto)
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		msg := mongoMsg(7, 0, 0, find)
+		_, _, ok := MongoProtoDetect(msg[:mongoHeaderLen+5+20], 0)
+		assert.True(t, ok)
+	})
+
+	t.Run("reply", func(t *testing.T) {
+		msg := mongoMsg(8, 7, 0, find)
+		_, _, ok := MongoProtoDetect(msg, len(msg))
+		assert.False(t, ok)
+	})
+
+	t.Run("unknown command", func(t *testing.T) {
+		msg := mongoMsg(7, 0, 0, bsoncore.BuildDocument(nil,
+			bsoncore.AppendStringElement(nil, "foo", "bar")))
+		_, _, ok := MongoProtoDetect(msg, len(msg))
+		assert.False(t, ok)
+	})
+
+	t.Run("not mongodb", func(t *testing.T) {
+		_, _, ok := MongoProtoDetect([]byte("GET / HTTP/1.1\r\nHost: localhost\r\n\r\n"), 0)
+		assert.False(t, ok)
+	})
+}
+
+func TestMongoDecPipe(t *testing.T) {
+	const (
+		cliPort = 50000
+		srvPort = 27017
+	)
+
+	dec := newMongoDecPipe(ProtoMongoDB)
+
+	// find, reply read by two syscalls
+	dec.Decode(comm.NICDEgress, mongoNetData(mongoMsg(1, 0, 0, bsoncore.BuildDocument(nil,
+		bsoncore.AppendStringElement(nil, "find", "users"),
+		bsoncore.AppendStringElement(nil, "$db", "app"),
+	)), cliPort, srvPort, 100), 0, nil)
+
+	reply := mongoMsg(2, 1, 0, bsoncore.BuildDocument(nil,
+		bsoncore.AppendDocumentElement(nil, "cursor", bsoncore.BuildDocument(nil,
+			bsoncore.AppendInt64Element(nil, "id", 0))),
+		bsoncore.AppendDoubleElement(nil, "ok", 1),
+	))
+	dec.Decode(comm.NICDIngress, mongoNetData(reply[:mongoHeaderLen], cliPort, srvPort, 300), 0, nil)
+	dec.Decode(comm.NICDIngress, mongoNetData(reply[mongoHeaderLen:], cliPort, srvPort, 400), 0, nil)
+
+	// insert failed by the duplicate key
+	dec.Decode(comm.NICDEgress, mongoNetData(mongoMsg(3, 0, 0, bsoncore.BuildDocument(nil,
+		bsoncore.AppendStringElement(nil, "insert", "users"),
+		bsoncore.AppendStringElement(nil, "$db", "app"),
+	)), cliPort, srvPort, 1000), 0, nil)
+
+	writeErr := bsoncore.BuildDocument(nil,
+		bsoncore.AppendInt32Element(nil, "index", 0),
+		bsoncore.AppendInt32Element(nil, "code", 11000),
+		bsoncore.AppendStringElement(nil, "errmsg", "E11000 duplicate key error"),
+	)
+	dec.Decode(comm.NICDIngress, mongoNetData(mongoMsg(4, 3, 0, bsoncore.BuildDocument(nil,
+		bsoncore.AppendInt32Element(nil, "n", 0),
+		bsoncore.AppendArrayElement(nil, "writeErrors", bsoncore.BuildDocument(nil,
+			bsoncore.AppendDocumentElement(nil, "0", writeErr))),
+		bsoncore.AppendDoubleElement(nil, "ok", 1),
+	)), cliPort, srvPort, 1500), 0, nil)
+
+	// command failed
+	dec.Decode(comm.NICDEgress, mongoNetData(mongoMsg(5, 0, 0, bsoncore.BuildDocument(nil,
+		bsoncore.AppendStringElement(nil, "drop", "orders"),
+		bsoncore.AppendStringElement(nil, "$db", "app"),
+	)), cliPort, srvPort, 2000), 0, nil)
+	dec.Decode(comm.NICDIngress, mongoNetData(mongoMsg(6, 5, 0, bsoncore.BuildDocument(nil,
+		bsoncore.AppendDoubleElement(nil, "ok", 0),
+		bsoncore.AppendStringElement(nil, "errmsg", "ns not found"),
+		bsoncore.AppendInt32Element(nil, "code", 26),
+		bsoncore.AppendStringElement(nil, "codeName", "NamespaceNotFound"),
+	)), cliPort, srvPort, 2100), 0, nil)
+
+	data := dec.Export(true)
+	if !assert.Len(t, data, 3) {
+		return
+	}
+
+	assert.Equal(t, "find app.users", data[0].KVs.Get(comm.FieldResource).GetS())
+	assert.Equal(t, "ok", data[0].KVs.Get(comm.FieldStatus).GetS())
+	assert.Equal(t, int64(300), data[0].Duration)
+	assert.Equal(t, int64(len(reply)), data[0].KVs.Get(comm.FieldBytesRead).GetI())
+	assert.Equal(t, comm.DOut, data[0].Direction)
+
+	assert.Equal(t, "insert", data[1].KVs.Get(comm.FieldResourceType).GetS())
+	assert.Equal(t, "error", data[1].KVs.Get(comm.FieldStatus).GetS())
+	assert.Equal(t, int64(11000), data[1].KVs.Get(comm.FieldMongoErrCode).GetI())
+
+	assert.Equal(t, "error", data[2].KVs.Get(comm.FieldStatus).GetS())
+	assert.Equal(t, "NamespaceNotFound", data[2].KVs.Get(comm.FieldMongoCodeName).GetS())
+	assert.Equal(t, "ns not found", data[2].KVs.Get(comm.FieldMongoErrMsg).GetS())
+	assert.Equal(t, int64(100), data[2].Duration)
+}
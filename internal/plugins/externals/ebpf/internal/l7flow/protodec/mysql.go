@@ -441,6 +441,7 @@ func (dec *mysqlDecPipe) Decode(txRx comm.NICDirection, data *comm.NetwrkData,
 		}
 
 		inf.meta.ReqTCPSeq = firstSeq
+		inf.meta.Payload.req(ProtoMySQL, data.Payload)
 		if inf.dur[0] == 0 {
 			inf.dur[0] = data.TS
 		}
@@ -467,6 +468,7 @@ func (dec *mysqlDecPipe) Decode(txRx comm.NICDirection, data *comm.NetwrkData,
 
 		dec.reqResp = 2
 		inf.meta.RespTCPSeq = firstSeq
+		inf.meta.Payload.resp(ProtoMySQL, data.Payload)
 		inf.meta.Threads[1] = data.Thread
 
 		inf.dur[1] = data.TS
@@ -0,0 +1,194 @@
+//go:build linux
+// +build linux
+
+package protodec
+
+import (
+	"bytes"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultPayloadMaxSize   = 256
+	DefaultPayloadMaxPerMin = 60
+)
+
+// PayloadSample is the truncated request and response payload of the sampled
+// call, only the headers are kept for HTTP, the credentials are redacted.
+type PayloadSample struct {
+	Sampled bool
+	Req     []byte
+	Resp    []byte
+}
+
+// payloadSampler decides which calls carry the payload samples, the fraction
+// of the calls is sampled, and at most maxPerMin samples in every minute.
+type payloadSampler struct {
+	rate      float64
+	maxSize   int
+	maxPerMin int
+
+	winStart time.Time
+	count    int
+}
+
+var (
+	_payloadSampler *payloadSampler
+	_payloadMu      sync.Mutex
+)
+
+// SetPayloadSampling sets the payload sampling of the captured calls, it's
+// disabled if the rate is not greater than 0.
+func SetPayloadSampling(rate float64, maxSize, maxPerMin int) {
+	_payloadMu.Lock()
+	defer _payloadMu.Unlock()
+
+	if rate <= 0 {
+		_payloadSampler = nil
+		return
+	}
+	if rate > 1 {
+		rate = 1
+	}
+	if maxSize <= 0 {
+		maxSize = DefaultPayloadMaxSize
+	}
+	if maxPerMin <= 0 {
+		maxPerMin = DefaultPayloadMaxPerMin
+	}
+
+	_payloadSampler = &payloadSampler{
+		rate:      rate,
+		maxSize:   maxSize,
+		maxPerMin: maxPerMin,
+	}
+}
+
+// samplePayload returns the max size of the payload sample if the call is
+// sampled, or 0 if not.
+func samplePayload() int {
+	_payloadMu.Lock()
+	defer _payloadMu.Unlock()
+
+	s := _payloadSampler
+	if s == nil {
+		return 0
+	}
+
+	if s.rate < 1 && rand.Float64() >= s.rate { //nolint:gosec
+		return 0
+	}
+
+	now := time.Now()
+	if now.Sub(s.winStart) >= time.Minute {
+		s.winStart = now
+		s.count = 0
+	}
+	if s.count >= s.maxPerMin {
+		return 0
+	}
+	s.count++
+
+	return s.maxSize
+}
+
+func payloadMaxSize() int {
+	_payloadMu.Lock()
+	defer _payloadMu.Unlock()
+
+	if _payloadSampler == nil {
+		return 0
+	}
+	return _payloadSampler.maxSize
+}
+
+// req decides whether the call is sampled on the request, the payload is
+// copied since the buffer is reused.
+func (s *PayloadSample) req(proto L7Protocol, payload []byte) {
+	size := samplePayload()
+	if size == 0 {
+		return
+	}
+	s.Sampled = true
+	s.Req = truncPayload(proto, payload, size)
+}
+
+func (s *PayloadSample) resp(proto L7Protocol, payload []byte) {
+	if !s.Sampled || s.Resp != nil {
+		return
+	}
+	if size := payloadMaxSize(); size > 0 {
+		s.Resp = truncPayload(proto, payload, size)
+	}
+}
+
+var (
+	httpHeaderEnd = []byte("\r\n\r\n")
+	httpLineEnd   = []byte("\r\n")
+
+	// the credentials in these headers are not exported
+	httpRedactedHeaders = [][]byte{
+		[]byte("Authorization"),
+		[]byte("Cookie"),
+		[]byte("Set-Cookie"),
+	}
+	httpRedacted = []byte(" [REDACTED]")
+)
+
+func truncPayload(proto L7Protocol, payload []byte, size int) []byte {
+	if proto == ProtoHTTP {
+		if i := bytes.Index(payload, httpHeaderEnd); i >= 0 {
+			payload = payload[:i]
+		}
+		payload = redactHTTPHeaders(payload)
+	}
+	if len(payload) > size {
+		payload = payload[:size]
+	}
+
+	return append([]byte{}, payload...)
+}
+
+// redactHTTPHeaders replaces the values of the credential headers, the
+// payload is not modified.
+func redactHTTPHeaders(payload []byte) []byte {
+	lines := bytes.Split(payload, httpLineEnd)
+
+	redacted := false
+	for i := 1; i < len(lines); i++ { // the first is the request or status line
+		name, _, ok := bytes.Cut(lines[i], []byte(":"))
+		if !ok {
+			continue
+		}
+		name = bytes.TrimSpace(name)
+		for _, h := range httpRedactedHeaders {
+			if bytes.EqualFold(name, h) {
+				n := bytes.IndexByte(lines[i], ':') + 1
+				lines[i] = append(lines[i][:n:n], httpRedacted...)
+				redacted = true
+				break
+			}
+		}
+	}
+
+	if !redacted {
+		return payload
+	}
+	return bytes.Join(lines, httpLineEnd)
+}
+
+// PrintablePayload replaces the non-printable bytes of the payload with '.',
+// the binary protocols are displayed the same as the ascii column of hexdump.
+func PrintablePayload(payload []byte) string {
+	b := make([]byte, len(payload))
+	for i, c := range payload {
+		if (c < 0x20 || c > 0x7e) && c != '\r' && c != '\n' {
+			b[i] = '.'
+		} else {
+			b[i] = c
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,81 @@
+//go:build linux
+// +build linux
+
+package protodec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/internal/l7flow/comm"
+)
+
+func TestTruncPayload(t *testing.T) {
+	req := []byte("POST /api HTTP/1.1\r\nHost: a\r\n\r\n{\"password\":\"x\"}")
+
+	assert.Equal(t, "POST /api HTTP/1.1\r\nHost: a", string(truncPayload(ProtoHTTP, req, 256)))
+	assert.Equal(t, "POST /api", string(truncPayload(ProtoHTTP, req, 9)))
+	assert.Equal(t, string(req), string(truncPayload(ProtoRedis, req, 256)))
+
+	// the credentials redacted before truncated
+	req = []byte("GET / HTTP/1.1\r\nAuthorization: Bearer secret\r\ncookie: sid=1\r\nAccept: */*\r\n\r\n")
+	assert.Equal(t, "GET / HTTP/1.1\r\nAuthorization: [REDACTED]\r\ncookie: [REDACTED]\r\nAccept: */*",
+		string(truncPayload(ProtoHTTP, req, 256)))
+	assert.Equal(t, "GET / HTTP/1.1\r\nAuthorization: [RED", string(truncPayload(ProtoHTTP, req, 35)))
+	assert.Contains(t, string(req), "Bearer secret")
+
+	resp := []byte("HTTP/1.1 200 OK\r\nSet-Cookie: sid=2; HttpOnly")
+	assert.Equal(t, "HTTP/1.1 200 OK\r\nSet-Cookie: [REDACTED]", string(truncPayload(ProtoHTTP, resp, 256)))
+
+	assert.Equal(t, "*1\r\n$4\r\n..ng", PrintablePayload([]byte("*1\r\n$4\r\n\x00\xffng")))
+}
+
+func TestSamplePayload(t *testing.T) {
+	defer SetPayloadSampling(0, 0, 0)
+
+	SetPayloadSampling(0, 0, 0)
+	assert.Equal(t, 0, samplePayload())
+
+	SetPayloadSampling(1, 16, 2)
+	assert.Equal(t, 16, samplePayload())
+	assert.Equal(t, 16, samplePayload())
+	// the samples per minute are capped
+	assert.Equal(t, 0, samplePayload())
+
+	SetPayloadSampling(2, 0, 0)
+	assert.Equal(t, DefaultPayloadMaxSize, samplePayload())
+}
+
+func TestHTTPPayloadSample(t *testing.T) {
+	defer SetPayloadSampling(0, 0, 0)
+
+	decode := func(dec ProtoDecPipe) {
+		dec.Decode(comm.NICDEgress, mongoNetData([]byte(
+			"GET /users HTTP/1.1\r\nHost: a\r\n"+
+				"traceparent: 00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01\r\n\r\n"),
+			50000, 80, 100), 0, nil)
+		dec.Decode(comm.NICDIngress, mongoNetData([]byte(
+			"HTTP/1.1 200 OK\r\nContent-Length: 2\r\n\r\n[]"),
+			50000, 80, 200), 0, nil)
+	}
+
+	dec := newHTTPDecPipe(ProtoHTTP)
+	decode(dec)
+	data := dec.Export(true)
+	if assert.Len(t, data, 1) {
+		assert.False(t, data[0].Meta.Payload.Sampled)
+		assert.Nil(t, data[0].Meta.Payload.Req)
+	}
+
+	SetPayloadSampling(1, 64, 10)
+	dec = newHTTPDecPipe(ProtoHTTP)
+	decode(dec)
+	data = dec.Export(true)
+	if assert.Len(t, data, 1) {
+		p := data[0].Meta.Payload
+		assert.True(t, p.Sampled)
+		assert.Len(t, p.Req, 64)
+		assert.Equal(t, "HTTP/1.1 200 OK\r\nContent-Length: 2", string(p.Resp))
+		assert.False(t, data[0].Meta.TraceID.Zero())
+	}
+}
@@ -288,6 +288,7 @@ func (dec *pgsqlDecPipe) Decode(txRx comm.NICDirection, data *comm.NetwrkData,
 		inf.ts = ts
 		inf.meta.Threads[0] = data.Thread
 		inf.meta.ReqTCPSeq = data.TCPSeq
+		inf.meta.Payload.req(ProtoPgsql, data.Payload)
 		inf.dur[0] = data.TS
 		inf.ktime[0] = data.TSTail
 
@@ -296,6 +297,7 @@ func (dec *pgsqlDecPipe) Decode(txRx comm.NICDirection, data *comm.NetwrkData,
 
 		inf.meta.Threads[1] = data.Thread
 		inf.meta.RespTCPSeq = data.TCPSeq
+		inf.meta.Payload.resp(ProtoPgsql, data.Payload)
 
 		inf.dur[1] = data.TS
 		inf.ktime[2] = data.TSTail
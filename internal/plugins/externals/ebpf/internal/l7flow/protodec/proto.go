@@ -31,6 +31,8 @@ const (
 	ProtoRedis
 	ProtoAMQP
 	ProtoPgsql
+	ProtoMongoDB
+	ProtoKafka
 )
 
 var _protoSet = &ProtoSet{
@@ -60,6 +62,12 @@ func (p *ProtoSet) RegisterAggregator(proto L7Protocol, fn func(L7Protocol) AggP
 	p.protoAgg[proto] = fn
 }
 
+// Has returns true if the protocol could be detected by the set.
+func (p *ProtoSet) Has(proto L7Protocol) bool {
+	_, ok := p.protoDect[proto]
+	return ok
+}
+
 func (p *ProtoSet) ProtoDetector(data []byte, actSize int) (L7Protocol, ProtoDecPipe, bool) {
 	for _, fn := range p.protoDect {
 		proto, pipe, ok := fn(data, actSize)
@@ -110,6 +118,10 @@ func (p L7Protocol) StringLower() string {
 		return "amqp"
 	case ProtoPgsql:
 		return "postgresql"
+	case ProtoMongoDB:
+		return "mongodb"
+	case ProtoKafka:
+		return "kafka"
 	default:
 		return "unknown"
 	}
@@ -131,6 +143,10 @@ func (p L7Protocol) String() string {
 		return "AMQP"
 	case ProtoPgsql:
 		return "PostgreSQL"
+	case ProtoMongoDB:
+		return "MongoDB"
+	case ProtoKafka:
+		return "Kafka"
 	default:
 		return "unknown"
 	}
@@ -153,12 +169,16 @@ func ProtocalNum(p string) L7Protocol {
 		return ProtoAMQP
 	case "postgresql":
 		return ProtoPgsql
+	case "mongodb":
+		return ProtoMongoDB
+	case "kafka":
+		return ProtoKafka
 	default:
 		return ProtoUnknown
 	}
 }
 
-var AllProtos = []L7Protocol{ProtoHTTP, ProtoHTTP2, ProtoGRPC, ProtoMySQL, ProtoRedis, ProtoPgsql, ProtoAMQP}
+var AllProtos = []L7Protocol{ProtoHTTP, ProtoHTTP2, ProtoGRPC, ProtoMySQL, ProtoRedis, ProtoPgsql, ProtoAMQP, ProtoMongoDB, ProtoKafka}
 
 type ProtoMeta struct {
 	TraceID      spanid.ID128
@@ -172,6 +192,8 @@ type ProtoMeta struct {
 
 	// req, resp ;; sys_thread, user_thread
 	Threads [2][2]int32
+
+	Payload PayloadSample
 }
 
 type ProtoData struct {
@@ -215,5 +237,11 @@ func Init() {
 
 	_protoSet.RegisterDetector(ProtoPgsql, PgsqlProtoDetect)
 
+	_protoSet.RegisterDetector(ProtoMongoDB, MongoProtoDetect)
+
+	_protoSet.RegisterDetector(ProtoKafka, KafkaProtoDetect)
+
 	_protoSet.RegisterAggregator(ProtoHTTP, newHTTPAggP)
+	// the gRPC is detected as HTTP2
+	_protoSet.RegisterAggregator(ProtoHTTP2, newHTTPAggP)
 }
@@ -112,6 +112,7 @@ func (dec *redisDecPipe) Decode(txRx comm.NICDirection, data *comm.NetwrkData,
 		}
 
 		inf.meta.ReqTCPSeq = data.TCPSeq
+		inf.meta.Payload.req(ProtoRedis, data.Payload)
 		inf.dur[0] = data.TS
 		inf.ktime[0] = data.TSTail
 
@@ -120,6 +121,7 @@ func (dec *redisDecPipe) Decode(txRx comm.NICDirection, data *comm.NetwrkData,
 
 		inf.statusCode = statusOK
 		inf.meta.RespTCPSeq = data.TCPSeq
+		inf.meta.Payload.resp(ProtoRedis, data.Payload)
 		inf.meta.Threads[1] = data.Thread
 
 		if len(inf.payload) != 0 {
@@ -86,7 +86,11 @@ func kv2point(key *aggKey, value *aggValue, pTime time.Time,
 
 	if key.DNATAddr != "" && key.DNATPort != 0 {
 		tags["dst_nat_ip"] = key.DNATAddr
-		tags["dst_nat_port"] = strconv.FormatInt(int64(key.DNATPort), 10)
+		if key.DNATPort == math.MaxUint32 {
+			tags["dst_nat_port"] = "*"
+		} else {
+			tags["dst_nat_port"] = strconv.FormatInt(int64(key.DNATPort), 10)
+		}
 	} else {
 		tags["dst_nat_ip"] = NoValue
 		tags["dst_nat_port"] = NoValue
@@ -147,6 +151,13 @@ func kv2point(key *aggKey, value *aggValue, pTime time.Time,
 
 type FlowAgg struct {
 	data map[aggKey]*aggValue
+	nat  *natResolver
+
+	// the traffic per process, nil if disabled
+	proc *ProcAgg
+
+	// the rtt histogram per k8s service, nil if disabled
+	svc *SvcAgg
 }
 
 func (agg *FlowAgg) Len() int {
@@ -162,6 +173,12 @@ func (agg *FlowAgg) Append(info ConnectionInfo, stats ConnFullStats) error {
 		agg.data = map[aggKey]*aggValue{}
 	}
 
+	agg.nat.resolve(&info)
+
+	if agg.proc != nil {
+		agg.proc.Append(&info, &stats)
+	}
+
 	var key aggKey
 
 	// family
@@ -228,11 +245,19 @@ func (agg *FlowAgg) Append(info ConnectionInfo, stats ConnFullStats) error {
 		if IsEphemeralPort(key.DPort) {
 			key.DPort = math.MaxUint32
 		}
+		// the real port of the masqueraded client
+		if IsEphemeralPort(key.DNATPort) {
+			key.DNATPort = math.MaxUint32
+		}
 	}
 
 	// pid
 	key.pid = int(info.Pid)
 
+	if agg.svc != nil {
+		agg.svc.Append(&key.BaseKey, key.direction, &stats)
+	}
+
 	var value *aggValue
 	// agg latency and count ++
 	if v, ok := agg.data[key]; ok {
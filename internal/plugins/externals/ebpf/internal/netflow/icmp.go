@@ -0,0 +1,220 @@
+//go:build linux
+// +build linux
+
+package netflow
+
+import (
+	"strconv"
+	"time"
+	"unsafe"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/cilium/ebpf"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/internal/sysmonitor"
+)
+
+// #include "../c/netflow/conn_stats.h"
+import "C"
+
+const (
+	transportICMP = "icmp"
+
+	bpfMapICMPEchoStats = "bpfmap_icmp_echo_stats"
+)
+
+type ICMPEchoKeyC C.struct_icmp_echo_key
+
+type ICMPEchoStatsC C.struct_icmp_echo_stats
+
+// ICMPEchoKey is the peer of the echo requests sent by the ping socket of
+// the process.
+type ICMPEchoKey struct {
+	Daddr [4]uint32
+	Pid   uint32
+	Netns uint32
+	Meta  uint32
+}
+
+type ICMPEchoStats struct {
+	Requests  uint64
+	Replies   uint64
+	SentBytes uint64
+	RecvBytes uint64
+
+	RttTotal uint64 // ns
+	RttCount uint64
+
+	Timestamp uint64
+}
+
+func (s ICMPEchoStats) sub(last ICMPEchoStats) ICMPEchoStats {
+	return ICMPEchoStats{
+		Requests:  s.Requests - last.Requests,
+		Replies:   s.Replies - last.Replies,
+		SentBytes: s.SentBytes - last.SentBytes,
+		RecvBytes: s.RecvBytes - last.RecvBytes,
+		RttTotal:  s.RttTotal - last.RttTotal,
+		RttCount:  s.RttCount - last.RttCount,
+		Timestamp: s.Timestamp,
+	}
+}
+
+// icmpEchoRecord keeps the counters of the previous cycle, the counters in
+// the bpf map are cumulative.
+type icmpEchoRecord struct {
+	last map[ICMPEchoKey]ICMPEchoStats
+}
+
+// update returns the statistics of the current cycle, expired is true if no
+// echo is sent or received in the idle timeout.
+func (r *icmpEchoRecord) update(key ICMPEchoKey, cur ICMPEchoStats,
+	uptime, idleTimeout time.Duration,
+) (delta ICMPEchoStats, expired bool) {
+	if r.last == nil {
+		r.last = map[ICMPEchoKey]ICMPEchoStats{}
+	}
+
+	delta = cur.sub(r.last[key])
+	if delta.Requests == 0 && delta.Replies == 0 &&
+		uptime-time.Duration(cur.Timestamp) > idleTimeout {
+		delete(r.last, key)
+		return delta, true
+	}
+
+	r.last[key] = cur
+	return delta, false
+}
+
+// collect reads the map and returns the statistics of the current cycle, the
+// expired entries are deleted from the map.
+func (r *icmpEchoRecord) collect(m *ebpf.Map, uptime, idleTimeout time.Duration) map[ICMPEchoKey]ICMPEchoStats {
+	var keyC ICMPEchoKeyC
+	var statsC ICMPEchoStatsC
+
+	result := map[ICMPEchoKey]ICMPEchoStats{}
+	var expired []ICMPEchoKeyC
+
+	iter := m.Iterate()
+	for iter.Next(unsafe.Pointer(&keyC), unsafe.Pointer(&statsC)) { //nolint:gosec
+		key := ICMPEchoKey{
+			Daddr: (*(*[4]uint32)(unsafe.Pointer(&keyC.daddr))), //nolint:gosec
+			Pid:   uint32(keyC.pid),
+			Netns: uint32(keyC.netns),
+			Meta:  uint32(keyC.meta),
+		}
+		cur := ICMPEchoStats{
+			Requests:  uint64(statsC.requests),
+			Replies:   uint64(statsC.replies),
+			SentBytes: uint64(statsC.sent_bytes),
+			RecvBytes: uint64(statsC.recv_bytes),
+			RttTotal:  uint64(statsC.rtt_total),
+			RttCount:  uint64(statsC.rtt_count),
+			Timestamp: uint64(statsC.timestamp),
+		}
+
+		delta, exp := r.update(key, cur, uptime, idleTimeout)
+		if exp {
+			expired = append(expired, keyC)
+			continue
+		}
+		if delta.Requests == 0 && delta.Replies == 0 {
+			continue
+		}
+		result[key] = delta
+	}
+	if err := iter.Err(); err != nil {
+		l.Debug(err)
+	}
+
+	for i := range expired {
+		if err := m.Delete(unsafe.Pointer(&expired[i])); err != nil { //nolint:gosec
+			l.Debug(err)
+		}
+	}
+
+	return result
+}
+
+// icmpEchoPoints converts the echo statistics to the netflow points with the
+// transport icmp, the rtt is in microseconds the same as the tcp rtt.
+func icmpEchoPoints(data map[ICMPEchoKey]ICMPEchoStats, addTags map[string]string,
+	procName func(pid int) string, pTime time.Time,
+) []*point.Point {
+	var result []*point.Point
+
+	for k, v := range data {
+		isV6 := !ConnAddrIsIPv4(k.Meta)
+		dstIP := U32BEToIP(k.Daddr, isV6).String()
+
+		tags := map[string]string{
+			"direction": DirectionOutgoing,
+			"transport": transportICMP,
+			"dst_ip":    dstIP,
+			"pid":       strconv.FormatInt(int64(k.Pid), 10),
+			"netns":     strconv.FormatUint(uint64(k.Netns), 10),
+		}
+		if isV6 {
+			tags["family"] = "IPv6"
+			tags["dst_ip_type"] = ConnIPv6Type(k.Daddr)
+		} else {
+			tags["family"] = "IPv4"
+			tags["dst_ip_type"] = ConnIPv4Type(k.Daddr[3])
+		}
+
+		if procName != nil {
+			tags["process_name"] = procName(int(k.Pid))
+		}
+		if tags["process_name"] == "" {
+			tags["process_name"] = NoValue
+		}
+		if dnsRecord != nil {
+			tags["dst_domain"] = dnsRecord.LookupAddr(dstIP)
+		}
+
+		for tk, tv := range addTags {
+			if _, ok := tags[tk]; !ok {
+				tags[tk] = tv
+			}
+		}
+
+		var rtt int64
+		if v.RttCount > 0 {
+			rtt = int64(v.RttTotal/v.RttCount) / 1000
+		}
+
+		fields := map[string]any{
+			"bytes_read":          int64(v.RecvBytes),
+			"bytes_written":       int64(v.SentBytes),
+			"icmp_echo_requests":  int64(v.Requests),
+			"icmp_echo_replies":   int64(v.Replies),
+			"icmp_echo_lost_rate": icmpLostRate(v),
+			"rtt":                 rtt,
+		}
+
+		kvs := point.NewTags(tags)
+		kvs = append(kvs, point.NewKVs(fields)...)
+		result = append(result, point.NewPointV2(srcNameM, kvs, append(
+			point.CommonLoggingOptions(), point.WithTime(pTime))...))
+	}
+
+	return result
+}
+
+func icmpLostRate(v ICMPEchoStats) float64 {
+	if v.Requests == 0 || v.Replies >= v.Requests {
+		return 0
+	}
+	return float64(v.Requests-v.Replies) * 100 / float64(v.Requests)
+}
+
+func procNameFn(procFilter *sysmonitor.ProcessFilter) func(pid int) string {
+	return func(pid int) string {
+		if procFilter == nil {
+			return ""
+		}
+		if v, ok := procFilter.GetProcInfo(pid); ok {
+			return v.Name()
+		}
+		return ""
+	}
+}
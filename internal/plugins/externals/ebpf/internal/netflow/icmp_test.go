@@ -0,0 +1,65 @@
+//go:build linux
+// +build linux
+
+package netflow
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestICMPEchoRecord(t *testing.T) {
+	r := &icmpEchoRecord{}
+	key := ICMPEchoKey{Daddr: [4]uint32{0, 0, 0, 0x04030201}, Pid: 100}
+
+	cur := ICMPEchoStats{Requests: 4, Replies: 3, RttTotal: 3000000, RttCount: 3, Timestamp: uint64(10 * time.Second)}
+	delta, expired := r.update(key, cur, 11*time.Second, time.Minute)
+	assert.False(t, expired)
+	assert.Equal(t, uint64(4), delta.Requests)
+	assert.Equal(t, uint64(3), delta.Replies)
+
+	cur.Requests, cur.Replies = 6, 6
+	delta, expired = r.update(key, cur, 12*time.Second, time.Minute)
+	assert.False(t, expired)
+	assert.Equal(t, uint64(2), delta.Requests)
+	assert.Equal(t, uint64(3), delta.Replies)
+
+	// no echo in the idle timeout
+	_, expired = r.update(key, cur, 2*time.Minute, time.Minute)
+	assert.True(t, expired)
+	assert.Empty(t, r.last)
+}
+
+func TestICMPEchoPoints(t *testing.T) {
+	key := ICMPEchoKey{Daddr: [4]uint32{0, 0, 0, 0x04030201}, Pid: 100, Meta: ConnL3IPv4}
+	data := map[ICMPEchoKey]ICMPEchoStats{
+		key: {Requests: 4, Replies: 3, SentBytes: 256, RecvBytes: 192, RttTotal: 3000000, RttCount: 3},
+	}
+
+	pts := icmpEchoPoints(data, map[string]string{"host": "h1", "transport": "x"},
+		func(int) string { return "ping" }, time.Now())
+	if assert.Len(t, pts, 1) {
+		pt := pts[0]
+		assert.Equal(t, "icmp", pt.Get("transport"))
+		assert.Equal(t, "1.2.3.4", pt.Get("dst_ip"))
+		assert.Equal(t, "IPv4", pt.Get("family"))
+		assert.Equal(t, "ping", pt.Get("process_name"))
+		assert.Equal(t, "h1", pt.Get("host"))
+		assert.Equal(t, int64(256), pt.Get("bytes_written"))
+		assert.Equal(t, int64(4), pt.Get("icmp_echo_requests"))
+		assert.Equal(t, 25.0, pt.Get("icmp_echo_lost_rate"))
+		assert.Equal(t, int64(1000), pt.Get("rtt"))
+	}
+}
+
+func TestConnExpired(t *testing.T) {
+	defer SetFlowIdleTimeout(2 * time.Minute)
+	SetFlowIdleTimeout(time.Minute)
+
+	ts := uint64(10 * time.Second)
+	assert.False(t, connExpired(ConnL4UDP, 60, ts))
+	assert.True(t, connExpired(ConnL4UDP, 80, ts))
+	assert.False(t, connExpired(ConnL4TCP, 80, ts))
+}
@@ -0,0 +1,130 @@
+//go:build linux
+// +build linux
+
+package netflow
+
+import (
+	"github.com/cilium/ebpf"
+)
+
+const bpfMapConntrackTuple = "bpfmap_conntrack_tuple"
+
+// Same as struct 'nf_origin_tuple' and 'nf_reply_tuple' in
+// internal/plugins/externals/ebpf/internal/c/conntrack/utils.h.
+type (
+	ctOriginTuple struct {
+		SrcIP   [4]uint32
+		DstIP   [4]uint32
+		SrcPort uint16
+		DstPort uint16
+		NetNS   uint32
+	}
+
+	ctReplyTuple struct {
+		TS      uint64
+		SrcIP   [4]uint32
+		DstIP   [4]uint32
+		SrcPort uint16
+		DstPort uint16
+		NetNS   uint32
+	}
+)
+
+type natTuple struct {
+	srcIP, dstIP     [4]uint32
+	srcPort, dstPort uint32
+}
+
+// natResolver indexes the conntrack tuples regardless of the netns. The NAT
+// of kube-proxy and docker is done in the host netns, but the sockets of the
+// pods are in their own netns, so the tuples are not found by the kernel.
+type natResolver struct {
+	ctMap *ebpf.Map
+
+	// origin -> reply, reply -> origin
+	origin map[natTuple]natTuple
+	reply  map[natTuple]natTuple
+}
+
+func newNATResolver(ctMap *ebpf.Map) *natResolver {
+	return &natResolver{
+		ctMap:  ctMap,
+		origin: map[natTuple]natTuple{},
+		reply:  map[natTuple]natTuple{},
+	}
+}
+
+func (r *natResolver) add(origin, reply natTuple) {
+	r.origin[origin] = reply
+	r.reply[reply] = origin
+}
+
+// refresh reloads the tuples from the bpf map.
+func (r *natResolver) refresh() {
+	if r == nil || r.ctMap == nil {
+		return
+	}
+
+	r.origin = make(map[natTuple]natTuple, len(r.origin))
+	r.reply = make(map[natTuple]natTuple, len(r.reply))
+
+	var k ctOriginTuple
+	var v ctReplyTuple
+	iter := r.ctMap.Iterate()
+	for iter.Next(&k, &v) {
+		r.add(natTuple{
+			srcIP: k.SrcIP, dstIP: k.DstIP,
+			srcPort: uint32(k.SrcPort), dstPort: uint32(k.DstPort),
+		}, natTuple{
+			srcIP: v.SrcIP, dstIP: v.DstIP,
+			srcPort: uint32(v.SrcPort), dstPort: uint32(v.DstPort),
+		})
+	}
+	if err := iter.Err(); err != nil {
+		l.Debug(err)
+	}
+}
+
+// resolve sets the real address of the peer if the connection is translated:
+// the server after DNAT of the client side, or the client before SNAT(masquerade)
+// of the server side.
+func (r *natResolver) resolve(info *ConnectionInfo) {
+	if r == nil || len(r.origin) == 0 {
+		return
+	}
+
+	// resolved by the kernel
+	if info.NATDport != 0 && (info.NATDaddr[0]|
+		info.NATDaddr[1]|info.NATDaddr[2]|info.NATDaddr[3]) != 0 {
+		return
+	}
+
+	conn := natTuple{
+		srcIP: unmapIPv4(info.Saddr), dstIP: unmapIPv4(info.Daddr),
+		srcPort: info.Sport, dstPort: info.Dport,
+	}
+
+	// the client side, the reply is sent by the real server
+	if reply, ok := r.origin[conn]; ok {
+		if reply.srcIP != conn.dstIP || reply.srcPort != conn.dstPort {
+			info.NATDaddr, info.NATDport = reply.srcIP, reply.srcPort
+		}
+		return
+	}
+
+	// the server side, the connection is initiated by the real client
+	if origin, ok := r.reply[conn]; ok {
+		if origin.srcIP != conn.dstIP || origin.srcPort != conn.dstPort {
+			info.NATDaddr, info.NATDport = origin.srcIP, origin.srcPort
+		}
+	}
+}
+
+// unmapIPv4 converts the IPv4-mapped IPv6 address to the IPv4 address
+// used by conntrack.
+func unmapIPv4(addr [4]uint32) [4]uint32 {
+	if addr[0] == 0 && addr[1] == 0 && addr[2] == 0xffff0000 {
+		addr[2] = 0
+	}
+	return addr
+}
@@ -0,0 +1,82 @@
+//go:build linux
+// +build linux
+
+package netflow
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func natIPv4(s string) [4]uint32 {
+	ip := net.ParseIP(s).To4()
+	return [4]uint32{0, 0, 0, uint32(ip[0]) | uint32(ip[1])<<8 | uint32(ip[2])<<16 | uint32(ip[3])<<24}
+}
+
+func TestNATResolver(t *testing.T) {
+	var (
+		client = natIPv4("10.0.1.5")
+		svc    = natIPv4("10.96.0.10")
+		pod    = natIPv4("10.0.2.8")
+		node   = natIPv4("192.168.1.2")
+	)
+
+	r := newNATResolver(nil)
+
+	// client pod -> service, DNAT to the server pod
+	r.add(natTuple{srcIP: client, dstIP: svc, srcPort: 40000, dstPort: 80},
+		natTuple{srcIP: pod, dstIP: client, srcPort: 8080, dstPort: 40000})
+
+	// external client -> node port, DNAT and masqueraded
+	r.add(natTuple{srcIP: natIPv4("172.16.0.9"), dstIP: node, srcPort: 50000, dstPort: 30080},
+		natTuple{srcIP: pod, dstIP: node, srcPort: 8080, dstPort: 61000})
+
+	t.Run("client", func(t *testing.T) {
+		info := ConnectionInfo{Saddr: client, Daddr: svc, Sport: 40000, Dport: 80}
+		r.resolve(&info)
+		assert.Equal(t, pod, info.NATDaddr)
+		assert.Equal(t, uint32(8080), info.NATDport)
+	})
+
+	t.Run("ipv4 mapped", func(t *testing.T) {
+		mapped := func(a [4]uint32) [4]uint32 {
+			a[2] = 0xffff0000
+			return a
+		}
+		info := ConnectionInfo{Saddr: mapped(client), Daddr: mapped(svc), Sport: 40000, Dport: 80}
+		r.resolve(&info)
+		assert.Equal(t, pod, info.NATDaddr)
+	})
+
+	t.Run("server not masqueraded", func(t *testing.T) {
+		info := ConnectionInfo{Saddr: pod, Daddr: client, Sport: 8080, Dport: 40000}
+		r.resolve(&info)
+		assert.Equal(t, uint32(0), info.NATDport)
+	})
+
+	t.Run("server masqueraded", func(t *testing.T) {
+		info := ConnectionInfo{Saddr: pod, Daddr: node, Sport: 8080, Dport: 61000}
+		r.resolve(&info)
+		assert.Equal(t, natIPv4("172.16.0.9"), info.NATDaddr)
+		assert.Equal(t, uint32(50000), info.NATDport)
+	})
+
+	t.Run("resolved by kernel", func(t *testing.T) {
+		info := ConnectionInfo{
+			Saddr: client, Daddr: svc, Sport: 40000, Dport: 80,
+			NATDaddr: natIPv4("10.0.3.3"), NATDport: 9090,
+		}
+		r.resolve(&info)
+		assert.Equal(t, natIPv4("10.0.3.3"), info.NATDaddr)
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		var nilR *natResolver
+		info := ConnectionInfo{Saddr: client, Daddr: svc, Sport: 40000, Dport: 80}
+		nilR.refresh()
+		nilR.resolve(&info)
+		assert.Equal(t, uint32(0), info.NATDport)
+	})
+}
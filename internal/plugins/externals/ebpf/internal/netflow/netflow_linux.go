@@ -37,6 +37,35 @@ func SetEnableUDP(on bool) {
 	enableUDP = on
 }
 
+var enableSvcHistogram bool
+
+// SetEnableSvcHistogram enables the rtt histogram and the retransmits of the
+// tcp connections per k8s service, it requires the k8s info.
+func SetEnableSvcHistogram(on bool) {
+	enableSvcHistogram = on
+}
+
+// flowIdleTimeout is the idle timeout of the UDP flows and ICMP echo, they
+// are removed from the bpf maps after the timeout, since there is no close
+// event of the UDP flows unless the socket is destroyed.
+var flowIdleTimeout = 2 * time.Minute
+
+func SetFlowIdleTimeout(d time.Duration) {
+	if d > 0 {
+		flowIdleTimeout = d
+	}
+}
+
+// connExpired returns true if the connection is idle for a long time, uptime
+// is in seconds and ts is the ktime in nanoseconds.
+func connExpired(meta uint32, uptime, ts uint64) bool {
+	idle := time.Duration(uptime)*time.Second - time.Duration(ts)
+	if ConnProtocolIsTCP(meta) {
+		return idle > connExpirationInterval*time.Second
+	}
+	return idle > flowIdleTimeout
+}
+
 type NetFlowTracer struct {
 	connStatsRecord *ConnStatsRecord
 	resultCh        chan *ConnResult
@@ -66,7 +95,19 @@ func (tracer *NetFlowTracer) Run(ctx context.Context, bpfManger *manager.Manager
 		return err
 	}
 
-	go tracer.connCollectHanllder(ctx, connStatsMap, tcpStatsMap,
+	// the map is empty if the ebpf-conntrack plugin is not enabled
+	var nat *natResolver
+	if ctMap, found, err := bpfManger.GetMap(bpfMapConntrackTuple); err == nil && found {
+		nat = newNATResolver(ctMap)
+	}
+
+	// the map is empty if the ping probes are not attached
+	icmpMap, found, err := bpfManger.GetMap(bpfMapICMPEchoStats)
+	if err != nil || !found {
+		icmpMap = nil
+	}
+
+	go tracer.connCollectHanllder(ctx, connStatsMap, tcpStatsMap, icmpMap, nat,
 		interval, gTags)
 	return nil
 }
@@ -135,19 +176,25 @@ func (tracer *NetFlowTracer) bpfMapCleanup(cl []ConnectionInfo, connStatsMap *eb
 const KernelTaskCommLen = 16
 
 // Lock resource connStatsRecord while scanning connStatMap.
-func (tracer *NetFlowTracer) connCollectHanllder(ctx context.Context, connStatsMap, tcpStatsMap *ebpf.Map,
-	interval time.Duration, gTags map[string]string,
+func (tracer *NetFlowTracer) connCollectHanllder(ctx context.Context, connStatsMap, tcpStatsMap, icmpMap *ebpf.Map,
+	nat *natResolver, interval time.Duration, gTags map[string]string,
 ) {
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
-	agg := FlowAgg{}
+	agg := FlowAgg{nat: nat, proc: &ProcAgg{}}
+	if enableSvcHistogram && k8sNetInfo != nil {
+		agg.svc = &SvcAgg{lookup: k8sSvcLookup(k8sNetInfo)}
+	}
+	icmp := &icmpEchoRecord{}
 
 	for {
 		select {
 		case event := <-tracer.closedEventCh:
 			tracer.connStatsRecord.updateClosedUseEvent(event)
 		case <-ticker.C:
+			nat.refresh()
+
 			var connInfoC ConnectionInfoC
 
 			var connStatsC ConnectionStatsC
@@ -219,7 +266,7 @@ func (tracer *NetFlowTracer) connCollectHanllder(ctx context.Context, connStatsM
 					connInfoC.pid = pid
 				}
 				connFullStats = tracer.connStatsRecord.mergeWithClosedLastActive(connInfo, connFullStats)
-				if int(uptime)-int(connFullStats.Stats.Timestamp/1000000000) > connExpirationInterval {
+				if connExpired(connInfo.Meta, uptime, connFullStats.Stats.Timestamp) {
 					if connFullStats.TotalClosed == 0 && connFullStats.TotalEstablished == 0 &&
 						connFullStats.Stats.RecvBytes == 0 && connFullStats.Stats.SentBytes == 0 {
 						connsNeedCleanup = append(connsNeedCleanup, connInfo)
@@ -247,8 +294,20 @@ func (tracer *NetFlowTracer) connCollectHanllder(ctx context.Context, connStatsM
 			tracer.connStatsRecord.clearClosedConnsCache()
 
 			pts := agg.ToPoint(gTags, k8sNetInfo)
+			if icmpMap != nil {
+				pts = append(pts, icmpEchoPoints(
+					icmp.collect(icmpMap, time.Duration(uptime)*time.Second, flowIdleTimeout),
+					gTags, procNameFn(tracer.procFilter), time.Now())...)
+			}
+			procPts := agg.proc.ToPoint(gTags)
+			if agg.svc != nil {
+				procPts = append(procPts, agg.svc.ToPoint(gTags)...)
+				agg.svc.Clean()
+			}
 			agg.Clean()
+			agg.proc.Clean()
 			tracer.feedHandler(inputName, point.Network, pts)
+			tracer.feedHandler(inputName, point.Metric, procPts)
 		case <-ctx.Done():
 			return
 		}
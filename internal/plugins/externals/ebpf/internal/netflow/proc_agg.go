@@ -0,0 +1,132 @@
+//go:build linux
+// +build linux
+
+package netflow
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/internal/sysmonitor"
+)
+
+const srcNameProcM = "netflow_process"
+
+type procAggKey struct {
+	pid         int
+	processName string
+}
+
+type procAggValue struct {
+	bytesRead      int64
+	bytesWritten   int64
+	packetsRead    int64
+	packetsWritten int64
+
+	retransmits    int64
+	tcpClosed      int64
+	tcpEstablished int64
+
+	connections int
+}
+
+// ProcAgg aggregates the traffic of the connections by the process, the
+// tags pid, process_name and container_id are the same as the host process
+// input, so the points can be joined with the process metrics.
+type ProcAgg struct {
+	data map[procAggKey]*procAggValue
+
+	// cache the container id until the process exits
+	containerID map[int]string
+}
+
+func (agg *ProcAgg) Append(info *ConnectionInfo, stats *ConnFullStats) {
+	// the pid is unknown
+	if info.Pid == 0 {
+		return
+	}
+
+	if agg.data == nil {
+		agg.data = map[procAggKey]*procAggValue{}
+	}
+
+	key := procAggKey{
+		pid:         int(info.Pid),
+		processName: info.ProcessName,
+	}
+
+	v, ok := agg.data[key]
+	if !ok {
+		v = &procAggValue{}
+		agg.data[key] = v
+	}
+
+	v.connections++
+	v.bytesRead += int64(stats.Stats.RecvBytes)
+	v.bytesWritten += int64(stats.Stats.SentBytes)
+	v.packetsRead += int64(stats.Stats.RecvPackets)
+	v.packetsWritten += int64(stats.Stats.SentPackets)
+
+	if ConnProtocolIsTCP(info.Meta) {
+		v.retransmits += int64(stats.TCPStats.Retransmits)
+		v.tcpClosed += stats.TotalClosed
+		v.tcpEstablished += stats.TotalEstablished
+	}
+}
+
+func (agg *ProcAgg) ToPoint(addTags map[string]string) []*point.Point {
+	var result []*point.Point
+
+	if agg.containerID == nil {
+		agg.containerID = map[int]string{}
+	}
+
+	pTime := time.Now()
+	active := map[int]string{}
+	for k, v := range agg.data {
+		id, ok := agg.containerID[k.pid]
+		if !ok {
+			id = sysmonitor.ProcContainerID(k.pid)
+		}
+		active[k.pid] = id
+
+		tags := map[string]string{
+			"pid":          strconv.Itoa(k.pid),
+			"process_name": k.processName,
+		}
+		if id != "" {
+			tags["container_id"] = id
+		}
+		for tk, tv := range addTags {
+			if _, ok := tags[tk]; !ok {
+				tags[tk] = tv
+			}
+		}
+
+		fields := map[string]any{
+			"bytes_read":      v.bytesRead,
+			"bytes_written":   v.bytesWritten,
+			"packets_read":    v.packetsRead,
+			"packets_written": v.packetsWritten,
+			"retransmits":     v.retransmits,
+			"tcp_closed":      v.tcpClosed,
+			"tcp_established": v.tcpEstablished,
+			"connections":     v.connections,
+		}
+
+		kvs := point.NewTags(tags)
+		kvs = append(kvs, point.NewKVs(fields)...)
+		result = append(result, point.NewPointV2(srcNameProcM, kvs, append(
+			point.DefaultMetricOptions(), point.WithTime(pTime))...))
+	}
+
+	// the processes without traffic in the cycle are dropped from the cache
+	agg.containerID = active
+
+	return result
+}
+
+func (agg *ProcAgg) Clean() {
+	agg.data = map[procAggKey]*procAggValue{}
+}
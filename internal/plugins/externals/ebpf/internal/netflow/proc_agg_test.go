@@ -0,0 +1,61 @@
+//go:build linux
+// +build linux
+
+package netflow
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcAgg(t *testing.T) {
+	agg := &ProcAgg{}
+
+	tcp := ConnFullStats{
+		Stats:            ConnectionStats{SentBytes: 100, RecvBytes: 200, SentPackets: 2, RecvPackets: 3},
+		TCPStats:         ConnectionTCPStats{Retransmits: 1},
+		TotalEstablished: 1,
+	}
+	udp := ConnFullStats{
+		Stats:    ConnectionStats{SentBytes: 10, RecvBytes: 20, SentPackets: 1, RecvPackets: 1},
+		TCPStats: ConnectionTCPStats{Retransmits: 5},
+	}
+
+	agg.Append(&ConnectionInfo{Pid: 1000, ProcessName: "nginx", Meta: ConnL3IPv4 | ConnL4TCP}, &tcp)
+	agg.Append(&ConnectionInfo{Pid: 1000, ProcessName: "nginx", Meta: ConnL3IPv4 | ConnL4TCP}, &tcp)
+	agg.Append(&ConnectionInfo{Pid: 1000, ProcessName: "nginx", Meta: ConnL3IPv4 | ConnL4UDP}, &udp)
+	agg.Append(&ConnectionInfo{Pid: 2000, ProcessName: "curl", Meta: ConnL3IPv4 | ConnL4UDP}, &udp)
+	// the pid is unknown
+	agg.Append(&ConnectionInfo{Pid: 0, Meta: ConnL3IPv4 | ConnL4TCP}, &tcp)
+
+	pts := agg.ToPoint(map[string]string{"host": "h1", "pid": "1"})
+	if !assert.Len(t, pts, 2) {
+		return
+	}
+
+	for _, pt := range pts {
+		assert.Equal(t, srcNameProcM, pt.Name())
+		assert.Equal(t, "h1", pt.Get("host"))
+
+		switch pt.Get("pid") {
+		case "1000":
+			assert.Equal(t, "nginx", pt.Get("process_name"))
+			assert.Equal(t, int64(210), pt.Get("bytes_written"))
+			assert.Equal(t, int64(420), pt.Get("bytes_read"))
+			assert.Equal(t, int64(7), pt.Get("packets_read"))
+			assert.Equal(t, int64(2), pt.Get("retransmits"))
+			assert.Equal(t, int64(2), pt.Get("tcp_established"))
+			assert.Equal(t, int64(3), pt.Get("connections"))
+		case "2000":
+			assert.Equal(t, "curl", pt.Get("process_name"))
+			assert.Equal(t, int64(0), pt.Get("retransmits"))
+		default:
+			t.Errorf("unexpected pid %v", pt.Get("pid"))
+		}
+	}
+
+	agg.Clean()
+	assert.Empty(t, agg.ToPoint(nil))
+	assert.Empty(t, agg.containerID)
+}
@@ -0,0 +1,197 @@
+//go:build linux
+// +build linux
+
+package netflow
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/internal/k8sinfo"
+)
+
+const srcNameSvcM = "netflow_service"
+
+// rttBuckets are the upper bounds(us) of the rtt histogram, the same as the
+// le of the prometheus histogram, the last bucket +Inf is implied.
+var rttBuckets = []int64{
+	100, 250, 500,
+	1000, 2500, 5000,
+	10000, 25000, 50000,
+	100000, 250000, 500000,
+	1000000,
+}
+
+type svcLabels struct {
+	namespace  string
+	service    string
+	pod        string
+	deployment string
+}
+
+// svcLookup returns the k8s labels of the endpoint, false if the endpoint is
+// not a pod or a service.
+type svcLookup func(ip string, port uint32, transport string) (svcLabels, bool)
+
+func k8sSvcLookup(k8sNetInfo *k8sinfo.K8sNetInfo) svcLookup {
+	if k8sNetInfo == nil {
+		return nil
+	}
+
+	return func(ip string, port uint32, transport string) (svcLabels, bool) {
+		if pod, svc, ns, dp, err := k8sNetInfo.QueryPodInfo(ip, port, transport); err == nil {
+			return svcLabels{namespace: ns, service: svc, pod: pod, deployment: dp}, true
+		}
+		if svc, ns, dp, err := k8sNetInfo.QuerySvcInfo(ip, port, transport); err == nil {
+			return svcLabels{namespace: ns, service: svc, deployment: dp}, true
+		}
+		return svcLabels{}, false
+	}
+}
+
+type svcAggKey struct {
+	svcLabels
+	direction string
+}
+
+type svcAggValue struct {
+	// the counts of the buckets are not cumulative, the last is +Inf
+	rttBuckets []int64
+	rttSum     int64
+	rttCount   int64
+
+	retransmits          int64
+	retransmitConnection int64
+
+	connections int64
+}
+
+// SvcAgg aggregates the rtt and the retransmits of the tcp connections by the
+// k8s service and pod of the server side, the rtt histogram is reported as
+// the prometheus histogram (field rtt_bucket with tag le).
+//
+// For the incoming connections the server is the local pod, for the outgoing
+// connections the server is the pod after DNAT, or the service if the DNAT
+// is not resolved.
+type SvcAgg struct {
+	data   map[svcAggKey]*svcAggValue
+	lookup svcLookup
+}
+
+func (agg *SvcAgg) Append(key *BaseKey, direction string, stats *ConnFullStats) {
+	if agg.lookup == nil || key.Transport != transportTCP {
+		return
+	}
+
+	var (
+		labels svcLabels
+		ok     bool
+	)
+	switch direction {
+	case DirectionIncoming:
+		labels, ok = agg.lookup(key.SAddr, key.SPort, key.Transport)
+	default:
+		if key.DNATAddr != "" && key.DNATPort != 0 && key.DNATPort != math.MaxUint32 {
+			labels, ok = agg.lookup(key.DNATAddr, key.DNATPort, key.Transport)
+		}
+		if !ok {
+			labels, ok = agg.lookup(key.DAddr, key.DPort, key.Transport)
+		}
+	}
+	if !ok {
+		return
+	}
+
+	if agg.data == nil {
+		agg.data = map[svcAggKey]*svcAggValue{}
+	}
+
+	k := svcAggKey{svcLabels: labels, direction: direction}
+	v, ok := agg.data[k]
+	if !ok {
+		v = &svcAggValue{rttBuckets: make([]int64, len(rttBuckets)+1)}
+		agg.data[k] = v
+	}
+
+	// the rtt is not sampled yet
+	if rtt := int64(stats.TCPStats.Rtt); rtt > 0 {
+		i := 0
+		for ; i < len(rttBuckets); i++ {
+			if rtt <= rttBuckets[i] {
+				break
+			}
+		}
+		v.rttBuckets[i]++
+		v.rttSum += rtt
+		v.rttCount++
+	}
+
+	if stats.TCPStats.Retransmits > 0 {
+		v.retransmits += int64(stats.TCPStats.Retransmits)
+		v.retransmitConnection++
+	}
+	v.connections++
+}
+
+func noValue(s string) string {
+	if s == "" {
+		return NoValue
+	}
+	return s
+}
+
+func (agg *SvcAgg) ToPoint(addTags map[string]string) []*point.Point {
+	var result []*point.Point
+
+	pTime := time.Now()
+	opts := append(point.DefaultMetricOptions(), point.WithTime(pTime))
+
+	for k, v := range agg.data {
+		tags := map[string]string{
+			"k8s_namespace":       noValue(k.namespace),
+			"k8s_service_name":    noValue(k.service),
+			"k8s_pod_name":        noValue(k.pod),
+			"k8s_deployment_name": noValue(k.deployment),
+			"direction":           k.direction,
+		}
+		for tk, tv := range addTags {
+			if _, ok := tags[tk]; !ok {
+				tags[tk] = tv
+			}
+		}
+
+		fields := map[string]any{
+			"rtt_sum":                v.rttSum,
+			"rtt_count":              v.rttCount,
+			"retransmits":            v.retransmits,
+			"retransmit_connections": v.retransmitConnection,
+			"connections":            v.connections,
+		}
+		kvs := point.NewTags(tags)
+		kvs = append(kvs, point.NewKVs(fields)...)
+		result = append(result, point.NewPointV2(srcNameSvcM, kvs, opts...))
+
+		var cumulative int64
+		for i, n := range v.rttBuckets {
+			cumulative += n
+
+			le := "+Inf"
+			if i < len(rttBuckets) {
+				le = strconv.FormatInt(rttBuckets[i], 10)
+			}
+
+			kvs := point.NewTags(tags)
+			kvs = kvs.AddTag("le", le)
+			kvs = kvs.Add("rtt_bucket", cumulative, false, false)
+			result = append(result, point.NewPointV2(srcNameSvcM, kvs, opts...))
+		}
+	}
+
+	return result
+}
+
+func (agg *SvcAgg) Clean() {
+	agg.data = map[svcAggKey]*svcAggValue{}
+}
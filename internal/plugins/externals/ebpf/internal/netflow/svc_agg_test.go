@@ -0,0 +1,85 @@
+//go:build linux
+// +build linux
+
+package netflow
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSvcAgg(t *testing.T) {
+	agg := &SvcAgg{
+		lookup: func(ip string, port uint32, transport string) (svcLabels, bool) {
+			switch ip {
+			case "10.0.0.1":
+				return svcLabels{namespace: "default", service: "web", pod: "web-0"}, true
+			case "10.96.0.10":
+				return svcLabels{namespace: "default", service: "db"}, true
+			}
+			return svcLabels{}, false
+		},
+	}
+
+	// the local pod web-0 is the server
+	in := BaseKey{SAddr: "10.0.0.1", SPort: 80, DAddr: "10.0.0.9", DPort: math.MaxUint32, Transport: transportTCP}
+	agg.Append(&in, DirectionIncoming, &ConnFullStats{TCPStats: ConnectionTCPStats{Rtt: 80}})
+	agg.Append(&in, DirectionIncoming, &ConnFullStats{TCPStats: ConnectionTCPStats{Rtt: 3000, Retransmits: 2}})
+	// not sampled
+	agg.Append(&in, DirectionIncoming, &ConnFullStats{})
+
+	// web-0 calls the service db
+	out := BaseKey{SAddr: "10.0.0.1", SPort: math.MaxUint32, DAddr: "10.96.0.10", DPort: 5432, Transport: transportTCP}
+	agg.Append(&out, DirectionOutgoing, &ConnFullStats{TCPStats: ConnectionTCPStats{Rtt: 2000000}})
+
+	// not k8s, or not tcp
+	agg.Append(&BaseKey{SAddr: "1.1.1.1", DAddr: "2.2.2.2", Transport: transportTCP}, DirectionOutgoing, &ConnFullStats{})
+	agg.Append(&BaseKey{SAddr: "10.0.0.1", Transport: transportUDP}, DirectionIncoming, &ConnFullStats{})
+
+	pts := agg.ToPoint(map[string]string{"host": "h1"})
+	// 2 services, the sum/count point and 14 buckets for each
+	assert.Len(t, pts, 2*(1+len(rttBuckets)+1))
+
+	buckets := map[string]map[string]int64{}
+	for _, pt := range pts {
+		assert.Equal(t, srcNameSvcM, pt.Name())
+		assert.Equal(t, "h1", pt.Get("host"))
+
+		svc := pt.Get("k8s_service_name").(string)
+		if le, ok := pt.Get("le").(string); ok {
+			if buckets[svc] == nil {
+				buckets[svc] = map[string]int64{}
+			}
+			buckets[svc][le] = pt.Get("rtt_bucket").(int64)
+			continue
+		}
+
+		switch svc {
+		case "web":
+			assert.Equal(t, DirectionIncoming, pt.Get("direction"))
+			assert.Equal(t, "web-0", pt.Get("k8s_pod_name"))
+			assert.Equal(t, int64(3080), pt.Get("rtt_sum"))
+			assert.Equal(t, int64(2), pt.Get("rtt_count"))
+			assert.Equal(t, int64(2), pt.Get("retransmits"))
+			assert.Equal(t, int64(1), pt.Get("retransmit_connections"))
+			assert.Equal(t, int64(3), pt.Get("connections"))
+		case "db":
+			assert.Equal(t, DirectionOutgoing, pt.Get("direction"))
+			assert.Equal(t, NoValue, pt.Get("k8s_pod_name"))
+		default:
+			t.Errorf("unexpected service %s", svc)
+		}
+	}
+
+	assert.Equal(t, int64(1), buckets["web"]["100"])
+	assert.Equal(t, int64(1), buckets["web"]["2500"])
+	assert.Equal(t, int64(2), buckets["web"]["5000"])
+	assert.Equal(t, int64(2), buckets["web"]["+Inf"])
+	assert.Equal(t, int64(0), buckets["db"]["1000000"])
+	assert.Equal(t, int64(1), buckets["db"]["+Inf"])
+
+	agg.Clean()
+	assert.Empty(t, agg.ToPoint(nil))
+}
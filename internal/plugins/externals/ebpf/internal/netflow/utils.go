@@ -216,6 +216,23 @@ func NewNetFlowManger(constEditor []manager.ConstantEditor, ctMap map[string]*eb
 				ProbeIdentificationPair: manager.ProbeIdentificationPair{
 					EBPFFuncName: "kprobe__udp_destroy_sock",
 				},
+			}, {
+				ProbeIdentificationPair: manager.ProbeIdentificationPair{
+					EBPFFuncName: "kprobe__ping_v4_sendmsg",
+				},
+			}, {
+				ProbeIdentificationPair: manager.ProbeIdentificationPair{
+					EBPFFuncName: "kprobe__ping_v6_sendmsg",
+				},
+			}, {
+				ProbeIdentificationPair: manager.ProbeIdentificationPair{
+					EBPFFuncName: "kprobe__ping_recvmsg",
+				},
+			}, {
+				ProbeIdentificationPair: manager.ProbeIdentificationPair{
+					EBPFFuncName: "kretprobe__ping_recvmsg",
+				},
+				KProbeMaxActive: 128,
 			},
 		},
 		PerfMaps: []*manager.PerfMap{
@@ -238,6 +255,7 @@ func NewNetFlowManger(constEditor []manager.ConstantEditor, ctMap map[string]*eb
 			Max: math.MaxUint64,
 		},
 		ConstantEditors: constEditor,
+		ActivatedProbes: activatedProbes(m.Probes),
 	}
 
 	if ctMap != nil {
@@ -253,6 +271,29 @@ func NewNetFlowManger(constEditor []manager.ConstantEditor, ctMap map[string]*eb
 	return m, nil
 }
 
+// bestEffortProbes are not attached if the functions are not found, such as
+// the ipv6 module is not loaded.
+var bestEffortProbes = map[string]bool{
+	"kprobe__ping_v4_sendmsg": true,
+	"kprobe__ping_v6_sendmsg": true,
+	"kprobe__ping_recvmsg":    true,
+	"kretprobe__ping_recvmsg": true,
+}
+
+func activatedProbes(probes []*manager.Probe) []manager.ProbesSelector {
+	var result []manager.ProbesSelector
+	bestEffort := &manager.BestEffort{}
+	for _, p := range probes {
+		sel := &manager.ProbeSelector{ProbeIdentificationPair: p.ProbeIdentificationPair}
+		if bestEffortProbes[p.EBPFFuncName] {
+			bestEffort.Selectors = append(bestEffort.Selectors, sel)
+		} else {
+			result = append(result, sel)
+		}
+	}
+	return append(result, bestEffort)
+}
+
 func ConvConn2M(k ConnectionInfo, v ConnFullStats, name string,
 	gTags map[string]string, ptTime time.Time, pidMap map[int][2]string,
 ) (*point.Point, error) {
@@ -0,0 +1,135 @@
+//go:build linux
+// +build linux
+
+package profiling
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"syscall"
+
+	"github.com/cilium/ebpf"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/internal/sysmonitor"
+)
+
+// Same as enum 'prof_cgroup_filter' in
+// internal/plugins/externals/ebpf/internal/c/profiling/profiling.h.
+const (
+	cgroupFilterNone  = 0
+	cgroupFilterAllow = 1
+	cgroupFilterDeny  = 2
+)
+
+// cgroupMatcher matches the path of the cgroup (relative to the cgroup root,
+// such as /kubepods.slice/...) by the glob patterns, the pattern also matches
+// all sub cgroups.
+type cgroupMatcher []string
+
+func (m cgroupMatcher) match(cgPath string) bool {
+	for _, pattern := range m {
+		for p := cgPath; p != "/" && p != "."; p = path.Dir(p) {
+			if ok, _ := path.Match(pattern, p); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// cgroupFilter keeps the ids of the cgroups matched by the allow list or
+// the deny list in the bpf map, the id of the cgroup v2 is the inode of
+// the directory.
+type cgroupFilter struct {
+	mode    uint64
+	matcher cgroupMatcher
+
+	root  string
+	bpfm  *ebpf.Map
+	added map[uint64]struct{}
+}
+
+func newCgroupFilter(enabled, disabled []string) *cgroupFilter {
+	f := &cgroupFilter{
+		added: map[uint64]struct{}{},
+	}
+
+	switch {
+	case len(enabled) > 0:
+		f.mode, f.matcher = cgroupFilterAllow, enabled
+	case len(disabled) > 0:
+		f.mode, f.matcher = cgroupFilterDeny, disabled
+	default:
+		return f
+	}
+
+	f.root = cgroupV2Root()
+	if f.root == "" {
+		l.Warn("cgroup v2 not found, the cgroup filter is disabled")
+		f.mode = cgroupFilterNone
+	}
+
+	return f
+}
+
+func cgroupV2Root() string {
+	for _, p := range []string{
+		sysmonitor.HostSys("fs", "cgroup"),
+		sysmonitor.HostSys("fs", "cgroup", "unified"),
+	} {
+		if _, err := os.Stat(filepath.Join(p, "cgroup.controllers")); err == nil {
+			return p
+		}
+	}
+	return ""
+}
+
+// update syncs the ids of the matched cgroups to the bpf map.
+func (f *cgroupFilter) update() {
+	if f.mode == cgroupFilterNone || f.bpfm == nil {
+		return
+	}
+
+	cur := map[uint64]struct{}{}
+	_ = filepath.WalkDir(f.root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil //nolint:nilerr
+		}
+		rel, err := filepath.Rel(f.root, p)
+		if err != nil || !f.matcher.match("/"+filepath.ToSlash(rel)) {
+			return nil //nolint:nilerr
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil //nolint:nilerr
+		}
+		if st, ok := info.Sys().(*syscall.Stat_t); ok {
+			cur[st.Ino] = struct{}{}
+		}
+		return nil
+	})
+
+	v := uint8(1)
+	for id := range cur {
+		if _, ok := f.added[id]; ok {
+			continue
+		}
+		if err := f.bpfm.Update(id, v, ebpf.UpdateAny); err != nil {
+			l.Debug(err)
+			continue
+		}
+		f.added[id] = struct{}{}
+	}
+
+	for id := range f.added {
+		if _, ok := cur[id]; ok {
+			continue
+		}
+		if err := f.bpfm.Delete(id); err != nil {
+			l.Debug(err)
+		}
+		delete(f.added, id)
+	}
+}
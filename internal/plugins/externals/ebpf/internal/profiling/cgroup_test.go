@@ -0,0 +1,21 @@
+//go:build linux
+// +build linux
+
+package profiling
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCgroupMatcher(t *testing.T) {
+	m := cgroupMatcher{"/kubepods.slice/*", "/system.slice/nginx.service"}
+
+	assert.True(t, m.match("/kubepods.slice/kubepods-besteffort.slice"))
+	assert.True(t, m.match("/kubepods.slice/kubepods-besteffort.slice/pod1.slice"))
+	assert.True(t, m.match("/system.slice/nginx.service"))
+	assert.False(t, m.match("/system.slice/sshd.service"))
+	assert.False(t, m.match("/kubepods.slice"))
+	assert.False(t, m.match("/"))
+}
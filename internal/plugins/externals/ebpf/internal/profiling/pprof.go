@@ -0,0 +1,122 @@
+//go:build linux
+// +build linux
+
+package profiling
+
+import (
+	"time"
+
+	"github.com/google/pprof/profile"
+)
+
+const (
+	labelPID         = "pid"
+	labelProcessName = "process_name"
+	labelThreadName  = "thread_name"
+)
+
+type stackSample struct {
+	pid         uint32
+	processName string
+	threadName  string
+
+	// the leaf first, the kernel frames are followed by the user frames
+	frames []frame
+
+	cpuNS    int64
+	offCPUNS int64
+}
+
+type profileBuilder struct {
+	p *profile.Profile
+
+	offCPU bool
+
+	functions map[frame]*profile.Function
+	locations map[frame]*profile.Location
+}
+
+// newProfileBuilder creates the builder of the profile, period is the
+// sampling interval of the cpu in nanoseconds.
+func newProfileBuilder(start, end time.Time, period int64, offCPU bool) *profileBuilder {
+	p := &profile.Profile{
+		SampleType: []*profile.ValueType{
+			{Type: "cpu", Unit: "nanoseconds"},
+		},
+		PeriodType:    &profile.ValueType{Type: "cpu", Unit: "nanoseconds"},
+		Period:        period,
+		TimeNanos:     start.UnixNano(),
+		DurationNanos: end.Sub(start).Nanoseconds(),
+	}
+	if offCPU {
+		p.SampleType = append(p.SampleType,
+			&profile.ValueType{Type: "off_cpu", Unit: "nanoseconds"})
+	}
+
+	return &profileBuilder{
+		p:         p,
+		offCPU:    offCPU,
+		functions: map[frame]*profile.Function{},
+		locations: map[frame]*profile.Location{},
+	}
+}
+
+func (b *profileBuilder) location(fr frame) *profile.Location {
+	if loc, ok := b.locations[fr]; ok {
+		return loc
+	}
+
+	fn, ok := b.functions[fr]
+	if !ok {
+		fn = &profile.Function{
+			ID:         uint64(len(b.p.Function) + 1),
+			Name:       fr.name,
+			SystemName: fr.name,
+			Filename:   fr.file,
+		}
+		b.functions[fr] = fn
+		b.p.Function = append(b.p.Function, fn)
+	}
+
+	loc := &profile.Location{
+		ID:   uint64(len(b.p.Location) + 1),
+		Line: []profile.Line{{Function: fn}},
+	}
+	b.locations[fr] = loc
+	b.p.Location = append(b.p.Location, loc)
+
+	return loc
+}
+
+func (b *profileBuilder) add(s *stackSample) {
+	if len(s.frames) == 0 || (s.cpuNS == 0 && s.offCPUNS == 0) {
+		return
+	}
+
+	sample := &profile.Sample{
+		Value: []int64{s.cpuNS},
+		Label: map[string][]string{
+			labelProcessName: {s.processName},
+			labelThreadName:  {s.threadName},
+		},
+		NumLabel: map[string][]int64{
+			labelPID: {int64(s.pid)},
+		},
+	}
+	if b.offCPU {
+		sample.Value = append(sample.Value, s.offCPUNS)
+	} else if s.cpuNS == 0 {
+		return
+	}
+
+	for _, fr := range s.frames {
+		sample.Location = append(sample.Location, b.location(fr))
+	}
+
+	b.p.Sample = append(b.p.Sample, sample)
+}
+
+// profile merges the samples with the same stack and labels.
+func (b *profileBuilder) profile() *profile.Profile {
+	return b.p.Compact()
+}
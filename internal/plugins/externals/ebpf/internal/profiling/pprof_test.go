@@ -0,0 +1,75 @@
+//go:build linux
+// +build linux
+
+package profiling
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/google/pprof/profile"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProfileBuilder(t *testing.T) {
+	end := time.Now()
+	start := end.Add(-time.Minute)
+
+	b := newProfileBuilder(start, end, 10_000_000, true)
+
+	main := frame{name: "main.main", file: "/app"}
+	work := frame{name: "main.work", file: "/app"}
+	read := frame{name: "ksys_read", file: kernelFile}
+
+	b.add(&stackSample{pid: 1, processName: "app", threadName: "app",
+		frames: []frame{work, main}, cpuNS: 30_000_000})
+	b.add(&stackSample{pid: 1, processName: "app", threadName: "app",
+		frames: []frame{work, main}, cpuNS: 20_000_000})
+	b.add(&stackSample{pid: 1, processName: "app", threadName: "worker",
+		frames: []frame{read, work, main}, offCPUNS: 5_000_000})
+	b.add(&stackSample{pid: 1, processName: "app", threadName: "app"})
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, b.profile().Write(buf))
+
+	p, err := profile.Parse(buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, p.CheckValid())
+
+	assert.Len(t, p.SampleType, 2)
+	assert.Equal(t, "cpu", p.SampleType[0].Type)
+	assert.Equal(t, "off_cpu", p.SampleType[1].Type)
+	assert.Equal(t, int64(10_000_000), p.Period)
+	assert.Equal(t, time.Minute.Nanoseconds(), p.DurationNanos)
+
+	assert.Len(t, p.Location, 3)
+	if !assert.Len(t, p.Sample, 2) {
+		return
+	}
+
+	var cpu, offCPU int64
+	for _, s := range p.Sample {
+		cpu += s.Value[0]
+		offCPU += s.Value[1]
+		assert.Equal(t, []int64{1}, s.NumLabel[labelPID])
+		assert.Equal(t, "main.main", s.Location[len(s.Location)-1].Line[0].Function.Name)
+	}
+	assert.Equal(t, int64(50_000_000), cpu)
+	assert.Equal(t, int64(5_000_000), offCPU)
+}
+
+func TestProfileBuilderCPUOnly(t *testing.T) {
+	now := time.Now()
+	b := newProfileBuilder(now, now.Add(time.Minute), 10_000_000, false)
+
+	fr := frame{name: "main.main", file: "/app"}
+	b.add(&stackSample{pid: 1, frames: []frame{fr}, cpuNS: 10_000_000})
+	b.add(&stackSample{pid: 2, frames: []frame{fr}, offCPUNS: 10_000_000})
+
+	p := b.profile()
+	assert.Len(t, p.SampleType, 1)
+	assert.Len(t, p.Sample, 1)
+}
@@ -0,0 +1,415 @@
+//go:build linux
+// +build linux
+
+// Package profiling samples the on-cpu and off-cpu stacks by eBPF
+package profiling
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	manager "github.com/DataDog/ebpf-manager"
+	"github.com/GuanceCloud/cliutils/logger"
+	"github.com/cilium/ebpf"
+	dkebpf "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/internal/c"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/internal/exporter"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/internal/sysmonitor"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	bpfMapStacks       = "bpfmap_prof_stacks"
+	bpfMapCounts       = "bpfmap_prof_counts"
+	bpfMapCgroupFilter = "bpfmap_prof_cgroup_filter"
+
+	// PROF_STACK_DEPTH.
+	stackDepth = 127
+
+	defaultSampleRate = 99
+
+	profileFile = "cpu.pprof"
+
+	tagContainerID = "container_id"
+)
+
+var l = logger.DefaultSLogger("ebpf")
+
+func SetLogger(nl *logger.Logger) {
+	l = nl
+}
+
+// Same as struct 'prof_key' and 'prof_value' in
+// internal/plugins/externals/ebpf/internal/c/profiling/profiling.h.
+type (
+	profKey struct {
+		PID         uint32
+		UserStackID int32
+		KernStackID int32
+		_           uint32
+		CgroupID    uint64
+		Comm        [16]byte
+	}
+
+	profValue struct {
+		CPUSamples uint64
+		OffCPUNS   uint64
+	}
+)
+
+type Profiler struct {
+	selfPid    int
+	sampleRate int
+	offCPU     bool
+	tags       map[string]string
+
+	filter *cgroupFilter
+	symbol *symbolizer
+}
+
+type Option func(p *Profiler)
+
+func WithSelfPid(pid int) Option {
+	return func(p *Profiler) {
+		p.selfPid = pid
+	}
+}
+
+// WithSampleRate sets the frequency(Hz) of the cpu sampling.
+func WithSampleRate(hz int) Option {
+	return func(p *Profiler) {
+		if hz > 0 {
+			p.sampleRate = hz
+		}
+	}
+}
+
+func WithOffCPU(on bool) Option {
+	return func(p *Profiler) {
+		p.offCPU = on
+	}
+}
+
+func WithTags(tags map[string]string) Option {
+	return func(p *Profiler) {
+		p.tags = tags
+	}
+}
+
+// WithCgroupFilter sets the allow list or the deny list of the cgroups, the
+// deny list is ignored if the allow list is not empty.
+func WithCgroupFilter(enabled, disabled []string) Option {
+	return func(p *Profiler) {
+		p.filter = newCgroupFilter(enabled, disabled)
+	}
+}
+
+func NewProfiler(opts ...Option) *Profiler {
+	p := &Profiler{
+		sampleRate: defaultSampleRate,
+		tags:       map[string]string{},
+	}
+	for _, fn := range opts {
+		if fn != nil {
+			fn(p)
+		}
+	}
+	if p.filter == nil {
+		p.filter = newCgroupFilter(nil, nil)
+	}
+	return p
+}
+
+func NewProfilingManger(constEditor []manager.ConstantEditor, sampleRate int, offCPU bool) (*manager.Manager, error) {
+	m := &manager.Manager{
+		Probes: []*manager.Probe{
+			{
+				ProbeIdentificationPair: manager.ProbeIdentificationPair{
+					EBPFFuncName: "perf_event__cpu_clock",
+				},
+				PerfEventType:   unix.PERF_TYPE_SOFTWARE,
+				PerfEventConfig: unix.PERF_COUNT_SW_CPU_CLOCK,
+				SampleFrequency: sampleRate,
+			},
+		},
+	}
+	mOpts := manager.Options{
+		RLimit: &unix.Rlimit{
+			Cur: math.MaxUint64,
+			Max: math.MaxUint64,
+		},
+		ConstantEditors: constEditor,
+	}
+
+	if offCPU {
+		m.Probes = append(m.Probes, &manager.Probe{
+			ProbeIdentificationPair: manager.ProbeIdentificationPair{
+				EBPFFuncName: "tracepoint__sched_switch",
+			},
+		})
+	} else {
+		mOpts.ExcludedFunctions = []string{"tracepoint__sched_switch"}
+	}
+
+	buf, err := dkebpf.ProfilingBin()
+	if err != nil {
+		return nil, fmt.Errorf("profiling.o: %w", err)
+	}
+
+	if err := m.InitWithOptions((bytes.NewReader(buf)), mOpts); err != nil {
+		return nil, fmt.Errorf("init profiling: %w", err)
+	}
+	return m, nil
+}
+
+func (p *Profiler) Run(ctx context.Context, interval time.Duration) error {
+	m, err := NewProfilingManger([]manager.ConstantEditor{
+		{
+			Name:  "profiling_self_pid",
+			Value: uint64(p.selfPid),
+		},
+		{
+			Name:  "profiling_cgroup_filter",
+			Value: p.filter.mode,
+		},
+	}, p.sampleRate, p.offCPU)
+	if err != nil {
+		return err
+	}
+
+	maps := map[string]*ebpf.Map{}
+	for _, name := range []string{bpfMapStacks, bpfMapCounts, bpfMapCgroupFilter} {
+		bpfm, ok, err := m.GetMap(name)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("bpf map %s not found", name)
+		}
+		maps[name] = bpfm
+	}
+
+	p.filter.bpfm = maps[bpfMapCgroupFilter]
+	p.filter.update()
+
+	if err := m.Start(); err != nil {
+		return fmt.Errorf("start profiling: %w", err)
+	}
+
+	p.symbol = newSymbolizer()
+
+	go func() {
+		defer m.Stop(manager.CleanAll) //nolint:errcheck
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		start := time.Now()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case end := <-ticker.C:
+				p.collect(maps[bpfMapCounts], maps[bpfMapStacks], start, end)
+				p.filter.update()
+				start = end
+			}
+		}
+	}()
+
+	return nil
+}
+
+type profileGroup struct {
+	tags    map[string]string
+	samples []*stackSample
+}
+
+// collect reads and clears the counts and the stacks, then uploads the
+// profiles grouped by the containers, the processes not in containers are
+// grouped by the pid.
+func (p *Profiler) collect(countsMap, stacksMap *ebpf.Map, start, end time.Time) {
+	defer p.symbol.reset()
+
+	var (
+		keys   []profKey
+		values []profValue
+		k      profKey
+		v      profValue
+	)
+	iter := countsMap.Iterate()
+	for iter.Next(&k, &v) {
+		keys = append(keys, k)
+		values = append(values, v)
+	}
+	if err := iter.Err(); err != nil {
+		l.Warn(err)
+	}
+
+	// the counts added between the iteration and the deletion are lost
+	for i := range keys {
+		_ = countsMap.Delete(&keys[i])
+	}
+
+	stacks := map[int32][]uint64{}
+	stack := func(id int32) []uint64 {
+		// < 0: no stack, for example the user stack of kernel threads
+		if id < 0 {
+			return nil
+		}
+		if st, ok := stacks[id]; ok {
+			return st
+		}
+		var buf [stackDepth]uint64
+		if err := stacksMap.Lookup(uint32(id), &buf); err != nil {
+			stacks[id] = nil
+			return nil
+		}
+		n := 0
+		for n < len(buf) && buf[n] != 0 {
+			n++
+		}
+		stacks[id] = buf[:n]
+		return stacks[id]
+	}
+
+	period := int64(time.Second) / int64(p.sampleRate)
+	groups := map[string]*profileGroup{}
+	procs := map[uint32]*procMeta{}
+
+	for i := range keys {
+		k, v := &keys[i], &values[i]
+
+		proc, ok := procs[k.PID]
+		if !ok {
+			proc = readProcMeta(k.PID)
+			procs[k.PID] = proc
+		}
+
+		s := &stackSample{
+			pid:         k.PID,
+			processName: proc.name,
+			threadName:  unix.ByteSliceToString(k.Comm[:]),
+			cpuNS:       int64(v.CPUSamples) * period,
+			offCPUNS:    int64(v.OffCPUNS),
+		}
+		if s.processName == "" {
+			s.processName = s.threadName
+		}
+
+		for _, addr := range stack(k.KernStackID) {
+			s.frames = append(s.frames, p.symbol.kernelFrame(addr))
+		}
+		for i, addr := range stack(k.UserStackID) {
+			// the return address of the caller
+			if i > 0 || len(s.frames) > 0 {
+				addr--
+			}
+			s.frames = append(s.frames, p.symbol.userFrame(k.PID, addr))
+		}
+		if len(s.frames) == 0 {
+			continue
+		}
+
+		groupKey := proc.containerID
+		if groupKey == "" {
+			groupKey = "pid:" + strconv.Itoa(int(k.PID))
+		}
+		g, ok := groups[groupKey]
+		if !ok {
+			g = &profileGroup{tags: map[string]string{}}
+			for key, val := range p.tags {
+				g.tags[key] = val
+			}
+			if proc.containerID != "" {
+				g.tags[tagContainerID] = proc.containerID
+			} else {
+				g.tags[labelProcessName] = s.processName
+				g.tags[labelPID] = strconv.Itoa(int(k.PID))
+			}
+			groups[groupKey] = g
+		}
+		g.samples = append(g.samples, s)
+	}
+
+	for id := range stacks {
+		_ = stacksMap.Delete(uint32(id))
+	}
+
+	for _, g := range groups {
+		if err := p.upload(g, start, end, period); err != nil {
+			l.Warnf("upload profile: %s", err.Error())
+		}
+	}
+}
+
+func (p *Profiler) upload(g *profileGroup, start, end time.Time, period int64) error {
+	b := newProfileBuilder(start, end, period, p.offCPU)
+	for _, s := range g.samples {
+		b.add(s)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := b.profile().Write(buf); err != nil {
+		return err
+	}
+
+	event, err := json.Marshal(newMetadata(g.tags, start, end))
+	if err != nil {
+		return err
+	}
+
+	return exporter.FeedProfile(event, map[string][]byte{
+		profileFile: buf.Bytes(),
+	})
+}
+
+type metadata struct {
+	Format       string   `json:"format"`
+	Profiler     string   `json:"profiler"`
+	Attachments  []string `json:"attachments"`
+	TagsProfiler string   `json:"tags_profiler"`
+	Start        string   `json:"start"`
+	End          string   `json:"end"`
+}
+
+func newMetadata(tags map[string]string, start, end time.Time) *metadata {
+	kvs := make([]string, 0, len(tags))
+	for k, v := range tags {
+		if k != "" && v != "" {
+			kvs = append(kvs, k+":"+v)
+		}
+	}
+
+	return &metadata{
+		Format:       "pprof",
+		Profiler:     "ebpf",
+		Attachments:  []string{profileFile},
+		TagsProfiler: strings.Join(kvs, ","),
+		Start:        start.Format(time.RFC3339Nano),
+		End:          end.Format(time.RFC3339Nano),
+	}
+}
+
+type procMeta struct {
+	name        string
+	containerID string
+}
+
+func readProcMeta(pid uint32) *procMeta {
+	meta := &procMeta{}
+	pidStr := strconv.Itoa(int(pid))
+
+	if b, err := os.ReadFile(sysmonitor.HostProc(pidStr, "comm")); err == nil {
+		meta.name = strings.TrimSpace(string(b))
+	}
+	meta.containerID = sysmonitor.ProcContainerID(int(pid))
+
+	return meta
+}
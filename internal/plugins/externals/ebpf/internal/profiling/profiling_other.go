@@ -0,0 +1,5 @@
+//go:build !linux
+// +build !linux
+
+// Package profiling samples the on-cpu and off-cpu stacks by eBPF
+package profiling
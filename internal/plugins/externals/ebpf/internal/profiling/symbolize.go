@@ -0,0 +1,339 @@
+//go:build linux
+// +build linux
+
+package profiling
+
+import (
+	"bufio"
+	"debug/elf"
+	"debug/gosym"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/ebpf/internal/sysmonitor"
+)
+
+const (
+	kernelFile = "[kernel.kallsyms]"
+	unknownSym = "[unknown]"
+
+	maxCachedFiles = 512
+)
+
+type frame struct {
+	name string
+	file string
+}
+
+type symbol struct {
+	start, end uint64
+	name       string
+}
+
+// symbols is sorted by the start address.
+type symbols []symbol
+
+func (syms symbols) lookup(addr uint64) (string, bool) {
+	i := sort.Search(len(syms), func(i int) bool {
+		return syms[i].start > addr
+	}) - 1
+	if i < 0 {
+		return "", false
+	}
+	if s := syms[i]; s.end == 0 || addr < s.end {
+		return s.name, true
+	}
+	return "", false
+}
+
+func (syms symbols) sort() symbols {
+	sort.Slice(syms, func(i, j int) bool {
+		return syms[i].start < syms[j].start
+	})
+
+	// the symbol may be in both .symtab and .dynsym
+	r := syms[:0]
+	for i := range syms {
+		if len(r) > 0 && r[len(r)-1].start == syms[i].start {
+			continue
+		}
+		r = append(r, syms[i])
+	}
+	return r
+}
+
+// parseKallsyms reads the text symbols of the kernel and the modules.
+func parseKallsyms(r io.Reader) symbols {
+	var syms symbols
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		// ffffffff81000000 T _stext [module]
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 {
+			continue
+		}
+		switch fields[1] {
+		case "t", "T", "w", "W":
+		default:
+			continue
+		}
+		addr, err := strconv.ParseUint(fields[0], 16, 64)
+		if err != nil || addr == 0 {
+			continue
+		}
+		syms = append(syms, symbol{start: addr, name: fields[2]})
+	}
+
+	return syms.sort()
+}
+
+type fileKey struct {
+	dev   string
+	inode uint64
+}
+
+type procMapping struct {
+	start, end, offset uint64
+
+	key  fileKey
+	path string
+}
+
+// parseProcMaps returns the executable mappings backed by files.
+func parseProcMaps(r io.Reader) []procMapping {
+	var mappings []procMapping
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		// 7f1c2e000000-7f1c2e1b5000 r-xp 00028000 fd:01 1835113 /usr/lib/libc.so.6
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 6 || !strings.Contains(fields[1], "x") {
+			continue
+		}
+		if !strings.HasPrefix(fields[5], "/") {
+			continue
+		}
+
+		addrs := strings.SplitN(fields[0], "-", 2)
+		if len(addrs) != 2 {
+			continue
+		}
+		start, err := strconv.ParseUint(addrs[0], 16, 64)
+		if err != nil {
+			continue
+		}
+		end, err := strconv.ParseUint(addrs[1], 16, 64)
+		if err != nil {
+			continue
+		}
+		offset, err := strconv.ParseUint(fields[2], 16, 64)
+		if err != nil {
+			continue
+		}
+		inode, err := strconv.ParseUint(fields[4], 10, 64)
+		if err != nil || inode == 0 {
+			continue
+		}
+
+		mappings = append(mappings, procMapping{
+			start:  start,
+			end:    end,
+			offset: offset,
+			key:    fileKey{dev: fields[3], inode: inode},
+			path:   strings.Join(fields[5:], " "),
+		})
+	}
+
+	return mappings
+}
+
+type segment struct {
+	off, vaddr, size uint64
+}
+
+type elfSymbols struct {
+	syms  symbols
+	gotab *gosym.Table
+
+	segments []segment
+}
+
+// vaddr converts the file offset to the virtual address in the ELF file.
+func (e *elfSymbols) vaddr(off uint64) (uint64, bool) {
+	for _, seg := range e.segments {
+		if off >= seg.off && off < seg.off+seg.size {
+			return off - seg.off + seg.vaddr, true
+		}
+	}
+	return 0, false
+}
+
+func (e *elfSymbols) lookup(vaddr uint64) (string, bool) {
+	if name, ok := e.syms.lookup(vaddr); ok {
+		return name, true
+	}
+	if e.gotab != nil {
+		if fn := e.gotab.PCToFunc(vaddr); fn != nil {
+			return fn.Name, true
+		}
+	}
+	return "", false
+}
+
+func loadELFSymbols(fp string) (*elfSymbols, error) {
+	f, err := elf.Open(fp)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() //nolint:errcheck
+
+	e := &elfSymbols{}
+	for _, prog := range f.Progs {
+		if prog.Type == elf.PT_LOAD && prog.Flags&elf.PF_X != 0 {
+			e.segments = append(e.segments, segment{
+				off: prog.Off, vaddr: prog.Vaddr, size: prog.Filesz,
+			})
+		}
+	}
+	if len(e.segments) == 0 {
+		return nil, fmt.Errorf("no executable segment")
+	}
+
+	syms, _ := f.Symbols()
+	dynSyms, _ := f.DynamicSymbols()
+	for _, s := range append(syms, dynSyms...) {
+		if elf.ST_TYPE(s.Info) != elf.STT_FUNC || s.Value == 0 {
+			continue
+		}
+		var end uint64
+		if s.Size > 0 {
+			end = s.Value + s.Size
+		}
+		e.syms = append(e.syms, symbol{start: s.Value, end: end, name: s.Name})
+	}
+	e.syms = e.syms.sort()
+
+	// the stripped go binary
+	if len(syms) == 0 {
+		e.gotab = loadGoSymTab(f)
+	}
+
+	return e, nil
+}
+
+func loadGoSymTab(f *elf.File) *gosym.Table {
+	pclntab := f.Section(".gopclntab")
+	text := f.Section(".text")
+	if pclntab == nil || text == nil {
+		return nil
+	}
+	data, err := pclntab.Data()
+	if err != nil {
+		return nil
+	}
+
+	var symtab []byte
+	if sec := f.Section(".gosymtab"); sec != nil {
+		symtab, _ = sec.Data()
+	}
+
+	tab, err := gosym.NewTable(symtab, gosym.NewLineTable(data, text.Addr))
+	if err != nil {
+		return nil
+	}
+	return tab
+}
+
+// symbolizer resolves the addresses of the stacks, the symbols of the files are
+// cached, and the mappings of the processes are cached until reset.
+type symbolizer struct {
+	kernel symbols
+
+	files map[fileKey]*elfSymbols
+	procs map[uint32][]procMapping
+}
+
+func newSymbolizer() *symbolizer {
+	s := &symbolizer{
+		files: map[fileKey]*elfSymbols{},
+		procs: map[uint32][]procMapping{},
+	}
+
+	if f, err := os.Open(sysmonitor.HostProc("kallsyms")); err != nil {
+		l.Warn(err)
+	} else {
+		s.kernel = parseKallsyms(f)
+		_ = f.Close()
+	}
+
+	return s
+}
+
+// reset drops the mappings of processes, it is called after every collection.
+func (s *symbolizer) reset() {
+	s.procs = map[uint32][]procMapping{}
+	if len(s.files) > maxCachedFiles {
+		s.files = map[fileKey]*elfSymbols{}
+	}
+}
+
+func (s *symbolizer) kernelFrame(addr uint64) frame {
+	name, ok := s.kernel.lookup(addr)
+	if !ok {
+		name = unknownSym
+	}
+	return frame{name: name, file: kernelFile}
+}
+
+func (s *symbolizer) userFrame(pid uint32, addr uint64) frame {
+	mappings, ok := s.procs[pid]
+	if !ok {
+		if f, err := os.Open(sysmonitor.HostProc(strconv.Itoa(int(pid)), "maps")); err == nil {
+			mappings = parseProcMaps(f)
+			_ = f.Close()
+		}
+		s.procs[pid] = mappings
+	}
+
+	for i := range mappings {
+		m := &mappings[i]
+		if addr < m.start || addr >= m.end {
+			continue
+		}
+
+		off := addr - m.start + m.offset
+		fr := frame{
+			name: fmt.Sprintf("%s+0x%x", filepath.Base(m.path), off),
+			file: m.path,
+		}
+
+		e, ok := s.files[m.key]
+		if !ok {
+			// the path is in the mount namespace of the process
+			var err error
+			if e, err = loadELFSymbols(sysmonitor.HostProc(
+				strconv.Itoa(int(pid)), "root", m.path)); err != nil {
+				l.Debugf("load symbols of %s: %s", m.path, err.Error())
+			}
+			s.files[m.key] = e
+		}
+		if e == nil {
+			return fr
+		}
+
+		if vaddr, ok := e.vaddr(off); ok {
+			if name, ok := e.lookup(vaddr); ok {
+				fr.name = name
+			}
+		}
+		return fr
+	}
+
+	return frame{name: unknownSym}
+}
@@ -0,0 +1,99 @@
+//go:build linux
+// +build linux
+
+package profiling
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseKallsyms(t *testing.T) {
+	syms := parseKallsyms(strings.NewReader(`ffffffff81000000 T _stext
+ffffffff81001000 t do_one_initcall
+ffffffff81002000 D some_data
+0000000000000000 T zero_addr
+ffffffffc0000000 t nf_conntrack_in	[nf_conntrack]
+`))
+
+	if !assert.Len(t, syms, 3) {
+		return
+	}
+
+	name, ok := syms.lookup(0xffffffff81001010)
+	assert.True(t, ok)
+	assert.Equal(t, "do_one_initcall", name)
+
+	name, ok = syms.lookup(0xffffffffc0000100)
+	assert.True(t, ok)
+	assert.Equal(t, "nf_conntrack_in", name)
+
+	_, ok = syms.lookup(0x1000)
+	assert.False(t, ok)
+}
+
+func TestParseProcMaps(t *testing.T) {
+	mappings := parseProcMaps(strings.NewReader(`55d5c4a00000-55d5c4a28000 r--p 00000000 fd:01 1835110 /usr/bin/app
+55d5c4a28000-55d5c4b00000 r-xp 00028000 fd:01 1835110 /usr/bin/app
+7f1c2e000000-7f1c2e1b5000 r-xp 00028000 fd:01 1835113 /usr/lib/libc.so.6
+7ffd3b9e0000-7ffd3ba01000 rw-p 00000000 00:00 0 [stack]
+7ffd3bbf2000-7ffd3bbf4000 r-xp 00000000 00:00 0 [vdso]
+`))
+
+	if !assert.Len(t, mappings, 2) {
+		return
+	}
+	assert.Equal(t, uint64(0x55d5c4a28000), mappings[0].start)
+	assert.Equal(t, uint64(0x28000), mappings[0].offset)
+	assert.Equal(t, fileKey{dev: "fd:01", inode: 1835110}, mappings[0].key)
+	assert.Equal(t, "/usr/lib/libc.so.6", mappings[1].path)
+}
+
+func TestSymbolsLookup(t *testing.T) {
+	syms := symbols{
+		{start: 0x3000, end: 0x3100, name: "c"},
+		{start: 0x1000, end: 0x1100, name: "a"},
+		{start: 0x1000, end: 0x1100, name: "a"},
+		{start: 0x2000, name: "b"},
+	}.sort()
+	assert.Len(t, syms, 3)
+
+	for addr, expected := range map[uint64]string{
+		0x1050: "a",
+		0x1100: "",
+		0x2fff: "b",
+		0x3000: "c",
+	} {
+		name, _ := syms.lookup(addr)
+		assert.Equal(t, expected, name)
+	}
+}
+
+func TestLoadELFSymbols(t *testing.T) {
+	exe, err := os.Executable()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	e, err := loadELFSymbols(exe)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var addr uint64
+	for _, s := range e.syms {
+		if s.name == "runtime.main" {
+			addr = s.start + 1
+		}
+	}
+	if addr == 0 {
+		t.Skip("symbols stripped")
+	}
+
+	name, ok := e.lookup(addr)
+	assert.True(t, ok)
+	assert.Equal(t, "runtime.main", name)
+}
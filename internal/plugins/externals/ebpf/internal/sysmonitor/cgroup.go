@@ -0,0 +1,54 @@
+//go:build linux
+// +build linux
+
+package sysmonitor
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var containerIDRegexp = regexp.MustCompile(`[0-9a-f]{64}`)
+
+// ContainerIDFromCgroup returns the container id in the content of
+// /proc/<pid>/cgroup, such as
+//
+//	0::/kubepods.slice/.../cri-containerd-<id>.scope
+//	12:cpu,cpuacct:/docker/<id>
+func ContainerIDFromCgroup(r io.Reader) string {
+	var id string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		for _, seg := range strings.Split(parts[2], "/") {
+			if v := containerIDRegexp.FindString(seg); v != "" {
+				id = v
+			}
+		}
+		if id != "" {
+			return id
+		}
+	}
+
+	return id
+}
+
+// ProcContainerID returns the container id of the process, it returns an
+// empty string if the process not in a container or exited.
+func ProcContainerID(pid int) string {
+	f, err := os.Open(HostProc(strconv.Itoa(pid), "cgroup"))
+	if err != nil {
+		return ""
+	}
+	defer f.Close() //nolint:errcheck
+
+	return ContainerIDFromCgroup(f)
+}
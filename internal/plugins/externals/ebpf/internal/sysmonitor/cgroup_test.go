@@ -0,0 +1,25 @@
+//go:build linux
+// +build linux
+
+package sysmonitor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContainerIDFromCgroup(t *testing.T) {
+	const id = "4f1b7e0c5a3d2b6e8f9a0c1d2e3f4a5b6c7d8e9f0a1b2c3d4e5f6a7b8c9d0e1f"
+
+	cases := map[string]string{
+		"0::/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod1234.slice/cri-containerd-" + id + ".scope": id,
+		"12:cpu,cpuacct:/docker/" + id + "\n11:memory:/docker/" + id:                                                   id,
+		"0::/system.slice/docker-" + id + ".scope":                                                                     id,
+		"0::/user.slice/user-1000.slice/session-2.scope":                                                               "",
+	}
+	for content, expected := range cases {
+		assert.Equal(t, expected, ContainerIDFromCgroup(strings.NewReader(content)), content)
+	}
+}
@@ -0,0 +1,314 @@
+//go:build linux
+// +build linux
+
+package sysmonitor
+
+import (
+	"context"
+	"debug/buildinfo"
+	"debug/elf"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	manager "github.com/DataDog/ebpf-manager"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+const (
+	goTLSWriteSym = "crypto/tls.(*Conn).Write"
+	goTLSReadSym  = "crypto/tls.(*Conn).Read"
+
+	goTLSWriteProg   = "uprobe__gotls_write"
+	goTLSReadProg    = "uprobe__gotls_read"
+	goTLSReadRetProg = "uprobe__gotls_read_ret"
+)
+
+// GoTLSRegister attaches uprobes to crypto/tls.(*Conn).Read and Write of
+// the running go processes, the binaries are deduplicated by the inode.
+type GoTLSRegister struct {
+	m       *manager.Manager
+	selfPid int
+
+	attached   map[fileKey]*goTLSBinary
+	attachInfo ProcessAttachInfo
+
+	run int32 // 0 or 1(true)
+
+	sync.Mutex
+}
+
+type goTLSBinary struct {
+	modTime time.Time
+	probes  []manager.ProbeIdentificationPair
+}
+
+func NewGoTLSRegister(m *manager.Manager, selfPid int) *GoTLSRegister {
+	return &GoTLSRegister{
+		m:        m,
+		selfPid:  selfPid,
+		attached: map[fileKey]*goTLSBinary{},
+	}
+}
+
+func (r *GoTLSRegister) ScanAndUpdate() {
+	r.Lock()
+	defer r.Unlock()
+
+	entries, err := os.ReadDir(HostProc())
+	if err != nil {
+		log.Warn(err)
+		return
+	}
+
+	cur := map[fileKey]struct{}{}
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || pid == r.selfPid {
+			continue
+		}
+
+		// the exe link is resolved by the kernel, no matter the process
+		// is running in a container or not
+		exe := HostProc(entry.Name(), "exe")
+		key, err := FileInfo(exe)
+		if err != nil {
+			continue
+		}
+		if _, ok := cur[key]; ok {
+			continue
+		}
+		cur[key] = struct{}{}
+
+		fstat, err := os.Stat(exe)
+		if err != nil {
+			continue
+		}
+		if bin, ok := r.attached[key]; ok && bin.modTime.Equal(fstat.ModTime()) {
+			continue
+		}
+
+		id := fmt.Sprintf("%d_%d", key.dev, key.ino)
+		if tmod, ok := r.attachInfo.GetCannotAndAttachInfo(id); ok && tmod.Equal(fstat.ModTime()) {
+			continue
+		}
+
+		r.detach(key)
+		if err := r.attach(key, exe, fstat.ModTime()); err != nil {
+			log.Debugf("attach go tls uprobes to %s(pid %d): %s", exe, pid, err.Error())
+			r.attachInfo.AddCannotAttach(id, fstat.ModTime())
+		}
+	}
+
+	for key := range r.attached {
+		if _, ok := cur[key]; !ok {
+			r.detach(key)
+		}
+	}
+}
+
+func (r *GoTLSRegister) attach(key fileKey, binPath string, modTime time.Time) error {
+	args, err := getGoTLSAttachArgs(binPath)
+	if err != nil {
+		return err
+	}
+
+	uid := ShortID(binPath, modTime.String())
+	log.Info("AddHook: ", binPath, " ShortID: ", uid)
+
+	bin := &goTLSBinary{modTime: modTime}
+	for i, arg := range args {
+		probeID := manager.ProbeIdentificationPair{
+			UID:          uid + "_" + strconv.Itoa(i),
+			EBPFFuncName: arg.uprobeFunc,
+		}
+		if err := r.m.AddHook("", &manager.Probe{
+			ProbeIdentificationPair: probeID,
+			UprobeOffset:            arg.symbolOffset,
+			BinaryPath:              binPath,
+		}); err != nil {
+			log.Warn(err)
+			continue
+		}
+		bin.probes = append(bin.probes, probeID)
+	}
+
+	r.attached[key] = bin
+	return nil
+}
+
+func (r *GoTLSRegister) detach(key fileKey) {
+	bin, ok := r.attached[key]
+	if !ok {
+		return
+	}
+	delete(r.attached, key)
+
+	for _, probeID := range bin.probes {
+		p, ok := r.m.GetProbe(probeID)
+		if !ok {
+			continue
+		}
+		pp := p.Program()
+		if err := r.m.DetachHook(probeID); err != nil {
+			log.Debug(err)
+		}
+		if pp != nil {
+			if err := pp.Close(); err != nil {
+				log.Debug(err)
+			}
+		}
+	}
+}
+
+func (r *GoTLSRegister) CleanAll() {
+	r.Lock()
+	defer r.Unlock()
+
+	for key := range r.attached {
+		r.detach(key)
+	}
+}
+
+func (r *GoTLSRegister) Monitor(ctx context.Context, scanInterval time.Duration) {
+	if old := atomic.SwapInt32(&r.run, 1); old == 1 {
+		log.Warn("go tls monitor started")
+		return
+	}
+	ticker := time.NewTicker(scanInterval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				r.ScanAndUpdate()
+			case <-ctx.Done():
+				r.CleanAll()
+				return
+			}
+		}
+	}()
+}
+
+func getGoTLSAttachArgs(binPath string) ([]uprobeAttachArg, error) {
+	inf, err := buildinfo.ReadFile(binPath)
+	if err != nil {
+		return nil, err
+	}
+
+	goVer, ok := parseGoVersion(inf.GoVersion)
+	if !ok {
+		return nil, fmt.Errorf("unknown go version %s", inf.GoVersion)
+	}
+	if !goUseRegisterABI(goVer) {
+		return nil, fmt.Errorf("%s on %s not supported", inf.GoVersion, runtime.GOARCH)
+	}
+
+	f, err := os.Open(binPath) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() //nolint:errcheck,gosec
+
+	elfFile, err := elf.NewFile(f)
+	if err != nil {
+		return nil, err
+	}
+
+	write, err := findGoFunc(elfFile, goVer, goTLSWriteSym)
+	if err != nil {
+		return nil, err
+	}
+	read, err := findGoFunc(elfFile, goVer, goTLSReadSym)
+	if err != nil {
+		return nil, err
+	}
+
+	code := make([]byte, read.End-read.Start)
+	if _, err := f.ReadAt(code, int64(read.Start)); err != nil {
+		return nil, err
+	}
+	rets := findRetOffsets(code, runtime.GOARCH)
+	if len(rets) == 0 {
+		return nil, fmt.Errorf("no RET instruction found in %s", goTLSReadSym)
+	}
+
+	args := []uprobeAttachArg{
+		{binPath: binPath, symbol: goTLSWriteSym, symbolOffset: write.Start, uprobeFunc: goTLSWriteProg},
+		{binPath: binPath, symbol: goTLSReadSym, symbolOffset: read.Start, uprobeFunc: goTLSReadProg},
+	}
+	for _, off := range rets {
+		args = append(args, uprobeAttachArg{
+			binPath:      binPath,
+			symbol:       goTLSReadSym,
+			symbolOffset: read.Start + off,
+			uprobeFunc:   goTLSReadRetProg,
+		})
+	}
+
+	return args, nil
+}
+
+func goUseRegisterABI(goVer [2]int) bool {
+	if goVer[0] > 1 {
+		return true
+	}
+	switch runtime.GOARCH {
+	case "amd64":
+		return goVer[1] >= 17
+	case "arm64":
+		return goVer[1] >= 18
+	default:
+		return false
+	}
+}
+
+// findGoFunc returns the file offsets of the function, the pclntab is used
+// if the binary is stripped.
+func findGoFunc(elfFile *elf.File, goVer [2]int, name string) (*SymLoc, error) {
+	if syms, err := FindSymbol(elfFile, name); err == nil && len(syms) == 1 && syms[0].Size > 0 {
+		// the value is sanitized to the file offset
+		return &SymLoc{
+			Name:  name,
+			Start: syms[0].Value,
+			End:   syms[0].Value + syms[0].Size,
+		}, nil
+	}
+
+	return getGoUprobeSymbolFromPCLN(elfFile, goVer[1] >= 20, name)
+}
+
+// findRetOffsets returns the offsets of the RET instructions in the code
+// of the function.
+func findRetOffsets(code []byte, arch string) []uint64 {
+	var rets []uint64
+
+	switch arch {
+	case "amd64":
+		for i := 0; i < len(code); {
+			inst, err := x86asm.Decode(code[i:], 64)
+			if err != nil {
+				i++
+				continue
+			}
+			if inst.Op == x86asm.RET {
+				rets = append(rets, uint64(i))
+			}
+			i += inst.Len
+		}
+	case "arm64":
+		const ret = 0xd65f03c0
+		for i := 0; i+4 <= len(code); i += 4 {
+			if binary.LittleEndian.Uint32(code[i:]) == ret {
+				rets = append(rets, uint64(i))
+			}
+		}
+	}
+
+	return rets
+}
@@ -0,0 +1,32 @@
+//go:build linux
+// +build linux
+
+package sysmonitor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindRetOffsets(t *testing.T) {
+	t.Run("amd64", func(t *testing.T) {
+		code := []byte{
+			0x48, 0x89, 0xc3, // mov rbx, rax
+			0xc3,                                     // ret
+			0x48, 0xc7, 0xc0, 0xc3, 0x00, 0x00, 0x00, // mov rax, 0xc3
+			0xc3, // ret
+		}
+		assert.Equal(t, []uint64{3, 11}, findRetOffsets(code, "amd64"))
+	})
+
+	t.Run("arm64", func(t *testing.T) {
+		code := []byte{
+			0xe0, 0x03, 0x01, 0xaa, // mov x0, x1
+			0xc0, 0x03, 0x5f, 0xd6, // ret
+			0x1f, 0x20, 0x03, 0xd5, // nop
+			0xc0, 0x03, 0x5f, 0xd6, // ret
+		}
+		assert.Equal(t, []uint64{4, 12}, findRetOffsets(code, "arm64"))
+	})
+}
@@ -51,6 +51,9 @@ type Option struct {
 	Username        string `long:"username" description:"db username"`
 	Password        string `long:"password" description:"db password"`
 	ServiceName     string `long:"service-name" description:"oracle service name"`
+	ConnectString   string `long:"connect-string" description:"oracle connect descriptor or TNS alias, overrides host, port and service name"`
+	Wallet          string `long:"wallet" description:"oracle wallet directory, login by the wallet if username is empty"`
+	ProxyClient     string `long:"proxy-client" description:"connect as this user through the proxy user username"`
 	Tags            string `long:"tags" description:"additional tags in 'a=b,c=d,...' format"`
 	DatakitHTTPHost string `long:"datakit-http-host" description:"DataKit HTTP server host" default:"localhost"`
 	DatakitHTTPPort int    `long:"datakit-http-port" description:"DataKit HTTP server port" default:"9529"`
@@ -59,6 +62,7 @@ type Option struct {
 	Database        string `long:"database" description:"database name"`
 	SlowQueryTime   string `long:"slow-query-time" description:"Slow query time defined" default:""`
 	CustomQueryFile string `long:"custom-query" description:"Custom query file path" default:""`
+	ASHTopN         int    `long:"ash-top" description:"Sample active sessions and report top N SQL, 0 to disable" default:"0"`
 
 	Log      string   `long:"log" description:"log path"`
 	LogLevel string   `long:"log-level" description:"log file" default:"info"`
@@ -130,6 +134,22 @@ func BuildPointLogging(kvs point.KVs, metricName string, tags, hostTag map[strin
 	return point.NewPointV2(metricName, kvs, opts...)
 }
 
+// BuildPointEvent builds key event, kvs should contain df_title, df_message and df_status.
+func BuildPointEvent(kvs point.KVs, name string, tags, hostTag map[string]string) *point.Point {
+	opts := point.DefaultLoggingOptions()
+	opts = append(opts, point.WithTime(time.Now()))
+
+	for k, v := range tags {
+		kvs = kvs.AddTag(k, v)
+	}
+
+	for k, v := range hostTag {
+		kvs = kvs.MustAddTag(k, v)
+	}
+
+	return point.NewPointV2(name, kvs, opts...)
+}
+
 func GetHostTag(l *logger.Logger, hostVar string) map[string]string {
 	var err error
 
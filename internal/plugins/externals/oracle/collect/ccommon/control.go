@@ -0,0 +1,93 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package ccommon
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/GuanceCloud/cliutils/logger"
+	"github.com/jessevdk/go-flags"
+)
+
+const (
+	ActionReload = "reload"
+
+	// envInputPrefix is the prefix of environment variables could be reloaded,
+	// such as ENV_INPUT_ORACLE_PASSWORD.
+	envInputPrefix = "ENV_INPUT_"
+
+	maxControlMessageSize = 1 << 20
+)
+
+// ControlMessage is sent by DataKit to the external collector by stdin, one
+// JSON per line.
+// Same as struct 'controlMessage' in internal/plugins/inputs/external/control.go.
+type ControlMessage struct {
+	Action string   `json:"action"`
+	Args   []string `json:"args"`
+	Envs   []string `json:"envs"`
+}
+
+// Reloader is implemented by inputs could apply the reloaded option without
+// restarting the process.
+type Reloader interface {
+	Reload(opt *Option)
+}
+
+// WatchControl reads control messages from r until EOF. If the collector is
+// not started by DataKit with the control channel, such as stdin is
+// /dev/null, it returns at once.
+func WatchControl(l *logger.Logger, r io.Reader, f func(*ControlMessage)) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 4096), maxControlMessageSize)
+
+	for sc.Scan() {
+		var msg ControlMessage
+		if err := json.Unmarshal(sc.Bytes(), &msg); err != nil {
+			l.Warnf("invalid control message: %s, ignored", err.Error())
+			continue
+		}
+
+		l.Infof("control message %q received", msg.Action)
+		f(&msg)
+	}
+
+	if err := sc.Err(); err != nil {
+		l.Warnf("read control channel failed: %s", err.Error())
+	}
+}
+
+// ParseReloadOption parses args of the reload message. Environment variables
+// of ENV_INPUT_* are replaced by envs of the message, such as the rotated
+// password, other environment variables are not changed.
+func ParseReloadOption(msg *ControlMessage) (*Option, error) {
+	var opt Option
+	if _, err := flags.NewParser(&opt, flags.HelpFlag|flags.PassDoubleDash).ParseArgs(msg.Args); err != nil {
+		return nil, err
+	}
+
+	for _, kv := range os.Environ() {
+		if k, _, ok := strings.Cut(kv, "="); ok && strings.HasPrefix(k, envInputPrefix) {
+			if err := os.Unsetenv(k); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, kv := range msg.Envs {
+		if k, v, ok := strings.Cut(kv, "="); ok && strings.HasPrefix(k, envInputPrefix) {
+			if err := os.Setenv(k, v); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &opt, nil
+}
@@ -0,0 +1,60 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package ccommon
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/GuanceCloud/cliutils/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchControl(t *testing.T) {
+	input := `{"action":"reload","args":["--host","db1"],"envs":["ENV_INPUT_ORACLE_PASSWORD=new"]}
+not-json
+{"action":"reload","args":["--host","db2"]}
+`
+
+	var msgs []*ControlMessage
+	WatchControl(logger.DefaultSLogger("test"), strings.NewReader(input), func(msg *ControlMessage) {
+		msgs = append(msgs, msg)
+	})
+
+	require.Len(t, msgs, 2)
+	assert.Equal(t, ActionReload, msgs[0].Action)
+	assert.Equal(t, []string{"--host", "db1"}, msgs[0].Args)
+	assert.Equal(t, []string{"ENV_INPUT_ORACLE_PASSWORD=new"}, msgs[0].Envs)
+	assert.Equal(t, []string{"--host", "db2"}, msgs[1].Args)
+}
+
+func TestParseReloadOption(t *testing.T) {
+	t.Setenv("ENV_INPUT_ORACLE_PASSWORD", "old")
+	t.Setenv("ENV_INPUT_REMOVED", "x")
+	t.Setenv("LD_LIBRARY_PATH", "/opt/oracle")
+
+	opt, err := ParseReloadOption(&ControlMessage{
+		Action: ActionReload,
+		Args:   []string{"--host", "db1", "--username", "datakit", "--interval", "1m"},
+		Envs:   []string{"ENV_INPUT_ORACLE_PASSWORD=new", "LD_LIBRARY_PATH=/tmp"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "db1", opt.Host)
+	assert.Equal(t, "datakit", opt.Username)
+	assert.Equal(t, "1m", opt.Interval)
+	assert.Equal(t, "1521", opt.Port) // default applied
+
+	assert.Equal(t, "new", os.Getenv("ENV_INPUT_ORACLE_PASSWORD"))
+	_, ok := os.LookupEnv("ENV_INPUT_REMOVED")
+	assert.False(t, ok)
+	assert.Equal(t, "/opt/oracle", os.Getenv("LD_LIBRARY_PATH"))
+
+	_, err = ParseReloadOption(&ControlMessage{Action: ActionReload, Args: []string{"--no-such-flag"}})
+	assert.Error(t, err)
+}
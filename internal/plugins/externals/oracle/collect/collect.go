@@ -10,8 +10,10 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 
+	"github.com/GuanceCloud/cliutils/logger"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/oracle/collect/ccommon"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/oracle/collect/coracle"
 )
@@ -33,15 +35,32 @@ func Run(opt *ccommon.Option) {
 
 	ch := make(chan struct{}, len(definedInputList))
 
+	var (
+		mu      sync.Mutex
+		started []ccommon.IInput
+	)
+
+	// DataKit sends the reloaded config by stdin, so that it's applied without
+	// restarting the process. It's watched before the inputs constructed, the
+	// config reloaded meanwhile is applied once the inputs started.
+	go ccommon.WatchControl(logger.SLogger("control"), os.Stdin, func(msg *ccommon.ControlMessage) {
+		mu.Lock()
+		defer mu.Unlock()
+		reload(started, msg)
+	})
+
+	mu.Lock()
 	for _, inputFunc := range definedInputList {
 		input := inputFunc(infoMsgs, opt)
 		if input != nil {
+			started = append(started, input)
 			go func() {
 				input.Run()
 				ch <- struct{}{}
 			}()
 		}
 	}
+	mu.Unlock()
 
 	count := 0
 	select {
@@ -55,6 +74,27 @@ func Run(opt *ccommon.Option) {
 	}
 }
 
+func reload(started []ccommon.IInput, msg *ccommon.ControlMessage) {
+	l := logger.SLogger("control")
+
+	if msg.Action != ccommon.ActionReload {
+		l.Warnf("unknown control action %q, ignored", msg.Action)
+		return
+	}
+
+	opt, err := ccommon.ParseReloadOption(msg)
+	if err != nil {
+		l.Errorf("parse reloaded args failed: %s, ignored", err.Error())
+		return
+	}
+
+	for _, input := range started {
+		if r, ok := input.(ccommon.Reloader); ok {
+			r.Reload(opt)
+		}
+	}
+}
+
 var infoMsgs []string
 
 func PrintInfof(format string, args ...interface{}) {
@@ -0,0 +1,199 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package coracle
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/oracle/collect/ccommon"
+)
+
+const (
+	waitClassOnCPU = "ON CPU"
+
+	// ASH_SAMPLE_QUERY samples active user sessions like ASH does, without
+	// querying v$active_session_history which requires the Diagnostics Pack.
+	// Formatted with the inst_id column and the session view.
+	//
+	//nolint:stylecheck
+	ASH_SAMPLE_QUERY = `SELECT
+  %s inst_id,
+  s.sql_id,
+  DECODE(s.state, 'WAITING', s.wait_class, 'ON CPU') wait_class
+FROM %s s
+WHERE
+  s.status = 'ACTIVE'
+  AND s.type = 'USER'
+  AND s.sid <> SYS_CONTEXT('USERENV', 'SID')
+  AND (s.state <> 'WAITING' OR s.wait_class <> 'Idle')`
+)
+
+type ashSampleRowDB struct {
+	InstID    sql.NullString `db:"INST_ID"`
+	SQLID     sql.NullString `db:"SQL_ID"`
+	WaitClass string         `db:"WAIT_CLASS"`
+}
+
+type ashWaitClass struct {
+	inst, waitClass string
+}
+
+type ashSQL struct {
+	inst, sqlID     string
+	onCPU, waiting  int
+	topWaitClass    string
+	waitClassCounts map[string]int
+}
+
+func (s *ashSQL) sessions() int {
+	return s.onCPU + s.waiting
+}
+
+type ashMetrics struct {
+	x collectParameters
+}
+
+var _ ccommon.DBMetricsCollector = (*ashMetrics)(nil)
+
+func newASHMetrics(opts ...collectOption) *ashMetrics {
+	m := &ashMetrics{}
+
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&m.x)
+		}
+	}
+
+	return m
+}
+
+func (m *ashMetrics) Collect() ([]*point.Point, error) {
+	l.Debug("Collect entry")
+
+	// disabled by --ash-top 0, which could be changed by reloading.
+	topN := m.x.Ipt.ashTopN
+	if topN <= 0 {
+		return nil, nil
+	}
+
+	view, instCol := m.x.Ipt.instView("session")
+
+	rows := []ashSampleRowDB{}
+	if err := selectWrapper(m.x.Ipt, &rows, fmt.Sprintf(ASH_SAMPLE_QUERY, instCol, view)); err != nil {
+		return nil, fmt.Errorf("failed to sample active sessions: %w", err)
+	}
+
+	waitClasses, topSQL := aggregateASH(rows, topN)
+
+	var pts []*point.Point
+	hostTag := ccommon.GetHostTag(l, m.x.Ipt.host)
+
+	for _, wc := range sortedWaitClasses(waitClasses) {
+		var kvs point.KVs
+		if wc.inst != "" {
+			kvs = kvs.AddTag(instID, wc.inst)
+		}
+		kvs = kvs.AddTag("wait_class", wc.waitClass)
+		kvs = kvs.Add("active_sessions", waitClasses[wc], false, false)
+
+		pts = append(pts, ccommon.BuildPointMetric(kvs, metricNameWaitClass, m.x.Ipt.tags, hostTag))
+	}
+
+	for _, s := range topSQL {
+		var kvs point.KVs
+		if s.inst != "" {
+			kvs = kvs.AddTag(instID, s.inst)
+		}
+		kvs = kvs.AddTag("sql_id", s.sqlID)
+		kvs = kvs.AddTag("top_wait_class", s.topWaitClass)
+		kvs = kvs.Add("active_sessions", s.sessions(), false, false)
+		kvs = kvs.Add("on_cpu_sessions", s.onCPU, false, false)
+		kvs = kvs.Add("waiting_sessions", s.waiting, false, false)
+
+		pts = append(pts, ccommon.BuildPointMetric(kvs, metricNameTopSQL, m.x.Ipt.tags, hostTag))
+	}
+
+	return pts, nil
+}
+
+// aggregateASH counts sampled sessions by wait class, and returns the top N
+// SQL of each instance by active sessions.
+func aggregateASH(rows []ashSampleRowDB, topN int) (map[ashWaitClass]int, []*ashSQL) {
+	waitClasses := map[ashWaitClass]int{}
+	sqls := map[[2]string]*ashSQL{}
+
+	for _, r := range rows {
+		inst := r.InstID.String
+		waitClasses[ashWaitClass{inst: inst, waitClass: r.WaitClass}]++
+
+		// sessions not running SQL, like PL/SQL waiting on locks.
+		if !r.SQLID.Valid || r.SQLID.String == "" {
+			continue
+		}
+
+		key := [2]string{inst, r.SQLID.String}
+		s, ok := sqls[key]
+		if !ok {
+			s = &ashSQL{inst: inst, sqlID: r.SQLID.String, waitClassCounts: map[string]int{}}
+			sqls[key] = s
+		}
+
+		if r.WaitClass == waitClassOnCPU {
+			s.onCPU++
+		} else {
+			s.waiting++
+		}
+		s.waitClassCounts[r.WaitClass]++
+	}
+
+	var all []*ashSQL
+	for _, s := range sqls {
+		for wc, n := range s.waitClassCounts {
+			if n > s.waitClassCounts[s.topWaitClass] || (n == s.waitClassCounts[s.topWaitClass] && wc < s.topWaitClass) {
+				s.topWaitClass = wc
+			}
+		}
+		all = append(all, s)
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].inst != all[j].inst {
+			return all[i].inst < all[j].inst
+		}
+		if all[i].sessions() != all[j].sessions() {
+			return all[i].sessions() > all[j].sessions()
+		}
+		return all[i].sqlID < all[j].sqlID
+	})
+
+	var top []*ashSQL
+	count := map[string]int{}
+	for _, s := range all {
+		if count[s.inst] < topN {
+			top = append(top, s)
+			count[s.inst]++
+		}
+	}
+
+	return waitClasses, top
+}
+
+func sortedWaitClasses(waitClasses map[ashWaitClass]int) []ashWaitClass {
+	keys := make([]ashWaitClass, 0, len(waitClasses))
+	for k := range waitClasses {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].inst != keys[j].inst {
+			return keys[i].inst < keys[j].inst
+		}
+		return keys[i].waitClass < keys[j].waitClass
+	})
+	return keys
+}
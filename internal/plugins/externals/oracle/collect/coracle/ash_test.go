@@ -0,0 +1,59 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package coracle
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAggregateASH(t *testing.T) {
+	row := func(inst, sqlID, waitClass string) ashSampleRowDB {
+		return ashSampleRowDB{
+			InstID:    sql.NullString{String: inst, Valid: inst != ""},
+			SQLID:     sql.NullString{String: sqlID, Valid: sqlID != ""},
+			WaitClass: waitClass,
+		}
+	}
+
+	waitClasses, top := aggregateASH([]ashSampleRowDB{
+		row("1", "a", waitClassOnCPU),
+		row("1", "a", "User I/O"),
+		row("1", "a", "User I/O"),
+		row("1", "b", waitClassOnCPU),
+		row("1", "c", "Concurrency"),
+		row("1", "c", "Concurrency"),
+		row("1", "", "Application"),
+		row("2", "d", waitClassOnCPU),
+	}, 2)
+
+	assert.Equal(t, map[ashWaitClass]int{
+		{inst: "1", waitClass: waitClassOnCPU}: 2,
+		{inst: "1", waitClass: "User I/O"}:     2,
+		{inst: "1", waitClass: "Concurrency"}:  2,
+		{inst: "1", waitClass: "Application"}:  1,
+		{inst: "2", waitClass: waitClassOnCPU}: 1,
+	}, waitClasses)
+
+	// top 2 of each instance
+	require.Len(t, top, 3)
+
+	assert.Equal(t, "a", top[0].sqlID)
+	assert.Equal(t, 3, top[0].sessions())
+	assert.Equal(t, 1, top[0].onCPU)
+	assert.Equal(t, 2, top[0].waiting)
+	assert.Equal(t, "User I/O", top[0].topWaitClass)
+
+	assert.Equal(t, "c", top[1].sqlID)
+	assert.Equal(t, "Concurrency", top[1].topWaitClass)
+
+	assert.Equal(t, "2", top[2].inst)
+	assert.Equal(t, "d", top[2].sqlID)
+	assert.Equal(t, waitClassOnCPU, top[2].topWaitClass)
+}
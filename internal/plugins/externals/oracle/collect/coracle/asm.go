@@ -0,0 +1,139 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package coracle
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/oracle/collect/ccommon"
+)
+
+type asmMetrics struct {
+	x collectParameters
+}
+
+var _ ccommon.DBMetricsCollector = (*asmMetrics)(nil)
+
+func newASMMetrics(opts ...collectOption) *asmMetrics {
+	m := &asmMetrics{}
+
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&m.x)
+		}
+	}
+
+	return m
+}
+
+func (m *asmMetrics) Collect() ([]*point.Point, error) {
+	l.Debug("Collect entry")
+
+	// disk groups are shared by RAC instances.
+	if !m.x.Ipt.clusterWide() {
+		l.Debug("ASM disk groups collected by other RAC instance, skipped")
+		return nil, nil
+	}
+	return m.diskgroups()
+}
+
+// ASM_QUERY is the get ASM disk group info SQL query, disks and running
+// rebalance operations are aggregated by disk group.
+// https://docs.oracle.com/en/database/oracle/oracle-database/19/refrn/V-ASM_DISKGROUP.html
+//
+//nolint:stylecheck
+const ASM_QUERY = `SELECT
+  g.name diskgroup_name,
+  g.state,
+  g.type,
+  g.total_mb,
+  g.free_mb,
+  g.usable_file_mb,
+  g.required_mirror_free_mb,
+  g.offline_disks,
+  NVL(d.disks, 0) disks,
+  NVL(d.read_errs, 0) read_errs,
+  NVL(d.write_errs, 0) write_errs,
+  NVL(o.operations, 0) rebalance_operations,
+  NVL(o.est_minutes, 0) rebalance_est_minutes
+FROM
+  v$asm_diskgroup g,
+  (SELECT group_number, COUNT(*) disks, SUM(read_errs) read_errs, SUM(write_errs) write_errs
+    FROM v$asm_disk GROUP BY group_number) d,
+  (SELECT group_number, COUNT(*) operations, MAX(est_minutes) est_minutes
+    FROM v$asm_operation WHERE operation = 'REBAL' GROUP BY group_number) o
+WHERE
+  g.group_number = d.group_number(+) AND g.group_number = o.group_number(+)`
+
+type asmRowDB struct {
+	DiskgroupName        string  `db:"DISKGROUP_NAME"`
+	State                string  `db:"STATE"`
+	Type                 string  `db:"TYPE"`
+	TotalMB              float64 `db:"TOTAL_MB"`
+	FreeMB               float64 `db:"FREE_MB"`
+	UsableFileMB         float64 `db:"USABLE_FILE_MB"`
+	RequiredMirrorFreeMB float64 `db:"REQUIRED_MIRROR_FREE_MB"`
+	OfflineDisks         float64 `db:"OFFLINE_DISKS"`
+	Disks                float64 `db:"DISKS"`
+	ReadErrs             float64 `db:"READ_ERRS"`
+	WriteErrs            float64 `db:"WRITE_ERRS"`
+	RebalanceOperations  float64 `db:"REBALANCE_OPERATIONS"`
+	RebalanceEstMinutes  float64 `db:"REBALANCE_EST_MINUTES"`
+}
+
+func (m *asmMetrics) diskgroups() ([]*point.Point, error) {
+	rows := []asmRowDB{}
+	if err := selectWrapper(m.x.Ipt, &rows, ASM_QUERY); err != nil {
+		if strings.Contains(err.Error(), "ORA-00942") {
+			l.Debugf("asm: %s, ignored", err)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to collect ASM disk groups: %w", err)
+	}
+
+	// no disk group mounted if ASM not enabled.
+	l.Debugf("asm: len(rows) = %d", len(rows))
+
+	var pts []*point.Point
+	hostTag := ccommon.GetHostTag(l, m.x.Ipt.host)
+
+	for _, r := range rows {
+		pts = append(pts, ccommon.BuildPointMetric(
+			asmKVs(r), m.x.MetricName,
+			m.x.Ipt.tags, hostTag,
+		))
+	}
+
+	return pts, nil
+}
+
+func asmKVs(r asmRowDB) point.KVs {
+	const mb = 1024 * 1024
+
+	var kvs point.KVs
+
+	kvs = kvs.AddTag("diskgroup_name", r.DiskgroupName)
+	kvs = kvs.AddTag("state", r.State)
+	kvs = kvs.AddTag("type", r.Type)
+
+	kvs = kvs.Add("total_space", r.TotalMB*mb, false, false)
+	kvs = kvs.Add("free_space", r.FreeMB*mb, false, false)
+	kvs = kvs.Add("usable_file_space", r.UsableFileMB*mb, false, false)
+	kvs = kvs.Add("required_mirror_free_space", r.RequiredMirrorFreeMB*mb, false, false)
+	if r.TotalMB > 0 {
+		kvs = kvs.Add("used_percent", (r.TotalMB-r.FreeMB)/r.TotalMB*100, false, false)
+	}
+	kvs = kvs.Add("disks", r.Disks, false, false)
+	kvs = kvs.Add("offline_disks", r.OfflineDisks, false, false)
+	kvs = kvs.Add("read_errors", r.ReadErrs, false, false)
+	kvs = kvs.Add("write_errors", r.WriteErrs, false, false)
+	kvs = kvs.Add("rebalance_operations", r.RebalanceOperations, false, false)
+	kvs = kvs.Add("rebalance_est_minutes", r.RebalanceEstMinutes, false, false)
+
+	return kvs
+}
@@ -0,0 +1,39 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package coracle
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestASMKVs(t *testing.T) {
+	kvs := asmKVs(asmRowDB{
+		DiskgroupName:       "DATA",
+		State:               "MOUNTED",
+		Type:                "NORMAL",
+		TotalMB:             1000,
+		FreeMB:              250,
+		UsableFileMB:        100,
+		OfflineDisks:        1,
+		Disks:               4,
+		RebalanceOperations: 1,
+		RebalanceEstMinutes: 12,
+	})
+
+	assert.Len(t, kvs.Tags(), 3)
+	assert.Equal(t, "DATA", kvs.Get("diskgroup_name").GetS())
+	assert.Equal(t, "MOUNTED", kvs.Get("state").GetS())
+	assert.Equal(t, 1000.0*1024*1024, kvs.Get("total_space").GetF())
+	assert.Equal(t, 75.0, kvs.Get("used_percent").GetF())
+	assert.Equal(t, 1.0, kvs.Get("offline_disks").GetF())
+	assert.Equal(t, 12.0, kvs.Get("rebalance_est_minutes").GetF())
+
+	// empty disk group
+	kvs = asmKVs(asmRowDB{DiskgroupName: "FRA", State: "DISMOUNTED"})
+	assert.Nil(t, kvs.Get("used_percent"))
+}
@@ -0,0 +1,169 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package coracle
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/oracle/collect/ccommon"
+)
+
+const (
+	connectTimeout    = 10 * time.Second
+	minConnectBackoff = 3 * time.Second
+	maxConnectBackoff = 2 * time.Minute
+)
+
+// lostConnectionErrors are errors the connection should be established again,
+// with a failover connect descriptor, the next available address is connected.
+var lostConnectionErrors = []string{
+	"ORA-01012", // not logged on
+	"ORA-03113", // end-of-file on communication channel
+	"ORA-03114", // not connected to ORACLE
+	"ORA-03135", // connection lost contact
+	"ORA-12537", // TNS:connection closed
+	"database is closed",
+}
+
+func isConnectionLost(err error) bool {
+	for _, s := range lostConnectionErrors {
+		if strings.Contains(err.Error(), s) {
+			return true
+		}
+	}
+	return false
+}
+
+// nextBackoff doubles the retry delay, limited by maxConnectBackoff.
+func nextBackoff(d time.Duration) time.Duration {
+	if d < minConnectBackoff {
+		return minConnectBackoff
+	}
+	if d *= 2; d > maxConnectBackoff {
+		return maxConnectBackoff
+	}
+	return d
+}
+
+// connString returns the godror connect string like user/password@connect.
+//
+// The connect part is the --connect-string(TNS alias or connect descriptor) if
+// set, or host:port/service_name. Login by the wallet if wallet set and
+// username empty, both username and password are empty for the external
+// authentication. For proxy authentication, the username is like proxy[client].
+func (ipt *Input) connString() string {
+	connect := ipt.connectString
+	if connect == "" {
+		connect = fmt.Sprintf("%s:%s/%s", ipt.host, ipt.port, ipt.serviceName)
+	}
+
+	if ipt.wallet != "" && ipt.user == "" {
+		return "/@" + connect
+	}
+
+	user := ipt.user
+	if ipt.proxyClient != "" {
+		user += "[" + ipt.proxyClient + "]"
+	}
+
+	return fmt.Sprintf("%s/%s@%s", user, ipt.password, connect)
+}
+
+// reconnect connects to the database again after the connection lost. Retries
+// are delayed by backoff, so queries would not connect one by one while the
+// database is down.
+func (ipt *Input) reconnect() error {
+	if now := time.Now(); now.Before(ipt.nextReconnect) {
+		return fmt.Errorf("connection lost, reconnect after %s", ipt.nextReconnect.Sub(now).Round(time.Second))
+	}
+
+	if err := ipt.ConnectDB(); err != nil {
+		ipt.reconnectBackoff = nextBackoff(ipt.reconnectBackoff)
+		ipt.nextReconnect = time.Now().Add(ipt.reconnectBackoff)
+		return err
+	}
+
+	ipt.reconnectBackoff = 0
+	ipt.nextReconnect = time.Time{}
+	return nil
+}
+
+// connectIfNil connects to the database if it's not connected on start up.
+func (ipt *Input) connectIfNil() error {
+	if ipt.db != nil {
+		return nil
+	}
+
+	return ipt.reconnect()
+}
+
+// ping checks the connection, reconnect if failed.
+func (ipt *Input) ping() error {
+	if err := ipt.connectIfNil(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+	defer cancel()
+
+	if err := ipt.db.PingContext(ctx); err != nil {
+		l.Warnf("ping failed: %s, reconnecting", err.Error())
+		return ipt.reconnect()
+	}
+
+	ipt.lastConnected = time.Now()
+	return nil
+}
+
+type healthMetrics struct {
+	x collectParameters
+}
+
+var _ ccommon.DBMetricsCollector = (*healthMetrics)(nil)
+
+func newHealthMetrics(opts ...collectOption) *healthMetrics {
+	m := &healthMetrics{}
+
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&m.x)
+		}
+	}
+
+	return m
+}
+
+// Collect reports the connection state, it's reported even if the database
+// is unreachable.
+func (m *healthMetrics) Collect() ([]*point.Point, error) {
+	l.Debug("Collect entry")
+
+	ipt := m.x.Ipt
+
+	connected := 1
+	if err := ipt.ping(); err != nil {
+		ccommon.ReportErrorf(inputName, l, "connection unavailable: %v", err)
+		connected = 0
+	}
+
+	return []*point.Point{
+		ccommon.BuildPointMetric(healthKVs(connected, time.Since(ipt.lastConnected)),
+			m.x.MetricName, ipt.tags, ccommon.GetHostTag(l, ipt.host)),
+	}, nil
+}
+
+func healthKVs(connected int, sinceConnected time.Duration) point.KVs {
+	var kvs point.KVs
+
+	kvs = kvs.Add("connected", connected, false, true)
+	kvs = kvs.Add("since_last_connected", sinceConnected.Seconds(), false, true)
+
+	return kvs
+}
@@ -0,0 +1,94 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package coracle
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConnString(t *testing.T) {
+	const descriptor = `(DESCRIPTION=(FAILOVER=on)(ADDRESS_LIST=(ADDRESS=(PROTOCOL=TCP)(HOST=db1)(PORT=1521))(ADDRESS=(PROTOCOL=TCP)(HOST=db2)(PORT=1521)))(CONNECT_DATA=(SERVICE_NAME=orcl)))`
+
+	cases := []struct {
+		name string
+		ipt  *Input
+		want string
+	}{
+		{
+			name: "host",
+			ipt:  &Input{user: "dk", password: "secret", host: "db1", port: "1521", serviceName: "orcl"},
+			want: "dk/secret@db1:1521/orcl",
+		},
+		{
+			name: "connect-descriptor",
+			ipt:  &Input{user: "dk", password: "secret", host: "db1", port: "1521", connectString: descriptor},
+			want: "dk/secret@" + descriptor,
+		},
+		{
+			name: "wallet",
+			ipt:  &Input{wallet: "/opt/wallet", connectString: "orcl_high"},
+			want: "/@orcl_high",
+		},
+		{
+			name: "wallet-with-user",
+			ipt:  &Input{wallet: "/opt/wallet", user: "dk", password: "secret", connectString: "orcl_high"},
+			want: "dk/secret@orcl_high",
+		},
+		{
+			name: "proxy",
+			ipt:  &Input{user: "proxy", password: "secret", proxyClient: "dk", host: "db1", port: "1521", serviceName: "orcl"},
+			want: "proxy[dk]/secret@db1:1521/orcl",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, tc.ipt.connString())
+		})
+	}
+}
+
+func TestNextBackoff(t *testing.T) {
+	assert.Equal(t, minConnectBackoff, nextBackoff(0))
+	assert.Equal(t, 2*minConnectBackoff, nextBackoff(minConnectBackoff))
+	assert.Equal(t, maxConnectBackoff, nextBackoff(maxConnectBackoff-time.Second))
+	assert.Equal(t, maxConnectBackoff, nextBackoff(maxConnectBackoff))
+}
+
+func TestIsConnectionLost(t *testing.T) {
+	assert.True(t, isConnectionLost(errors.New("ORA-03113: end-of-file on communication channel")))
+	assert.True(t, isConnectionLost(errors.New("sql: database is closed")))
+	assert.False(t, isConnectionLost(errors.New("ORA-00942: table or view does not exist")))
+}
+
+func TestHealthKVs(t *testing.T) {
+	kvs := healthKVs(0, 90*time.Second)
+	assert.Equal(t, int64(0), kvs.Get("connected").GetI())
+	assert.Equal(t, 90.0, kvs.Get("since_last_connected").GetF())
+}
+
+func TestHealthNotConnected(t *testing.T) {
+	start := time.Now().Add(-time.Minute)
+	ipt := &Input{
+		nextReconnect: time.Now().Add(time.Hour),
+		lastConnected: start,
+	}
+
+	m := newHealthMetrics(withInput(ipt), withMetricName(metricNameHealth))
+
+	pts, err := m.Collect()
+	assert.NoError(t, err)
+	assert.Len(t, pts, 1)
+	assert.Equal(t, int64(0), pts[0].Get("connected"))
+	assert.GreaterOrEqual(t, pts[0].Get("since_last_connected").(float64), 60.0)
+
+	assert.Nil(t, ipt.q("SELECT 1 FROM dual"))
+	assert.Error(t, selectWrapper(ipt, &[]struct{}{}, "SELECT 1 FROM dual"))
+}
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"reflect"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/GuanceCloud/cliutils/point"
@@ -53,9 +54,15 @@ func (m *customQueryCollector) collectMysqlCustomQueries() error {
 
 	l.Debugf("m.x.Ipt.Query = %v", m.x.Ipt.Query)
 
+	now := time.Now()
 	for _, item := range m.x.Ipt.Query {
 		l.Debugf("item = %s", item.SQL)
 
+		if !item.due(now) {
+			continue
+		}
+		item.lastRun = now
+
 		arr := getCleanMysqlCustomQueries(m.x.Ipt.q(item.SQL))
 		if arr == nil {
 			l.Debug("arr == nil")
@@ -101,8 +108,7 @@ func (m *customQueryCollector) buildMysqlCustomQueries() ([]*point.Point, error)
 
 			for _, fdKey := range qy.Fields {
 				if value, ok := item[fdKey]; ok {
-					// transform all fields to float64
-					kvs = kvs.Add(fdKey, cast.ToFloat64(value), false, false)
+					kvs = kvs.Add(fdKey, qy.fieldValue(fdKey, value), false, false)
 				}
 			}
 
@@ -118,6 +124,29 @@ func (m *customQueryCollector) buildMysqlCustomQueries() ([]*point.Point, error)
 	return pts, nil
 }
 
+// due checks if the query should run, queries without interval run on
+// every collection.
+func (q *customQuery) due(now time.Time) bool {
+	return q.Interval <= 0 || q.lastRun.IsZero() || now.Sub(q.lastRun) >= q.Interval
+}
+
+// fieldValue converts the column value by type in FieldTypes, float64 by default.
+func (q *customQuery) fieldValue(column string, value interface{}) interface{} {
+	switch strings.ToLower(q.FieldTypes[column]) {
+	case "int":
+		return cast.ToInt64(value)
+	case "bool":
+		return cast.ToBool(value)
+	case "string":
+		return cast.ToString(value)
+	case "", "float":
+		return cast.ToFloat64(value)
+	default:
+		l.Warnf("unknown type %q of field %s, use float", q.FieldTypes[column], column)
+		return cast.ToFloat64(value)
+	}
+}
+
 func getCleanMysqlCustomQueries(r rows) []map[string]interface{} {
 	l.Debugf("getCleanMysqlCustomQueries entry")
 
@@ -0,0 +1,42 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package coracle
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCustomQueryDue(t *testing.T) {
+	now := time.Now()
+
+	q := &customQuery{}
+	assert.True(t, q.due(now))
+
+	q = &customQuery{Interval: 5 * time.Minute}
+	assert.True(t, q.due(now))
+
+	q.lastRun = now
+	assert.False(t, q.due(now.Add(time.Minute)))
+	assert.True(t, q.due(now.Add(5*time.Minute)))
+}
+
+func TestCustomQueryFieldValue(t *testing.T) {
+	q := &customQuery{FieldTypes: map[string]string{
+		"CNT":     "int",
+		"ENABLED": "bool",
+		"NAME":    "STRING",
+		"BAD":     "decimal",
+	}}
+
+	assert.Equal(t, int64(12), q.fieldValue("CNT", 12.0))
+	assert.Equal(t, true, q.fieldValue("ENABLED", int64(1)))
+	assert.Equal(t, "orders", q.fieldValue("NAME", "orders"))
+	assert.Equal(t, 1.5, q.fieldValue("BAD", "1.5"))
+	assert.Equal(t, 3.0, q.fieldValue("VALUE", int64(3)))
+}
@@ -0,0 +1,279 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package coracle
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/oracle/collect/ccommon"
+)
+
+const (
+	// DATAGUARD_STATS_QUERY is the get lag SQL query on standby.
+	// https://docs.oracle.com/en/database/oracle/oracle-database/19/refrn/V-DATAGUARD_STATS.html
+	//
+	//nolint:stylecheck
+	DATAGUARD_STATS_QUERY = `SELECT name, value FROM v$dataguard_stats
+  WHERE name IN ('transport lag', 'apply lag', 'apply finish time')`
+
+	//nolint:stylecheck
+	DATAGUARD_ROLE_QUERY = `SELECT database_role, db_unique_name FROM v$database`
+
+	//nolint:stylecheck
+	ARCHIVE_GAP_QUERY = `SELECT NVL(SUM(high_sequence# - low_sequence# + 1), 0) FROM v$archive_gap`
+
+	// ARCHIVE_DEST_QUERY is the get status SQL query of standby destinations.
+	//
+	//nolint:stylecheck
+	ARCHIVE_DEST_QUERY = `SELECT
+  d.dest_id,
+  d.dest_name,
+  d.destination,
+  d.status,
+  d.error,
+  s.gap_status,
+  s.archived_seq# archived_seq,
+  s.applied_seq# applied_seq
+FROM
+  v$archive_dest d, v$archive_dest_status s
+WHERE
+  d.dest_id = s.dest_id AND d.target = 'STANDBY' AND d.status <> 'INACTIVE'`
+)
+
+type dataguardStatsRowDB struct {
+	Name  string         `db:"NAME"`
+	Value sql.NullString `db:"VALUE"`
+}
+
+type dataguardRoleRowDB struct {
+	DatabaseRole string `db:"DATABASE_ROLE"`
+	DBUniqueName string `db:"DB_UNIQUE_NAME"`
+}
+
+type archiveDestRowDB struct {
+	DestID      int             `db:"DEST_ID"`
+	DestName    string          `db:"DEST_NAME"`
+	Destination sql.NullString  `db:"DESTINATION"`
+	Status      string          `db:"STATUS"`
+	Error       sql.NullString  `db:"ERROR"`
+	GapStatus   sql.NullString  `db:"GAP_STATUS"`
+	ArchivedSeq sql.NullFloat64 `db:"ARCHIVED_SEQ"`
+	AppliedSeq  sql.NullFloat64 `db:"APPLIED_SEQ"`
+}
+
+type dataguardMetrics struct {
+	x collectParameters
+
+	// last transport error of each destination.
+	destErrors map[string]string
+	events     []*point.Point
+}
+
+var _ ccommon.DBMetricsCollector = (*dataguardMetrics)(nil)
+
+func newDataguardMetrics(opts ...collectOption) *dataguardMetrics {
+	m := &dataguardMetrics{destErrors: map[string]string{}}
+
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&m.x)
+		}
+	}
+
+	return m
+}
+
+func (m *dataguardMetrics) Collect() ([]*point.Point, error) {
+	l.Debug("Collect entry")
+
+	if !m.x.Ipt.clusterWide() {
+		l.Debug("Data Guard collected by other RAC instance, skipped")
+		return nil, nil
+	}
+
+	var role dataguardRoleRowDB
+	if err := getWrapper(m.x.Ipt, &role, DATAGUARD_ROLE_QUERY); err != nil {
+		return nil, fmt.Errorf("failed to get database role: %w", err)
+	}
+
+	var pts []*point.Point
+	hostTag := ccommon.GetHostTag(l, m.x.Ipt.host)
+
+	if role.DatabaseRole != "PRIMARY" {
+		kvs, err := m.standby(role)
+		if err != nil {
+			return nil, err
+		}
+		pts = append(pts, ccommon.BuildPointMetric(kvs, metricNameDataguard, m.x.Ipt.tags, hostTag))
+	}
+
+	rows := []archiveDestRowDB{}
+	if err := selectWrapper(m.x.Ipt, &rows, ARCHIVE_DEST_QUERY); err != nil {
+		if strings.Contains(err.Error(), "ORA-00942") {
+			l.Debugf("dataguard: %s, ignored", err)
+			return pts, nil
+		}
+		return nil, fmt.Errorf("failed to collect archive destinations: %w", err)
+	}
+
+	for _, r := range rows {
+		kvs := archiveDestKVs(r)
+		pts = append(pts, ccommon.BuildPointMetric(kvs, metricNameArchiveDest, m.x.Ipt.tags, hostTag))
+
+		if event := m.destEvent(r); event != nil {
+			m.events = append(m.events, ccommon.BuildPointEvent(event, metricNameDataguardEvent, m.x.Ipt.tags, hostTag))
+		}
+	}
+
+	return pts, nil
+}
+
+// standby returns lag of the standby database.
+func (m *dataguardMetrics) standby(role dataguardRoleRowDB) (point.KVs, error) {
+	var kvs point.KVs
+
+	kvs = kvs.AddTag("database_role", role.DatabaseRole)
+	kvs = kvs.AddTag("db_unique_name", role.DBUniqueName)
+
+	rows := []dataguardStatsRowDB{}
+	if err := selectWrapper(m.x.Ipt, &rows, DATAGUARD_STATS_QUERY); err != nil {
+		return nil, fmt.Errorf("failed to collect Data Guard stats: %w", err)
+	}
+
+	for _, r := range rows {
+		// value is NULL if not computed yet.
+		if !r.Value.Valid {
+			continue
+		}
+
+		du, err := parseDataguardInterval(r.Value.String)
+		if err != nil {
+			l.Warnf("invalid %s %q: %s, ignored", r.Name, r.Value.String, err)
+			continue
+		}
+		kvs = kvs.Add(strings.ReplaceAll(r.Name, " ", "_"), du.Seconds(), false, false)
+	}
+
+	var gap float64
+	if err := getWrapper(m.x.Ipt, &gap, ARCHIVE_GAP_QUERY); err != nil {
+		return nil, fmt.Errorf("failed to get archive gap: %w", err)
+	}
+	kvs = kvs.Add("archive_gap_sequences", gap, false, false)
+
+	return kvs, nil
+}
+
+func archiveDestKVs(r archiveDestRowDB) point.KVs {
+	var kvs point.KVs
+
+	kvs = kvs.AddTag("dest_id", strconv.Itoa(r.DestID))
+	kvs = kvs.AddTag("dest_name", r.DestName)
+	kvs = kvs.AddTag("status", r.Status)
+	if r.Destination.String != "" {
+		kvs = kvs.AddTag("destination", r.Destination.String)
+	}
+	if r.GapStatus.String != "" {
+		kvs = kvs.AddTag("gap_status", r.GapStatus.String)
+	}
+
+	var hasError int
+	if r.Error.String != "" {
+		hasError = 1
+	}
+	kvs = kvs.Add("error", hasError, false, false)
+
+	if r.ArchivedSeq.Valid {
+		kvs = kvs.Add("archived_seq", r.ArchivedSeq.Float64, false, false)
+	}
+	if r.AppliedSeq.Valid {
+		kvs = kvs.Add("applied_seq", r.AppliedSeq.Float64, false, false)
+	}
+	if r.ArchivedSeq.Valid && r.AppliedSeq.Valid {
+		kvs = kvs.Add("sequence_gap", r.ArchivedSeq.Float64-r.AppliedSeq.Float64, false, false)
+	}
+
+	return kvs
+}
+
+// destEvent returns the key event if transport error of the destination
+// occurred, changed or recovered since last collection.
+func (m *dataguardMetrics) destEvent(r archiveDestRowDB) point.KVs {
+	prev, curr := m.destErrors[r.DestName], strings.TrimSpace(r.Error.String)
+	if prev == curr {
+		return nil
+	}
+	m.destErrors[r.DestName] = curr
+
+	var kvs point.KVs
+	kvs = kvs.AddTag("dest_name", r.DestName)
+	if r.Destination.String != "" {
+		kvs = kvs.AddTag("destination", r.Destination.String)
+	}
+
+	if curr == "" {
+		return kvs.Add("df_title", fmt.Sprintf("Oracle Data Guard transport to %s recovered", r.DestName), false, false).
+			Add("df_message", fmt.Sprintf("transport to %s (%s) recovered from: %s", r.DestName, r.Destination.String, prev), false, false).
+			Add("df_status", "ok", false, false)
+	}
+
+	return kvs.Add("df_title", fmt.Sprintf("Oracle Data Guard transport to %s failed", r.DestName), false, false).
+		Add("df_message", fmt.Sprintf("transport to %s (%s) in status %s: %s", r.DestName, r.Destination.String, r.Status, curr), false, false).
+		Add("df_status", "error", false, false)
+}
+
+// dataguardEvents reports key events found by dataguardMetrics.
+type dataguardEvents struct {
+	m *dataguardMetrics
+}
+
+var _ ccommon.DBMetricsCollector = (*dataguardEvents)(nil)
+
+func (e *dataguardEvents) Collect() ([]*point.Point, error) {
+	events := e.m.events
+	e.m.events = nil
+	return events, nil
+}
+
+// parseDataguardInterval parses value of v$dataguard_stats, which is an
+// interval like +00 00:01:05 or +00 00:01:05.000.
+func parseDataguardInterval(s string) (time.Duration, error) {
+	days, clock, ok := strings.Cut(strings.TrimPrefix(strings.TrimSpace(s), "+"), " ")
+	if !ok {
+		return 0, fmt.Errorf("invalid interval")
+	}
+
+	parts := strings.Split(clock, ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("invalid interval")
+	}
+
+	d, err := strconv.Atoi(days)
+	if err != nil {
+		return 0, err
+	}
+	h, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, err
+	}
+	min, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, err
+	}
+	sec, err := strconv.ParseFloat(parts[2], 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(d)*24*time.Hour +
+		time.Duration(h)*time.Hour +
+		time.Duration(min)*time.Minute +
+		time.Duration(sec*float64(time.Second)), nil
+}
@@ -0,0 +1,77 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package coracle
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDataguardInterval(t *testing.T) {
+	du, err := parseDataguardInterval("+00 00:01:05")
+	require.NoError(t, err)
+	assert.Equal(t, 65*time.Second, du)
+
+	du, err = parseDataguardInterval("+01 02:00:00.500")
+	require.NoError(t, err)
+	assert.Equal(t, 26*time.Hour+500*time.Millisecond, du)
+
+	_, err = parseDataguardInterval("5 seconds")
+	assert.Error(t, err)
+}
+
+func TestArchiveDestKVs(t *testing.T) {
+	kvs := archiveDestKVs(archiveDestRowDB{
+		DestID:      2,
+		DestName:    "LOG_ARCHIVE_DEST_2",
+		Destination: sql.NullString{String: "stby", Valid: true},
+		Status:      "ERROR",
+		Error:       sql.NullString{String: "ORA-12541: TNS:no listener", Valid: true},
+		GapStatus:   sql.NullString{String: "RESOLVABLE GAP", Valid: true},
+		ArchivedSeq: sql.NullFloat64{Float64: 120, Valid: true},
+		AppliedSeq:  sql.NullFloat64{Float64: 100, Valid: true},
+	})
+
+	assert.Equal(t, "2", kvs.Get("dest_id").GetS())
+	assert.Equal(t, "RESOLVABLE GAP", kvs.Get("gap_status").GetS())
+	assert.Equal(t, int64(1), kvs.Get("error").GetI())
+	assert.Equal(t, 20.0, kvs.Get("sequence_gap").GetF())
+
+	kvs = archiveDestKVs(archiveDestRowDB{DestID: 3, Status: "VALID"})
+	assert.Equal(t, int64(0), kvs.Get("error").GetI())
+	assert.Nil(t, kvs.Get("sequence_gap"))
+}
+
+func TestDataguardDestEvent(t *testing.T) {
+	m := newDataguardMetrics(withInput(&Input{}))
+
+	row := archiveDestRowDB{
+		DestName:    "LOG_ARCHIVE_DEST_2",
+		Destination: sql.NullString{String: "stby", Valid: true},
+		Status:      "VALID",
+	}
+	assert.Nil(t, m.destEvent(row))
+
+	row.Status = "ERROR"
+	row.Error = sql.NullString{String: "ORA-12541: TNS:no listener", Valid: true}
+	kvs := m.destEvent(row)
+	require.NotNil(t, kvs)
+	assert.Equal(t, "error", kvs.Get("df_status").GetS())
+	assert.Contains(t, kvs.Get("df_message").GetS(), "ORA-12541")
+
+	// same error reported once
+	assert.Nil(t, m.destEvent(row))
+
+	row.Status = "VALID"
+	row.Error = sql.NullString{}
+	kvs = m.destEvent(row)
+	require.NotNil(t, kvs)
+	assert.Equal(t, "ok", kvs.Get("df_status").GetS())
+}
@@ -0,0 +1,84 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package coracle
+
+import (
+	"time"
+)
+
+const (
+	// growthWindow is how long the used space history kept, growth rate is
+	// the average over the window.
+	growthWindow = 7 * 24 * time.Hour
+	// growthSampleInterval limits samples kept in the window.
+	growthSampleInterval = time.Hour
+	// minGrowthSpan is the minimal history required to report growth rate.
+	minGrowthSpan = time.Hour
+)
+
+type usageSample struct {
+	t    time.Time
+	used float64
+}
+
+// growthTracker keeps used space history of tablespaces in memory, the history
+// is lost once the collector restarted.
+type growthTracker struct {
+	samples map[string][]usageSample
+}
+
+func newGrowthTracker() *growthTracker {
+	return &growthTracker{samples: map[string][]usageSample{}}
+}
+
+// observe records used space of the key, returns the growth rate in bytes per
+// day since the oldest sample in the window, false returned if the history is
+// shorter than minGrowthSpan.
+func (g *growthTracker) observe(key string, now time.Time, used float64) (float64, bool) {
+	samples := g.samples[key]
+
+	i := 0
+	for i < len(samples) && now.Sub(samples[i].t) > growthWindow {
+		i++
+	}
+	samples = samples[i:]
+
+	if len(samples) == 0 || now.Sub(samples[len(samples)-1].t) >= growthSampleInterval {
+		samples = append(samples, usageSample{t: now, used: used})
+	}
+	g.samples[key] = samples
+
+	span := now.Sub(samples[0].t)
+	if span < minGrowthSpan {
+		return 0, false
+	}
+
+	return (used - samples[0].used) / span.Hours() * 24, true
+}
+
+// retain removes history of keys not exist any more, such as dropped tablespaces.
+func (g *growthTracker) retain(keys map[string]bool) {
+	for k := range g.samples {
+		if !keys[k] {
+			delete(g.samples, k)
+		}
+	}
+}
+
+// daysUntilFull returns days until used space reaches max size at the growth
+// rate(bytes per day), false returned if not growing.
+func daysUntilFull(maxSize, used, rate float64) (float64, bool) {
+	if rate <= 0 {
+		return 0, false
+	}
+
+	free := maxSize - used
+	if free < 0 {
+		free = 0
+	}
+
+	return free / rate, true
+}
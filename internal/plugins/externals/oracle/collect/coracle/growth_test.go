@@ -0,0 +1,78 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package coracle
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrowthTracker(t *testing.T) {
+	g := newGrowthTracker()
+	start := time.Unix(1700000000, 0)
+
+	_, ok := g.observe("ts", start, 100)
+	assert.False(t, ok)
+
+	// short history
+	_, ok = g.observe("ts", start.Add(30*time.Minute), 110)
+	assert.False(t, ok)
+	assert.Len(t, g.samples["ts"], 1)
+
+	rate, ok := g.observe("ts", start.Add(12*time.Hour), 200)
+	require.True(t, ok)
+	assert.Equal(t, 200.0, rate)
+	assert.Len(t, g.samples["ts"], 2)
+
+	// samples out of window dropped
+	rate, ok = g.observe("ts", start.Add(growthWindow+6*time.Hour), 200)
+	require.True(t, ok)
+	assert.Equal(t, 0.0, rate)
+	assert.Len(t, g.samples["ts"], 2)
+
+	g.observe("dropped", start, 100)
+	g.retain(map[string]bool{"ts": true})
+	assert.NotContains(t, g.samples, "dropped")
+	assert.Contains(t, g.samples, "ts")
+}
+
+func TestDaysUntilFull(t *testing.T) {
+	days, ok := daysUntilFull(1000, 400, 100)
+	require.True(t, ok)
+	assert.Equal(t, 6.0, days)
+
+	days, ok = daysUntilFull(1000, 1200, 100)
+	require.True(t, ok)
+	assert.Equal(t, 0.0, days)
+
+	_, ok = daysUntilFull(1000, 400, 0)
+	assert.False(t, ok)
+
+	_, ok = daysUntilFull(1000, 400, -10)
+	assert.False(t, ok)
+}
+
+func TestTablespaceGrowthKVs(t *testing.T) {
+	m := newTablespaceMetrics()
+	start := time.Unix(1700000000, 0)
+	f := datafilesRowDB{TablespaceName: "USERS", Bytes: 500, MaxBytes: 2000}
+
+	kvs := m.growthKVs("/USERS", start, RowDB{TablespaceName: "USERS", Used: 400, Size: 2000}, f, true)
+	assert.Equal(t, 500.0, kvs.Get("allocated_space").GetF())
+	assert.Equal(t, 1500.0, kvs.Get("autoextend_headroom").GetF())
+	assert.Nil(t, kvs.Get("days_until_full"))
+
+	kvs = m.growthKVs("/USERS", start.Add(24*time.Hour), RowDB{TablespaceName: "USERS", Used: 600, Size: 2000}, f, true)
+	assert.Equal(t, 200.0, kvs.Get("growth_rate").GetF())
+	assert.Equal(t, 7.0, kvs.Get("days_until_full").GetF())
+
+	// no datafiles, such as temporary tablespaces
+	kvs = m.growthKVs("/TEMP", start, RowDB{TablespaceName: "TEMP", Used: 100, Size: 1000}, datafilesRowDB{}, false)
+	assert.Nil(t, kvs.Get("max_size"))
+}
@@ -8,6 +8,7 @@ package coracle
 
 import (
 	"bytes"
+	"context"
 	"encoding/gob"
 	"fmt"
 	"os"
@@ -27,7 +28,16 @@ const (
 	metricNameProcess    = "oracle_process"
 	metricNameTablespace = "oracle_tablespace"
 	metricNameSystem     = "oracle_system"
-	metricNameLogging    = "oracle_log"
+	metricNameASM        = "oracle_asm"
+
+	metricNameDataguard      = "oracle_dataguard"
+	metricNameArchiveDest    = "oracle_archive_dest"
+	metricNameDataguardEvent = "oracle_dataguard_event"
+
+	metricNameWaitClass = "oracle_wait_class"
+	metricNameTopSQL    = "oracle_top_sql"
+	metricNameLogging   = "oracle_log"
+	metricNameHealth    = "oracle_health"
 
 	pdbName        = "pdb_name"
 	tablespaceName = "tablespace_name"
@@ -47,17 +57,31 @@ type Input struct {
 	host          string
 	port          string
 	serviceName   string
+	connectString string
+	wallet        string
+	proxyClient   string
 	tags          map[string]string
 	election      bool
 	SlowQueryTime time.Duration
+	ashTopN       int
 	Query         []*customQuery
 
 	db                    *sqlx.DB
+	cdb                   bool // connected to a container database
+	cdbChecked            bool
+	rac                   racInfo
+	opt                   *ccommon.Option
+	reloadCh              chan *ccommon.Option
+	lastConnected         time.Time // last time connected or pinged successfully
+	nextReconnect         time.Time
+	reconnectBackoff      time.Duration
 	intervalDuration      time.Duration
 	collectors            []ccommon.DBMetricsCollector
 	collectorsLogging     []ccommon.DBMetricsCollector
+	collectorsEvent       []ccommon.DBMetricsCollector
 	datakitPostURL        string
 	datakitPostLoggingURL string
+	datakitPostEventURL   string
 
 	// collected metrics - mysql custom queries
 	mCustomQueries map[string][]map[string]interface{}
@@ -71,7 +95,12 @@ type customQuery struct {
 	Tags   []string `toml:"tags"`
 	Fields []string `toml:"fields"`
 
+	Interval   time.Duration     `toml:"interval"`
+	FieldTypes map[string]string `toml:"field_types"`
+
 	MD5Hash string
+
+	lastRun time.Time
 }
 
 func NewInput(infoMsgs []string, opt *ccommon.Option) ccommon.IInput {
@@ -100,15 +129,64 @@ func NewInput(infoMsgs []string, opt *ccommon.Option) ccommon.IInput {
 	}
 
 	ipt := &Input{
-		interval:    opt.Interval,
-		user:        opt.Username,
-		password:    opt.Password,
-		desc:        opt.InstanceDesc,
-		host:        opt.Host,
-		port:        opt.Port,
-		serviceName: opt.ServiceName,
-		tags:        make(map[string]string),
-		election:    opt.Election,
+		reloadCh: make(chan *ccommon.Option, 1),
+	}
+	ipt.applyOption(opt)
+
+	// Not blocked by the unreachable database, the collectors connect it
+	// with backoff, and oracle_health is reported during that.
+	if err := ipt.ConnectDB(); err != nil {
+		ccommon.ReportErrorf(inputName, l, inputName+" connect failed %v, retry in %s...", err, minConnectBackoff)
+		ipt.reconnectBackoff = minConnectBackoff
+		ipt.nextReconnect = time.Now().Add(minConnectBackoff)
+		ipt.lastConnected = time.Now() // since_last_connected counts from the start
+	}
+
+	healthMetric := newHealthMetrics(withInput(ipt), withMetricName(metricNameHealth))
+	proMetric := newProcessMetrics(withInput(ipt), withMetricName(metricNameProcess))
+	tsMetric := newTablespaceMetrics(withInput(ipt), withMetricName(metricNameTablespace))
+	sysMetric := newSystemMetrics(withInput(ipt), withMetricName(metricNameSystem))
+	asmMetric := newASMMetrics(withInput(ipt), withMetricName(metricNameASM))
+	dgMetric := newDataguardMetrics(withInput(ipt))
+	customMetric := newCustomQueryCollector(withInput(ipt))
+
+	// ASH and slow query collectors are always added, they are disabled by
+	// the option, so that they could be enabled by reloading.
+	ashMetric := newASHMetrics(withInput(ipt))
+
+	ipt.collectors = append(ipt.collectors, healthMetric, proMetric, tsMetric, sysMetric, asmMetric, dgMetric, customMetric, ashMetric)
+	l.Infof("collectors len = %d", len(ipt.collectors))
+
+	ipt.collectorsEvent = append(ipt.collectorsEvent, &dataguardEvents{m: dgMetric})
+
+	slowQueryLoggingR := newSlowQueryLogging(withInput(ipt), withMetricName(metricNameLogging))
+	ipt.collectorsLogging = append(ipt.collectorsLogging, slowQueryLoggingR)
+
+	return ipt
+}
+
+// applyOption sets up the input by the command line option.
+func (ipt *Input) applyOption(opt *ccommon.Option) {
+	ipt.opt = opt
+	ipt.interval = opt.Interval
+	ipt.user = opt.Username
+	ipt.password = opt.Password
+	ipt.desc = opt.InstanceDesc
+	ipt.host = opt.Host
+	ipt.port = opt.Port
+	ipt.serviceName = opt.ServiceName
+	ipt.connectString = opt.ConnectString
+	ipt.wallet = opt.Wallet
+	ipt.proxyClient = opt.ProxyClient
+	ipt.tags = make(map[string]string)
+	ipt.election = opt.Election
+
+	// The wallet directory contains cwallet.sso, sqlnet.ora and tnsnames.ora,
+	// which must be set before the first connection.
+	if ipt.wallet != "" {
+		if err := os.Setenv("TNS_ADMIN", ipt.wallet); err != nil {
+			l.Errorf("set TNS_ADMIN to %s failed: %s", ipt.wallet, err.Error())
+		}
 	}
 
 	// ENV_INPUT_ORACLE_PASSWORD
@@ -117,6 +195,7 @@ func NewInput(infoMsgs []string, opt *ccommon.Option) ccommon.IInput {
 		ipt.password = pwd
 	}
 
+	ipt.SlowQueryTime = 0
 	if len(opt.SlowQueryTime) > 0 {
 		du, err := time.ParseDuration(opt.SlowQueryTime)
 		if err != nil {
@@ -131,6 +210,7 @@ func NewInput(infoMsgs []string, opt *ccommon.Option) ccommon.IInput {
 	}
 
 	l.Infof("opt.CustomQueryFile = %s", opt.CustomQueryFile)
+	ipt.Query = nil
 	if len(opt.CustomQueryFile) > 0 {
 		ipt.Query = bytesToQuery(opt)
 	}
@@ -149,7 +229,9 @@ func NewInput(infoMsgs []string, opt *ccommon.Option) ccommon.IInput {
 	}
 
 	ipt.tags[inputName+"_service"] = ipt.serviceName
-	ipt.tags[inputName+"_server"] = fmt.Sprintf("%s:%s", ipt.host, ipt.port)
+	if ipt.host != "" || ipt.connectString == "" {
+		ipt.tags[inputName+"_server"] = fmt.Sprintf("%s:%s", ipt.host, ipt.port)
+	}
 
 	if ipt.interval != "" {
 		du, err := time.ParseDuration(ipt.interval)
@@ -161,23 +243,7 @@ func NewInput(infoMsgs []string, opt *ccommon.Option) ccommon.IInput {
 		}
 	}
 
-	for {
-		if err := ipt.ConnectDB(); err != nil {
-			ccommon.ReportErrorf(inputName, l, inputName+" connect faild %v, retry each 3 seconds...", err)
-			time.Sleep(time.Second * 3)
-			continue
-		}
-
-		break
-	}
-
-	proMetric := newProcessMetrics(withInput(ipt), withMetricName(metricNameProcess))
-	tsMetric := newTablespaceMetrics(withInput(ipt), withMetricName(metricNameTablespace))
-	sysMetric := newSystemMetrics(withInput(ipt), withMetricName(metricNameSystem))
-	customMetric := newCustomQueryCollector(withInput(ipt))
-
-	ipt.collectors = append(ipt.collectors, proMetric, tsMetric, sysMetric, customMetric)
-	l.Infof("collectors len = %d", len(ipt.collectors))
+	ipt.ashTopN = opt.ASHTopN
 
 	ipt.datakitPostURL = ccommon.GetPostURL(
 		opt.Election,
@@ -186,18 +252,18 @@ func NewInput(infoMsgs []string, opt *ccommon.Option) ccommon.IInput {
 	)
 	l.Infof("Datakit post metric URL: %s", ipt.datakitPostURL)
 
-	if ipt.SlowQueryTime > 0 {
-		slowQueryLoggingR := newSlowQueryLogging(withInput(ipt), withMetricName(metricNameLogging))
-		ipt.collectorsLogging = append(ipt.collectorsLogging, slowQueryLoggingR)
-
-		ipt.datakitPostLoggingURL = ccommon.GetPostURL(
-			opt.Election,
-			ccommon.CategoryLogging, inputName, opt.DatakitHTTPHost,
-			opt.DatakitHTTPPort,
-		)
-	}
+	ipt.datakitPostEventURL = ccommon.GetPostURL(
+		opt.Election,
+		ccommon.CategoryEvent, inputName, opt.DatakitHTTPHost,
+		opt.DatakitHTTPPort,
+	)
+	l.Infof("Datakit post event URL: %s", ipt.datakitPostEventURL)
 
-	return ipt
+	ipt.datakitPostLoggingURL = ccommon.GetPostURL(
+		opt.Election,
+		ccommon.CategoryLogging, inputName, opt.DatakitHTTPHost,
+		opt.DatakitHTTPPort,
+	)
 }
 
 func bytesToQuery(opt *ccommon.Option) []*customQuery {
@@ -224,17 +290,31 @@ func bytesToQuery(opt *ccommon.Option) []*customQuery {
 
 // ConnectDB establishes a connection to an Oracle instance and returns an open connection to the database.
 func (ipt *Input) ConnectDB() error {
-	db, err := sqlx.Open("godror",
-		fmt.Sprintf("%s/%s@%s:%s/%s",
-			ipt.user, ipt.password, ipt.host, ipt.port, ipt.serviceName))
-	if err == nil {
-		ipt.db = db
+	db, err := sqlx.Open("godror", ipt.connString())
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+	defer cancel()
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close() //nolint:errcheck,gosec
 		return err
 	}
+
+	ipt.CloseDB()
+	ipt.db = db
+	ipt.lastConnected = time.Now()
 	return nil
 }
 
 func (ipt *Input) q(s string) rows {
+	if err := ipt.connectIfNil(); err != nil {
+		l.Errorf(`query failed, sql (%q), error: %s, ignored`, s, err.Error())
+		return nil
+	}
+
 	rows, err := ipt.db.Query(s)
 	if err != nil {
 		l.Errorf(`query failed, sql (%q), error: %s, ignored`, s, err.Error())
@@ -279,14 +359,21 @@ func (ipt *Input) Run() {
 	l.Info("starting " + inputName + "...")
 
 	tick := time.NewTicker(ipt.intervalDuration)
-	defer ipt.db.Close() //nolint:errcheck
+	defer ipt.CloseDB()
 	defer tick.Stop()
 
 	for {
 		collectAndReport(&ipt.collectors, ipt.datakitPostURL)
 		collectAndReport(&ipt.collectorsLogging, ipt.datakitPostLoggingURL)
-
-		<-tick.C
+		collectAndReport(&ipt.collectorsEvent, ipt.datakitPostEventURL)
+
+		select {
+		case <-tick.C:
+		case opt := <-ipt.reloadCh:
+			// applied between collections, then collect at once.
+			ipt.reload(opt)
+			tick.Reset(ipt.intervalDuration)
+		}
 	}
 }
 
@@ -322,9 +409,13 @@ func collectAndReport(collectors *[]ccommon.DBMetricsCollector, reportURL string
 func selectWrapper[T any](ipt *Input, s T, sql string) error {
 	now := time.Now()
 
+	if err := ipt.connectIfNil(); err != nil {
+		return err
+	}
+
 	err := ipt.db.Select(s, sql)
-	if err != nil && (strings.Contains(err.Error(), "ORA-01012") || strings.Contains(err.Error(), "database is closed")) {
-		if err := ipt.ConnectDB(); err != nil {
+	if err != nil && isConnectionLost(err) {
+		if err := ipt.reconnect(); err != nil {
 			ipt.CloseDB()
 			return err
 		}
@@ -337,9 +428,13 @@ func selectWrapper[T any](ipt *Input, s T, sql string) error {
 func getWrapper[T any](ipt *Input, s T, sql string, binds ...interface{}) error {
 	now := time.Now()
 
+	if err := ipt.connectIfNil(); err != nil {
+		return err
+	}
+
 	err := ipt.db.Get(s, sql, binds...)
-	if err != nil && (strings.Contains(err.Error(), "ORA-01012") || strings.Contains(err.Error(), "database is closed")) {
-		if err := ipt.ConnectDB(); err != nil {
+	if err != nil && isConnectionLost(err) {
+		if err := ipt.reconnect(); err != nil {
 			ipt.CloseDB()
 			return err
 		}
@@ -0,0 +1,96 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package coracle
+
+import (
+	"database/sql"
+	"strings"
+)
+
+const (
+	// cdbRoot is the pdb_name of data of the whole CDB or the root container.
+	cdbRoot = "CDB$ROOT"
+
+	cdbQuery = `SELECT cdb FROM v$database`
+
+	openPDBsQuery = `SELECT name FROM v$containers
+  WHERE open_mode IN ('READ WRITE', 'READ ONLY') AND name <> 'PDB$SEED'`
+
+	// PDB_SESSIONS_QUERY counts sessions of each container, formatted with the
+	// inst_id column and the session view.
+	//
+	//nolint:stylecheck
+	PDB_SESSIONS_QUERY = `SELECT
+	inst_id,
+	pdb_name,
+	COUNT(*) total,
+	SUM(active) active
+  FROM (SELECT
+	%s inst_id,
+	c.name pdb_name,
+	DECODE(s.status, 'ACTIVE', 1, 0) active
+    FROM %s s, v$containers c
+    WHERE s.con_id = c.con_id)
+  GROUP BY inst_id, pdb_name`
+)
+
+type pdbSessionsRowDB struct {
+	InstID  sql.NullString `db:"INST_ID"`
+	PdbName string         `db:"PDB_NAME"`
+	Total   float64        `db:"TOTAL"`
+	Active  float64        `db:"ACTIVE"`
+}
+
+// isCDB checks if connected to a container database, which is checked only
+// once. Databases before 12c are not CDB.
+func (ipt *Input) isCDB() bool {
+	if ipt.cdbChecked {
+		return ipt.cdb
+	}
+
+	var cdb string
+	if err := getWrapper(ipt, &cdb, cdbQuery); err != nil {
+		// ORA-00904: "CDB": invalid identifier, Oracle 11g
+		if !strings.Contains(err.Error(), "ORA-00904") {
+			l.Warnf("check CDB failed: %s, retry on next collection", err)
+			return false
+		}
+	}
+
+	ipt.cdb = cdb == "YES"
+	ipt.cdbChecked = true
+	l.Infof("container database: %t", ipt.cdb)
+
+	return ipt.cdb
+}
+
+// openPDBs returns names of open PDBs and the root container.
+func (ipt *Input) openPDBs() (map[string]bool, error) {
+	var names []string
+	if err := selectWrapper(ipt, &names, openPDBsQuery); err != nil {
+		return nil, err
+	}
+
+	pdbs := make(map[string]bool, len(names))
+	for _, name := range names {
+		pdbs[name] = true
+	}
+	return pdbs, nil
+}
+
+// pdbOf returns pdb_name of the row, rows not belong to any container are
+// regarded as CDB$ROOT in CDB, empty returned for non-CDB.
+func pdbOf(name sql.NullString, cdb bool) string {
+	if name.Valid && name.String != "" {
+		return name.String
+	}
+
+	if cdb {
+		return cdbRoot
+	}
+
+	return ""
+}
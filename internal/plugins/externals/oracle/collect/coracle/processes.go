@@ -38,10 +38,12 @@ func (m *processMetrics) Collect() ([]*point.Point, error) {
 	return m.processMemory()
 }
 
-// PGA_QUERY is the get process info SQL query for Oracle 11g+.
+// PGA_QUERY is the get process info SQL query for Oracle 11g+, formatted with
+// the inst_id column and the process view.
 //
 //nolint:stylecheck
 const PGA_QUERY = `SELECT 
+	%s inst_id,
 	name pdb_name, 
 	pid, 
 	program, 
@@ -49,7 +51,7 @@ const PGA_QUERY = `SELECT
 	nvl(pga_alloc_mem,0) pga_alloc_mem, 
 	nvl(pga_freeable_mem,0) pga_freeable_mem, 
 	nvl(pga_max_mem,0) pga_max_mem
-  FROM v$process p, v$containers c
+  FROM %s p, v$containers c
   WHERE
   	c.con_id(+) = p.con_id`
 
@@ -65,6 +67,7 @@ const PGA_QUERY_OLD = `SELECT
   FROM GV$PROCESS`
 
 type processesRowDB struct {
+	InstID         sql.NullString `db:"INST_ID"`
 	PdbName        sql.NullString `db:"PDB_NAME"`
 	PID            uint64         `db:"PID"`
 	Program        sql.NullString `db:"PROGRAM"`
@@ -77,7 +80,8 @@ type processesRowDB struct {
 func (m *processMetrics) processMemory() ([]*point.Point, error) {
 	rows := []processesRowDB{}
 
-	err := selectWrapper(m.x.Ipt, &rows, PGA_QUERY)
+	view, instCol := m.x.Ipt.instView("process")
+	err := selectWrapper(m.x.Ipt, &rows, fmt.Sprintf(PGA_QUERY, instCol, view))
 	if err != nil {
 		l.Debug("process: dpiStmt_execute: ORA-00942: table or view does not exist")
 
@@ -93,11 +97,16 @@ func (m *processMetrics) processMemory() ([]*point.Point, error) {
 
 	var pts []*point.Point
 	hostTag := ccommon.GetHostTag(l, m.x.Ipt.host)
+	cdb := m.x.Ipt.isCDB()
 
 	for _, r := range rows {
 		var kvs point.KVs
-		if r.PdbName.Valid {
-			kvs = kvs.AddTag(pdbName, r.PdbName.String)
+		if pdb := pdbOf(r.PdbName, cdb); pdb != "" {
+			kvs = kvs.AddTag(pdbName, pdb)
+		}
+
+		if r.InstID.Valid {
+			kvs = kvs.AddTag(instID, r.InstID.String)
 		}
 
 		if r.Program.Valid {
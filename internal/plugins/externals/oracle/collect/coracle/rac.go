@@ -0,0 +1,90 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package coracle
+
+import (
+	"strings"
+)
+
+const (
+	instID = "inst_id"
+
+	racQuery = `SELECT value FROM v$parameter WHERE name = 'cluster_database'`
+
+	instanceQuery = `SELECT instance_number FROM v$instance`
+
+	minOpenInstanceQuery = `SELECT MIN(inst_id) FROM gv$instance WHERE status = 'OPEN'`
+)
+
+type racInfo struct {
+	checked bool
+	enabled bool
+	instID  int
+}
+
+// isRAC checks if connected to a RAC database, which is checked only once.
+func (ipt *Input) isRAC() bool {
+	if ipt.rac.checked {
+		return ipt.rac.enabled
+	}
+
+	var clusterDatabase string
+	if err := getWrapper(ipt, &clusterDatabase, racQuery); err != nil {
+		l.Warnf("check RAC failed: %s, retry on next collection", err)
+		return false
+	}
+
+	if strings.EqualFold(clusterDatabase, "TRUE") {
+		if err := getWrapper(ipt, &ipt.rac.instID, instanceQuery); err != nil {
+			l.Warnf("get instance number failed: %s, retry on next collection", err)
+			return false
+		}
+		ipt.rac.enabled = true
+	}
+
+	ipt.rac.checked = true
+	l.Infof("RAC: %t, instance number: %d", ipt.rac.enabled, ipt.rac.instID)
+
+	return ipt.rac.enabled
+}
+
+// globalViews reports if gv$ views of all instances are collected. It's only
+// enabled under election: the leader datakit connects to the SCAN address and
+// collects the whole cluster, the others are paused by election.
+func (ipt *Input) globalViews() bool {
+	return ipt.election && ipt.isRAC()
+}
+
+// instView returns the view to query instance data and the expression of the
+// inst_id column. Without election, each datakit collects its local instance
+// by the v$ view.
+func (ipt *Input) instView(view string) (string, string) {
+	switch {
+	case !ipt.isRAC():
+		return "v$" + view, "NULL"
+	case ipt.election:
+		return "gv$" + view, "inst_id"
+	default:
+		return "v$" + view, "SYS_CONTEXT('USERENV', 'INSTANCE')"
+	}
+}
+
+// clusterWide reports if data of the whole database (such as tablespaces) should
+// be collected on this connection. In RAC without election, every datakit
+// connects to its local instance, so only the lowest open instance collects them.
+func (ipt *Input) clusterWide() bool {
+	if ipt.election || !ipt.isRAC() {
+		return true
+	}
+
+	var minID int
+	if err := getWrapper(ipt, &minID, minOpenInstanceQuery); err != nil {
+		l.Warnf("get open RAC instances failed: %s, collect cluster wide data anyway", err)
+		return true
+	}
+
+	return minID == ipt.rac.instID
+}
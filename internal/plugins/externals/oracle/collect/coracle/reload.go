@@ -0,0 +1,73 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package coracle
+
+import (
+	"strings"
+	"time"
+
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/oracle/collect/ccommon"
+)
+
+var _ ccommon.Reloader = (*Input)(nil)
+
+// Reload applies the option sent by DataKit on config changed, such as the
+// rotated password. It's applied between collections, only the latest one
+// applied if reloaded several times during a collection.
+func (ipt *Input) Reload(opt *ccommon.Option) {
+	select {
+	case <-ipt.reloadCh:
+	default:
+	}
+	ipt.reloadCh <- opt
+}
+
+func (ipt *Input) reload(opt *ccommon.Option) {
+	if names := restartOptions(ipt.opt, opt); len(names) > 0 {
+		l.Warnf("%s changed, applied after restarted", strings.Join(names, ", "))
+	}
+
+	conn, wallet := ipt.connString(), ipt.wallet
+	ipt.applyOption(opt)
+
+	if ipt.connString() == conn && ipt.wallet == wallet {
+		l.Info("reloaded, connection not changed")
+		return
+	}
+
+	if err := ipt.ConnectDB(); err != nil {
+		// The previous connection is kept, and it's connected by the new
+		// option on next reconnecting.
+		ccommon.ReportErrorf(inputName, l, "connect with reloaded option failed: %v", err)
+		return
+	}
+
+	// Might connected to another database.
+	ipt.cdbChecked = false
+	ipt.rac = racInfo{}
+	ipt.reconnectBackoff = 0
+	ipt.nextReconnect = time.Time{}
+
+	l.Info("reloaded, reconnected")
+}
+
+// restartOptions returns options not applied by reloading, the logger is set
+// up only once.
+func restartOptions(cur, opt *ccommon.Option) []string {
+	var names []string
+
+	if cur.Log != opt.Log {
+		names = append(names, "--log")
+	}
+	if cur.LogLevel != opt.LogLevel {
+		names = append(names, "--log-level")
+	}
+	if cur.InstanceDesc != opt.InstanceDesc {
+		names = append(names, "--instance-desc")
+	}
+
+	return names
+}
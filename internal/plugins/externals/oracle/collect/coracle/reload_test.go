@@ -0,0 +1,67 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package coracle
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/oracle/collect/ccommon"
+)
+
+func TestApplyOption(t *testing.T) {
+	t.Setenv("ENV_INPUT_ORACLE_PASSWORD", "from-env")
+
+	ipt := &Input{reloadCh: make(chan *ccommon.Option, 1)}
+	ipt.applyOption(&ccommon.Option{
+		Interval:    "1m",
+		Host:        "db1",
+		Port:        "1521",
+		Username:    "datakit",
+		Password:    "secret",
+		ServiceName: "orcl",
+		Tags:        "a=b;c = d",
+		ASHTopN:     5,
+	})
+
+	assert.Equal(t, "datakit/from-env@db1:1521/orcl", ipt.connString())
+	assert.Equal(t, time.Minute, ipt.intervalDuration)
+	assert.Equal(t, 5, ipt.ashTopN)
+	assert.Equal(t, map[string]string{
+		"a": "b", "c": "d", "oracle_server": "db1:1521", "oracle_service": "orcl",
+	}, ipt.tags)
+
+	// tags and slow query reset by reloading
+	ipt.applyOption(&ccommon.Option{
+		Interval:      "30s",
+		ConnectString: "orcl_high",
+		Username:      "datakit",
+		SlowQueryTime: "1s",
+	})
+	assert.Equal(t, "datakit/from-env@orcl_high", ipt.connString())
+	assert.Equal(t, time.Second, ipt.SlowQueryTime)
+	assert.Equal(t, 0, ipt.ashTopN)
+	assert.Equal(t, map[string]string{"oracle_service": ""}, ipt.tags)
+}
+
+func TestReloadLatest(t *testing.T) {
+	ipt := &Input{reloadCh: make(chan *ccommon.Option, 1)}
+
+	ipt.Reload(&ccommon.Option{Host: "db1"})
+	ipt.Reload(&ccommon.Option{Host: "db2"})
+
+	require.Len(t, ipt.reloadCh, 1)
+	assert.Equal(t, "db2", (<-ipt.reloadCh).Host)
+}
+
+func TestRestartOptions(t *testing.T) {
+	cur := &ccommon.Option{Log: "/var/log/oracle.log", LogLevel: "info", Host: "db1"}
+
+	assert.Empty(t, restartOptions(cur, &ccommon.Option{Log: "/var/log/oracle.log", LogLevel: "info", Host: "db2"}))
+	assert.Equal(t, []string{"--log-level"}, restartOptions(cur, &ccommon.Option{Log: "/var/log/oracle.log", LogLevel: "debug"}))
+}
@@ -10,6 +10,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/GuanceCloud/cliutils/point"
@@ -39,12 +40,18 @@ func newSlowQueryLogging(opts ...collectOption) *slowQueryLogging {
 
 func (m *slowQueryLogging) Collect() ([]*point.Point, error) {
 	l.Debug("Collect entry")
+
+	// disabled if --slow-query-time not set, which could be changed by reloading.
+	if m.x.Ipt.SlowQueryTime <= 0 {
+		return nil, nil
+	}
 	return m.slowQuery()
 }
 
-// SLOW_QUERY is the get slow query info SQL query for Oracle.
+// SLOW_QUERY is the get slow query info SQL query for Oracle. Child cursors of
+// V$SQL are queried, so each execution plan of the SQL is reported.
 //
-// excluded fields SQL_TEXT, OPTIMIZER_ENV, ADDRESS, LAST_ACTIVE_CHILD_ADDRESS, BIND_DATA
+// excluded fields SQL_TEXT, OPTIMIZER_ENV, ADDRESS, CHILD_ADDRESS, BIND_DATA
 //
 //nolint:stylecheck
 const SLOW_QUERY = `SELECT 
@@ -114,7 +121,7 @@ const SLOW_QUERY = `SELECT
 	sa.PERSISTENT_MEM,
 	sa.RUNTIME_MEM,
 	sa.SORTS,
-	sa.VERSION_COUNT,
+	sa.CHILD_NUMBER,
 	sa.LOADED_VERSIONS,
 	sa.OPEN_VERSIONS,
 	sa.USERS_OPENING,
@@ -126,7 +133,7 @@ const SLOW_QUERY = `SELECT
 	sa.LOADS,
 	sa.ELAPSED_TIME/sa.EXECUTIONS AVG_ELAPSED,
 	u.USERNAME
-  FROM V$SQLAREA sa
+  FROM V$SQL sa
   LEFT JOIN ALL_USERS u
   ON sa.PARSING_USER_ID = u.USER_ID
   WHERE
@@ -203,7 +210,7 @@ type slowQueryRowDB struct {
 	PERSISTENT_MEM                 sql.NullString `db:"PERSISTENT_MEM"`
 	RUNTIME_MEM                    sql.NullString `db:"RUNTIME_MEM"`
 	SORTS                          sql.NullString `db:"SORTS"`
-	VERSION_COUNT                  sql.NullString `db:"VERSION_COUNT"`
+	CHILD_NUMBER                   sql.NullString `db:"CHILD_NUMBER"`
 	LOADED_VERSIONS                sql.NullString `db:"LOADED_VERSIONS"`
 	OPEN_VERSIONS                  sql.NullString `db:"OPEN_VERSIONS"`
 	USERS_OPENING                  sql.NullString `db:"USERS_OPENING"`
@@ -220,7 +227,7 @@ type slowQueryRowDB struct {
 // SQL_QUERY_MAX_ACTIVE get the max LAST_ACTIVE_TIME in the table.
 //
 //nolint:stylecheck
-const SQL_QUERY_MAX_ACTIVE = `SELECT MAX(LAST_ACTIVE_TIME) FROM V$SQLAREA`
+const SQL_QUERY_MAX_ACTIVE = `SELECT MAX(LAST_ACTIVE_TIME) FROM V$SQL`
 
 //nolint:stylecheck
 type maxQueryRowDB struct {
@@ -257,7 +264,7 @@ func (m *slowQueryLogging) slowQuery() ([]*point.Point, error) {
 		return nil, nil
 	}
 
-	mResults := make([]map[string]string, 0)
+	mResults := make([]map[string]string, 0, len(rows))
 
 	for _, r := range rows {
 		gotlastActiveTime, err := dateparse.ParseAny(r.LAST_ACTIVE_TIME.String)
@@ -348,7 +355,7 @@ func (m *slowQueryLogging) slowQuery() ([]*point.Point, error) {
 		mRes["persistent_mem"] = r.PERSISTENT_MEM.String
 		mRes["runtime_mem"] = r.RUNTIME_MEM.String
 		mRes["sorts"] = r.SORTS.String
-		mRes["version_count"] = r.VERSION_COUNT.String
+		mRes["child_number"] = r.CHILD_NUMBER.String
 		mRes["loaded_versions"] = r.LOADED_VERSIONS.String
 		mRes["open_versions"] = r.OPEN_VERSIONS.String
 		mRes["users_opening"] = r.USERS_OPENING.String
@@ -379,6 +386,9 @@ func (m *slowQueryLogging) slowQuery() ([]*point.Point, error) {
 		var kvs point.KVs
 		kvs = kvs.Add("status", "warning", false, false)
 		kvs = kvs.Add("message", string(jsn), false, false)
+		kvs = kvs.Add("sql_id", v["sql_id"], false, false)
+		kvs = kvs.Add("plan_hash_value", v["plan_hash_value"], false, false)
+		kvs = addSlowQueryStats(kvs, v)
 
 		pts = append(pts, ccommon.BuildPointLogging(
 			kvs, m.x.MetricName,
@@ -402,15 +412,48 @@ func getOracleTimeString(in string) string {
 	return out
 }
 
-var reg = regexp.MustCompile(`\n|\s+`) //nolint:gocritic
+// slowQueryStats is execution stats of the SQL added as fields.
+var slowQueryStats = []string{
+	"executions",
+	"elapsed_time",
+	"avg_elapsed",
+	"cpu_time",
+	"buffer_gets",
+	"disk_reads",
+	"rows_processed",
+}
 
+func addSlowQueryStats(kvs point.KVs, res map[string]string) point.KVs {
+	for _, k := range slowQueryStats {
+		if v, err := strconv.ParseFloat(res[k], 64); err == nil {
+			kvs = kvs.Add(k, v, false, false)
+		}
+	}
+	return kvs
+}
+
+var (
+	reg = regexp.MustCompile(`\n|\s+`) //nolint:gocritic
+
+	// bind variables like :1, :b1, :name or :"name".
+	bindReg = regexp.MustCompile(`:(\w+|"[^"]*")`)
+
+	// literals redacted if SQL obfuscated failed: q-quoted strings, strings
+	// (maybe unterminated) and numbers.
+	literalReg = regexp.MustCompile(`(?is)\bn?q'(\[.*?\]|\{.*?\}|\(.*?\)|<.*?>)'|'(?:[^']|'')*'?|\b\d+(?:\.\d+)?(?:e[+-]?\d+)?\b`)
+)
+
+// obfuscateSQL replaces bind variables and literals of the SQL with ?, literals
+// are redacted by regexp if the SQL failed to be obfuscated.
 func obfuscateSQL(text string) (sql string, err error) {
 	defer func() {
 		sql = strings.TrimSpace(reg.ReplaceAllString(sql, " "))
 	}()
 
+	text = bindReg.ReplaceAllString(text, "?")
+
 	if out, err := obfuscate.NewObfuscator(nil).Obfuscate("sql", text); err != nil {
-		return text, err
+		return literalReg.ReplaceAllString(text, "?"), err
 	} else {
 		return out.Query, nil
 	}
@@ -0,0 +1,69 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package coracle
+
+import (
+	"testing"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObfuscateSQL(t *testing.T) {
+	cases := []struct {
+		name, in, expect string
+		fail             bool
+	}{
+		{
+			name:   "literals",
+			in:     "SELECT * FROM t1 WHERE a = 'secret'\n  AND b = 12",
+			expect: "SELECT * FROM t1 WHERE a = ? AND b = ?",
+		},
+		{
+			name:   "binds",
+			in:     `SELECT * FROM t WHERE a = :1 AND b = :name AND c = :"Quoted"`,
+			expect: "SELECT * FROM t WHERE a = ? AND b = ? AND c = ?",
+		},
+		{
+			name:   "q-quote",
+			in:     "INSERT INTO t VALUES (q'[it's secret]', 1.5e3, 'x''y')",
+			expect: "INSERT INTO t VALUES (?, ?, ?)",
+			fail:   true,
+		},
+		{
+			name:   "unterminated",
+			in:     "SELECT 'secret FROM t",
+			expect: "SELECT ?",
+			fail:   true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := obfuscateSQL(tc.in)
+			if tc.fail {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.expect, out)
+		})
+	}
+}
+
+func TestAddSlowQueryStats(t *testing.T) {
+	var kvs point.KVs
+	kvs = addSlowQueryStats(kvs, map[string]string{
+		"executions":   "3",
+		"elapsed_time": "3000000",
+		"avg_elapsed":  "1000000",
+		"buffer_gets":  "",
+	})
+
+	assert.Equal(t, 3, kvs.FieldCount())
+	assert.Equal(t, 1000000.0, kvs.Get("avg_elapsed").GetF())
+	assert.Nil(t, kvs.Get("buffer_gets"))
+}
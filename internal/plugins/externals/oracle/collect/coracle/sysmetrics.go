@@ -8,6 +8,7 @@ package coracle
 import (
 	"database/sql"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/GuanceCloud/cliutils/point"
@@ -37,11 +38,13 @@ func (m *systemMetrics) Collect() ([]*point.Point, error) {
 	return m.sysMetrics()
 }
 
-// SYSMETRICS_QUERY is the get system info SQL query for Oracle for 12c2 and 12c2+.
+// SYSMETRICS_QUERY is the get system info SQL query for Oracle for 12c2 and 12c2+,
+// formatted with the inst_id column and the sysmetric view.
 // https://docs.oracle.com/en/database/oracle/oracle-database/12.2/refrn/V-CON_SYSMETRIC.html#GUID-B7A51ACA-952B-47FE-9BC5-B265A120A9F7
 //
 //nolint:stylecheck
 const SYSMETRICS_QUERY = `SELECT 
+	%s inst_id,
 	metric_name,
 	value, 
 	metric_unit, 
@@ -59,6 +62,7 @@ const SYSMETRICS_QUERY_OLD = `SELECT
   FROM GV$SYSMETRIC ORDER BY BEGIN_TIME`
 
 type sysmetricsRowDB struct {
+	InstID     sql.NullString `db:"INST_ID"`
 	MetricName string         `db:"METRIC_NAME"`
 	Value      float64        `db:"VALUE"`
 	MetricUnit string         `db:"METRIC_UNIT"`
@@ -167,7 +171,7 @@ var (
 	}
 )
 
-func (*systemMetrics) addMetric(r sysmetricsRowDB, seen map[string]bool) point.KVs {
+func (*systemMetrics) addMetric(r sysmetricsRowDB) point.KVs {
 	var kvs point.KVs
 
 	if metric, ok := SYSMETRICS_COLS[r.MetricName]; ok {
@@ -177,9 +181,6 @@ func (*systemMetrics) addMetric(r sysmetricsRowDB, seen map[string]bool) point.K
 		}
 
 		l.Debugf("%s: %f", metric.DDmetric, value)
-		if r.PdbName.Valid {
-			kvs = kvs.AddTag(pdbName, r.PdbName.String)
-		}
 
 		alias, ok := dic[metric.DDmetric]
 		if ok {
@@ -187,20 +188,124 @@ func (*systemMetrics) addMetric(r sysmetricsRowDB, seen map[string]bool) point.K
 		} else {
 			kvs = kvs.Add(metric.DDmetric, value, false, false)
 		}
-		seen[r.MetricName] = true
 	}
 
 	return kvs
 }
 
-func (m *systemMetrics) sysMetrics() ([]*point.Point, error) {
+// sysmetricsKey is the instance and PDB of system metrics, both empty for
+// non-RAC and non-CDB.
+type sysmetricsKey struct {
+	inst, pdb string
+}
+
+// sysmetricsGroups is fields of system metrics of each instance and PDB.
+type sysmetricsGroups map[sysmetricsKey]point.KVs
+
+// addRows adds metric rows into their instance and PDB, rows of PDBs not in open
+// are dropped, open is nil for non-CDB. Metrics of an instance in seen are marked,
+// and these in skip are ignored.
+func (m *systemMetrics) addRows(groups sysmetricsGroups, rows []sysmetricsRowDB, open, seen, skip map[string]bool) {
+	cdb := open != nil
+	for _, r := range rows {
+		// the same metric of different intervals, keep the first one.
+		metricKey := r.InstID.String + "/" + r.MetricName
+		if skip != nil && (skip[metricKey] || seen[metricKey]) {
+			continue
+		}
+
+		key := sysmetricsKey{inst: r.InstID.String, pdb: pdbOf(r.PdbName, cdb)}
+		if cdb && !open[key.pdb] {
+			continue
+		}
+
+		newKVs := m.addMetric(r)
+		if newKVs.FieldCount() > 0 {
+			groups[key] = appendKVs(groups[key], newKVs)
+			seen[metricKey] = true
+		}
+	}
+}
+
+// addPDBSessions adds session counts of each open PDB.
+func (m *systemMetrics) addPDBSessions(groups sysmetricsGroups, open map[string]bool) {
+	view, instCol := m.x.Ipt.instView("session")
+
+	rows := []pdbSessionsRowDB{}
+	if err := selectWrapper(m.x.Ipt, &rows, fmt.Sprintf(PDB_SESSIONS_QUERY, instCol, view)); err != nil {
+		l.Warnf("failed to collect PDB sessions: %s, ignored", err)
+		return
+	}
+
+	for _, r := range rows {
+		if !open[r.PdbName] {
+			continue
+		}
+
+		// session_count from sysmetric preferred.
+		key := sysmetricsKey{inst: r.InstID.String, pdb: r.PdbName}
+		groups[key] = groups[key].
+			Add("session_count", r.Total, false, false).
+			Add("active_session_count", r.Active, false, false)
+	}
+}
+
+// buildPoints builds one point for each instance and PDB, instance wide PGA
+// metrics are added to the root container.
+func (m *systemMetrics) buildPoints(groups sysmetricsGroups, cdb bool) ([]*point.Point, error) {
 	var pts []*point.Point
-	seenInContainerMetrics := make(map[string]bool)
 
 	hostTag := ccommon.GetHostTag(l, m.x.Ipt.host)
+	root := pdbOf(sql.NullString{}, cdb)
+
+	keys := make([]sysmetricsKey, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].inst != keys[j].inst {
+			return keys[i].inst < keys[j].inst
+		}
+		return keys[i].pdb < keys[j].pdb
+	})
+
+	for _, key := range keys {
+		kvs := groups[key]
+		if kvs.FieldCount() == 0 {
+			continue
+		}
+
+		if key.pdb == root {
+			var err error
+			if kvs, err = m.getOverAllocationCount(kvs, key.inst); err != nil {
+				return nil, err
+			}
+		}
+
+		if key.pdb != "" {
+			kvs = kvs.AddTag(pdbName, key.pdb)
+		}
+		if key.inst != "" {
+			kvs = kvs.AddTag(instID, key.inst)
+		}
+
+		pts = append(pts, ccommon.BuildPointMetric(
+			kvs, m.x.MetricName,
+			m.x.Ipt.tags, hostTag,
+		))
+	}
+
+	return pts, nil
+}
+
+func (m *systemMetrics) sysMetrics() ([]*point.Point, error) {
+	groups := sysmetricsGroups{}
+	seenInContainerMetrics := make(map[string]bool)
+
+	view, instCol := m.x.Ipt.instView("con_sysmetric")
 
 	metricRows := []sysmetricsRowDB{}
-	err := selectWrapper(m.x.Ipt, &metricRows, fmt.Sprintf(SYSMETRICS_QUERY, "v$con_sysmetric"))
+	err := selectWrapper(m.x.Ipt, &metricRows, fmt.Sprintf(SYSMETRICS_QUERY, instCol, view))
 	if err != nil {
 		if strings.Contains(err.Error(), "dpiStmt_execute: ORA-00942: table or view does not exist") {
 			l.Debug("system: dpiStmt_execute: ORA-00942: table or view does not exist. Maybe Oracle version lower than 12.")
@@ -210,91 +315,40 @@ func (m *systemMetrics) sysMetrics() ([]*point.Point, error) {
 				return nil, fmt.Errorf("failed to collect old sysmetrics: %w", err)
 			}
 
-			var kvs point.KVs
-			for _, r := range metricRows {
-				newKVs := m.addMetric(r, seenInContainerMetrics)
-				if newKVs.FieldCount() > 0 {
-					kvs = appendKVs(kvs, newKVs)
-				}
-			}
-
-			if kvs.FieldCount() > 0 {
-				kvs, err = m.getOverAllocationCount(kvs)
-				if err != nil {
-					return nil, err
-				}
-
-				pts = append(pts, ccommon.BuildPointMetric(
-					kvs, m.x.MetricName,
-					m.x.Ipt.tags, hostTag,
-				))
-			}
-
-			return pts, nil
+			m.addRows(groups, metricRows, nil, seenInContainerMetrics, nil)
+			return m.buildPoints(groups, false)
 		} else {
 			return nil, fmt.Errorf("failed to collect container sysmetrics: %w", err)
 		}
 	}
 	l.Debugf("system 1: len(metricRows) = %d", len(metricRows))
 
-	{
-		var kvs point.KVs
-		for _, r := range metricRows {
-			newKVs := m.addMetric(r, seenInContainerMetrics)
-			if newKVs.FieldCount() > 0 {
-				kvs = appendKVs(kvs, newKVs)
-			}
+	var open map[string]bool
+	if m.x.Ipt.isCDB() {
+		if open, err = m.x.Ipt.openPDBs(); err != nil {
+			return nil, fmt.Errorf("failed to get open PDBs: %w", err)
 		}
+	}
 
-		if kvs.FieldCount() > 0 {
-			kvs, err = m.getOverAllocationCount(kvs)
-			if err != nil {
-				return nil, err
-			}
+	m.addRows(groups, metricRows, open, seenInContainerMetrics, nil)
 
-			pts = append(pts, ccommon.BuildPointMetric(
-				kvs, m.x.MetricName,
-				m.x.Ipt.tags, hostTag,
-			))
-		}
-	}
+	view, instCol = m.x.Ipt.instView("sysmetric")
 
-	seenInGlobalMetrics := make(map[string]bool)
-	err = selectWrapper(m.x.Ipt, &metricRows, fmt.Sprintf(SYSMETRICS_QUERY, "v$sysmetric")+" ORDER BY begin_time ASC, metric_name ASC")
+	metricRows = []sysmetricsRowDB{}
+	err = selectWrapper(m.x.Ipt, &metricRows, fmt.Sprintf(SYSMETRICS_QUERY, instCol, view)+" ORDER BY begin_time ASC, metric_name ASC")
 	if err != nil {
 		return nil, fmt.Errorf("failed to collect sysmetrics: %w", err)
 	}
 	l.Debugf("system 2: len(metricRows) = %d", len(metricRows))
 
-	{
-		var kvs point.KVs
-		for _, r := range metricRows {
-			if _, ok := seenInContainerMetrics[r.MetricName]; !ok {
-				if _, ok := seenInGlobalMetrics[r.MetricName]; ok {
-					break
-				} else {
-					newKVs := m.addMetric(r, seenInGlobalMetrics)
-					if newKVs.FieldCount() > 0 {
-						kvs = appendKVs(kvs, newKVs)
-					}
-				}
-			}
-		}
+	// metrics of the whole instance, belongs to the root container in CDB.
+	m.addRows(groups, metricRows, open, make(map[string]bool), seenInContainerMetrics)
 
-		if kvs.FieldCount() > 0 {
-			kvs, err = m.getOverAllocationCount(kvs)
-			if err != nil {
-				return nil, err
-			}
-
-			pts = append(pts, ccommon.BuildPointMetric(
-				kvs, m.x.MetricName,
-				m.x.Ipt.tags, hostTag,
-			))
-		}
+	if open != nil {
+		m.addPDBSessions(groups, open)
 	}
 
-	return pts, nil
+	return m.buildPoints(groups, open != nil)
 }
 
 func appendKVs(in, toAdd point.KVs) point.KVs {
@@ -309,22 +363,30 @@ type pgaOverAllocationCount struct {
 	valid bool
 }
 
-var previousPGAOverAllocationCount pgaOverAllocationCount
+// previousPGAOverAllocationCount is the last PGA over allocation count of
+// each instance.
+var previousPGAOverAllocationCount = map[string]pgaOverAllocationCount{}
+
+func (m *systemMetrics) getOverAllocationCount(kvs point.KVs, inst string) (point.KVs, error) {
+	query := "SELECT value FROM v$pgastat WHERE name = 'over allocation count'"
+	var binds []interface{}
+	if inst != "" && m.x.Ipt.globalViews() {
+		query = "SELECT value FROM gv$pgastat WHERE name = 'over allocation count' AND inst_id = :1"
+		binds = append(binds, inst)
+	}
 
-func (m *systemMetrics) getOverAllocationCount(kvs point.KVs) (point.KVs, error) {
 	var overAllocationCount float64
-	if err := getWrapper(m.x.Ipt, &overAllocationCount, "SELECT value FROM v$pgastat WHERE name = 'over allocation count'"); err != nil {
+	if err := getWrapper(m.x.Ipt, &overAllocationCount, query, binds...); err != nil {
 		return nil, fmt.Errorf("failed to get PGA over allocation count: %w", err)
 	}
 
-	if previousPGAOverAllocationCount.valid {
-		v := overAllocationCount - previousPGAOverAllocationCount.value
+	if prev := previousPGAOverAllocationCount[inst]; prev.valid {
+		v := overAllocationCount - prev.value
 		kvs = kvs.Add("pga_over_allocation_count", v, false, false)
-		previousPGAOverAllocationCount.value = overAllocationCount
 	} else {
 		kvs = kvs.Add("pga_over_allocation_count", float64(0), false, false)
-		previousPGAOverAllocationCount = pgaOverAllocationCount{value: overAllocationCount, valid: true}
 	}
+	previousPGAOverAllocationCount[inst] = pgaOverAllocationCount{value: overAllocationCount, valid: true}
 
 	return kvs, nil
 }
@@ -0,0 +1,120 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package coracle
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSysmetricsAddRows(t *testing.T) {
+	pdb := func(name string) sql.NullString {
+		return sql.NullString{String: name, Valid: name != ""}
+	}
+
+	m := newSystemMetrics(withInput(&Input{}), withMetricName(metricNameSystem))
+
+	t.Run("cdb", func(t *testing.T) {
+		groups := sysmetricsGroups{}
+		open := map[string]bool{cdbRoot: true, "PDB1": true, "PDB2": true}
+		seen := map[string]bool{}
+
+		m.addRows(groups, []sysmetricsRowDB{
+			{MetricName: "Session Count", Value: 10, PdbName: pdb(cdbRoot)},
+			{MetricName: "Session Count", Value: 3, PdbName: pdb("PDB1")},
+			{MetricName: "Session Count", Value: 5, PdbName: pdb("PDB2")},
+			{MetricName: "Session Count", Value: 1, PdbName: pdb("PDB$SEED")},
+			{MetricName: "Background Time Per Sec", Value: 150, MetricUnit: "CentiSeconds Per Second", PdbName: pdb("PDB1")},
+			{MetricName: "Unknown Metric", Value: 1, PdbName: pdb("PDB1")},
+		}, open, seen, nil)
+
+		// instance wide metrics go to the root container
+		m.addRows(groups, []sysmetricsRowDB{
+			{MetricName: "Session Count", Value: 100},
+			{MetricName: "Logons Per Sec", Value: 2},
+			{MetricName: "Logons Per Sec", Value: 4},
+		}, open, map[string]bool{}, seen)
+
+		require.Len(t, groups, 3)
+		assert.Equal(t, 10.0, groups[sysmetricsKey{pdb: cdbRoot}].Get("session_count").GetF())
+		assert.Equal(t, 2.0, groups[sysmetricsKey{pdb: cdbRoot}].Get("logons").GetF())
+		assert.Equal(t, 3.0, groups[sysmetricsKey{pdb: "PDB1"}].Get("session_count").GetF())
+		assert.Equal(t, 1.5, groups[sysmetricsKey{pdb: "PDB1"}].Get("active_background").GetF())
+		assert.Equal(t, 2, groups[sysmetricsKey{pdb: "PDB1"}].FieldCount())
+		assert.Equal(t, 5.0, groups[sysmetricsKey{pdb: "PDB2"}].Get("session_count").GetF())
+	})
+
+	t.Run("non-cdb", func(t *testing.T) {
+		groups := sysmetricsGroups{}
+
+		m.addRows(groups, []sysmetricsRowDB{
+			{MetricName: "Session Count", Value: 10},
+			{MetricName: "Logons Per Sec", Value: 2},
+		}, nil, map[string]bool{}, nil)
+
+		require.Len(t, groups, 1)
+		assert.Equal(t, 2, groups[sysmetricsKey{}].FieldCount())
+	})
+}
+
+func TestSysmetricsAddRowsRAC(t *testing.T) {
+	inst := func(id string) sql.NullString {
+		return sql.NullString{String: id, Valid: true}
+	}
+
+	m := newSystemMetrics(withInput(&Input{}), withMetricName(metricNameSystem))
+
+	groups := sysmetricsGroups{}
+	seen := map[string]bool{}
+	m.addRows(groups, []sysmetricsRowDB{
+		{InstID: inst("1"), MetricName: "Session Count", Value: 10},
+		{InstID: inst("2"), MetricName: "Session Count", Value: 20},
+	}, nil, seen, nil)
+
+	// the second interval of instance 1 ignored
+	m.addRows(groups, []sysmetricsRowDB{
+		{InstID: inst("1"), MetricName: "Session Count", Value: 100},
+		{InstID: inst("1"), MetricName: "Logons Per Sec", Value: 1},
+		{InstID: inst("2"), MetricName: "Logons Per Sec", Value: 2},
+		{InstID: inst("1"), MetricName: "Logons Per Sec", Value: 3},
+	}, nil, map[string]bool{}, seen)
+
+	require.Len(t, groups, 2)
+	assert.Equal(t, 10.0, groups[sysmetricsKey{inst: "1"}].Get("session_count").GetF())
+	assert.Equal(t, 1.0, groups[sysmetricsKey{inst: "1"}].Get("logons").GetF())
+	assert.Equal(t, 20.0, groups[sysmetricsKey{inst: "2"}].Get("session_count").GetF())
+	assert.Equal(t, 2.0, groups[sysmetricsKey{inst: "2"}].Get("logons").GetF())
+}
+
+func TestInstView(t *testing.T) {
+	ipt := &Input{rac: racInfo{checked: true}}
+	view, col := ipt.instView("process")
+	assert.Equal(t, "v$process", view)
+	assert.Equal(t, "NULL", col)
+	assert.True(t, ipt.clusterWide())
+
+	ipt.rac = racInfo{checked: true, enabled: true, instID: 2}
+	view, col = ipt.instView("process")
+	assert.Equal(t, "v$process", view)
+	assert.Equal(t, "SYS_CONTEXT('USERENV', 'INSTANCE')", col)
+	assert.False(t, ipt.globalViews())
+
+	ipt.election = true
+	view, col = ipt.instView("process")
+	assert.Equal(t, "gv$process", view)
+	assert.Equal(t, "inst_id", col)
+	assert.True(t, ipt.globalViews())
+	assert.True(t, ipt.clusterWide())
+}
+
+func TestPDBOf(t *testing.T) {
+	assert.Equal(t, "PDB1", pdbOf(sql.NullString{String: "PDB1", Valid: true}, true))
+	assert.Equal(t, cdbRoot, pdbOf(sql.NullString{}, true))
+	assert.Equal(t, "", pdbOf(sql.NullString{}, false))
+}
@@ -9,19 +9,21 @@ import (
 	"database/sql"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/GuanceCloud/cliutils/point"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/externals/oracle/collect/ccommon"
 )
 
 type tablespaceMetrics struct {
-	x collectParameters
+	x      collectParameters
+	growth *growthTracker
 }
 
 var _ ccommon.DBMetricsCollector = (*tablespaceMetrics)(nil)
 
 func newTablespaceMetrics(opts ...collectOption) *tablespaceMetrics {
-	m := &tablespaceMetrics{}
+	m := &tablespaceMetrics{growth: newGrowthTracker()}
 
 	for _, opt := range opts {
 		if opt != nil {
@@ -34,6 +36,11 @@ func newTablespaceMetrics(opts ...collectOption) *tablespaceMetrics {
 
 func (m *tablespaceMetrics) Collect() ([]*point.Point, error) {
 	l.Debug("Collect entry")
+
+	if !m.x.Ipt.clusterWide() {
+		l.Debug("tablespaces collected by other RAC instance, skipped")
+		return nil, nil
+	}
 	return m.tablespaces()
 }
 
@@ -50,7 +57,7 @@ const QUERY = `SELECT
 FROM
   cdb_tablespace_usage_metrics m, cdb_tablespaces t, v$containers c
 WHERE
-  m.tablespace_name(+) = t.tablespace_name and c.con_id(+) = t.con_id`
+  m.tablespace_name(+) = t.tablespace_name and m.con_id(+) = t.con_id and c.con_id(+) = t.con_id`
 
 // QUERY_OLD is the get tablespace info SQL query for Oracle 11g and 11g-.
 //
@@ -65,6 +72,40 @@ FROM
   dba_tablespace_usage_metrics m
   join dba_tablespaces t on m.tablespace_name = t.tablespace_name`
 
+// DATAFILES_QUERY is the get allocated and max size (autoextend included) of
+// datafiles by tablespace SQL query.
+//
+//nolint:stylecheck
+const DATAFILES_QUERY = `SELECT
+  c.name pdb_name,
+  f.tablespace_name,
+  SUM(f.bytes) bytes,
+  SUM(DECODE(f.autoextensible, 'YES', GREATEST(f.maxbytes, f.bytes), f.bytes)) max_bytes
+FROM
+  cdb_data_files f, v$containers c
+WHERE
+  c.con_id(+) = f.con_id
+GROUP BY c.name, f.tablespace_name`
+
+// DATAFILES_QUERY_OLD is DATAFILES_QUERY for Oracle 11g and 11g-.
+//
+//nolint:stylecheck
+const DATAFILES_QUERY_OLD = `SELECT
+  NULL pdb_name,
+  tablespace_name,
+  SUM(bytes) bytes,
+  SUM(DECODE(autoextensible, 'YES', GREATEST(maxbytes, bytes), bytes)) max_bytes
+FROM
+  dba_data_files
+GROUP BY tablespace_name`
+
+type datafilesRowDB struct {
+	PdbName        sql.NullString `db:"PDB_NAME"`
+	TablespaceName string         `db:"TABLESPACE_NAME"`
+	Bytes          float64        `db:"BYTES"`
+	MaxBytes       float64        `db:"MAX_BYTES"`
+}
+
 // RowDB is for Oracle 11g+.
 type RowDB struct {
 	PdbName        sql.NullString `db:"PDB_NAME"`
@@ -89,12 +130,14 @@ func (m *tablespaceMetrics) tablespaces() ([]*point.Point, error) {
 	var pts []*point.Point
 
 	rows := []RowDB{}
+	datafilesQuery := DATAFILES_QUERY
 	err := selectWrapper(m.x.Ipt, &rows, QUERY)
 	if err != nil {
 		l.Debug("tablespace: dpiStmt_execute: ORA-00942: table or view does not exist")
 
 		if strings.Contains(err.Error(), "dpiStmt_execute: ORA-00942: table or view does not exist") {
 			// oracle old version. 11g
+			datafilesQuery = DATAFILES_QUERY_OLD
 			rowsOld := []RowDBOld{}
 			if err = selectWrapper(m.x.Ipt, &rowsOld, QUERY_OLD); err != nil {
 				return nil, fmt.Errorf("failed to collect old tablespace info: %w", err)
@@ -118,12 +161,28 @@ func (m *tablespaceMetrics) tablespaces() ([]*point.Point, error) {
 	}
 
 	hostTag := ccommon.GetHostTag(l, m.x.Ipt.host)
+	cdb := m.x.Ipt.isCDB()
+
+	// Temporary tablespaces have no datafiles, forecast with the size of
+	// usage metrics then.
+	files := map[string]datafilesRowDB{}
+	var fileRows []datafilesRowDB
+	if err := selectWrapper(m.x.Ipt, &fileRows, datafilesQuery); err != nil {
+		l.Warnf("failed to collect datafiles info: %s, ignored", err)
+	}
+	for _, r := range fileRows {
+		files[pdbOf(r.PdbName, cdb)+"/"+r.TablespaceName] = r
+	}
+
+	now := time.Now()
+	seen := make(map[string]bool, len(rows))
 
 	for _, r := range rows {
 		var kvs point.KVs
 
-		if r.PdbName.Valid {
-			kvs = kvs.AddTag(pdbName, r.PdbName.String)
+		pdb := pdbOf(r.PdbName, cdb)
+		if pdb != "" {
+			kvs = kvs.AddTag(pdbName, pdb)
 		}
 
 		kvs = kvs.AddTag(tablespaceName, r.TablespaceName)
@@ -133,11 +192,44 @@ func (m *tablespaceMetrics) tablespaces() ([]*point.Point, error) {
 		kvs = kvs.Add("ts_size", r.Size, false, false)
 		kvs = kvs.Add("used_space", r.Used, false, false)
 
+		key := pdb + "/" + r.TablespaceName
+		seen[key] = true
+		f, ok := files[key]
+		kvs = append(kvs, m.growthKVs(key, now, r, f, ok)...)
+
 		pts = append(pts, ccommon.BuildPointMetric(
 			kvs, m.x.MetricName,
 			m.x.Ipt.tags, hostTag,
 		))
 	}
 
+	m.growth.retain(seen)
+
 	return pts, nil
 }
+
+// growthKVs returns autoextend headroom of datafiles and the forecast of the
+// tablespace, f is datafiles of the tablespace if hasFiles.
+func (m *tablespaceMetrics) growthKVs(key string, now time.Time, r RowDB, f datafilesRowDB, hasFiles bool) point.KVs {
+	var kvs point.KVs
+
+	maxSize := r.Size
+	if hasFiles {
+		maxSize = f.MaxBytes
+		kvs = kvs.Add("allocated_space", f.Bytes, false, false)
+		kvs = kvs.Add("max_size", f.MaxBytes, false, false)
+		kvs = kvs.Add("autoextend_headroom", f.MaxBytes-f.Bytes, false, false)
+	}
+
+	rate, ok := m.growth.observe(key, now, r.Used)
+	if !ok {
+		return kvs
+	}
+	kvs = kvs.Add("growth_rate", rate, false, false)
+
+	if days, ok := daysUntilFull(maxSize, r.Used, rate); ok {
+		kvs = kvs.Add("days_until_full", days, false, false)
+	}
+
+	return kvs
+}
@@ -50,8 +50,7 @@ func handleInput(confdInputs map[string][]*ConfdInfo, handleList []handle, ctx c
 				l.Info("before set pipelines")
 
 				if m, ok := plval.GetManager(); ok && m != nil {
-					m.LoadScriptsFromWorkspace(plmanager.NSConfd,
-						datakit.ConfdPipelineDir, nil)
+					plval.LoadScriptsFromWorkspace(m, plmanager.NSConfd, datakit.ConfdPipelineDir)
 				}
 			}
 		}
@@ -0,0 +1,297 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package doris
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/config"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/goroutine"
+	dkio "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/metrics"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/tailer"
+)
+
+const (
+	auditMeasurementName = "doris_audit"
+
+	// maxAuditDatabases limits databases aggregated within an interval.
+	maxAuditDatabases = 10000
+)
+
+type auditLog struct {
+	Files             []string `toml:"files"`
+	Pipeline          string   `toml:"pipeline"`
+	IgnoreStatus      []string `toml:"ignore"`
+	CharacterEncoding string   `toml:"character_encoding"`
+
+	// SlowQueryTime only sends audit entries taking longer than it as logs,
+	// 0 for all entries.
+	SlowQueryTime time.Duration `toml:"slow_query_time"`
+
+	// RED aggregates requests, errors and query time per database.
+	RED bool `toml:"red"`
+}
+
+// auditEntry is the part of an audit entry used by slow query filter and
+// RED aggregation, see also fe/fe-core/.../qe/AuditEvent.java of Doris.
+type auditEntry struct {
+	db        string
+	isError   bool
+	queryTime time.Duration
+	scanBytes int64
+	scanRows  int64
+}
+
+// parseAuditEntry parses audit entry like
+//
+//	2023-09-13 15:40:52,968 [query] |Client=...|User=root|Db=demo|State=EOF|...|Time(ms)=22|ScanBytes=3072|...|Stmt=select ...
+//
+// fields after Stmt are ignored, since the statement may contains `|`.
+func parseAuditEntry(msg string) (*auditEntry, bool) {
+	idx := strings.Index(msg, "] |")
+	if idx < 0 {
+		return nil, false
+	}
+
+	entry := &auditEntry{}
+	for _, kv := range strings.Split(msg[idx+3:], "|") {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			continue
+		}
+
+		switch k {
+		case "Db":
+			entry.db = v
+		case "State":
+			entry.isError = v == "ERR"
+		case "Time(ms)":
+			ms, _ := strconv.ParseInt(v, 10, 64)
+			entry.queryTime = time.Duration(ms) * time.Millisecond
+		case "ScanBytes":
+			entry.scanBytes, _ = strconv.ParseInt(v, 10, 64)
+		case "ScanRows":
+			entry.scanRows, _ = strconv.ParseInt(v, 10, 64)
+		case "Stmt":
+			return entry, true
+		}
+	}
+
+	return entry, true
+}
+
+type redStats struct {
+	requests, errors, slowQueries int64
+	queryTimeSum, queryTimeMax    time.Duration
+	scanBytes, scanRows           int64
+}
+
+// auditFeeder wraps the feeder of the tailer, audit entries are aggregated
+// and filtered by query time before sent to the pipeline.
+type auditFeeder struct {
+	dkio.Feeder
+	opt *auditLog
+
+	mu  sync.Mutex
+	red map[string]*redStats // db -> stats
+}
+
+func newAuditFeeder(feeder dkio.Feeder, opt *auditLog) *auditFeeder {
+	return &auditFeeder{
+		Feeder: feeder,
+		opt:    opt,
+		red:    make(map[string]*redStats),
+	}
+}
+
+func (f *auditFeeder) FeedV2(category point.Category, pts []*point.Point, opts ...dkio.FeedOption) error {
+	kept := pts[:0]
+	for _, pt := range pts {
+		msg, _ := pt.Get(pipeline.FieldMessage).(string)
+		entry, ok := parseAuditEntry(msg)
+		if !ok { // not audit entry, like lines of other log files
+			kept = append(kept, pt)
+			continue
+		}
+
+		if f.opt.RED {
+			f.observe(entry)
+		}
+
+		if f.opt.SlowQueryTime > 0 && entry.queryTime < f.opt.SlowQueryTime {
+			continue
+		}
+		kept = append(kept, pt)
+	}
+
+	if len(kept) == 0 {
+		return nil
+	}
+	return f.Feeder.FeedV2(category, kept, opts...)
+}
+
+func (f *auditFeeder) observe(entry *auditEntry) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	s, ok := f.red[entry.db]
+	if !ok {
+		if len(f.red) >= maxAuditDatabases {
+			return
+		}
+		s = &redStats{}
+		f.red[entry.db] = s
+	}
+
+	s.requests++
+	if entry.isError {
+		s.errors++
+	}
+	if f.opt.SlowQueryTime > 0 && entry.queryTime >= f.opt.SlowQueryTime {
+		s.slowQueries++
+	}
+	s.queryTimeSum += entry.queryTime
+	if entry.queryTime > s.queryTimeMax {
+		s.queryTimeMax = entry.queryTime
+	}
+	s.scanBytes += entry.scanBytes
+	s.scanRows += entry.scanRows
+}
+
+// flush returns RED points of databases since last flush.
+func (f *auditFeeder) flush(tags map[string]string, ts time.Time) []*point.Point {
+	f.mu.Lock()
+	red := f.red
+	f.red = make(map[string]*redStats)
+	f.mu.Unlock()
+
+	dbs := make([]string, 0, len(red))
+	for db := range red {
+		dbs = append(dbs, db)
+	}
+	sort.Strings(dbs)
+
+	opts := append(point.DefaultMetricOptions(), point.WithTime(ts))
+
+	pts := make([]*point.Point, 0, len(dbs))
+	for _, db := range dbs {
+		s := red[db]
+
+		var kvs point.KVs
+		for k, v := range tags {
+			kvs = kvs.AddTag(k, v)
+		}
+		kvs = kvs.AddTag("db", db)
+		kvs = kvs.Add("requests", s.requests, false, true)
+		kvs = kvs.Add("errors", s.errors, false, true)
+		if f.opt.SlowQueryTime > 0 {
+			kvs = kvs.Add("slow_queries", s.slowQueries, false, true)
+		}
+		kvs = kvs.Add("query_time_avg", float64(s.queryTimeSum.Milliseconds())/float64(s.requests), false, true)
+		kvs = kvs.Add("query_time_max", s.queryTimeMax.Milliseconds(), false, true)
+		kvs = kvs.Add("scan_bytes", s.scanBytes, false, true)
+		kvs = kvs.Add("scan_rows", s.scanRows, false, true)
+
+		pts = append(pts, point.NewPointV2(auditMeasurementName, kvs, opts...))
+	}
+
+	return pts
+}
+
+func (*Input) PipelineConfig() map[string]string {
+	return map[string]string{
+		inputName: pipelineCfg,
+	}
+}
+
+func (ipt *Input) GetPipeline() []tailer.Option {
+	opts := []tailer.Option{
+		tailer.WithSource(inputName),
+		tailer.WithService(inputName),
+	}
+	if ipt.Log != nil {
+		opts = append(opts, tailer.WithPipeline(ipt.Log.Pipeline))
+	}
+	return opts
+}
+
+//nolint:lll
+func (*Input) LogExamples() map[string]map[string]string {
+	return map[string]map[string]string{
+		inputName: {
+			"Doris audit log": `2023-09-13 15:40:52,968 [query] |Client=172.17.0.1:58020|User=root|Db=demo|State=EOF|ErrorCode=0|ErrorMessage=|Time(ms)=22|ScanBytes=3072|ScanRows=100|ReturnRows=10|StmtId=15|QueryId=4a1e0ec3c5d04b2f-9fd7d1d7b4c6d0e1|IsQuery=true|feIp=172.17.0.2|Stmt=select * from demo.t limit 10|CpuTimeMS=3|SqlHash=ab12|peakMemoryBytes=0|SqlDigest=|TraceId=|WorkloadGroup=normal|FuzzyVariables=`,
+		},
+	}
+}
+
+// RunPipeline tails audit logs of FE.
+func (ipt *Input) RunPipeline() {
+	if ipt.Log == nil || len(ipt.Log.Files) == 0 {
+		return
+	}
+
+	ipt.auditFeeder = newAuditFeeder(ipt.feeder, ipt.Log)
+
+	opts := []tailer.Option{
+		tailer.WithSource(inputName),
+		tailer.WithService(inputName),
+		tailer.WithPipeline(ipt.Log.Pipeline),
+		tailer.WithIgnoreStatus(ipt.Log.IgnoreStatus),
+		tailer.WithCharacterEncoding(ipt.Log.CharacterEncoding),
+		tailer.EnableMultiline(true),
+		tailer.WithMultilinePatterns([]string{`^\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}`}),
+		tailer.WithGlobalTags(inputs.MergeTags(ipt.tagger.HostTags(), ipt.Tags, "")),
+		tailer.EnableDebugFields(config.Cfg.EnableDebugFields),
+		tailer.WithFeeder(ipt.auditFeeder),
+	}
+
+	var err error
+	ipt.tail, err = tailer.NewTailer(ipt.Log.Files, opts...)
+	if err != nil {
+		l.Error(err)
+		ipt.feeder.FeedLastError(err.Error(),
+			metrics.WithLastErrorInput(inputName),
+			metrics.WithLastErrorCategory(point.Logging),
+		)
+		return
+	}
+
+	g := goroutine.NewGroup(goroutine.Option{Name: "inputs_doris"})
+	g.Go(func(ctx context.Context) error {
+		ipt.tail.Start()
+		return nil
+	})
+}
+
+// collectRED sends RED metrics aggregated from audit logs, which are local
+// to the node, so not affected by election.
+func (ipt *Input) collectRED() {
+	if ipt.auditFeeder == nil || !ipt.Log.RED {
+		return
+	}
+
+	pts := ipt.auditFeeder.flush(inputs.MergeTags(ipt.tagger.HostTags(), ipt.Tags, ""), ipt.start)
+	if len(pts) == 0 {
+		return
+	}
+
+	if err := ipt.feeder.FeedV2(point.Metric, pts,
+		dkio.WithCollectCost(time.Since(ipt.start)),
+		dkio.WithInputName(inputName+"/audit"),
+	); err != nil {
+		l.Errorf("feed measurement: %s", err)
+	}
+}
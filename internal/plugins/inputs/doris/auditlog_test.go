@@ -0,0 +1,114 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package doris
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/pipeline/manager"
+	"github.com/GuanceCloud/cliutils/pipeline/ptinput"
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	dkio "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io"
+)
+
+const (
+	auditQuery = `2023-09-13 15:40:52,968 [query] |Client=172.17.0.1:58020|User=root|Db=demo|State=EOF|ErrorCode=0|ErrorMessage=|Time(ms)=22|ScanBytes=3072|ScanRows=100|ReturnRows=10|StmtId=15|QueryId=4a1e0ec3c5d04b2f-9fd7d1d7b4c6d0e1|IsQuery=true|feIp=172.17.0.2|Stmt=select a|b from demo.t limit 10|CpuTimeMS=3|SqlHash=ab12|peakMemoryBytes=0|SqlDigest=|TraceId=|WorkloadGroup=normal|FuzzyVariables=`
+	auditError = `2023-09-13 15:41:00,001 [slow_query] |Client=172.17.0.1:58021|User=etl|Db=demo|State=ERR|ErrorCode=1105|ErrorMessage=timeout|Time(ms)=6000|ScanBytes=0|ScanRows=0|ReturnRows=0|StmtId=16|QueryId=5b|IsQuery=true|feIp=172.17.0.2|Stmt=select sleep(10)`
+	auditOther = `2023-09-13 15:41:01,002 [query] |Client=172.17.0.1:58022|User=root|Db=ods|State=OK|ErrorCode=0|ErrorMessage=|Time(ms)=100|ScanBytes=10|ScanRows=1|ReturnRows=0|StmtId=17|QueryId=6c|IsQuery=false|feIp=172.17.0.2|Stmt=insert into t values(1)`
+)
+
+func TestPipeline(t *testing.T) {
+	pl, errs := manager.NewScripts(map[string]string{"doris.p": pipelineCfg}, nil, "", point.Logging)
+	require.Empty(t, errs)
+	require.Contains(t, pl, "doris.p")
+
+	run := func(msg string) map[string]interface{} {
+		pt := point.NewPointV2(inputName, point.NewKVs(map[string]interface{}{"message": msg}), point.DefaultLoggingOptions()...)
+		ptD := ptinput.PtWrap(point.Logging, pt)
+		require.NoError(t, pl["doris.p"].Run(ptD, nil, nil))
+		return ptD.Point().InfluxFields()
+	}
+
+	fields := run(auditQuery)
+	assert.Equal(t, "query", fields["audit_type"])
+	assert.Equal(t, "172.17.0.1:58020", fields["client"])
+	assert.Equal(t, "root", fields["user"])
+	assert.Equal(t, "demo", fields["db"])
+	assert.Equal(t, "EOF", fields["state"])
+	assert.Equal(t, int64(22), fields["query_time"])
+	assert.Equal(t, int64(3072), fields["scan_bytes"])
+	assert.Equal(t, int64(100), fields["scan_rows"])
+	assert.Equal(t, int64(10), fields["return_rows"])
+	assert.Equal(t, true, fields["is_query"])
+	assert.Equal(t, "select a|b from demo.t limit 10", fields["statement"])
+	assert.NotContains(t, fields, "error_message")
+	assert.NotContains(t, fields, "audit_kvs")
+
+	fields = run(auditError)
+	assert.Equal(t, "slow_query", fields["audit_type"])
+	assert.Equal(t, "ERR", fields["state"])
+	assert.Equal(t, int64(1105), fields["error_code"])
+	assert.Equal(t, "timeout", fields["error_message"])
+	assert.Equal(t, "select sleep(10)", fields["statement"])
+}
+
+func TestParseAuditEntry(t *testing.T) {
+	entry, ok := parseAuditEntry(auditQuery)
+	require.True(t, ok)
+	assert.Equal(t, &auditEntry{db: "demo", queryTime: 22 * time.Millisecond, scanBytes: 3072, scanRows: 100}, entry)
+
+	entry, ok = parseAuditEntry(auditError)
+	require.True(t, ok)
+	assert.True(t, entry.isError)
+	assert.Equal(t, 6*time.Second, entry.queryTime)
+
+	_, ok = parseAuditEntry("2023-09-13 15:41:01,002 INFO (main) started")
+	assert.False(t, ok)
+}
+
+func TestAuditFeeder(t *testing.T) {
+	newPts := func(msgs ...string) []*point.Point {
+		var pts []*point.Point
+		for _, msg := range msgs {
+			pts = append(pts, point.NewPointV2(inputName, point.NewKVs(map[string]interface{}{"message": msg}), point.DefaultLoggingOptions()...))
+		}
+		return pts
+	}
+
+	feeder := dkio.NewMockedFeeder()
+	f := newAuditFeeder(feeder, &auditLog{SlowQueryTime: time.Second, RED: true})
+
+	require.NoError(t, f.FeedV2(point.Logging, newPts(auditQuery, auditError, auditOther, "not audit entry")))
+
+	// only slow queries and lines not audit entries sent
+	pts, err := feeder.NPoints(2, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, auditError, pts[0].Get("message"))
+	assert.Equal(t, "not audit entry", pts[1].Get("message"))
+
+	red := f.flush(map[string]string{"host": "foo"}, time.Now())
+	require.Len(t, red, 2)
+
+	assert.Equal(t, auditMeasurementName, red[0].Name())
+	assert.Equal(t, "demo", red[0].Get("db"))
+	assert.Equal(t, "foo", red[0].Get("host"))
+	assert.Equal(t, int64(2), red[0].Get("requests"))
+	assert.Equal(t, int64(1), red[0].Get("errors"))
+	assert.Equal(t, int64(1), red[0].Get("slow_queries"))
+	assert.Equal(t, 3011.0, red[0].Get("query_time_avg"))
+	assert.Equal(t, int64(6000), red[0].Get("query_time_max"))
+	assert.Equal(t, int64(3072), red[0].Get("scan_bytes"))
+
+	assert.Equal(t, "ods", red[1].Get("db"))
+	assert.Equal(t, int64(1), red[1].Get("requests"))
+	assert.Equal(t, int64(0), red[1].Get("errors"))
+
+	assert.Empty(t, f.flush(nil, time.Now()))
+}
@@ -0,0 +1,90 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package doris
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const backendsPath = "/api/backends?is_alive=true"
+
+// backendsResponse is the response of FE API /api/backends, see also
+// https://doris.apache.org/docs/admin-manual/fe/get-be-list-action
+type backendsResponse struct {
+	Msg  string `json:"msg"`
+	Code int    `json:"code"`
+	Data struct {
+		Backends []struct {
+			IP       string `json:"ip"`
+			HTTPPort int    `json:"http_port"`
+			IsAlive  bool   `json:"is_alive"`
+		} `json:"backends"`
+	} `json:"data"`
+}
+
+// discoverBEs returns URLs of alive BE nodes from the first FE answered,
+// BE URLs use the scheme of the FE URL.
+func (ipt *Input) discoverBEs() ([]string, error) {
+	var lastErr error
+	for _, fe := range ipt.FEURLs {
+		bes, err := ipt.getBackends(strings.TrimSuffix(fe, "/"))
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return bes, nil
+	}
+	return nil, lastErr
+}
+
+func (ipt *Input) getBackends(fe string) ([]string, error) {
+	resp, err := ipt.pm.Request(fe + backendsPath)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("get backends from %s: status %d: %s", fe, resp.StatusCode, body)
+	}
+
+	return parseBackends(fe, body)
+}
+
+func parseBackends(fe string, body []byte) ([]string, error) {
+	var res backendsResponse
+	if err := json.Unmarshal(body, &res); err != nil {
+		return nil, fmt.Errorf("get backends from %s: %w", fe, err)
+	}
+	if res.Code != 0 {
+		return nil, fmt.Errorf("get backends from %s: code %d: %s", fe, res.Code, res.Msg)
+	}
+
+	scheme := "http"
+	if strings.HasPrefix(fe, "https://") {
+		scheme = "https"
+	}
+
+	var bes []string
+	for _, be := range res.Data.Backends {
+		if !be.IsAlive || be.IP == "" || be.HTTPPort == 0 {
+			continue
+		}
+		bes = append(bes, scheme+"://"+net.JoinHostPort(be.IP, strconv.Itoa(be.HTTPPort)))
+	}
+	return bes, nil
+}
@@ -0,0 +1,207 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package doris
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/go-sql-driver/mysql"
+
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs"
+)
+
+const (
+	healthMeasurementName = "doris_cluster_health"
+
+	procStatistic    = "/statistic"
+	procTabletHealth = "/cluster_health/tablet_health"
+)
+
+// clusterHealth queries tablet health of the cluster from FE by MySQL protocol.
+type clusterHealth struct {
+	Addr     string `toml:"addr"`
+	User     string `toml:"user"`
+	Password string `toml:"password"`
+}
+
+// Columns of SHOW PROC results to fields, see also
+// fe/fe-core/.../common/proc/StatisticProcDir.java and TabletHealthProcDir.java of Doris.
+var (
+	statisticColumns = map[string]string{
+		"TabletNum":             "tablets",
+		"ReplicaNum":            "replicas",
+		"UnhealthyTabletNum":    "unhealthy_tablets",
+		"InconsistentTabletNum": "inconsistent_tablets",
+		"CloningTabletNum":      "cloning_tablets",
+		"BadTabletNum":          "bad_tablets",
+	}
+
+	tabletHealthColumns = map[string]string{
+		"ReplicaMissingNum":           "replica_missing_tablets",
+		"VersionIncompleteNum":        "version_incomplete_tablets",
+		"UnrecoverableNum":            "unrecoverable_tablets",
+		"ReplicaCompactionTooHighNum": "compaction_too_high_tablets",
+	}
+)
+
+func (h *clusterHealth) dsn(timeout time.Duration) string {
+	cfg := mysql.Config{
+		AllowNativePasswords: true,
+		CheckConnLiveness:    true,
+		Net:                  "tcp",
+		User:                 h.User,
+		Passwd:               h.Password,
+		Addr:                 h.Addr,
+		Timeout:              timeout,
+	}
+	return cfg.FormatDSN()
+}
+
+// queryProc runs SHOW PROC on the path, returns columns and rows.
+func (ipt *Input) queryProc(path string) ([]string, [][]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), ipt.Timeout)
+	defer cancel()
+
+	rows, err := ipt.db.QueryContext(ctx, fmt.Sprintf("SHOW PROC '%s'", path))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close() //nolint:errcheck
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var res [][]string
+	for rows.Next() {
+		vals := make([]sql.NullString, len(cols))
+		dest := make([]interface{}, len(cols))
+		for i := range vals {
+			dest[i] = &vals[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, nil, err
+		}
+
+		row := make([]string, len(cols))
+		for i, v := range vals {
+			row[i] = v.String
+		}
+		res = append(res, row)
+	}
+
+	return cols, res, rows.Err()
+}
+
+// sumProcColumns sums columns of per-database rows into fields, the Total row
+// is skipped. Columns missing in the Doris version are ignored.
+func sumProcColumns(cols []string, rows [][]string, columns map[string]string, fields map[string]int64) {
+	for _, row := range rows {
+		if len(row) > 0 && row[0] == "Total" {
+			continue
+		}
+
+		for i, col := range cols {
+			name, ok := columns[col]
+			if !ok || i >= len(row) {
+				continue
+			}
+
+			n, err := strconv.ParseInt(row[i], 10, 64)
+			if err != nil {
+				continue
+			}
+			fields[name] += n
+		}
+	}
+}
+
+// maxCompactionScore returns the max compaction score reported by FE.
+func maxCompactionScore(pts []*point.Point) (float64, bool) {
+	var (
+		score float64
+		found bool
+	)
+
+	for _, pt := range pts {
+		if pt.Name() != "doris_fe" {
+			continue
+		}
+
+		for _, k := range []string{"max_tablet_compaction_score", "tablet_max_compaction_score"} {
+			if v, ok := pt.Get(k).(float64); ok {
+				if !found || v > score {
+					score = v
+				}
+				found = true
+			}
+		}
+	}
+
+	return score, found
+}
+
+// clusterTags returns tags of cluster-level points, instance tag added if
+// instance not empty.
+func (ipt *Input) clusterTags(instance string) map[string]string {
+	tags := map[string]string{}
+	for k, v := range ipt.Tags {
+		tags[k] = v
+	}
+	if instance != "" && !ipt.DisableInstanceTag {
+		if _, ok := tags["instance"]; !ok {
+			tags["instance"] = instance
+		}
+	}
+
+	if ipt.Election {
+		return inputs.MergeTags(ipt.tagger.ElectionTags(), tags, "")
+	}
+	return inputs.MergeTags(ipt.tagger.HostTags(), tags, "")
+}
+
+// collectHealth returns the doris_cluster_health point, pts are metrics
+// collected from FE.
+func (ipt *Input) collectHealth(pts []*point.Point) (*point.Point, error) {
+	fields := map[string]int64{}
+
+	cols, rows, err := ipt.queryProc(procStatistic)
+	if err != nil {
+		return nil, fmt.Errorf("show proc %s: %w", procStatistic, err)
+	}
+	sumProcColumns(cols, rows, statisticColumns, fields)
+
+	// tablet_health is not available before Doris 2.0.
+	if cols, rows, err := ipt.queryProc(procTabletHealth); err != nil {
+		l.Debugf("show proc %s: %s, ignored", procTabletHealth, err)
+	} else {
+		sumProcColumns(cols, rows, tabletHealthColumns, fields)
+	}
+
+	return ipt.buildHealthPoint(fields, pts), nil
+}
+
+func (ipt *Input) buildHealthPoint(fields map[string]int64, pts []*point.Point) *point.Point {
+	var kvs point.KVs
+	for k, v := range ipt.clusterTags(ipt.ClusterHealth.Addr) {
+		kvs = kvs.AddTag(k, v)
+	}
+	for k, v := range fields {
+		kvs = kvs.Add(k, v, false, true)
+	}
+	if score, ok := maxCompactionScore(pts); ok {
+		kvs = kvs.Add("compaction_score_max", score, false, true)
+	}
+
+	opts := append(point.DefaultMetricOptions(), point.WithTime(ipt.start))
+	return point.NewPointV2(healthMeasurementName, kvs, opts...)
+}
@@ -0,0 +1,77 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package doris
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSumProcColumns(t *testing.T) {
+	fields := map[string]int64{}
+
+	sumProcColumns(
+		[]string{"DbId", "DbName", "TableNum", "PartitionNum", "IndexNum", "TabletNum", "ReplicaNum", "UnhealthyTabletNum", "InconsistentTabletNum", "CloningTabletNum", "BadTabletNum"},
+		[][]string{
+			{"10002", "demo", "2", "2", "2", "20", "60", "1", "0", "1", "0"},
+			{"10003", "ods", "1", "1", "1", "10", "30", "2", "1", "0", "1"},
+			{"Total", "2", "3", "3", "3", "30", "90", "3", "1", "1", "1"},
+		},
+		statisticColumns, fields)
+
+	// ReplicaMissingInClusterNum not mapped, missing OversizeNum ignored
+	sumProcColumns(
+		[]string{"DbId", "DbName", "TabletNum", "HealthyNum", "ReplicaMissingNum", "VersionIncompleteNum", "ReplicaMissingInClusterNum", "UnrecoverableNum", "ReplicaCompactionTooHighNum"},
+		[][]string{
+			{"10002", "demo", "20", "19", "1", "0", "5", "0", "2"},
+			{"10003", "ods", "10", "8", "2", "1", "5", "1", "-"},
+			{"Total", "2", "30", "27", "3", "1", "10", "1", "2"},
+		},
+		tabletHealthColumns, fields)
+
+	assert.Equal(t, map[string]int64{
+		"tablets":                     30,
+		"replicas":                    90,
+		"unhealthy_tablets":           3,
+		"inconsistent_tablets":        1,
+		"cloning_tablets":             1,
+		"bad_tablets":                 1,
+		"replica_missing_tablets":     3,
+		"version_incomplete_tablets":  1,
+		"unrecoverable_tablets":       1,
+		"compaction_too_high_tablets": 2,
+	}, fields)
+}
+
+func TestBuildHealthPoint(t *testing.T) {
+	newPt := func(name string, fields map[string]interface{}) *point.Point {
+		return point.NewPointV2(name, point.NewKVs(fields), point.DefaultMetricOptions()...)
+	}
+
+	ipt := defaultInput()
+	ipt.ClusterHealth = &clusterHealth{Addr: "fe:9030"}
+	ipt.tagger = &taggerMock{electionTags: map[string]string{"election": "foo"}}
+	ipt.start = time.Now()
+
+	pt := ipt.buildHealthPoint(map[string]int64{"unhealthy_tablets": 3}, []*point.Point{
+		newPt("doris_fe", map[string]interface{}{"max_tablet_compaction_score": 12.0}),
+		newPt("doris_fe", map[string]interface{}{"tablet_max_compaction_score": 35.0}),
+		newPt("doris_be", map[string]interface{}{"tablet_base_max_compaction_score": 99.0}),
+	})
+
+	assert.Equal(t, healthMeasurementName, pt.Name())
+	assert.Equal(t, map[string]string{"election": "foo", "instance": "fe:9030"}, pt.MapTags())
+	assert.Equal(t, int64(3), pt.Get("unhealthy_tablets"))
+	assert.Equal(t, 35.0, pt.Get("compaction_score_max"))
+
+	pt = ipt.buildHealthPoint(map[string]int64{}, nil)
+	require.NotNil(t, pt)
+	assert.Nil(t, pt.Get("compaction_score_max"))
+}
@@ -3,30 +3,422 @@
 // This product includes software developed at Guance Cloud (https://www.guance.com/).
 // Copyright 2021-present Guance, Inc.
 
-// Package doris collect doris metrics by using input prom
+// Package doris collect doris metrics from metrics endpoints of FE and BE nodes.
 //
 //nolint:lll
 package doris
 
 import (
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/GuanceCloud/cliutils"
+	"github.com/GuanceCloud/cliutils/logger"
+	"github.com/GuanceCloud/cliutils/point"
+
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/config"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/datakit"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/httpcli"
+	dkio "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/metrics"
+	dknet "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/net"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs"
+	iprom "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/prom"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/tailer"
 )
 
 const (
 	inputName   = "doris"
 	catalogName = "db"
+
+	minInterval     = time.Second
+	maxInterval     = time.Minute
+	defaultInterval = time.Second * 30
+	defaultTimeout  = time.Second * 30
+
+	metricsPath = "/metrics"
+
+	roleFE = "fe"
+	roleBE = "be"
+)
+
+var (
+	l = logger.DefaultSLogger(inputName)
+
+	_ inputs.ElectionInput = (*Input)(nil)
+	_ inputs.InputV2       = (*Input)(nil)
+	_ inputs.PipelineInput = (*Input)(nil)
 )
 
-type Input struct{}
+type Input struct {
+	Interval time.Duration `toml:"interval"`
+	Timeout  time.Duration `toml:"timeout"`
+
+	FEURLs     []string `toml:"fe_urls"`
+	BEURLs     []string `toml:"be_urls"`
+	DiscoverBE bool     `toml:"discover_be"`
+
+	// Username and password for basic auth of FE/BE HTTP APIs, Auth takes
+	// precedence for metrics and BE discovery if configured.
+	Username string            `toml:"username"`
+	Password string            `toml:"password"`
+	Auth     map[string]string `toml:"auth"`
+
+	TLSOpen    bool   `toml:"tls_open"`
+	CacertFile string `toml:"tls_ca"`
+	CertFile   string `toml:"tls_cert"`
+	KeyFile    string `toml:"tls_key"`
+
+	Log           *auditLog      `toml:"log"`
+	ClusterHealth *clusterHealth `toml:"cluster_health"`
+	Load          *loadJobs      `toml:"load"`
+
+	Tags               map[string]string `toml:"tags"`
+	DisableHostTag     bool              `toml:"disable_host_tag"`
+	DisableInstanceTag bool              `toml:"disable_instance_tag"`
+
+	Election bool `toml:"election"`
+	pauseCh  chan bool
+	pause    bool
+
+	semStop *cliutils.Sem
+	feeder  dkio.Feeder
+	tagger  datakit.GlobalTagger
+	pm      *iprom.Prom
+	db      *sql.DB
+	cli     *http.Client
+
+	tail        *tailer.Tailer
+	auditFeeder *auditFeeder
+
+	// BE nodes discovered from FE, kept if discovery failed.
+	discoveredBEs []string
+
+	// states of routine load jobs by ID, and finish time of the latest stream
+	// load collected of each database.
+	routineLoadStates map[string]string
+	streamLoadMarks   map[string]string
+
+	start time.Time
+}
+
+// node is a metrics endpoint of FE or BE.
+type node struct {
+	role string
+	url  string // base URL like http://127.0.0.1:8030
+
+	// local BE configured in be_urls, scraped by each DataKit regardless of election.
+	local bool
+}
+
+func (ipt *Input) Run() {
+	if err := ipt.setup(); err != nil {
+		l.Errorf("setup err: %v", err)
+		return
+	}
+
+	tick := time.NewTicker(ipt.Interval)
+	defer tick.Stop()
+
+	ipt.start = time.Now()
+
+	for {
+		if ipt.pause {
+			l.Debugf("%s election paused", inputName)
+		} else {
+			ipt.collect()
+		}
+		ipt.collectLocal()
+		ipt.collectRED()
+
+		select {
+		case tt := <-tick.C:
+			ipt.start = time.UnixMilli(inputs.AlignTimeMillSec(tt, ipt.start.UnixMilli(), ipt.Interval.Milliseconds()))
+		case <-datakit.Exit.Wait():
+			l.Infof("%s input exit", inputName)
+			return
+		case <-ipt.semStop.Wait():
+			l.Infof("%s input return", inputName)
+			return
+		case ipt.pause = <-ipt.pauseCh:
+		}
+	}
+}
+
+func (ipt *Input) setup() error {
+	l = logger.SLogger(inputName)
+	l.Infof("%s input started", inputName)
+
+	ipt.Interval = config.ProtectedInterval(minInterval, maxInterval, ipt.Interval)
+
+	if len(ipt.FEURLs) == 0 && len(ipt.BEURLs) == 0 && (ipt.Log == nil || len(ipt.Log.Files) == 0) &&
+		(ipt.ClusterHealth == nil || ipt.ClusterHealth.Addr == "") {
+		return fmt.Errorf("no FE/BE URLs, audit log files or cluster health address configured")
+	}
+
+	for _, u := range append(append([]string{}, ipt.FEURLs...), ipt.BEURLs...) {
+		if _, err := parseBaseURL(u); err != nil {
+			return err
+		}
+	}
+
+	opts := []iprom.PromOption{
+		iprom.WithLogger(l), // WithLogger must in the first
+		iprom.WithSource(inputName),
+		iprom.WithTimeout(ipt.Timeout),
+		iprom.WithMeasurementName("doris_common"),
+		iprom.WithMeasurements([]iprom.Rule{
+			{Prefix: "doris_fe_", Name: "doris_fe"},
+			{Prefix: "doris_be_", Name: "doris_be"},
+			{Prefix: "jvm_", Name: "doris_jvm"},
+		}),
+		iprom.WithTLSOpen(ipt.TLSOpen),
+		iprom.WithCacertFiles([]string{ipt.CacertFile}),
+		iprom.WithCertFile(ipt.CertFile),
+		iprom.WithKeyFile(ipt.KeyFile),
+	}
+
+	if len(ipt.Auth) > 0 {
+		opts = append(opts, iprom.WithAuth(ipt.Auth))
+	} else if ipt.Username != "" {
+		opts = append(opts, iprom.WithHTTPHeaders(map[string]string{
+			"Authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte(ipt.Username+":"+ipt.Password)),
+		}))
+	}
+
+	pm, err := iprom.NewProm(opts...)
+	if err != nil {
+		return err
+	}
+	ipt.pm = pm
+
+	cli, err := ipt.newHTTPClient()
+	if err != nil {
+		return err
+	}
+	ipt.cli = cli
+
+	if ipt.ClusterHealth != nil && ipt.ClusterHealth.Addr != "" {
+		db, err := sql.Open("mysql", ipt.ClusterHealth.dsn(ipt.Timeout))
+		if err != nil {
+			return err
+		}
+		ipt.db = db
+	}
+
+	return nil
+}
+
+// newHTTPClient returns HTTP client of FE APIs other than metrics, with the
+// same TLS config of metrics.
+func (ipt *Input) newHTTPClient() (*http.Client, error) {
+	opt := httpcli.NewOptions()
+	opt.DialTimeout = ipt.Timeout
+
+	if tc := dknet.MergeTLSConfig(nil, []string{ipt.CacertFile}, ipt.CertFile, ipt.KeyFile, ipt.TLSOpen, false); tc != nil {
+		tlsConfig, err := tc.TLSConfig()
+		if err != nil {
+			return nil, fmt.Errorf("load TLS config: %w", err)
+		}
+		opt.TLSClientConfig = tlsConfig
+	}
+
+	cli := httpcli.Cli(opt)
+	cli.Timeout = ipt.Timeout
+	return cli, nil
+}
+
+// parseBaseURL checks the FE/BE URL, which is the HTTP address of the node
+// without path, like http://127.0.0.1:8030.
+func parseBaseURL(u string) (*url.URL, error) {
+	uu, err := url.Parse(u)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL %q: %w", u, err)
+	}
+	if (uu.Scheme != "http" && uu.Scheme != "https") || uu.Host == "" {
+		return nil, fmt.Errorf("invalid URL %q: expect http(s)://host:port", u)
+	}
+	return uu, nil
+}
+
+// nodes returns FE nodes configured and BE nodes discovered, which are
+// cluster-level and scraped by the elected DataKit only.
+func (ipt *Input) nodes() []node {
+	var nodes []node
+	for _, u := range ipt.FEURLs {
+		nodes = append(nodes, node{role: roleFE, url: strings.TrimSuffix(u, "/")})
+	}
+
+	if ipt.DiscoverBE && len(ipt.FEURLs) > 0 {
+		if discovered, err := ipt.discoverBEs(); err != nil {
+			l.Warnf("discover BE nodes failed: %s, using %d BE nodes discovered before", err, len(ipt.discoveredBEs))
+		} else {
+			ipt.discoveredBEs = discovered
+		}
+
+		// BE nodes in be_urls are scraped as local nodes.
+		bes := map[string]bool{}
+		for _, n := range ipt.localNodes() {
+			bes[n.url] = true
+		}
+
+		for _, u := range ipt.discoveredBEs {
+			if !bes[u] {
+				bes[u] = true
+				nodes = append(nodes, node{role: roleBE, url: u})
+			}
+		}
+	}
+
+	return nodes
+}
+
+// localNodes returns BE nodes configured, usually the BE on the same host
+// of the DataKit.
+func (ipt *Input) localNodes() []node {
+	var nodes []node
+	bes := map[string]bool{}
+	for _, u := range ipt.BEURLs {
+		u = strings.TrimSuffix(u, "/")
+		if !bes[u] {
+			bes[u] = true
+			nodes = append(nodes, node{role: roleBE, url: u, local: true})
+		}
+	}
+	return nodes
+}
+
+// collectLocal collects local BE nodes, not affected by election.
+func (ipt *Input) collectLocal() {
+	pts := ipt.getPts(ipt.localNodes())
+	if len(pts) == 0 {
+		return
+	}
+
+	if err := ipt.feeder.FeedV2(point.Metric, pts,
+		dkio.WithCollectCost(time.Since(ipt.start)),
+		dkio.WithInputName(inputName+"/be")); err != nil {
+		ipt.feeder.FeedLastError(err.Error(),
+			metrics.WithLastErrorInput(inputName),
+			metrics.WithLastErrorCategory(point.Metric),
+		)
+		l.Errorf("feed measurement: %s", err)
+	}
+}
+
+func (ipt *Input) collect() {
+	pts := ipt.getPts(ipt.nodes())
+
+	if ipt.db != nil {
+		if pt, err := ipt.collectHealth(pts); err != nil {
+			l.Warnf("collect cluster health: %s", err)
+			ipt.feeder.FeedLastError(err.Error(),
+				metrics.WithLastErrorInput(inputName),
+				metrics.WithLastErrorCategory(point.Metric),
+			)
+		} else {
+			pts = append(pts, pt)
+		}
+	}
+
+	var events []*point.Point
+	if ipt.Load != nil && len(ipt.FEURLs) > 0 {
+		var loads []*point.Point
+		loads, events = ipt.collectLoads()
+		pts = append(pts, loads...)
+	}
+
+	defer ipt.feedLoadEvents(events)
+
+	if len(pts) == 0 {
+		return
+	}
+
+	if err := ipt.feeder.FeedV2(point.Metric, pts,
+		dkio.WithCollectCost(time.Since(ipt.start)),
+		dkio.WithElection(ipt.Election),
+		dkio.WithInputName(inputName)); err != nil {
+		ipt.feeder.FeedLastError(err.Error(),
+			metrics.WithLastErrorInput(inputName),
+			metrics.WithLastErrorCategory(point.Metric),
+		)
+		l.Errorf("feed measurement: %s", err)
+	}
+}
+
+// getPts collects metrics of nodes, nodes failed are skipped.
+func (ipt *Input) getPts(nodes []node) []*point.Point {
+	var points []*point.Point
+
+	for _, n := range nodes {
+		pts, err := ipt.pm.CollectFromHTTPV2(n.url+metricsPath, iprom.WithTimestamp(ipt.start.UnixNano()))
+		if err != nil {
+			l.Warnf("collect %s %s: %s", n.role, n.url, err)
+			ipt.feeder.FeedLastError(err.Error(),
+				metrics.WithLastErrorInput(inputName),
+				metrics.WithLastErrorCategory(point.Metric),
+			)
+			continue
+		}
 
-var _ inputs.InputV2 = (*Input)(nil)
+		tags := ipt.nodeTags(n)
+		for _, pt := range pts {
+			for k, v := range tags {
+				pt.AddTag(k, v)
+			}
+		}
 
-func (*Input) Run() { /*nil*/ }
+		points = append(points, pts...)
+	}
+
+	return points
+}
+
+// nodeTags returns tags of points from the node, with role and address of the node.
+func (ipt *Input) nodeTags(n node) map[string]string {
+	uu, _ := url.Parse(n.url) //nolint:errcheck // checked in setup() or built by discovery
+
+	tags := map[string]string{}
+	for k, v := range ipt.Tags {
+		tags[k] = v
+	}
+	tags["role"] = n.role
+	tags["node"] = uu.Host
+	if !ipt.DisableInstanceTag {
+		if _, ok := tags["instance"]; !ok {
+			tags["instance"] = uu.Host
+		}
+	}
+
+	remote := n.url
+	if ipt.DisableHostTag {
+		remote = ""
+	}
+
+	if ipt.Election && !n.local {
+		return inputs.MergeTags(ipt.tagger.ElectionTags(), tags, remote)
+	}
+	return inputs.MergeTags(ipt.tagger.HostTags(), tags, remote)
+}
 
 func (*Input) Catalog() string { return catalogName }
 
-func (*Input) Terminate() { /*do nothing*/ }
+func (ipt *Input) Terminate() {
+	if ipt.semStop != nil {
+		ipt.semStop.Close()
+	}
+	if ipt.tail != nil {
+		ipt.tail.Close()
+	}
+	if ipt.db != nil {
+		ipt.db.Close() //nolint:errcheck,gosec
+	}
+}
 
 func (*Input) SampleConfig() string { return sampleCfg }
 
@@ -38,11 +430,60 @@ func (*Input) SampleMeasurement() []inputs.Measurement {
 		&beMeasurement{},
 		&commonMeasurement{},
 		&jvmMeasurement{},
+		&healthMeasurement{},
+		&routineLoadMeasurement{},
+		&streamLoadMeasurement{},
+		&loadEventMeasurement{},
+		&auditMeasurement{},
+		&auditLogMeasurement{},
+	}
+}
+
+func (ipt *Input) ElectionEnabled() bool {
+	return ipt.Election
+}
+
+func (ipt *Input) Pause() error {
+	tick := time.NewTicker(inputs.ElectionPauseTimeout)
+	defer tick.Stop()
+	select {
+	case ipt.pauseCh <- true:
+		return nil
+	case <-tick.C:
+		return fmt.Errorf("pause %s failed", inputName)
+	}
+}
+
+func (ipt *Input) Resume() error {
+	tick := time.NewTicker(inputs.ElectionResumeTimeout)
+	defer tick.Stop()
+	select {
+	case ipt.pauseCh <- false:
+		return nil
+	case <-tick.C:
+		return fmt.Errorf("resume %s failed", inputName)
+	}
+}
+
+func defaultInput() *Input {
+	return &Input{
+		Interval:   defaultInterval,
+		Timeout:    defaultTimeout,
+		DiscoverBE: true,
+		Election:   true,
+		Tags:       make(map[string]string),
+
+		streamLoadMarks: make(map[string]string),
+
+		pauseCh: make(chan bool, inputs.ElectionPauseChannelLength),
+		semStop: cliutils.NewSem(),
+		feeder:  dkio.DefaultFeeder(),
+		tagger:  datakit.DefaultGlobalTagger(),
 	}
 }
 
 func init() { //nolint:gochecknoinits
 	inputs.Add(inputName, func() inputs.Input {
-		return &Input{}
+		return defaultInput()
 	})
 }
@@ -0,0 +1,304 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package doris
+
+import (
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type taggerMock struct {
+	hostTags, electionTags map[string]string
+}
+
+func (m *taggerMock) HostTags() map[string]string {
+	return m.hostTags
+}
+
+func (m *taggerMock) ElectionTags() map[string]string {
+	return m.electionTags
+}
+
+func (m *taggerMock) UpdateVersion() {}
+func (m *taggerMock) Updated() bool  { return false }
+
+const (
+	feMetrics = `# HELP doris_fe_qps query per second
+# TYPE doris_fe_qps gauge
+doris_fe_qps 10
+# HELP jvm_heap_size_bytes jvm heap stat
+# TYPE jvm_heap_size_bytes gauge
+jvm_heap_size_bytes{type="max"} 1024
+`
+	beMetrics = `# TYPE doris_be_load_rows counter
+doris_be_load_rows 100
+`
+)
+
+func newBEServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, metricsPath, r.URL.Path)
+		fmt.Fprint(w, beMetrics)
+	}))
+}
+
+func newFEServer(t *testing.T, backends string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case metricsPath:
+			fmt.Fprint(w, feMetrics)
+		case "/api/backends":
+			if backends == "" {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			fmt.Fprint(w, backends)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func backendsJSON(addrs ...string) string {
+	var items []string
+	for _, addr := range addrs {
+		ip, port := addr[:strings.LastIndex(addr, ":")], addr[strings.LastIndex(addr, ":")+1:]
+		items = append(items, fmt.Sprintf(`{"ip":%q,"http_port":%s,"is_alive":true}`, ip, port))
+	}
+	items = append(items, `{"ip":"10.0.0.1","http_port":8040,"is_alive":false}`)
+	return fmt.Sprintf(`{"msg":"success","code":0,"data":{"backends":[%s]},"count":0}`, strings.Join(items, ","))
+}
+
+func TestCollect(t *testing.T) {
+	be := newBEServer(t)
+	defer be.Close()
+	beAddr := strings.TrimPrefix(be.URL, "http://")
+
+	fe := newFEServer(t, backendsJSON(beAddr))
+	defer fe.Close()
+	feAddr := strings.TrimPrefix(fe.URL, "http://")
+
+	ipt := defaultInput()
+	ipt.FEURLs = []string{fe.URL}
+	ipt.Election = false
+	ipt.tagger = &taggerMock{hostTags: map[string]string{"host": "foo"}}
+	require.NoError(t, ipt.setup())
+
+	pts := ipt.getPts(ipt.nodes())
+
+	got := map[string]map[string]string{}
+	for _, pt := range pts {
+		got[pt.Name()] = pt.MapTags()
+	}
+
+	require.Contains(t, got, "doris_fe")
+	assert.Equal(t, roleFE, got["doris_fe"]["role"])
+	assert.Equal(t, feAddr, got["doris_fe"]["node"])
+	assert.Equal(t, feAddr, got["doris_fe"]["instance"])
+	assert.Equal(t, "foo", got["doris_fe"]["host"])
+
+	require.Contains(t, got, "doris_jvm")
+	assert.Equal(t, roleFE, got["doris_jvm"]["role"])
+
+	require.Contains(t, got, "doris_be")
+	assert.Equal(t, roleBE, got["doris_be"]["role"])
+	assert.Equal(t, beAddr, got["doris_be"]["node"])
+}
+
+func TestCollectElection(t *testing.T) {
+	be := newBEServer(t)
+	defer be.Close()
+
+	fe := newFEServer(t, backendsJSON(strings.TrimPrefix(be.URL, "http://")))
+	defer fe.Close()
+
+	ipt := defaultInput()
+	ipt.FEURLs = []string{fe.URL}
+	ipt.BEURLs = []string{be.URL}
+	ipt.tagger = &taggerMock{
+		hostTags:     map[string]string{"host": "foo"},
+		electionTags: map[string]string{"election": "bar"},
+	}
+	require.NoError(t, ipt.setup())
+
+	// local BE tagged with host tags
+	pts := ipt.getPts(ipt.localNodes())
+	require.NotEmpty(t, pts)
+	for _, pt := range pts {
+		assert.Equal(t, "doris_be", pt.Name())
+		assert.Equal(t, "foo", pt.Get("host"))
+		assert.Nil(t, pt.Get("election"))
+	}
+
+	// FE tagged with election tags, BE discovered skipped for it's local
+	pts = ipt.getPts(ipt.nodes())
+	require.NotEmpty(t, pts)
+	for _, pt := range pts {
+		assert.NotEqual(t, "doris_be", pt.Name())
+		assert.Equal(t, roleFE, pt.Get("role"))
+		assert.Equal(t, "bar", pt.Get("election"))
+		assert.Nil(t, pt.Get("host"))
+	}
+}
+
+func TestNodes(t *testing.T) {
+	be := newBEServer(t)
+	defer be.Close()
+
+	t.Run("discovered-and-configured", func(t *testing.T) {
+		fe := newFEServer(t, backendsJSON("10.0.0.2:8040", strings.TrimPrefix(be.URL, "http://")))
+		defer fe.Close()
+
+		ipt := defaultInput()
+		ipt.FEURLs = []string{fe.URL + "/"}
+		ipt.BEURLs = []string{be.URL}
+		require.NoError(t, ipt.setup())
+
+		var urls []string
+		for _, n := range ipt.nodes() {
+			urls = append(urls, n.role+" "+n.url)
+		}
+		sort.Strings(urls)
+		assert.Equal(t, []string{"be http://10.0.0.2:8040", "fe " + fe.URL}, urls)
+
+		assert.Equal(t, []node{{role: roleBE, url: be.URL, local: true}}, ipt.localNodes())
+	})
+
+	t.Run("discovery-failed", func(t *testing.T) {
+		fe := newFEServer(t, "")
+		defer fe.Close()
+
+		ipt := defaultInput()
+		ipt.FEURLs = []string{fe.URL}
+		ipt.discoveredBEs = []string{"http://10.0.0.2:8040"}
+		require.NoError(t, ipt.setup())
+
+		nodes := ipt.nodes()
+		require.Len(t, nodes, 2)
+		assert.Equal(t, node{role: roleBE, url: "http://10.0.0.2:8040"}, nodes[1])
+	})
+
+	t.Run("discovery-disabled", func(t *testing.T) {
+		fe := newFEServer(t, backendsJSON("10.0.0.2:8040"))
+		defer fe.Close()
+
+		ipt := defaultInput()
+		ipt.FEURLs = []string{fe.URL}
+		ipt.DiscoverBE = false
+		require.NoError(t, ipt.setup())
+
+		assert.Equal(t, []node{{role: roleFE, url: fe.URL}}, ipt.nodes())
+	})
+}
+
+func TestSetup(t *testing.T) {
+	ipt := defaultInput()
+	assert.Error(t, ipt.setup())
+
+	ipt.FEURLs = []string{"127.0.0.1:8030"}
+	assert.Error(t, ipt.setup())
+
+	ipt.FEURLs = []string{"http://127.0.0.1:8030"}
+	assert.NoError(t, ipt.setup())
+}
+
+func TestAuthAndTLS(t *testing.T) {
+	var authorized func(r *http.Request) bool
+
+	fe := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(r) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case metricsPath:
+			fmt.Fprint(w, feMetrics)
+		case "/api/backends":
+			fmt.Fprint(w, backendsJSON("10.0.0.2:8040"))
+		}
+	}))
+	defer fe.Close()
+
+	ca := filepath.Join(t.TempDir(), "ca.crt")
+	require.NoError(t, os.WriteFile(ca, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: fe.Certificate().Raw}), 0o600))
+
+	newInput := func() *Input {
+		ipt := defaultInput()
+		ipt.FEURLs = []string{fe.URL}
+		ipt.TLSOpen = true
+		ipt.CacertFile = ca
+		return ipt
+	}
+
+	t.Run("basic-auth", func(t *testing.T) {
+		authorized = func(r *http.Request) bool {
+			user, password, ok := r.BasicAuth()
+			return ok && user == "root" && password == "secret"
+		}
+
+		ipt := newInput()
+		ipt.Username = "root"
+		ipt.Password = "secret"
+		require.NoError(t, ipt.setup())
+
+		bes, err := ipt.discoverBEs()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"https://10.0.0.2:8040"}, bes)
+
+		_, err = ipt.pm.CollectFromHTTPV2(fe.URL + metricsPath)
+		assert.NoError(t, err)
+
+		ipt = newInput()
+		require.NoError(t, ipt.setup())
+		_, err = ipt.discoverBEs()
+		assert.Error(t, err)
+	})
+
+	t.Run("token", func(t *testing.T) {
+		authorized = func(r *http.Request) bool {
+			return r.Header.Get("Authorization") == "Bearer xyz"
+		}
+
+		ipt := newInput()
+		ipt.Username = "root"
+		ipt.Auth = map[string]string{"type": "bearer_token", "token": "xyz"}
+		require.NoError(t, ipt.setup())
+
+		_, err := ipt.pm.CollectFromHTTPV2(fe.URL + metricsPath)
+		assert.NoError(t, err)
+	})
+
+	t.Run("invalid-ca", func(t *testing.T) {
+		ipt := newInput()
+		ipt.CacertFile = filepath.Join(t.TempDir(), "not-exist.crt")
+		assert.Error(t, ipt.setup())
+	})
+}
+
+func TestParseBackends(t *testing.T) {
+	bes, err := parseBackends("https://fe:8030", []byte(backendsJSON("10.0.0.2:8040", "::1:8041")))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://10.0.0.2:8040", "https://[::1]:8041"}, bes)
+
+	_, err = parseBackends("http://fe:8030", []byte(`{"msg":"Unauthorized","code":401}`))
+	assert.Error(t, err)
+
+	_, err = parseBackends("http://fe:8030", []byte(`<html>`))
+	assert.Error(t, err)
+}
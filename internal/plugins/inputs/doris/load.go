@@ -0,0 +1,333 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package doris
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/GuanceCloud/cliutils/point"
+
+	dkio "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/metrics"
+)
+
+const (
+	routineLoadMeasurementName = "doris_routine_load"
+	streamLoadMeasurementName  = "doris_stream_load"
+	loadEventName              = "doris_load_event"
+
+	// queryPath is the FE API to execute SQL, see also
+	// https://doris.apache.org/docs/admin-manual/http-actions/fe/query-api
+	queryPath = "/api/query/default_cluster/"
+
+	// maxStreamLoads limits stream load records queried per database.
+	maxStreamLoads = 1000
+)
+
+// loadJobs monitors routine load and stream load jobs.
+type loadJobs struct {
+	RoutineLoad   bool     `toml:"routine_load"`
+	StreamLoadDBs []string `toml:"stream_load_dbs"`
+}
+
+type queryResponse struct {
+	Msg  string          `json:"msg"`
+	Code int             `json:"code"`
+	Data json.RawMessage `json:"data"`
+}
+
+type queryResult struct {
+	Meta []struct {
+		Name string `json:"name"`
+	} `json:"meta"`
+	Data [][]interface{} `json:"data"`
+}
+
+// parseQueryResult returns rows of the result set as column -> value.
+func parseQueryResult(body []byte) ([]map[string]string, error) {
+	var res queryResponse
+	if err := json.Unmarshal(body, &res); err != nil {
+		return nil, err
+	}
+	if res.Code != 0 {
+		return nil, fmt.Errorf("code %d: %s: %s", res.Code, res.Msg, res.Data)
+	}
+
+	var rs queryResult
+	dec := json.NewDecoder(bytes.NewReader(res.Data))
+	dec.UseNumber()
+	if err := dec.Decode(&rs); err != nil {
+		return nil, err
+	}
+
+	rows := make([]map[string]string, 0, len(rs.Data))
+	for _, vals := range rs.Data {
+		row := make(map[string]string, len(rs.Meta))
+		for i, m := range rs.Meta {
+			if i < len(vals) && vals[i] != nil {
+				row[m.Name] = fmt.Sprint(vals[i])
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// query executes the statement on the first FE answered.
+func (ipt *Input) query(db, stmt string) ([]map[string]string, error) {
+	var lastErr error
+	for _, fe := range ipt.FEURLs {
+		rows, err := ipt.queryFE(strings.TrimSuffix(fe, "/"), db, stmt)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return rows, nil
+	}
+	return nil, lastErr
+}
+
+func (ipt *Input) queryFE(fe, db, stmt string) ([]map[string]string, error) {
+	reqBody, err := json.Marshal(map[string]string{"stmt": stmt})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, fe+queryPath+db, bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth(ipt.Username, ipt.Password)
+
+	resp, err := ipt.cli.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("query %q on %s: status %d: %s", stmt, fe, resp.StatusCode, body)
+	}
+
+	rows, err := parseQueryResult(body)
+	if err != nil {
+		return nil, fmt.Errorf("query %q on %s: %w", stmt, fe, err)
+	}
+	return rows, nil
+}
+
+// collectLoads returns metrics and key events of load jobs.
+func (ipt *Input) collectLoads() (pts, events []*point.Point) {
+	if ipt.Load.RoutineLoad {
+		rows, err := ipt.query("information_schema", "SHOW ALL ROUTINE LOAD")
+		if err != nil {
+			ipt.feedLoadError(fmt.Errorf("collect routine load: %w", err))
+		} else {
+			pts, events = ipt.parseRoutineLoads(rows)
+		}
+	}
+
+	for _, db := range ipt.Load.StreamLoadDBs {
+		rows, err := ipt.query(db, fmt.Sprintf("SHOW STREAM LOAD FROM `%s` ORDER BY FinishTime DESC LIMIT %d", db, maxStreamLoads))
+		if err != nil {
+			ipt.feedLoadError(fmt.Errorf("collect stream load of %s: %w", db, err))
+			continue
+		}
+		if pt := ipt.parseStreamLoads(db, rows); pt != nil {
+			pts = append(pts, pt)
+		}
+	}
+
+	return pts, events
+}
+
+func (ipt *Input) feedLoadError(err error) {
+	l.Warn(err)
+	ipt.feeder.FeedLastError(err.Error(),
+		metrics.WithLastErrorInput(inputName),
+		metrics.WithLastErrorCategory(point.Metric),
+	)
+}
+
+// routineLoadStatistic is column Statistic of SHOW ROUTINE LOAD.
+type routineLoadStatistic struct {
+	ReceivedBytes  int64 `json:"receivedBytes"`
+	LoadedRows     int64 `json:"loadedRows"`
+	ErrorRows      int64 `json:"errorRows"`
+	TotalRows      int64 `json:"totalRows"`
+	UnselectedRows int64 `json:"unselectedRows"`
+}
+
+// parseRoutineLoads builds points of routine load jobs, and key events of
+// jobs turned to PAUSED or CANCELLED since last collection.
+func (ipt *Input) parseRoutineLoads(rows []map[string]string) (pts, events []*point.Point) {
+	tags := ipt.clusterTags("")
+	opts := append(point.DefaultMetricOptions(), point.WithTime(ipt.start))
+
+	states := make(map[string]string, len(rows))
+	for _, row := range rows {
+		id, state := row["Id"], row["State"]
+		states[id] = state
+
+		var kvs point.KVs
+		for k, v := range tags {
+			kvs = kvs.AddTag(k, v)
+		}
+		kvs = kvs.AddTag("db", row["DbName"]).
+			AddTag("table", row["TableName"]).
+			AddTag("job", row["Name"]).
+			AddTag("state", state).
+			AddTag("data_source_type", row["DataSourceType"])
+
+		var stat routineLoadStatistic
+		if err := json.Unmarshal([]byte(row["Statistic"]), &stat); err != nil {
+			l.Debugf("invalid statistic of routine load %s: %s", row["Name"], err)
+		} else {
+			kvs = kvs.Add("received_bytes", stat.ReceivedBytes, false, true).
+				Add("loaded_rows", stat.LoadedRows, false, true).
+				Add("error_rows", stat.ErrorRows, false, true).
+				Add("total_rows", stat.TotalRows, false, true).
+				Add("unselected_rows", stat.UnselectedRows, false, true)
+		}
+
+		// Lag is offset lag of each partition, like {"0":10,"1":0}
+		var lag map[string]int64
+		if err := json.Unmarshal([]byte(row["Lag"]), &lag); err == nil {
+			var sum int64
+			for _, n := range lag {
+				sum += n
+			}
+			kvs = kvs.Add("lag_rows", sum, false, true)
+		}
+
+		if n, err := strconv.ParseInt(row["CurrentTaskNum"], 10, 64); err == nil {
+			kvs = kvs.Add("current_tasks", n, false, true)
+		}
+
+		pts = append(pts, point.NewPointV2(routineLoadMeasurementName, kvs, opts...))
+
+		if prev, ok := ipt.routineLoadStates[id]; ok && prev != state {
+			if pt := ipt.newRoutineLoadEvent(row, tags); pt != nil {
+				events = append(events, pt)
+			}
+		}
+	}
+
+	ipt.routineLoadStates = states
+	return pts, events
+}
+
+func (ipt *Input) newRoutineLoadEvent(row map[string]string, tags map[string]string) *point.Point {
+	var status string
+	switch row["State"] {
+	case "PAUSED":
+		status = "warning"
+	case "CANCELLED":
+		status = "error"
+	default:
+		return nil
+	}
+
+	job := row["DbName"] + "." + row["Name"]
+
+	msg := fmt.Sprintf("routine load job %s of table %s turned to %s: %s", job, row["TableName"], row["State"], row["ReasonOfStateChanged"])
+	if urls := row["ErrorLogUrls"]; urls != "" {
+		msg += ", error log: " + urls
+	}
+
+	var kvs point.KVs
+	for k, v := range tags {
+		kvs = kvs.AddTag(k, v)
+	}
+	kvs = kvs.AddTag("db", row["DbName"]).
+		AddTag("table", row["TableName"]).
+		AddTag("job", row["Name"]).
+		AddTag("state", row["State"]).
+		Add("df_title", fmt.Sprintf("Doris routine load job %s %s", job, strings.ToLower(row["State"])), false, false).
+		Add("df_message", msg, false, false).
+		Add("df_status", status, false, false)
+
+	return point.NewPointV2(loadEventName, kvs, append(point.DefaultLoggingOptions(), point.WithTime(ipt.start))...)
+}
+
+// parseStreamLoads aggregates stream loads of the database finished since
+// last collection, nil returned on first collection.
+func (ipt *Input) parseStreamLoads(db string, rows []map[string]string) *point.Point {
+	// FinishTime like 2023-09-13 15:40:52.968 is comparable as string.
+	mark, seen := ipt.streamLoadMarks[db]
+
+	var (
+		loads, failed, loadedRows, filteredRows, loadBytes int64
+		latest                                             = mark
+	)
+
+	for _, row := range rows {
+		finish := row["FinishTime"]
+		if finish <= mark {
+			continue
+		}
+		if finish > latest {
+			latest = finish
+		}
+
+		loads++
+		if strings.EqualFold(row["Status"], "fail") {
+			failed++
+		}
+		n, _ := strconv.ParseInt(row["LoadedRows"], 10, 64)
+		loadedRows += n
+		n, _ = strconv.ParseInt(row["FilteredRows"], 10, 64)
+		filteredRows += n
+		n, _ = strconv.ParseInt(row["LoadBytes"], 10, 64)
+		loadBytes += n
+	}
+
+	ipt.streamLoadMarks[db] = latest
+	if !seen {
+		return nil
+	}
+
+	var kvs point.KVs
+	for k, v := range ipt.clusterTags("") {
+		kvs = kvs.AddTag(k, v)
+	}
+	kvs = kvs.AddTag("db", db).
+		Add("loads", loads, false, true).
+		Add("failed_loads", failed, false, true).
+		Add("loaded_rows", loadedRows, false, true).
+		Add("filtered_rows", filteredRows, false, true).
+		Add("load_bytes", loadBytes, false, true)
+
+	return point.NewPointV2(streamLoadMeasurementName, kvs, append(point.DefaultMetricOptions(), point.WithTime(ipt.start))...)
+}
+
+func (ipt *Input) feedLoadEvents(events []*point.Point) {
+	if len(events) == 0 {
+		return
+	}
+
+	if err := ipt.feeder.FeedV2(point.KeyEvent, events,
+		dkio.WithElection(ipt.Election),
+		dkio.WithInputName(inputName+"/load")); err != nil {
+		ipt.feeder.FeedLastError(err.Error(),
+			metrics.WithLastErrorInput(inputName),
+			metrics.WithLastErrorCategory(point.KeyEvent),
+		)
+		l.Errorf("feed load events: %s", err)
+	}
+}
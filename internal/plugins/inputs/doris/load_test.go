@@ -0,0 +1,174 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package doris
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func queryJSON(cols []string, rows ...[]interface{}) string {
+	var meta []map[string]string
+	for _, c := range cols {
+		meta = append(meta, map[string]string{"name": c, "type": "CHAR"})
+	}
+
+	j, _ := json.Marshal(map[string]interface{}{
+		"msg":  "success",
+		"code": 0,
+		"data": map[string]interface{}{
+			"type": "result_set",
+			"meta": meta,
+			"data": rows,
+			"time": 5,
+		},
+		"count": 0,
+	})
+	return string(j)
+}
+
+var routineLoadColumns = []string{"Id", "Name", "DbName", "TableName", "State", "DataSourceType", "CurrentTaskNum", "Statistic", "Lag", "ReasonOfStateChanged", "ErrorLogUrls"}
+
+func routineLoadRow(id, state string) []interface{} {
+	return []interface{}{
+		id, "job" + id, "demo", "t" + id, state, "KAFKA", 1,
+		`{"receivedBytes":1024,"errorRows":2,"loadedRows":98,"totalRows":100,"unselectedRows":0}`,
+		`{"0":10,"1":5}`, "ErrorReason{code=errCode = 100, msg='too many filtered rows'}", "",
+	}
+}
+
+func TestQuery(t *testing.T) {
+	var stmt string
+	fe := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, _ := r.BasicAuth()
+		if user != "root" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, queryPath+"information_schema", r.URL.Path)
+
+		var req map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		stmt = req["stmt"]
+
+		fmt.Fprint(w, queryJSON(routineLoadColumns, routineLoadRow("1", "RUNNING")))
+	}))
+	defer fe.Close()
+
+	ipt := defaultInput()
+	ipt.FEURLs = []string{"http://127.0.0.1:1", fe.URL}
+	ipt.Username = "root"
+	ipt.Password = "secret"
+	require.NoError(t, ipt.setup())
+
+	rows, err := ipt.query("information_schema", "SHOW ALL ROUTINE LOAD")
+	require.NoError(t, err)
+	assert.Equal(t, "SHOW ALL ROUTINE LOAD", stmt)
+	require.Len(t, rows, 1)
+	assert.Equal(t, "job1", rows[0]["Name"])
+	assert.Equal(t, "1", rows[0]["CurrentTaskNum"])
+
+	ipt.Password = ""
+	_, err = ipt.query("information_schema", "SHOW ALL ROUTINE LOAD")
+	assert.Error(t, err)
+}
+
+func TestParseQueryResult(t *testing.T) {
+	_, err := parseQueryResult([]byte(`{"msg":"Unauthorized","code":401,"data":"Need auth information."}`))
+	assert.Error(t, err)
+
+	rows, err := parseQueryResult([]byte(queryJSON([]string{"a", "b"}, []interface{}{1234567890123, nil})))
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]string{{"a": "1234567890123"}}, rows)
+}
+
+func TestParseRoutineLoads(t *testing.T) {
+	toRows := func(rows ...[]interface{}) []map[string]string {
+		res, err := parseQueryResult([]byte(queryJSON(routineLoadColumns, rows...)))
+		require.NoError(t, err)
+		return res
+	}
+
+	ipt := defaultInput()
+	ipt.tagger = &taggerMock{electionTags: map[string]string{"election": "foo"}}
+	ipt.start = time.Now()
+
+	// no events on first collection
+	pts, events := ipt.parseRoutineLoads(toRows(routineLoadRow("1", "RUNNING"), routineLoadRow("2", "PAUSED"), routineLoadRow("3", "RUNNING")))
+	require.Len(t, pts, 3)
+	assert.Empty(t, events)
+
+	pt := pts[0]
+	assert.Equal(t, routineLoadMeasurementName, pt.Name())
+	assert.Equal(t, map[string]string{
+		"election": "foo", "db": "demo", "table": "t1", "job": "job1", "state": "RUNNING", "data_source_type": "KAFKA",
+	}, pt.MapTags())
+	assert.Equal(t, int64(15), pt.Get("lag_rows"))
+	assert.Equal(t, int64(2), pt.Get("error_rows"))
+	assert.Equal(t, int64(1024), pt.Get("received_bytes"))
+	assert.Equal(t, int64(1), pt.Get("current_tasks"))
+
+	_, events = ipt.parseRoutineLoads(toRows(routineLoadRow("1", "PAUSED"), routineLoadRow("2", "PAUSED"), routineLoadRow("3", "CANCELLED")))
+	require.Len(t, events, 2)
+
+	assert.Equal(t, loadEventName, events[0].Name())
+	assert.Equal(t, "PAUSED", events[0].Get("state"))
+	assert.Equal(t, "warning", events[0].Get("df_status"))
+	assert.Equal(t, "Doris routine load job demo.job1 paused", events[0].Get("df_title"))
+	assert.Contains(t, events[0].Get("df_message"), "too many filtered rows")
+
+	assert.Equal(t, "error", events[1].Get("df_status"))
+	assert.Equal(t, "job3", events[1].Get("job"))
+
+	// resumed jobs not reported
+	_, events = ipt.parseRoutineLoads(toRows(routineLoadRow("1", "RUNNING")))
+	assert.Empty(t, events)
+}
+
+func TestParseStreamLoads(t *testing.T) {
+	cols := []string{"Label", "Db", "Status", "LoadedRows", "FilteredRows", "LoadBytes", "FinishTime"}
+	toRows := func(rows ...[]interface{}) []map[string]string {
+		res, err := parseQueryResult([]byte(queryJSON(cols, rows...)))
+		require.NoError(t, err)
+		return res
+	}
+
+	ipt := defaultInput()
+	ipt.tagger = &taggerMock{electionTags: map[string]string{"election": "foo"}}
+
+	// only mark the latest stream load on first collection
+	assert.Nil(t, ipt.parseStreamLoads("demo", toRows(
+		[]interface{}{"l1", "demo", "Success", 10, 0, 100, "2023-09-13 15:40:50.000"},
+	)))
+
+	pt := ipt.parseStreamLoads("demo", toRows(
+		[]interface{}{"l3", "demo", "Fail", 0, 5, 50, "2023-09-13 15:41:00.000"},
+		[]interface{}{"l2", "demo", "Success", 20, 1, 200, "2023-09-13 15:40:55.000"},
+		[]interface{}{"l1", "demo", "Success", 10, 0, 100, "2023-09-13 15:40:50.000"},
+	))
+	require.NotNil(t, pt)
+	assert.Equal(t, streamLoadMeasurementName, pt.Name())
+	assert.Equal(t, "demo", pt.Get("db"))
+	assert.Equal(t, int64(2), pt.Get("loads"))
+	assert.Equal(t, int64(1), pt.Get("failed_loads"))
+	assert.Equal(t, int64(20), pt.Get("loaded_rows"))
+	assert.Equal(t, int64(6), pt.Get("filtered_rows"))
+	assert.Equal(t, int64(250), pt.Get("load_bytes"))
+	assert.Equal(t, "2023-09-13 15:41:00.000", ipt.streamLoadMarks["demo"])
+
+	pt = ipt.parseStreamLoads("demo", nil)
+	require.NotNil(t, pt)
+	assert.Equal(t, int64(0), pt.Get("loads"))
+}
@@ -70,6 +70,8 @@ func (*feMeasurement) Info() *inputs.MeasurementInfo {
 		Tags: map[string]interface{}{
 			"host":     inputs.NewTagInfo("Host name."),
 			"instance": inputs.NewTagInfo("Instance endpoint."),
+			"role":     inputs.NewTagInfo("Role of the node, `fe` or `be`."),
+			"node":     inputs.NewTagInfo("Address of the node, `host:port` of the HTTP port."),
 			"job":      inputs.NewTagInfo("Job type."),
 			"method":   inputs.NewTagInfo("Method type."),
 			"name":     inputs.NewTagInfo("Metric name."),
@@ -99,6 +101,8 @@ func (*jvmMeasurement) Info() *inputs.MeasurementInfo {
 		Tags: map[string]interface{}{
 			"host":     inputs.NewTagInfo("Host name."),
 			"instance": inputs.NewTagInfo("Instance endpoint."),
+			"role":     inputs.NewTagInfo("Role of the node, `fe` or `be`."),
+			"node":     inputs.NewTagInfo("Address of the node, `host:port` of the HTTP port."),
 			"type":     inputs.NewTagInfo("Metric type."),
 		},
 	}
@@ -119,6 +123,8 @@ func (*commonMeasurement) Info() *inputs.MeasurementInfo {
 		Tags: map[string]interface{}{
 			"host":     inputs.NewTagInfo("Host name."),
 			"instance": inputs.NewTagInfo("Instance endpoint."),
+			"role":     inputs.NewTagInfo("Role of the node, `fe` or `be`."),
+			"node":     inputs.NewTagInfo("Address of the node, `host:port` of the HTTP port."),
 			"name":     inputs.NewTagInfo("Metric name."),
 			"type":     inputs.NewTagInfo("Metric type."),
 			"state":    inputs.NewTagInfo("Metric state."),
@@ -269,6 +275,8 @@ func (*beMeasurement) Info() *inputs.MeasurementInfo {
 		Tags: map[string]interface{}{
 			"host":     inputs.NewTagInfo("Host name."),
 			"instance": inputs.NewTagInfo("Instance endpoint."),
+			"role":     inputs.NewTagInfo("Role of the node, `fe` or `be`."),
+			"node":     inputs.NewTagInfo("Address of the node, `host:port` of the HTTP port."),
 			"mode":     inputs.NewTagInfo("Metric mode."),
 			"name":     inputs.NewTagInfo("Metric name."),
 			"path":     inputs.NewTagInfo("File path."),
@@ -279,3 +287,162 @@ func (*beMeasurement) Info() *inputs.MeasurementInfo {
 		},
 	}
 }
+
+type healthMeasurement struct{}
+
+//nolint:lll
+func (*healthMeasurement) Info() *inputs.MeasurementInfo {
+	return &inputs.MeasurementInfo{
+		Name: healthMeasurementName,
+		Type: "metric",
+		Desc: "Tablet health of the cluster, queried by `SHOW PROC '/statistic'` and `SHOW PROC '/cluster_health/tablet_health'` of FE, only if `[inputs.doris.cluster_health]` configured.",
+		Fields: map[string]interface{}{
+			"tablets":                     &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.NCount, Desc: "Number of tablets."},
+			"replicas":                    &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.NCount, Desc: "Number of replicas."},
+			"unhealthy_tablets":           &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.NCount, Desc: "Number of unhealthy tablets."},
+			"inconsistent_tablets":        &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.NCount, Desc: "Number of tablets with inconsistent replicas."},
+			"cloning_tablets":             &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.NCount, Desc: "Number of tablets being cloned."},
+			"bad_tablets":                 &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.NCount, Desc: "Number of tablets with bad replicas."},
+			"replica_missing_tablets":     &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.NCount, Desc: "Number of tablets missing replicas, Doris 2.0 and later."},
+			"version_incomplete_tablets":  &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.NCount, Desc: "Number of tablets with incomplete versions, Doris 2.0 and later."},
+			"unrecoverable_tablets":       &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.NCount, Desc: "Number of tablets unrecoverable, Doris 2.0 and later."},
+			"compaction_too_high_tablets": &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.NCount, Desc: "Number of tablets with too many versions to compact, Doris 2.0 and later."},
+			"compaction_score_max":        &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.NCount, Desc: "Max compaction score of BE nodes reported by FE, only if FE metrics collected."},
+		},
+		Tags: map[string]interface{}{
+			"host":     inputs.NewTagInfo("Host name."),
+			"instance": inputs.NewTagInfo("MySQL protocol address of FE queried."),
+		},
+	}
+}
+
+type routineLoadMeasurement struct{}
+
+//nolint:lll
+func (*routineLoadMeasurement) Info() *inputs.MeasurementInfo {
+	return &inputs.MeasurementInfo{
+		Name: routineLoadMeasurementName,
+		Type: "metric",
+		Desc: "Routine load jobs from `SHOW ALL ROUTINE LOAD`, only if `routine_load` of `[inputs.doris.load]` enabled.",
+		Fields: map[string]interface{}{
+			"received_bytes":  &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Count, Unit: inputs.SizeByte, Desc: "Bytes received by the job."},
+			"loaded_rows":     &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Count, Unit: inputs.NCount, Desc: "Rows loaded by the job."},
+			"error_rows":      &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Count, Unit: inputs.NCount, Desc: "Rows failed to load."},
+			"total_rows":      &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Count, Unit: inputs.NCount, Desc: "Rows consumed by the job."},
+			"unselected_rows": &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Count, Unit: inputs.NCount, Desc: "Rows filtered out by the WHERE clause of the job."},
+			"lag_rows":        &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.NCount, Desc: "Offset lag summed over partitions of the data source."},
+			"current_tasks":   &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.NCount, Desc: "Number of running tasks of the job."},
+		},
+		Tags: map[string]interface{}{
+			"host":             inputs.NewTagInfo("Host name."),
+			"db":               inputs.NewTagInfo("Database of the job."),
+			"table":            inputs.NewTagInfo("Table of the job."),
+			"job":              inputs.NewTagInfo("Name of the job."),
+			"state":            inputs.NewTagInfo("State of the job, `NEED_SCHEDULE/RUNNING/PAUSED/STOPPED/CANCELLED`."),
+			"data_source_type": inputs.NewTagInfo("Data source of the job, like `KAFKA`."),
+		},
+	}
+}
+
+type streamLoadMeasurement struct{}
+
+//nolint:lll
+func (*streamLoadMeasurement) Info() *inputs.MeasurementInfo {
+	return &inputs.MeasurementInfo{
+		Name: streamLoadMeasurementName,
+		Type: "metric",
+		Desc: "Stream loads of the database finished within the interval from `SHOW STREAM LOAD`, only for `stream_load_dbs` of `[inputs.doris.load]`.",
+		Fields: map[string]interface{}{
+			"loads":         &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Count, Unit: inputs.NCount, Desc: "Number of stream loads finished."},
+			"failed_loads":  &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Count, Unit: inputs.NCount, Desc: "Number of stream loads failed."},
+			"loaded_rows":   &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Count, Unit: inputs.NCount, Desc: "Rows loaded."},
+			"filtered_rows": &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Count, Unit: inputs.NCount, Desc: "Rows filtered for bad data quality."},
+			"load_bytes":    &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Count, Unit: inputs.SizeByte, Desc: "Bytes loaded."},
+		},
+		Tags: map[string]interface{}{
+			"host": inputs.NewTagInfo("Host name."),
+			"db":   inputs.NewTagInfo("Database of stream loads."),
+		},
+	}
+}
+
+type loadEventMeasurement struct{}
+
+//nolint:lll
+func (*loadEventMeasurement) Info() *inputs.MeasurementInfo {
+	return &inputs.MeasurementInfo{
+		Name: loadEventName,
+		Type: "keyevent",
+		Desc: "Key events of routine load jobs turned to `PAUSED` or `CANCELLED`.",
+		Fields: map[string]interface{}{
+			"df_title":   &inputs.FieldInfo{DataType: inputs.String, Type: inputs.UnknownType, Unit: inputs.UnknownUnit, Desc: "Event title."},
+			"df_message": &inputs.FieldInfo{DataType: inputs.String, Type: inputs.UnknownType, Unit: inputs.UnknownUnit, Desc: "Event detail, with reason of the state changed and URLs of error logs."},
+			"df_status":  &inputs.FieldInfo{DataType: inputs.String, Type: inputs.UnknownType, Unit: inputs.UnknownUnit, Desc: "Event status, `warning` for `PAUSED` and `error` for `CANCELLED`."},
+		},
+		Tags: map[string]interface{}{
+			"host":  inputs.NewTagInfo("Host name."),
+			"db":    inputs.NewTagInfo("Database of the job."),
+			"table": inputs.NewTagInfo("Table of the job."),
+			"job":   inputs.NewTagInfo("Name of the job."),
+			"state": inputs.NewTagInfo("State of the job turned to."),
+		},
+	}
+}
+
+type auditMeasurement struct{}
+
+//nolint:lll
+func (*auditMeasurement) Info() *inputs.MeasurementInfo {
+	return &inputs.MeasurementInfo{
+		Name: auditMeasurementName,
+		Type: "metric",
+		Desc: "RED metrics of queries per database, aggregated from FE audit logs within each interval, only if `red` of `[inputs.doris.log]` enabled.",
+		Fields: map[string]interface{}{
+			"requests":       &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Count, Unit: inputs.NCount, Desc: "Number of audit entries of the database."},
+			"errors":         &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Count, Unit: inputs.NCount, Desc: "Number of audit entries in state `ERR`."},
+			"slow_queries":   &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Count, Unit: inputs.NCount, Desc: "Number of audit entries taking longer than `slow_query_time`, only if `slow_query_time` configured."},
+			"query_time_avg": &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.DurationMS, Desc: "Average query time."},
+			"query_time_max": &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.DurationMS, Desc: "Max query time."},
+			"scan_bytes":     &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Count, Unit: inputs.SizeByte, Desc: "Bytes scanned."},
+			"scan_rows":      &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Count, Unit: inputs.NCount, Desc: "Rows scanned."},
+		},
+		Tags: map[string]interface{}{
+			"host": inputs.NewTagInfo("Host name."),
+			"db":   inputs.NewTagInfo("Database of the query, empty for statements without database."),
+		},
+	}
+}
+
+type auditLogMeasurement struct{}
+
+//nolint:lll
+func (*auditLogMeasurement) Info() *inputs.MeasurementInfo {
+	return &inputs.MeasurementInfo{
+		Name: inputName,
+		Type: "logging",
+		Desc: "FE audit log entries parsed by the built-in pipeline `doris.p`, only entries taking longer than `slow_query_time` if configured.",
+		Fields: map[string]interface{}{
+			"message":       &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "The audit log entry."},
+			"audit_type":    &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "Audit type, `query` or `slow_query`."},
+			"client":        &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "Client address."},
+			"user":          &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "User of the query."},
+			"db":            &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "Database of the query."},
+			"state":         &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "State of the query, like `EOF`, `OK` and `ERR`."},
+			"error_code":    &inputs.FieldInfo{DataType: inputs.Int, Unit: inputs.UnknownUnit, Desc: "Error code."},
+			"error_message": &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "Error message."},
+			"query_time":    &inputs.FieldInfo{DataType: inputs.Int, Unit: inputs.DurationMS, Desc: "Query time."},
+			"scan_bytes":    &inputs.FieldInfo{DataType: inputs.Int, Unit: inputs.SizeByte, Desc: "Bytes scanned."},
+			"scan_rows":     &inputs.FieldInfo{DataType: inputs.Int, Unit: inputs.NCount, Desc: "Rows scanned."},
+			"return_rows":   &inputs.FieldInfo{DataType: inputs.Int, Unit: inputs.NCount, Desc: "Rows returned."},
+			"query_id":      &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "Query ID."},
+			"is_query":      &inputs.FieldInfo{DataType: inputs.Bool, Unit: inputs.UnknownUnit, Desc: "Whether the statement is a query."},
+			"fe_ip":         &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "IP of the FE node."},
+			"statement":     &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "The statement."},
+		},
+		Tags: map[string]interface{}{
+			"host":    inputs.NewTagInfo("Host name."),
+			"service": inputs.NewTagInfo("Service name, `doris`."),
+			"status":  inputs.NewTagInfo("Log status, `error` for entries in state `ERR`, else `info`."),
+		},
+	}
+}
@@ -0,0 +1,51 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package doris
+
+//nolint:lll
+const pipelineCfg = `
+grok(_, "%{TIMESTAMP_ISO8601:time} \\[%{NOTSPACE:audit_type}\\] \\|%{GREEDYDATA:audit_kvs}")
+
+kv_split(audit_kvs, "\\|", "=", include_keys=["Client", "User", "Db", "State", "ErrorCode", "ErrorMessage", "Time(ms)", "ScanBytes", "ScanRows", "ReturnRows", "QueryId", "IsQuery", "feIp"])
+
+# statement may contains '|', fields after it are ignored
+grok(audit_kvs, "(^|\\|)Stmt=%{DATA:statement}\\|(CpuTimeMS|SqlHash|peakMemoryBytes)=")
+if statement == nil {
+  grok(audit_kvs, "(^|\\|)Stmt=%{GREEDYDATA:statement}")
+}
+drop_key(audit_kvs)
+
+rename("client", Client)
+rename("user", User)
+rename("db", Db)
+rename("state", State)
+rename("error_code", ErrorCode)
+rename("error_message", ErrorMessage)
+rename("query_time", ` + "`Time(ms)`" + `)
+rename("scan_bytes", ScanBytes)
+rename("scan_rows", ScanRows)
+rename("return_rows", ReturnRows)
+rename("query_id", QueryId)
+rename("is_query", IsQuery)
+rename("fe_ip", feIp)
+
+cast(error_code, "int")
+cast(query_time, "int")
+cast(scan_bytes, "int")
+cast(scan_rows, "int")
+cast(return_rows, "int")
+cast(is_query, "bool")
+
+nullif(error_message, "")
+
+if state == "ERR" {
+  add_key(status, "error")
+} else {
+  add_key(status, "info")
+}
+
+default_time(time)
+`
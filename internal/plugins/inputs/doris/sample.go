@@ -6,28 +6,36 @@
 package doris
 
 const sampleCfg = `
-[[inputs.prom]]
-  ## Collector alias.
-  source = "doris"
+[[inputs.doris]]
+  ## HTTP addresses of FE nodes, metrics scraped from <url>/metrics.
+  fe_urls = ["http://127.0.0.1:8030"]
 
-  ## (Optional) Collect interval: (defaults to "30s").
-  # interval = "15s"
+  ## HTTP addresses of local BE nodes, scraped by each DataKit even if election enabled.
+  # be_urls = ["http://127.0.0.1:8040"]
 
-  ## Exporter URLs.
-  urls = ["http://127.0.0.1:8030/metrics","http://127.0.0.1:8040/metrics"]
+  ## Discover alive BE nodes by API /api/backends of FE nodes, BE nodes
+  ## discovered are scraped along with FE nodes by the elected DataKit.
+  discover_be = true
 
-  ## Stream Size. 
-  ## The source stream segmentation size.
-  ## Default 1, source stream undivided. 
-  stream_size = 0
+  ## User and password for basic auth of FE/BE HTTP ports, also required by
+  ## FE API /api/query of load job monitoring.
+  # username = "root"
+  # password = ""
 
-  ## TLS configuration.
+  ## TLS configuration, for https FE/BE URLs.
   tls_open = false
   # tls_ca = "/tmp/ca.crt"
   # tls_cert = "/tmp/peer.crt"
   # tls_key = "/tmp/peer.key"
 
-  ## Set to 'true' to enable election.
+  ## (Optional) Collect interval: (defaults to "30s").
+  # interval = "30s"
+
+  ## (Optional) HTTP request timeout: (defaults to "30s").
+  # timeout = "30s"
+
+  ## Set to 'true' to enable election, FE nodes, BE nodes discovered, cluster
+  ## health and load jobs are collected by the elected DataKit only.
   election = true
 
   ## disable setting host tag for this input
@@ -36,29 +44,40 @@ const sampleCfg = `
   ## disable setting instance tag for this input
   disable_instance_tag = false
 
-  ## Measurement name.
-  ## If measurement_name is empty, split metric name by '_', the first field after split as measurement set name, the rest as current metric name.
-  ## If measurement_name is not empty, using this as measurement set name.
-  ## Always add 'measurement_prefix' prefix at last.
-  measurement_name = "doris_common"
-
-## Customize measurement set name.
-## Treat those metrics with prefix as one set.
-## Prioritier over 'measurement_name' configuration.
-[[inputs.prom.measurements]]
-  prefix = "doris_fe_"
-  name = "doris_fe"
-
-[[inputs.prom.measurements]]
-  prefix = "doris_be_"
-  name = "doris_be"
-
-[[inputs.prom.measurements]]
-  prefix = "jvm_"
-  name = "doris_jvm"
-
-## Customize tags.
-# [inputs.prom.tags]
-  # some_tag = "some_value"
-  # more_tag = "some_other_value"
+  ## Customize authentification of metrics and BE discovery, instead of
+  ## username and password. For now support Bearer Token only.
+  ## Filling in 'token' or 'token_file' is acceptable.
+  # [inputs.doris.auth]
+    # type = "bearer_token"
+    # token = "xxxxxxxx"
+    # token_file = "/tmp/token"
+
+  ## Query tablet health of the cluster by SHOW PROC over the MySQL protocol
+  ## port of FE, reported as metric doris_cluster_health.
+  # [inputs.doris.cluster_health]
+  #   addr = "127.0.0.1:9030"
+  #   user = "root"
+  #   password = ""
+
+  ## Monitor load jobs by FE API /api/query, key events are sent when routine
+  ## load jobs turned to PAUSED or CANCELLED.
+  # [inputs.doris.load]
+  #   routine_load = true
+  #   ## Databases to collect stream loads of, requires enable_stream_load_record = true of BE.
+  #   stream_load_dbs = ["demo"]
+
+  ## FE audit logs, parsed by the built-in pipeline doris.p.
+  # [inputs.doris.log]
+  #   files = ["/opt/doris/fe/log/fe.audit.log"]
+  #   ## grok pipeline script path
+  #   pipeline = "doris.p"
+  #   ## Only send audit entries of queries taking longer than it as logs, all entries sent if not set.
+  #   slow_query_time = "5s"
+  #   ## Aggregate requests, errors and query time of audit entries per database as metric doris_audit.
+  #   red = false
+
+  ## Customize tags.
+  # [inputs.doris.tags]
+    # some_tag = "some_value"
+    # more_tag = "some_other_value"
 `
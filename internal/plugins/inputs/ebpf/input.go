@@ -41,6 +41,8 @@ var (
 		"ebpf-conntrack": true,
 		"ebpf-trace":     true,
 		"bpf-netlog":     true,
+		"ebpf-profiling": true,
+		"ebpf-exec":      true,
 	}
 )
 
@@ -79,8 +81,27 @@ type Input struct {
 	TraceNameList      []string `toml:"trace_name_list"`
 	TraceNameBlacklist []string `toml:"trace_name_blacklist"`
 
+	TraceProtocolList      []string `toml:"trace_protocol_list"`
+	TraceProtocolBlacklist []string `toml:"trace_protocol_blacklist"`
+
+	TracePayloadSamplingRate string `toml:"trace_payload_sampling_rate"`
+	TracePayloadMaxSize      int    `toml:"trace_payload_max_size"`
+	TracePayloadMaxPerMin    int    `toml:"trace_payload_max_per_min"`
+
+	ProfilingSampleRate     int      `toml:"profiling_sample_rate"`
+	ProfilingOffCPU         bool     `toml:"profiling_off_cpu"`
+	ProfilingCgroupEnabled  []string `toml:"profiling_cgroup_enabled"`
+	ProfilingCgroupDisabled []string `toml:"profiling_cgroup_disabled"`
+
+	ExecUsers        []string `toml:"exec_users"`
+	ExecBinaries     []string `toml:"exec_binaries"`
+	ExecBashDisabled bool     `toml:"exec_bash_disabled"`
+
 	IPv6Disabled          bool   `toml:"ipv6_disabled"`
 	EphemeralPort         int32  `toml:"ephemeral_port"`
+	NetflowUDPDisabled    bool   `toml:"netflow_udp_disabled"`
+	NetflowIdleTimeout    string `toml:"netflow_idle_timeout"`
+	NetflowSvcHistogram   bool   `toml:"netflow_svc_histogram"`
 	Interval              string `toml:"interval"`
 	SamplingRate          string `toml:"sampling_rate"`
 	SamplingRatePtsPerMin string `toml:"sampling_rate_pts_per_min"`
@@ -165,7 +186,7 @@ loop:
 	}
 	if ipt.NetlogBlacklist != "" {
 		ipt.Input.Envs = append(ipt.Input.Envs,
-			fmt.Sprintf("DKE_NETLOG_NET_FILTER=%s", ipt.NetlogBlacklist))
+			fmt.Sprintf("ENV_INPUT_EBPF_NETLOG_NET_FILTER=%s", ipt.NetlogBlacklist))
 	}
 
 	if ipt.L7NetDisabled == nil && ipt.L7NetEnabled == nil {
@@ -200,6 +221,21 @@ loop:
 			"--ephemeral_port", strconv.FormatInt(int64(ipt.EphemeralPort), 10))
 	}
 
+	if ipt.NetflowUDPDisabled {
+		ipt.Input.Args = append(ipt.Input.Args,
+			"--netflow-udp-disabled")
+	}
+
+	if ipt.NetflowIdleTimeout != "" {
+		ipt.Input.Args = append(ipt.Input.Args,
+			"--netflow-idle-timeout", ipt.NetflowIdleTimeout)
+	}
+
+	if ipt.NetflowSvcHistogram {
+		ipt.Input.Args = append(ipt.Input.Args,
+			"--netflow-svc-histogram")
+	}
+
 	if ipt.Interval != "" {
 		ipt.Input.Args = append(ipt.Input.Args,
 			"--interval", ipt.Interval)
@@ -244,11 +280,63 @@ loop:
 			"--trace-name-blacklist", strings.Join(ipt.TraceNameBlacklist, ","))
 	}
 
+	if len(ipt.TraceProtocolList) > 0 {
+		ipt.Input.Args = append(ipt.Input.Args,
+			"--trace-protos", strings.Join(ipt.TraceProtocolList, ","))
+	}
+	if len(ipt.TraceProtocolBlacklist) > 0 {
+		ipt.Input.Args = append(ipt.Input.Args,
+			"--trace-protos-blacklist", strings.Join(ipt.TraceProtocolBlacklist, ","))
+	}
+
+	if ipt.TracePayloadSamplingRate != "" {
+		ipt.Input.Args = append(ipt.Input.Args,
+			"--trace-payload-sampling-rate", ipt.TracePayloadSamplingRate)
+	}
+	if ipt.TracePayloadMaxSize > 0 {
+		ipt.Input.Args = append(ipt.Input.Args,
+			"--trace-payload-max-size", strconv.Itoa(ipt.TracePayloadMaxSize))
+	}
+	if ipt.TracePayloadMaxPerMin > 0 {
+		ipt.Input.Args = append(ipt.Input.Args,
+			"--trace-payload-max-per-min", strconv.Itoa(ipt.TracePayloadMaxPerMin))
+	}
+
 	if ipt.Conv2DD {
 		ipt.Input.Args = append(ipt.Input.Args,
 			"--conv-to-ddtrace")
 	}
 
+	if ipt.ProfilingSampleRate > 0 {
+		ipt.Input.Args = append(ipt.Input.Args,
+			"--profiling-sample-rate", strconv.Itoa(ipt.ProfilingSampleRate))
+	}
+	if ipt.ProfilingOffCPU {
+		ipt.Input.Args = append(ipt.Input.Args,
+			"--profiling-off-cpu")
+	}
+	if len(ipt.ProfilingCgroupEnabled) > 0 {
+		ipt.Input.Args = append(ipt.Input.Args,
+			"--profiling-cgroup-enabled", strings.Join(ipt.ProfilingCgroupEnabled, ","))
+	}
+	if len(ipt.ProfilingCgroupDisabled) > 0 {
+		ipt.Input.Args = append(ipt.Input.Args,
+			"--profiling-cgroup-disabled", strings.Join(ipt.ProfilingCgroupDisabled, ","))
+	}
+
+	if len(ipt.ExecUsers) > 0 {
+		ipt.Input.Args = append(ipt.Input.Args,
+			"--exec-users", strings.Join(ipt.ExecUsers, ","))
+	}
+	if len(ipt.ExecBinaries) > 0 {
+		ipt.Input.Args = append(ipt.Input.Args,
+			"--exec-binaries", strings.Join(ipt.ExecBinaries, ","))
+	}
+	if ipt.ExecBashDisabled {
+		ipt.Input.Args = append(ipt.Input.Args,
+			"--exec-bash-disabled")
+	}
+
 	if ipt.CPULimit != "" {
 		ipt.Input.Args = append(ipt.Input.Args,
 			"--res-cpu", ipt.CPULimit)
@@ -309,8 +397,14 @@ func (*Input) SampleConfig() string { return configSample }
 func (*Input) SampleMeasurement() []inputs.Measurement {
 	return []inputs.Measurement{
 		&ConnStatsM{},
+		&NetflowProcessM{},
+		&NetflowServiceM{},
 		&DNSStatsM{},
+		&DNSClientM{},
+		&DNSResolverM{},
+		&DNSQueryM{},
 		&BashM{},
+		&ExecAuditM{},
 		&HTTPFlowM{},
 		&BPFL4Log{},
 		&BPFL7Log{},
@@ -328,6 +422,9 @@ func (*Input) AvailableArchs() []string {
 // ENV_INPUT_EBPF_L7NET_ENABLED   : []string
 // ENV_INPUT_EBPF_IPV6_DISABLED   : bool
 // ENV_INPUT_EBPF_EPHEMERAL_PORT  : int32
+// ENV_INPUT_EBPF_NETFLOW_UDP_DISABLED : bool
+// ENV_INPUT_EBPF_NETFLOW_IDLE_TIMEOUT : string
+// ENV_INPUT_EBPF_NETFLOW_SVC_HISTOGRAM : bool
 // ENV_INPUT_EBPF_INTERVAL        : string
 // ENV_INPUT_EBPF_PPROF_HOST      : string
 // ENV_INPUT_EBPF_PPROF_PORT      : string
@@ -349,6 +446,18 @@ func (*Input) AvailableArchs() []string {
 // ENV_INPUT_EBPF_TRACE_NAME_LIST      : string
 // ENV_INPUT_EBPF_TRACE_NAME_BLACKLIST : string
 //
+// ENV_INPUT_EBPF_TRACE_PROTOCOL_LIST      : string
+// ENV_INPUT_EBPF_TRACE_PROTOCOL_BLACKLIST : string
+//
+// ENV_INPUT_EBPF_PROFILING_SAMPLE_RATE     : int
+// ENV_INPUT_EBPF_PROFILING_OFF_CPU         : bool
+// ENV_INPUT_EBPF_PROFILING_CGROUP_ENABLED  : string
+// ENV_INPUT_EBPF_PROFILING_CGROUP_DISABLED : string
+//
+// ENV_INPUT_EBPF_EXEC_USERS         : string
+// ENV_INPUT_EBPF_EXEC_BINARIES      : string
+// ENV_INPUT_EBPF_EXEC_BASH_DISABLED : bool
+//
 // ENV_INPUT_EBPF_SAMPLING_RATE           : string
 // ENV_INPUT_EBPF_SAMPLING_RATE_PTSPERMIN : string.
 func (ipt *Input) ReadEnv(envs map[string]string) {
@@ -389,6 +498,36 @@ func (ipt *Input) ReadEnv(envs map[string]string) {
 		ipt.TraceNameBlacklist = strings.Split(nameBlack, ",")
 	}
 
+	if v, ok := envs["ENV_INPUT_EBPF_TRACE_PROTOCOL_LIST"]; ok {
+		l.Debugf("add trace protocol list from ENV: %v", v)
+		ipt.TraceProtocolList = strings.Split(v, ",")
+	}
+
+	if v, ok := envs["ENV_INPUT_EBPF_TRACE_PROTOCOL_BLACKLIST"]; ok {
+		l.Debugf("add trace protocol blacklist from ENV: %v", v)
+		ipt.TraceProtocolBlacklist = strings.Split(v, ",")
+	}
+
+	if v, ok := envs["ENV_INPUT_EBPF_TRACE_PAYLOAD_SAMPLING_RATE"]; ok {
+		ipt.TracePayloadSamplingRate = v
+	}
+
+	if v, ok := envs["ENV_INPUT_EBPF_TRACE_PAYLOAD_MAX_SIZE"]; ok {
+		if i, err := strconv.Atoi(v); err != nil {
+			l.Warnf("parse ENV_INPUT_EBPF_TRACE_PAYLOAD_MAX_SIZE: %s", err)
+		} else {
+			ipt.TracePayloadMaxSize = i
+		}
+	}
+
+	if v, ok := envs["ENV_INPUT_EBPF_TRACE_PAYLOAD_MAX_PER_MIN"]; ok {
+		if i, err := strconv.Atoi(v); err != nil {
+			l.Warnf("parse ENV_INPUT_EBPF_TRACE_PAYLOAD_MAX_PER_MIN: %s", err)
+		} else {
+			ipt.TracePayloadMaxPerMin = i
+		}
+	}
+
 	if v, ok := envs["ENV_INPUT_EBPF_IPV6_DISABLED"]; ok {
 		switch v {
 		case "", "f", "false", "FALSE", "False", "0": //nolint:goconst
@@ -415,6 +554,28 @@ func (ipt *Input) ReadEnv(envs map[string]string) {
 		}
 	}
 
+	if v, ok := envs["ENV_INPUT_EBPF_NETFLOW_UDP_DISABLED"]; ok {
+		switch v {
+		case "", "f", "false", "FALSE", "False", "0":
+			ipt.NetflowUDPDisabled = false
+		default:
+			ipt.NetflowUDPDisabled = true
+		}
+	}
+
+	if v, ok := envs["ENV_INPUT_EBPF_NETFLOW_IDLE_TIMEOUT"]; ok {
+		ipt.NetflowIdleTimeout = v
+	}
+
+	if v, ok := envs["ENV_INPUT_EBPF_NETFLOW_SVC_HISTOGRAM"]; ok {
+		switch v {
+		case "", "f", "false", "FALSE", "False", "0":
+			ipt.NetflowSvcHistogram = false
+		default:
+			ipt.NetflowSvcHistogram = true
+		}
+	}
+
 	if v, ok := envs["ENV_INPUT_EBPF_INTERVAL"]; ok {
 		ipt.Interval = v
 	}
@@ -504,6 +665,48 @@ func (ipt *Input) ReadEnv(envs map[string]string) {
 		ipt.NetLimit = v
 	}
 
+	if v, ok := envs["ENV_INPUT_EBPF_PROFILING_SAMPLE_RATE"]; ok {
+		if rate, err := strconv.Atoi(v); err != nil {
+			l.Warnf("parse ENV_INPUT_EBPF_PROFILING_SAMPLE_RATE: %s", err)
+		} else {
+			ipt.ProfilingSampleRate = rate
+		}
+	}
+
+	if v, ok := envs["ENV_INPUT_EBPF_PROFILING_OFF_CPU"]; ok {
+		switch v {
+		case "", "f", "false", "FALSE", "False", "0":
+			ipt.ProfilingOffCPU = false
+		default:
+			ipt.ProfilingOffCPU = true
+		}
+	}
+
+	if v, ok := envs["ENV_INPUT_EBPF_PROFILING_CGROUP_ENABLED"]; ok {
+		ipt.ProfilingCgroupEnabled = strings.Split(v, ",")
+	}
+
+	if v, ok := envs["ENV_INPUT_EBPF_PROFILING_CGROUP_DISABLED"]; ok {
+		ipt.ProfilingCgroupDisabled = strings.Split(v, ",")
+	}
+
+	if v, ok := envs["ENV_INPUT_EBPF_EXEC_USERS"]; ok {
+		ipt.ExecUsers = strings.Split(v, ",")
+	}
+
+	if v, ok := envs["ENV_INPUT_EBPF_EXEC_BINARIES"]; ok {
+		ipt.ExecBinaries = strings.Split(v, ",")
+	}
+
+	if v, ok := envs["ENV_INPUT_EBPF_EXEC_BASH_DISABLED"]; ok {
+		switch v {
+		case "", "f", "false", "FALSE", "False", "0":
+			ipt.ExecBashDisabled = false
+		default:
+			ipt.ExecBashDisabled = true
+		}
+	}
+
 	// ENV_INPUT_EBPF_SAMPLING_RATE           : string
 	// ENV_INPUT_EBPF_SAMPLING_RATE_PTSPERMIN : string
 	if v, ok := envs["ENV_INPUT_EBPF_SAMPLING_RATE"]; ok {
@@ -40,7 +40,7 @@ func (m *ConnStatsM) Info() *inputs.MeasurementInfo {
 			"dst_k8s_namespace":       inputs.TagInfo{Desc: "Destination K8s namespace"},
 			"pid":                     inputs.TagInfo{Desc: "Process identification number"},
 			"process_name":            inputs.TagInfo{Desc: "Process name"},
-			"transport":               inputs.TagInfo{Desc: "Transport layer protocol. (udp/tcp)"},
+			"transport":               inputs.TagInfo{Desc: "Transport layer protocol. (udp/tcp/icmp)"},
 			"family":                  inputs.TagInfo{Desc: "Network layer protocol. (IPv4/IPv6)"},
 			"direction":               inputs.TagInfo{Desc: "Use the source (src_ip:src_port) as a frame of reference to identify the connection initiator. (incoming/outgoing)"},
 			"source":                  inputs.TagInfo{Desc: "Fixed value: `netflow`."},
@@ -50,10 +50,69 @@ func (m *ConnStatsM) Info() *inputs.MeasurementInfo {
 			"bytes_read":      newFInfInt("The number of bytes read", inputs.SizeByte),
 			"bytes_written":   newFInfInt("The number of bytes written", inputs.SizeByte),
 			"retransmits":     newFInfInt("The number of retransmissions", inputs.NCount),
-			"rtt":             newFInfInt("TCP Latency", inputs.DurationUS),
+			"rtt":             newFInfInt("TCP Latency, or the ICMP echo round-trip time for the `icmp` transport", inputs.DurationUS),
 			"rtt_var":         newFInfInt("TCP Jitter", inputs.DurationUS),
 			"tcp_closed":      newFInfInt("The number of TCP connection closed", inputs.NCount),
 			"tcp_established": newFInfInt("The number of TCP connection established", inputs.NCount),
+
+			"icmp_echo_requests":  newFInfInt("The number of ICMP echo requests sent by the ping socket", inputs.NCount),
+			"icmp_echo_replies":   newFInfInt("The number of ICMP echo replies received by the ping socket", inputs.NCount),
+			"icmp_echo_lost_rate": newFInfFloat("The percentage of ICMP echo requests without replies", inputs.Percent),
+		},
+	}
+}
+
+type NetflowProcessM struct{}
+
+//nolint:lll
+func (m *NetflowProcessM) Info() *inputs.MeasurementInfo {
+	return &inputs.MeasurementInfo{
+		Name: "netflow_process",
+		Type: point.Metric.String(),
+		Desc: "The network traffic of each process in a collection cycle, the tags `pid/process_name/container_id` are the same as the host process collector",
+		Tags: map[string]interface{}{
+			"host":         inputs.TagInfo{Desc: "System hostname"},
+			"pid":          inputs.TagInfo{Desc: "Process identification number"},
+			"process_name": inputs.TagInfo{Desc: "Process name"},
+			"container_id": inputs.TagInfo{Desc: "Container ID of the process, not set if the process is not in a container"},
+		},
+		Fields: map[string]interface{}{
+			"bytes_read":      newFInfInt("The number of bytes read", inputs.SizeByte),
+			"bytes_written":   newFInfInt("The number of bytes written", inputs.SizeByte),
+			"packets_read":    newFInfInt("The number of packets read", inputs.NCount),
+			"packets_written": newFInfInt("The number of packets written", inputs.NCount),
+			"retransmits":     newFInfInt("The number of TCP retransmissions", inputs.NCount),
+			"tcp_closed":      newFInfInt("The number of TCP connection closed", inputs.NCount),
+			"tcp_established": newFInfInt("The number of TCP connection established", inputs.NCount),
+			"connections":     newFInfInt("The number of connections with traffic", inputs.NCount),
+		},
+	}
+}
+
+type NetflowServiceM struct{}
+
+//nolint:lll
+func (m *NetflowServiceM) Info() *inputs.MeasurementInfo {
+	return &inputs.MeasurementInfo{
+		Name: "netflow_service",
+		Type: point.Metric.String(),
+		Desc: "The TCP RTT histogram and retransmissions of the connections in a collection cycle, aggregated by the K8s service and pod of the server side. The histogram is the same as the Prometheus histogram, the field `rtt_bucket` is reported with the tag `le`",
+		Tags: map[string]interface{}{
+			"host":                inputs.TagInfo{Desc: "System hostname"},
+			"k8s_namespace":       inputs.TagInfo{Desc: "K8s namespace of the server"},
+			"k8s_service_name":    inputs.TagInfo{Desc: "K8s service name of the server"},
+			"k8s_pod_name":        inputs.TagInfo{Desc: "K8s pod name of the server, `N/A` if the connection to the service is not resolved to the pod"},
+			"k8s_deployment_name": inputs.TagInfo{Desc: "K8s deployment name of the server"},
+			"direction":           inputs.TagInfo{Desc: "`incoming` if the server is the local pod, otherwise `outgoing`"},
+			"le":                  inputs.TagInfo{Desc: "The upper bound(us) of the bucket, only for the field `rtt_bucket`"},
+		},
+		Fields: map[string]interface{}{
+			"rtt_bucket":             newFInfInt("The number of the connections whose RTT is not greater than `le`", inputs.NCount),
+			"rtt_sum":                newFInfInt("The sum of the RTT of the connections", inputs.DurationUS),
+			"rtt_count":              newFInfInt("The number of the connections with the RTT sampled", inputs.NCount),
+			"retransmits":            newFInfInt("The number of TCP retransmissions", inputs.NCount),
+			"retransmit_connections": newFInfInt("The number of the connections with retransmissions", inputs.NCount),
+			"connections":            newFInfInt("The number of TCP connections with traffic", inputs.NCount),
 		},
 	}
 }
@@ -95,15 +154,16 @@ func (m *HTTPFlowM) Info() *inputs.MeasurementInfo {
 			"sub_source":              inputs.TagInfo{Desc: "Some specific connection classifications, such as the sub_source value for Kubernetes network traffic is K8s"},
 		},
 		Fields: map[string]interface{}{
-			"truncated":     newFInfBool("The length of the request path has reached the upper limit of the number of bytes collected, and the request path may be truncated", inputs.UnknownUnit),
-			"path":          newFString("Request path"),
-			"status_code":   newFInfInt("Http status codes", inputs.UnknownUnit),
-			"method":        newFString("GET/POST/..."),
-			"latency":       newFInfInt("TTFB", inputs.DurationNS),
-			"http_version":  newFString("1.1 / 1.0 ..."),
-			"count":         newFInfInt("The total number of HTTP requests in a collection cycle", inputs.UnknownUnit),
-			"bytes_read":    newFInfInt("The number of bytes read", inputs.SizeByte),
-			"bytes_written": newFInfInt("The number of bytes written", inputs.SizeByte),
+			"truncated":        newFInfBool("The length of the request path has reached the upper limit of the number of bytes collected, and the request path may be truncated", inputs.UnknownUnit),
+			"path":             newFString("Request path"),
+			"status_code":      newFInfInt("Http status codes", inputs.UnknownUnit),
+			"grpc_status_code": newFInfInt("gRPC status code, only for gRPC", inputs.UnknownUnit),
+			"method":           newFString("GET/POST/..."),
+			"latency":          newFInfInt("TTFB", inputs.DurationNS),
+			"http_version":     newFString("1.1 / 1.0 / 2.0 ..."),
+			"count":            newFInfInt("The total number of HTTP requests in a collection cycle", inputs.UnknownUnit),
+			"bytes_read":       newFInfInt("The number of bytes read", inputs.SizeByte),
+			"bytes_written":    newFInfInt("The number of bytes written", inputs.SizeByte),
 		},
 	}
 }
@@ -151,6 +211,108 @@ func (m *DNSStatsM) Info() *inputs.MeasurementInfo {
 	}
 }
 
+var (
+	dnsAnomalyCountFields = map[string]interface{}{
+		"count":          newFInfInt("The number of DNS requests in a collection cycle", inputs.NCount),
+		"nxdomain_count": newFInfInt("The number of `NXDomain` responses", inputs.NCount),
+		"servfail_count": newFInfInt("The number of `ServFail` responses", inputs.NCount),
+		"timeout_count":  newFInfInt("The number of requests timed out", inputs.NCount),
+	}
+
+	dnsAnomalySrcK8sTags = map[string]interface{}{
+		"src_k8s_pod_name":        inputs.TagInfo{Desc: "Source K8s pod name"},
+		"src_k8s_deployment_name": inputs.TagInfo{Desc: "Source K8s deployment name"},
+		"src_k8s_service_name":    inputs.TagInfo{Desc: "Source K8s service name"},
+		"src_k8s_namespace":       inputs.TagInfo{Desc: "Source K8s namespace"},
+	}
+)
+
+func mergeInfo(maps ...map[string]interface{}) map[string]interface{} {
+	r := map[string]interface{}{}
+	for _, m := range maps {
+		for k, v := range m {
+			r[k] = v
+		}
+	}
+	return r
+}
+
+type DNSClientM struct{}
+
+//nolint:lll
+func (m *DNSClientM) Info() *inputs.MeasurementInfo {
+	return &inputs.MeasurementInfo{
+		Name: "dnsflow_client",
+		Type: point.Network.String(),
+		Desc: "The DNS error rates of each client in a collection cycle",
+		Tags: mergeInfo(dnsAnomalySrcK8sTags, map[string]interface{}{
+			"host":       inputs.TagInfo{Desc: "System hostname"},
+			"src_ip":     inputs.TagInfo{Desc: "Client IP"},
+			"family":     inputs.TagInfo{Desc: "Network layer protocol. (IPv4/IPv6)"},
+			"source":     inputs.TagInfo{Desc: "Fixed value: `dnsflow_client`."},
+			"sub_source": inputs.TagInfo{Desc: "The sub_source value for Kubernetes pods is K8s"},
+		}),
+		Fields: mergeInfo(dnsAnomalyCountFields, map[string]interface{}{
+			"nxdomain_rate": newFInfFloat("The percentage of `NXDomain` responses", inputs.Percent),
+			"servfail_rate": newFInfFloat("The percentage of `ServFail` responses", inputs.Percent),
+			"error_rate":    newFInfFloat("The percentage of `NXDomain`, `ServFail` responses and timed out requests", inputs.Percent),
+		}),
+	}
+}
+
+type DNSResolverM struct{}
+
+//nolint:lll
+func (m *DNSResolverM) Info() *inputs.MeasurementInfo {
+	return &inputs.MeasurementInfo{
+		Name: "dnsflow_resolver",
+		Type: point.Network.String(),
+		Desc: "The response time quantiles and error rates of each DNS resolver in a collection cycle",
+		Tags: map[string]interface{}{
+			"host":                    inputs.TagInfo{Desc: "System hostname"},
+			"dst_ip":                  inputs.TagInfo{Desc: "Resolver IP"},
+			"dst_k8s_pod_name":        inputs.TagInfo{Desc: "Destination K8s pod name"},
+			"dst_k8s_deployment_name": inputs.TagInfo{Desc: "Destination K8s deployment name"},
+			"dst_k8s_service_name":    inputs.TagInfo{Desc: "Destination K8s service name"},
+			"dst_k8s_namespace":       inputs.TagInfo{Desc: "Destination K8s namespace"},
+			"family":                  inputs.TagInfo{Desc: "Network layer protocol. (IPv4/IPv6)"},
+			"source":                  inputs.TagInfo{Desc: "Fixed value: `dnsflow_resolver`."},
+			"sub_source":              inputs.TagInfo{Desc: "The sub_source value for Kubernetes pods is K8s"},
+		},
+		Fields: mergeInfo(dnsAnomalyCountFields, map[string]interface{}{
+			"slow_count":   newFInfInt("The number of responses slower than 100ms", inputs.NCount),
+			"error_rate":   newFInfFloat("The percentage of `NXDomain`, `ServFail` responses and timed out requests", inputs.Percent),
+			"timeout_rate": newFInfFloat("The percentage of requests timed out", inputs.Percent),
+			"latency_p50":  newFInfInt("P50 response time", inputs.DurationNS),
+			"latency_p90":  newFInfInt("P90 response time", inputs.DurationNS),
+			"latency_p99":  newFInfInt("P99 response time", inputs.DurationNS),
+			"latency_max":  newFInfInt("Maximum response time", inputs.DurationNS),
+		}),
+	}
+}
+
+type DNSQueryM struct{}
+
+//nolint:lll
+func (m *DNSQueryM) Info() *inputs.MeasurementInfo {
+	return &inputs.MeasurementInfo{
+		Name: "dnsflow_query",
+		Type: point.Network.String(),
+		Desc: "The top 10 queried domains of each client in a collection cycle",
+		Tags: mergeInfo(dnsAnomalySrcK8sTags, map[string]interface{}{
+			"host":       inputs.TagInfo{Desc: "System hostname"},
+			"src_ip":     inputs.TagInfo{Desc: "Client IP"},
+			"qname":      inputs.TagInfo{Desc: "The queried domain"},
+			"family":     inputs.TagInfo{Desc: "Network layer protocol. (IPv4/IPv6)"},
+			"source":     inputs.TagInfo{Desc: "Fixed value: `dnsflow_query`."},
+			"sub_source": inputs.TagInfo{Desc: "The sub_source value for Kubernetes pods is K8s"},
+		}),
+		Fields: mergeInfo(dnsAnomalyCountFields, map[string]interface{}{
+			"latency": newFInfInt("Average response time, the timed out requests are excluded", inputs.DurationNS),
+		}),
+	}
+}
+
 type BashM struct{}
 
 //nolint:lll
@@ -170,6 +332,37 @@ func (m *BashM) Info() *inputs.MeasurementInfo {
 	}
 }
 
+type ExecAuditM struct{}
+
+//nolint:lll
+func (m *ExecAuditM) Info() *inputs.MeasurementInfo {
+	return &inputs.MeasurementInfo{
+		Name: "exec_audit",
+		Type: point.Security.String(),
+		Tags: map[string]interface{}{
+			"host":         inputs.TagInfo{Desc: "Host name"},
+			"category":     inputs.TagInfo{Desc: "Fixed value: `system`"},
+			"level":        inputs.TagInfo{Desc: "The level of the event, `warn` if the execution failed, otherwise `info`"},
+			"rule":         inputs.TagInfo{Desc: "The kind of the event, `exec` for the execve syscalls and `bash` for the bash command lines"},
+			"title":        inputs.TagInfo{Desc: "The title of the event"},
+			"uid":          inputs.TagInfo{Desc: "The uid of the user"},
+			"user":         inputs.TagInfo{Desc: "The user name, or the uid if not found"},
+			"process_name": inputs.TagInfo{Desc: "The name of the process"},
+			"binary":       inputs.TagInfo{Desc: "The path of the executed binary, only for `exec`"},
+			"container_id": inputs.TagInfo{Desc: "The container id of the process"},
+		},
+		Fields: map[string]interface{}{
+			"message": newFString("The audit record generated by the collector"),
+			"pid":     newFInfInt("Process identification number", inputs.UnknownUnit),
+			"ppid":    newFInfInt("The pid of the parent process, not set if the process exited", inputs.UnknownUnit),
+			"argv":    newFString("The arguments of the execution, the first 16 arguments are kept and each is truncated to 63 bytes"),
+			"argc":    newFInfInt("The number of the kept arguments", inputs.NCount),
+			"retval":  newFInfInt("The return value of the execve syscall, negative if failed", inputs.UnknownUnit),
+			"cmd":     newFString("The bash command line, only for `bash`"),
+		},
+	}
+}
+
 type BPFL4Log struct{}
 
 //nolint:lll
@@ -316,6 +509,9 @@ func (bpftrace *EBPFTrace) Info() *inputs.MeasurementInfo {
 			"app_trace_id":   newFString("Trace id carried by the application in the request"),
 			"app_parent_id":  newFString("Parent span id carried by the application in the request"),
 
+			"req_payload":  newFString("The truncated request payload of the sampled call, only the headers for HTTP"),
+			"resp_payload": newFString("The truncated response payload of the sampled call, only the headers for HTTP"),
+
 			"source_type": newFString("Source type, value is `ebpf`"),
 
 			"pid":          newFString("Process identification number"),
@@ -373,3 +569,12 @@ func newFInfInt(desc, unit string) *inputs.FieldInfo {
 		Desc:     desc,
 	}
 }
+
+func newFInfFloat(desc, unit string) *inputs.FieldInfo {
+	return &inputs.FieldInfo{
+		Type:     inputs.Gauge,
+		DataType: inputs.Float,
+		Unit:     unit,
+		Desc:     desc,
+	}
+}
@@ -45,13 +45,19 @@ const configSample = `
   ## - "bpf-netlog":
   ##     contains L4-network log (bpf_net_l4_log), L7-network log (bpf_net_l7_log), 
   ##              L4-network(netflow), L7-network(httpflow, dnsflow) collection
+  ## - "ebpf-profiling":
+  ##     the on-cpu and off-cpu profiles(pprof) of the processes, grouped by the containers
+  ## - "ebpf-exec":
+  ##     audit the command executions(execve) and the bash command lines as security events
   enabled_plugins = [
     "ebpf-net",
   ]
 
   ## If you enable the ebpf-net plugin, you can configure:
   ##  - "httpflow" (* enabled by default)
-  ##  - "httpflow-tls"
+  ##  - "httpflow-tls":
+  ##      attach uprobes to OpenSSL(libssl.so) and Go crypto/tls(amd64 go1.17+, arm64 go1.18+),
+  ##      the decrypted payloads of the HTTPS traffic are collected
   ##
   l7net_enabled = [
     "httpflow",
@@ -105,6 +111,48 @@ const configSample = `
     # "datakit-ebpf",
   ]
 
+  ## trace the specified protocols only, such as "http", "grpc", "mysql",
+  ## "redis", all protocols are traced if not set
+  ##
+  # trace_protocol_list = []
+
+  ## deny tracing the specified protocols
+  ##
+  # trace_protocol_blacklist = []
+
+  ## attach the truncated request and response payload(only the headers
+  ## for HTTP) to the fraction of traced calls, capped by the size(byte) and
+  ## the number per minute; the samples carry app_trace_id if the trace
+  ## propagation headers are present
+  ##
+  # trace_payload_sampling_rate = "0.01"
+  # trace_payload_max_size = 256
+  # trace_payload_max_per_min = 60
+
+  ## ebpf-profiling plugin, the cpu sampling frequency(Hz)
+  ##
+  # profiling_sample_rate = 99
+
+  ## collect the off-cpu time(blocked by IO, lock, sleep, etc.) with the stacks
+  ##
+  # profiling_off_cpu = false
+
+  ## only profile the cgroup v2 paths matching any of the glob patterns, the
+  ## sub cgroups are also matched, such as "/kubepods.slice/*"; the disabled list
+  ## is ignored when the enabled list is set
+  ##
+  # profiling_cgroup_enabled = []
+  # profiling_cgroup_disabled = []
+
+  ## If you enable the ebpf-exec plugin, you can audit the commands executed by
+  ## the users(names or uids) or the binaries(path or name patterns) only,
+  ## all are audited if not set. The users are also checked for the bash command
+  ## lines, which are not audited if exec_bash_disabled is true.
+  ##
+  # exec_users = ["root"]
+  # exec_binaries = ["curl", "/usr/sbin/*"]
+  # exec_bash_disabled = false
+
   ## conv other trace id to datadog trace id (base 10, 64-bit) 
   conv_to_ddtrace = false
 
@@ -116,6 +164,17 @@ const configSample = `
   ##
   # ephemeral_port = 10001
 
+  ## the udp flows are collected by netflow if not disabled, the udp flows
+  ## and icmp echo statistics are flushed after idle for <netflow_idle_timeout>
+  ##
+  # netflow_udp_disabled = false
+  # netflow_idle_timeout = "2m"
+
+  ## report the metric netflow_service, the tcp rtt histogram and retransmits
+  ## aggregated by the k8s service and pod of the server side
+  ##
+  # netflow_svc_histogram = false
+
   # interval = "60s"
 
   # sampling_rate = "0.50"
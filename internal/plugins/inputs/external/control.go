@@ -0,0 +1,238 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package external
+
+import (
+	"encoding/json"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GuanceCloud/cliutils"
+)
+
+const actionReload = "reload"
+
+// reloadGracePeriod is how long the process of a terminated input kept
+// running, waiting for the reloaded input to take it over.
+var reloadGracePeriod = 30 * time.Second
+
+// SupportControl decides if an external input reads control messages from
+// stdin, the reloaded config is sent to the running process instead of
+// restarting it.
+func SupportControl(name string) bool {
+	list := []string{"oracle", "ebpf"}
+	for _, v := range list {
+		if name == v {
+			return true
+		}
+	}
+	return false
+}
+
+// controlMessage is sent to the external collector by stdin, one JSON per line.
+// Same as struct 'ControlMessage' in internal/plugins/externals/oracle/collect/ccommon/control.go
+// and 'controlMessage' in internal/plugins/externals/ebpf/internal/cmd/run/reload.go.
+type controlMessage struct {
+	Action string   `json:"action"`
+	Args   []string `json:"args"`
+	Envs   []string `json:"envs"`
+}
+
+// daemonProc is the running process of a terminated daemon input.
+type daemonProc struct {
+	key     string
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	semStop *cliutils.Sem // close to kill the process
+	exited  chan struct{} // closed once the process exited
+	timer   *time.Timer
+}
+
+func (p *daemonProc) pid() int {
+	if p.cmd == nil || p.cmd.Process == nil {
+		return -1
+	}
+	return p.cmd.Process.Pid
+}
+
+func (p *daemonProc) send(msg *controlMessage) error {
+	j, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	_, err = p.stdin.Write(append(j, '\n'))
+	return err
+}
+
+var (
+	parkedMtx sync.Mutex
+	parked    = map[string][]*daemonProc{}
+)
+
+// reloadableArgs are the args applied by the running process of the input on
+// reloaded, other args could not be applied without restarting. The args of
+// inputs not listed are all reloadable.
+var reloadableArgs = map[string]map[string]bool{
+	"ebpf": {
+		"--sampling-rate":               true,
+		"--sampling-rate-ptsperminute":  true,
+		"--trace-protos":                true,
+		"--trace-protos-blacklist":      true,
+		"--trace-payload-sampling-rate": true,
+		"--trace-payload-max-size":      true,
+		"--trace-payload-max-per-min":   true,
+	},
+}
+
+// restartArgs returns the args could not be applied by reloading, the
+// reloadable args and their values are removed.
+func restartArgs(name string, args []string) []string {
+	reloadable, ok := reloadableArgs[name]
+	if !ok {
+		return nil
+	}
+
+	var arr []string
+	for i := 0; i < len(args); i++ {
+		flag, _, withValue := strings.Cut(args[i], "=")
+		if !reloadable[flag] {
+			arr = append(arr, args[i])
+			continue
+		}
+
+		if !withValue { // --flag value
+			i++
+		}
+	}
+	return arr
+}
+
+// procKey identifies processes could be taken over by each other. Changes of
+// the command, args not reloadable or environment variables except ENV_INPUT_*
+// (such as LD_LIBRARY_PATH) could not be applied to a running process, the
+// process is restarted instead.
+func procKey(name, cmd string, args, envs []string) string {
+	arr := []string{name, cmd}
+	arr = append(arr, restartArgs(name, args)...)
+	for _, env := range envs {
+		if !strings.HasPrefix(env, "ENV_INPUT_") {
+			arr = append(arr, env)
+		}
+	}
+	return strings.Join(arr, "\n")
+}
+
+// park keeps the process running for reloadGracePeriod, it's killed if not
+// taken over.
+func park(p *daemonProc) {
+	parkedMtx.Lock()
+	defer parkedMtx.Unlock()
+
+	parked[p.key] = append(parked[p.key], p)
+	p.timer = time.AfterFunc(reloadGracePeriod, func() {
+		if unpark(p) {
+			l.Infof("process %d not taken over in %s, stop it", p.pid(), reloadGracePeriod)
+			p.semStop.Close()
+		}
+	})
+}
+
+func unpark(p *daemonProc) bool {
+	parkedMtx.Lock()
+	defer parkedMtx.Unlock()
+
+	arr := parked[p.key]
+	for i, x := range arr {
+		if x == p {
+			parked[p.key] = append(arr[:i], arr[i+1:]...)
+			if len(parked[p.key]) == 0 {
+				delete(parked, p.key)
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// takeParked returns a running parked process of the key, nil if none.
+func takeParked(key string) *daemonProc {
+	parkedMtx.Lock()
+	defer parkedMtx.Unlock()
+
+	for len(parked[key]) > 0 {
+		p := parked[key][0]
+		parked[key] = parked[key][1:]
+		p.timer.Stop()
+
+		select {
+		case <-p.exited:
+			continue
+		default:
+			return p
+		}
+	}
+
+	delete(parked, key)
+	return nil
+}
+
+// parkDaemon parks the running process on terminated, the process is killed
+// as usual if the input not support control.
+func (ipt *Input) parkDaemon() {
+	ipt.mtx.Lock()
+	defer ipt.mtx.Unlock()
+
+	if !ipt.Daemon || !ipt.daemonStarted || ipt.stdin == nil || ipt.parked {
+		return
+	}
+
+	park(&daemonProc{
+		key:     procKey(ipt.Name, ipt.Cmd, ipt.Args, ipt.Envs),
+		cmd:     ipt.cmd,
+		stdin:   ipt.stdin,
+		semStop: ipt.semStopProcess,
+		exited:  ipt.procExitReply,
+	})
+	ipt.parked = true
+}
+
+// takeOver sends the config to the parked process of the same input, false
+// returned if no process taken over.
+func (ipt *Input) takeOver() bool {
+	if !SupportControl(ipt.Name) {
+		return false
+	}
+
+	p := takeParked(procKey(ipt.Name, ipt.Cmd, ipt.Args, ipt.Envs))
+	if p == nil {
+		return false
+	}
+
+	ipt.getCustomQuery()
+
+	if err := p.send(&controlMessage{Action: actionReload, Args: ipt.Args, Envs: ipt.Envs}); err != nil {
+		l.Warnf("send config to process %d of %s failed: %s, restart it", p.pid(), ipt.Name, err.Error())
+		p.semStop.Close()
+		<-p.exited
+		return false
+	}
+
+	ipt.mtx.Lock()
+	defer ipt.mtx.Unlock()
+
+	ipt.cmd = p.cmd
+	ipt.stdin = p.stdin
+	ipt.semStopProcess = p.semStop
+	ipt.procExitReply = p.exited
+	ipt.daemonStarted = true
+
+	l.Infof("external input %s took over process %d, config reloaded", ipt.Name, p.pid())
+	return true
+}
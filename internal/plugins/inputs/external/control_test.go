@@ -0,0 +1,124 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package external
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/GuanceCloud/cliutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type bufCloser struct {
+	bytes.Buffer
+}
+
+func (*bufCloser) Close() error { return nil }
+
+func newParkedProc(key string) *daemonProc {
+	return &daemonProc{
+		key:     key,
+		stdin:   &bufCloser{},
+		semStop: cliutils.NewSem(),
+		exited:  make(chan struct{}),
+	}
+}
+
+func TestProcKey(t *testing.T) {
+	assert.Equal(t,
+		procKey("oracle", "/usr/local/datakit/externals/oracle", []string{"--host", "db1"}, []string{"ENV_INPUT_ORACLE_PASSWORD=a", "LD_LIBRARY_PATH=/opt"}),
+		procKey("oracle", "/usr/local/datakit/externals/oracle", []string{"--host", "db2"}, []string{"LD_LIBRARY_PATH=/opt", "ENV_INPUT_ORACLE_PASSWORD=b"}))
+
+	assert.NotEqual(t,
+		procKey("oracle", "/usr/local/datakit/externals/oracle", nil, []string{"LD_LIBRARY_PATH=/opt"}),
+		procKey("oracle", "/usr/local/datakit/externals/oracle", nil, []string{"LD_LIBRARY_PATH=/usr/lib"}))
+
+	const ebpf = "/usr/local/datakit/externals/datakit-ebpf"
+
+	// reloadable args changed
+	assert.Equal(t,
+		procKey("ebpf", ebpf, []string{"run", "--enabled", "ebpf-net", "--sampling-rate", "0.5", "--trace-protos=http"}, nil),
+		procKey("ebpf", ebpf, []string{"run", "--enabled", "ebpf-net", "--sampling-rate", "0.1"}, nil))
+
+	// the BPF programs enabled changed
+	assert.NotEqual(t,
+		procKey("ebpf", ebpf, []string{"run", "--enabled", "ebpf-net", "--sampling-rate", "0.5"}, nil),
+		procKey("ebpf", ebpf, []string{"run", "--enabled", "ebpf-net,ebpf-trace", "--sampling-rate", "0.5"}, nil))
+}
+
+func TestRestartArgs(t *testing.T) {
+	assert.Nil(t, restartArgs("oracle", []string{"--host", "db1"}))
+	assert.Equal(t, []string{"run", "--netlog-metric", "--hostname", "h1"},
+		restartArgs("ebpf", []string{"run", "--netlog-metric", "--trace-protos", "http,grpc", "--hostname", "h1", "--sampling-rate=0.5"}))
+}
+
+func TestParkAndTake(t *testing.T) {
+	t.Run("take-over", func(t *testing.T) {
+		p1, p2 := newParkedProc("k"), newParkedProc("k")
+		park(p1)
+		park(p2)
+
+		assert.Equal(t, p1, takeParked("k"))
+		assert.Equal(t, p2, takeParked("k"))
+		assert.Nil(t, takeParked("k"))
+		assert.NotContains(t, parked, "k")
+	})
+
+	t.Run("exited-skipped", func(t *testing.T) {
+		p1, p2 := newParkedProc("k"), newParkedProc("k")
+		close(p1.exited)
+		park(p1)
+		park(p2)
+
+		assert.Equal(t, p2, takeParked("k"))
+	})
+
+	t.Run("stopped-after-grace-period", func(t *testing.T) {
+		defer func(d time.Duration) { reloadGracePeriod = d }(reloadGracePeriod)
+		reloadGracePeriod = 10 * time.Millisecond
+
+		p := newParkedProc("k")
+		park(p)
+
+		select {
+		case <-p.semStop.Wait():
+		case <-time.After(time.Second):
+			t.Fatal("parked process not stopped")
+		}
+		assert.Nil(t, takeParked("k"))
+	})
+}
+
+func TestTakeOver(t *testing.T) {
+	ipt := NewInput()
+	ipt.Name = "oracle"
+	ipt.Daemon = true
+	ipt.Cmd = "/usr/local/datakit/externals/oracle"
+	ipt.Args = []string{"--host", "db2"}
+	ipt.Envs = []string{"ENV_INPUT_ORACLE_PASSWORD=new"}
+
+	assert.False(t, ipt.takeOver())
+
+	p := newParkedProc(procKey(ipt.Name, ipt.Cmd, []string{"--host", "db1"}, []string{"ENV_INPUT_ORACLE_PASSWORD=old"}))
+	park(p)
+
+	require.True(t, ipt.takeOver())
+	assert.True(t, ipt.daemonStarted)
+	assert.Equal(t, p.semStop, ipt.semStopProcess)
+
+	var msg controlMessage
+	require.NoError(t, json.Unmarshal(p.stdin.(*bufCloser).Bytes(), &msg))
+	assert.Equal(t, controlMessage{Action: actionReload, Args: ipt.Args, Envs: ipt.Envs}, msg)
+
+	// park again on terminated
+	ipt.Terminate()
+	assert.True(t, ipt.parked)
+	assert.Equal(t, p.semStop, takeParked(p.key).semStop)
+}
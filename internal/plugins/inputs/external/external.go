@@ -11,9 +11,11 @@ import (
 	"context"
 	"encoding/gob"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/GuanceCloud/cliutils"
@@ -82,6 +84,13 @@ type Input struct {
 
 	daemonStarted bool
 
+	// stdin is the control channel of the daemon process if SupportControl.
+	stdin  io.WriteCloser
+	parked bool // the daemon process parked on terminated
+	mtx    sync.Mutex
+
+	customQueryAdded bool
+
 	pauseCh chan bool
 	pause   bool
 }
@@ -93,6 +102,11 @@ type customQuery struct {
 	Tags   []string `toml:"tags"`
 	Fields []string `toml:"fields"`
 
+	// Interval runs the query every interval instead of every collection.
+	Interval time.Duration `toml:"interval"`
+	// FieldTypes is column -> type of fields, float/int/bool/string, float by default.
+	FieldTypes map[string]string `toml:"field_types"`
+
 	MD5Hash string
 }
 
@@ -158,6 +172,14 @@ func (ipt *Input) start() error {
 			return fmt.Errorf("command failed: %w, %s", err, res)
 		}
 	} else {
+		if SupportControl(ipt.Name) {
+			stdin, err := ipt.cmd.StdinPipe()
+			if err != nil {
+				return err
+			}
+			ipt.stdin = stdin
+		}
+
 		if err := ipt.cmd.Start(); err != nil {
 			l.Errorf("start external input %s failed: %s", ipt.Name, err.Error())
 			return err
@@ -234,18 +256,25 @@ func (ipt *Input) Run() {
 
 		case <-ipt.semStop.Wait():
 			l.Infof("external input %s stopped", ipt.Name)
-			ipt.semStopProcess.Close()
+			ipt.mtx.Lock()
+			if !ipt.parked { // parked process is taken over by the reloaded input
+				ipt.semStopProcess.Close()
+			}
+			ipt.mtx.Unlock()
 			return
 
 		case ipt.pause = <-ipt.pauseCh:
 			if ipt.pause {
 				l.Infof("%s paused", ipt.Name)
+				ipt.mtx.Lock()
 				if ipt.Daemon && ipt.daemonStarted { // stop the daemon running process
 					ipt.semStopProcess.Close() // trigger the daemon process exit
 					<-ipt.procExitReply        // sync with goroutine monitoring external input process
 					ipt.daemonStarted = false
+					ipt.stdin = nil
 					ipt.semStopProcess = cliutils.NewSem() // reopen the sem
 				}
+				ipt.mtx.Unlock()
 			}
 
 		case <-tick.C:
@@ -269,6 +298,10 @@ func (ipt *Input) getCustomQuery() {
 		return
 	}
 
+	if ipt.customQueryAdded {
+		return
+	}
+
 	tmpFile, err := os.CreateTemp(os.TempDir(), "custom_query")
 	if err != nil {
 		l.Errorf("os.CreateTemp() failed: %v", err)
@@ -289,6 +322,7 @@ func (ipt *Input) getCustomQuery() {
 	l.Infof("Wrote file %s, wrote %d bytes.", tmpFile.Name(), cnt)
 
 	ipt.Args = append(ipt.Args, "--custom-query", tmpFile.Name())
+	ipt.customQueryAdded = true
 }
 
 func (ipt *Input) daemonRun() {
@@ -296,6 +330,11 @@ func (ipt *Input) daemonRun() {
 		return
 	}
 
+	// the process of the input before reloading still running
+	if ipt.takeOver() {
+		return
+	}
+
 	// start failed, retry
 	for {
 		l.Debug("daemon starting")
@@ -303,11 +342,14 @@ func (ipt *Input) daemonRun() {
 			time.Sleep(time.Second)
 			continue
 		}
-		ipt.daemonStarted = true
 		break
 	}
 	g := goroutine.NewGroup(goroutine.Option{Name: "inputs_external"})
 
+	ipt.mtx.Lock()
+	defer ipt.mtx.Unlock()
+
+	ipt.daemonStarted = true
 	ipt.procExitReply = make(chan struct{})
 
 	func(process *os.Process, name string, semStopProcess *cliutils.Sem, procExitReply chan struct{}) {
@@ -348,6 +390,10 @@ func (ipt *Input) Resume() error {
 }
 
 func (ipt *Input) Terminate() {
+	if SupportControl(ipt.Name) {
+		ipt.parkDaemon()
+	}
+
 	if ipt.semStop != nil {
 		ipt.semStop.Close()
 	}
@@ -221,6 +221,14 @@ func TestInput_queryToBytes(t *testing.T) {
 						Tags:   []string{"GROUP_ID"},
 						Fields: []string{"METRIC_ID, VALUE"},
 					},
+					{
+						SQL:        "SELECT REGION, COUNT(*) CNT, SUM(AMOUNT) AMOUNT FROM ORDERS GROUP BY REGION",
+						Metric:     "oracle_orders",
+						Tags:       []string{"REGION"},
+						Fields:     []string{"CNT", "AMOUNT"},
+						Interval:   5 * time.Minute,
+						FieldTypes: map[string]string{"CNT": "int"},
+					},
 				},
 			},
 		},
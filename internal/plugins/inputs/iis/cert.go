@@ -0,0 +1,263 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package iis
+
+import (
+	"crypto/sha1" //nolint:gosec
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+
+	dkio "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/metrics"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs"
+)
+
+const (
+	metricNameCert = "iis_cert"
+	objectNameCert = "iis_certificate"
+
+	defaultAppHostConfig = `C:\Windows\System32\inetsrv\config\applicationHost.config`
+
+	// certificates rarely change, no need to check them every interval.
+	certCheckInterval = 10 * time.Minute
+	certDialTimeout   = 5 * time.Second
+)
+
+type IISCert struct{}
+
+//nolint:lll
+func (m *IISCert) Info() *inputs.MeasurementInfo {
+	return &inputs.MeasurementInfo{
+		Name: metricNameCert,
+		Type: "metric",
+		Desc: "Certificate of each HTTPS binding of web sites, enabled by `cert_bindings`.",
+		Tags: map[string]interface{}{
+			"host":    &inputs.TagInfo{Desc: "Host name"},
+			"website": &inputs.TagInfo{Desc: "IIS web site"},
+			"binding": &inputs.TagInfo{Desc: "Binding information of the web site, like `*:443:www.example.com`"},
+		},
+		Fields: map[string]interface{}{
+			"expiry_days": &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.UnknownUnit, Desc: "Days remaining before the certificate expires, negative if expired."},
+		},
+	}
+}
+
+type IISCertObject struct{}
+
+//nolint:lll
+func (m *IISCertObject) Info() *inputs.MeasurementInfo {
+	return &inputs.MeasurementInfo{
+		Name: objectNameCert,
+		Type: "custom_object",
+		Desc: "Certificate of each HTTPS binding of web sites, enabled by `cert_bindings`.",
+		Tags: map[string]interface{}{
+			"name":    &inputs.TagInfo{Desc: "Object uniq ID, `<website>/<binding>`"},
+			"host":    &inputs.TagInfo{Desc: "Host name"},
+			"website": &inputs.TagInfo{Desc: "IIS web site"},
+			"binding": &inputs.TagInfo{Desc: "Binding information of the web site, like `*:443:www.example.com`"},
+		},
+		Fields: map[string]interface{}{
+			"thumbprint":  &inputs.FieldInfo{DataType: inputs.String, Type: inputs.UnknownType, Unit: inputs.UnknownUnit, Desc: "SHA-1 thumbprint of the certificate, the same as shown in IIS Manager."},
+			"subject":     &inputs.FieldInfo{DataType: inputs.String, Type: inputs.UnknownType, Unit: inputs.UnknownUnit, Desc: "Subject of the certificate."},
+			"issuer":      &inputs.FieldInfo{DataType: inputs.String, Type: inputs.UnknownType, Unit: inputs.UnknownUnit, Desc: "Issuer of the certificate."},
+			"sans":        &inputs.FieldInfo{DataType: inputs.String, Type: inputs.UnknownType, Unit: inputs.UnknownUnit, Desc: "Subject alternative names of the certificate, separated by comma."},
+			"not_before":  &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.UnknownType, Unit: inputs.TimestampSec, Desc: "Start of the validity period."},
+			"not_after":   &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.UnknownType, Unit: inputs.TimestampSec, Desc: "End of the validity period."},
+			"expiry_days": &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.UnknownUnit, Desc: "Days remaining before the certificate expires, negative if expired."},
+		},
+	}
+}
+
+type httpsBinding struct {
+	website string
+	info    string // bindingInformation, `<ip>:<port>:<host>`
+
+	ip, port, hostname string
+}
+
+// parseHTTPSBindings get HTTPS bindings of web sites from applicationHost.config.
+func parseHTTPSBindings(r io.Reader) ([]*httpsBinding, error) {
+	var conf struct {
+		Sites []struct {
+			Name     string `xml:"name,attr"`
+			Bindings []struct {
+				Protocol string `xml:"protocol,attr"`
+				Info     string `xml:"bindingInformation,attr"`
+			} `xml:"bindings>binding"`
+		} `xml:"system.applicationHost>sites>site"`
+	}
+
+	if err := xml.NewDecoder(r).Decode(&conf); err != nil {
+		return nil, err
+	}
+
+	var res []*httpsBinding
+	for _, site := range conf.Sites {
+		for _, b := range site.Bindings {
+			if !strings.EqualFold(b.Protocol, "https") {
+				continue
+			}
+
+			// IPv6 address in brackets, such as `[::1]:443:`
+			idx := strings.LastIndex(b.Info, ":")
+			if idx < 0 {
+				continue
+			}
+			addr, hostname := b.Info[:idx], b.Info[idx+1:]
+
+			ip, port, err := net.SplitHostPort(addr)
+			if err != nil {
+				continue
+			}
+
+			res = append(res, &httpsBinding{
+				website:  site.Name,
+				info:     b.Info,
+				ip:       ip,
+				port:     port,
+				hostname: hostname,
+			})
+		}
+	}
+
+	return res, nil
+}
+
+// fetchCert handshake with the binding locally to get its certificate,
+// hostname of the binding used as SNI.
+func (b *httpsBinding) fetchCert(timeout time.Duration) (*x509.Certificate, error) {
+	ip := b.ip
+	if ip == "*" || ip == "" {
+		ip = "127.0.0.1"
+	}
+
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: timeout}, "tcp", net.JoinHostPort(ip, b.port),
+		&tls.Config{ServerName: b.hostname, InsecureSkipVerify: true}) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close() //nolint:errcheck
+
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificate")
+	}
+
+	return certs[0], nil
+}
+
+func certSANs(cert *x509.Certificate) []string {
+	sans := append([]string{}, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	sans = append(sans, cert.EmailAddresses...)
+	for _, u := range cert.URIs {
+		sans = append(sans, u.String())
+	}
+	return sans
+}
+
+// certPoints build metric and object of the certificate of the binding.
+func certPoints(b *httpsBinding, cert *x509.Certificate, tags map[string]string, now time.Time) (*point.Point, *point.Point) {
+	expiryDays := cert.NotAfter.Sub(now).Hours() / 24
+	thumbprint := sha1.Sum(cert.Raw) //nolint:gosec
+
+	var kvs point.KVs
+	kvs = kvs.AddTag("website", b.website).
+		AddTag("binding", b.info).
+		Add("expiry_days", expiryDays, false, false)
+	for k, v := range tags {
+		kvs = kvs.AddTag(k, v)
+	}
+	metric := point.NewPointV2(metricNameCert, kvs, append(point.DefaultMetricOptions(), point.WithTime(now))...)
+
+	var objKVs point.KVs
+	objKVs = objKVs.AddTag("name", b.website+"/"+b.info).
+		AddTag("website", b.website).
+		AddTag("binding", b.info).
+		Add("thumbprint", strings.ToUpper(hex.EncodeToString(thumbprint[:])), false, false).
+		Add("subject", cert.Subject.String(), false, false).
+		Add("issuer", cert.Issuer.String(), false, false).
+		Add("sans", strings.Join(certSANs(cert), ","), false, false).
+		Add("not_before", cert.NotBefore.Unix(), false, false).
+		Add("not_after", cert.NotAfter.Unix(), false, false).
+		Add("expiry_days", expiryDays, false, false)
+	for k, v := range tags {
+		objKVs = objKVs.AddTag(k, v)
+	}
+	object := point.NewPointV2(objectNameCert, objKVs, append(point.DefaultObjectOptions(), point.WithTime(now))...)
+
+	return metric, object
+}
+
+func (ipt *Input) collectCerts() {
+	file := ipt.AppHostConfig
+	if file == "" {
+		file = defaultAppHostConfig
+	}
+
+	f, err := os.Open(file) //nolint:gosec
+	if err != nil {
+		l.Errorf("open %s: %s", file, err)
+		ipt.feeder.FeedLastError(err.Error(), metrics.WithLastErrorInput(inputName))
+		return
+	}
+	defer f.Close() //nolint:errcheck
+
+	bindings, err := parseHTTPSBindings(f)
+	if err != nil {
+		l.Errorf("parse %s: %s", file, err)
+		ipt.feeder.FeedLastError(err.Error(), metrics.WithLastErrorInput(inputName))
+		return
+	}
+
+	tags := inputs.MergeTags(ipt.Tagger.HostTags(), ipt.Tags, "")
+	now := time.Now()
+
+	var metricPts, objectPts []*point.Point
+	for _, b := range bindings {
+		cert, err := b.fetchCert(certDialTimeout)
+		if err != nil {
+			l.Warnf("get certificate of binding %q of web site %q: %s", b.info, b.website, err)
+			continue
+		}
+
+		m, o := certPoints(b, cert, tags, now)
+		metricPts = append(metricPts, m)
+		objectPts = append(objectPts, o)
+	}
+
+	if len(metricPts) == 0 {
+		return
+	}
+
+	if err := ipt.feeder.FeedV2(point.Metric, metricPts, dkio.WithInputName(inputName+"/cert")); err != nil {
+		l.Errorf("feed certificate metrics: %s", err)
+		ipt.feeder.FeedLastError(err.Error(),
+			metrics.WithLastErrorInput(inputName),
+			metrics.WithLastErrorCategory(point.Metric),
+		)
+	}
+
+	if err := ipt.feeder.FeedV2(point.CustomObject, objectPts, dkio.WithInputName(inputName+"/cert")); err != nil {
+		l.Errorf("feed certificate objects: %s", err)
+		ipt.feeder.FeedLastError(err.Error(),
+			metrics.WithLastErrorInput(inputName),
+			metrics.WithLastErrorCategory(point.CustomObject),
+		)
+	}
+}
@@ -0,0 +1,83 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package iis
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const appHostConfig = `<?xml version="1.0" encoding="UTF-8"?>
+<configuration>
+  <system.applicationHost>
+    <sites>
+      <site name="Default Web Site" id="1">
+        <bindings>
+          <binding protocol="http" bindingInformation="*:80:" />
+          <binding protocol="https" bindingInformation="*:443:www.example.com" sslFlags="1" />
+        </bindings>
+      </site>
+      <site name="shop" id="2">
+        <bindings>
+          <binding protocol="https" bindingInformation="[::1]:8443:" />
+          <binding protocol="net.tcp" bindingInformation="808:*" />
+        </bindings>
+      </site>
+    </sites>
+  </system.applicationHost>
+</configuration>`
+
+func TestParseHTTPSBindings(t *testing.T) {
+	bindings, err := parseHTTPSBindings(strings.NewReader(appHostConfig))
+	require.NoError(t, err)
+	require.Len(t, bindings, 2)
+
+	assert.Equal(t, &httpsBinding{
+		website:  "Default Web Site",
+		info:     "*:443:www.example.com",
+		ip:       "*",
+		port:     "443",
+		hostname: "www.example.com",
+	}, bindings[0])
+
+	assert.Equal(t, "shop", bindings[1].website)
+	assert.Equal(t, "::1", bindings[1].ip)
+	assert.Equal(t, "8443", bindings[1].port)
+	assert.Equal(t, "", bindings[1].hostname)
+}
+
+func TestCertPoints(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	host, port, err := net.SplitHostPort(srv.Listener.Addr().String())
+	require.NoError(t, err)
+
+	b := &httpsBinding{website: "shop", info: "*:" + port + ":", ip: host, port: port}
+	cert, err := b.fetchCert(time.Second)
+	require.NoError(t, err)
+
+	now := cert.NotAfter.Add(-48 * time.Hour)
+	metric, object := certPoints(b, cert, map[string]string{"host": "HOST"}, now)
+
+	assert.Equal(t, metricNameCert, metric.Name())
+	assert.Equal(t, 2.0, metric.Get("expiry_days"))
+	assert.Equal(t, "shop", metric.Get("website"))
+	assert.Equal(t, "HOST", metric.Get("host"))
+
+	assert.Equal(t, objectNameCert, object.Name())
+	assert.Equal(t, "shop/*:"+port+":", object.Get("name"))
+	assert.Len(t, object.Get("thumbprint"), 40)
+	assert.Contains(t, object.Get("sans"), "example.com")
+	assert.Equal(t, cert.NotAfter.Unix(), object.Get("not_after"))
+}
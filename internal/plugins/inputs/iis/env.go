@@ -0,0 +1,76 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package iis
+
+import (
+	"strings"
+	"time"
+
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/config"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/export/doc"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs"
+)
+
+const (
+	defaultInterval = time.Second * 15
+	minInterval     = time.Second * 5
+	maxInterval     = time.Minute * 10
+)
+
+var _ inputs.ReadEnv = (*Input)(nil)
+
+func (ipt *Input) GetENVDoc() []*inputs.ENVInfo {
+	// nolint:lll
+	infos := []*inputs.ENVInfo{
+		{FieldName: "Interval", Default: "15s"},
+		{FieldName: "LogFiles", ENVName: "LOG_FILES", ConfField: "log.files", Type: doc.List, Example: "`C:/inetpub/logs/LogFiles/W3SVC1/*,C:/inetpub/logs/LogFiles/W3SVC2/*`", Desc: "Absolute paths of IIS log files, glob supported", DescZh: "IIS 日志文件的绝对路径，支持通配符"},
+		{FieldName: "Tags"},
+	}
+
+	return doc.SetENVDoc("ENV_INPUT_IIS_", infos)
+}
+
+// ReadEnv support envs：
+//
+//	ENV_INPUT_IIS_INTERVAL : datakit.Duration
+//	ENV_INPUT_IIS_LOG_FILES : []string
+//	ENV_INPUT_IIS_TAGS : "a=b,c=d"
+func (ipt *Input) ReadEnv(envs map[string]string) {
+	if str, ok := envs["ENV_INPUT_IIS_INTERVAL"]; ok {
+		da, err := time.ParseDuration(str)
+		if err != nil {
+			l.Warnf("parse ENV_INPUT_IIS_INTERVAL to time.Duration: %s, ignore", err)
+		} else {
+			ipt.Interval.Duration = config.ProtectedInterval(minInterval, maxInterval, da)
+		}
+	}
+
+	if str, ok := envs["ENV_INPUT_IIS_LOG_FILES"]; ok {
+		var files []string
+		for _, f := range strings.Split(str, ",") {
+			if f = strings.TrimSpace(f); f != "" {
+				files = append(files, f)
+			}
+		}
+
+		if len(files) > 0 {
+			if ipt.Log == nil {
+				ipt.Log = &iisLog{}
+			}
+			ipt.Log.Files = files
+		}
+	}
+
+	if str, ok := envs["ENV_INPUT_IIS_TAGS"]; ok {
+		tags := config.ParseGlobalTags(str)
+		if ipt.Tags == nil {
+			ipt.Tags = map[string]string{}
+		}
+		for k, v := range tags {
+			ipt.Tags[k] = v
+		}
+	}
+}
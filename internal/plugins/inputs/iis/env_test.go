@@ -0,0 +1,35 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package iis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadEnv(t *testing.T) {
+	ipt := &Input{}
+	ipt.ReadEnv(map[string]string{
+		"ENV_INPUT_IIS_INTERVAL":  "30s",
+		"ENV_INPUT_IIS_LOG_FILES": "C:/inetpub/logs/LogFiles/W3SVC1/*, C:/inetpub/logs/LogFiles/W3SVC2/*",
+		"ENV_INPUT_IIS_TAGS":      "a=b,c=d",
+	})
+
+	assert.Equal(t, 30*time.Second, ipt.Interval.Duration)
+	require.NotNil(t, ipt.Log)
+	assert.Equal(t, []string{"C:/inetpub/logs/LogFiles/W3SVC1/*", "C:/inetpub/logs/LogFiles/W3SVC2/*"}, ipt.Log.Files)
+	assert.Equal(t, map[string]string{"a": "b", "c": "d"}, ipt.Tags)
+
+	// protected interval and invalid values ignored
+	ipt.ReadEnv(map[string]string{"ENV_INPUT_IIS_INTERVAL": "1s"})
+	assert.Equal(t, minInterval, ipt.Interval.Duration)
+
+	ipt.ReadEnv(map[string]string{"ENV_INPUT_IIS_INTERVAL": "abc"})
+	assert.Equal(t, minInterval, ipt.Interval.Duration)
+}
@@ -0,0 +1,339 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package iis
+
+import (
+	"encoding/xml"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/datakit"
+	dkio "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/metrics"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs"
+)
+
+const (
+	eventName = "iis_event"
+
+	eventRecycle   = "app_pool_recycle"
+	eventCrash     = "app_pool_crash"
+	eventRapidFail = "rapid_fail_protection"
+	eventError     = "error"
+	event5xxStorm  = "5xx_storm"
+
+	providerWAS   = "Microsoft-Windows-WAS"
+	providerW3SVC = "Microsoft-Windows-IIS-W3SVC"
+)
+
+type IISEvent struct{}
+
+//nolint:lll
+func (m *IISEvent) Info() *inputs.MeasurementInfo {
+	return &inputs.MeasurementInfo{
+		Name: eventName,
+		Type: "keyevent",
+		Desc: "Key events of app pool recycles and crashes from Windows event log(enabled by `event_log`), and 5xx storms from W3C logs(enabled by `error_storm_threshold` of `[inputs.iis.log]`).",
+		Fields: map[string]interface{}{
+			"df_title":     &inputs.FieldInfo{DataType: inputs.String, Type: inputs.UnknownType, Unit: inputs.UnknownUnit, Desc: "Event title."},
+			"df_message":   &inputs.FieldInfo{DataType: inputs.String, Type: inputs.UnknownType, Unit: inputs.UnknownUnit, Desc: "Event detail."},
+			"df_status":    &inputs.FieldInfo{DataType: inputs.String, Type: inputs.UnknownType, Unit: inputs.UnknownUnit, Desc: "Event status, `info/warning/error/critical`, and `ok` on recovery of 5xx storm."},
+			"event_id":     &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.UnknownType, Unit: inputs.UnknownUnit, Desc: "ID of the Windows event."},
+			"process_id":   &inputs.FieldInfo{DataType: inputs.String, Type: inputs.UnknownType, Unit: inputs.UnknownUnit, Desc: "PID of the worker process, if any."},
+			"requests_5xx": &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.NCount, Desc: "Number of 5xx responses of the web site in the interval."},
+		},
+		Tags: map[string]interface{}{
+			"host":     &inputs.TagInfo{Desc: "Host name"},
+			"event":    &inputs.TagInfo{Desc: "Event type, `app_pool_recycle/app_pool_crash/rapid_fail_protection/error/5xx_storm`."},
+			"app_pool": &inputs.TagInfo{Desc: "IIS app pool, for events from Windows event log"},
+			"website":  &inputs.TagInfo{Desc: "IIS web site of 5xx storm, `s-sitename` of W3C log or the log directory like `W3SVC1`"},
+			"provider": &inputs.TagInfo{Desc: "Provider of the Windows event, `Microsoft-Windows-WAS/Microsoft-Windows-IIS-W3SVC`"},
+		},
+	}
+}
+
+type wasEvent struct {
+	event, status, title string
+}
+
+// wasEvents are known events of WAS, other events logged at error level of
+// WAS and W3SVC reported as eventError.
+var wasEvents = map[int]wasEvent{
+	5002: {eventRapidFail, "critical", "IIS app pool disabled by rapid-fail protection"},
+
+	5009: {eventCrash, "error", "IIS worker process terminated unexpectedly"},
+	5010: {eventCrash, "error", "IIS worker process failed to respond to ping"},
+	5011: {eventCrash, "error", "IIS worker process suffered a fatal communication error"},
+	5013: {eventCrash, "error", "IIS worker process exceeded time limits during shutdown"},
+
+	5074: {eventRecycle, "info", "IIS app pool recycled for processing time limit"},
+	5075: {eventRecycle, "info", "IIS app pool recycled for scheduled time"},
+	5076: {eventRecycle, "info", "IIS app pool recycled for request limit"},
+	5077: {eventRecycle, "warning", "IIS app pool recycled for virtual memory limit"},
+	5079: {eventRecycle, "info", "IIS app pool recycled on demand"},
+	5080: {eventRecycle, "info", "IIS app pool recycled for configuration change"},
+	5081: {eventRecycle, "warning", "IIS app pool recycled for unhealthy ISAPI"},
+	5117: {eventRecycle, "warning", "IIS app pool recycled for private memory limit"},
+}
+
+// winEvent is a Windows event rendered in XML.
+type winEvent struct {
+	System struct {
+		Provider struct {
+			Name string `xml:"Name,attr"`
+		} `xml:"Provider"`
+		EventID     int    `xml:"EventID"`
+		Level       int    `xml:"Level"`
+		RecordID    uint64 `xml:"EventRecordID"`
+		TimeCreated struct {
+			SystemTime string `xml:"SystemTime,attr"`
+		} `xml:"TimeCreated"`
+	} `xml:"System"`
+	EventData struct {
+		Data []struct {
+			Name  string `xml:"Name,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"Data"`
+	} `xml:"EventData"`
+}
+
+func parseWinEvent(data []byte) (*winEvent, error) {
+	var e winEvent
+	if err := xml.Unmarshal(data, &e); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (e *winEvent) data(name string) string {
+	for _, d := range e.EventData.Data {
+		if d.Name == name {
+			return d.Value
+		}
+	}
+	return ""
+}
+
+// eventQuery build XPath query of WAS and W3SVC events, since the record
+// ID, or within the duration if no record read yet.
+func eventQuery(lastRecordID uint64, within time.Duration) string {
+	cond := fmt.Sprintf("TimeCreated[timediff(@SystemTime) <= %d]", within.Milliseconds())
+	if lastRecordID > 0 {
+		cond = fmt.Sprintf("EventRecordID > %d", lastRecordID)
+	}
+
+	return fmt.Sprintf("*[System[Provider[@Name='%s' or @Name='%s'] and %s]]", providerWAS, providerW3SVC, cond)
+}
+
+// levelStatus map level of Windows event to status.
+func levelStatus(level int) string {
+	switch level {
+	case 1:
+		return "critical"
+	case 2:
+		return "error"
+	case 3:
+		return "warning"
+	default:
+		return "info"
+	}
+}
+
+// newWinEventPoint build key event of the Windows event, nil returned if
+// it's not an event we care.
+func newWinEventPoint(e *winEvent, tags map[string]string) *point.Point {
+	we, ok := wasEvents[e.System.EventID]
+	if !ok {
+		if e.System.Level == 0 || e.System.Level > 2 {
+			return nil
+		}
+		we = wasEvent{eventError, levelStatus(e.System.Level), "IIS error event"}
+	}
+
+	appPool := e.data("AppPoolID")
+
+	var values []string
+	for _, d := range e.EventData.Data {
+		if d.Value != "" {
+			values = append(values, d.Name+"="+d.Value)
+		}
+	}
+
+	title := we.title
+	if appPool != "" {
+		title = fmt.Sprintf("%s: %s", we.title, appPool)
+	}
+
+	var kvs point.KVs
+	kvs = kvs.AddTag("event", we.event).
+		AddTag("provider", e.System.Provider.Name).
+		Add("df_title", title, false, false).
+		Add("df_message", fmt.Sprintf("event %d of %s: %s",
+			e.System.EventID, e.System.Provider.Name, strings.Join(values, ", ")), false, false).
+		Add("df_status", we.status, false, false).
+		Add("event_id", int64(e.System.EventID), false, false)
+
+	if appPool != "" {
+		kvs = kvs.AddTag("app_pool", appPool)
+	}
+
+	if pid := e.data("ProcessID"); pid != "" {
+		kvs = kvs.Add("process_id", pid, false, false)
+	}
+
+	for k, v := range tags {
+		kvs = kvs.AddTag(k, v)
+	}
+
+	opts := point.DefaultLoggingOptions()
+	if t, err := time.Parse(time.RFC3339Nano, e.System.TimeCreated.SystemTime); err == nil {
+		opts = append(opts, point.WithTime(t))
+	}
+
+	return point.NewPointV2(eventName, kvs, opts...)
+}
+
+// stormDetector count 5xx responses of each web site, and report event
+// when number of them in an interval reaches or falls below the threshold.
+type stormDetector struct {
+	threshold int64
+
+	mu       sync.Mutex
+	counts   map[string]int64
+	storming map[string]bool
+}
+
+func newStormDetector(threshold int64) *stormDetector {
+	return &stormDetector{
+		threshold: threshold,
+		counts:    map[string]int64{},
+		storming:  map[string]bool{},
+	}
+}
+
+// websiteOf get web site of the log, by `s-sitename` or the log directory
+// such as `W3SVC1`.
+func websiteOf(file string, fields map[string]interface{}) string {
+	if site, ok := fields["site_name"].(string); ok && site != "" {
+		return site
+	}
+	return path.Base(path.Dir(strings.ReplaceAll(file, `\`, "/")))
+}
+
+func (d *stormDetector) add(website string, statusCode int64) {
+	if d == nil || statusCode < 500 || statusCode >= 600 {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.counts[website]++
+}
+
+// flush build events of web sites whose storm state changed, and reset
+// counts of the interval.
+func (d *stormDetector) flush(interval time.Duration, tags map[string]string, ptTS int64) []*point.Point {
+	d.mu.Lock()
+	counts := d.counts
+	d.counts = map[string]int64{}
+
+	sites := make([]string, 0, len(counts)+len(d.storming))
+	for site := range counts {
+		sites = append(sites, site)
+	}
+	for site := range d.storming {
+		if _, ok := counts[site]; !ok {
+			sites = append(sites, site)
+		}
+	}
+	sort.Strings(sites)
+
+	var pts []*point.Point
+	for _, site := range sites {
+		n := counts[site]
+		storming := n >= d.threshold
+		if storming == d.storming[site] {
+			continue
+		}
+
+		if storming {
+			d.storming[site] = true
+		} else {
+			delete(d.storming, site)
+		}
+
+		status, title := "error", "IIS 5xx storm: "+site
+		if !storming {
+			status, title = "ok", "[Recovered] "+title
+		}
+
+		var kvs point.KVs
+		kvs = kvs.AddTag("event", event5xxStorm).
+			AddTag("website", site).
+			Add("df_title", title, false, false).
+			Add("df_message", fmt.Sprintf("%d 5xx responses of web site %s in %s, threshold %d",
+				n, site, interval, d.threshold), false, false).
+			Add("df_status", status, false, false).
+			Add("requests_5xx", n, false, false)
+
+		for k, v := range tags {
+			kvs = kvs.AddTag(k, v)
+		}
+
+		pts = append(pts, point.NewPointV2(eventName, kvs,
+			append(point.DefaultLoggingOptions(), point.WithTimestamp(ptTS))...))
+	}
+	d.mu.Unlock()
+
+	return pts
+}
+
+func (ipt *Input) feedEvents(pts []*point.Point) {
+	if len(pts) == 0 {
+		return
+	}
+
+	if err := ipt.feeder.FeedV2(point.KeyEvent, pts, dkio.WithInputName(inputName+"/event")); err != nil {
+		l.Errorf("feed events: %s", err)
+		ipt.feeder.FeedLastError(err.Error(),
+			metrics.WithLastErrorInput(inputName),
+			metrics.WithLastErrorCategory(point.KeyEvent),
+		)
+	}
+}
+
+func (ipt *Input) runStormDetector() {
+	interval := ipt.Interval.Duration
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
+	tags := inputs.MergeTags(ipt.Tagger.HostTags(), ipt.Tags, "")
+
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+
+	start := time.Now()
+	for {
+		select {
+		case <-datakit.Exit.Wait():
+			return
+
+		case <-ipt.semStop.Wait():
+			return
+
+		case tt := <-tick.C:
+			start = time.UnixMilli(inputs.AlignTimeMillSec(tt, start.UnixMilli(), interval.Milliseconds()))
+			ipt.feedEvents(ipt.storm.flush(interval, tags, start.UnixNano()))
+		}
+	}
+}
@@ -0,0 +1,94 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package iis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const rapidFailEvent = `<Event xmlns="http://schemas.microsoft.com/win/2004/08/events/event">
+<System>
+  <Provider Name="Microsoft-Windows-WAS" Guid="{524B5D04-133C-4A62-8362-64E8EDB9CE40}" EventSourceName="WAS"/>
+  <EventID Qualifiers="49152">5002</EventID>
+  <Level>1</Level>
+  <TimeCreated SystemTime="2024-01-01T08:00:01.123456700Z"/>
+  <EventRecordID>4321</EventRecordID>
+</System>
+<EventData>
+  <Data Name="AppPoolID">DefaultAppPool</Data>
+</EventData>
+</Event>`
+
+func TestWinEvent(t *testing.T) {
+	e, err := parseWinEvent([]byte(rapidFailEvent))
+	require.NoError(t, err)
+	assert.Equal(t, providerWAS, e.System.Provider.Name)
+	assert.Equal(t, 5002, e.System.EventID)
+	assert.Equal(t, uint64(4321), e.System.RecordID)
+
+	pt := newWinEventPoint(e, map[string]string{"host": "HOST"})
+	require.NotNil(t, pt)
+	assert.Equal(t, eventRapidFail, pt.Get("event"))
+	assert.Equal(t, "DefaultAppPool", pt.Get("app_pool"))
+	assert.Equal(t, "critical", pt.Get("df_status"))
+	assert.Equal(t, "HOST", pt.Get("host"))
+	assert.Equal(t, int64(5002), pt.Get("event_id"))
+	assert.Equal(t, time.Date(2024, 1, 1, 8, 0, 1, 123456700, time.UTC).UnixNano(), pt.Time().UnixNano())
+
+	// unknown events below error level ignored
+	e.System.EventID, e.System.Level = 5186, 4
+	assert.Nil(t, newWinEventPoint(e, nil))
+
+	e.System.Level = 2
+	pt = newWinEventPoint(e, nil)
+	require.NotNil(t, pt)
+	assert.Equal(t, eventError, pt.Get("event"))
+	assert.Equal(t, "error", pt.Get("df_status"))
+}
+
+func TestEventQuery(t *testing.T) {
+	assert.Equal(t,
+		"*[System[Provider[@Name='Microsoft-Windows-WAS' or @Name='Microsoft-Windows-IIS-W3SVC'] and TimeCreated[timediff(@SystemTime) <= 15000]]]",
+		eventQuery(0, 15*time.Second))
+	assert.Equal(t,
+		"*[System[Provider[@Name='Microsoft-Windows-WAS' or @Name='Microsoft-Windows-IIS-W3SVC'] and EventRecordID > 10]]",
+		eventQuery(10, 15*time.Second))
+}
+
+func TestStormDetector(t *testing.T) {
+	d := newStormDetector(2)
+
+	assert.Equal(t, "W3SVC1", websiteOf(`C:\inetpub\logs\LogFiles\W3SVC1\u_ex240101.log`, nil))
+	assert.Equal(t, "shop", websiteOf("any.log", map[string]interface{}{"site_name": "shop"}))
+
+	d.add("W3SVC1", 500)
+	d.add("W3SVC1", 503)
+	d.add("W3SVC1", 404)
+	d.add("W3SVC2", 502)
+
+	pts := d.flush(time.Minute, map[string]string{"host": "HOST"}, 0)
+	require.Len(t, pts, 1)
+	assert.Equal(t, "W3SVC1", pts[0].Get("website"))
+	assert.Equal(t, "error", pts[0].Get("df_status"))
+	assert.Equal(t, int64(2), pts[0].Get("requests_5xx"))
+
+	// still storming, no event
+	d.add("W3SVC1", 500)
+	d.add("W3SVC1", 500)
+	assert.Len(t, d.flush(time.Minute, nil, 0), 0)
+
+	// recovered
+	pts = d.flush(time.Minute, nil, 0)
+	require.Len(t, pts, 1)
+	assert.Equal(t, "ok", pts[0].Get("df_status"))
+	assert.Equal(t, int64(0), pts[0].Get("requests_5xx"))
+
+	assert.Len(t, d.flush(time.Minute, nil, 0), 0)
+}
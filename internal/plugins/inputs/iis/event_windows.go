@@ -0,0 +1,157 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+//go:build windows && amd64
+// +build windows,amd64
+
+package iis
+
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"golang.org/x/sys/windows"
+
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/metrics"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs"
+)
+
+const (
+	evtQueryChannelPath      = 0x1
+	evtQueryForwardDirection = 0x100
+	evtRenderEventXML        = 1
+
+	eventBatchSize = 64
+	eventChannel   = "System" // WAS and W3SVC log to the System channel
+)
+
+var (
+	modwevtapi = windows.NewLazySystemDLL("wevtapi.dll")
+
+	procEvtQuery  = modwevtapi.NewProc("EvtQuery")
+	procEvtNext   = modwevtapi.NewProc("EvtNext")
+	procEvtRender = modwevtapi.NewProc("EvtRender")
+	procEvtClose  = modwevtapi.NewProc("EvtClose")
+)
+
+// eventWatcher poll new events of WAS and W3SVC from Windows event log.
+type eventWatcher struct {
+	lastRecordID uint64
+}
+
+func evtClose(h uintptr) {
+	procEvtClose.Call(h) //nolint:errcheck
+}
+
+func evtQuery(channel, query string) (uintptr, error) {
+	c, err := windows.UTF16PtrFromString(channel)
+	if err != nil {
+		return 0, err
+	}
+	q, err := windows.UTF16PtrFromString(query)
+	if err != nil {
+		return 0, err
+	}
+
+	h, _, err := procEvtQuery.Call(0,
+		uintptr(unsafe.Pointer(c)),
+		uintptr(unsafe.Pointer(q)),
+		evtQueryChannelPath|evtQueryForwardDirection)
+	if h == 0 {
+		return 0, fmt.Errorf("EvtQuery: %w", err)
+	}
+	return h, nil
+}
+
+// evtRender render the event in XML.
+func evtRender(h uintptr) ([]byte, error) {
+	buf := make([]uint16, 4096)
+	for {
+		var used, count uint32
+		r, _, err := procEvtRender.Call(0, h, evtRenderEventXML,
+			uintptr(len(buf)*2),
+			uintptr(unsafe.Pointer(&buf[0])),
+			uintptr(unsafe.Pointer(&used)),
+			uintptr(unsafe.Pointer(&count)))
+		if r != 0 {
+			return []byte(windows.UTF16ToString(buf)), nil
+		}
+
+		if errors.Is(err, windows.ERROR_INSUFFICIENT_BUFFER) {
+			buf = make([]uint16, used/2+1)
+			continue
+		}
+		return nil, fmt.Errorf("EvtRender: %w", err)
+	}
+}
+
+// poll read events after the last one, or events within the interval on
+// the first poll.
+func (w *eventWatcher) poll(ipt *Input) ([]*winEvent, error) {
+	query, err := evtQuery(eventChannel, eventQuery(w.lastRecordID, ipt.Interval.Duration))
+	if err != nil {
+		return nil, err
+	}
+	defer evtClose(query)
+
+	var events []*winEvent
+	handles := make([]uintptr, eventBatchSize)
+	for {
+		var returned uint32
+		r, _, err := procEvtNext.Call(query, eventBatchSize,
+			uintptr(unsafe.Pointer(&handles[0])), 0, 0,
+			uintptr(unsafe.Pointer(&returned)))
+		if r == 0 {
+			if errors.Is(err, windows.ERROR_NO_MORE_ITEMS) {
+				return events, nil
+			}
+			return events, fmt.Errorf("EvtNext: %w", err)
+		}
+
+		for _, h := range handles[:returned] {
+			data, err := evtRender(h)
+			evtClose(h)
+			if err != nil {
+				l.Warnf("render event: %s, ignored", err)
+				continue
+			}
+
+			e, err := parseWinEvent(data)
+			if err != nil {
+				l.Warnf("parse event: %s, ignored", err)
+				continue
+			}
+
+			if e.System.RecordID > w.lastRecordID {
+				w.lastRecordID = e.System.RecordID
+			}
+			events = append(events, e)
+		}
+	}
+}
+
+func (ipt *Input) collectEvents() {
+	events, err := ipt.events.poll(ipt)
+	if err != nil {
+		l.Errorf("poll IIS events: %s", err)
+		ipt.feeder.FeedLastError(err.Error(),
+			metrics.WithLastErrorInput(inputName),
+			metrics.WithLastErrorCategory(point.KeyEvent),
+		)
+	}
+
+	tags := inputs.MergeTags(ipt.Tagger.HostTags(), ipt.Tags, "")
+
+	var pts []*point.Point
+	for _, e := range events {
+		if pt := newWinEventPoint(e, tags); pt != nil {
+			pts = append(pts, pt)
+		}
+	}
+
+	ipt.feedEvents(pts)
+}
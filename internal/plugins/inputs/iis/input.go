@@ -9,7 +9,6 @@
 package iis
 
 import (
-	"context"
 	"fmt"
 	"time"
 
@@ -20,7 +19,6 @@ import (
 
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/config"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/datakit"
-	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/goroutine"
 	dkio "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/metrics"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs"
@@ -28,17 +26,15 @@ import (
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/win_utils/pdh"
 )
 
-const (
-	minInterval = time.Second * 5
-	maxInterval = time.Minute * 10
-)
-
 var (
-	inputName                           = "iis"
-	metricNameWebService                = "iis_web_service"
-	metricNameAppPoolWas                = "iis_app_pool_was"
-	l                                   = logger.DefaultSLogger("iis")
-	_                    inputs.InputV2 = (*Input)(nil)
+	inputName                              = "iis"
+	metricNameWebService                   = "iis_web_service"
+	metricNameAppPoolWas                   = "iis_app_pool_was"
+	metricNameRequestQueue                 = "iis_http_request_queue"
+	metricNameWorkerProcess                = "iis_worker_process"
+	metricNameAspNetApp                    = "iis_aspnet_application"
+	l                                      = logger.DefaultSLogger("iis")
+	_                       inputs.InputV2 = (*Input)(nil)
 )
 
 type Input struct {
@@ -46,9 +42,22 @@ type Input struct {
 
 	Tags map[string]string
 
+	Websites []string `toml:"websites"`
+	AppPools []string `toml:"app_pools"`
+	EventLog bool     `toml:"event_log"`
+
+	CertBindings  bool   `toml:"cert_bindings"`
+	AppHostConfig string `toml:"application_host_config"`
+
 	Log  *iisLog `toml:"log"`
 	tail *tailer.Tailer
 
+	filter *instanceFilter
+	events *eventWatcher
+	storm  *stormDetector
+
+	workers *workerRecorder
+
 	collectCache []*point.Point
 
 	semStop *cliutils.Sem // start stop signal
@@ -56,11 +65,6 @@ type Input struct {
 	Tagger  datakit.GlobalTagger
 }
 
-type iisLog struct {
-	Files    []string `toml:"files"`
-	Pipeline string   `toml:"pipeline"`
-}
-
 func (*Input) Catalog() string { return "iis" }
 
 func (*Input) SampleConfig() string { return sampleConfig }
@@ -69,37 +73,15 @@ func (*Input) SampleMeasurement() []inputs.Measurement {
 	return []inputs.Measurement{
 		&IISAppPoolWas{},
 		&IISWebService{},
+		&IISHTTPRequestQueue{},
+		&IISWorkerProcess{},
+		&IISAspNetApplication{},
+		&IISCert{},
+		&IISCertObject{},
+		&IISEvent{},
 	}
 }
 
-// RunPipeline TODO.
-func (ipt *Input) RunPipeline() {
-	if ipt.Log == nil || len(ipt.Log.Files) == 0 {
-		return
-	}
-
-	opts := []tailer.Option{
-		tailer.WithSource("iis"),
-		tailer.WithService("iis"),
-		tailer.WithPipeline(ipt.Log.Pipeline),
-		tailer.WithGlobalTags(inputs.MergeTags(ipt.Tagger.HostTags(), ipt.Tags, "")),
-		tailer.EnableDebugFields(config.Cfg.EnableDebugFields),
-	}
-
-	var err error
-	if ipt.tail, err = tailer.NewTailer(ipt.Log.Files, opts...); err != nil {
-		l.Error(err)
-		metrics.FeedLastError(inputName, err.Error())
-		return
-	}
-
-	g := goroutine.NewGroup(goroutine.Option{Name: "inputs_iis"})
-	g.Go(func(ctx context.Context) error {
-		ipt.tail.Start()
-		return nil
-	})
-}
-
 func (*Input) PipelineConfig() map[string]string {
 	pipelineConfig := map[string]string{
 		inputName: pipelineCfg,
@@ -128,8 +110,21 @@ func (ipt *Input) Run() {
 	l.Infof("iis input started")
 
 	ipt.Interval.Duration = config.ProtectedInterval(minInterval, maxInterval, ipt.Interval.Duration)
+
+	if f, err := newInstanceFilter(ipt.Websites, ipt.AppPools); err != nil {
+		l.Errorf("newInstanceFilter: %s, collect all web sites and app pools", err)
+		metrics.FeedLastError(inputName, err.Error())
+	} else {
+		ipt.filter = f
+	}
+
+	if ipt.EventLog {
+		ipt.events = &eventWatcher{}
+	}
+
 	tick := time.NewTicker(ipt.Interval.Duration)
 	start := time.Now()
+	var lastCertCheck time.Time
 
 	defer tick.Stop()
 	for {
@@ -150,6 +145,15 @@ func (ipt *Input) Run() {
 				metrics.FeedLastError(inputName, err.Error())
 			}
 			ipt.collectCache = make([]*point.Point, 0)
+
+			if ipt.events != nil {
+				ipt.collectEvents()
+			}
+
+			if ipt.CertBindings && time.Since(lastCertCheck) >= certCheckInterval {
+				ipt.collectCerts()
+				lastCertCheck = time.Now()
+			}
 		case <-datakit.Exit.Wait():
 			ipt.exit()
 			l.Infof("iis input exit")
@@ -177,6 +181,14 @@ func (ipt *Input) Terminate() {
 }
 
 func (ipt *Input) Collect(ptTS int64) error {
+	if ipt.workers == nil {
+		ipt.workers = newWorkerRecorder()
+	}
+
+	now := time.Now()
+	alive := map[int32]bool{}
+	defer ipt.workers.prune(alive)
+
 	for mName, metricCounterMap := range PerfObjMetricMap {
 		for objName := range metricCounterMap {
 			// measurement name -> instance name -> metric name -> counter query handle list index
@@ -185,13 +197,31 @@ func (ipt *Input) Collect(ptTS int64) error {
 			// counter name is localized and cannot be used
 			instanceList, _, ret := pdh.PdhEnumObjectItems(objName)
 			if ret != uint32(windows.ERROR_SUCCESS) {
+				if optionalObjects[objName] {
+					l.Debugf("object %s not found, skipped", objName)
+					continue
+				}
 				return fmt.Errorf("failed to enumerate the instance and counter of object %s", objName)
 			}
 
+			// instance name -> tags of the instance
+			instanceTagsMap := map[string]map[string]string{}
+
 			pathList := make([]string, 0)
 			pathListIndex := 0
 			// instance
 			for i := 0; i < len(instanceList); i++ {
+				itags, err := instanceTags(objName, instanceList[i])
+				if err != nil {
+					return fmt.Errorf("measurement name: %s: %w", mName, err)
+				}
+
+				if itags == nil || !ipt.filter.match(itags) {
+					l.Debugf("instance %q of %s not selected, skipped", instanceList[i], objName)
+					continue
+				}
+				instanceTagsMap[instanceList[i]] = itags
+
 				indexMap[mName][instanceList[i]] = map[string]int{}
 				for keyCounter := range metricCounterMap[objName] {
 					if metricName, ok := metricCounterMap[objName][keyCounter]; ok {
@@ -205,6 +235,9 @@ func (ipt *Input) Collect(ptTS int64) error {
 				}
 			}
 			if len(pathList) < 1 {
+				if len(instanceList) > 0 {
+					continue // all instances filtered out
+				}
 				return fmt.Errorf("obj %s no valid counter ", objName)
 			}
 			var handle pdh.PDH_HQUERY
@@ -261,13 +294,17 @@ func (ipt *Input) Collect(ptTS int64) error {
 				for metricName := range indexMap[mName][instanceName] {
 					fields[metricName] = valueList[indexMap[mName][instanceName][metricName]]
 				}
-				switch objName {
-				case "Web Service":
-					tags["website"] = instanceName
-				case "APP_POOL_WAS":
-					tags["app_pool"] = instanceName
-				default:
-					return fmt.Errorf("action not defined, obj name: %s  measurement name: %s", objName, mName)
+				for k, v := range instanceTagsMap[instanceName] {
+					tags[k] = v
+				}
+
+				if objName == objWorkerProcess {
+					if pid, _, ok := parseWorkerInstance(instanceName); ok {
+						alive[pid] = true
+						for k, v := range ipt.workers.fields(pid, now) {
+							fields[k] = v
+						}
+					}
 				}
 
 				opts := point.DefaultMetricOptions()
@@ -289,7 +326,7 @@ func (ipt *Input) Collect(ptTS int64) error {
 func init() { // nolint:gochecknoinits
 	inputs.Add(inputName, func() inputs.Input {
 		return &Input{
-			Interval: datakit.Duration{Duration: time.Second * 15},
+			Interval: datakit.Duration{Duration: defaultInterval},
 
 			semStop: cliutils.NewSem(),
 			feeder:  dkio.DefaultFeeder(),
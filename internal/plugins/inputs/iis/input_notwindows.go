@@ -9,14 +9,23 @@
 package iis
 
 import (
+	"github.com/GuanceCloud/cliutils"
+	"github.com/GuanceCloud/cliutils/logger"
+
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/datakit"
+	dkio "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/tailer"
 )
 
 var (
-	inputName            = "iis"
-	metricNameWebService = "iis_web_service"
-	metricNameAppPoolWas = "iis_app_pool_was"
+	inputName               = "iis"
+	metricNameWebService    = "iis_web_service"
+	metricNameAppPoolWas    = "iis_app_pool_was"
+	metricNameRequestQueue  = "iis_http_request_queue"
+	metricNameWorkerProcess = "iis_worker_process"
+	metricNameAspNetApp     = "iis_aspnet_application"
+	l                       = logger.DefaultSLogger("iis")
 
 	_ inputs.InputV2 = (*Input)(nil)
 )
@@ -27,30 +36,40 @@ type Input struct {
 
 	Tags map[string]string
 
-	Log *iisLog `toml:"log"`
-}
+	Websites []string `toml:"websites"`
+	AppPools []string `toml:"app_pools"`
+	EventLog bool     `toml:"event_log"`
+
+	CertBindings  bool   `toml:"cert_bindings"`
+	AppHostConfig string `toml:"application_host_config"`
+
+	Log  *iisLog `toml:"log"`
+	tail *tailer.Tailer
+
+	storm *stormDetector
 
-type iisLog struct {
-	Files    []string `toml:"files"`
-	Pipeline string   `toml:"pipeline"`
+	semStop *cliutils.Sem
+	feeder  dkio.Feeder
+	Tagger  datakit.GlobalTagger
 }
 
 func (ipt *Input) SampleConfig() string {
 	return sampleConfig
 }
 
-func (*Input) Terminate() {
-	// Do nothing
+func (ipt *Input) Terminate() {
+	if ipt.semStop != nil {
+		ipt.semStop.Close()
+	}
+	if ipt.tail != nil {
+		ipt.tail.Close()
+	}
 }
 
 func (ipt *Input) Catalog() string {
 	return "iis"
 }
 
-func (*Input) RunPipeline() {
-	// TODO
-}
-
 func (ipt *Input) AvailableArchs() []string {
 	return []string{datakit.OSLabelWindows}
 }
@@ -59,6 +78,12 @@ func (ipt *Input) SampleMeasurement() []inputs.Measurement {
 	return []inputs.Measurement{
 		&IISAppPoolWas{},
 		&IISWebService{},
+		&IISHTTPRequestQueue{},
+		&IISWorkerProcess{},
+		&IISAspNetApplication{},
+		&IISCert{},
+		&IISCertObject{},
+		&IISEvent{},
 	}
 }
 
@@ -73,6 +98,12 @@ func (ipt *Input) Run() {}
 
 func init() { //nolint:gochecknoinits
 	inputs.Add(inputName, func() inputs.Input {
-		return &Input{}
+		return &Input{
+			Interval: datakit.Duration{Duration: defaultInterval},
+
+			semStop: cliutils.NewSem(),
+			feeder:  dkio.DefaultFeeder(),
+			Tagger:  datakit.DefaultGlobalTagger(),
+		}
 	})
 }
@@ -0,0 +1,139 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package iis
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gobwas/glob"
+)
+
+const (
+	objWebService = "Web Service"
+	objAppPoolWas = "APP_POOL_WAS"
+
+	// instances of HTTP.SYS request queues are named by app pools.
+	objRequestQueue = "HTTP Service Request Queues"
+
+	// instances are ASP.NET applications, like `_LM_W3SVC_1_ROOT`, absent if
+	// ASP.NET is not installed.
+	objAspNetApps = "ASP.NET Applications"
+)
+
+// optionalObjects are perf objects that may be absent, skipped instead of
+// failing the collection.
+var optionalObjects = map[string]bool{
+	objAspNetApps: true,
+}
+
+// instanceTags returns tags of the instance of the perf object: each
+// instance of `Web Service` is a web site, each instance of `APP_POOL_WAS` or
+// `HTTP Service Request Queues` is an application pool, and each instance of
+// `W3SVC_W3WP` is a worker process of an application pool, and each instance
+// of `ASP.NET Applications` is an ASP.NET application. Nil returned if the
+// instance should be skipped.
+func instanceTags(objName, instance string) (map[string]string, error) {
+	switch objName {
+	case objWebService:
+		return map[string]string{"website": instance}, nil
+	case objAppPoolWas, objRequestQueue:
+		return map[string]string{"app_pool": instance}, nil
+	case objWorkerProcess:
+		pid, appPool, ok := parseWorkerInstance(instance)
+		if !ok {
+			return nil, nil // such as `_Total`
+		}
+		return map[string]string{"app_pool": appPool, "pid": fmt.Sprintf("%d", pid)}, nil
+	case objAspNetApps:
+		tags := map[string]string{"aspnet_app": instance}
+		if id := aspNetSiteID(instance); id != "" {
+			tags["site_id"] = id
+		}
+		return tags, nil
+	default:
+		return nil, fmt.Errorf("unknown perf object %q", objName)
+	}
+}
+
+// instanceFilter select web sites and app pools to collect by glob patterns,
+// all instances selected if no pattern configured.
+type instanceFilter struct {
+	websites []glob.Glob
+	appPools []glob.Glob
+}
+
+func newInstanceFilter(websites, appPools []string) (*instanceFilter, error) {
+	compile := func(patterns []string) ([]glob.Glob, error) {
+		var res []glob.Glob
+		for _, p := range patterns {
+			g, err := glob.Compile(p)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %w", p, err)
+			}
+			res = append(res, g)
+		}
+		return res, nil
+	}
+
+	f := &instanceFilter{}
+
+	var err error
+	if f.websites, err = compile(websites); err != nil {
+		return nil, err
+	}
+	if f.appPools, err = compile(appPools); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// match check web site or app pool in tags of the instance.
+func (f *instanceFilter) match(tags map[string]string) bool {
+	if f == nil {
+		return true
+	}
+
+	return matchAny(f.websites, tags["website"]) && matchAny(f.appPools, tags["app_pool"])
+}
+
+// matchAny returns true if no pattern or any pattern matched, for values
+// that not in tags of the instance, such as web site of app pools, patterns
+// are ignored.
+func matchAny(patterns []glob.Glob, value string) bool {
+	if len(patterns) == 0 || value == "" {
+		return true
+	}
+
+	for _, g := range patterns {
+		if g.Match(value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// aspNetSiteID get ID of the web site from the ASP.NET application, such as
+// `1` of `_LM_W3SVC_1_ROOT_shop`.
+func aspNetSiteID(app string) string {
+	const prefix = "_LM_W3SVC_"
+	if !strings.HasPrefix(app, prefix) {
+		return "" // such as `__Total__`
+	}
+
+	id := strings.TrimPrefix(app, prefix)
+	if idx := strings.Index(id, "_"); idx > 0 {
+		id = id[:idx]
+	}
+
+	if _, err := strconv.ParseUint(id, 10, 64); err != nil {
+		return ""
+	}
+	return id
+}
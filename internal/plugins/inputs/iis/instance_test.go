@@ -0,0 +1,69 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package iis
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstanceTags(t *testing.T) {
+	cases := []struct {
+		obj, instance string
+		tags          map[string]string
+	}{
+		{objWebService, "Default Web Site", map[string]string{"website": "Default Web Site"}},
+		{objAppPoolWas, "DefaultAppPool", map[string]string{"app_pool": "DefaultAppPool"}},
+		{objRequestQueue, "DefaultAppPool", map[string]string{"app_pool": "DefaultAppPool"}},
+		{objWorkerProcess, "1234_Shop_Pool", map[string]string{"app_pool": "Shop_Pool", "pid": "1234"}},
+		{objWorkerProcess, "_Total", nil},
+		{objAspNetApps, "_LM_W3SVC_1_ROOT", map[string]string{"aspnet_app": "_LM_W3SVC_1_ROOT", "site_id": "1"}},
+		{objAspNetApps, "_LM_W3SVC_12_ROOT_shop", map[string]string{"aspnet_app": "_LM_W3SVC_12_ROOT_shop", "site_id": "12"}},
+		{objAspNetApps, "__Total__", map[string]string{"aspnet_app": "__Total__"}},
+	}
+
+	for _, tc := range cases {
+		tags, err := instanceTags(tc.obj, tc.instance)
+		require.NoError(t, err)
+		assert.Equal(t, tc.tags, tags, tc.instance)
+	}
+
+	_, err := instanceTags("Processor", "0")
+	assert.Error(t, err)
+}
+
+func TestInstanceFilter(t *testing.T) {
+	t.Run("no-pattern", func(t *testing.T) {
+		f, err := newInstanceFilter(nil, nil)
+		require.NoError(t, err)
+		assert.True(t, f.match(map[string]string{"website": "Default Web Site"}))
+		assert.True(t, f.match(map[string]string{"app_pool": "_Total"}))
+
+		var nilFilter *instanceFilter
+		assert.True(t, nilFilter.match(map[string]string{"website": "any"}))
+	})
+
+	t.Run("patterns", func(t *testing.T) {
+		f, err := newInstanceFilter([]string{"shop-*", "Default Web Site"}, []string{"DefaultAppPool"})
+		require.NoError(t, err)
+
+		assert.True(t, f.match(map[string]string{"website": "shop-cn"}))
+		assert.True(t, f.match(map[string]string{"website": "Default Web Site"}))
+		assert.False(t, f.match(map[string]string{"website": "_Total"}))
+
+		assert.True(t, f.match(map[string]string{"app_pool": "DefaultAppPool"}))
+		assert.False(t, f.match(map[string]string{"app_pool": "shop-cn"}))
+		assert.True(t, f.match(map[string]string{"app_pool": "DefaultAppPool", "pid": "1234"}))
+		assert.False(t, f.match(map[string]string{"app_pool": "_Total"}))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := newInstanceFilter([]string{"[a-"}, nil)
+		assert.Error(t, err)
+	})
+}
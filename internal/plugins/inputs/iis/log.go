@@ -0,0 +1,281 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package iis
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/config"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/goroutine"
+	dkio "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/metrics"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/tailer"
+)
+
+type iisLog struct {
+	Files    []string `toml:"files"`
+	Pipeline string   `toml:"pipeline"`
+
+	ErrorStormThreshold int64 `toml:"error_storm_threshold"`
+}
+
+const w3cTimeLayout = "2006-01-02 15:04:05"
+
+// defaultW3CFields are fields of W3C log with default settings of IIS, used
+// before any `#Fields:` directive read, such as DataKit restarted and
+// tailing from the middle of the log file.
+var defaultW3CFields = []string{
+	"date", "time", "s-ip", "cs-method", "cs-uri-stem", "cs-uri-query", "s-port", "cs-username",
+	"c-ip", "cs(User-Agent)", "cs(Referer)", "sc-status", "sc-substatus", "sc-win32-status", "time-taken",
+}
+
+// w3cFieldNames map W3C fields to field names of the point, the same as the
+// iis.p pipeline. Other W3C fields, such as `cs(Cookie)`, are named
+// by w3cFieldName.
+var w3cFieldNames = map[string]string{
+	"s-sitename":      "site_name",
+	"s-computername":  "server_name",
+	"s-ip":            "server_ip",
+	"cs-method":       "http_method",
+	"cs-uri-stem":     "http_url",
+	"cs-uri-query":    "url_param",
+	"s-port":          "port",
+	"cs-username":     "username",
+	"c-ip":            "client_ip",
+	"cs-version":      "http_version",
+	"cs(User-Agent)":  "user_agent",
+	"cs(Referer)":     "referer",
+	"cs-host":         "http_host",
+	"sc-status":       "status_code",
+	"sc-substatus":    "sub_status",
+	"sc-win32-status": "win32_status",
+	"sc-bytes":        "bytes_sent",
+	"cs-bytes":        "bytes_received",
+	"time-taken":      "time_taken", // in milliseconds
+}
+
+var w3cIntFields = map[string]bool{
+	"port":           true,
+	"status_code":    true,
+	"sub_status":     true,
+	"win32_status":   true,
+	"bytes_sent":     true,
+	"bytes_received": true,
+	"time_taken":     true,
+}
+
+func w3cFieldName(f string) string {
+	if name, ok := w3cFieldNames[f]; ok {
+		return name
+	}
+
+	return strings.Trim(strings.NewReplacer("-", "_", "(", "_", ")", "").Replace(strings.ToLower(f)), "_")
+}
+
+// statusOf get log status from HTTP status code, the same as the iis.p pipeline.
+func statusOf(code int64) string {
+	switch {
+	case code >= 200 && code < 300:
+		return "ok"
+	case code >= 300 && code < 400:
+		return "notice"
+	case code >= 400 && code < 500:
+		return "warning"
+	case code >= 500 && code < 600:
+		return "error"
+	default:
+		return ""
+	}
+}
+
+type w3cEntry struct {
+	time   time.Time // zero if no date and time in the log
+	fields map[string]interface{}
+}
+
+// w3cParser parse W3C logs by fields of the latest `#Fields:` directive
+// of each file, so that the fields selected in IIS Manager don't matter.
+type w3cParser struct {
+	mu     sync.Mutex
+	fields map[string][]string // log file -> W3C fields
+}
+
+func newW3CParser() *w3cParser {
+	return &w3cParser{fields: map[string][]string{}}
+}
+
+// directive handle directive line of the file, such as `#Fields: date time ...`.
+func (p *w3cParser) directive(file, line string) {
+	if !strings.HasPrefix(line, "#Fields:") {
+		return // other directives like #Software, #Version and #Date ignored
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.fields[file] = strings.Fields(strings.TrimPrefix(line, "#Fields:"))
+}
+
+// parse parse the log line of the file, false returned if number of values
+// mismatch fields of the file.
+func (p *w3cParser) parse(file, line string) (*w3cEntry, bool) {
+	p.mu.Lock()
+	fields, ok := p.fields[file]
+	p.mu.Unlock()
+
+	if !ok {
+		fields = defaultW3CFields
+	}
+
+	values := strings.Fields(line)
+	if len(values) != len(fields) {
+		return nil, false
+	}
+
+	var date, tm string
+	entry := &w3cEntry{fields: map[string]interface{}{}}
+
+	for i, f := range fields {
+		v := values[i]
+		if v == "-" { // no value
+			continue
+		}
+
+		switch f {
+		case "date":
+			date = v
+			continue
+		case "time":
+			tm = v
+			continue
+		}
+
+		name := w3cFieldName(f)
+		if w3cIntFields[name] {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				continue
+			}
+			entry.fields[name] = n
+		} else {
+			entry.fields[name] = v
+		}
+	}
+
+	if date != "" && tm != "" {
+		// time of W3C log is always in UTC
+		if t, err := time.Parse(w3cTimeLayout, date+" "+tm); err == nil {
+			entry.time = t
+		}
+	}
+
+	if code, ok := entry.fields["status_code"].(int64); ok {
+		if status := statusOf(code); status != "" {
+			entry.fields["status"] = status
+		}
+	}
+
+	return entry, true
+}
+
+// w3cFeeder parse W3C logs fed by tailer before feeding to IO, directive
+// lines dropped, and 5xx responses counted for storm detecting.
+type w3cFeeder struct {
+	dkio.Feeder
+	parser *w3cParser
+	storm  *stormDetector // nil if error_storm_threshold not set
+}
+
+func (f *w3cFeeder) FeedV2(category point.Category, pts []*point.Point, opts ...dkio.FeedOption) error {
+	if category != point.Logging {
+		return f.Feeder.FeedV2(category, pts, opts...)
+	}
+
+	res := pts[:0]
+	for _, pt := range pts {
+		file, _ := pt.Get("filepath").(string)
+		msg, _ := pt.Get("message").(string)
+
+		if strings.HasPrefix(msg, "#") {
+			f.parser.directive(file, strings.TrimSpace(msg))
+			continue
+		}
+
+		if entry, ok := f.parser.parse(file, msg); ok {
+			for k, v := range entry.fields {
+				pt.MustAdd(k, v)
+			}
+			if !entry.time.IsZero() {
+				pt.SetTime(entry.time)
+			}
+			if code, ok := entry.fields["status_code"].(int64); ok {
+				f.storm.add(websiteOf(file, entry.fields), code)
+			}
+		}
+
+		res = append(res, pt)
+	}
+
+	if len(res) == 0 {
+		return nil
+	}
+
+	return f.Feeder.FeedV2(category, res, opts...)
+}
+
+// RunPipeline tail IIS logs, W3C logs are parsed by the `#Fields:` directive
+// if no pipeline configured.
+func (ipt *Input) RunPipeline() {
+	if ipt.Log == nil || len(ipt.Log.Files) == 0 {
+		return
+	}
+
+	opts := []tailer.Option{
+		tailer.WithSource(inputName),
+		tailer.WithService(inputName),
+		tailer.WithPipeline(ipt.Log.Pipeline),
+		tailer.WithGlobalTags(inputs.MergeTags(ipt.Tagger.HostTags(), ipt.Tags, "")),
+		tailer.EnableDebugFields(config.Cfg.EnableDebugFields),
+	}
+
+	g := goroutine.NewGroup(goroutine.Option{Name: "inputs_iis"})
+
+	if ipt.Log.Pipeline == "" {
+		feeder := &w3cFeeder{Feeder: ipt.feeder, parser: newW3CParser()}
+
+		if ipt.Log.ErrorStormThreshold > 0 {
+			ipt.storm = newStormDetector(ipt.Log.ErrorStormThreshold)
+			feeder.storm = ipt.storm
+
+			g.Go(func(ctx context.Context) error {
+				ipt.runStormDetector()
+				return nil
+			})
+		}
+
+		opts = append(opts, tailer.WithFeeder(feeder))
+	} else if ipt.Log.ErrorStormThreshold > 0 {
+		l.Warnf("error_storm_threshold ignored since pipeline %q configured", ipt.Log.Pipeline)
+	}
+
+	var err error
+	if ipt.tail, err = tailer.NewTailer(ipt.Log.Files, opts...); err != nil {
+		l.Error(err)
+		metrics.FeedLastError(inputName, err.Error())
+		return
+	}
+
+	g.Go(func(ctx context.Context) error {
+		ipt.tail.Start()
+		return nil
+	})
+}
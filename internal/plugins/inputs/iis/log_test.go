@@ -0,0 +1,97 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package iis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	dkio "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io"
+)
+
+func TestW3CParser(t *testing.T) {
+	const file = `C:\inetpub\logs\LogFiles\W3SVC1\u_ex240101.log`
+
+	t.Run("default-fields", func(t *testing.T) {
+		p := newW3CParser()
+		entry, ok := p.parse(file,
+			`2024-01-01 08:00:01 10.0.0.1 GET /index.html - 80 - 10.0.0.2 Mozilla/5.0 - 200 0 0 15`)
+		require.True(t, ok)
+
+		assert.Equal(t, time.Date(2024, 1, 1, 8, 0, 1, 0, time.UTC), entry.time)
+		assert.Equal(t, map[string]interface{}{
+			"server_ip":    "10.0.0.1",
+			"http_method":  "GET",
+			"http_url":     "/index.html",
+			"port":         int64(80),
+			"client_ip":    "10.0.0.2",
+			"user_agent":   "Mozilla/5.0",
+			"status_code":  int64(200),
+			"sub_status":   int64(0),
+			"win32_status": int64(0),
+			"time_taken":   int64(15),
+			"status":       "ok",
+		}, entry.fields)
+	})
+
+	t.Run("fields-directive", func(t *testing.T) {
+		p := newW3CParser()
+		p.directive(file, "#Software: Microsoft Internet Information Services 10.0")
+		p.directive(file, "#Fields: time-taken sc-status cs-uri-stem s-sitename cs(Cookie) sc-bytes")
+
+		entry, ok := p.parse(file, `230 503 /api W3SVC1 a=b 1024`)
+		require.True(t, ok)
+		assert.True(t, entry.time.IsZero())
+		assert.Equal(t, map[string]interface{}{
+			"time_taken":  int64(230),
+			"status_code": int64(503),
+			"http_url":    "/api",
+			"site_name":   "W3SVC1",
+			"cs_cookie":   "a=b",
+			"bytes_sent":  int64(1024),
+			"status":      "error",
+		}, entry.fields)
+
+		// fields of other files not changed
+		_, ok = p.parse("other.log", `230 503 /api W3SVC1 a=b 1024`)
+		assert.False(t, ok)
+	})
+}
+
+func TestW3CFeeder(t *testing.T) {
+	newLog := func(msg string) *point.Point {
+		var kvs point.KVs
+		kvs = kvs.Add("message", msg, false, false).Add("filepath", "u_ex240101.log", false, false)
+		return point.NewPointV2(inputName, kvs, point.DefaultLoggingOptions()...)
+	}
+
+	feeder := dkio.NewMockedFeeder()
+	f := &w3cFeeder{Feeder: feeder, parser: newW3CParser(), storm: newStormDetector(1)}
+
+	require.NoError(t, f.FeedV2(point.Logging, []*point.Point{
+		newLog("#Fields: date time cs-method sc-status time-taken"),
+		newLog("2024-01-01 08:00:01 POST 404 3"),
+		newLog("not a w3c log"),
+	}))
+
+	pts, err := feeder.AnyPoints()
+	require.NoError(t, err)
+	require.Len(t, pts, 2)
+
+	assert.Equal(t, "POST", pts[0].Get("http_method"))
+	assert.Equal(t, int64(404), pts[0].Get("status_code"))
+	assert.Equal(t, "warning", pts[0].Get("status"))
+	assert.Equal(t, time.Date(2024, 1, 1, 8, 0, 1, 0, time.UTC).UnixNano(), pts[0].Time().UnixNano())
+
+	assert.Nil(t, pts[1].Get("status_code"))
+
+	// 404 is not 5xx
+	assert.Len(t, f.storm.flush(time.Minute, nil, 0), 0)
+}
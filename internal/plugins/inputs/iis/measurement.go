@@ -30,7 +30,7 @@ func (m *IISAppPoolWas) Info() *inputs.MeasurementInfo {
 		Desc: "",
 		Tags: map[string]interface{}{
 			"host":     &inputs.TagInfo{Desc: "Host name"},
-			"app_pool": &inputs.TagInfo{Desc: "IIS app pool"},
+			"app_pool": &inputs.TagInfo{Desc: "IIS app pool, `_Total` for the sum of all app pools"},
 		},
 		Fields: map[string]interface{}{
 			"current_app_pool_uptime": newFieldInfoSecond("The uptime of the application pool since it was started."),
@@ -40,6 +40,81 @@ func (m *IISAppPoolWas) Info() *inputs.MeasurementInfo {
 	}
 }
 
+type IISHTTPRequestQueue measurement
+
+//nolint:lll
+func (m *IISHTTPRequestQueue) Info() *inputs.MeasurementInfo {
+	return &inputs.MeasurementInfo{
+		Name: metricNameRequestQueue,
+		Type: "metric",
+		Desc: "HTTP.SYS kernel request queue of each app pool, where requests wait for worker processes of the app pool.",
+		Tags: map[string]interface{}{
+			"host":     &inputs.TagInfo{Desc: "Host name"},
+			"app_pool": &inputs.TagInfo{Desc: "IIS app pool, `_Total` for the sum of all app pools"},
+		},
+		Fields: map[string]interface{}{
+			"current_queue_size": newFieldInfoCount("Number of requests in the queue."),
+			"max_queue_item_age": &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.DurationMS, Desc: "Age of the oldest request in the queue."},
+			"arrival_rate":       newFieldInfoRPS("Rate at which requests are arriving in the queue."),
+			"rejection_rate":     newFieldInfoRPS("Rate at which requests are rejected from the queue."),
+			"rejected_requests":  newFieldInfoCount("Total number of requests rejected from the queue, such as the queue is full or the app pool is stopped."),
+			"cache_hit_rate":     newFieldInfoRPS("Rate of cache hits from the queue."),
+		},
+	}
+}
+
+type IISWorkerProcess measurement
+
+//nolint:lll
+func (m *IISWorkerProcess) Info() *inputs.MeasurementInfo {
+	return &inputs.MeasurementInfo{
+		Name: metricNameWorkerProcess,
+		Type: "metric",
+		Desc: "Worker process(w3wp.exe) of each app pool, the `pid` tag is the same as of the process collector.",
+		Tags: map[string]interface{}{
+			"host":     &inputs.TagInfo{Desc: "Host name"},
+			"app_pool": &inputs.TagInfo{Desc: "IIS app pool served by the worker process"},
+			"pid":      &inputs.TagInfo{Desc: "Process ID of the worker process"},
+		},
+		Fields: map[string]interface{}{
+			"active_requests": newFieldInfoCount("Number of requests being processed by the worker process."),
+			"requests":        newFieldInfoRPS("Rate at which HTTP requests are served by the worker process."),
+			"total_requests":  newFieldInfoCount("Number of HTTP requests served by the worker process since it started."),
+			"active_threads":  newFieldInfoCount("Number of threads actively processing requests in the worker process."),
+			"threads":         &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.NCount, Desc: "Number of threads of the worker process."},
+			"cpu_usage":       &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.Percent, Desc: "CPU usage of the worker process in the interval, since the process started on the first collection."},
+			"mem_rss":         &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.SizeByte, Desc: "Resident set size(working set) of the worker process."},
+			"mem_vms":         &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.SizeByte, Desc: "Virtual memory size of the worker process."},
+		},
+	}
+}
+
+type IISAspNetApplication measurement
+
+//nolint:lll
+func (m *IISAspNetApplication) Info() *inputs.MeasurementInfo {
+	return &inputs.MeasurementInfo{
+		Name: metricNameAspNetApp,
+		Type: "metric",
+		Desc: "ASP.NET applications of the managed pipeline, only if ASP.NET installed.",
+		Tags: map[string]interface{}{
+			"host":       &inputs.TagInfo{Desc: "Host name"},
+			"aspnet_app": &inputs.TagInfo{Desc: "ASP.NET application, like `_LM_W3SVC_1_ROOT`, `__Total__` for the sum of all applications"},
+			"site_id":    &inputs.TagInfo{Desc: "ID of the web site of the application"},
+		},
+		Fields: map[string]interface{}{
+			"requests_in_application_queue": newFieldInfoCount("Number of requests in the application request queue."),
+			"requests_executing":            newFieldInfoCount("Number of requests currently executing."),
+			"requests":                      newFieldInfoRPS("Number of requests executed per second."),
+			"request_execution_time":        &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.DurationMS, Desc: "Time it took to execute the most recent request."},
+			"requests_failed":               newFieldInfoCount("Total number of failed requests."),
+			"requests_timed_out":            newFieldInfoCount("Total number of requests timed out."),
+			"errors_total":                  newFieldInfoCount("Total number of errors occurred during the execution of HTTP requests, including parser, compilation and runtime errors."),
+			"errors":                        &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.UnknownUnit, Desc: "Rate of errors occurred per second."},
+		},
+	}
+}
+
 type IISWebService measurement
 
 //nolint:lll
@@ -50,7 +125,7 @@ func (m *IISWebService) Info() *inputs.MeasurementInfo {
 		Desc: "",
 		Tags: map[string]interface{}{
 			"host":    &inputs.TagInfo{Desc: "Host name"},
-			"website": &inputs.TagInfo{Desc: "IIS web site"},
+			"website": &inputs.TagInfo{Desc: "IIS web site, `_Total` for the sum of all web sites"},
 		},
 		Fields: map[string]interface{}{
 			"service_uptime": newFieldInfoSecond("Service uptime."),
@@ -42,4 +42,34 @@ var PerfObjMetricMap = map[string]map[string]map[string]string{
 			"Total Application Pool Recycles": "total_app_pool_recycles",
 		},
 	},
+	"iis_http_request_queue": {
+		"HTTP Service Request Queues": {
+			"CurrentQueueSize": "current_queue_size",
+			"MaxQueueItemAge":  "max_queue_item_age",
+			"ArrivalRate":      "arrival_rate",
+			"RejectionRate":    "rejection_rate",
+			"RejectedRequests": "rejected_requests",
+			"CacheHitRate":     "cache_hit_rate",
+		},
+	},
+	"iis_worker_process": {
+		"W3SVC_W3WP": {
+			"Active Requests":            "active_requests",
+			"Requests / Sec":             "requests",
+			"Total HTTP Requests Served": "total_requests",
+			"Active Threads Count":       "active_threads",
+		},
+	},
+	"iis_aspnet_application": {
+		"ASP.NET Applications": {
+			"Requests In Application Queue": "requests_in_application_queue",
+			"Requests Executing":            "requests_executing",
+			"Requests/Sec":                  "requests",
+			"Request Execution Time":        "request_execution_time",
+			"Requests Failed":               "requests_failed",
+			"Requests Timed Out":            "requests_timed_out",
+			"Errors Total":                  "errors_total",
+			"Errors Total/Sec":              "errors",
+		},
+	},
 }
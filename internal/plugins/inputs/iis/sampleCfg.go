@@ -11,12 +11,31 @@ const (
 [[inputs.iis]]
   ## (optional) collect interval, default is 15 seconds
   interval = '15s'
-  ##
+
+  ## (optional) glob patterns of web sites and application pools to collect,
+  ## all of them collected if empty. Instance "_Total" is the sum of all
+  ## web sites(or application pools).
+  # websites = ["Default Web Site", "shop-*"]
+  # app_pools = ["DefaultAppPool"]
+
+  ## (optional) watch app pool recycle and crash events of WAS and W3SVC
+  ## from Windows event log
+  # event_log = true
+
+  ## (optional) report certificate expiry of HTTPS bindings of web sites,
+  ## certificates are checked every 10 minutes
+  # cert_bindings = true
+  # application_host_config = 'C:\Windows\System32\inetsrv\config\applicationHost.config'
 
   [inputs.iis.log]
     files = []
-    ## grok pipeline script path
-    pipeline = "iis.p"
+    ## W3C logs are parsed by the "#Fields:" directive of log files if no pipeline
+    ## configured, or set grok pipeline script path like "iis.p"
+    # pipeline = "iis.p"
+
+    ## (optional) emit 5xx storm event if number of 5xx responses of a web site
+    ## in an interval reaches the threshold, only if no pipeline configured
+    # error_storm_threshold = 100
 
   [inputs.iis.tags]
     ## tag1 = "v1"
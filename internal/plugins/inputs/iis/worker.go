@@ -0,0 +1,102 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package iis
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	pr "github.com/shirou/gopsutil/v3/process"
+)
+
+// objWorkerProcess is the perf object of w3wp.exe, instances are named like
+// `1234_DefaultAppPool`, i.e. `<PID>_<app pool>`.
+const objWorkerProcess = "W3SVC_W3WP"
+
+func parseWorkerInstance(instance string) (int32, string, bool) {
+	idx := strings.Index(instance, "_")
+	if idx <= 0 || idx == len(instance)-1 {
+		return 0, "", false
+	}
+
+	pid, err := strconv.ParseInt(instance[:idx], 10, 32)
+	if err != nil {
+		return 0, "", false
+	}
+
+	return int32(pid), instance[idx+1:], true
+}
+
+type workerCPU struct {
+	t     time.Time
+	total float64 // user + system CPU seconds
+}
+
+// workerRecorder get CPU and memory of worker processes, CPU usage is
+// calculated between 2 collections, or since the process started on
+// the first collection.
+type workerRecorder struct {
+	mu  sync.Mutex
+	cpu map[int32]workerCPU
+}
+
+func newWorkerRecorder() *workerRecorder {
+	return &workerRecorder{cpu: map[int32]workerCPU{}}
+}
+
+func (w *workerRecorder) fields(pid int32, now time.Time) map[string]interface{} {
+	ps, err := pr.NewProcess(pid)
+	if err != nil {
+		l.Debugf("worker process %d: %s, ignored", pid, err)
+		return nil
+	}
+
+	fields := map[string]interface{}{}
+
+	if mem, err := ps.MemoryInfo(); err == nil {
+		fields["mem_rss"] = int64(mem.RSS)
+		fields["mem_vms"] = int64(mem.VMS)
+	}
+
+	if n, err := ps.NumThreads(); err == nil {
+		fields["threads"] = int64(n)
+	}
+
+	times, err := ps.Times()
+	if err != nil || times == nil {
+		return fields
+	}
+	total := times.User + times.System
+
+	w.mu.Lock()
+	last, ok := w.cpu[pid]
+	w.cpu[pid] = workerCPU{t: now, total: total}
+	w.mu.Unlock()
+
+	if ok && now.After(last.t) && total >= last.total {
+		fields["cpu_usage"] = 100 * (total - last.total) / now.Sub(last.t).Seconds()
+	} else if created, err := ps.CreateTime(); err == nil {
+		if d := now.Sub(time.UnixMilli(created)).Seconds(); d > 0 {
+			fields["cpu_usage"] = 100 * total / d
+		}
+	}
+
+	return fields
+}
+
+// prune remove records of exited worker processes.
+func (w *workerRecorder) prune(alive map[int32]bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for pid := range w.cpu {
+		if !alive[pid] {
+			delete(w.cpu, pid)
+		}
+	}
+}
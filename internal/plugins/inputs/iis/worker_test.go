@@ -0,0 +1,43 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package iis
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseWorkerInstance(t *testing.T) {
+	pid, pool, ok := parseWorkerInstance("1234_DefaultAppPool")
+	assert.True(t, ok)
+	assert.Equal(t, int32(1234), pid)
+	assert.Equal(t, "DefaultAppPool", pool)
+
+	for _, x := range []string{"_Total", "abc_pool", "1234_", "1234"} {
+		_, _, ok := parseWorkerInstance(x)
+		assert.False(t, ok, x)
+	}
+}
+
+func TestWorkerRecorder(t *testing.T) {
+	w := newWorkerRecorder()
+	pid := int32(os.Getpid())
+
+	fields := w.fields(pid, time.Now())
+	assert.Greater(t, fields["mem_rss"], int64(0))
+	assert.Contains(t, fields, "cpu_usage")
+
+	fields = w.fields(pid, time.Now().Add(time.Second))
+	assert.Contains(t, fields, "cpu_usage")
+
+	w.prune(map[int32]bool{})
+	assert.Len(t, w.cpu, 0)
+
+	assert.Nil(t, w.fields(-1, time.Now()))
+}
@@ -97,15 +97,7 @@ const (
 `
 )
 
-var (
-	log            = logger.DefaultSLogger(inputName)
-	afterGatherRun itrace.AfterGatherHandler
-	ignoreTags     []*regexp.Regexp
-	tags           map[string]string
-	wkpool         *workerpool.WorkerPool
-	localCache     *storage.Storage
-	delMessage     bool
-)
+var log = logger.DefaultSLogger(inputName)
 
 type Input struct {
 	Path             string                       `toml:"path"`          // deprecated
@@ -127,6 +119,14 @@ type Input struct {
 	feeder  dkio.Feeder
 	semStop *cliutils.Sem // start stop signal
 	Tagger  datakit.GlobalTagger
+
+	// per-instance runtime state, so that multiple jaeger inputs
+	// (e.g. thrift-HTTP and UDP) do not interfere with each other.
+	afterGatherRun itrace.AfterGatherHandler
+	ignoreTags     []*regexp.Regexp
+	traceOpts      []point.Option
+	wkpool         *workerpool.WorkerPool
+	localCache     *storage.Storage
 }
 
 func (*Input) Catalog() string { return inputName }
@@ -144,17 +144,17 @@ func (ipt *Input) RegHTTPHandler() {
 
 	var err error
 	if ipt.WPConfig != nil {
-		if wkpool, err = workerpool.NewWorkerPool(ipt.WPConfig, log); err != nil {
+		if ipt.wkpool, err = workerpool.NewWorkerPool(ipt.WPConfig, log); err != nil {
 			log.Errorf("### new worker-pool failed: %s", err.Error())
-		} else if err = wkpool.Start(); err != nil {
+		} else if err = ipt.wkpool.Start(); err != nil {
 			log.Errorf("### start worker-pool failed: %s", err.Error())
 		}
 	}
 	if ipt.LocalCacheConfig != nil {
-		if localCache, err = storage.NewStorage(ipt.LocalCacheConfig, log); err != nil {
+		if ipt.localCache, err = storage.NewStorage(ipt.LocalCacheConfig, log); err != nil {
 			log.Errorf("### new local-cache failed: %s", err.Error())
 		} else {
-			localCache.RegisterConsumer(storage.HTTP_KEY, func(buf []byte) error {
+			ipt.localCache.RegisterConsumer(storage.HTTP_KEY, func(buf []byte) error {
 				start := time.Now()
 				reqpb := &storage.Request{}
 				if err := proto.Unmarshal(buf, reqpb); err != nil {
@@ -179,21 +179,21 @@ func (ipt *Input) RegHTTPHandler() {
 					if req.URL, err = url.Parse(reqpb.Url); err != nil {
 						log.Errorf("### parse raw URL: %s failed: %s", reqpb.Url, err.Error())
 					}
-					handleJaegerTrace(&httpapi.NopResponseWriter{}, req)
+					ipt.handleJaegerTrace(&httpapi.NopResponseWriter{}, req)
 
 					log.Debugf("### process status: buffer-size: %dkb, cost: %dms, err: %v", len(reqpb.Body)>>10, time.Since(start)/time.Millisecond, err)
 
 					return nil
 				}
 			})
-			if err = localCache.RunConsumeWorker(); err != nil {
+			if err = ipt.localCache.RunConsumeWorker(); err != nil {
 				log.Errorf("### run local-cache consumer failed: %s", err.Error())
 			}
 		}
 	}
 
 	var afterGather *itrace.AfterGather
-	if localCache != nil && localCache.Enabled() {
+	if ipt.localCache != nil && ipt.localCache.Enabled() {
 		afterGather = itrace.NewAfterGather(
 			itrace.WithLogger(log),
 			itrace.WithRetry(100*time.Millisecond),
@@ -204,7 +204,7 @@ func (ipt *Input) RegHTTPHandler() {
 		afterGather = itrace.NewAfterGather(itrace.WithLogger(log),
 			itrace.WithPointOptions(point.WithExtraTags(ipt.Tagger.HostTags())), itrace.WithFeeder(ipt.feeder))
 	}
-	afterGatherRun = afterGather
+	ipt.afterGatherRun = afterGather
 
 	// add filters: the order of appending filters into AfterGather is important!!!
 	// the order of appending represents the order of that filter executes.
@@ -231,27 +231,26 @@ func (ipt *Input) RegHTTPHandler() {
 	log.Debugf("### register handler for %s of agent %s", ipt.Endpoint, inputName)
 	if ipt.Endpoint != "" {
 		httpapi.RegHTTPHandler("POST", ipt.Endpoint,
-			workerpool.HTTPWrapper(httpStatusRespFunc, wkpool,
-				httpapi.HTTPStorageWrapper(storage.HTTP_KEY, httpStatusRespFunc, localCache, handleJaegerTrace)))
+			workerpool.HTTPWrapper(httpStatusRespFunc, ipt.wkpool,
+				httpapi.HTTPStorageWrapper(storage.HTTP_KEY, httpStatusRespFunc, ipt.localCache, ipt.handleJaegerTrace)))
 	}
 }
 
 func (ipt *Input) Run() {
+	ipt.ignoreTags = ipt.ignoreTags[:0]
 	for _, v := range ipt.IgnoreTags {
 		if rexp, err := regexp.Compile(v); err != nil {
 			log.Debug(err.Error())
 		} else {
-			ignoreTags = append(ignoreTags, rexp)
+			ipt.ignoreTags = append(ipt.ignoreTags, rexp)
 		}
 	}
-	tags = ipt.Tags
-	traceOpts = append(point.CommonLoggingOptions(), point.WithExtraTags(ipt.Tagger.HostTags()))
-	delMessage = ipt.DelMessage
+	ipt.traceOpts = append(point.CommonLoggingOptions(), point.WithExtraTags(ipt.Tagger.HostTags()))
 	if ipt.Address != "" {
 		log.Debugf("### %s UDP agent is starting...", inputName)
 		g := goroutine.NewGroup(goroutine.Option{Name: inputName})
 		g.Go(func(ctx context.Context) error {
-			if err := StartUDPAgent(CompactProtocol, ipt.Address, ipt.semStop); err != nil {
+			if err := ipt.StartUDPAgent(CompactProtocol, ipt.Address, ipt.semStop); err != nil {
 				log.Errorf("### start %s UDP agent failed: %s", inputName, err.Error())
 			}
 			return nil
@@ -260,7 +259,7 @@ func (ipt *Input) Run() {
 	if ipt.BinaryAddress != "" {
 		g := goroutine.NewGroup(goroutine.Option{Name: inputName})
 		g.Go(func(ctx context.Context) error {
-			if err := StartUDPAgent(BinaryProtocol, ipt.BinaryAddress, ipt.semStop); err != nil {
+			if err := ipt.StartUDPAgent(BinaryProtocol, ipt.BinaryAddress, ipt.semStop); err != nil {
 				log.Errorf("### start %s UDP agent failed: %s", inputName, err.Error())
 			}
 			return nil
@@ -282,12 +281,12 @@ func (ipt *Input) Run() {
 }
 
 func (ipt *Input) exit() {
-	if wkpool != nil {
-		wkpool.Shutdown()
+	if ipt.wkpool != nil {
+		ipt.wkpool.Shutdown()
 		log.Debug("### workerpool closed")
 	}
-	if localCache != nil {
-		if err := localCache.Close(); err != nil {
+	if ipt.localCache != nil {
+		if err := ipt.localCache.Close(); err != nil {
 			log.Error(err.Error())
 		}
 		log.Debug("### storage closed")
@@ -62,7 +62,6 @@ func TestIntegrate(t *testing.T) {
 	for _, tc := range cases {
 		func(tc *caseSpec) {
 			t.Run(tc.name, func(t *testing.T) {
-				// t.Parallel() // jaeger should not be paralleled due to code design. For example, afterGatherRun is global variable.
 				caseStart := time.Now()
 
 				t.Logf("testing %s...", tc.name)
@@ -281,7 +280,7 @@ func (cs *caseSpec) checkPoint(pts []*point.Point) error {
 }
 
 func (cs *caseSpec) handler(c *gin.Context) {
-	handleJaegerTrace(c.Writer, c.Request)
+	cs.ipt.handleJaegerTrace(c.Writer, c.Request)
 }
 
 func (cs *caseSpec) run() error {
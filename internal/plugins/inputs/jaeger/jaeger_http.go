@@ -26,7 +26,7 @@ func httpStatusRespFunc(resp http.ResponseWriter, req *http.Request, err error)
 	resp.WriteHeader(http.StatusOK)
 }
 
-func handleJaegerTrace(resp http.ResponseWriter, req *http.Request) {
+func (ipt *Input) handleJaegerTrace(resp http.ResponseWriter, req *http.Request) {
 	log.Debugf("### receiving trace data from path: %s", req.URL.Path)
 
 	pbuf := bufpool.GetBuffer()
@@ -41,7 +41,7 @@ func handleJaegerTrace(resp http.ResponseWriter, req *http.Request) {
 	}
 
 	param := &itrace.TraceParameters{Body: pbuf}
-	if err = parseJaegerTrace(param); err != nil {
+	if err = ipt.parseJaegerTrace(param); err != nil {
 		log.Errorf("### parse jaeger trace from HTTP failed: %s", err.Error())
 		resp.WriteHeader(http.StatusBadRequest)
 
@@ -51,7 +51,7 @@ func handleJaegerTrace(resp http.ResponseWriter, req *http.Request) {
 	resp.WriteHeader(http.StatusOK)
 }
 
-func parseJaegerTrace(param *itrace.TraceParameters) error {
+func (ipt *Input) parseJaegerTrace(param *itrace.TraceParameters) error {
 	tmbuf := thrift.NewTMemoryBuffer()
 	_, err := tmbuf.ReadFrom(param.Body)
 	if err != nil {
@@ -66,8 +66,8 @@ func parseJaegerTrace(param *itrace.TraceParameters) error {
 		return err
 	}
 
-	if dktrace := batchToDkTrace(batch); len(dktrace) != 0 && afterGatherRun != nil {
-		afterGatherRun.Run(inputName, itrace.DatakitTraces{dktrace})
+	if dktrace := ipt.batchToDkTrace(batch); len(dktrace) != 0 && ipt.afterGatherRun != nil {
+		ipt.afterGatherRun.Run(inputName, itrace.DatakitTraces{dktrace})
 	}
 
 	return nil
@@ -81,9 +81,7 @@ type DkJaegerSpan struct {
 	*jaeger.Span
 }
 
-var traceOpts = []point.Option{}
-
-func batchToDkTrace(batch *jaeger.Batch) itrace.DatakitTrace {
+func (ipt *Input) batchToDkTrace(batch *jaeger.Batch) itrace.DatakitTrace {
 	var (
 		project, version, env = getExpandInfo(batch)
 		dktrace               itrace.DatakitTrace
@@ -138,7 +136,7 @@ func batchToDkTrace(batch *jaeger.Batch) itrace.DatakitTrace {
 			}
 		}
 
-		if mTags, err := itrace.MergeInToCustomerTags(tags, sourceTags, ignoreTags); err == nil {
+		if mTags, err := itrace.MergeInToCustomerTags(ipt.Tags, sourceTags, ipt.ignoreTags); err == nil {
 			for k, v := range mTags {
 				spanKV = spanKV.AddTag(k, v)
 			}
@@ -160,7 +158,7 @@ func batchToDkTrace(batch *jaeger.Batch) itrace.DatakitTrace {
 			ParentSpanId: uint64(span.ParentSpanId),
 			Span:         span,
 		}
-		if !delMessage {
+		if !ipt.DelMessage {
 			if buf, err := json.Marshal(dkJSpan); err != nil {
 				log.Warn(err.Error())
 			} else {
@@ -169,7 +167,7 @@ func batchToDkTrace(batch *jaeger.Batch) itrace.DatakitTrace {
 		}
 
 		t := time.UnixMicro(span.StartTime)
-		pt := point.NewPointV2(inputName, spanKV, append(traceOpts, point.WithTime(t))...)
+		pt := point.NewPointV2(inputName, spanKV, append(ipt.traceOpts, point.WithTime(t))...)
 		dktrace = append(dktrace, &itrace.DkSpan{Point: pt})
 	}
 
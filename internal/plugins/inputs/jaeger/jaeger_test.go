@@ -12,7 +12,8 @@ import (
 )
 
 func TestJaegerAgent(t *testing.T) {
-	afterGatherRun = itrace.AfterGatherFunc(func(inputName string, dktraces itrace.DatakitTraces) {})
+	ipt := defaultInput()
+	ipt.afterGatherRun = itrace.AfterGatherFunc(func(inputName string, dktraces itrace.DatakitTraces) {})
 
 	testHTTPHandler(t)
 	testUDPClient(t)
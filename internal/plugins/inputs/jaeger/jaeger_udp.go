@@ -24,7 +24,7 @@ const (
 	CompactProtocol = "compact"
 )
 
-func StartUDPAgent(protocol string, addr string, semStop *cliutils.Sem) error {
+func (ipt *Input) StartUDPAgent(protocol string, addr string, semStop *cliutils.Sem) error {
 	udpAddr, err := net.ResolveUDPAddr("udp", addr)
 	if err != nil {
 		return err
@@ -71,7 +71,7 @@ func StartUDPAgent(protocol string, addr string, semStop *cliutils.Sem) error {
 		log.Debugf("### read from udp server:%s %d bytes", addr, n)
 
 		param := &itrace.TraceParameters{Body: bytes.NewBuffer(buf[:n])}
-		if err = parseJaegerTraceUDP(protocol, param); err != nil {
+		if err = ipt.parseJaegerTraceUDP(protocol, param); err != nil {
 			log.Errorf("### parse jaeger trace from UDP failed: %s", err.Error())
 		}
 	}
@@ -83,7 +83,7 @@ func udpExit(udpConn *net.UDPConn) {
 	}
 }
 
-func parseJaegerTraceUDP(protocol string, param *itrace.TraceParameters) error {
+func (ipt *Input) parseJaegerTraceUDP(protocol string, param *itrace.TraceParameters) error {
 	tmbuf := thrift.NewTMemoryBufferLen(param.Body.Len())
 	_, err := tmbuf.Write(param.Body.Bytes())
 	if err != nil {
@@ -110,8 +110,8 @@ func parseJaegerTraceUDP(protocol string, param *itrace.TraceParameters) error {
 		return err
 	}
 
-	if dktrace := batchToDkTrace(batch.Batch); len(dktrace) != 0 && afterGatherRun != nil {
-		afterGatherRun.Run(inputName, itrace.DatakitTraces{dktrace})
+	if dktrace := ipt.batchToDkTrace(batch.Batch); len(dktrace) != 0 && ipt.afterGatherRun != nil {
+		ipt.afterGatherRun.Run(inputName, itrace.DatakitTraces{dktrace})
 	}
 
 	return nil
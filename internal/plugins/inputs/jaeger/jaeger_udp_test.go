@@ -169,8 +169,9 @@ func TestStartUDPAgent(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Logf("test for %s", tt.name)
 			af := newtAfterGather(t)
-			afterGatherRun = af
-			go StartUDPAgent(tt.args.protocol, tt.args.addr, tt.args.semStop)
+			ipt := defaultInput()
+			ipt.afterGatherRun = af
+			go ipt.StartUDPAgent(tt.args.protocol, tt.args.addr, tt.args.semStop)
 			time.Sleep(time.Second * 2)
 			batch := mockBatch(tt.args.spanCount, map[string]string{"tag_a": "a"})
 			bts := protocolMarshalers[tt.args.protocol](t, batch)
@@ -256,3 +257,24 @@ func Test_EmitBatch(t *testing.T) {
 	}
 	t.Logf("batch = %s", batch1.Batch.String())
 }
+
+func TestMultiInstanceIsolation(t *testing.T) {
+	iptA := defaultInput()
+	iptA.Tags = map[string]string{"instance": "a"}
+	iptA.DelMessage = true
+
+	iptB := defaultInput()
+	iptB.Tags = map[string]string{"instance": "b"}
+
+	batch := mockBatch(1, nil)
+
+	ptsA := iptA.batchToDkTrace(batch)
+	ptsB := iptB.batchToDkTrace(batch)
+	assert.Len(t, ptsA, 1)
+	assert.Len(t, ptsB, 1)
+
+	assert.Equal(t, "a", ptsA[0].GetTag("instance"))
+	assert.Equal(t, "b", ptsB[0].GetTag("instance"))
+	assert.Nil(t, ptsA[0].Get(itrace.FieldMessage))
+	assert.NotNil(t, ptsB[0].Get(itrace.FieldMessage))
+}
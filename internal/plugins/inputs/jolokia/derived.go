@@ -0,0 +1,193 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package jolokia
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strconv"
+)
+
+// derivedField is a field computed from other fields of the point, such as
+// `heap_used_percent = HeapMemoryUsage.used / HeapMemoryUsage.max * 100`.
+// Only number literals, field names and `+ - * /` are allowed in expression.
+type derivedField struct {
+	name string
+	expr ast.Expr
+}
+
+func compileDerivedFields(exprs map[string]string) ([]derivedField, error) {
+	names := make([]string, 0, len(exprs))
+	for k := range exprs {
+		names = append(names, k)
+	}
+	sort.Strings(names) // derived field can refer to the former ones
+
+	res := make([]derivedField, 0, len(names))
+	for _, name := range names {
+		expr, err := parser.ParseExpr(exprs[name])
+		if err != nil {
+			return nil, fmt.Errorf("invalid expression of %q: %w", name, err)
+		}
+
+		if err := checkExpr(expr); err != nil {
+			return nil, fmt.Errorf("invalid expression of %q: %w", name, err)
+		}
+
+		res = append(res, derivedField{name: name, expr: expr})
+	}
+
+	return res, nil
+}
+
+func checkExpr(e ast.Expr) error {
+	switch x := e.(type) {
+	case *ast.BasicLit:
+		if x.Kind != token.INT && x.Kind != token.FLOAT {
+			return fmt.Errorf("unsupported literal %s", x.Value)
+		}
+		return nil
+
+	case *ast.Ident:
+		return nil
+
+	case *ast.SelectorExpr:
+		_, err := fieldName(x)
+		return err
+
+	case *ast.ParenExpr:
+		return checkExpr(x.X)
+
+	case *ast.UnaryExpr:
+		if x.Op != token.SUB && x.Op != token.ADD {
+			return fmt.Errorf("unsupported operator %s", x.Op)
+		}
+		return checkExpr(x.X)
+
+	case *ast.BinaryExpr:
+		switch x.Op { //nolint:exhaustive
+		case token.ADD, token.SUB, token.MUL, token.QUO:
+		default:
+			return fmt.Errorf("unsupported operator %s", x.Op)
+		}
+
+		if err := checkExpr(x.X); err != nil {
+			return err
+		}
+		return checkExpr(x.Y)
+
+	default:
+		return fmt.Errorf("unsupported expression %T", e)
+	}
+}
+
+// fieldName get field name like `HeapMemoryUsage.used` from selector expression.
+func fieldName(e ast.Expr) (string, error) {
+	switch x := e.(type) {
+	case *ast.Ident:
+		return x.Name, nil
+	case *ast.SelectorExpr:
+		prefix, err := fieldName(x.X)
+		if err != nil {
+			return "", err
+		}
+		return prefix + "." + x.Sel.Name, nil
+	default:
+		return "", fmt.Errorf("unsupported field %T", e)
+	}
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch x := v.(type) {
+	case int64:
+		return float64(x), true
+	case float64:
+		return x, true
+	case int:
+		return float64(x), true
+	default:
+		return 0, false
+	}
+}
+
+func evalExpr(e ast.Expr, fields map[string]interface{}) (float64, error) {
+	switch x := e.(type) {
+	case *ast.BasicLit:
+		return strconv.ParseFloat(x.Value, 64)
+
+	case *ast.Ident, *ast.SelectorExpr:
+		name, err := fieldName(x)
+		if err != nil {
+			return 0, err
+		}
+
+		v, ok := fields[name]
+		if !ok {
+			return 0, fmt.Errorf("field %q not found", name)
+		}
+
+		f, ok := toFloat(v)
+		if !ok {
+			return 0, fmt.Errorf("field %q is not number", name)
+		}
+		return f, nil
+
+	case *ast.ParenExpr:
+		return evalExpr(x.X, fields)
+
+	case *ast.UnaryExpr:
+		v, err := evalExpr(x.X, fields)
+		if err != nil {
+			return 0, err
+		}
+		if x.Op == token.SUB {
+			return -v, nil
+		}
+		return v, nil
+
+	case *ast.BinaryExpr:
+		l, err := evalExpr(x.X, fields)
+		if err != nil {
+			return 0, err
+		}
+		r, err := evalExpr(x.Y, fields)
+		if err != nil {
+			return 0, err
+		}
+
+		switch x.Op { //nolint:exhaustive
+		case token.ADD:
+			return l + r, nil
+		case token.SUB:
+			return l - r, nil
+		case token.MUL:
+			return l * r, nil
+		case token.QUO:
+			if r == 0 {
+				return 0, fmt.Errorf("division by zero")
+			}
+			return l / r, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unsupported expression %T", e)
+}
+
+// addDerivedFields compute derived fields and add them to fields, derived
+// fields failed to compute(missing field or division by zero) are ignored.
+func addDerivedFields(derived []derivedField, fields map[string]interface{}) {
+	for _, df := range derived {
+		v, err := evalExpr(df.expr, fields)
+		if err != nil {
+			log.Debugf("derived field %q ignored: %s", df.name, err)
+			continue
+		}
+		fields[df.name] = v
+	}
+}
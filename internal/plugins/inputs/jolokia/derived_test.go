@@ -0,0 +1,51 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package jolokia
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDerivedFields(t *testing.T) {
+	t.Run("compute", func(t *testing.T) {
+		derived, err := compileDerivedFields(map[string]string{
+			"heap_used_percent": "HeapMemoryUsage.used / HeapMemoryUsage.max * 100",
+			"heap_free":         "HeapMemoryUsage.max - HeapMemoryUsage.used",
+			"neg":               "-(ThreadCount + 1) * 2.5",
+			"missing":           "NoSuchField / 2",
+			"div_zero":          "ThreadCount / (HeapMemoryUsage.max - 400)",
+		})
+		require.NoError(t, err)
+
+		fields := map[string]interface{}{
+			"HeapMemoryUsage.used": int64(100),
+			"HeapMemoryUsage.max":  int64(400),
+			"ThreadCount":          3.0,
+		}
+		addDerivedFields(derived, fields)
+
+		assert.Equal(t, 25.0, fields["heap_used_percent"])
+		assert.Equal(t, 300.0, fields["heap_free"])
+		assert.Equal(t, -10.0, fields["neg"])
+		assert.NotContains(t, fields, "missing")
+		assert.NotContains(t, fields, "div_zero")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, expr := range []string{
+			"a % b",
+			`a + "x"`,
+			"f(a)",
+			"a +",
+		} {
+			_, err := compileDerivedFields(map[string]string{"x": expr})
+			assert.Error(t, err, expr)
+		}
+	})
+}
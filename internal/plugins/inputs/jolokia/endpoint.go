@@ -0,0 +1,149 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package jolokia
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf/plugins/common/tls"
+)
+
+// Endpoint is a Jolokia agent URL with its own auth, TLS and headers, such as
+// a hardened agent behind reverse proxy. Empty settings of the endpoint
+// fallback to the settings of the input.
+type Endpoint struct {
+	URL          string            `toml:"url"`
+	Username     string            `toml:"username"`
+	Password     string            `toml:"password"`
+	UsernameFile string            `toml:"username_file"`
+	PasswordFile string            `toml:"password_file"`
+	Headers      map[string]string `toml:"headers"`
+
+	tls.ClientConfig
+}
+
+func tlsConfigured(c *tls.ClientConfig) bool {
+	return c.TLSCA != "" || c.TLSCert != "" || c.TLSKey != "" || c.InsecureSkipVerify
+}
+
+// endpoints returns all URLs to collect with their settings.
+func (j *JolokiaAgent) endpoints() []*Endpoint {
+	res := make([]*Endpoint, 0, len(j.URLs)+len(j.Endpoints))
+
+	for _, u := range j.URLs {
+		res = append(res, &Endpoint{URL: u})
+	}
+
+	for i := range j.Endpoints {
+		ep := j.Endpoints[i]
+		res = append(res, &ep)
+	}
+
+	for _, ep := range res {
+		if ep.Username == "" && ep.Password == "" && ep.UsernameFile == "" && ep.PasswordFile == "" {
+			ep.Username, ep.Password = j.Username, j.Password
+			ep.UsernameFile, ep.PasswordFile = j.UsernameFile, j.PasswordFile
+		}
+
+		if !tlsConfigured(&ep.ClientConfig) {
+			ep.ClientConfig = j.ClientConfig
+		}
+
+		headers := map[string]string{}
+		for k, v := range j.Headers {
+			headers[k] = v
+		}
+		for k, v := range ep.Headers {
+			headers[k] = v
+		}
+		ep.Headers = headers
+	}
+
+	return res
+}
+
+// credentialFile is a file of username or password, reloaded once modified,
+// so that credentials can be rotated without restarting DataKit.
+type credentialFile struct {
+	path    string
+	modTime time.Time
+	value   string
+}
+
+func (f *credentialFile) load() string {
+	if f == nil || f.path == "" {
+		return ""
+	}
+
+	fi, err := os.Stat(f.path)
+	if err != nil {
+		log.Warnf("stat credential file %q: %s, use the last one", f.path, err)
+		return f.value
+	}
+
+	if !fi.ModTime().Equal(f.modTime) {
+		data, err := os.ReadFile(f.path) //nolint:gosec
+		if err != nil {
+			log.Warnf("read credential file %q: %s, use the last one", f.path, err)
+			return f.value
+		}
+
+		f.value = string(bytes.TrimSpace(data))
+		f.modTime = fi.ModTime()
+		log.Infof("credential file %q loaded", f.path)
+	}
+
+	return f.value
+}
+
+type credentials struct {
+	mu           sync.Mutex
+	usernameFile *credentialFile
+	passwordFile *credentialFile
+}
+
+func newCredentials(usernameFile, passwordFile string) *credentials {
+	return &credentials{
+		usernameFile: &credentialFile{path: usernameFile},
+		passwordFile: &credentialFile{path: passwordFile},
+	}
+}
+
+// basicAuth returns username and password of the client, these from files
+// take precedence over configured ones.
+func (c *Client) basicAuth() (string, string) {
+	username, password := c.config.Username, c.config.Password
+
+	if c.cred == nil {
+		return username, password
+	}
+
+	c.cred.mu.Lock()
+	defer c.cred.mu.Unlock()
+
+	if c.config.UsernameFile != "" {
+		username = c.cred.usernameFile.load()
+	}
+	if c.config.PasswordFile != "" {
+		password = c.cred.passwordFile.load()
+	}
+
+	return username, password
+}
+
+func (c *Client) setHeaders(req *http.Request) {
+	for k, v := range c.config.Headers {
+		if http.CanonicalHeaderKey(k) == "Host" {
+			req.Host = v
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+}
@@ -0,0 +1,97 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package jolokia
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/plugins/common/tls"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEndpoints(t *testing.T) {
+	j := &JolokiaAgent{
+		URLs:         []string{"http://a:8080/jolokia"},
+		Username:     "user",
+		Password:     "pass",
+		Headers:      map[string]string{"X-Env": "prod", "X-Token": "global"},
+		ClientConfig: tls.ClientConfig{TLSCA: "/ca.pem"},
+		Endpoints: []Endpoint{
+			{
+				URL:          "https://b:8443/jolokia",
+				PasswordFile: "/pass",
+				Headers:      map[string]string{"X-Token": "b"},
+				ClientConfig: tls.ClientConfig{InsecureSkipVerify: true},
+			},
+		},
+	}
+
+	eps := j.endpoints()
+	require.Len(t, eps, 2)
+
+	assert.Equal(t, "user", eps[0].Username)
+	assert.Equal(t, "/ca.pem", eps[0].TLSCA)
+	assert.Equal(t, map[string]string{"X-Env": "prod", "X-Token": "global"}, eps[0].Headers)
+
+	assert.Equal(t, "", eps[1].Username)
+	assert.Equal(t, "/pass", eps[1].PasswordFile)
+	assert.Equal(t, "", eps[1].TLSCA)
+	assert.True(t, eps[1].InsecureSkipVerify)
+	assert.Equal(t, map[string]string{"X-Env": "prod", "X-Token": "b"}, eps[1].Headers)
+
+	// configured endpoints not modified
+	assert.Equal(t, map[string]string{"X-Token": "b"}, j.Endpoints[0].Headers)
+}
+
+func TestCredentialFileReload(t *testing.T) {
+	dir := t.TempDir()
+	passFile := filepath.Join(dir, "password")
+	require.NoError(t, os.WriteFile(passFile, []byte("p1\n"), 0o600))
+
+	var auths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u, p, _ := r.BasicAuth()
+		auths = append(auths, u+":"+p+":"+r.Header.Get("X-Token"))
+		require.NoError(t, json.NewEncoder(w).Encode([]jolokiaResponse{}))
+	}))
+	defer srv.Close()
+
+	j := &JolokiaAgent{}
+	c, err := j.createClient(&Endpoint{
+		URL:          srv.URL + "/jolokia",
+		Username:     "user",
+		PasswordFile: passFile,
+		Headers:      map[string]string{"X-Token": "t"},
+	})
+	require.NoError(t, err)
+
+	requests := []ReadRequest{{Mbean: "java.lang:type=Runtime", Attributes: []string{"Uptime"}}}
+
+	_, err = c.read(requests)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(passFile, []byte("p2"), 0o600))
+	// make sure modify time changed
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(passFile, future, future))
+
+	_, err = c.read(requests)
+	require.NoError(t, err)
+
+	// keep the last password if file removed
+	require.NoError(t, os.Remove(passFile))
+	_, err = c.read(requests)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"user:p1:t", "user:p2:t", "user:p2:t"}, auths)
+}
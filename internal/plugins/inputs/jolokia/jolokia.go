@@ -52,12 +52,27 @@ type JolokiaAgent struct {
 	URLs            []string `toml:"urls"`
 	Username        string
 	Password        string
-	ResponseTimeout time.Duration `toml:"response_timeout"`
-	Interval        string        `toml:"interval"`
-	Election        bool          `toml:"election"`
+	UsernameFile    string            `toml:"username_file"`
+	PasswordFile    string            `toml:"password_file"`
+	Headers         map[string]string `toml:"headers"`
+	ResponseTimeout time.Duration     `toml:"response_timeout"`
+	Interval        string            `toml:"interval"`
+	Election        bool              `toml:"election"`
 
 	tls.ClientConfig
 
+	// Max number of mbean reads sent in one bulk POST, 0 means all in one POST.
+	MaxBulkRequests int `toml:"max_bulk_requests"`
+
+	// Proxy mode, URLs are Jolokia proxy agents and JVMs
+	// collected are configured as targets.
+	DefaultTargetUsername string              `toml:"default_target_username"`
+	DefaultTargetPassword string              `toml:"default_target_password"`
+	Targets               []ProxyTargetConfig `toml:"target"`
+
+	// URLs with their own auth, TLS and headers.
+	Endpoints []Endpoint `toml:"endpoint"`
+
 	Metrics  []MetricConfig `toml:"metric"`
 	gatherer *Gatherer
 	clients  []*Client
@@ -155,9 +170,10 @@ func (j *JolokiaAgent) Gather(ptTS int64) error {
 
 	// Initialize clients once
 	if j.clients == nil {
-		j.clients = make([]*Client, 0, len(j.URLs))
-		for _, url := range j.URLs {
-			client, err := j.createClient(url)
+		endpoints := j.endpoints()
+		j.clients = make([]*Client, 0, len(endpoints))
+		for _, ep := range endpoints {
+			client, err := j.createClient(ep)
 			if err != nil {
 				return err
 			}
@@ -213,13 +229,27 @@ func (j *JolokiaAgent) createMetrics() []Metric {
 	return metrics
 }
 
-func (j *JolokiaAgent) createClient(url string) (*Client, error) {
-	return NewClient(url, &ClientConfig{
-		Username:        j.Username,
-		Password:        j.Password,
+func (j *JolokiaAgent) createClient(ep *Endpoint) (*Client, error) {
+	conf := &ClientConfig{
+		Username:        ep.Username,
+		Password:        ep.Password,
+		UsernameFile:    ep.UsernameFile,
+		PasswordFile:    ep.PasswordFile,
+		Headers:         ep.Headers,
 		ResponseTimeout: j.ResponseTimeout,
-		ClientConfig:    j.ClientConfig,
-	})
+		MaxBulkRequests: j.MaxBulkRequests,
+		ClientConfig:    ep.ClientConfig,
+	}
+
+	if len(j.Targets) > 0 {
+		conf.ProxyConfig = &ProxyConfig{
+			DefaultTargetUsername: j.DefaultTargetUsername,
+			DefaultTargetPassword: j.DefaultTargetPassword,
+			Targets:               j.Targets,
+		}
+	}
+
+	return NewClient(ep.URL, conf)
 }
 
 func (j *JolokiaAgent) Adaptor() *JolokiaAgent {
@@ -246,7 +276,8 @@ func (j *JolokiaAgent) Adaptor() *JolokiaAgent {
 }
 
 func (j *JolokiaAgent) getKafkaVersionAndUptime(client *Client) (version string, uptimeSeconds int64, err error) {
-	requestURL, err := formatReadURL(client.URL, client.config.Username, client.config.Password)
+	username, password := client.basicAuth()
+	requestURL, err := formatReadURL(client.URL, username, password)
 	if err != nil {
 		return "", 0, fmt.Errorf("failed to format read URL: %w", err)
 	}
@@ -259,8 +290,9 @@ func (j *JolokiaAgent) getKafkaVersionAndUptime(client *Client) (version string,
 	if err != nil {
 		return "", 0, fmt.Errorf("failed to create request: %w", err)
 	}
-	req.SetBasicAuth(client.config.Username, client.config.Password)
+	req.SetBasicAuth(username, password)
 	req.Header.Add("Content-Type", "application/json")
+	client.setHeaders(req)
 
 	resp, err := client.client.Do(req)
 	if err != nil {
@@ -467,8 +499,7 @@ func (g *Gatherer) Gather(client *Client, j *JolokiaAgent, ptTS int64) error {
 		tags["jolokia_agent_url"] = client.URL
 	}
 
-	requests := makeReadRequests(g.metrics)
-	responses, err := client.read(requests)
+	responses, err := client.read(g.requests)
 	if err != nil {
 		return err
 	}
@@ -480,8 +511,11 @@ func (g *Gatherer) Gather(client *Client, j *JolokiaAgent, ptTS int64) error {
 // returned by a Jolokia agent.
 func (g *Gatherer) gatherResponses(responses []ReadResponse, tags map[string]string, j *JolokiaAgent, ptTS int64) error {
 	series := make(map[string][]jpoint)
+	derived := make(map[string][]derivedField)
 
 	for _, metric := range g.metrics {
+		derived[metric.Name] = append(derived[metric.Name], metric.derived...)
+
 		points, ok := series[metric.Name]
 		if !ok {
 			points = make([]jpoint, 0)
@@ -519,6 +553,8 @@ func (g *Gatherer) gatherResponses(responses []ReadResponse, tags map[string]str
 				continue
 			}
 
+			addDerivedFields(derived[measurement], field)
+
 			tag := mergeTags(point.Tags, tags)
 
 			var hostURL string
@@ -530,7 +566,7 @@ func (g *Gatherer) gatherResponses(responses []ReadResponse, tags map[string]str
 			if len(hostURL) > 0 {
 				if host, err := getHostFromURL(hostURL); err != nil {
 					return err
-				} else {
+				} else if host != "" { // no host in JMX service URL of proxy target
 					tag["host"] = host
 				}
 			}
@@ -783,6 +819,11 @@ type MetricConfig struct {
 	FieldSeparator *string  `toml:"field_separator"`
 	TagPrefix      *string  `toml:"tag_prefix"`
 	TagKeys        []string `toml:"tag_keys"`
+	AutoTagKeys    bool     `toml:"auto_tag_keys"`
+
+	// Fields computed from collected fields, such as
+	// heap_used_percent = "HeapMemoryUsage.used / HeapMemoryUsage.max * 100".
+	Derived map[string]string `toml:"derived"`
 }
 
 // A Metric represents a specification for a
@@ -797,17 +838,26 @@ type Metric struct {
 	FieldSeparator string
 	TagPrefix      string
 	TagKeys        []string
+	AutoTagKeys    bool
 
 	mbeanDomain     string
 	mbeanProperties []string
+
+	// key properties matched by wildcard in mbean pattern, all key properties
+	// not in the pattern are also wildcard ones if anyProperties is true.
+	wildcardKeys  map[string]bool
+	anyProperties bool
+
+	derived []derivedField
 }
 
 func NewMetric(config MetricConfig, defaultFieldPrefix, defaultFieldSeparator, defaultTagPrefix string) Metric {
 	metric := Metric{
-		Name:    config.Name,
-		Mbean:   config.Mbean,
-		Paths:   config.Paths,
-		TagKeys: config.TagKeys,
+		Name:        config.Name,
+		Mbean:       config.Mbean,
+		Paths:       config.Paths,
+		TagKeys:     config.TagKeys,
+		AutoTagKeys: config.AutoTagKeys,
 	}
 
 	if config.FieldName != nil {
@@ -835,10 +885,61 @@ func NewMetric(config MetricConfig, defaultFieldPrefix, defaultFieldSeparator, d
 	mbeanDomain, mbeanProperties := parseMbeanObjectName(config.Mbean)
 	metric.mbeanDomain = mbeanDomain
 	metric.mbeanProperties = mbeanProperties
+	metric.wildcardKeys, metric.anyProperties = parseWildcardKeys(mbeanProperties)
+
+	if derived, err := compileDerivedFields(config.Derived); err != nil {
+		log.Warnf("derived fields of metric %q ignored: %s", config.Name, err)
+	} else {
+		metric.derived = derived
+	}
 
 	return metric
 }
 
+// parseWildcardKeys find key properties with wildcard value in mbean pattern,
+// such as `name` in `Catalina:type=ThreadPool,name=*`, and whether the pattern
+// matches any other key properties, such as `Catalina:type=Servlet,*`.
+func parseWildcardKeys(properties []string) (map[string]bool, bool) {
+	keys := map[string]bool{}
+	anyProperties := false
+
+	for _, p := range properties {
+		pair := strings.SplitN(p, "=", 2)
+		if len(pair) != 2 {
+			if p == "*" {
+				anyProperties = true
+			}
+			continue
+		}
+
+		if strings.ContainsAny(pair[1], "*?") {
+			keys[pair[0]] = true
+		}
+	}
+
+	return keys, anyProperties
+}
+
+// isWildcardKey returns true if the key property of object name
+// is matched by wildcard of the mbean pattern.
+func (m Metric) isWildcardKey(key string) bool {
+	if m.wildcardKeys[key] {
+		return true
+	}
+
+	if !m.anyProperties {
+		return false
+	}
+
+	for _, p := range m.mbeanProperties {
+		if strings.HasPrefix(p, key+"=") {
+			return false
+		}
+	}
+
+	return true
+}
+
 func (m Metric) MatchObjectName(name string) bool {
 	if name == m.Mbean {
 		return true
@@ -923,7 +1024,7 @@ func newPointBuilder(metric Metric, attributes []string, path string) *pointBuil
 
 // Build generates a point for a given mbean name/pattern and value object.
 func (pb *pointBuilder) Build(mbean string, value interface{}) []jpoint {
-	hasPattern := strings.Contains(mbean, "*")
+	hasPattern := strings.ContainsAny(mbean, "*?")
 	if !hasPattern {
 		value = map[string]interface{}{mbean: value}
 	}
@@ -951,11 +1052,18 @@ func (pb *pointBuilder) extractTags(mbean string) map[string]string {
 	tagMap := make(map[string]string)
 
 	for key, value := range propertyMap {
-		if pb.includeTag(key) {
-			tagName := pb.formatTagName(key)
-			tagName = strings.ReplaceAll(tagName, "-", "_")
-			tagMap[tagName] = value
+		switch {
+		case pb.includeTag(key):
+		case pb.metric.AutoTagKeys && pb.metric.isWildcardKey(key):
+			// quoted key property like name="http-nio-8080"
+			value = strings.Trim(value, `"`)
+		default:
+			continue
 		}
+
+		tagName := pb.formatTagName(key)
+		tagName = strings.ReplaceAll(tagName, "-", "_")
+		tagMap[tagName] = value
 	}
 
 	return tagMap
@@ -1221,6 +1329,7 @@ type Client struct {
 	URL     string
 	client  *http.Client
 	config  *ClientConfig
+	cred    *credentials
 	upState int
 }
 
@@ -1228,6 +1337,10 @@ type ClientConfig struct {
 	ResponseTimeout time.Duration
 	Username        string
 	Password        string
+	UsernameFile    string
+	PasswordFile    string
+	Headers         map[string]string
+	MaxBulkRequests int
 	ProxyConfig     *ProxyConfig
 	tls.ClientConfig
 }
@@ -1239,9 +1352,9 @@ type ProxyConfig struct {
 }
 
 type ProxyTargetConfig struct {
-	Username string
-	Password string
-	URL      string
+	Username string `toml:"username"`
+	Password string `toml:"password"`
+	URL      string `toml:"url"`
 }
 
 type ReadRequest struct {
@@ -1316,21 +1429,53 @@ func NewClient(url string, config *ClientConfig) (*Client, error) {
 		Timeout:   config.ResponseTimeout,
 	}
 
-	return &Client{
+	c := &Client{
 		URL:    url,
 		config: config,
 		client: client,
-	}, nil
+	}
+
+	if config.UsernameFile != "" || config.PasswordFile != "" {
+		c.cred = newCredentials(config.UsernameFile, config.PasswordFile)
+	}
+
+	return c, nil
 }
 
+// read send all requests in bulk POSTs, at most MaxBulkRequests requests in each POST.
 func (c *Client) read(requests []ReadRequest) ([]ReadResponse, error) {
 	jRequests := makeJolokiaRequests(requests, c.config.ProxyConfig)
+
+	size := c.config.MaxBulkRequests
+	if size <= 0 {
+		size = len(jRequests)
+	}
+
+	var responses []ReadResponse
+	for start := 0; start < len(jRequests); start += size {
+		end := start + size
+		if end > len(jRequests) {
+			end = len(jRequests)
+		}
+
+		res, err := c.post(jRequests[start:end])
+		if err != nil {
+			return nil, err
+		}
+		responses = append(responses, res...)
+	}
+
+	return responses, nil
+}
+
+func (c *Client) post(jRequests []jolokiaRequest) ([]ReadResponse, error) {
 	requestBody, err := json.Marshal(jRequests)
 	if err != nil {
 		return nil, err
 	}
 	// kafka requestURL eg http://localhost:8080/jolokia/read
-	requestURL, err := formatReadURL(c.URL, c.config.Username, c.config.Password)
+	username, password := c.basicAuth()
+	requestURL, err := formatReadURL(c.URL, username, password)
 	if err != nil {
 		return nil, err
 	}
@@ -1342,6 +1487,7 @@ func (c *Client) read(requests []ReadRequest) ([]ReadResponse, error) {
 	}
 
 	req.Header.Add("Content-type", "application/json")
+	c.setHeaders(req)
 
 	resp, err := c.client.Do(req)
 	if err != nil {
@@ -0,0 +1,150 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package jolokia
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockAgent echo requests with value 1 and record requests of each POST.
+func mockAgent(t *testing.T, posts *[][]jolokiaRequest) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reqs []jolokiaRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&reqs))
+		*posts = append(*posts, reqs)
+
+		var resps []jolokiaResponse
+		for _, req := range reqs {
+			resps = append(resps, jolokiaResponse{Request: req, Value: 1, Status: 200})
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(resps))
+	}))
+}
+
+func TestClientRead(t *testing.T) {
+	requests := []ReadRequest{
+		{Mbean: "java.lang:type=Runtime", Attributes: []string{"Uptime"}},
+		{Mbean: "java.lang:type=Threading", Attributes: []string{"ThreadCount"}},
+		{Mbean: "java.lang:type=ClassLoading", Attributes: []string{"LoadedClassCount"}},
+	}
+
+	t.Run("bulk", func(t *testing.T) {
+		var posts [][]jolokiaRequest
+		srv := mockAgent(t, &posts)
+		defer srv.Close()
+
+		j := &JolokiaAgent{}
+		c, err := j.createClient(&Endpoint{URL: srv.URL + "/jolokia"})
+		require.NoError(t, err)
+
+		res, err := c.read(requests)
+		require.NoError(t, err)
+		assert.Len(t, res, 3)
+		assert.Len(t, posts, 1)
+	})
+
+	t.Run("max-bulk-requests", func(t *testing.T) {
+		var posts [][]jolokiaRequest
+		srv := mockAgent(t, &posts)
+		defer srv.Close()
+
+		j := &JolokiaAgent{MaxBulkRequests: 2}
+		c, err := j.createClient(&Endpoint{URL: srv.URL + "/jolokia"})
+		require.NoError(t, err)
+
+		res, err := c.read(requests)
+		require.NoError(t, err)
+		assert.Len(t, res, 3)
+		require.Len(t, posts, 2)
+		assert.Len(t, posts[0], 2)
+		assert.Len(t, posts[1], 1)
+	})
+
+	t.Run("proxy", func(t *testing.T) {
+		var posts [][]jolokiaRequest
+		srv := mockAgent(t, &posts)
+		defer srv.Close()
+
+		j := &JolokiaAgent{
+			DefaultTargetUsername: "admin",
+			Targets: []ProxyTargetConfig{
+				{URL: "service:jmx:rmi:///jndi/rmi://t1:9010/jmxrmi"},
+				{URL: "service:jmx:rmi:///jndi/rmi://t2:9010/jmxrmi", Username: "u2"},
+			},
+		}
+		c, err := j.createClient(&Endpoint{URL: srv.URL + "/jolokia"})
+		require.NoError(t, err)
+
+		res, err := c.read(requests)
+		require.NoError(t, err)
+		require.Len(t, res, 6)
+		require.Len(t, posts, 1)
+
+		assert.Equal(t, "admin", posts[0][0].Target.User)
+		assert.Equal(t, "u2", posts[0][5].Target.User)
+		assert.Equal(t, "service:jmx:rmi:///jndi/rmi://t2:9010/jmxrmi", res[5].RequestTarget)
+	})
+}
+
+func TestAutoTagKeys(t *testing.T) {
+	kafkaPrefix := "kafka_"
+
+	cases := []struct {
+		name   string
+		conf   MetricConfig
+		object string
+		tags   map[string]string
+	}{
+		{
+			name:   "wildcard-value",
+			conf:   MetricConfig{Mbean: "Catalina:type=ThreadPool,name=*", AutoTagKeys: true},
+			object: `Catalina:name="http-nio-8080",type=ThreadPool`,
+			tags:   map[string]string{"name": "http-nio-8080"},
+		},
+		{
+			name:   "wildcard-property-list",
+			conf:   MetricConfig{Mbean: "Catalina:j2eeType=Servlet,*", AutoTagKeys: true},
+			object: "Catalina:J2EEApplication=none,J2EEServer=none,WebModule=//localhost/,j2eeType=Servlet,name=jsp",
+			tags: map[string]string{
+				"J2EEApplication": "none",
+				"J2EEServer":      "none",
+				"WebModule":       "//localhost/",
+				"name":            "jsp",
+			},
+		},
+		{
+			name:   "with-tag-prefix",
+			conf:   MetricConfig{Mbean: "kafka.server:client-id=*,type=*", AutoTagKeys: true, TagPrefix: &kafkaPrefix},
+			object: "kafka.server:client-id=c1,type=Fetch",
+			tags:   map[string]string{"kafka_client_id": "c1", "kafka_type": "Fetch"},
+		},
+		{
+			name:   "disabled",
+			conf:   MetricConfig{Mbean: "Catalina:type=ThreadPool,name=*"},
+			object: `Catalina:name="http-nio-8080",type=ThreadPool`,
+			tags:   map[string]string{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := NewMetric(tc.conf, "", ".", "")
+			pts := newPointBuilder(m, []string{"currentThreadsBusy"}, "").
+				Build(m.Mbean, map[string]interface{}{tc.object: map[string]interface{}{"currentThreadsBusy": 1}})
+
+			require.Len(t, pts, 1)
+			assert.Equal(t, tc.tags, pts[0].Tags)
+		})
+	}
+}
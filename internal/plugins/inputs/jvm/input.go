@@ -30,6 +30,11 @@ const (
 
   # username = ""
   # password = ""
+  ## Read username/password from files instead, reloaded once the files changed.
+  # username_file = "/path/to/username"
+  # password_file = "/path/to/password"
+  ## Extra HTTP headers, such as token required by reverse proxy.
+  # headers = { "X-Token" = "<TOKEN>" }
   # response_timeout = "5s"
 
   ## Optional TLS config
@@ -44,6 +49,24 @@ const (
   # Add agents URLs to query
   urls = ["http://localhost:8080/jolokia"]
 
+  ## Max number of mbean reads in one bulk POST to each URL, 0 means all in one POST.
+  # max_bulk_requests = 0
+
+  ## Proxy mode: urls are Jolokia proxy agents, and JVMs collected through them are set as targets.
+  # default_target_username = ""
+  # default_target_password = ""
+  # [[inputs.jvm.target]]
+  #   url      = "service:jmx:rmi:///jndi/rmi://targethost:9999/jmxrmi"
+  #   username = ""
+  #   password = ""
+
+  ## URLs with their own auth, TLS and headers, settings not set are the same as above.
+  # [[inputs.jvm.endpoint]]
+  #   url           = "https://jolokia.example.com/jolokia"
+  #   password_file = "/path/to/password"
+  #   tls_ca        = "/var/private/ca.pem"
+  #   headers       = { "X-Token" = "<TOKEN>" }
+
   ## Add metrics to read
   [[inputs.jvm.metric]]
     name  = "java_runtime"
@@ -54,6 +77,9 @@ const (
     name  = "java_memory"
     mbean = "java.lang:type=Memory"
     paths = ["HeapMemoryUsage", "NonHeapMemoryUsage", "ObjectPendingFinalizationCount"]
+    ## Fields computed from the collected ones, only + - * / allowed.
+    # [inputs.jvm.metric.derived]
+    #   heap_used_percent = "HeapMemoryUsageused / HeapMemoryUsagemax * 100"
 
   [[inputs.jvm.metric]]
     name     = "java_garbage_collector"
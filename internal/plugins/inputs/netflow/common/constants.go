@@ -19,6 +19,10 @@ const (
 	// DefaultAggregatorFlushInterval is the default flush interval in seconds.
 	DefaultAggregatorFlushInterval = 300 // 5min
 
+	// MinAggregatorFlushInterval is the min flush interval in seconds, limited by
+	// the interval of checking flows to flush.
+	MinAggregatorFlushInterval = 10
+
 	// DefaultAggregatorBufferSize is the default aggregator buffer size interval.
 	DefaultAggregatorBufferSize = 10000
 
@@ -0,0 +1,54 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package common
+
+import "time"
+
+// InterfaceCounters contains generic interface counters (RFC 3176) of sFlow
+// counter samples, counters are cumulative.
+type InterfaceCounters struct {
+	Namespace    string
+	AgentAddr    []byte
+	SubAgentID   uint32
+	TimeReceived time.Time
+
+	IfIndex     uint32
+	IfType      uint32
+	IfSpeed     uint64 // in bits per second
+	IfDirection uint32
+	IfStatus    uint32 // bit 0: admin status, bit 1: oper status
+
+	InOctets        uint64
+	InUcastPkts     uint32
+	InMulticastPkts uint32
+	InBroadcastPkts uint32
+	InDiscards      uint32
+	InErrors        uint32
+	InUnknownProtos uint32
+
+	OutOctets        uint64
+	OutUcastPkts     uint32
+	OutMulticastPkts uint32
+	OutBroadcastPkts uint32
+	OutDiscards      uint32
+	OutErrors        uint32
+}
+
+// AdminStatus returns admin status in ifStatus.
+func (c *InterfaceCounters) AdminStatus() IfAdminStatus {
+	if c.IfStatus&1 != 0 {
+		return AdminStatus_Up
+	}
+	return AdminStatus_Down
+}
+
+// OperStatus returns oper status in ifStatus.
+func (c *InterfaceCounters) OperStatus() IfOperStatus {
+	if c.IfStatus&2 != 0 {
+		return OperStatus_Up
+	}
+	return OperStatus_Down
+}
@@ -0,0 +1,112 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package common
+
+import "sync"
+
+// ExporterMetadata contains metadata of exporters sent by NetFlow v9/IPFIX
+// options data records or polled by SNMP, such as interface names and
+// application names.
+type ExporterMetadata struct {
+	mu        sync.RWMutex
+	exporters map[string]*exporterMetadata // exporter address -> metadata
+}
+
+type exporterMetadata struct {
+	interfaces   map[uint32]string // ifIndex -> ifName
+	aliases      map[uint32]string // ifIndex -> ifAlias
+	applications map[string]string // application ID -> application name
+}
+
+// NewExporterMetadata returns an empty ExporterMetadata.
+func NewExporterMetadata() *ExporterMetadata {
+	return &ExporterMetadata{
+		exporters: make(map[string]*exporterMetadata),
+	}
+}
+
+// must be called with mu locked.
+func (m *ExporterMetadata) exporter(addr []byte) *exporterMetadata {
+	e, ok := m.exporters[string(addr)]
+	if !ok {
+		e = &exporterMetadata{
+			interfaces:   make(map[uint32]string),
+			aliases:      make(map[uint32]string),
+			applications: make(map[string]string),
+		}
+		m.exporters[string(addr)] = e
+	}
+	return e
+}
+
+// SetInterfaceName sets name of the interface of the exporter.
+func (m *ExporterMetadata) SetInterfaceName(exporterAddr []byte, index uint32, name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.exporter(exporterAddr).interfaces[index] = name
+}
+
+// InterfaceName returns name of the interface of the exporter, empty if unknown.
+func (m *ExporterMetadata) InterfaceName(exporterAddr []byte, index uint32) string {
+	if m == nil {
+		return ""
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if e, ok := m.exporters[string(exporterAddr)]; ok {
+		return e.interfaces[index]
+	}
+	return ""
+}
+
+// SetInterfaceAlias sets alias(ifAlias) of the interface of the exporter.
+func (m *ExporterMetadata) SetInterfaceAlias(exporterAddr []byte, index uint32, alias string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.exporter(exporterAddr).aliases[index] = alias
+}
+
+// InterfaceAlias returns alias of the interface of the exporter, empty if unknown.
+func (m *ExporterMetadata) InterfaceAlias(exporterAddr []byte, index uint32) string {
+	if m == nil {
+		return ""
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if e, ok := m.exporters[string(exporterAddr)]; ok {
+		return e.aliases[index]
+	}
+	return ""
+}
+
+// SetApplicationName sets name of the application of the exporter.
+func (m *ExporterMetadata) SetApplicationName(exporterAddr []byte, appID, name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.exporter(exporterAddr).applications[appID] = name
+}
+
+// ApplicationName returns name of the application of the exporter, empty if unknown.
+func (m *ExporterMetadata) ApplicationName(exporterAddr []byte, appID string) string {
+	if m == nil {
+		return ""
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if e, ok := m.exporters[string(exporterAddr)]; ok {
+		return e.applications[appID]
+	}
+	return ""
+}
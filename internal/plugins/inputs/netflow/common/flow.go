@@ -29,10 +29,15 @@ type Flow struct {
 	StartTimestamp uint64 // in seconds
 	EndTimestamp   uint64 // in seconds
 
-	// Size of the sampled packet
+	// Size of the sampled packet, scaled by the sampling rate if sampled
+	// flows extrapolated
 	Bytes   uint64
 	Packets uint64
 
+	// Size of the sampled packet as exported, not scaled
+	RawBytes   uint64
+	RawPackets uint64
+
 	// Source/destination addresses
 	SrcAddr []byte // FLOW KEY
 	DstAddr []byte // FLOW KEY
@@ -46,6 +51,12 @@ type Flow struct {
 	// Flags
 	TCPFlags uint32 `json:"tcp_flags"`
 
+	// Number of raw flows aggregated, and TCP flows of them with SYN only or
+	// RST flag, counted when flows added to the accumulator
+	FlowCount         uint64
+	TCPSYNOnlyFlowCnt uint64
+	TCPRSTFlowCnt     uint64
+
 	// Ports for UDP and TCP
 	// Port number can be zero/positive or `-1` (ephemeral port)
 	SrcPort int32 // FLOW KEY
@@ -67,6 +78,61 @@ type Flow struct {
 	Tos uint32 // FLOW KEY
 
 	NextHop []byte // FLOW KEY
+
+	// Application ID of IPFIX/NetFlow v9, classification engine ID followed by selector ID
+	ApplicationID []byte // FLOW KEY
+
+	// GeoIP/ASN of source/destination addresses, nil if GeoIP not enabled or not found
+	SrcGeo *GeoInfo
+	DstGeo *GeoInfo
+
+	// Tunnel and inner header of encapsulated flows like VXLAN and GRE, from
+	// template fields of IPFIX or sampled headers of sFlow
+	TunnelType      TunnelType // FLOW KEY
+	TunnelID        uint64     // FLOW KEY, VNI of VXLAN, VSID of NVGRE or key of GRE
+	InnerSrcAddr    []byte     // FLOW KEY
+	InnerDstAddr    []byte     // FLOW KEY
+	InnerIPProtocol uint32     // FLOW KEY
+	InnerSrcPort    int32      // FLOW KEY
+	InnerDstPort    int32      // FLOW KEY
+}
+
+// Encapsulated returns true if the flow comes with inner header.
+func (f *Flow) Encapsulated() bool {
+	return f.TunnelType != TunnelNone || len(f.InnerSrcAddr) > 0 || len(f.InnerDstAddr) > 0
+}
+
+// TunnelType is the type of encapsulation, values same as segment types of
+// IPFIX layer2SegmentId.
+type TunnelType uint8
+
+const (
+	TunnelNone  TunnelType = 0
+	TunnelVXLAN TunnelType = 1
+	TunnelNVGRE TunnelType = 2
+	TunnelGRE   TunnelType = 0x80 // not defined by layer2SegmentId
+)
+
+// String returns name of the tunnel type, empty if unknown.
+func (t TunnelType) String() string {
+	switch t {
+	case TunnelVXLAN:
+		return "vxlan"
+	case TunnelNVGRE:
+		return "nvgre"
+	case TunnelGRE:
+		return "gre"
+	default:
+		return ""
+	}
+}
+
+// GeoInfo contains geography and ASN of an IP address.
+type GeoInfo struct {
+	Country string
+	City    string
+	ASN     uint32
+	Org     string // organization of the ASN
 }
 
 // AggregationHash return a hash used as aggregation key.
@@ -81,6 +147,16 @@ func (f *Flow) AggregationHash() uint64 {
 	binary.Write(h, binary.LittleEndian, f.IPProtocol)     //nolint:errcheck,gosec
 	binary.Write(h, binary.LittleEndian, f.Tos)            //nolint:errcheck,gosec
 	binary.Write(h, binary.LittleEndian, f.InputInterface) //nolint:errcheck,gosec
+	h.Write(f.ApplicationID)                               //nolint:errcheck,gosec
+	if f.Encapsulated() {
+		binary.Write(h, binary.LittleEndian, f.TunnelType)      //nolint:errcheck,gosec
+		binary.Write(h, binary.LittleEndian, f.TunnelID)        //nolint:errcheck,gosec
+		h.Write(f.InnerSrcAddr)                                 //nolint:errcheck,gosec
+		h.Write(f.InnerDstAddr)                                 //nolint:errcheck,gosec
+		binary.Write(h, binary.LittleEndian, f.InnerIPProtocol) //nolint:errcheck,gosec
+		binary.Write(h, binary.LittleEndian, f.InnerSrcPort)    //nolint:errcheck,gosec
+		binary.Write(h, binary.LittleEndian, f.InnerDstPort)    //nolint:errcheck,gosec
+	}
 	return h.Sum64()
 }
 
@@ -95,7 +171,15 @@ func IsEqualFlowContext(a Flow, b Flow) bool {
 		a.DstPort == b.DstPort &&
 		a.IPProtocol == b.IPProtocol &&
 		a.Tos == b.Tos &&
-		a.InputInterface == b.InputInterface {
+		a.InputInterface == b.InputInterface &&
+		bytes.Equal(a.ApplicationID, b.ApplicationID) &&
+		a.TunnelType == b.TunnelType &&
+		a.TunnelID == b.TunnelID &&
+		bytes.Equal(a.InnerSrcAddr, b.InnerSrcAddr) &&
+		bytes.Equal(a.InnerDstAddr, b.InnerDstAddr) &&
+		a.InnerIPProtocol == b.InnerIPProtocol &&
+		a.InnerSrcPort == b.InnerSrcPort &&
+		a.InnerDstPort == b.InnerDstPort {
 		return true
 	}
 	return false
@@ -74,6 +74,16 @@ func TestFlow_AggregationHash(t *testing.T) {
 	assert.NotEqual(t, origHash, flow.AggregationHash())
 	allHash[flow.AggregationHash()] = true
 
+	flow = origFlow
+	flow.TunnelType, flow.TunnelID = TunnelVXLAN, 100
+	flow.InnerSrcAddr, flow.InnerDstAddr = []byte{10, 0, 0, 1}, []byte{10, 0, 0, 2}
+	assert.NotEqual(t, origHash, flow.AggregationHash())
+	allHash[flow.AggregationHash()] = true
+
+	flow.InnerDstAddr = []byte{10, 0, 0, 3}
+	assert.NotEqual(t, origHash, flow.AggregationHash())
+	allHash[flow.AggregationHash()] = true
+
 	// OutputInterface is not a key field, changing it should not change the hash
 	flow = origFlow
 	flow.OutputInterface = 1
@@ -87,7 +97,7 @@ func TestFlow_AggregationHash(t *testing.T) {
 	allHash[flow.AggregationHash()] = true
 
 	// Should contain expected number of different hashes
-	assert.Equal(t, 10, len(allHash))
+	assert.Equal(t, 12, len(allHash))
 }
 
 func TestFlow_IsEqualFlowContext(t *testing.T) {
@@ -139,6 +149,10 @@ func TestFlow_IsEqualFlowContext(t *testing.T) {
 	flow.IPProtocol = 7
 	assert.False(t, IsEqualFlowContext(origFlow, flow))
 
+	flow = origFlow
+	flow.TunnelType, flow.InnerSrcAddr = TunnelGRE, []byte{10, 0, 0, 1}
+	assert.False(t, IsEqualFlowContext(origFlow, flow))
+
 	flow = origFlow
 	flow.SrcPort = 2001
 	assert.False(t, IsEqualFlowContext(origFlow, flow))
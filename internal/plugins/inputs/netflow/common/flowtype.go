@@ -8,6 +8,7 @@ package common
 
 import (
 	"fmt"
+	"time"
 )
 
 // FlowType represent the flow protocol (netflow5,netflow9,ipfix, sflow, etc).
@@ -77,6 +78,83 @@ func GetAllFlowTypes() []FlowType {
 }
 
 type FlowOpt struct {
-	Type FlowType `toml:"flow_type,omitempty"`
-	Port uint16   `toml:"port,omitempty"`
+	Type  FlowType  `toml:"flow_type,omitempty"`
+	Port  uint16    `toml:"port,omitempty"`
+	GeoIP *GeoIPOpt `toml:"geoip,omitempty"`
+}
+
+// GeoIPOpt configures GeoIP/ASN enrichment of flows of the listener.
+type GeoIPOpt struct {
+	Enabled bool `toml:"enabled"`
+
+	// MaxMind GeoIP2/GeoLite2 database files, the IP database of DataKit used
+	// if CityDB not set.
+	CityDB string `toml:"city_db,omitempty"`
+	ASNDB  string `toml:"asn_db,omitempty"`
+}
+
+// AggregationOpt configures rollup of flows within the aggregation window,
+// flows with the same key after rollup are merged into one.
+type AggregationOpt struct {
+	// Window is the interval flows aggregated and sent.
+	Window time.Duration `toml:"window"`
+
+	// Prefix lengths that source/destination addresses masked to, 0 to keep the full address.
+	SrcPrefixLen   int `toml:"src_prefix_len"`
+	DstPrefixLen   int `toml:"dst_prefix_len"`
+	SrcPrefixLenV6 int `toml:"src_prefix_len_v6"`
+	DstPrefixLenV6 int `toml:"dst_prefix_len_v6"`
+
+	// Remove ports and the IP protocol from the key, shown as `*`.
+	IgnoreSrcPort  bool `toml:"ignore_src_port"`
+	IgnoreDstPort  bool `toml:"ignore_dst_port"`
+	IgnoreProtocol bool `toml:"ignore_protocol"`
+
+	// TopN keeps the N flows with most bytes of each window, 0 to keep all.
+	TopN int `toml:"top_n"`
+}
+
+// RolledUp returns true if any of the key of flows rolled up.
+func (opt *AggregationOpt) RolledUp() bool {
+	return opt.SrcPrefixLen > 0 || opt.DstPrefixLen > 0 ||
+		opt.SrcPrefixLenV6 > 0 || opt.DstPrefixLenV6 > 0 ||
+		opt.IgnoreSrcPort || opt.IgnoreDstPort || opt.IgnoreProtocol
+}
+
+// SNMPOpt configures polling interface names of exporters by SNMP.
+type SNMPOpt struct {
+	Enabled bool `toml:"enabled"`
+
+	// Interval of polling ifTable/ifXTable of each exporter.
+	Interval time.Duration `toml:"interval"`
+	Port     uint16        `toml:"port"`
+
+	// SNMP v1/v2c
+	Version   uint8  `toml:"snmp_version"`
+	Community string `toml:"community"`
+
+	// SNMP v3
+	User         string `toml:"v3_user"`
+	AuthProtocol string `toml:"v3_auth_protocol"`
+	AuthKey      string `toml:"v3_auth_key"`
+	PrivProtocol string `toml:"v3_priv_protocol"`
+	PrivKey      string `toml:"v3_priv_key"`
+	ContextName  string `toml:"v3_context_name"`
+}
+
+// ThreatOpt configures threat indicators detected from flows.
+type ThreatOpt struct {
+	Enabled bool `toml:"enabled"`
+
+	// Window is the interval distinct ports/hosts of each source counted.
+	Window time.Duration `toml:"window"`
+
+	// Sources contacting more distinct destination ports/hosts than the
+	// thresholds within the window are reported, 0 to disable.
+	PortScanThreshold int `toml:"port_scan_threshold"`
+	HostScanThreshold int `toml:"host_scan_threshold"`
+
+	// Blocklist is the file of blocked IPs/CIDRs, one per line, reloaded
+	// once modified.
+	Blocklist string `toml:"blocklist"`
 }
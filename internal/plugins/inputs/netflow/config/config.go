@@ -9,6 +9,8 @@ package config
 
 import (
 	"fmt"
+	"path/filepath"
+	"time"
 
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/dkstring"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/common"
@@ -24,6 +26,14 @@ type NetflowConfig struct {
 	AggregatorPortRollupThreshold int
 	AggregatorPortRollupDisabled  bool
 
+	// AggregatorRollup configures rollup and top N of flows within the flush
+	// interval, nil if flows aggregated by flow context only.
+	AggregatorRollup *common.AggregationOpt
+
+	// ExtrapolateSampled scales bytes/packets of sampled flows by the sampling
+	// rate, raw values of flows sent if false.
+	ExtrapolateSampled bool
+
 	// AggregatorRollupTrackerRefreshInterval is useful to speed up testing to avoid wait for 1h default
 	AggregatorRollupTrackerRefreshInterval uint
 
@@ -38,19 +48,29 @@ type ListenerConfig struct {
 	BindHost  string
 	Workers   int
 	Namespace string
+	GeoIP     *common.GeoIPOpt
 }
 
 // ReadConfig builds and returns configuration from Agent configuration.
 //
 //nolint:lll
-func ReadConfig(flows []*common.FlowOpt, namespace string) (*NetflowConfig, error) {
+func ReadConfig(flows []*common.FlowOpt, namespace string, aggregation *common.AggregationOpt) (*NetflowConfig, error) {
 	var mainConfig NetflowConfig
 
+	if aggregation != nil {
+		if err := checkAggregation(aggregation); err != nil {
+			return nil, err
+		}
+		mainConfig.AggregatorFlushInterval = int(aggregation.Window / time.Second)
+		mainConfig.AggregatorRollup = aggregation
+	}
+
 	for _, flow := range flows {
 		mainConfig.Listeners = append(mainConfig.Listeners, ListenerConfig{
 			FlowType:  flow.Type,
 			Port:      flow.Port,
 			Namespace: namespace,
+			GeoIP:     flow.GeoIP,
 		})
 	}
 
@@ -112,6 +132,36 @@ func ReadConfig(flows []*common.FlowOpt, namespace string) (*NetflowConfig, erro
 	return &mainConfig, nil
 }
 
+func checkAggregation(opt *common.AggregationOpt) error {
+	if opt.Window < common.MinAggregatorFlushInterval*time.Second {
+		return fmt.Errorf("aggregation window `%s` less than %ds", opt.Window, common.MinAggregatorFlushInterval)
+	}
+
+	for _, prefix := range []struct {
+		name      string
+		len, bits int
+	}{
+		{"src_prefix_len", opt.SrcPrefixLen, 32},
+		{"dst_prefix_len", opt.DstPrefixLen, 32},
+		{"src_prefix_len_v6", opt.SrcPrefixLenV6, 128},
+		{"dst_prefix_len_v6", opt.DstPrefixLenV6, 128},
+	} {
+		if prefix.len < 0 || prefix.len > prefix.bits {
+			return fmt.Errorf("invalid aggregation %s `%d`, should be in [0, %d]", prefix.name, prefix.len, prefix.bits)
+		}
+	}
+
+	if opt.TopN < 0 {
+		return fmt.Errorf("invalid aggregation top_n `%d`", opt.TopN)
+	}
+	return nil
+}
+
+// TemplateFile returns file in dir that templates of the listener persisted to.
+func (c *ListenerConfig) TemplateFile(dir string) string {
+	return filepath.Join(dir, fmt.Sprintf("templates_%s_%d.json", c.FlowType, c.Port))
+}
+
 // Addr returns the host:port address to listen on.
 func (c *ListenerConfig) Addr() string {
 	return fmt.Sprintf("%s:%d", c.BindHost, c.Port)
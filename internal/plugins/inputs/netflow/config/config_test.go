@@ -8,6 +8,7 @@ package config
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/common"
@@ -21,3 +22,29 @@ func TestListenerConfig_Addr(t *testing.T) {
 	}
 	assert.Equal(t, "127.0.0.1:1234", listenerConfig.Addr())
 }
+
+func TestReadConfig_aggregation(t *testing.T) {
+	flows := []*common.FlowOpt{{Type: common.TypeNetFlow9}}
+
+	cfg, err := ReadConfig(flows, "", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, common.DefaultAggregatorFlushInterval, cfg.AggregatorFlushInterval)
+	assert.Nil(t, cfg.AggregatorRollup)
+
+	aggregation := &common.AggregationOpt{Window: time.Minute, SrcPrefixLen: 24, TopN: 100}
+	cfg, err = ReadConfig(flows, "", aggregation)
+	assert.NoError(t, err)
+	assert.Equal(t, 60, cfg.AggregatorFlushInterval)
+	assert.Equal(t, 60, cfg.AggregatorFlowContextTTL)
+	assert.Equal(t, aggregation, cfg.AggregatorRollup)
+
+	for _, opt := range []*common.AggregationOpt{
+		{Window: time.Second},
+		{Window: time.Minute, SrcPrefixLen: 33},
+		{Window: time.Minute, DstPrefixLenV6: 129},
+		{Window: time.Minute, TopN: -1},
+	} {
+		_, err = ReadConfig(flows, "", opt)
+		assert.Error(t, err, "%+v", opt)
+	}
+}
@@ -0,0 +1,31 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package enrichment
+
+import (
+	"encoding/hex"
+	"strconv"
+)
+
+// FormatApplicationID formats IPFIX applicationId (RFC 6759) into
+// `<classification engine ID>:<selector ID>` format (e.g. `3:80`), selector longer
+// than 8 bytes is hex encoded.
+func FormatApplicationID(appID []byte) string {
+	if len(appID) == 0 {
+		return ""
+	}
+
+	engine, selector := appID[0], appID[1:]
+	if len(selector) > 8 {
+		return strconv.Itoa(int(engine)) + ":" + hex.EncodeToString(selector)
+	}
+
+	var id uint64
+	for _, b := range selector {
+		id = id<<8 | uint64(b)
+	}
+	return strconv.Itoa(int(engine)) + ":" + strconv.FormatUint(id, 10)
+}
@@ -0,0 +1,20 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package enrichment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatApplicationID(t *testing.T) {
+	assert.Equal(t, "", FormatApplicationID(nil))
+	assert.Equal(t, "3:0", FormatApplicationID([]byte{3}))
+	assert.Equal(t, "3:80", FormatApplicationID([]byte{3, 0, 80}))
+	assert.Equal(t, "13:453", FormatApplicationID([]byte{13, 0, 0, 1, 197}))
+	assert.Equal(t, "20:0102030405060708090a", FormatApplicationID([]byte{20, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10}))
+}
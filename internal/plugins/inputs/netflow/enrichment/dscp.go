@@ -0,0 +1,48 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package enrichment
+
+import "strconv"
+
+var dscpClassMap = map[uint32]string{
+	0:  "BE",
+	8:  "CS1",
+	10: "AF11",
+	12: "AF12",
+	14: "AF13",
+	16: "CS2",
+	18: "AF21",
+	20: "AF22",
+	22: "AF23",
+	24: "CS3",
+	26: "AF31",
+	28: "AF32",
+	30: "AF33",
+	32: "CS4",
+	34: "AF41",
+	36: "AF42",
+	38: "AF43",
+	40: "CS5",
+	44: "VA",
+	46: "EF",
+	48: "CS6",
+	56: "CS7",
+}
+
+// TosToDSCP returns DSCP of the ToS byte, the upper 6 bits.
+func TosToDSCP(tos uint32) uint32 {
+	return (tos & 0xff) >> 2
+}
+
+// MapDSCPClass map DSCP to class name like `EF` and `AF41`,
+// or the DSCP number for DSCP not standardized.
+// https://www.iana.org/assignments/dscp-registry/dscp-registry.xhtml
+func MapDSCPClass(dscp uint32) string {
+	if class, ok := dscpClassMap[dscp]; ok {
+		return class
+	}
+	return strconv.FormatUint(uint64(dscp), 10)
+}
@@ -0,0 +1,28 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package enrichment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTosToDSCP(t *testing.T) {
+	assert.Equal(t, uint32(0), TosToDSCP(0))
+	assert.Equal(t, uint32(46), TosToDSCP(0xb8))
+	assert.Equal(t, uint32(46), TosToDSCP(0xbb)) // ECN bits ignored
+	assert.Equal(t, uint32(34), TosToDSCP(0x88))
+}
+
+func TestMapDSCPClass(t *testing.T) {
+	assert.Equal(t, "BE", MapDSCPClass(0))
+	assert.Equal(t, "CS1", MapDSCPClass(8))
+	assert.Equal(t, "AF41", MapDSCPClass(34))
+	assert.Equal(t, "EF", MapDSCPClass(46))
+	assert.Equal(t, "CS7", MapDSCPClass(56))
+	assert.Equal(t, "1", MapDSCPClass(1))
+}
@@ -0,0 +1,150 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package enrichment
+
+import (
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/oschwald/geoip2-golang"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline/plval"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/common"
+)
+
+// geoIPCacheSize is the max number of IP addresses cached, cache is cleared
+// once it's full.
+const geoIPCacheSize = 10000
+
+// GeoIP looks up geography and ASN of IP addresses, by MaxMind databases or
+// the IP database of DataKit.
+type GeoIP struct {
+	city func(ip net.IP) (country, city string)
+	asn  func(ip net.IP) (asn uint32, org string)
+
+	// the databases are mmapped, lookups hold the read lock so Close() does
+	// not unmap them while any lookup running.
+	dbMu    sync.RWMutex
+	closed  bool
+	closers []func() error
+
+	mu    sync.Mutex
+	cache map[string]*common.GeoInfo
+}
+
+// NewGeoIP opens the MaxMind databases, the IP database of DataKit used if cityDB is empty.
+func NewGeoIP(cityDB, asnDB string) (*GeoIP, error) {
+	g := &GeoIP{cache: make(map[string]*common.GeoInfo)}
+
+	if cityDB == "" {
+		g.city = builtinCity
+	} else {
+		db, err := geoip2.Open(cityDB)
+		if err != nil {
+			return nil, fmt.Errorf("open GeoIP city database %q: %w", cityDB, err)
+		}
+		g.closers = append(g.closers, db.Close)
+		g.city = func(ip net.IP) (string, string) {
+			r, err := db.City(ip)
+			if err != nil || r == nil {
+				return "", ""
+			}
+			return r.Country.IsoCode, r.City.Names["en"]
+		}
+	}
+
+	if asnDB != "" {
+		db, err := geoip2.Open(asnDB)
+		if err != nil {
+			g.Close()
+			return nil, fmt.Errorf("open GeoIP ASN database %q: %w", asnDB, err)
+		}
+		g.closers = append(g.closers, db.Close)
+		g.asn = func(ip net.IP) (uint32, string) {
+			r, err := db.ASN(ip)
+			if err != nil || r == nil {
+				return 0, ""
+			}
+			return uint32(r.AutonomousSystemNumber), r.AutonomousSystemOrganization
+		}
+	}
+
+	return g, nil
+}
+
+func builtinCity(ip net.IP) (string, string) {
+	r, err := plval.Geo(ip.String())
+	if err != nil || r == nil {
+		return "", ""
+	}
+	return r.Country, r.City
+}
+
+// Lookup returns GeoIP/ASN of the IP address, nil if not found or it's not
+// a public address.
+func (g *GeoIP) Lookup(addr []byte) *common.GeoInfo {
+	if g == nil {
+		return nil
+	}
+
+	ip := net.IP(addr)
+	if len(addr) != net.IPv4len && len(addr) != net.IPv6len ||
+		ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return nil
+	}
+
+	g.mu.Lock()
+	info, ok := g.cache[string(addr)]
+	g.mu.Unlock()
+	if ok {
+		return info
+	}
+
+	g.dbMu.RLock()
+	if g.closed {
+		g.dbMu.RUnlock()
+		return nil
+	}
+
+	info = &common.GeoInfo{}
+	if g.city != nil {
+		info.Country, info.City = g.city(ip)
+	}
+	if g.asn != nil {
+		info.ASN, info.Org = g.asn(ip)
+	}
+	g.dbMu.RUnlock()
+
+	if *info == (common.GeoInfo{}) {
+		info = nil
+	}
+
+	g.mu.Lock()
+	if len(g.cache) >= geoIPCacheSize {
+		g.cache = make(map[string]*common.GeoInfo)
+	}
+	g.cache[string(addr)] = info
+	g.mu.Unlock()
+
+	return info
+}
+
+// Close closes the MaxMind databases after the running lookups done, the
+// lookups after closed return nil.
+func (g *GeoIP) Close() {
+	if g == nil {
+		return
+	}
+
+	g.dbMu.Lock()
+	defer g.dbMu.Unlock()
+
+	g.closed = true
+	for _, c := range g.closers {
+		c() //nolint:errcheck,gosec
+	}
+	g.closers = nil
+}
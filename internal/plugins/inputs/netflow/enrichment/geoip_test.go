@@ -0,0 +1,85 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package enrichment
+
+import (
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/common"
+)
+
+func TestGeoIPLookup(t *testing.T) {
+	lookups := 0
+	g := &GeoIP{
+		cache: make(map[string]*common.GeoInfo),
+		city: func(ip net.IP) (string, string) {
+			lookups++
+			if ip.Equal(net.IPv4(8, 8, 8, 8)) {
+				return "US", "Mountain View"
+			}
+			return "", ""
+		},
+		asn: func(ip net.IP) (uint32, string) {
+			if ip.Equal(net.IPv4(8, 8, 8, 8)) {
+				return 15169, "GOOGLE"
+			}
+			return 0, ""
+		},
+	}
+
+	t.Run("public", func(t *testing.T) {
+		expected := &common.GeoInfo{Country: "US", City: "Mountain View", ASN: 15169, Org: "GOOGLE"}
+		assert.Equal(t, expected, g.Lookup([]byte{8, 8, 8, 8}))
+		assert.Equal(t, expected, g.Lookup([]byte{8, 8, 8, 8}))
+		assert.Equal(t, 1, lookups, "cached")
+	})
+
+	t.Run("not-found", func(t *testing.T) {
+		assert.Nil(t, g.Lookup([]byte{1, 2, 3, 4}))
+		assert.Nil(t, g.Lookup([]byte{1, 2, 3, 4}))
+		assert.Equal(t, 2, lookups, "cached")
+	})
+
+	t.Run("not-public", func(t *testing.T) {
+		assert.Nil(t, g.Lookup([]byte{10, 0, 0, 1}))
+		assert.Nil(t, g.Lookup([]byte{127, 0, 0, 1}))
+		assert.Nil(t, g.Lookup([]byte{224, 0, 0, 1}))
+		assert.Nil(t, g.Lookup(net.ParseIP("fe80::1")))
+		assert.Nil(t, g.Lookup(nil))
+		assert.Equal(t, 2, lookups)
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		g := &GeoIP{
+			cache: make(map[string]*common.GeoInfo),
+			city:  func(ip net.IP) (string, string) { return "US", "" },
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					g.Lookup([]byte{8, 8, byte(i), byte(j)})
+				}
+			}(i)
+		}
+		g.Close()
+		wg.Wait()
+
+		assert.Nil(t, g.Lookup([]byte{9, 9, 9, 9}))
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		var g *GeoIP
+		assert.Nil(t, g.Lookup([]byte{8, 8, 8, 8}))
+		g.Close()
+	})
+}
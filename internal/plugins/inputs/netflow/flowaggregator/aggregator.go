@@ -9,6 +9,7 @@ package flowaggregator
 
 import (
 	"encoding/json"
+	"strconv"
 	"time"
 
 	"github.com/GuanceCloud/cliutils/logger"
@@ -19,6 +20,9 @@ import (
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/common"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/config"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/metrics"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/payload"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/snmppoller"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/threat"
 	"go.uber.org/atomic"
 )
 
@@ -32,6 +36,8 @@ const (
 // FlowAggregator is used for space and time aggregation of NetFlow flows.
 type FlowAggregator struct {
 	flowIn                       chan *common.Flow
+	counterIn                    chan []*common.InterfaceCounters
+	interfaces                   *interfaceTracker
 	flushFlowsToSendInterval     time.Duration // interval for checking flows to flush and send them to EP Forwarder
 	rollupTrackerRefreshInterval time.Duration
 	flowAcc                      *flowAccumulator
@@ -48,6 +54,10 @@ type FlowAggregator struct {
 	combinedTags            map[string]string
 	feeder                  dkio.Feeder
 	source                  string
+	exporterMetadata        *common.ExporterMetadata
+	ifPoller                *snmppoller.Poller // nil if SNMP polling not enabled
+	threats                 *threat.Detector   // nil if threat detection not enabled
+	extrapolateSampled      bool
 }
 
 type SequenceDeltaKey struct {
@@ -65,11 +75,14 @@ type SequenceDeltaValue struct {
 // NewFlowAggregator returns a new FlowAggregator.
 //
 //nolint:lll
-func NewFlowAggregator(config *config.NetflowConfig, combinedTags map[string]string, feeder dkio.Feeder, source string) *FlowAggregator {
+func NewFlowAggregator(config *config.NetflowConfig, combinedTags map[string]string, feeder dkio.Feeder, source string, exporterMetadata *common.ExporterMetadata, ifPoller *snmppoller.Poller, threats *threat.Detector) *FlowAggregator {
 	flushInterval := time.Duration(config.AggregatorFlushInterval) * time.Second
 	flowContextTTL := time.Duration(config.AggregatorFlowContextTTL) * time.Second
 	rollupTrackerRefreshInterval := time.Duration(config.AggregatorRollupTrackerRefreshInterval) * time.Second
 
+	flowAcc := newFlowAccumulator(flushInterval, flowContextTTL, config.AggregatorPortRollupThreshold, config.AggregatorPortRollupDisabled)
+	flowAcc.setAggregation(config.AggregatorRollup)
+
 	hostname, ok := combinedTags[hostTagKey]
 	if !ok {
 		hostname = unknownHost
@@ -77,7 +90,9 @@ func NewFlowAggregator(config *config.NetflowConfig, combinedTags map[string]str
 
 	return &FlowAggregator{
 		flowIn:                       make(chan *common.Flow, config.AggregatorBufferSize),
-		flowAcc:                      newFlowAccumulator(flushInterval, flowContextTTL, config.AggregatorPortRollupThreshold, config.AggregatorPortRollupDisabled),
+		counterIn:                    make(chan []*common.InterfaceCounters, config.AggregatorBufferSize),
+		interfaces:                   newInterfaceTracker(),
+		flowAcc:                      flowAcc,
 		flushFlowsToSendInterval:     flushFlowsToSendInterval,
 		rollupTrackerRefreshInterval: rollupTrackerRefreshInterval,
 		stopChan:                     make(chan struct{}),
@@ -90,9 +105,14 @@ func NewFlowAggregator(config *config.NetflowConfig, combinedTags map[string]str
 		TimeNowFunction:              time.Now,
 		lastSequencePerExporter:      make(map[SequenceDeltaKey]uint32),
 
-		combinedTags: combinedTags,
-		feeder:       feeder,
-		source:       source,
+		combinedTags:     combinedTags,
+		feeder:           feeder,
+		source:           source,
+		exporterMetadata: exporterMetadata,
+		ifPoller:         ifPoller,
+		threats:          threats,
+
+		extrapolateSampled: config.ExtrapolateSampled,
 	}
 }
 
@@ -115,7 +135,22 @@ func (agg *FlowAggregator) GetFlowInChan() chan *common.Flow {
 	return agg.flowIn
 }
 
+// GetCounterInChan returns interface counters input chan.
+func (agg *FlowAggregator) GetCounterInChan() chan []*common.InterfaceCounters {
+	return agg.counterIn
+}
+
 func (agg *FlowAggregator) run() {
+	expireTicker := time.NewTicker(interfaceCountersTTL)
+	defer expireTicker.Stop()
+
+	var threatTicker <-chan time.Time
+	if agg.threats != nil {
+		t := time.NewTicker(agg.threats.Window())
+		defer t.Stop()
+		threatTicker = t.C
+	}
+
 	for {
 		select {
 		case <-agg.stopChan:
@@ -124,14 +159,28 @@ func (agg *FlowAggregator) run() {
 			return
 		case flow := <-agg.flowIn:
 			agg.receivedFlowCount.Inc()
+			if !agg.extrapolateSampled {
+				flow.Bytes, flow.Packets = flow.RawBytes, flow.RawPackets
+			}
+			agg.ifPoller.Track(flow.ExporterAddr)
+			agg.threats.Observe(flow) // before ports/addresses rolled up by the accumulator
 			agg.flowAcc.add(flow)
+		case counters := <-agg.counterIn:
+			if len(counters) > 0 {
+				agg.ifPoller.Track(counters[0].AgentAddr) // counters of a packet are of the same agent
+			}
+			agg.sendInterfaceCounters(counters)
+		case now := <-expireTicker.C:
+			agg.interfaces.expire(now)
+		case now := <-threatTicker:
+			agg.sendThreats(agg.threats.Flush(now))
 		}
 	}
 }
 
 func (agg *FlowAggregator) sendFlows(flows []*common.Flow, flushTime time.Time) {
 	for _, flow := range flows {
-		flowPayload := buildPayload(flow, agg.hostname, flushTime)
+		flowPayload := buildPayload(flow, agg.hostname, flushTime, agg.exporterMetadata)
 		payloadBytes, err := json.Marshal(flowPayload)
 		if err != nil {
 			l.Errorf("Error marshaling device metadata: %s", err)
@@ -142,7 +191,7 @@ func (agg *FlowAggregator) sendFlows(flows []*common.Flow, flushTime time.Time)
 
 		logging := &metrics.NetflowMeasurement{
 			Name: agg.source,
-			Tags: agg.combinedTags,
+			Tags: agg.flowTags(&flowPayload),
 			TS:   flushTime,
 		}
 		if logging.Fields == nil {
@@ -151,6 +200,11 @@ func (agg *FlowAggregator) sendFlows(flows []*common.Flow, flushTime time.Time)
 		logging.Fields[pipeline.FieldMessage] = string(payloadBytes)
 		logging.Fields[pipeline.FieldStatus] = OK
 		logging.Fields["bytes"] = flowPayload.Bytes
+		logging.Fields["packets"] = flowPayload.Packets
+		if flowPayload.SamplingRate > 1 {
+			logging.Fields["raw_bytes"] = flowPayload.RawBytes
+			logging.Fields["raw_packets"] = flowPayload.RawPackets
+		}
 		logging.Fields["dest_ip"] = flowPayload.Destination.IP
 		logging.Fields["dest_port"] = flowPayload.Destination.Port
 		logging.Fields["device_ip"] = flowPayload.Exporter.IP
@@ -158,6 +212,24 @@ func (agg *FlowAggregator) sendFlows(flows []*common.Flow, flushTime time.Time)
 		logging.Fields["source_ip"] = flowPayload.Source.IP
 		logging.Fields["source_port"] = flowPayload.Source.Port
 		logging.Fields["type"] = flowPayload.FlowType
+		logging.Fields["ingress_interface"] = interfaceString(flowPayload.Ingress.Interface)
+		logging.Fields["egress_interface"] = interfaceString(flowPayload.Egress.Interface)
+		if alias := flowPayload.Ingress.Interface.Alias; alias != "" {
+			logging.Fields["ingress_interface_alias"] = alias
+		}
+		if alias := flowPayload.Egress.Interface.Alias; alias != "" {
+			logging.Fields["egress_interface_alias"] = alias
+		}
+		if flowPayload.Application != nil {
+			logging.Fields["application"] = applicationString(flowPayload.Application)
+		}
+		logging.Fields["tos"] = int64(flowPayload.Tos)
+		logging.Fields["dscp"] = int64(flowPayload.DSCP)
+		logging.Fields["dscp_class"] = flowPayload.DSCPClass
+		addTCPFlagFields(logging.Fields, &flowPayload)
+		addInnerFields(logging.Fields, flowPayload.Inner)
+		addGeoFields(logging.Fields, "source_", flowPayload.Source.Geo)
+		addGeoFields(logging.Fields, "dest_", flowPayload.Destination.Geo)
 
 		if err := agg.feeder.FeedV2(point.Logging, []*point.Point{logging.Point()},
 			dkio.WithCollectCost(time.Since(flushTime)),
@@ -168,6 +240,89 @@ func (agg *FlowAggregator) sendFlows(flows []*common.Flow, flushTime time.Time)
 	}
 }
 
+// flowTags returns tags of the flow, `sampled` and `sampling_rate` added for sampled flows.
+func (agg *FlowAggregator) flowTags(flowPayload *payload.FlowPayload) map[string]string {
+	if flowPayload.SamplingRate <= 1 {
+		return agg.combinedTags
+	}
+
+	tags := make(map[string]string, len(agg.combinedTags)+2)
+	for k, v := range agg.combinedTags {
+		tags[k] = v
+	}
+	tags["sampled"] = "true"
+	tags["sampling_rate"] = strconv.FormatUint(flowPayload.SamplingRate, 10)
+	return tags
+}
+
+// interfaceString returns ifName of the interface, or ifIndex if ifName unknown.
+func interfaceString(iface payload.Interface) string {
+	if iface.Name != "" {
+		return iface.Name
+	}
+	return strconv.FormatUint(uint64(iface.Index), 10)
+}
+
+// applicationString returns name of the application, or ID if name unknown.
+func applicationString(app *payload.Application) string {
+	if app.Name != "" {
+		return app.Name
+	}
+	return app.ID
+}
+
+// addTCPFlagFields adds counts of raw flows, and of TCP flows with SYN only or
+// RST, with ratio of RST flows.
+func addTCPFlagFields(fields map[string]interface{}, flowPayload *payload.FlowPayload) {
+	fields["flows"] = int64(flowPayload.Flows)
+	if flowPayload.IPProtocol != "TCP" {
+		return
+	}
+
+	fields["tcp_syn_only_flows"] = int64(flowPayload.TCPSYNOnly)
+	fields["tcp_rst_flows"] = int64(flowPayload.TCPRST)
+	if flowPayload.Flows > 0 {
+		fields["tcp_rst_ratio"] = float64(flowPayload.TCPRST) / float64(flowPayload.Flows)
+	}
+}
+
+// addInnerFields adds tunnel and inner header fields of encapsulated flows.
+func addInnerFields(fields map[string]interface{}, inner *payload.Inner) {
+	if inner == nil {
+		return
+	}
+
+	if inner.Tunnel != "" {
+		fields["tunnel_type"] = inner.Tunnel
+		fields["tunnel_id"] = int64(inner.TunnelID)
+	}
+	fields["inner_source_ip"] = inner.Source.IP
+	fields["inner_source_port"] = inner.Source.Port
+	fields["inner_dest_ip"] = inner.Destination.IP
+	fields["inner_dest_port"] = inner.Destination.Port
+	fields["inner_ip_protocol"] = inner.IPProtocol
+}
+
+// addGeoFields adds GeoIP/ASN fields of the endpoint, like `source_country`.
+func addGeoFields(fields map[string]interface{}, prefix string, geo *payload.Geo) {
+	if geo == nil {
+		return
+	}
+
+	if geo.Country != "" {
+		fields[prefix+"country"] = geo.Country
+	}
+	if geo.City != "" {
+		fields[prefix+"city"] = geo.City
+	}
+	if geo.ASN != 0 {
+		fields[prefix+"asn"] = int64(geo.ASN)
+	}
+	if geo.Org != "" {
+		fields[prefix+"org"] = geo.Org
+	}
+}
+
 func (agg *FlowAggregator) flushLoop() {
 	var flushFlowsToSendTicker <-chan time.Time
 
@@ -15,9 +15,25 @@ import (
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/portrollup"
 )
 
-func buildPayload(aggFlow *common.Flow, hostname string, flushTime time.Time) payload.FlowPayload {
+//nolint:lll
+func buildPayload(aggFlow *common.Flow, hostname string, flushTime time.Time, exporterMetadata *common.ExporterMetadata) payload.FlowPayload {
+	var app *payload.Application
+	if len(aggFlow.ApplicationID) > 0 {
+		appID := enrichment.FormatApplicationID(aggFlow.ApplicationID)
+		app = &payload.Application{
+			ID:   appID,
+			Name: exporterMetadata.ApplicationName(aggFlow.ExporterAddr, appID),
+		}
+	}
+
+	dscp := enrichment.TosToDSCP(aggFlow.Tos)
+
+	var rawBytes, rawPackets uint64
+	if aggFlow.SamplingRate > 1 {
+		rawBytes, rawPackets = aggFlow.RawBytes, aggFlow.RawPackets
+	}
+
 	return payload.FlowPayload{
-		// TODO: Implement Tos
 		FlushTimestamp: flushTime.UnixMilli(),
 		FlowType:       string(aggFlow.FlowType),
 		SamplingRate:   aggFlow.SamplingRate,
@@ -32,34 +48,84 @@ func buildPayload(aggFlow *common.Flow, hostname string, flushTime time.Time) pa
 		End:        aggFlow.EndTimestamp,
 		Bytes:      aggFlow.Bytes,
 		Packets:    aggFlow.Packets,
+		RawBytes:   rawBytes,
+		RawPackets: rawPackets,
 		EtherType:  enrichment.MapEtherType(aggFlow.EtherType),
-		IPProtocol: enrichment.MapIPProtocol(aggFlow.IPProtocol),
+		IPProtocol: ipProtocolString(aggFlow.IPProtocol),
 		Source: payload.Endpoint{
 			IP:   common.IPBytesToString(aggFlow.SrcAddr),
 			Port: portrollup.PortToString(aggFlow.SrcPort),
 			Mac:  enrichment.FormatMacAddress(aggFlow.SrcMac),
 			Mask: enrichment.FormatMask(aggFlow.SrcAddr, aggFlow.SrcMask),
+			Geo:  buildGeo(aggFlow.SrcGeo),
 		},
 		Destination: payload.Endpoint{
 			IP:   common.IPBytesToString(aggFlow.DstAddr),
 			Port: portrollup.PortToString(aggFlow.DstPort),
 			Mac:  enrichment.FormatMacAddress(aggFlow.DstMac),
 			Mask: enrichment.FormatMask(aggFlow.DstAddr, aggFlow.DstMask),
+			Geo:  buildGeo(aggFlow.DstGeo),
 		},
 		Ingress: payload.ObservationPoint{
 			Interface: payload.Interface{
 				Index: aggFlow.InputInterface,
+				Name:  exporterMetadata.InterfaceName(aggFlow.ExporterAddr, aggFlow.InputInterface),
+				Alias: exporterMetadata.InterfaceAlias(aggFlow.ExporterAddr, aggFlow.InputInterface),
 			},
 		},
 		Egress: payload.ObservationPoint{
 			Interface: payload.Interface{
 				Index: aggFlow.OutputInterface,
+				Name:  exporterMetadata.InterfaceName(aggFlow.ExporterAddr, aggFlow.OutputInterface),
+				Alias: exporterMetadata.InterfaceAlias(aggFlow.ExporterAddr, aggFlow.OutputInterface),
 			},
 		},
-		Host:     hostname,
-		TCPFlags: enrichment.FormatFCPFlags(aggFlow.TCPFlags),
+		Host:       hostname,
+		TCPFlags:   enrichment.FormatFCPFlags(aggFlow.TCPFlags),
+		Tos:        aggFlow.Tos,
+		DSCP:       dscp,
+		DSCPClass:  enrichment.MapDSCPClass(dscp),
+		Flows:      aggFlow.FlowCount,
+		TCPSYNOnly: aggFlow.TCPSYNOnlyFlowCnt,
+		TCPRST:     aggFlow.TCPRSTFlowCnt,
 		NextHop: payload.NextHop{
 			IP: common.IPBytesToString(aggFlow.NextHop),
 		},
+		Application: app,
+		Inner:       buildInner(aggFlow),
+	}
+}
+
+// buildInner returns inner header of encapsulated flows, nil if not encapsulated.
+func buildInner(aggFlow *common.Flow) *payload.Inner {
+	if !aggFlow.Encapsulated() {
+		return nil
+	}
+
+	return &payload.Inner{
+		Tunnel:     aggFlow.TunnelType.String(),
+		TunnelID:   aggFlow.TunnelID,
+		IPProtocol: ipProtocolString(aggFlow.InnerIPProtocol),
+		Source: payload.Endpoint{
+			IP:   common.IPBytesToString(aggFlow.InnerSrcAddr),
+			Port: portrollup.PortToString(aggFlow.InnerSrcPort),
+		},
+		Destination: payload.Endpoint{
+			IP:   common.IPBytesToString(aggFlow.InnerDstAddr),
+			Port: portrollup.PortToString(aggFlow.InnerDstPort),
+		},
+	}
+}
+
+func buildGeo(geo *common.GeoInfo) *payload.Geo {
+	if geo == nil {
+		return nil
+	}
+
+	return &payload.Geo{
+		Country: geo.Country,
+		City:    geo.City,
+		ASN:     geo.ASN,
+		Org:     geo.Org,
 	}
 }
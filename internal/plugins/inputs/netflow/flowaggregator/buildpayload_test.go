@@ -79,10 +79,12 @@ func Test_buildPayload(t *testing.T) {
 					Mac:  "00:00:00:00:00:14",
 					Mask: "10.10.0.0/20",
 				},
-				Ingress:  payload.ObservationPoint{Interface: payload.Interface{Index: 10}},
-				Egress:   payload.ObservationPoint{Interface: payload.Interface{Index: 20}},
-				Host:     "my-hostname",
-				TCPFlags: []string{"FIN", "SYN", "ACK"},
+				Ingress:   payload.ObservationPoint{Interface: payload.Interface{Index: 10}},
+				Egress:    payload.ObservationPoint{Interface: payload.Interface{Index: 20}},
+				Host:      "my-hostname",
+				TCPFlags:  []string{"FIN", "SYN", "ACK"},
+				Tos:       3,
+				DSCPClass: "BE",
 				NextHop: payload.NextHop{
 					IP: "10.10.10.30",
 				},
@@ -145,10 +147,12 @@ func Test_buildPayload(t *testing.T) {
 					Mac:  "00:00:00:00:00:14",
 					Mask: "10.10.0.0/20",
 				},
-				Ingress:  payload.ObservationPoint{Interface: payload.Interface{Index: 10}},
-				Egress:   payload.ObservationPoint{Interface: payload.Interface{Index: 20}},
-				Host:     "my-hostname",
-				TCPFlags: []string{"FIN", "SYN", "ACK"},
+				Ingress:   payload.ObservationPoint{Interface: payload.Interface{Index: 10}},
+				Egress:    payload.ObservationPoint{Interface: payload.Interface{Index: 20}},
+				Host:      "my-hostname",
+				TCPFlags:  []string{"FIN", "SYN", "ACK"},
+				Tos:       3,
+				DSCPClass: "BE",
 				NextHop: payload.NextHop{
 					IP: "10.10.10.30",
 				},
@@ -211,10 +215,12 @@ func Test_buildPayload(t *testing.T) {
 					Mac:  "00:00:00:00:00:14",
 					Mask: "10.10.0.0/20",
 				},
-				Ingress:  payload.ObservationPoint{Interface: payload.Interface{Index: 10}},
-				Egress:   payload.ObservationPoint{Interface: payload.Interface{Index: 20}},
-				Host:     "my-hostname",
-				TCPFlags: []string{"FIN", "SYN", "ACK"},
+				Ingress:   payload.ObservationPoint{Interface: payload.Interface{Index: 10}},
+				Egress:    payload.ObservationPoint{Interface: payload.Interface{Index: 20}},
+				Host:      "my-hostname",
+				TCPFlags:  []string{"FIN", "SYN", "ACK"},
+				Tos:       3,
+				DSCPClass: "BE",
 				NextHop: payload.NextHop{
 					IP: "10.10.10.30",
 				},
@@ -223,8 +229,133 @@ func Test_buildPayload(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			flowPayload := buildPayload(&tt.flow, "my-hostname", curTime)
+			flowPayload := buildPayload(&tt.flow, "my-hostname", curTime, nil)
 			assert.Equal(t, tt.expectedPayload, flowPayload)
 		})
 	}
 }
+
+func Test_buildPayload_exporterMetadata(t *testing.T) {
+	exporterMetadata := common.NewExporterMetadata()
+	exporterMetadata.SetInterfaceName([]byte{127, 0, 0, 1}, 10, "GigabitEthernet0/0")
+	exporterMetadata.SetApplicationName([]byte{127, 0, 0, 1}, "3:80", "http")
+	exporterMetadata.SetInterfaceAlias([]byte{127, 0, 0, 1}, 10, "uplink")
+	exporterMetadata.SetInterfaceName([]byte{127, 0, 0, 2}, 20, "other-exporter")
+
+	flow := common.Flow{
+		ExporterAddr:    []byte{127, 0, 0, 1},
+		InputInterface:  10,
+		OutputInterface: 20,
+		ApplicationID:   []byte{3, 0, 80},
+	}
+
+	flowPayload := buildPayload(&flow, "my-hostname", time.Now(), exporterMetadata)
+	assert.Equal(t, payload.Interface{Index: 10, Name: "GigabitEthernet0/0", Alias: "uplink"}, flowPayload.Ingress.Interface)
+	assert.Equal(t, payload.Interface{Index: 20}, flowPayload.Egress.Interface)
+	assert.Equal(t, &payload.Application{ID: "3:80", Name: "http"}, flowPayload.Application)
+
+	flow.ApplicationID = nil
+	flowPayload = buildPayload(&flow, "my-hostname", time.Now(), exporterMetadata)
+	assert.Nil(t, flowPayload.Application)
+}
+
+func Test_buildPayload_geo(t *testing.T) {
+	flow := common.Flow{
+		SrcAddr: []byte{10, 0, 0, 1},
+		DstAddr: []byte{8, 8, 8, 8},
+		DstGeo:  &common.GeoInfo{Country: "US", ASN: 15169, Org: "GOOGLE"},
+	}
+
+	flowPayload := buildPayload(&flow, "my-hostname", time.Now(), nil)
+	assert.Nil(t, flowPayload.Source.Geo)
+	assert.Equal(t, &payload.Geo{Country: "US", ASN: 15169, Org: "GOOGLE"}, flowPayload.Destination.Geo)
+
+	fields := map[string]interface{}{}
+	addGeoFields(fields, "source_", flowPayload.Source.Geo)
+	addGeoFields(fields, "dest_", flowPayload.Destination.Geo)
+	assert.Equal(t, map[string]interface{}{
+		"dest_country": "US",
+		"dest_asn":     int64(15169),
+		"dest_org":     "GOOGLE",
+	}, fields)
+}
+
+func Test_buildPayload_dscp(t *testing.T) {
+	flow := common.Flow{
+		IPProtocol:        uint32(6),
+		Tos:               0xb8,
+		FlowCount:         4,
+		TCPSYNOnlyFlowCnt: 3,
+		TCPRSTFlowCnt:     1,
+	}
+
+	flowPayload := buildPayload(&flow, "my-hostname", time.Now(), nil)
+	assert.Equal(t, uint32(0xb8), flowPayload.Tos)
+	assert.Equal(t, uint32(46), flowPayload.DSCP)
+	assert.Equal(t, "EF", flowPayload.DSCPClass)
+
+	fields := map[string]interface{}{}
+	addTCPFlagFields(fields, &flowPayload)
+	assert.Equal(t, map[string]interface{}{
+		"flows":              int64(4),
+		"tcp_syn_only_flows": int64(3),
+		"tcp_rst_flows":      int64(1),
+		"tcp_rst_ratio":      0.25,
+	}, fields)
+}
+
+func Test_buildPayload_sampled(t *testing.T) {
+	agg := &FlowAggregator{combinedTags: map[string]string{"host": "my-hostname"}}
+
+	flow := common.Flow{SamplingRate: 100, Bytes: 1000, Packets: 200, RawBytes: 10, RawPackets: 2}
+	flowPayload := buildPayload(&flow, "my-hostname", time.Now(), nil)
+	assert.Equal(t, uint64(10), flowPayload.RawBytes)
+	assert.Equal(t, uint64(2), flowPayload.RawPackets)
+	assert.Equal(t, map[string]string{"host": "my-hostname", "sampled": "true", "sampling_rate": "100"}, agg.flowTags(&flowPayload))
+	assert.Equal(t, map[string]string{"host": "my-hostname"}, agg.combinedTags)
+
+	// not sampled
+	flow = common.Flow{SamplingRate: 1, Bytes: 10, Packets: 2, RawBytes: 10, RawPackets: 2}
+	flowPayload = buildPayload(&flow, "my-hostname", time.Now(), nil)
+	assert.Zero(t, flowPayload.RawBytes)
+	assert.Zero(t, flowPayload.RawPackets)
+	assert.Equal(t, map[string]string{"host": "my-hostname"}, agg.flowTags(&flowPayload))
+}
+
+func Test_buildPayload_inner(t *testing.T) {
+	flow := common.Flow{
+		IPProtocol:      uint32(17),
+		TunnelType:      common.TunnelVXLAN,
+		TunnelID:        5001,
+		InnerSrcAddr:    []byte{10, 244, 1, 2},
+		InnerDstAddr:    []byte{10, 244, 2, 3},
+		InnerIPProtocol: uint32(6),
+		InnerSrcPort:    41000,
+		InnerDstPort:    8080,
+	}
+
+	flowPayload := buildPayload(&flow, "my-hostname", time.Now(), nil)
+	assert.Equal(t, &payload.Inner{
+		Tunnel:      "vxlan",
+		TunnelID:    5001,
+		IPProtocol:  "TCP",
+		Source:      payload.Endpoint{IP: "10.244.1.2", Port: "41000"},
+		Destination: payload.Endpoint{IP: "10.244.2.3", Port: "8080"},
+	}, flowPayload.Inner)
+
+	fields := map[string]interface{}{}
+	addInnerFields(fields, flowPayload.Inner)
+	assert.Equal(t, map[string]interface{}{
+		"tunnel_type":       "vxlan",
+		"tunnel_id":         int64(5001),
+		"inner_source_ip":   "10.244.1.2",
+		"inner_source_port": "41000",
+		"inner_dest_ip":     "10.244.2.3",
+		"inner_dest_port":   "8080",
+		"inner_ip_protocol": "TCP",
+	}, fields)
+
+	// not encapsulated
+	flowPayload = buildPayload(&common.Flow{}, "my-hostname", time.Now(), nil)
+	assert.Nil(t, flowPayload.Inner)
+}
@@ -0,0 +1,138 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package flowaggregator
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	dkio "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/common"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/metrics"
+)
+
+// interfaceCountersTTL is how long counters of an interface kept without new
+// counter samples, sFlow agents send counter samples every 20-30 seconds.
+const interfaceCountersTTL = 10 * time.Minute
+
+type interfaceKey struct {
+	namespace  string
+	agentAddr  string
+	subAgentID uint32
+	ifIndex    uint32
+}
+
+type interfaceOctets struct {
+	t       time.Time
+	in, out uint64
+}
+
+// interfaceTracker keeps octets of the last counter sample of each interface,
+// to calculate rates of the interface. Only used by the run() routine of
+// the aggregator, no lock needed.
+type interfaceTracker struct {
+	last map[interfaceKey]interfaceOctets
+}
+
+func newInterfaceTracker() *interfaceTracker {
+	return &interfaceTracker{last: make(map[interfaceKey]interfaceOctets)}
+}
+
+// fields returns fields of sflow_interface for the counters, rates added if
+// previous counters of the interface exist and not wrapped or reset.
+func (t *interfaceTracker) fields(c *common.InterfaceCounters) map[string]interface{} {
+	fields := map[string]interface{}{
+		"speed":              c.IfSpeed,
+		"in_octets":          c.InOctets,
+		"in_ucast_pkts":      c.InUcastPkts,
+		"in_multicast_pkts":  c.InMulticastPkts,
+		"in_broadcast_pkts":  c.InBroadcastPkts,
+		"in_discards":        c.InDiscards,
+		"in_errors":          c.InErrors,
+		"in_unknown_protos":  c.InUnknownProtos,
+		"out_octets":         c.OutOctets,
+		"out_ucast_pkts":     c.OutUcastPkts,
+		"out_multicast_pkts": c.OutMulticastPkts,
+		"out_broadcast_pkts": c.OutBroadcastPkts,
+		"out_discards":       c.OutDiscards,
+		"out_errors":         c.OutErrors,
+	}
+
+	key := interfaceKey{
+		namespace:  c.Namespace,
+		agentAddr:  string(c.AgentAddr),
+		subAgentID: c.SubAgentID,
+		ifIndex:    c.IfIndex,
+	}
+
+	last, ok := t.last[key]
+	t.last[key] = interfaceOctets{t: c.TimeReceived, in: c.InOctets, out: c.OutOctets}
+
+	if !ok || !c.TimeReceived.After(last.t) || c.InOctets < last.in || c.OutOctets < last.out {
+		return fields
+	}
+
+	secs := c.TimeReceived.Sub(last.t).Seconds()
+	inRate := float64(c.InOctets-last.in) / secs
+	outRate := float64(c.OutOctets-last.out) / secs
+	fields["in_bytes_per_sec"] = inRate
+	fields["out_bytes_per_sec"] = outRate
+
+	if c.IfSpeed > 0 {
+		fields["in_utilization"] = inRate * 8 * 100 / float64(c.IfSpeed)
+		fields["out_utilization"] = outRate * 8 * 100 / float64(c.IfSpeed)
+	}
+
+	return fields
+}
+
+// expire removes interfaces without counter samples since interfaceCountersTTL.
+func (t *interfaceTracker) expire(now time.Time) {
+	for key, last := range t.last {
+		if now.Sub(last.t) > interfaceCountersTTL {
+			delete(t.last, key)
+		}
+	}
+}
+
+func (agg *FlowAggregator) interfacePoint(c *common.InterfaceCounters) *point.Point {
+	var kvs point.KVs
+	kvs = kvs.AddTag("namespace", c.Namespace).
+		AddTag("device_ip", common.IPBytesToString(c.AgentAddr)).
+		AddTag("sub_agent_id", strconv.FormatUint(uint64(c.SubAgentID), 10)).
+		AddTag("if_index", strconv.FormatUint(uint64(c.IfIndex), 10)).
+		AddTag("admin_status", c.AdminStatus().AsString()).
+		AddTag("oper_status", c.OperStatus().AsString())
+
+	if name := agg.exporterMetadata.InterfaceName(c.AgentAddr, c.IfIndex); name != "" {
+		kvs = kvs.AddTag("if_name", name)
+	}
+
+	for k, v := range agg.combinedTags {
+		kvs = kvs.AddTag(k, v)
+	}
+
+	for k, v := range agg.interfaces.fields(c) {
+		kvs = kvs.Add(k, v, false, false)
+	}
+
+	return point.NewPointV2(metrics.SFlowInterfaceMetricName, kvs,
+		append(point.DefaultMetricOptions(), point.WithTime(c.TimeReceived))...)
+}
+
+func (agg *FlowAggregator) sendInterfaceCounters(counters []*common.InterfaceCounters) {
+	pts := make([]*point.Point, 0, len(counters))
+	for _, c := range counters {
+		pts = append(pts, agg.interfacePoint(c))
+	}
+
+	if err := agg.feeder.FeedV2(point.Metric, pts,
+		dkio.WithInputName(common.InputName+"/"+metrics.SFlowInterfaceMetricName),
+	); err != nil {
+		l.Errorf("Feed failed: %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package flowaggregator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/common"
+)
+
+func TestInterfaceTracker(t *testing.T) {
+	tracker := newInterfaceTracker()
+	now := time.Now()
+
+	c := &common.InterfaceCounters{
+		Namespace:    "my-ns",
+		AgentAddr:    []byte{192, 168, 1, 1},
+		IfIndex:      3,
+		IfSpeed:      1000000,
+		TimeReceived: now,
+		InOctets:     1000,
+		OutOctets:    2000,
+	}
+
+	// no rates on the first counter sample
+	fields := tracker.fields(c)
+	assert.Equal(t, uint64(1000), fields["in_octets"])
+	assert.NotContains(t, fields, "in_bytes_per_sec")
+
+	c2 := *c
+	c2.TimeReceived = now.Add(10 * time.Second)
+	c2.InOctets = 1000 + 12500*10
+	c2.OutOctets = 2000 + 1250*10
+	fields = tracker.fields(&c2)
+	assert.Equal(t, 12500.0, fields["in_bytes_per_sec"])
+	assert.Equal(t, 1250.0, fields["out_bytes_per_sec"])
+	assert.Equal(t, 10.0, fields["in_utilization"])
+	assert.Equal(t, 1.0, fields["out_utilization"])
+
+	// counters reset, such as the device rebooted
+	c3 := c2
+	c3.TimeReceived = now.Add(20 * time.Second)
+	c3.InOctets = 10
+	fields = tracker.fields(&c3)
+	assert.NotContains(t, fields, "in_bytes_per_sec")
+
+	tracker.expire(now.Add(20*time.Second + interfaceCountersTTL))
+	assert.Len(t, tracker.last, 1)
+	tracker.expire(now.Add(21*time.Second + interfaceCountersTTL))
+	assert.Len(t, tracker.last, 0)
+}
+
+func TestInterfacePoint(t *testing.T) {
+	exporterMetadata := common.NewExporterMetadata()
+	exporterMetadata.SetInterfaceName([]byte{192, 168, 1, 1}, 3, "eth3")
+
+	agg := &FlowAggregator{
+		interfaces:       newInterfaceTracker(),
+		combinedTags:     map[string]string{"host": "my-hostname"},
+		exporterMetadata: exporterMetadata,
+	}
+
+	pt := agg.interfacePoint(&common.InterfaceCounters{
+		Namespace:    "my-ns",
+		AgentAddr:    []byte{192, 168, 1, 1},
+		IfIndex:      3,
+		IfStatus:     1,
+		TimeReceived: time.Now(),
+		InOctets:     1000,
+	})
+
+	assert.Equal(t, "sflow_interface", pt.Name())
+	assert.Equal(t, "my-ns", pt.GetTag("namespace"))
+	assert.Equal(t, "192.168.1.1", pt.GetTag("device_ip"))
+	assert.Equal(t, "3", pt.GetTag("if_index"))
+	assert.Equal(t, "eth3", pt.GetTag("if_name"))
+	assert.Equal(t, "up", pt.GetTag("admin_status"))
+	assert.Equal(t, "down", pt.GetTag("oper_status"))
+	assert.Equal(t, "my-hostname", pt.GetTag("host"))
+	assert.Equal(t, uint64(1000), pt.Get("in_octets"))
+}
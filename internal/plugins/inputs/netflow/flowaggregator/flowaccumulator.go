@@ -10,6 +10,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/GuanceCloud/cliutils/metrics"
+	"github.com/prometheus/client_golang/prometheus"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/common"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/portrollup"
 	"go.uber.org/atomic"
@@ -17,6 +19,29 @@ import (
 
 var timeNow = time.Now
 
+var topNDroppedFlows = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Namespace: "datakit",
+		Subsystem: "input_netflow",
+		Name:      "topn_dropped_flows_total",
+		Help:      "Flows dropped out of the top N flows of the aggregation window",
+	},
+)
+
+//nolint:gochecknoinits
+func init() {
+	metrics.MustRegister(topNDroppedFlows)
+}
+
+const (
+	ipProtocolTCP = 6
+
+	tcpFlagFIN = 1
+	tcpFlagSYN = 2
+	tcpFlagRST = 4
+	tcpFlagACK = 16
+)
+
 // flowContext contains flow information and additional flush related data.
 type flowContext struct {
 	flow                *common.Flow
@@ -38,11 +63,21 @@ type flowAccumulator struct {
 	portRollupThreshold int
 	portRollupDisabled  bool
 
+	// rollup and topN configured by the aggregation window, flushes of flow
+	// contexts aligned to the window if set.
+	rollup     *flowRollup
+	topN       int
+	alignFlush bool
+
 	hashCollisionFlowCount *atomic.Uint64
 }
 
-func newFlowContext(flow *common.Flow) flowContext {
+func (f *flowAccumulator) newFlowContext(flow *common.Flow) flowContext {
 	now := timeNow()
+	if f.alignFlush {
+		// flushed at end of the window, so flows of the window flushed together
+		now = now.Truncate(f.flowFlushInterval).Add(f.flowFlushInterval)
+	}
 	return flowContext{
 		flow:      flow,
 		nextFlush: now,
@@ -62,6 +97,17 @@ func newFlowAccumulator(aggregatorFlushInterval time.Duration, aggregatorFlowCon
 	}
 }
 
+// setAggregation configures rollup of flows and top N flows kept by the aggregation window.
+func (f *flowAccumulator) setAggregation(opt *common.AggregationOpt) {
+	if opt == nil {
+		return
+	}
+
+	f.rollup = newFlowRollup(opt)
+	f.topN = opt.TopN
+	f.alignFlush = true
+}
+
 // flush will flush specific flow context (distinct hash) if nextFlush is reached
 // once a flow context is flushed nextFlush will be updated to the next flush time
 //
@@ -98,12 +144,21 @@ func (f *flowAccumulator) flush() []*common.Flow {
 		flowCtx.nextFlush = flowCtx.nextFlush.Add(f.flowFlushInterval)
 		f.flows[key] = flowCtx
 	}
+
+	flowsToFlush, dropped := topFlows(flowsToFlush, f.topN)
+	if dropped > 0 {
+		l.Debugf("Drop %d flows out of top %d", dropped, f.topN)
+		topNDroppedFlows.Add(float64(dropped))
+	}
 	return flowsToFlush
 }
 
 func (f *flowAccumulator) add(flowToAdd *common.Flow) {
 	l.Debugf("Add new flow: %+v", flowToAdd)
 
+	// counted before rollup, which may roll up the IP protocol
+	countTCPFlags(flowToAdd)
+
 	if !f.portRollupDisabled {
 		// Handle port rollup
 		f.portRollup.Add(flowToAdd.SrcAddr, flowToAdd.DstAddr, uint16(flowToAdd.SrcPort), uint16(flowToAdd.DstPort))
@@ -117,13 +172,15 @@ func (f *flowAccumulator) add(flowToAdd *common.Flow) {
 		}
 	}
 
+	f.rollup.apply(flowToAdd)
+
 	f.flowsMutex.Lock()
 	defer f.flowsMutex.Unlock()
 
 	aggHash := flowToAdd.AggregationHash()
 	aggFlow, ok := f.flows[aggHash]
 	if !ok {
-		f.flows[aggHash] = newFlowContext(flowToAdd)
+		f.flows[aggHash] = f.newFlowContext(flowToAdd)
 		return
 	}
 	if aggFlow.flow == nil {
@@ -135,14 +192,37 @@ func (f *flowAccumulator) add(flowToAdd *common.Flow) {
 		// accumulate flowToAdd with existing flow(s) with same hash
 		aggFlow.flow.Bytes += flowToAdd.Bytes
 		aggFlow.flow.Packets += flowToAdd.Packets
+		aggFlow.flow.RawBytes += flowToAdd.RawBytes
+		aggFlow.flow.RawPackets += flowToAdd.RawPackets
 		aggFlow.flow.StartTimestamp = common.MinUint64(aggFlow.flow.StartTimestamp, flowToAdd.StartTimestamp)
 		aggFlow.flow.EndTimestamp = common.MaxUint64(aggFlow.flow.EndTimestamp, flowToAdd.EndTimestamp)
 		aggFlow.flow.SequenceNum = common.MaxUint32(aggFlow.flow.SequenceNum, flowToAdd.SequenceNum)
 		aggFlow.flow.TCPFlags |= flowToAdd.TCPFlags
+		aggFlow.flow.FlowCount += flowToAdd.FlowCount
+		aggFlow.flow.TCPSYNOnlyFlowCnt += flowToAdd.TCPSYNOnlyFlowCnt
+		aggFlow.flow.TCPRSTFlowCnt += flowToAdd.TCPRSTFlowCnt
 	}
 	f.flows[aggHash] = aggFlow
 }
 
+// countTCPFlags counts the raw flow, and whether it's a TCP flow with SYN only
+// (SYN without ACK, like half-open connections of SYN flood) or with RST, since
+// TCP flags are OR-ed into aggregated flows.
+func countTCPFlags(flow *common.Flow) {
+	flow.FlowCount = 1
+	flow.TCPSYNOnlyFlowCnt, flow.TCPRSTFlowCnt = 0, 0
+
+	if flow.IPProtocol != ipProtocolTCP {
+		return
+	}
+	if flow.TCPFlags&tcpFlagSYN != 0 && flow.TCPFlags&(tcpFlagACK|tcpFlagRST|tcpFlagFIN) == 0 {
+		flow.TCPSYNOnlyFlowCnt = 1
+	}
+	if flow.TCPFlags&tcpFlagRST != 0 {
+		flow.TCPRSTFlowCnt = 1
+	}
+}
+
 func (f *flowAccumulator) getFlowContextCount() int {
 	f.flowsMutex.Lock()
 	defer f.flowsMutex.Unlock()
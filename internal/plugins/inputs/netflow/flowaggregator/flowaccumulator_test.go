@@ -95,6 +95,9 @@ func Test_flowAccumulator_add(t *testing.T) {
 	assert.Equal(t, uint64(1234568), wrappedFlowA.flow.StartTimestamp)
 	assert.Equal(t, uint64(1234579), wrappedFlowA.flow.EndTimestamp)
 	assert.Equal(t, synAckFlag, wrappedFlowA.flow.TCPFlags)
+	assert.Equal(t, uint64(2), wrappedFlowA.flow.FlowCount)
+	assert.Equal(t, uint64(1), wrappedFlowA.flow.TCPSYNOnlyFlowCnt)
+	assert.Equal(t, uint64(0), wrappedFlowA.flow.TCPRSTFlowCnt)
 
 	wrappedFlowB := acc.flows[flowB1.AggregationHash()]
 	assert.Equal(t, []byte{10, 10, 10, 10}, wrappedFlowB.flow.SrcAddr)
@@ -193,6 +196,9 @@ func Test_flowAccumulator_portRollUp(t *testing.T) {
 	assert.Equal(t, uint64(1234568), wrappedFlowA.flow.StartTimestamp)
 	assert.Equal(t, uint64(1234579), wrappedFlowA.flow.EndTimestamp)
 	assert.Equal(t, synAckFlag, wrappedFlowA.flow.TCPFlags)
+	assert.Equal(t, uint64(2), wrappedFlowA.flow.FlowCount)
+	assert.Equal(t, uint64(1), wrappedFlowA.flow.TCPSYNOnlyFlowCnt)
+	assert.Equal(t, uint64(0), wrappedFlowA.flow.TCPRSTFlowCnt)
 
 	assert.Equal(t, uint64(20), acc.flows[flowB1.AggregationHash()].flow.Packets)
 	assert.Equal(t, int32(2001), acc.flows[flowB1.AggregationHash()].flow.DstPort)
@@ -0,0 +1,103 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package flowaggregator
+
+import (
+	"net"
+	"sort"
+
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/common"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/enrichment"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/portrollup"
+)
+
+// rolledUpIPProtocol is the IP protocol of flows with protocol rolled up.
+const rolledUpIPProtocol = ^uint32(0)
+
+// flowRollup rolls up keys of flows before they are accumulated, so flows
+// of the same subnets/ports/protocol are merged into one.
+type flowRollup struct {
+	opt common.AggregationOpt
+}
+
+// newFlowRollup returns nil if nothing to roll up.
+func newFlowRollup(opt *common.AggregationOpt) *flowRollup {
+	if opt == nil || !opt.RolledUp() {
+		return nil
+	}
+	return &flowRollup{opt: *opt}
+}
+
+func (r *flowRollup) apply(flow *common.Flow) {
+	if r == nil {
+		return
+	}
+
+	if n := r.prefixLen(flow.SrcAddr, r.opt.SrcPrefixLen, r.opt.SrcPrefixLenV6); n > 0 {
+		flow.SrcAddr = maskAddr(flow.SrcAddr, n)
+		flow.SrcMask = uint32(n)
+		flow.SrcMac = 0
+	}
+	if n := r.prefixLen(flow.DstAddr, r.opt.DstPrefixLen, r.opt.DstPrefixLenV6); n > 0 {
+		flow.DstAddr = maskAddr(flow.DstAddr, n)
+		flow.DstMask = uint32(n)
+		flow.DstMac = 0
+	}
+
+	if r.opt.IgnoreSrcPort {
+		flow.SrcPort = portrollup.EphemeralPort
+	}
+	if r.opt.IgnoreDstPort {
+		flow.DstPort = portrollup.EphemeralPort
+	}
+	if r.opt.IgnoreProtocol {
+		flow.IPProtocol = rolledUpIPProtocol
+	}
+}
+
+// prefixLen returns prefix length for the IPv4 or IPv6 address, 0 if not rolled up.
+func (r *flowRollup) prefixLen(addr []byte, v4, v6 int) int {
+	switch len(addr) {
+	case net.IPv4len:
+		return v4
+	case net.IPv6len:
+		if net.IP(addr).To4() != nil {
+			return v4
+		}
+		return v6
+	default:
+		return 0
+	}
+}
+
+func maskAddr(addr []byte, prefixLen int) []byte {
+	ip := net.IP(addr)
+	if ip4 := ip.To4(); ip4 != nil {
+		return []byte(ip4.Mask(net.CIDRMask(prefixLen, 8*net.IPv4len)))
+	}
+	return []byte(ip.Mask(net.CIDRMask(prefixLen, 8*net.IPv6len)))
+}
+
+// topFlows returns n flows with most bytes, the others dropped. All flows
+// returned if n is 0.
+func topFlows(flows []*common.Flow, n int) (top []*common.Flow, dropped int) {
+	if n <= 0 || len(flows) <= n {
+		return flows, 0
+	}
+
+	sort.SliceStable(flows, func(i, j int) bool {
+		return flows[i].Bytes > flows[j].Bytes
+	})
+	return flows[:n], len(flows) - n
+}
+
+// ipProtocolString returns name of the IP protocol, `*` if rolled up.
+func ipProtocolString(protocol uint32) string {
+	if protocol == rolledUpIPProtocol {
+		return "*"
+	}
+	return enrichment.MapIPProtocol(protocol)
+}
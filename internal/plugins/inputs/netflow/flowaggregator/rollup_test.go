@@ -0,0 +1,125 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package flowaggregator
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	promClient "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/common"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/portrollup"
+)
+
+func counterValue(c prometheus.Counter) float64 {
+	var m promClient.Metric
+	c.Write(&m) //nolint:errcheck,gosec
+	return m.GetCounter().GetValue()
+}
+
+func Test_flowRollup_apply(t *testing.T) {
+	assert.Nil(t, newFlowRollup(nil))
+	assert.Nil(t, newFlowRollup(&common.AggregationOpt{Window: time.Minute, TopN: 10}))
+
+	rollup := newFlowRollup(&common.AggregationOpt{
+		SrcPrefixLen:   24,
+		DstPrefixLenV6: 64,
+		IgnoreSrcPort:  true,
+		IgnoreProtocol: true,
+	})
+
+	flow := &common.Flow{
+		SrcAddr:    []byte{10, 10, 10, 10},
+		DstAddr:    []byte{10, 10, 20, 20},
+		SrcPort:    2000,
+		DstPort:    80,
+		IPProtocol: 6,
+		SrcMac:     1,
+		DstMac:     2,
+	}
+	rollup.apply(flow)
+	assert.Equal(t, []byte{10, 10, 10, 0}, flow.SrcAddr)
+	assert.Equal(t, uint32(24), flow.SrcMask)
+	assert.Equal(t, uint64(0), flow.SrcMac)
+	assert.Equal(t, []byte{10, 10, 20, 20}, flow.DstAddr, "IPv6 prefix not for IPv4")
+	assert.Equal(t, uint64(2), flow.DstMac)
+	assert.Equal(t, portrollup.EphemeralPort, flow.SrcPort)
+	assert.Equal(t, int32(80), flow.DstPort)
+	assert.Equal(t, "*", ipProtocolString(flow.IPProtocol))
+
+	flow = &common.Flow{
+		SrcAddr: net.ParseIP("2001:db8::1"),
+		DstAddr: net.ParseIP("2001:db8:1:2:3::1"),
+	}
+	rollup.apply(flow)
+	assert.Equal(t, []byte(net.ParseIP("2001:db8::1")), flow.SrcAddr)
+	assert.Equal(t, []byte(net.ParseIP("2001:db8:1:2::")), flow.DstAddr)
+	assert.Equal(t, uint32(64), flow.DstMask)
+
+	var nilRollup *flowRollup
+	nilRollup.apply(flow)
+}
+
+func Test_topFlows(t *testing.T) {
+	flows := []*common.Flow{{Bytes: 10}, {Bytes: 30}, {Bytes: 20}}
+
+	top, dropped := topFlows(flows, 0)
+	assert.Len(t, top, 3)
+	assert.Equal(t, 0, dropped)
+
+	top, dropped = topFlows(flows, 2)
+	assert.Equal(t, []*common.Flow{{Bytes: 30}, {Bytes: 20}}, top)
+	assert.Equal(t, 1, dropped)
+}
+
+func Test_flowAccumulator_aggregation(t *testing.T) {
+	flushInterval := 60 * time.Second
+	acc := newFlowAccumulator(flushInterval, flushInterval, common.DefaultAggregatorPortRollupThreshold, true)
+	droppedBefore := counterValue(topNDroppedFlows)
+	acc.setAggregation(&common.AggregationOpt{
+		Window:        flushInterval,
+		SrcPrefixLen:  24,
+		IgnoreSrcPort: true,
+		TopN:          1,
+	})
+
+	newFlow := func(src byte, srcPort int32, dst byte, bytes uint64) *common.Flow {
+		return &common.Flow{
+			FlowType:     common.TypeNetFlow9,
+			ExporterAddr: []byte{127, 0, 0, 1},
+			SrcAddr:      []byte{10, 10, 10, src},
+			DstAddr:      []byte{10, 10, 20, dst},
+			IPProtocol:   6,
+			SrcPort:      srcPort,
+			DstPort:      80,
+			Bytes:        bytes,
+			Packets:      1,
+		}
+	}
+
+	startTime := time.Date(2000, 1, 1, 0, 0, 10, 0, time.UTC)
+	setMockTimeNow(startTime)
+	acc.add(newFlow(1, 2000, 1, 10))
+	acc.add(newFlow(2, 3000, 1, 20))
+	acc.add(newFlow(3, 4000, 2, 5))
+	assert.Equal(t, 2, acc.getFlowContextCount())
+
+	// flushed at end of the window
+	setMockTimeNow(startTime.Add(10 * time.Second))
+	assert.Empty(t, acc.flush())
+
+	setMockTimeNow(startTime.Add(50 * time.Second))
+	flows := acc.flush()
+	assert.Len(t, flows, 1)
+	assert.Equal(t, []byte{10, 10, 10, 0}, flows[0].SrcAddr)
+	assert.Equal(t, []byte{10, 10, 20, 1}, flows[0].DstAddr)
+	assert.Equal(t, uint64(30), flows[0].Bytes)
+	assert.Equal(t, uint64(2), flows[0].Packets)
+	assert.Equal(t, droppedBefore+1, counterValue(topNDroppedFlows))
+}
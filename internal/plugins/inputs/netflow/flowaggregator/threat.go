@@ -0,0 +1,93 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package flowaggregator
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	dkio "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/common"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/metrics"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/threat"
+)
+
+const (
+	threatCategory = "network"
+	threatLevel    = "warn"
+)
+
+// threatTitle returns title and message of the indicator.
+func threatTitle(ind *threat.Indicator) (string, string) {
+	src := common.IPBytesToString(ind.SrcAddr)
+	window := ind.WindowEnd.Sub(ind.WindowStart).Round(time.Second)
+
+	switch ind.Type {
+	case threat.TypePortScan:
+		return "Port scan from " + src,
+			fmt.Sprintf("%s attempted connections to %d distinct ports of %d hosts within %s", src, ind.DistinctPorts, ind.DistinctHosts, window)
+	case threat.TypeHostScan:
+		return "Host scan from " + src,
+			fmt.Sprintf("%s attempted connections to %d distinct hosts within %s", src, ind.DistinctHosts, window)
+	default:
+		dst := common.IPBytesToString(ind.DstAddr)
+		return "Blocked destination " + dst,
+			fmt.Sprintf("%s sent %d flows to %s, which is in blocklist entry %s, within %s", src, ind.Flows, dst, ind.Blocked, window)
+	}
+}
+
+func (agg *FlowAggregator) threatPoint(ind *threat.Indicator) *point.Point {
+	title, message := threatTitle(ind)
+
+	var kvs point.KVs
+	kvs = kvs.AddTag("namespace", ind.Namespace).
+		AddTag("device_ip", common.IPBytesToString(ind.ExporterAddr)).
+		AddTag("category", threatCategory).
+		AddTag("level", threatLevel).
+		AddTag("rule", ind.Type).
+		AddTag("title", title)
+
+	for k, v := range agg.combinedTags {
+		kvs = kvs.AddTag(k, v)
+	}
+
+	kvs = kvs.Add(pipeline.FieldMessage, message, false, false).
+		Add("source_ip", common.IPBytesToString(ind.SrcAddr), false, false).
+		Add("flows", int64(ind.Flows), false, false).
+		Add("bytes", int64(ind.Bytes), false, false).
+		Add("packets", int64(ind.Packets), false, false).
+		Add("window", int64(ind.WindowEnd.Sub(ind.WindowStart).Seconds()), false, false)
+
+	if ind.Type == threat.TypeBlocklist {
+		kvs = kvs.Add("dest_ip", common.IPBytesToString(ind.DstAddr), false, false).
+			Add("blocklist_entry", ind.Blocked, false, false)
+	} else {
+		kvs = kvs.Add("distinct_ports", int64(ind.DistinctPorts), false, false).
+			Add("distinct_hosts", int64(ind.DistinctHosts), false, false)
+	}
+
+	return point.NewPointV2(metrics.ThreatName, kvs,
+		append(point.DefaultLoggingOptions(), point.WithTime(ind.WindowEnd))...)
+}
+
+func (agg *FlowAggregator) sendThreats(indicators []*threat.Indicator) {
+	if len(indicators) == 0 {
+		return
+	}
+
+	pts := make([]*point.Point, 0, len(indicators))
+	for _, ind := range indicators {
+		pts = append(pts, agg.threatPoint(ind))
+	}
+
+	if err := agg.feeder.FeedV2(point.Security, pts,
+		dkio.WithInputName(common.InputName+"/"+metrics.ThreatName),
+	); err != nil {
+		l.Errorf("Feed failed: %v", err)
+	}
+}
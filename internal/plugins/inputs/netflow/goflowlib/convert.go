@@ -7,12 +7,23 @@
 package goflowlib
 
 import (
+	"net"
+	"time"
+
+	"github.com/netsampler/goflow2/decoders/sflow"
 	flowpb "github.com/netsampler/goflow2/pb"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/common"
 )
 
-// ConvertFlow convert goflow flow structure to internal flow structure.
+// ConvertFlow convert goflow flow structure to internal flow structure, bytes
+// and packets of sampled flows are scaled by the sampling rate, with values
+// exported kept in RawBytes and RawPackets.
 func ConvertFlow(srcFlow *flowpb.FlowMessage, namespace string) *common.Flow {
+	scale := srcFlow.SamplingRate
+	if scale == 0 {
+		scale = 1
+	}
+
 	return &common.Flow{
 		Namespace:       namespace,
 		FlowType:        convertFlowType(srcFlow.Type),
@@ -22,8 +33,10 @@ func ConvertFlow(srcFlow *flowpb.FlowMessage, namespace string) *common.Flow {
 		ExporterAddr:    srcFlow.SamplerAddress, // Sampler is renamed to Exporter since it's a more commonly used
 		StartTimestamp:  srcFlow.TimeFlowStart,
 		EndTimestamp:    srcFlow.TimeFlowEnd,
-		Bytes:           srcFlow.Bytes,
-		Packets:         srcFlow.Packets,
+		Bytes:           srcFlow.Bytes * scale,
+		Packets:         srcFlow.Packets * scale,
+		RawBytes:        srcFlow.Bytes,
+		RawPackets:      srcFlow.Packets,
 		SrcAddr:         srcFlow.SrcAddr,
 		DstAddr:         srcFlow.DstAddr,
 		SrcMac:          srcFlow.SrcMac,
@@ -39,6 +52,47 @@ func ConvertFlow(srcFlow *flowpb.FlowMessage, namespace string) *common.Flow {
 		Tos:             srcFlow.IpTos,
 		NextHop:         srcFlow.NextHop,
 		TCPFlags:        srcFlow.TcpFlags,
+		ApplicationID:   srcFlow.CustomBytes_1,
+		TunnelType:      common.TunnelType(srcFlow.CustomInteger_4 >> tunnelSegmentTypeShift),
+		TunnelID:        srcFlow.CustomInteger_4 & (1<<tunnelSegmentTypeShift - 1),
+		InnerSrcAddr:    srcFlow.CustomBytes_2,
+		InnerDstAddr:    srcFlow.CustomBytes_3,
+		InnerIPProtocol: uint32(srcFlow.CustomInteger_1),
+		InnerSrcPort:    int32(srcFlow.CustomInteger_2),
+		InnerDstPort:    int32(srcFlow.CustomInteger_3),
+	}
+}
+
+// ConvertIfCounters convert generic interface counters of sFlow counter sample to internal structure.
+func ConvertIfCounters(src *sflow.IfCounters, packet *sflow.Packet, namespace string, received time.Time) *common.InterfaceCounters {
+	agentAddr := packet.AgentIP
+	if ip4 := net.IP(agentAddr).To4(); ip4 != nil {
+		agentAddr = ip4
+	}
+
+	return &common.InterfaceCounters{
+		Namespace:        namespace,
+		AgentAddr:        agentAddr,
+		SubAgentID:       packet.SubAgentId,
+		TimeReceived:     received,
+		IfIndex:          src.IfIndex,
+		IfType:           src.IfType,
+		IfSpeed:          src.IfSpeed,
+		IfDirection:      src.IfDirection,
+		IfStatus:         src.IfStatus,
+		InOctets:         src.IfInOctets,
+		InUcastPkts:      src.IfInUcastPkts,
+		InMulticastPkts:  src.IfInMulticastPkts,
+		InBroadcastPkts:  src.IfInBroadcastPkts,
+		InDiscards:       src.IfInDiscards,
+		InErrors:         src.IfInErrors,
+		InUnknownProtos:  src.IfInUnknownProtos,
+		OutOctets:        src.IfOutOctets,
+		OutUcastPkts:     src.IfOutUcastPkts,
+		OutMulticastPkts: src.IfOutMulticastPkts,
+		OutBroadcastPkts: src.IfOutBroadcastPkts,
+		OutDiscards:      src.IfOutDiscards,
+		OutErrors:        src.IfOutErrors,
 	}
 }
 
@@ -73,6 +73,7 @@ func TestConvertFlow(t *testing.T) {
 		OutIf:          20,
 		IpTos:          3,
 		NextHop:        []byte{10, 10, 10, 30},
+		CustomBytes_1:  []byte{3, 0, 80},
 	}
 	expectedFlow := common.Flow{
 		Namespace:       "my-ns",
@@ -83,8 +84,10 @@ func TestConvertFlow(t *testing.T) {
 		ExporterAddr:    []byte{127, 0, 0, 1},
 		StartTimestamp:  1234568,
 		EndTimestamp:    1234569,
-		Bytes:           10,
-		Packets:         2,
+		Bytes:           100,
+		Packets:         20,
+		RawBytes:        10,
+		RawPackets:      2,
 		SrcAddr:         []byte{10, 10, 10, 10},
 		DstAddr:         []byte{10, 10, 10, 20},
 		SrcMac:          uint64(10),
@@ -99,7 +102,32 @@ func TestConvertFlow(t *testing.T) {
 		OutputInterface: 20,
 		Tos:             3,
 		NextHop:         []byte{10, 10, 10, 30},
+		ApplicationID:   []byte{3, 0, 80},
 	}
 	actualFlow := ConvertFlow(&srcFlow, "my-ns")
 	assert.Equal(t, expectedFlow, *actualFlow)
+
+	// not sampled
+	srcFlow.SamplingRate = 0
+	actualFlow = ConvertFlow(&srcFlow, "my-ns")
+	assert.Equal(t, uint64(10), actualFlow.Bytes)
+	assert.Equal(t, uint64(2), actualFlow.Packets)
+	assert.False(t, actualFlow.Encapsulated())
+
+	// encapsulated
+	srcFlow.CustomBytes_2 = []byte{192, 168, 0, 1}
+	srcFlow.CustomBytes_3 = []byte{192, 168, 0, 2}
+	srcFlow.CustomInteger_1 = 17
+	srcFlow.CustomInteger_2 = 53000
+	srcFlow.CustomInteger_3 = 53
+	srcFlow.CustomInteger_4 = 1<<56 | 5000
+	actualFlow = ConvertFlow(&srcFlow, "my-ns")
+	assert.True(t, actualFlow.Encapsulated())
+	assert.Equal(t, common.TunnelVXLAN, actualFlow.TunnelType)
+	assert.Equal(t, uint64(5000), actualFlow.TunnelID)
+	assert.Equal(t, []byte{192, 168, 0, 1}, actualFlow.InnerSrcAddr)
+	assert.Equal(t, []byte{192, 168, 0, 2}, actualFlow.InnerDstAddr)
+	assert.Equal(t, uint32(17), actualFlow.InnerIPProtocol)
+	assert.Equal(t, int32(53000), actualFlow.InnerSrcPort)
+	assert.Equal(t, int32(53), actualFlow.InnerDstPort)
 }
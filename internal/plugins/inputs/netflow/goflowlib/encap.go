@@ -0,0 +1,248 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package goflowlib
+
+import (
+	"encoding/binary"
+
+	"github.com/netsampler/goflow2/decoders/sflow"
+	flowpb "github.com/netsampler/goflow2/pb"
+	"github.com/netsampler/goflow2/producer"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/common"
+)
+
+// Inner headers of encapsulated flows are kept in custom fields of flow
+// message, from template fields of IPFIX or sampled headers of sFlow:
+//
+//   - CustomBytes_2/CustomBytes_3: inner source/destination address
+//   - CustomInteger_1: inner IP protocol
+//   - CustomInteger_2/CustomInteger_3: inner source/destination port
+//   - CustomInteger_4: tunnel in format of IPFIX layer2SegmentId, tunnel type
+//     in the first octet followed by the segment ID (VNI or GRE key)
+const (
+	ieLayer2SegmentID = 351
+
+	// VMware exports inner headers of VXLAN/GENEVE flows as tenant fields.
+	penVMware          = 6876
+	ieTenantProtocol   = 880
+	ieTenantSourceIPv4 = 881
+	ieTenantDestIPv4   = 882
+	ieTenantSourceIPv6 = 883
+	ieTenantDestIPv6   = 884
+	ieTenantSourcePort = 886
+	ieTenantDestPort   = 887
+
+	// tunnelSegmentTypeShift is the shift of the segment type of layer2SegmentId.
+	tunnelSegmentTypeShift = 56
+)
+
+// encapMapping maps template fields of inner headers to custom fields.
+var encapMapping = []producer.NetFlowMapField{
+	{Type: ieLayer2SegmentID, Destination: "CustomInteger_4"},
+	{PenProvided: true, Pen: penVMware, Type: ieTenantProtocol, Destination: "CustomInteger_1"},
+	{PenProvided: true, Pen: penVMware, Type: ieTenantSourceIPv4, Destination: "CustomBytes_2"},
+	{PenProvided: true, Pen: penVMware, Type: ieTenantDestIPv4, Destination: "CustomBytes_3"},
+	{PenProvided: true, Pen: penVMware, Type: ieTenantSourceIPv6, Destination: "CustomBytes_2"},
+	{PenProvided: true, Pen: penVMware, Type: ieTenantDestIPv6, Destination: "CustomBytes_3"},
+	{PenProvided: true, Pen: penVMware, Type: ieTenantSourcePort, Destination: "CustomInteger_2"},
+	{PenProvided: true, Pen: penVMware, Type: ieTenantDestPort, Destination: "CustomInteger_3"},
+}
+
+const (
+	etherTypeIPv4 = 0x0800
+	etherTypeIPv6 = 0x86dd
+	etherTypeVLAN = 0x8100
+	etherTypeQinQ = 0x88a8
+	etherTypeTEB  = 0x6558 // transparent Ethernet bridging, Ethernet frames in NVGRE
+
+	ipProtocolTCP = 6
+	ipProtocolUDP = 17
+	ipProtocolGRE = 47
+
+	vxlanPort = 4789
+
+	sflowHeaderEthernet = 1 // header protocol of sampled headers
+)
+
+// innerHeader is the inner L3/L4 header of an encapsulated packet.
+type innerHeader struct {
+	tunnel           common.TunnelType
+	segmentID        uint64
+	srcAddr, dstAddr []byte
+	ipProtocol       uint32
+	srcPort, dstPort uint16
+}
+
+// setInnerHeaders decodes inner headers of VXLAN/GRE packets from sampled
+// headers of flow samples into flow messages, flowMessageSet is produced from
+// the flow samples in order.
+func setInnerHeaders(flowMessageSet []*flowpb.FlowMessage, flowSamples []interface{}) {
+	for i, sample := range flowSamples {
+		if i >= len(flowMessageSet) {
+			return
+		}
+
+		var records []sflow.FlowRecord
+		switch s := sample.(type) {
+		case sflow.FlowSample:
+			records = s.Records
+		case sflow.ExpandedFlowSample:
+			records = s.Records
+		}
+
+		for _, record := range records {
+			header, ok := record.Data.(sflow.SampledHeader)
+			if !ok || header.Protocol != sflowHeaderEthernet {
+				continue
+			}
+			if inner := decodeEncapsulated(header.HeaderData); inner != nil {
+				inner.setTo(flowMessageSet[i])
+			}
+		}
+	}
+}
+
+func (h *innerHeader) setTo(fmsg *flowpb.FlowMessage) {
+	fmsg.CustomBytes_2 = h.srcAddr
+	fmsg.CustomBytes_3 = h.dstAddr
+	fmsg.CustomInteger_1 = uint64(h.ipProtocol)
+	fmsg.CustomInteger_2 = uint64(h.srcPort)
+	fmsg.CustomInteger_3 = uint64(h.dstPort)
+	fmsg.CustomInteger_4 = uint64(h.tunnel)<<tunnelSegmentTypeShift | h.segmentID
+}
+
+// decodeEncapsulated decodes the inner header of the Ethernet frame if it's
+// a VXLAN or GRE packet, nil if not encapsulated or truncated.
+func decodeEncapsulated(frame []byte) *innerHeader {
+	etherType, offset, ok := skipEthernet(frame, 0)
+	if !ok {
+		return nil
+	}
+
+	proto, _, _, l4, ok := decodeIP(frame, offset, etherType)
+	if !ok || l4 == 0 {
+		return nil
+	}
+
+	inner := &innerHeader{}
+
+	switch proto {
+	case ipProtocolUDP:
+		// UDP header followed by VXLAN header: flags(1) reserved(3) VNI(3) reserved(1)
+		if len(frame) < l4+16 || binary.BigEndian.Uint16(frame[l4+2:]) != vxlanPort || frame[l4+8]&0x08 == 0 {
+			return nil
+		}
+		inner.tunnel = common.TunnelVXLAN
+		inner.segmentID = uint64(binary.BigEndian.Uint32(frame[l4+12:]) >> 8)
+		if etherType, offset, ok = skipEthernet(frame, l4+16); !ok {
+			return nil
+		}
+
+	case ipProtocolGRE:
+		// flags and version(2) protocol type(2), optional checksum, key and sequence number
+		if len(frame) < l4+4 {
+			return nil
+		}
+		flags := binary.BigEndian.Uint16(frame[l4:])
+		if flags&0x7 != 0 { // enhanced GRE of PPTP
+			return nil
+		}
+		etherType = binary.BigEndian.Uint16(frame[l4+2:])
+		offset = l4 + 4
+
+		if flags&0x8000 != 0 { // checksum present
+			offset += 4
+		}
+		var key uint32
+		if flags&0x2000 != 0 { // key present
+			if len(frame) < offset+4 {
+				return nil
+			}
+			key = binary.BigEndian.Uint32(frame[offset:])
+			offset += 4
+		}
+		if flags&0x1000 != 0 { // sequence number present
+			offset += 4
+		}
+
+		if etherType == etherTypeTEB {
+			inner.tunnel = common.TunnelNVGRE
+			inner.segmentID = uint64(key >> 8) // VSID of NVGRE
+			if etherType, offset, ok = skipEthernet(frame, offset); !ok {
+				return nil
+			}
+		} else {
+			inner.tunnel = common.TunnelGRE
+			inner.segmentID = uint64(key)
+		}
+
+	default:
+		return nil
+	}
+
+	var src, dst []byte
+	if inner.ipProtocol, src, dst, l4, ok = decodeIP(frame, offset, etherType); !ok {
+		return nil
+	}
+	inner.srcAddr = append([]byte(nil), src...)
+	inner.dstAddr = append([]byte(nil), dst...)
+
+	if (inner.ipProtocol == ipProtocolTCP || inner.ipProtocol == ipProtocolUDP) && l4 > 0 && len(frame) >= l4+4 {
+		inner.srcPort = binary.BigEndian.Uint16(frame[l4:])
+		inner.dstPort = binary.BigEndian.Uint16(frame[l4+2:])
+	}
+
+	return inner
+}
+
+// skipEthernet returns the EtherType and offset of the payload of the Ethernet
+// frame at offset, VLAN tags skipped.
+func skipEthernet(frame []byte, offset int) (uint16, int, bool) {
+	if len(frame) < offset+14 {
+		return 0, 0, false
+	}
+	etherType := binary.BigEndian.Uint16(frame[offset+12:])
+	offset += 14
+
+	for etherType == etherTypeVLAN || etherType == etherTypeQinQ {
+		if len(frame) < offset+4 {
+			return 0, 0, false
+		}
+		etherType = binary.BigEndian.Uint16(frame[offset+2:])
+		offset += 4
+	}
+
+	return etherType, offset, true
+}
+
+// decodeIP decodes IPv4/IPv6 header at offset, returns IP protocol, addresses
+// and offset of L4 header, which is 0 for non-first fragments.
+func decodeIP(frame []byte, offset int, etherType uint16) (proto uint32, src, dst []byte, l4 int, ok bool) {
+	switch etherType {
+	case etherTypeIPv4:
+		if len(frame) < offset+20 {
+			return 0, nil, nil, 0, false
+		}
+		ihl := int(frame[offset]&0x0f) * 4
+		proto = uint32(frame[offset+9])
+		src, dst = frame[offset+12:offset+16], frame[offset+16:offset+20]
+		if binary.BigEndian.Uint16(frame[offset+6:])&0x1fff == 0 {
+			l4 = offset + ihl
+		}
+		return proto, src, dst, l4, true
+
+	case etherTypeIPv6:
+		if len(frame) < offset+40 {
+			return 0, nil, nil, 0, false
+		}
+		proto = uint32(frame[offset+6])
+		src, dst = frame[offset+8:offset+24], frame[offset+24:offset+40]
+		return proto, src, dst, offset + 40, true
+
+	default:
+		return 0, nil, nil, 0, false
+	}
+}
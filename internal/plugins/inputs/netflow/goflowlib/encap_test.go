@@ -0,0 +1,174 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package goflowlib
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/netsampler/goflow2/decoders/sflow"
+	flowpb "github.com/netsampler/goflow2/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/common"
+)
+
+func ethernetHeader(etherType uint16) []byte {
+	b := make([]byte, 14)
+	binary.BigEndian.PutUint16(b[12:], etherType)
+	return b
+}
+
+func ipv4Header(proto byte, src, dst []byte) []byte {
+	b := make([]byte, 20)
+	b[0] = 0x45
+	b[9] = proto
+	copy(b[12:], src)
+	copy(b[16:], dst)
+	return b
+}
+
+func l4Ports(src, dst uint16) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint16(b, src)
+	binary.BigEndian.PutUint16(b[2:], dst)
+	return b
+}
+
+func concat(parts ...[]byte) []byte {
+	var b []byte
+	for _, p := range parts {
+		b = append(b, p...)
+	}
+	return b
+}
+
+func innerTCPFrame() []byte {
+	return concat(
+		ethernetHeader(etherTypeIPv4),
+		ipv4Header(ipProtocolTCP, []byte{10, 244, 1, 2}, []byte{10, 244, 2, 3}),
+		l4Ports(41000, 8080),
+	)
+}
+
+func vxlanFrame(vni uint32) []byte {
+	vxlan := make([]byte, 8)
+	vxlan[0] = 0x08
+	binary.BigEndian.PutUint32(vxlan[4:], vni<<8)
+
+	return concat(
+		ethernetHeader(etherTypeIPv4),
+		ipv4Header(ipProtocolUDP, []byte{172, 16, 0, 1}, []byte{172, 16, 0, 2}),
+		l4Ports(50000, vxlanPort),
+		vxlan,
+		innerTCPFrame(),
+	)
+}
+
+func TestDecodeEncapsulated(t *testing.T) {
+	t.Run("vxlan", func(t *testing.T) {
+		inner := decodeEncapsulated(vxlanFrame(5001))
+		require.NotNil(t, inner)
+		assert.Equal(t, &innerHeader{
+			tunnel:     common.TunnelVXLAN,
+			segmentID:  5001,
+			srcAddr:    []byte{10, 244, 1, 2},
+			dstAddr:    []byte{10, 244, 2, 3},
+			ipProtocol: ipProtocolTCP,
+			srcPort:    41000,
+			dstPort:    8080,
+		}, inner)
+	})
+
+	t.Run("vxlan-vlan-tagged", func(t *testing.T) {
+		frame := vxlanFrame(7)
+		tagged := concat(ethernetHeader(etherTypeVLAN)[:12], []byte{0x81, 0x00, 0, 10, 0x08, 0x00}, frame[14:])
+		inner := decodeEncapsulated(tagged)
+		require.NotNil(t, inner)
+		assert.Equal(t, uint64(7), inner.segmentID)
+		assert.Equal(t, uint16(8080), inner.dstPort)
+	})
+
+	t.Run("nvgre", func(t *testing.T) {
+		gre := make([]byte, 8)
+		binary.BigEndian.PutUint16(gre, 0x2000) // key present
+		binary.BigEndian.PutUint16(gre[2:], etherTypeTEB)
+		binary.BigEndian.PutUint32(gre[4:], 300<<8)
+
+		frame := concat(
+			ethernetHeader(etherTypeIPv4),
+			ipv4Header(ipProtocolGRE, []byte{172, 16, 0, 1}, []byte{172, 16, 0, 2}),
+			gre,
+			innerTCPFrame(),
+		)
+		inner := decodeEncapsulated(frame)
+		require.NotNil(t, inner)
+		assert.Equal(t, common.TunnelNVGRE, inner.tunnel)
+		assert.Equal(t, uint64(300), inner.segmentID)
+		assert.Equal(t, []byte{10, 244, 1, 2}, inner.srcAddr)
+		assert.Equal(t, uint16(41000), inner.srcPort)
+	})
+
+	t.Run("gre", func(t *testing.T) {
+		gre := make([]byte, 4)
+		binary.BigEndian.PutUint16(gre[2:], etherTypeIPv4)
+
+		frame := concat(
+			ethernetHeader(etherTypeIPv4),
+			ipv4Header(ipProtocolGRE, []byte{172, 16, 0, 1}, []byte{172, 16, 0, 2}),
+			gre,
+			ipv4Header(ipProtocolUDP, []byte{192, 168, 1, 1}, []byte{192, 168, 1, 2}),
+			l4Ports(1234, 53),
+		)
+		inner := decodeEncapsulated(frame)
+		require.NotNil(t, inner)
+		assert.Equal(t, common.TunnelGRE, inner.tunnel)
+		assert.Equal(t, uint64(0), inner.segmentID)
+		assert.Equal(t, []byte{192, 168, 1, 2}, inner.dstAddr)
+		assert.Equal(t, uint32(ipProtocolUDP), inner.ipProtocol)
+		assert.Equal(t, uint16(53), inner.dstPort)
+	})
+
+	t.Run("not-encapsulated", func(t *testing.T) {
+		assert.Nil(t, decodeEncapsulated(innerTCPFrame()))
+
+		frame := vxlanFrame(1)
+		binary.BigEndian.PutUint16(frame[14+20+2:], 53) // not VXLAN port
+		assert.Nil(t, decodeEncapsulated(frame))
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		frame := vxlanFrame(1)
+		assert.Nil(t, decodeEncapsulated(frame[:14+20+8+8+10]))
+		assert.Nil(t, decodeEncapsulated(frame[:10]))
+	})
+}
+
+func TestSetInnerHeaders(t *testing.T) {
+	flowMessageSet := []*flowpb.FlowMessage{{}, {}}
+	flowSamples := []interface{}{
+		sflow.FlowSample{Records: []sflow.FlowRecord{
+			{Data: sflow.SampledHeader{Protocol: sflowHeaderEthernet, HeaderData: innerTCPFrame()}},
+		}},
+		sflow.ExpandedFlowSample{Records: []sflow.FlowRecord{
+			{Data: sflow.SampledHeader{Protocol: sflowHeaderEthernet, HeaderData: vxlanFrame(42)}},
+		}},
+	}
+
+	setInnerHeaders(flowMessageSet, flowSamples)
+
+	assert.Equal(t, &flowpb.FlowMessage{}, flowMessageSet[0])
+
+	flow := ConvertFlow(flowMessageSet[1], "default")
+	assert.True(t, flow.Encapsulated())
+	assert.Equal(t, common.TunnelVXLAN, flow.TunnelType)
+	assert.Equal(t, uint64(42), flow.TunnelID)
+	assert.Equal(t, []byte{10, 244, 1, 2}, flow.InnerSrcAddr)
+	assert.Equal(t, []byte{10, 244, 2, 3}, flow.InnerDstAddr)
+	assert.Equal(t, uint32(ipProtocolTCP), flow.InnerIPProtocol)
+	assert.Equal(t, int32(41000), flow.InnerSrcPort)
+	assert.Equal(t, int32(8080), flow.InnerDstPort)
+}
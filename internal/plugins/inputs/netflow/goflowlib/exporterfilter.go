@@ -0,0 +1,160 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package goflowlib
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/GuanceCloud/cliutils/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
+)
+
+const (
+	dropReasonNotAllowed  = "not_allowed"
+	dropReasonRateLimited = "rate_limited"
+
+	// maxExporterLimiters limits number of exporters rate limited, limiters
+	// cleared once it's full, in case of spoofed source addresses.
+	maxExporterLimiters = 10000
+
+	// maxLabeledExporters limits the exporter label values of the dropped
+	// packets, the others are counted as exporterOther.
+	maxLabeledExporters = 100
+	exporterOther       = "other"
+)
+
+var droppedPacketsVec = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "datakit",
+		Subsystem: "input_netflow",
+		Name:      "dropped_packets_total",
+		Help:      "Flow packets dropped before decoding, exporter is empty for packets not allowed, and other for exporters exceeding the label limit",
+	},
+	[]string{"exporter", "reason"},
+)
+
+//nolint:gochecknoinits
+func init() {
+	metrics.MustRegister(droppedPacketsVec)
+}
+
+// ExporterFilter drops packets of exporters not allowed, or exceeding the
+// packets/sec limit of each exporter, before they are decoded.
+type ExporterFilter struct {
+	allowed []*net.IPNet
+	limit   rate.Limit
+	burst   int
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter // exporter IP -> limiter
+	labeled  map[string]bool          // exporters used as label value
+}
+
+// NewExporterFilter returns nil if all exporters allowed without limits.
+// allowed are CIDRs or IPs, packetsPerSecond is the limit of each exporter,
+// 0 for no limit.
+func NewExporterFilter(allowed []string, packetsPerSecond float64) (*ExporterFilter, error) {
+	if len(allowed) == 0 && packetsPerSecond <= 0 {
+		return nil, nil
+	}
+
+	f := &ExporterFilter{limiters: make(map[string]*rate.Limiter), labeled: make(map[string]bool)}
+
+	for _, s := range allowed {
+		if !strings.Contains(s, "/") {
+			if ip := net.ParseIP(s); ip != nil && ip.To4() != nil {
+				s += "/32"
+			} else {
+				s += "/128"
+			}
+		}
+
+		_, ipNet, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowed exporter %q: %w", s, err)
+		}
+		f.allowed = append(f.allowed, ipNet)
+	}
+
+	if packetsPerSecond > 0 {
+		f.limit = rate.Limit(packetsPerSecond)
+		f.burst = int(packetsPerSecond)
+		if f.burst < 1 {
+			f.burst = 1
+		}
+	}
+
+	return f, nil
+}
+
+// Allow check the packet from the exporter address, drops counted if not allowed.
+func (f *ExporterFilter) Allow(exporter net.IP) bool {
+	if f == nil {
+		return true
+	}
+
+	if len(f.allowed) > 0 && !f.isAllowed(exporter) {
+		droppedPacketsVec.WithLabelValues("", dropReasonNotAllowed).Inc()
+		return false
+	}
+
+	if f.limit > 0 && !f.limiter(exporter).Allow() {
+		droppedPacketsVec.WithLabelValues(f.exporterLabel(exporter), dropReasonRateLimited).Inc()
+		return false
+	}
+
+	return true
+}
+
+// exporterLabel returns the exporter IP as the label value, or exporterOther
+// if too many exporters labeled, in case of spoofed source addresses.
+func (f *ExporterFilter) exporterLabel(exporter net.IP) string {
+	s := exporter.String()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.labeled[s] {
+		return s
+	}
+
+	if len(f.labeled) >= maxLabeledExporters {
+		return exporterOther
+	}
+
+	f.labeled[s] = true
+	return s
+}
+
+func (f *ExporterFilter) isAllowed(exporter net.IP) bool {
+	for _, ipNet := range f.allowed {
+		if ipNet.Contains(exporter) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *ExporterFilter) limiter(exporter net.IP) *rate.Limiter {
+	key := string(exporter.To16())
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	lim, ok := f.limiters[key]
+	if !ok {
+		if len(f.limiters) >= maxExporterLimiters {
+			f.limiters = make(map[string]*rate.Limiter)
+		}
+		lim = rate.NewLimiter(f.limit, f.burst)
+		f.limiters[key] = lim
+	}
+	return lim
+}
@@ -0,0 +1,99 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package goflowlib
+
+import (
+	"net"
+	"testing"
+
+	"github.com/netsampler/goflow2/utils"
+	"github.com/prometheus/client_golang/prometheus"
+	promClient "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/common"
+)
+
+func counterValue(c prometheus.Counter) float64 {
+	var m promClient.Metric
+	c.Write(&m) //nolint:errcheck,gosec
+	return m.GetCounter().GetValue()
+}
+
+func TestExporterFilter(t *testing.T) {
+	f, err := NewExporterFilter(nil, 0)
+	require.NoError(t, err)
+	assert.Nil(t, f)
+	assert.True(t, f.Allow(net.IPv4(1, 2, 3, 4)))
+
+	_, err = NewExporterFilter([]string{"10.0.0.0/33"}, 0)
+	assert.Error(t, err)
+	_, err = NewExporterFilter([]string{"not-ip"}, 0)
+	assert.Error(t, err)
+
+	t.Run("allowed", func(t *testing.T) {
+		f, err := NewExporterFilter([]string{"10.0.0.0/8", "192.168.1.1", "2001:db8::/32"}, 0)
+		require.NoError(t, err)
+
+		notAllowed := droppedPacketsVec.WithLabelValues("", dropReasonNotAllowed)
+		before := counterValue(notAllowed)
+
+		assert.True(t, f.Allow(net.IPv4(10, 1, 2, 3)))
+		assert.True(t, f.Allow(net.IP{192, 168, 1, 1}))
+		assert.True(t, f.Allow(net.ParseIP("2001:db8::1")))
+		assert.False(t, f.Allow(net.IPv4(192, 168, 1, 2)))
+		assert.False(t, f.Allow(net.ParseIP("2001:db9::1")))
+		assert.Equal(t, before+2, counterValue(notAllowed))
+	})
+
+	t.Run("rate-limited", func(t *testing.T) {
+		f, err := NewExporterFilter(nil, 2)
+		require.NoError(t, err)
+
+		rateLimited := droppedPacketsVec.WithLabelValues("10.0.0.1", dropReasonRateLimited)
+		before := counterValue(rateLimited)
+
+		exporter := net.IPv4(10, 0, 0, 1)
+		assert.True(t, f.Allow(exporter))
+		assert.True(t, f.Allow(exporter))
+		assert.False(t, f.Allow(exporter))
+		assert.True(t, f.Allow(net.IPv4(10, 0, 0, 2)), "limited per exporter")
+		assert.Equal(t, before+1, counterValue(rateLimited))
+	})
+
+	t.Run("label-limit", func(t *testing.T) {
+		f, err := NewExporterFilter(nil, 1)
+		require.NoError(t, err)
+
+		for i := 0; i < maxLabeledExporters; i++ {
+			assert.Equal(t, net.IPv4(10, 1, 0, byte(i)).String(), f.exporterLabel(net.IPv4(10, 1, 0, byte(i))))
+		}
+
+		assert.Equal(t, exporterOther, f.exporterLabel(net.IPv4(10, 2, 0, 1)))
+		assert.Equal(t, "10.1.0.1", f.exporterLabel(net.IPv4(10, 1, 0, 1)), "labeled before")
+	})
+}
+
+func TestStateNetFlow_filter(t *testing.T) {
+	f, err := NewExporterFilter([]string{"10.0.0.0/8"}, 0)
+	require.NoError(t, err)
+
+	flowIn := make(chan *common.Flow, 10)
+	state := newStateNetFlow()
+	state.Format = NewAggregatorFormatDriver(flowIn, "my-ns")
+	state.TemplateSystem = newTemplateStore("")
+	state.Filter = f
+
+	pkt := ipfixPacket(
+		ipfixSet(2, uint16(256), uint16(1), uint16(1), uint16(8)),
+		ipfixSet(256, uint64(15)),
+	)
+	require.NoError(t, state.DecodeFlow(utils.BaseMessage{Src: net.IP{127, 0, 0, 1}, Payload: pkt}))
+	assert.Len(t, flowIn, 0)
+
+	require.NoError(t, state.DecodeFlow(utils.BaseMessage{Src: net.IP{10, 0, 0, 1}, Payload: pkt}))
+	assert.Len(t, flowIn, 1)
+}
@@ -8,13 +8,10 @@
 package goflowlib
 
 import (
-	"context"
 	"fmt"
 
-	"github.com/netsampler/goflow2/decoders/netflow/templates"
-	_ "github.com/netsampler/goflow2/decoders/netflow/templates/memory"
-	"github.com/netsampler/goflow2/utils"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/common"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/enrichment"
 )
 
 // setting reusePort to false since not expected to be useful
@@ -37,39 +34,43 @@ type FlowRunnableState interface {
 	Shutdown()
 }
 
-// StartFlowRoutine starts one of the goflow flow routine depending on the flow type.
+// StartFlowRoutine starts one of the goflow flow routine depending on the flow type,
+// metadata of NetFlow v9/IPFIX exporters saved into exporterMetadata, and interface
+// counters of sFlow sent to counterInChan. Flows are enriched by geoIP if not nil.
+// NetFlow v9/IPFIX templates are persisted to templatePath if not empty. Packets
+// of exporters are dropped by filter if not nil.
 //
 //nolint:lll
-func StartFlowRoutine(flowType common.FlowType, hostname string, port uint16, workers int, namespace string, flowInChan chan *common.Flow) (*FlowStateWrapper, error) {
+func StartFlowRoutine(flowType common.FlowType, hostname string, port uint16, workers int, namespace string, flowInChan chan *common.Flow, counterInChan chan []*common.InterfaceCounters, exporterMetadata *common.ExporterMetadata, geoIP *enrichment.GeoIP, templatePath string, filter *ExporterFilter) (*FlowStateWrapper, error) {
 	var flowState FlowRunnableState
 
 	formatDriver := NewAggregatorFormatDriver(flowInChan, namespace)
+	formatDriver.geoIP = geoIP
 	logger := GetLogrusLevel()
-	ctx := context.Background()
 
 	//nolint:exhaustive
 	switch flowType {
 	case common.TypeNetFlow9, common.TypeIPFIX:
-		templateSystem, err := templates.FindTemplateSystem(ctx, "memory")
-		if err != nil {
-			return nil, fmt.Errorf("goflow template system error flow type: %w", err)
-		}
-		defer templateSystem.Close(ctx) //nolint:errcheck
-
-		state := utils.NewStateNetFlow()
+		state := newStateNetFlow()
 		state.Format = formatDriver
 		state.Logger = logger
-		state.TemplateSystem = templateSystem
+		state.TemplateSystem = newTemplateStore(templatePath)
+		state.Metadata = exporterMetadata
+		state.Filter = filter
 		flowState = state
 	case common.TypeSFlow5:
-		state := utils.NewStateSFlow()
+		state := newStateSFlow()
 		state.Format = formatDriver
 		state.Logger = logger
+		state.Namespace = namespace
+		state.CounterIn = counterInChan
+		state.Filter = filter
 		flowState = state
 	case common.TypeNetFlow5:
-		state := utils.NewStateNFLegacy()
+		state := newStateNFLegacy()
 		state.Format = formatDriver
 		state.Logger = logger
+		state.Filter = filter
 		flowState = state
 	default:
 		return nil, fmt.Errorf("unknown flow type: %s", flowType)
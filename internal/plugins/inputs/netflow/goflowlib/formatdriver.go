@@ -12,12 +12,14 @@ import (
 
 	flowpb "github.com/netsampler/goflow2/pb"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/common"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/enrichment"
 )
 
 // AggregatorFormatDriver is used as goflow formatter to forward flow data to aggregator/EP Forwarder.
 type AggregatorFormatDriver struct {
 	namespace string
 	flowAggIn chan *common.Flow
+	geoIP     *enrichment.GeoIP // nil if GeoIP enrichment not enabled
 }
 
 // NewAggregatorFormatDriver returns a new AggregatorFormatDriver.
@@ -44,6 +46,11 @@ func (d *AggregatorFormatDriver) Format(data interface{}) ([]byte, []byte, error
 	if !ok {
 		return nil, nil, fmt.Errorf("message is not flowpb.FlowMessage")
 	}
-	d.flowAggIn <- ConvertFlow(flow, d.namespace)
+	aggFlow := ConvertFlow(flow, d.namespace)
+	if d.geoIP != nil {
+		aggFlow.SrcGeo = d.geoIP.Lookup(aggFlow.SrcAddr)
+		aggFlow.DstGeo = d.geoIP.Lookup(aggFlow.DstAddr)
+	}
+	d.flowAggIn <- aggFlow
 	return nil, nil, nil
 }
@@ -0,0 +1,227 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+// Some code modified from project goflow2 (https://github.com/netsampler/goflow2).
+
+package goflowlib
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/netsampler/goflow2/decoders/netflow"
+	"github.com/netsampler/goflow2/decoders/netflow/templates"
+	"github.com/netsampler/goflow2/format"
+	flowpb "github.com/netsampler/goflow2/pb"
+	"github.com/netsampler/goflow2/producer"
+	"github.com/netsampler/goflow2/utils"
+	"github.com/prometheus/client_golang/prometheus"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/common"
+)
+
+// ieApplicationID is IPFIX applicationId, mapped to CustomBytes_1 of flow message.
+const ieApplicationID = 95
+
+// producerConfig maps fields that goflow does not decode by default.
+var producerConfig = &producer.ProducerConfig{
+	IPFIX: producer.IPFIXProducerConfig{
+		Mapping: append([]producer.NetFlowMapField{{Type: ieApplicationID, Destination: "CustomBytes_1"}}, encapMapping...),
+	},
+	NetFlowV9: producer.NetFlowV9ProducerConfig{
+		Mapping: []producer.NetFlowMapField{{Type: ieApplicationID, Destination: "CustomBytes_1"}},
+	},
+}
+
+// stateNetFlow works like utils.StateNetFlow of goflow, except that options
+// data records are decoded into exporter metadata, which utils.StateNetFlow
+// only looks for sampling rate.
+type stateNetFlow struct {
+	Format         format.FormatInterface
+	Logger         utils.Logger
+	TemplateSystem templates.TemplateInterface
+	Metadata       *common.ExporterMetadata
+	Filter         *ExporterFilter
+
+	stopCh       chan struct{}
+	samplingLock sync.RWMutex
+	sampling     map[string]producer.SamplingRateSystem
+	configMapped *producer.ProducerConfigMapped
+	ctx          context.Context
+}
+
+func newStateNetFlow() *stateNetFlow {
+	return &stateNetFlow{
+		ctx:          context.Background(),
+		stopCh:       make(chan struct{}),
+		sampling:     make(map[string]producer.SamplingRateSystem),
+		configMapped: producer.NewProducerConfigMapped(producerConfig),
+	}
+}
+
+func (s *stateNetFlow) samplingSystem(key string) producer.SamplingRateSystem {
+	s.samplingLock.RLock()
+	sampling, ok := s.sampling[key]
+	s.samplingLock.RUnlock()
+	if ok {
+		return sampling
+	}
+
+	s.samplingLock.Lock()
+	defer s.samplingLock.Unlock()
+	if sampling, ok = s.sampling[key]; !ok {
+		sampling = producer.CreateSamplingSystem()
+		s.sampling[key] = sampling
+	}
+	return sampling
+}
+
+// DecodeFlow decodes NetFlow v9/IPFIX packet and sends flows to Format.
+func (s *stateNetFlow) DecodeFlow(msg interface{}) error {
+	pkt, ok := msg.(utils.BaseMessage)
+	if !ok {
+		return fmt.Errorf("message is not utils.BaseMessage")
+	}
+
+	if !s.Filter.Allow(pkt.Src) {
+		return nil
+	}
+
+	key := pkt.Src.String()
+	samplerAddress := pkt.Src
+	if samplerAddress.To4() != nil {
+		samplerAddress = samplerAddress.To4()
+	}
+
+	ts := uint64(time.Now().UTC().Unix())
+	if pkt.SetTime {
+		ts = uint64(pkt.RecvTime.UTC().Unix())
+	}
+
+	timeTrackStart := time.Now()
+	msgDec, err := netflow.DecodeMessageContext(s.ctx, bytes.NewBuffer(pkt.Payload), key,
+		netflow.TemplateWrapper{Ctx: s.ctx, Key: key, Inner: s.TemplateSystem})
+	if err != nil {
+		errType := "error_decoding"
+		if _, ok := err.(*netflow.ErrorTemplateNotFound); ok {
+			errType = "template_not_found"
+		}
+		utils.NetFlowErrors.With(prometheus.Labels{"router": key, "error": errType}).Inc()
+		return err
+	}
+
+	var (
+		version    uint16
+		flowSets   []interface{}
+		optionSets []netflow.OptionsDataFlowSet
+	)
+
+	switch msgDecConv := msgDec.(type) {
+	case netflow.NFv9Packet:
+		version, flowSets = 9, msgDecConv.FlowSets
+		_, _, _, optionSets = producer.SplitNetFlowSets(msgDecConv)
+	case netflow.IPFIXPacket:
+		version, flowSets = 10, msgDecConv.FlowSets
+		_, _, _, optionSets = producer.SplitIPFIXSets(msgDecConv)
+	default:
+		return fmt.Errorf("bad NetFlow/IPFIX version")
+	}
+
+	versionStr := strconv.Itoa(int(version))
+	utils.NetFlowStats.With(prometheus.Labels{"router": key, "version": versionStr}).Inc()
+	for _, fs := range flowSets {
+		fsType, records := flowSetStats(fs)
+		if fsType == "" {
+			continue
+		}
+		labels := prometheus.Labels{"router": key, "version": versionStr, "type": fsType}
+		utils.NetFlowSetStatsSum.With(labels).Inc()
+		utils.NetFlowSetRecordsStatsSum.With(labels).Add(float64(records))
+	}
+
+	s.updateMetadata(version, samplerAddress, optionSets)
+
+	flowMessageSet, err := producer.ProcessMessageNetFlowConfig(msgDec, s.samplingSystem(key), s.configMapped)
+	if err != nil {
+		return err
+	}
+
+	for _, fmsg := range flowMessageSet {
+		fmsg.TimeReceived = ts
+		fmsg.SamplerAddress = samplerAddress
+		utils.NetFlowTimeStatsSum.With(prometheus.Labels{"router": key, "version": versionStr}).
+			Observe(float64(fmsg.TimeReceived - fmsg.TimeFlowEnd))
+	}
+
+	utils.DecoderTime.With(prometheus.Labels{"name": "NetFlow"}).
+		Observe(float64(time.Since(timeTrackStart).Nanoseconds()) / 1000)
+
+	s.format(flowMessageSet)
+	return nil
+}
+
+func (s *stateNetFlow) format(flowMessageSet []*flowpb.FlowMessage) {
+	if s.Format == nil {
+		return
+	}
+
+	for _, fmsg := range flowMessageSet {
+		if _, _, err := s.Format.Format(fmsg); err != nil && s.Logger != nil {
+			s.Logger.Error(err)
+		}
+	}
+}
+
+// updateMetadata saves interface and application names in options data records.
+func (s *stateNetFlow) updateMetadata(version uint16, exporterAddr []byte, optionSets []netflow.OptionsDataFlowSet) {
+	if s.Metadata == nil {
+		return
+	}
+
+	for _, fs := range optionSets {
+		for _, record := range fs.Records {
+			opt := parseOptionsRecord(version, record)
+			if opt.hasInterface && opt.interfaceName != "" {
+				s.Metadata.SetInterfaceName(exporterAddr, opt.interfaceIndex, opt.interfaceName)
+			}
+			if opt.applicationID != "" && opt.applicationName != "" {
+				s.Metadata.SetApplicationName(exporterAddr, opt.applicationID, opt.applicationName)
+			}
+		}
+	}
+}
+
+func flowSetStats(fs interface{}) (string, int) {
+	switch fsConv := fs.(type) {
+	case netflow.TemplateFlowSet:
+		return "TemplateFlowSet", len(fsConv.Records)
+	case netflow.NFv9OptionsTemplateFlowSet:
+		return "OptionsTemplateFlowSet", len(fsConv.Records)
+	case netflow.IPFIXOptionsTemplateFlowSet:
+		return "OptionsTemplateFlowSet", len(fsConv.Records)
+	case netflow.OptionsDataFlowSet:
+		return "OptionsDataFlowSet", len(fsConv.Records)
+	case netflow.DataFlowSet:
+		return "DataFlowSet", len(fsConv.Records)
+	default:
+		return "", 0
+	}
+}
+
+// FlowRoutine starts flow processing workers.
+func (s *stateNetFlow) FlowRoutine(workers int, addr string, port int, reuseport bool) error {
+	return utils.UDPStoppableRoutine(s.stopCh, "NetFlow", s.DecodeFlow, workers, addr, port, reuseport, s.Logger)
+}
+
+// Shutdown trigger shutdown of the flow processing workers.
+func (s *stateNetFlow) Shutdown() {
+	select {
+	case <-s.stopCh:
+	default:
+		close(s.stopCh)
+	}
+}
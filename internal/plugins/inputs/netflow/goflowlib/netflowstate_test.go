@@ -0,0 +1,141 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package goflowlib
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"testing"
+
+	"github.com/netsampler/goflow2/decoders/netflow"
+	"github.com/netsampler/goflow2/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/common"
+)
+
+// ipfixSet builds IPFIX set with the ID and big-endian encoded values.
+func ipfixSet(id uint16, values ...interface{}) []byte {
+	var body bytes.Buffer
+	for _, v := range values {
+		binary.Write(&body, binary.BigEndian, v) //nolint:errcheck,gosec
+	}
+
+	var set bytes.Buffer
+	binary.Write(&set, binary.BigEndian, id)                   //nolint:errcheck,gosec
+	binary.Write(&set, binary.BigEndian, uint16(4+body.Len())) //nolint:errcheck,gosec
+	set.Write(body.Bytes())
+	return set.Bytes()
+}
+
+func ipfixPacket(sets ...[]byte) []byte {
+	var body bytes.Buffer
+	for _, s := range sets {
+		body.Write(s)
+	}
+
+	var pkt bytes.Buffer
+	binary.Write(&pkt, binary.BigEndian, uint16(10))            //nolint:errcheck,gosec
+	binary.Write(&pkt, binary.BigEndian, uint16(16+body.Len())) //nolint:errcheck,gosec
+	binary.Write(&pkt, binary.BigEndian, uint32(1700000000))    //nolint:errcheck,gosec
+	binary.Write(&pkt, binary.BigEndian, uint32(1))             //nolint:errcheck,gosec
+	binary.Write(&pkt, binary.BigEndian, uint32(0))             //nolint:errcheck,gosec
+	pkt.Write(body.Bytes())
+	return pkt.Bytes()
+}
+
+func fixedString(s string) [16]byte {
+	var b [16]byte
+	copy(b[:], s)
+	return b
+}
+
+func TestStateNetFlow_DecodeFlow(t *testing.T) {
+	flowIn := make(chan *common.Flow, 10)
+	exporterMetadata := common.NewExporterMetadata()
+
+	state := newStateNetFlow()
+	state.Format = NewAggregatorFormatDriver(flowIn, "my-ns")
+	state.TemplateSystem = newTemplateStore("")
+	state.Metadata = exporterMetadata
+
+	pkt := ipfixPacket(
+		// options template 256: scope ingressInterface, with interfaceName and samplingInterval
+		// options template 257: scope applicationId, with applicationName
+		ipfixSet(3,
+			uint16(256), uint16(3), uint16(1), uint16(10), uint16(4), uint16(82), uint16(16), uint16(34), uint16(4),
+			uint16(257), uint16(2), uint16(1), uint16(95), uint16(3), uint16(96), uint16(16),
+		),
+		// template 258: sourceIPv4Address, destinationIPv4Address, octetDeltaCount,
+		// packetDeltaCount, ingressInterface, applicationId
+		ipfixSet(2,
+			uint16(258), uint16(6),
+			uint16(8), uint16(4), uint16(12), uint16(4), uint16(1), uint16(8), uint16(2), uint16(8),
+			uint16(10), uint16(4), uint16(95), uint16(3),
+		),
+		ipfixSet(256, uint32(3), fixedString("eth0"), uint32(100)),
+		ipfixSet(257, [3]byte{3, 0, 80}, fixedString("http")),
+		ipfixSet(258, [4]byte{10, 0, 0, 1}, [4]byte{10, 0, 0, 2}, uint64(15), uint64(2), uint32(3), [3]byte{3, 0, 80}),
+	)
+
+	err := state.DecodeFlow(utils.BaseMessage{Src: net.IP{127, 0, 0, 1}, Payload: pkt})
+	require.NoError(t, err)
+
+	require.Len(t, flowIn, 1)
+	flow := <-flowIn
+	assert.Equal(t, common.TypeIPFIX, flow.FlowType)
+	assert.Equal(t, uint64(100), flow.SamplingRate)
+	assert.Equal(t, uint64(1500), flow.Bytes)
+	assert.Equal(t, uint64(200), flow.Packets)
+	assert.Equal(t, uint32(3), flow.InputInterface)
+	assert.Equal(t, []byte{3, 0, 80}, flow.ApplicationID)
+
+	assert.Equal(t, "eth0", exporterMetadata.InterfaceName([]byte{127, 0, 0, 1}, 3))
+	assert.Equal(t, "http", exporterMetadata.ApplicationName([]byte{127, 0, 0, 1}, "3:80"))
+	assert.Equal(t, "", exporterMetadata.InterfaceName([]byte{127, 0, 0, 2}, 3))
+}
+
+func TestParseOptionsRecord(t *testing.T) {
+	field := func(typ uint16, v []byte) netflow.DataField {
+		return netflow.DataField{Type: typ, Value: v}
+	}
+
+	t.Run("nfv9-interface-scope", func(t *testing.T) {
+		opt := parseOptionsRecord(9, netflow.OptionsDataRecord{
+			ScopesValues:  []netflow.DataField{field(nfv9ScopeInterface, []byte{0, 0, 0, 5})},
+			OptionsValues: []netflow.DataField{field(ieInterfaceDescription, []byte("uplink\x00\x00"))},
+		})
+		assert.Equal(t, optionsRecord{hasInterface: true, interfaceIndex: 5, interfaceName: "uplink"}, opt)
+	})
+
+	t.Run("nfv9-system-scope", func(t *testing.T) {
+		opt := parseOptionsRecord(9, netflow.OptionsDataRecord{
+			ScopesValues: []netflow.DataField{field(1, []byte{0, 0, 0, 1})},
+			OptionsValues: []netflow.DataField{
+				field(ieIngressInterface, []byte{0, 7}),
+				field(ieInterfaceName, []byte("Gi0/7")),
+				field(ieInterfaceDescription, []byte("to core")),
+			},
+		})
+		assert.Equal(t, optionsRecord{hasInterface: true, interfaceIndex: 7, interfaceName: "Gi0/7"}, opt)
+	})
+
+	t.Run("ipfix-application", func(t *testing.T) {
+		opt := parseOptionsRecord(10, netflow.OptionsDataRecord{
+			ScopesValues:  []netflow.DataField{field(ieApplicationID, []byte{13, 0, 0, 1, 197})},
+			OptionsValues: []netflow.DataField{field(ieApplicationDescription, []byte("Secure Shell"))},
+		})
+		assert.Equal(t, optionsRecord{applicationID: "13:453", applicationName: "Secure Shell"}, opt)
+	})
+
+	t.Run("enterprise-fields-ignored", func(t *testing.T) {
+		opt := parseOptionsRecord(10, netflow.OptionsDataRecord{
+			OptionsValues: []netflow.DataField{{PenProvided: true, Pen: 9, Type: ieInterfaceName, Value: []byte("x")}},
+		})
+		assert.Equal(t, optionsRecord{}, opt)
+	})
+}
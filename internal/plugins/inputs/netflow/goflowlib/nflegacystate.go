@@ -0,0 +1,47 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package goflowlib
+
+import (
+	"github.com/netsampler/goflow2/utils"
+)
+
+// stateNFLegacy wraps utils.StateNFLegacy of goflow, to drop packets by Filter before decoding.
+type stateNFLegacy struct {
+	*utils.StateNFLegacy
+	Filter *ExporterFilter
+
+	stopCh chan struct{}
+}
+
+func newStateNFLegacy() *stateNFLegacy {
+	return &stateNFLegacy{
+		StateNFLegacy: utils.NewStateNFLegacy(),
+		stopCh:        make(chan struct{}),
+	}
+}
+
+// DecodeFlow decodes NetFlow v5 packet and sends flows to Format.
+func (s *stateNFLegacy) DecodeFlow(msg interface{}) error {
+	if pkt, ok := msg.(utils.BaseMessage); ok && !s.Filter.Allow(pkt.Src) {
+		return nil
+	}
+	return s.StateNFLegacy.DecodeFlow(msg)
+}
+
+// FlowRoutine starts flow processing workers.
+func (s *stateNFLegacy) FlowRoutine(workers int, addr string, port int, reuseport bool) error {
+	return utils.UDPStoppableRoutine(s.stopCh, "NetFlowV5", s.DecodeFlow, workers, addr, port, reuseport, s.Logger)
+}
+
+// Shutdown trigger shutdown of the flow processing workers.
+func (s *stateNFLegacy) Shutdown() {
+	select {
+	case <-s.stopCh:
+	default:
+		close(s.stopCh)
+	}
+}
@@ -0,0 +1,122 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package goflowlib
+
+import (
+	"strings"
+
+	"github.com/netsampler/goflow2/decoders/netflow"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/enrichment"
+)
+
+// Information elements used in options data records.
+const (
+	ieIngressInterface       = 10
+	ieEgressInterface        = 14
+	ieInterfaceName          = 82
+	ieInterfaceDescription   = 83
+	ieApplicationDescription = 94
+	ieApplicationName        = 96
+
+	// nfv9ScopeInterface is the `Interface` scope type of NetFlow v9 options template.
+	nfv9ScopeInterface = 2
+)
+
+// optionsRecord is metadata decoded from an options data record.
+type optionsRecord struct {
+	hasInterface   bool
+	interfaceIndex uint32
+	interfaceName  string
+
+	applicationID   string
+	applicationName string
+}
+
+// parseOptionsRecord decodes interface table (ifIndex -> ifName/ifDescr) and
+// application table (applicationId -> applicationName/applicationDescription)
+// from options data record. ifName and applicationName are preferred to their
+// descriptions.
+func parseOptionsRecord(version uint16, record netflow.OptionsDataRecord) optionsRecord {
+	var (
+		opt                  optionsRecord
+		ifDescr, appDescr    string
+		fields               = append([]netflow.DataField{}, record.OptionsValues...)
+		nfv9InterfaceScoping bool
+	)
+
+	for _, scope := range record.ScopesValues {
+		if version == 9 {
+			// scope types of NetFlow v9 are not information elements
+			if scope.Type == nfv9ScopeInterface {
+				if v, ok := optionUint32(scope); ok {
+					opt.hasInterface, opt.interfaceIndex = true, v
+					nfv9InterfaceScoping = true
+				}
+			}
+			continue
+		}
+		fields = append(fields, scope)
+	}
+
+	for _, f := range fields {
+		if f.PenProvided {
+			continue
+		}
+
+		switch f.Type {
+		case ieIngressInterface, ieEgressInterface:
+			if nfv9InterfaceScoping {
+				continue
+			}
+			if v, ok := optionUint32(f); ok {
+				opt.hasInterface, opt.interfaceIndex = true, v
+			}
+		case ieInterfaceName:
+			opt.interfaceName = optionString(f)
+		case ieInterfaceDescription:
+			ifDescr = optionString(f)
+		case ieApplicationID:
+			if b, ok := f.Value.([]byte); ok {
+				opt.applicationID = enrichment.FormatApplicationID(b)
+			}
+		case ieApplicationName:
+			opt.applicationName = optionString(f)
+		case ieApplicationDescription:
+			appDescr = optionString(f)
+		}
+	}
+
+	if opt.interfaceName == "" {
+		opt.interfaceName = ifDescr
+	}
+	if opt.applicationName == "" {
+		opt.applicationName = appDescr
+	}
+
+	return opt
+}
+
+func optionUint32(f netflow.DataField) (uint32, bool) {
+	b, ok := f.Value.([]byte)
+	if !ok || len(b) == 0 || len(b) > 4 {
+		return 0, false
+	}
+
+	var v uint32
+	for _, c := range b {
+		v = v<<8 | uint32(c)
+	}
+	return v, true
+}
+
+// optionString decodes string value padded with NUL.
+func optionString(f netflow.DataField) string {
+	b, ok := f.Value.([]byte)
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimRight(string(b), "\x00"))
+}
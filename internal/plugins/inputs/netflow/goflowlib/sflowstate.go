@@ -0,0 +1,157 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+// Some code modified from project goflow2 (https://github.com/netsampler/goflow2).
+
+package goflowlib
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/netsampler/goflow2/decoders/sflow"
+	"github.com/netsampler/goflow2/format"
+	"github.com/netsampler/goflow2/producer"
+	"github.com/netsampler/goflow2/utils"
+	"github.com/prometheus/client_golang/prometheus"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/common"
+)
+
+// stateSFlow works like utils.StateSFlow of goflow, except that generic
+// interface counters in counter samples are sent to CounterIn, which
+// utils.StateSFlow drops, and inner headers of VXLAN/GRE packets sampled
+// are decoded.
+type stateSFlow struct {
+	Format    format.FormatInterface
+	Logger    utils.Logger
+	Namespace string
+	CounterIn chan []*common.InterfaceCounters
+	Filter    *ExporterFilter
+
+	stopCh       chan struct{}
+	configMapped *producer.ProducerConfigMapped
+}
+
+func newStateSFlow() *stateSFlow {
+	return &stateSFlow{
+		stopCh:       make(chan struct{}),
+		configMapped: producer.NewProducerConfigMapped(nil),
+	}
+}
+
+// DecodeFlow decodes sFlow packet, sends flows to Format and interface counters to CounterIn.
+func (s *stateSFlow) DecodeFlow(msg interface{}) error {
+	pkt, ok := msg.(utils.BaseMessage)
+	if !ok {
+		return fmt.Errorf("message is not utils.BaseMessage")
+	}
+
+	if !s.Filter.Allow(pkt.Src) {
+		return nil
+	}
+
+	key := pkt.Src.String()
+
+	now := time.Now()
+	if pkt.SetTime {
+		now = pkt.RecvTime
+	}
+	ts := uint64(now.UTC().Unix())
+
+	timeTrackStart := time.Now()
+	msgDec, err := sflow.DecodeMessage(bytes.NewBuffer(pkt.Payload))
+	if err != nil {
+		var errType string
+		switch err.(type) {
+		case *sflow.ErrorVersion:
+			errType = "error_version"
+		case *sflow.ErrorIPVersion:
+			errType = "error_ip_version"
+		case *sflow.ErrorDataFormat:
+			errType = "error_data_format"
+		default:
+			errType = "error_decoding"
+		}
+		utils.SFlowErrors.With(prometheus.Labels{"router": key, "error": errType}).Inc()
+		return err
+	}
+
+	packet, ok := msgDec.(sflow.Packet)
+	if !ok {
+		return fmt.Errorf("bad sFlow version")
+	}
+
+	agentStr := net.IP(packet.AgentIP).String()
+	utils.SFlowStats.With(prometheus.Labels{"router": key, "agent": agentStr, "version": "5"}).Inc()
+
+	var counters []*common.InterfaceCounters
+	for _, sample := range packet.Samples {
+		typeStr, records := "unknown", 0
+
+		switch sampleConv := sample.(type) {
+		case sflow.FlowSample:
+			typeStr, records = "FlowSample", len(sampleConv.Records)
+		case sflow.ExpandedFlowSample:
+			typeStr, records = "ExpandedFlowSample", len(sampleConv.Records)
+		case sflow.CounterSample:
+			typeStr, records = "CounterSample", len(sampleConv.Records)
+			if sampleConv.Header.Format == 4 {
+				typeStr = "Expanded" + typeStr
+			}
+
+			for _, record := range sampleConv.Records {
+				if ifCounters, ok := record.Data.(sflow.IfCounters); ok {
+					counters = append(counters, ConvertIfCounters(&ifCounters, &packet, s.Namespace, now))
+				}
+			}
+		}
+
+		labels := prometheus.Labels{"router": key, "agent": agentStr, "version": "5", "type": typeStr}
+		utils.SFlowSampleStatsSum.With(labels).Inc()
+		utils.SFlowSampleRecordsStatsSum.With(labels).Add(float64(records))
+	}
+
+	flowMessageSet, err := producer.ProcessMessageSFlowConfig(msgDec, s.configMapped)
+	if err != nil {
+		return err
+	}
+	setInnerHeaders(flowMessageSet, producer.GetSFlowFlowSamples(&packet))
+
+	utils.DecoderTime.With(prometheus.Labels{"name": "sFlow"}).
+		Observe(float64(time.Since(timeTrackStart).Nanoseconds()) / 1000)
+
+	for _, fmsg := range flowMessageSet {
+		fmsg.TimeReceived = ts
+		fmsg.TimeFlowStart = ts
+		fmsg.TimeFlowEnd = ts
+
+		if s.Format != nil {
+			if _, _, err := s.Format.Format(fmsg); err != nil && s.Logger != nil {
+				s.Logger.Error(err)
+			}
+		}
+	}
+
+	if len(counters) > 0 && s.CounterIn != nil {
+		s.CounterIn <- counters
+	}
+
+	return nil
+}
+
+// FlowRoutine starts flow processing workers.
+func (s *stateSFlow) FlowRoutine(workers int, addr string, port int, reuseport bool) error {
+	return utils.UDPStoppableRoutine(s.stopCh, "sFlow", s.DecodeFlow, workers, addr, port, reuseport, s.Logger)
+}
+
+// Shutdown trigger shutdown of the flow processing workers.
+func (s *stateSFlow) Shutdown() {
+	select {
+	case <-s.stopCh:
+	default:
+		close(s.stopCh)
+	}
+}
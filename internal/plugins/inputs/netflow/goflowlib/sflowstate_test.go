@@ -0,0 +1,90 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package goflowlib
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"testing"
+
+	"github.com/netsampler/goflow2/decoders/sflow"
+	"github.com/netsampler/goflow2/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/common"
+)
+
+func sflowCounterPacket(t *testing.T, counters sflow.IfCounters) []byte {
+	t.Helper()
+
+	var record bytes.Buffer
+	require.NoError(t, binary.Write(&record, binary.BigEndian, counters))
+
+	var sample bytes.Buffer
+	for _, v := range []uint32{
+		1,         // sample sequence number
+		0<<24 | 3, // source ID: ifIndex 3
+		1,         // records count
+		1,         // data format: generic interface counters
+		uint32(record.Len()),
+	} {
+		require.NoError(t, binary.Write(&sample, binary.BigEndian, v))
+	}
+	sample.Write(record.Bytes())
+
+	var pkt bytes.Buffer
+	for _, v := range []interface{}{
+		uint32(5), uint32(1), [4]byte{192, 168, 1, 1}, // version, IPv4 agent
+		uint32(0), uint32(100), uint32(1000), uint32(1), // sub-agent, sequence, uptime, samples count
+		uint32(2), uint32(sample.Len()), // counter sample
+	} {
+		require.NoError(t, binary.Write(&pkt, binary.BigEndian, v))
+	}
+	pkt.Write(sample.Bytes())
+
+	return pkt.Bytes()
+}
+
+func TestStateSFlow_DecodeFlow_counterSample(t *testing.T) {
+	flowIn := make(chan *common.Flow, 10)
+	counterIn := make(chan []*common.InterfaceCounters, 10)
+
+	state := newStateSFlow()
+	state.Format = NewAggregatorFormatDriver(flowIn, "my-ns")
+	state.Namespace = "my-ns"
+	state.CounterIn = counterIn
+
+	pkt := sflowCounterPacket(t, sflow.IfCounters{
+		IfIndex:       3,
+		IfType:        6,
+		IfSpeed:       1000000000,
+		IfStatus:      3,
+		IfInOctets:    123456,
+		IfInErrors:    2,
+		IfOutOctets:   654321,
+		IfOutDiscards: 1,
+	})
+
+	require.NoError(t, state.DecodeFlow(utils.BaseMessage{Src: net.IP{10, 0, 0, 1}, Payload: pkt}))
+
+	assert.Len(t, flowIn, 0)
+	require.Len(t, counterIn, 1)
+
+	counters := <-counterIn
+	require.Len(t, counters, 1)
+	c := counters[0]
+	assert.Equal(t, "my-ns", c.Namespace)
+	assert.Equal(t, []byte{192, 168, 1, 1}, c.AgentAddr)
+	assert.Equal(t, uint32(3), c.IfIndex)
+	assert.Equal(t, uint64(1000000000), c.IfSpeed)
+	assert.Equal(t, uint64(123456), c.InOctets)
+	assert.Equal(t, uint32(2), c.InErrors)
+	assert.Equal(t, uint64(654321), c.OutOctets)
+	assert.Equal(t, uint32(1), c.OutDiscards)
+	assert.Equal(t, common.AdminStatus_Up, c.AdminStatus())
+	assert.Equal(t, common.OperStatus_Up, c.OperStatus())
+}
@@ -0,0 +1,214 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package goflowlib
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/netsampler/goflow2/decoders/netflow"
+	"github.com/netsampler/goflow2/decoders/netflow/templates"
+)
+
+const (
+	// templateFileTTL is the max age of templates loaded from file, exporters
+	// re-announce templates periodically, so older ones are likely outdated.
+	templateFileTTL = 24 * time.Hour
+
+	// templateSaveInterval is the interval of saving unchanged templates, to
+	// refresh their updated time in file.
+	templateSaveInterval = 10 * time.Minute
+)
+
+var _ templates.TemplateInterface = (*templateStore)(nil)
+
+// templateStore keeps NetFlow v9/IPFIX templates in memory like the memory
+// template driver of goflow, and saves them to file if path set, so records
+// can be decoded right after restart, before templates re-announced.
+type templateStore struct {
+	path string
+
+	mu        sync.RWMutex
+	templates map[string]*templateEntry // TemplateKey.String() -> template
+	lastSave  time.Time
+
+	saveMu sync.Mutex
+}
+
+// templateEntry is a template saved to file, one of the template fields set.
+type templateEntry struct {
+	Key          templates.TemplateKey               `json:"key"`
+	Template     *netflow.TemplateRecord             `json:"template,omitempty"`
+	NFv9Options  *netflow.NFv9OptionsTemplateRecord  `json:"nfv9_options,omitempty"`
+	IPFIXOptions *netflow.IPFIXOptionsTemplateRecord `json:"ipfix_options,omitempty"`
+	Updated      time.Time                           `json:"updated"`
+}
+
+func newTemplateEntry(key *templates.TemplateKey, template interface{}) (*templateEntry, error) {
+	e := &templateEntry{Key: *key}
+
+	switch t := template.(type) {
+	case netflow.TemplateRecord:
+		e.Template = &t
+	case netflow.NFv9OptionsTemplateRecord:
+		e.NFv9Options = &t
+	case netflow.IPFIXOptionsTemplateRecord:
+		e.IPFIXOptions = &t
+	default:
+		return nil, fmt.Errorf("unknown template type %T", template)
+	}
+
+	return e, nil
+}
+
+func (e *templateEntry) template() interface{} {
+	switch {
+	case e.Template != nil:
+		return *e.Template
+	case e.NFv9Options != nil:
+		return *e.NFv9Options
+	case e.IPFIXOptions != nil:
+		return *e.IPFIXOptions
+	default:
+		return nil
+	}
+}
+
+// newTemplateStore returns a template store with templates loaded from path,
+// templates only kept in memory if path is empty.
+func newTemplateStore(path string) *templateStore {
+	s := &templateStore{
+		path:      path,
+		templates: make(map[string]*templateEntry),
+	}
+
+	if path != "" {
+		if n, err := s.load(); err != nil {
+			l.Warnf("Load NetFlow templates from %s failed: %s", path, err)
+		} else if n > 0 {
+			l.Infof("Loaded %d NetFlow templates from %s", n, path)
+		}
+	}
+
+	return s
+}
+
+func (s *templateStore) load() (int, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	var entries []*templateEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, e := range entries {
+		if e.template() == nil || now.Sub(e.Updated) > templateFileTTL {
+			continue
+		}
+		s.templates[e.Key.String()] = e
+	}
+
+	return len(s.templates), nil
+}
+
+// save writes all templates to file, by renaming a temporary file to avoid
+// partial file on crash.
+func (s *templateStore) save() error {
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+
+	s.mu.Lock()
+	entries := make([]*templateEntry, 0, len(s.templates))
+	for _, e := range s.templates {
+		entries = append(entries, e)
+	}
+	data, err := json.Marshal(entries)
+	s.lastSave = time.Now()
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), os.ModePerm); err != nil {
+		return err
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// ListTemplates sends keys of all templates to ch, followed by nil.
+func (s *templateStore) ListTemplates(ctx context.Context, ch chan *templates.TemplateKey) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, e := range s.templates {
+		key := e.Key
+		select {
+		case ch <- &key:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	ch <- nil
+	return nil
+}
+
+// GetTemplate returns the template, nil if not found like the memory driver of goflow.
+func (s *templateStore) GetTemplate(ctx context.Context, key *templates.TemplateKey) (interface{}, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if e, ok := s.templates[key.String()]; ok {
+		return e.template(), nil
+	}
+	return nil, nil
+}
+
+// AddTemplate adds the template, and saves templates to file if the template
+// changed or not saved for templateSaveInterval.
+func (s *templateStore) AddTemplate(ctx context.Context, key *templates.TemplateKey, template interface{}) error {
+	e, err := newTemplateEntry(key, template)
+	if err != nil {
+		return err
+	}
+	e.Updated = time.Now()
+
+	s.mu.Lock()
+	old, ok := s.templates[key.String()]
+	needSave := !ok || !reflect.DeepEqual(old.template(), template) ||
+		e.Updated.Sub(s.lastSave) > templateSaveInterval
+	s.templates[key.String()] = e
+	s.mu.Unlock()
+
+	if needSave && s.path != "" {
+		if err := s.save(); err != nil {
+			l.Warnf("Save NetFlow templates to %s failed: %s", s.path, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package goflowlib
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/netsampler/goflow2/decoders/netflow"
+	"github.com/netsampler/goflow2/decoders/netflow/templates"
+	"github.com/netsampler/goflow2/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/common"
+)
+
+func TestTemplateStore(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "netflow", "templates_ipfix_4739.json")
+
+	dataKey := templates.NewTemplateKey("127.0.0.1:1234", 10, 1, 256)
+	dataTemplate := netflow.TemplateRecord{
+		TemplateId: 256,
+		FieldCount: 1,
+		Fields:     []netflow.Field{{Type: 8, Length: 4}},
+	}
+	optionsKey := templates.NewTemplateKey("127.0.0.1:1235", 9, 2, 257)
+	optionsTemplate := netflow.NFv9OptionsTemplateRecord{
+		TemplateId: 257,
+		Scopes:     []netflow.Field{{Type: 2, Length: 4}},
+		Options:    []netflow.Field{{Type: 82, Length: 16}},
+	}
+
+	s := newTemplateStore(path)
+	require.NoError(t, s.AddTemplate(ctx, dataKey, dataTemplate))
+	require.NoError(t, s.AddTemplate(ctx, optionsKey, optionsTemplate))
+	assert.Error(t, s.AddTemplate(ctx, dataKey, "not-a-template"))
+
+	template, err := s.GetTemplate(ctx, dataKey)
+	require.NoError(t, err)
+	assert.Equal(t, dataTemplate, template)
+
+	template, err = s.GetTemplate(ctx, templates.NewTemplateKey("127.0.0.1:1234", 10, 1, 300))
+	require.NoError(t, err)
+	assert.Nil(t, template)
+
+	t.Run("reload", func(t *testing.T) {
+		s := newTemplateStore(path)
+
+		template, err := s.GetTemplate(ctx, dataKey)
+		require.NoError(t, err)
+		assert.Equal(t, dataTemplate, template)
+
+		template, err = s.GetTemplate(ctx, optionsKey)
+		require.NoError(t, err)
+		assert.Equal(t, optionsTemplate, template)
+
+		ch := make(chan *templates.TemplateKey, 3)
+		require.NoError(t, s.ListTemplates(ctx, ch))
+		assert.Len(t, ch, 3)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		entries := []*templateEntry{
+			{Key: *dataKey, Template: &dataTemplate, Updated: time.Now().Add(-templateFileTTL - time.Minute)},
+		}
+		data, err := json.Marshal(entries)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(path, data, 0o600))
+
+		s := newTemplateStore(path)
+		assert.Empty(t, s.templates)
+	})
+
+	t.Run("bad-file", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path, []byte("{"), 0o600))
+		s := newTemplateStore(path)
+		assert.Empty(t, s.templates)
+	})
+}
+
+func TestStateNetFlow_persistedTemplates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "templates_ipfix_4739.json")
+	src := net.IP{127, 0, 0, 1}
+
+	templatePkt := ipfixPacket(
+		// template 256: sourceIPv4Address, destinationIPv4Address, octetDeltaCount
+		ipfixSet(2, uint16(256), uint16(3), uint16(8), uint16(4), uint16(12), uint16(4), uint16(1), uint16(8)),
+	)
+	dataPkt := ipfixPacket(
+		ipfixSet(256, [4]byte{10, 0, 0, 1}, [4]byte{10, 0, 0, 2}, uint64(15)),
+	)
+
+	state := newStateNetFlow()
+	state.TemplateSystem = newTemplateStore(path)
+	require.NoError(t, state.DecodeFlow(utils.BaseMessage{Src: src, Payload: templatePkt}))
+
+	// restarted, templates not re-announced yet
+	flowIn := make(chan *common.Flow, 10)
+	state = newStateNetFlow()
+	state.Format = NewAggregatorFormatDriver(flowIn, "my-ns")
+	state.TemplateSystem = newTemplateStore(path)
+	require.NoError(t, state.DecodeFlow(utils.BaseMessage{Src: src, Payload: dataPkt}))
+
+	require.Len(t, flowIn, 1)
+	flow := <-flowIn
+	assert.Equal(t, []byte{10, 0, 0, 1}, flow.SrcAddr)
+	assert.Equal(t, uint64(15), flow.Bytes)
+}
@@ -7,6 +7,7 @@
 package netflow
 
 import (
+	"path/filepath"
 	"time"
 
 	"github.com/GuanceCloud/cliutils"
@@ -17,9 +18,12 @@ import (
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/common"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/config"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/enrichment"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/flowaggregator"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/goflowlib"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/metrics"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/snmppoller"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/threat"
 )
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -30,9 +34,31 @@ const (
     source    = "netflow"
     namespace = "namespace"
 
+    # Persist NetFlow v9/IPFIX templates, so flows can be decoded right after
+    # restart, before exporters re-announce templates.
+    persist_templates = true
+
+    # Scale bytes/packets of sampled flows by the sampling rate advertised by
+    # exporters, so traffic of sampled and unsampled exporters is comparable.
+    # Raw values are kept in raw_bytes/raw_packets of sampled flows.
+    extrapolate_sampled = true
+
+    # Only accept flows from exporters of these CIDRs/IPs, all exporters accepted if empty.
+    # allowed_exporters = ["10.0.0.0/8", "192.168.1.1"]
+
+    # Max packets/sec accepted from each exporter, 0 for no limit.
+    # exporter_rate_limit = 1000.0
+
     #[[inputs.netflow.listeners]]
     #    flow_type = "netflow9"
     #    port      = 2055
+    #
+    #    # GeoIP/ASN of source and destination addresses of flows
+    #    [inputs.netflow.listeners.geoip]
+    #        enabled = true
+    #        # MaxMind GeoIP2/GeoLite2 databases, the IP database of DataKit is used if city_db not set
+    #        # city_db = "/usr/local/share/GeoIP/GeoLite2-City.mmdb"
+    #        # asn_db  = "/usr/local/share/GeoIP/GeoLite2-ASN.mmdb"
 
     [[inputs.netflow.listeners]]
         flow_type = "netflow5"
@@ -46,6 +72,48 @@ const (
     #    flow_type = "sflow5"
     #    port      = 6343
 
+    # Roll up flows within the window before sending, to reduce volume of
+    # busy exporters. Flows are aggregated by 5-tuple if no key rolled up.
+    #[inputs.netflow.aggregation]
+    #    window = "60s"
+    #    # Mask addresses to the prefix, 0 to keep the full address
+    #    src_prefix_len    = 24
+    #    dst_prefix_len    = 24
+    #    src_prefix_len_v6 = 64
+    #    dst_prefix_len_v6 = 64
+    #    ignore_src_port   = false
+    #    ignore_dst_port   = false
+    #    ignore_protocol   = false
+    #    # Keep the N flows with most bytes of each window, 0 to keep all
+    #    top_n = 1000
+
+    # Poll ifName/ifAlias of exporters by SNMP, to resolve interfaces of flows.
+    #[inputs.netflow.snmp]
+    #    enabled      = true
+    #    interval     = "10m"
+    #    port         = 161
+    #    snmp_version = 2
+    #    community    = "public"
+    #    # SNMP v3
+    #    # v3_user          = ""
+    #    # v3_auth_protocol = ""
+    #    # v3_auth_key      = ""
+    #    # v3_priv_protocol = ""
+    #    # v3_priv_key      = ""
+    #    # v3_context_name  = ""
+
+    # Detect port/host scans and destinations in blocklist from flows, sent
+    # as security data.
+    #[inputs.netflow.threat]
+    #    enabled = true
+    #    window  = "1m"
+    #    # Report sources attempting connections to more distinct destination
+    #    # ports/hosts within the window, 0 to disable.
+    #    port_scan_threshold = 100
+    #    host_scan_threshold = 100
+    #    # File of blocked IPs/CIDRs, one per line, reloaded once modified.
+    #    # blocklist = "/usr/local/datakit/data/netflow_blocklist.txt"
+
     [inputs.netflow.tags]
     # some_tag = "some_value"
     # more_tag = "some_other_value"
@@ -65,6 +133,16 @@ type Input struct {
 	Listeners []common.FlowOpt  `toml:"listeners,omitempty"`
 	Tags      map[string]string `toml:"tags"`
 
+	PersistTemplates   bool `toml:"persist_templates"`
+	ExtrapolateSampled bool `toml:"extrapolate_sampled"`
+
+	AllowedExporters  []string `toml:"allowed_exporters"`
+	ExporterRateLimit float64  `toml:"exporter_rate_limit"`
+
+	Aggregation *common.AggregationOpt `toml:"aggregation,omitempty"`
+	SNMP        *common.SNMPOpt        `toml:"snmp,omitempty"`
+	Threat      *common.ThreatOpt      `toml:"threat,omitempty"`
+
 	semStop *cliutils.Sem // start stop signal
 	feeder  dkio.Feeder
 	tagger  datakit.GlobalTagger
@@ -75,6 +153,8 @@ func setLogger() {
 
 	flowaggregator.SetLogger(l)
 	goflowlib.SetLogger(l)
+	snmppoller.SetLogger(l)
+	threat.SetLogger(l)
 }
 
 func (ipt *Input) Run() {
@@ -107,22 +187,39 @@ func (ipt *Input) Run() {
 type netflowListener struct {
 	flowState *goflowlib.FlowStateWrapper
 	config    config.ListenerConfig
+	geoIP     *enrichment.GeoIP
 }
 
 // Shutdown will close the goflow listener state.
 func (l *netflowListener) shutdown() {
 	l.flowState.Shutdown()
+	l.geoIP.Close()
 }
 
 //nolint:lll
-func startFlowListener(listenerConfig config.ListenerConfig, flowAgg *flowaggregator.FlowAggregator) (*netflowListener, error) {
-	flowState, err := goflowlib.StartFlowRoutine(listenerConfig.FlowType, listenerConfig.BindHost, listenerConfig.Port, listenerConfig.Workers, listenerConfig.Namespace, flowAgg.GetFlowInChan())
+func startFlowListener(listenerConfig config.ListenerConfig, flowAgg *flowaggregator.FlowAggregator, exporterMetadata *common.ExporterMetadata, templateDir string, filter *goflowlib.ExporterFilter) (*netflowListener, error) {
+	var geoIP *enrichment.GeoIP
+	if opt := listenerConfig.GeoIP; opt != nil && opt.Enabled {
+		var err error
+		if geoIP, err = enrichment.NewGeoIP(opt.CityDB, opt.ASNDB); err != nil {
+			l.Warnf("GeoIP for listener %s disabled: %s", listenerConfig.Addr(), err)
+		}
+	}
+
+	var templatePath string
+	if templateDir != "" {
+		templatePath = listenerConfig.TemplateFile(templateDir)
+	}
+
+	flowState, err := goflowlib.StartFlowRoutine(listenerConfig.FlowType, listenerConfig.BindHost, listenerConfig.Port, listenerConfig.Workers, listenerConfig.Namespace, flowAgg.GetFlowInChan(), flowAgg.GetCounterInChan(), exporterMetadata, geoIP, templatePath, filter)
 	if err != nil {
+		geoIP.Close()
 		return nil, err
 	}
 	return &netflowListener{
 		flowState: flowState,
 		config:    listenerConfig,
+		geoIP:     geoIP,
 	}, nil
 }
 
@@ -139,6 +236,7 @@ type Server struct {
 	Addr      string
 	listeners []*netflowListener
 	flowAgg   *flowaggregator.FlowAggregator
+	ifPoller  *snmppoller.Poller
 }
 
 func getFlows(ipt *Input) []*common.FlowOpt {
@@ -153,8 +251,9 @@ func getFlows(ipt *Input) []*common.FlowOpt {
 		}
 
 		out = append(out, &common.FlowOpt{
-			Type: v.Type,
-			Port: v.Port,
+			Type:  v.Type,
+			Port:  v.Port,
+			GeoIP: v.GeoIP,
 		})
 	}
 
@@ -169,10 +268,24 @@ func NewNetflowServer(ipt *Input) (*Server, error) {
 
 	flows := getFlows(ipt)
 
-	mainConfig, err := config.ReadConfig(flows, ipt.Namespace)
+	mainConfig, err := config.ReadConfig(flows, ipt.Namespace, ipt.Aggregation)
 	if err != nil {
 		return nil, err
 	}
+	mainConfig.ExtrapolateSampled = ipt.ExtrapolateSampled
+
+	// shared by all listeners, so rate limit of exporter sending to multiple listeners is the total.
+	filter, err := goflowlib.NewExporterFilter(ipt.AllowedExporters, ipt.ExporterRateLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	var threats *threat.Detector
+	if ipt.Threat != nil && ipt.Threat.Enabled {
+		if threats, err = threat.NewDetector(*ipt.Threat); err != nil {
+			return nil, err
+		}
+	}
 
 	combinedTags := maputil.CopyMapString(ipt.Tags)
 	// global tags(host/election tags) got the lowest priority.
@@ -182,13 +295,29 @@ func NewNetflowServer(ipt *Input) (*Server, error) {
 		}
 	}
 
-	flowAgg := flowaggregator.NewFlowAggregator(mainConfig, combinedTags, ipt.feeder, ipt.Source)
+	// metadata from options data records of exporters, shared by all listeners.
+	exporterMetadata := common.NewExporterMetadata()
+
+	// interface names of exporters polled by SNMP, also saved into exporterMetadata.
+	var ifPoller *snmppoller.Poller
+	if ipt.SNMP != nil && ipt.SNMP.Enabled {
+		ifPoller = snmppoller.NewPoller(*ipt.SNMP, exporterMetadata)
+		ifPoller.Start()
+	}
+
+	flowAgg := flowaggregator.NewFlowAggregator(mainConfig, combinedTags, ipt.feeder, ipt.Source, exporterMetadata, ifPoller, threats)
 	go flowAgg.Start()
 
+	// NetFlow v9/IPFIX templates persisted across restarts.
+	var templateDir string
+	if ipt.PersistTemplates {
+		templateDir = filepath.Join(datakit.CacheDir, common.InputName)
+	}
+
 	l.Debugf("NetFlow Server configs (aggregator_buffer_size=%d, aggregator_flush_interval=%d, aggregator_flow_context_ttl=%d)", mainConfig.AggregatorBufferSize, mainConfig.AggregatorFlushInterval, mainConfig.AggregatorFlowContextTTL)
 	for _, listenerConfig := range mainConfig.Listeners {
 		l.Infof("Starting Netflow listener for flow type %s on %s", listenerConfig.FlowType, listenerConfig.Addr())
-		listener, err := startFlowListener(listenerConfig, flowAgg)
+		listener, err := startFlowListener(listenerConfig, flowAgg, exporterMetadata, templateDir, filter)
 		if err != nil {
 			l.Warnf("Error starting listener for config (flow_type:%s, bind_Host:%s, port:%d): %s", listenerConfig.FlowType, listenerConfig.BindHost, listenerConfig.Port, err)
 			continue
@@ -199,6 +328,7 @@ func NewNetflowServer(ipt *Input) (*Server, error) {
 	return &Server{
 		listeners: listeners,
 		flowAgg:   flowAgg,
+		ifPoller:  ifPoller,
 	}, nil
 }
 
@@ -207,6 +337,7 @@ func (svr *Server) stop() {
 	l.Infof("Stop NetFlow Server")
 
 	svr.flowAgg.Stop()
+	svr.ifPoller.Stop()
 
 	for _, listener := range svr.listeners {
 		stopped := make(chan interface{})
@@ -261,6 +392,8 @@ func (*Input) AvailableArchs() []string {
 func (*Input) SampleMeasurement() []inputs.Measurement {
 	return []inputs.Measurement{
 		&metrics.NetflowMeasurement{},
+		&metrics.SFlowInterfaceMeasurement{},
+		&metrics.ThreatMeasurement{},
 	}
 }
 
@@ -278,9 +411,11 @@ func (ipt *Input) Terminate() {
 
 func defaultInput() *Input {
 	return &Input{
-		feeder:  dkio.DefaultFeeder(),
-		semStop: cliutils.NewSem(),
-		tagger:  datakit.DefaultGlobalTagger(),
+		PersistTemplates:   true,
+		ExtrapolateSampled: true,
+		feeder:             dkio.DefaultFeeder(),
+		semStop:            cliutils.NewSem(),
+		tagger:             datakit.DefaultGlobalTagger(),
 	}
 }
 
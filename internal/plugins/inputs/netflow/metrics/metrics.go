@@ -60,20 +60,52 @@ func (*NetflowMeasurement) Info() *inputs.MeasurementInfo {
 		Type: "logging",
 		Desc: "Using `source` field in the config file, default is `default`.",
 		Tags: map[string]interface{}{
-			"ip":   inputs.NewTagInfo("Collector IP address."),
-			"host": inputs.NewTagInfo("Hostname."),
+			"ip":            inputs.NewTagInfo("Collector IP address."),
+			"host":          inputs.NewTagInfo("Hostname."),
+			"sampled":       inputs.NewTagInfo("`true` for sampled flows, absent for unsampled flows."),
+			"sampling_rate": inputs.NewTagInfo("Sampling rate advertised by the exporter, only for sampled flows."),
 		},
 		Fields: map[string]interface{}{
-			"message":     &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "The text of the logging."},
-			"status":      &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "The status of the logging, only supported `info/emerg/alert/critical/error/warning/debug/OK/unknown`."},
-			"bytes":       &inputs.FieldInfo{DataType: inputs.Int, Unit: inputs.SizeByte, Desc: "Flow bytes."},
-			"dest_ip":     &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "Flow destination IP."},
-			"dest_port":   &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "Flow destination port."},
-			"device_ip":   &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "NetFlow exporter IP."},
-			"ip_protocol": &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "Flow network protocol."},
-			"source_ip":   &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "Flow source IP."},
-			"source_port": &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "Flow source port."},
-			"type":        &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "Flow type."},
+			"message":                 &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "The text of the logging."},
+			"status":                  &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "The status of the logging, only supported `info/emerg/alert/critical/error/warning/debug/OK/unknown`."},
+			"bytes":                   &inputs.FieldInfo{DataType: inputs.Int, Unit: inputs.SizeByte, Desc: "Flow bytes, scaled by the sampling rate for sampled flows if `extrapolate_sampled` enabled."},
+			"packets":                 &inputs.FieldInfo{DataType: inputs.Int, Unit: inputs.NCount, Desc: "Flow packets, scaled by the sampling rate for sampled flows if `extrapolate_sampled` enabled."},
+			"raw_bytes":               &inputs.FieldInfo{DataType: inputs.Int, Unit: inputs.SizeByte, Desc: "Flow bytes of sampled packets as exported, only for sampled flows."},
+			"raw_packets":             &inputs.FieldInfo{DataType: inputs.Int, Unit: inputs.NCount, Desc: "Flow packets sampled as exported, only for sampled flows."},
+			"dest_ip":                 &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "Flow destination IP."},
+			"dest_port":               &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "Flow destination port."},
+			"device_ip":               &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "NetFlow exporter IP."},
+			"ip_protocol":             &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "Flow network protocol."},
+			"source_ip":               &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "Flow source IP."},
+			"source_port":             &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "Flow source port."},
+			"type":                    &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "Flow type."},
+			"ingress_interface":       &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "Ingress interface of the flow, ifName from options data records of NetFlow v9/IPFIX exporter or SNMP, or ifIndex if unknown."},
+			"egress_interface":        &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "Egress interface of the flow, ifName from options data records of NetFlow v9/IPFIX exporter or SNMP, or ifIndex if unknown."},
+			"ingress_interface_alias": &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "ifAlias of the ingress interface polled by SNMP, if SNMP polling enabled."},
+			"egress_interface_alias":  &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "ifAlias of the egress interface polled by SNMP, if SNMP polling enabled."},
+			"source_country":          &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "Country ISO code of the flow source IP, if GeoIP of the listener enabled."},
+			"source_city":             &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "City of the flow source IP, if GeoIP of the listener enabled."},
+			"source_asn":              &inputs.FieldInfo{DataType: inputs.Int, Unit: inputs.UnknownUnit, Desc: "ASN of the flow source IP, if `asn_db` of the listener configured."},
+			"source_org":              &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "Organization of the ASN of the flow source IP, if `asn_db` of the listener configured."},
+			"dest_country":            &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "Country ISO code of the flow destination IP, if GeoIP of the listener enabled."},
+			"dest_city":               &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "City of the flow destination IP, if GeoIP of the listener enabled."},
+			"dest_asn":                &inputs.FieldInfo{DataType: inputs.Int, Unit: inputs.UnknownUnit, Desc: "ASN of the flow destination IP, if `asn_db` of the listener configured."},
+			"dest_org":                &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "Organization of the ASN of the flow destination IP, if `asn_db` of the listener configured."},
+			"tos":                     &inputs.FieldInfo{DataType: inputs.Int, Unit: inputs.UnknownUnit, Desc: "IP ToS byte of the flow."},
+			"dscp":                    &inputs.FieldInfo{DataType: inputs.Int, Unit: inputs.UnknownUnit, Desc: "DSCP of the flow, upper 6 bits of ToS."},
+			"dscp_class":              &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "DSCP class name of the flow, like `BE`, `EF`, `AF41` and `CS6`, or the DSCP number if not standardized."},
+			"flows":                   &inputs.FieldInfo{DataType: inputs.Int, Unit: inputs.NCount, Desc: "Number of raw flows aggregated into the flow."},
+			"tcp_syn_only_flows":      &inputs.FieldInfo{DataType: inputs.Int, Unit: inputs.NCount, Desc: "Number of raw TCP flows with SYN flag but without ACK/RST/FIN flags, many of them indicate SYN flood or scanning."},
+			"tcp_rst_flows":           &inputs.FieldInfo{DataType: inputs.Int, Unit: inputs.NCount, Desc: "Number of raw TCP flows with RST flag."},
+			"tcp_rst_ratio":           &inputs.FieldInfo{DataType: inputs.Float, Unit: inputs.UnknownUnit, Desc: "Ratio of raw TCP flows with RST flag, in range [0, 1]."},
+			"application":             &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "Application of the flow, name from options data records of NetFlow v9/IPFIX exporter, or `<engine ID>:<selector ID>` if unknown."},
+			"tunnel_type":             &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "Tunnel type of encapsulated flows, `vxlan`, `nvgre` or `gre`."},
+			"tunnel_id":               &inputs.FieldInfo{DataType: inputs.Int, Unit: inputs.UnknownUnit, Desc: "Tunnel ID of encapsulated flows, VNI of VXLAN, VSID of NVGRE or key of GRE."},
+			"inner_source_ip":         &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "Inner source IP of encapsulated flows."},
+			"inner_source_port":       &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "Inner source port of encapsulated flows."},
+			"inner_dest_ip":           &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "Inner destination IP of encapsulated flows."},
+			"inner_dest_port":         &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "Inner destination port of encapsulated flows."},
+			"inner_ip_protocol":       &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "Inner network protocol of encapsulated flows."},
 		},
 	}
 }
@@ -0,0 +1,53 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package metrics
+
+import (
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs"
+)
+
+const SFlowInterfaceMetricName = "sflow_interface"
+
+type SFlowInterfaceMeasurement struct{}
+
+//nolint:lll
+func (*SFlowInterfaceMeasurement) Info() *inputs.MeasurementInfo {
+	return &inputs.MeasurementInfo{
+		Name: SFlowInterfaceMetricName,
+		Type: "metric",
+		Desc: "Generic interface counters from counter samples of sFlow v5 listeners.",
+		Tags: map[string]interface{}{
+			"host":         inputs.NewTagInfo("Hostname."),
+			"namespace":    inputs.NewTagInfo("Device namespace."),
+			"device_ip":    inputs.NewTagInfo("sFlow agent IP."),
+			"sub_agent_id": inputs.NewTagInfo("sFlow sub-agent ID."),
+			"if_index":     inputs.NewTagInfo("Interface index(ifIndex)."),
+			"if_name":      inputs.NewTagInfo("Interface name, if known from the exporter."),
+			"admin_status": inputs.NewTagInfo("Admin status of the interface, `up/down`."),
+			"oper_status":  inputs.NewTagInfo("Operational status of the interface, `up/down`."),
+		},
+		Fields: map[string]interface{}{
+			"speed":              &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.UnknownUnit, Desc: "Speed of the interface, in bits per second."},
+			"in_octets":          &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Count, Unit: inputs.SizeByte, Desc: "Total bytes received on the interface."},
+			"in_ucast_pkts":      &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Count, Unit: inputs.NCount, Desc: "Total unicast packets received on the interface."},
+			"in_multicast_pkts":  &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Count, Unit: inputs.NCount, Desc: "Total multicast packets received on the interface."},
+			"in_broadcast_pkts":  &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Count, Unit: inputs.NCount, Desc: "Total broadcast packets received on the interface."},
+			"in_discards":        &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Count, Unit: inputs.NCount, Desc: "Total inbound packets discarded."},
+			"in_errors":          &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Count, Unit: inputs.NCount, Desc: "Total inbound packets with errors."},
+			"in_unknown_protos":  &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Count, Unit: inputs.NCount, Desc: "Total inbound packets discarded because of an unknown or unsupported protocol."},
+			"out_octets":         &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Count, Unit: inputs.SizeByte, Desc: "Total bytes transmitted out of the interface."},
+			"out_ucast_pkts":     &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Count, Unit: inputs.NCount, Desc: "Total unicast packets transmitted out of the interface."},
+			"out_multicast_pkts": &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Count, Unit: inputs.NCount, Desc: "Total multicast packets transmitted out of the interface."},
+			"out_broadcast_pkts": &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Count, Unit: inputs.NCount, Desc: "Total broadcast packets transmitted out of the interface."},
+			"out_discards":       &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Count, Unit: inputs.NCount, Desc: "Total outbound packets discarded."},
+			"out_errors":         &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Count, Unit: inputs.NCount, Desc: "Total outbound packets with errors."},
+			"in_bytes_per_sec":   &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.BytesPerSec, Desc: "Inbound rate since the previous counter sample of the interface."},
+			"out_bytes_per_sec":  &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.BytesPerSec, Desc: "Outbound rate since the previous counter sample of the interface."},
+			"in_utilization":     &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.Percent, Desc: "Inbound utilization of the interface speed since the previous counter sample."},
+			"out_utilization":    &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.Percent, Desc: "Outbound utilization of the interface speed since the previous counter sample."},
+		},
+	}
+}
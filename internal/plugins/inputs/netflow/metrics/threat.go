@@ -0,0 +1,44 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package metrics
+
+import (
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs"
+)
+
+const ThreatName = "netflow_threat"
+
+type ThreatMeasurement struct{}
+
+//nolint:lll
+func (*ThreatMeasurement) Info() *inputs.MeasurementInfo {
+	return &inputs.MeasurementInfo{
+		Name: ThreatName,
+		Type: "security",
+		Desc: "Threat indicators detected from flows within each window, if `[inputs.netflow.threat]` enabled.",
+		Tags: map[string]interface{}{
+			"host":      inputs.NewTagInfo("Hostname."),
+			"namespace": inputs.NewTagInfo("Device namespace."),
+			"device_ip": inputs.NewTagInfo("Exporter IP of the first flow of the source."),
+			"category":  inputs.NewTagInfo("Always `network`."),
+			"level":     inputs.NewTagInfo("Always `warn`."),
+			"rule":      inputs.NewTagInfo("Type of the indicator, `port_scan`, `host_scan` or `blocklist`."),
+			"title":     inputs.NewTagInfo("Title of the indicator."),
+		},
+		Fields: map[string]interface{}{
+			"message":         &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "Description of the indicator."},
+			"source_ip":       &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "Source IP of flows."},
+			"dest_ip":         &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "Blocked destination IP, only for `blocklist`."},
+			"blocklist_entry": &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "IP/CIDR of the blocklist matched, only for `blocklist`."},
+			"distinct_ports":  &inputs.FieldInfo{DataType: inputs.Int, Unit: inputs.NCount, Desc: "Distinct destination ports of TCP/UDP connection attempts of the source, only for `port_scan` and `host_scan`."},
+			"distinct_hosts":  &inputs.FieldInfo{DataType: inputs.Int, Unit: inputs.NCount, Desc: "Distinct destination IPs of TCP/UDP connection attempts of the source, only for `port_scan` and `host_scan`."},
+			"flows":           &inputs.FieldInfo{DataType: inputs.Int, Unit: inputs.NCount, Desc: "Number of flows counted."},
+			"bytes":           &inputs.FieldInfo{DataType: inputs.Int, Unit: inputs.SizeByte, Desc: "Bytes of flows counted."},
+			"packets":         &inputs.FieldInfo{DataType: inputs.Int, Unit: inputs.NCount, Desc: "Packets of flows counted."},
+			"window":          &inputs.FieldInfo{DataType: inputs.Int, Unit: inputs.DurationSecond, Desc: "Duration of the window."},
+		},
+	}
+}
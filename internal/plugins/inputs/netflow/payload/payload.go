@@ -22,6 +22,15 @@ type Endpoint struct {
 	Port string `json:"port"` // Port number can be zero/positive or `*` (ephemeral port)
 	Mac  string `json:"mac"`
 	Mask string `json:"mask"`
+	Geo  *Geo   `json:"geo,omitempty"`
+}
+
+// Geo contains GeoIP/ASN details of the endpoint.
+type Geo struct {
+	Country string `json:"country,omitempty"`
+	City    string `json:"city,omitempty"`
+	ASN     uint32 `json:"asn,omitempty"`
+	Org     string `json:"org,omitempty"`
 }
 
 // NextHop contains next hop details.
@@ -32,6 +41,23 @@ type NextHop struct {
 // Interface contains interface details.
 type Interface struct {
 	Index uint32 `json:"index"`
+	Name  string `json:"name,omitempty"`  // from options data records or SNMP of the exporter
+	Alias string `json:"alias,omitempty"` // from SNMP of the exporter
+}
+
+// Application contains application details of IPFIX/NetFlow v9 flows.
+type Application struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"` // from options data records of the exporter
+}
+
+// Inner contains tunnel and inner header details of encapsulated flows.
+type Inner struct {
+	Tunnel      string   `json:"tunnel,omitempty"` // vxlan, nvgre or gre
+	TunnelID    uint64   `json:"tunnel_id"`        // VNI of VXLAN, VSID of NVGRE or key of GRE
+	IPProtocol  string   `json:"ip_protocol"`
+	Source      Endpoint `json:"source"`
+	Destination Endpoint `json:"destination"`
 }
 
 // ObservationPoint contains ingress or egress observation point.
@@ -49,6 +75,8 @@ type FlowPayload struct {
 	End            uint64           `json:"end"`   // in seconds
 	Bytes          uint64           `json:"bytes"`
 	Packets        uint64           `json:"packets"`
+	RawBytes       uint64           `json:"raw_bytes,omitempty"`   // only for sampled flows
+	RawPackets     uint64           `json:"raw_packets,omitempty"` // only for sampled flows
 	EtherType      string           `json:"ether_type,omitempty"`
 	IPProtocol     string           `json:"ip_protocol"`
 	Device         Device           `json:"device"`
@@ -59,5 +87,13 @@ type FlowPayload struct {
 	Egress         ObservationPoint `json:"egress"`
 	Host           string           `json:"host"`
 	TCPFlags       []string         `json:"tcp_flags,omitempty"`
+	Tos            uint32           `json:"tos"`
+	DSCP           uint32           `json:"dscp"`
+	DSCPClass      string           `json:"dscp_class"`
+	Flows          uint64           `json:"flows"`
+	TCPSYNOnly     uint64           `json:"tcp_syn_only_flows,omitempty"`
+	TCPRST         uint64           `json:"tcp_rst_flows,omitempty"`
 	NextHop        NextHop          `json:"next_hop,omitempty"`
+	Application    *Application     `json:"application,omitempty"`
+	Inner          *Inner           `json:"inner,omitempty"`
 }
@@ -0,0 +1,237 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+// Package snmppoller polls interface names of flow exporters by SNMP.
+package snmppoller
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/logger"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/common"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/snmp/snmputil"
+)
+
+const (
+	ifDescrOID = "1.3.6.1.2.1.2.2.1.2"     // ifTable
+	ifNameOID  = "1.3.6.1.2.1.31.1.1.1.1"  // ifXTable
+	ifAliasOID = "1.3.6.1.2.1.31.1.1.1.18" // ifXTable
+
+	defaultInterval = 10 * time.Minute
+	defaultPort     = 161
+
+	// checkInterval is the interval of checking exporters to poll, new
+	// exporters are polled within it.
+	checkInterval = 30 * time.Second
+
+	// exporters not sending flows for exporterTTL are not polled anymore.
+	exporterTTL = time.Hour
+)
+
+var l = logger.DefaultSLogger(common.InputName)
+
+func SetLogger(log *logger.Logger) {
+	l = log
+}
+
+type exporterState struct {
+	addr     []byte
+	lastSeen time.Time
+	nextPoll time.Time
+}
+
+// Poller polls ifName/ifAlias of exporters tracked and saves them into exporter metadata.
+type Poller struct {
+	opt      common.SNMPOpt
+	metadata *common.ExporterMetadata
+
+	newSession func(opts *snmputil.SessionOpts) (snmputil.Session, error)
+	timeNow    func() time.Time
+
+	mu        sync.Mutex
+	exporters map[string]*exporterState
+
+	stopCh chan struct{}
+	done   chan struct{}
+}
+
+// NewPoller returns a poller with defaults of opt filled.
+func NewPoller(opt common.SNMPOpt, metadata *common.ExporterMetadata) *Poller {
+	if opt.Interval <= 0 {
+		opt.Interval = defaultInterval
+	}
+	if opt.Port == 0 {
+		opt.Port = defaultPort
+	}
+
+	return &Poller{
+		opt:        opt,
+		metadata:   metadata,
+		newSession: snmputil.NewGosnmpSession,
+		timeNow:    time.Now,
+		exporters:  make(map[string]*exporterState),
+		stopCh:     make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+}
+
+// Track adds the exporter to poll, called for exporter of each flow.
+func (p *Poller) Track(exporterAddr []byte) {
+	if p == nil || len(exporterAddr) == 0 {
+		return
+	}
+
+	now := p.timeNow()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if e, ok := p.exporters[string(exporterAddr)]; ok {
+		e.lastSeen = now
+		return
+	}
+
+	p.exporters[string(exporterAddr)] = &exporterState{
+		addr:     append([]byte(nil), exporterAddr...),
+		lastSeen: now,
+		nextPoll: now,
+	}
+}
+
+// Start starts polling in background.
+func (p *Poller) Start() {
+	go func() {
+		defer close(p.done)
+
+		tick := time.NewTicker(checkInterval)
+		defer tick.Stop()
+
+		for {
+			select {
+			case <-p.stopCh:
+				return
+			case <-tick.C:
+				p.pollDue()
+			}
+		}
+	}()
+}
+
+// Stop stops polling.
+func (p *Poller) Stop() {
+	if p == nil {
+		return
+	}
+
+	close(p.stopCh)
+	<-p.done
+}
+
+// pollDue polls exporters reached next poll time, and removes exporters not seen for exporterTTL.
+func (p *Poller) pollDue() {
+	now := p.timeNow()
+
+	var due [][]byte
+	p.mu.Lock()
+	for key, e := range p.exporters {
+		if now.Sub(e.lastSeen) > exporterTTL {
+			delete(p.exporters, key)
+			continue
+		}
+		if !e.nextPoll.After(now) {
+			due = append(due, e.addr)
+			e.nextPoll = now.Add(p.opt.Interval)
+		}
+	}
+	p.mu.Unlock()
+
+	for _, addr := range due {
+		if err := p.poll(addr); err != nil {
+			l.Warnf("Poll interfaces of exporter %s by SNMP failed: %s", net.IP(addr), err)
+		}
+	}
+}
+
+// poll walks ifName/ifDescr/ifAlias of the exporter, ifDescr used if ifName absent.
+func (p *Poller) poll(exporterAddr []byte) error {
+	session, err := p.newSession(&snmputil.SessionOpts{
+		IPAddress:       net.IP(exporterAddr).String(),
+		Port:            p.opt.Port,
+		SnmpVersion:     p.opt.Version,
+		CommunityString: p.opt.Community,
+		User:            p.opt.User,
+		AuthProtocol:    p.opt.AuthProtocol,
+		AuthKey:         p.opt.AuthKey,
+		PrivProtocol:    p.opt.PrivProtocol,
+		PrivKey:         p.opt.PrivKey,
+		ContextName:     p.opt.ContextName,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := session.Connect(); err != nil {
+		return err
+	}
+	defer session.Close() //nolint:errcheck
+
+	names, err := walkColumn(session, ifNameOID)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		if names, err = walkColumn(session, ifDescrOID); err != nil {
+			return err
+		}
+	}
+
+	aliases, err := walkColumn(session, ifAliasOID)
+	if err != nil {
+		return err
+	}
+
+	for index, name := range names {
+		p.metadata.SetInterfaceName(exporterAddr, index, name)
+	}
+	for index, alias := range aliases {
+		p.metadata.SetInterfaceAlias(exporterAddr, index, alias)
+	}
+
+	l.Debugf("Polled %d interfaces of exporter %s", len(names), net.IP(exporterAddr))
+	return nil
+}
+
+// walkColumn returns ifIndex -> non-empty string value of the column.
+func walkColumn(session snmputil.Session, columnOID string) (map[uint32]string, error) {
+	pdus, err := session.GetWalkAll(columnOID)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[uint32]string, len(pdus))
+	for _, pdu := range pdus {
+		oid, value, err := snmputil.GetResultValueFromPDU(pdu)
+		if err != nil {
+			continue
+		}
+
+		index, err := strconv.ParseUint(strings.TrimPrefix(oid, columnOID+"."), 10, 32)
+		if err != nil {
+			continue
+		}
+
+		str, err := value.ToString()
+		if err != nil || str == "" {
+			continue
+		}
+		values[uint32(index)] = str
+	}
+
+	return values, nil
+}
@@ -0,0 +1,114 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package snmppoller
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/common"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/snmp/snmputil"
+)
+
+type fakeSession struct {
+	snmputil.Session // methods not used panic
+
+	columns map[string][]gosnmp.SnmpPDU
+	closed  bool
+}
+
+func (s *fakeSession) Connect() error { return nil }
+
+func (s *fakeSession) Close() error {
+	s.closed = true
+	return nil
+}
+
+func (s *fakeSession) GetWalkAll(rootOid string) ([]gosnmp.SnmpPDU, error) {
+	return s.columns[rootOid], nil
+}
+
+func octetString(oid string, value string) gosnmp.SnmpPDU {
+	return gosnmp.SnmpPDU{Name: "." + oid, Type: gosnmp.OctetString, Value: []byte(value)}
+}
+
+func TestPoller(t *testing.T) {
+	exporter := []byte{10, 0, 0, 1}
+	now := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	session := &fakeSession{columns: map[string][]gosnmp.SnmpPDU{
+		ifNameOID: {
+			octetString(ifNameOID+".1", "Gi0/1"),
+			octetString(ifNameOID+".2", ""),
+		},
+		ifDescrOID: {
+			octetString(ifDescrOID+".1", "GigabitEthernet0/1"),
+		},
+		ifAliasOID: {
+			octetString(ifAliasOID+".1", "uplink to core"),
+			{Name: "." + ifAliasOID + ".x", Type: gosnmp.OctetString, Value: []byte("bad index")},
+		},
+	}}
+
+	metadata := common.NewExporterMetadata()
+	p := NewPoller(common.SNMPOpt{Enabled: true, Version: 2, Community: "public"}, metadata)
+	p.timeNow = func() time.Time { return now }
+
+	var sessionOpts *snmputil.SessionOpts
+	p.newSession = func(opts *snmputil.SessionOpts) (snmputil.Session, error) {
+		sessionOpts = opts
+		return session, nil
+	}
+
+	p.Track(exporter)
+	p.pollDue()
+
+	require.NotNil(t, sessionOpts)
+	assert.Equal(t, "10.0.0.1", sessionOpts.IPAddress)
+	assert.Equal(t, uint16(161), sessionOpts.Port)
+	assert.Equal(t, "public", sessionOpts.CommunityString)
+	assert.True(t, session.closed)
+
+	assert.Equal(t, "Gi0/1", metadata.InterfaceName(exporter, 1))
+	assert.Equal(t, "", metadata.InterfaceName(exporter, 2))
+	assert.Equal(t, "uplink to core", metadata.InterfaceAlias(exporter, 1))
+
+	t.Run("not-due", func(t *testing.T) {
+		sessionOpts = nil
+		now = now.Add(time.Minute)
+		p.pollDue()
+		assert.Nil(t, sessionOpts)
+	})
+
+	t.Run("ifdescr", func(t *testing.T) {
+		delete(session.columns, ifNameOID)
+		now = now.Add(defaultInterval)
+		p.pollDue()
+		assert.NotNil(t, sessionOpts)
+		assert.Equal(t, "GigabitEthernet0/1", metadata.InterfaceName(exporter, 1))
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		now = now.Add(exporterTTL + time.Second)
+		p.pollDue()
+		assert.Empty(t, p.exporters)
+	})
+
+	t.Run("session-error", func(t *testing.T) {
+		p.newSession = func(opts *snmputil.SessionOpts) (snmputil.Session, error) {
+			return nil, fmt.Errorf("an authentication method needs to be provided")
+		}
+		assert.Error(t, p.poll(exporter))
+	})
+
+	var nilPoller *Poller
+	nilPoller.Track(exporter)
+	nilPoller.Stop()
+}
@@ -0,0 +1,158 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package threat
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// prefix is the mask of blocklist entries, bits is 32 for IPv4 and 128 for IPv6.
+type prefix struct {
+	ones, bits int
+}
+
+// blocklist holds blocked IPs/CIDRs, grouped by prefix, so lookup costs at
+// most one map access per distinct prefix length.
+type blocklist struct {
+	path    string
+	modTime time.Time
+
+	prefixes []prefix                     // the longest prefix matched first
+	entries  map[prefix]map[string]string // prefix -> masked address -> entry
+	size     int
+}
+
+func newBlocklist(path string) (*blocklist, error) {
+	b := &blocklist{path: path}
+	if err := b.load(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (b *blocklist) load() error {
+	f, err := os.Open(b.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close() //nolint:errcheck,gosec
+
+	st, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	entries, size, err := parseBlocklist(f)
+	if err != nil {
+		return fmt.Errorf("parse blocklist %q: %w", b.path, err)
+	}
+
+	prefixes := make([]prefix, 0, len(entries))
+	for p := range entries {
+		prefixes = append(prefixes, p)
+	}
+	sort.Slice(prefixes, func(i, j int) bool { return prefixes[i].ones > prefixes[j].ones })
+
+	b.prefixes, b.entries, b.size, b.modTime = prefixes, entries, size, st.ModTime()
+	return nil
+}
+
+// reloadIfModified reloads the file if it's modified since last loaded,
+// entries loaded kept if reload failed.
+func (b *blocklist) reloadIfModified() {
+	st, err := os.Stat(b.path)
+	if err != nil {
+		l.Warnf("Stat blocklist %s failed: %s", b.path, err)
+		return
+	}
+	if st.ModTime().Equal(b.modTime) {
+		return
+	}
+
+	if err := b.load(); err != nil {
+		l.Warnf("Reload blocklist %s failed: %s", b.path, err)
+		return
+	}
+	l.Infof("Reloaded %d entries from blocklist %s", b.size, b.path)
+}
+
+// parseBlocklist reads IPs/CIDRs, one per line. Empty lines, lines starting
+// with `#` and anything after the first field are ignored.
+func parseBlocklist(r io.Reader) (map[prefix]map[string]string, int, error) {
+	entries := make(map[prefix]map[string]string)
+	size := 0
+
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		ipNet, err := parseEntry(fields[0])
+		if err != nil {
+			return nil, 0, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+
+		var p prefix
+		p.ones, p.bits = ipNet.Mask.Size()
+		if entries[p] == nil {
+			entries[p] = make(map[string]string)
+		}
+		entries[p][string(ipNet.IP)] = fields[0]
+		size++
+	}
+
+	return entries, size, scanner.Err()
+}
+
+func parseEntry(s string) (*net.IPNet, error) {
+	if strings.Contains(s, "/") {
+		_, ipNet, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, err
+		}
+		if ip4 := ipNet.IP.To4(); ip4 != nil {
+			ipNet.IP = ip4
+		}
+		return ipNet, nil
+	}
+
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IP %q", s)
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+}
+
+// lookup returns the entry of the longest prefix matching the address, or
+// empty if not blocked.
+func (b *blocklist) lookup(addr []byte) string {
+	ip := net.IP(addr)
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+
+	for _, p := range b.prefixes {
+		if len(ip)*8 != p.bits {
+			continue
+		}
+		if entry, ok := b.entries[p][string(ip.Mask(net.CIDRMask(p.ones, p.bits)))]; ok {
+			return entry
+		}
+	}
+	return ""
+}
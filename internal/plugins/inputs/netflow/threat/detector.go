@@ -0,0 +1,266 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+// Package threat detects threat indicators from flows, like port scans and
+// connections to blocked addresses.
+package threat
+
+import (
+	"time"
+
+	"github.com/GuanceCloud/cliutils/logger"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/common"
+)
+
+// Types of indicators.
+const (
+	TypePortScan  = "port_scan"
+	TypeHostScan  = "host_scan"
+	TypeBlocklist = "blocklist"
+)
+
+const (
+	defaultWindow = time.Minute
+
+	// maxSources limits sources tracked within a window, in case of spoofed
+	// source addresses, flows of new sources ignored once it's full.
+	maxSources = 100000
+
+	// maxDistinct limits distinct ports/hosts counted of each source.
+	maxDistinct = 65536
+
+	// maxBlocklistHits limits source/destination pairs of blocklist hits kept within a window.
+	maxBlocklistHits = 10000
+
+	ipProtocolTCP = 6
+	ipProtocolUDP = 17
+
+	tcpFlagSYN = 2
+	tcpFlagACK = 16
+)
+
+var l = logger.DefaultSLogger(common.InputName)
+
+func SetLogger(log *logger.Logger) {
+	l = log
+}
+
+// Indicator is a threat indicator detected within a window.
+type Indicator struct {
+	Type         string
+	Namespace    string
+	ExporterAddr []byte
+	SrcAddr      []byte
+
+	// DstAddr and the blocklist entry matched, only for blocklist hits.
+	DstAddr []byte
+	Blocked string
+
+	// Distinct destination ports/hosts of the source, only for scans.
+	DistinctPorts int
+	DistinctHosts int
+
+	Flows   uint64
+	Bytes   uint64
+	Packets uint64
+
+	WindowStart time.Time
+	WindowEnd   time.Time
+}
+
+type sourceKey struct {
+	namespace string
+	addr      string
+}
+
+type sourceStats struct {
+	exporterAddr []byte
+	ports        map[int32]struct{}
+	hosts        map[string]struct{}
+
+	flows, bytes, packets uint64
+}
+
+type hitKey struct {
+	namespace string
+	src, dst  string
+}
+
+type hitStats struct {
+	exporterAddr []byte
+	blocked      string
+
+	flows, bytes, packets uint64
+}
+
+// Detector counts distinct destination ports/hosts of each source, and
+// matches destinations against the blocklist, within each window. Only used
+// by the run() routine of the aggregator, no lock needed.
+type Detector struct {
+	opt       common.ThreatOpt
+	blocklist *blocklist // nil if no blocklist configured
+
+	windowStart time.Time
+	sources     map[sourceKey]*sourceStats
+	hits        map[hitKey]*hitStats
+}
+
+// NewDetector returns a detector with the blocklist loaded.
+func NewDetector(opt common.ThreatOpt) (*Detector, error) {
+	if opt.Window <= 0 {
+		opt.Window = defaultWindow
+	}
+
+	d := &Detector{
+		opt:         opt,
+		windowStart: time.Now(),
+		sources:     make(map[sourceKey]*sourceStats),
+		hits:        make(map[hitKey]*hitStats),
+	}
+
+	if opt.Blocklist != "" {
+		b, err := newBlocklist(opt.Blocklist)
+		if err != nil {
+			return nil, err
+		}
+		l.Infof("Loaded %d entries from blocklist %s", b.size, opt.Blocklist)
+		d.blocklist = b
+	}
+
+	return d, nil
+}
+
+// Window returns the interval Flush should be called.
+func (d *Detector) Window() time.Duration {
+	return d.opt.Window
+}
+
+// Observe counts the flow, it should be called before the flow rolled up.
+func (d *Detector) Observe(flow *common.Flow) {
+	if d == nil {
+		return
+	}
+
+	if d.blocklist != nil {
+		if blocked := d.blocklist.lookup(flow.DstAddr); blocked != "" {
+			d.observeHit(flow, blocked)
+		}
+	}
+
+	if (d.opt.PortScanThreshold > 0 || d.opt.HostScanThreshold > 0) && isConnectionAttempt(flow) {
+		d.observeSource(flow)
+	}
+}
+
+// isConnectionAttempt returns false for flows not TCP/UDP, and TCP flows
+// of established or answered connections, so flows from servers to ports of
+// clients are not counted as scans. TCP flows without flags (exporters not
+// exporting TCP flags) are counted.
+func isConnectionAttempt(flow *common.Flow) bool {
+	switch flow.IPProtocol {
+	case ipProtocolUDP:
+		return true
+	case ipProtocolTCP:
+		return flow.TCPFlags == 0 || flow.TCPFlags&tcpFlagSYN != 0 && flow.TCPFlags&tcpFlagACK == 0
+	default:
+		return false
+	}
+}
+
+func (d *Detector) observeSource(flow *common.Flow) {
+	key := sourceKey{namespace: flow.Namespace, addr: string(flow.SrcAddr)}
+
+	s, ok := d.sources[key]
+	if !ok {
+		if len(d.sources) >= maxSources {
+			return
+		}
+		s = &sourceStats{
+			exporterAddr: flow.ExporterAddr,
+			ports:        make(map[int32]struct{}),
+			hosts:        make(map[string]struct{}),
+		}
+		d.sources[key] = s
+	}
+
+	if d.opt.PortScanThreshold > 0 && len(s.ports) < maxDistinct {
+		s.ports[flow.DstPort] = struct{}{}
+	}
+	if d.opt.HostScanThreshold > 0 && len(s.hosts) < maxDistinct {
+		s.hosts[string(flow.DstAddr)] = struct{}{}
+	}
+
+	s.flows++
+	s.bytes += flow.Bytes
+	s.packets += flow.Packets
+}
+
+func (d *Detector) observeHit(flow *common.Flow, blocked string) {
+	key := hitKey{namespace: flow.Namespace, src: string(flow.SrcAddr), dst: string(flow.DstAddr)}
+
+	h, ok := d.hits[key]
+	if !ok {
+		if len(d.hits) >= maxBlocklistHits {
+			return
+		}
+		h = &hitStats{exporterAddr: flow.ExporterAddr, blocked: blocked}
+		d.hits[key] = h
+	}
+
+	h.flows++
+	h.bytes += flow.Bytes
+	h.packets += flow.Packets
+}
+
+// Flush returns indicators of the window ended at now, and starts a new
+// window. The blocklist is reloaded if the file modified.
+func (d *Detector) Flush(now time.Time) []*Indicator {
+	if d == nil {
+		return nil
+	}
+
+	var indicators []*Indicator
+	newIndicator := func(typ, namespace string, exporterAddr []byte, src string) *Indicator {
+		ind := &Indicator{
+			Type:         typ,
+			Namespace:    namespace,
+			ExporterAddr: exporterAddr,
+			SrcAddr:      []byte(src),
+			WindowStart:  d.windowStart,
+			WindowEnd:    now,
+		}
+		indicators = append(indicators, ind)
+		return ind
+	}
+
+	for key, s := range d.sources {
+		if d.opt.PortScanThreshold > 0 && len(s.ports) > d.opt.PortScanThreshold {
+			ind := newIndicator(TypePortScan, key.namespace, s.exporterAddr, key.addr)
+			ind.DistinctPorts, ind.DistinctHosts = len(s.ports), len(s.hosts)
+			ind.Flows, ind.Bytes, ind.Packets = s.flows, s.bytes, s.packets
+		}
+		if d.opt.HostScanThreshold > 0 && len(s.hosts) > d.opt.HostScanThreshold {
+			ind := newIndicator(TypeHostScan, key.namespace, s.exporterAddr, key.addr)
+			ind.DistinctPorts, ind.DistinctHosts = len(s.ports), len(s.hosts)
+			ind.Flows, ind.Bytes, ind.Packets = s.flows, s.bytes, s.packets
+		}
+	}
+
+	for key, h := range d.hits {
+		ind := newIndicator(TypeBlocklist, key.namespace, h.exporterAddr, key.src)
+		ind.DstAddr, ind.Blocked = []byte(key.dst), h.blocked
+		ind.Flows, ind.Bytes, ind.Packets = h.flows, h.bytes, h.packets
+	}
+
+	d.windowStart = now
+	d.sources = make(map[sourceKey]*sourceStats)
+	d.hits = make(map[hitKey]*hitStats)
+
+	if d.blocklist != nil {
+		d.blocklist.reloadIfModified()
+	}
+
+	return indicators
+}
@@ -0,0 +1,129 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package threat
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/netflow/common"
+)
+
+func TestBlocklist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	require.NoError(t, os.WriteFile(path, []byte(`
+# comment
+1.2.3.4
+5.6.0.0/16  some description
+5.6.7.0/24
+2001:db8::/32
+`), 0o600))
+
+	b, err := newBlocklist(path)
+	require.NoError(t, err)
+	assert.Equal(t, 4, b.size)
+
+	assert.Equal(t, "1.2.3.4", b.lookup([]byte{1, 2, 3, 4}))
+	assert.Equal(t, "", b.lookup([]byte{1, 2, 3, 5}))
+	assert.Equal(t, "5.6.7.0/24", b.lookup([]byte{5, 6, 7, 8}))
+	assert.Equal(t, "5.6.0.0/16", b.lookup([]byte{5, 6, 8, 8}))
+	assert.Equal(t, "2001:db8::/32", b.lookup([]byte{0x20, 0x01, 0x0d, 0xb8, 15: 1}))
+	assert.Equal(t, "1.2.3.4", b.lookup([]byte{10: 0xff, 11: 0xff, 12: 1, 13: 2, 14: 3, 15: 4})) // IPv4-mapped
+
+	// reloaded once modified, entries kept if invalid
+	require.NoError(t, os.WriteFile(path, []byte("bad-ip\n"), 0o600))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Minute)))
+	b.reloadIfModified()
+	assert.Equal(t, "1.2.3.4", b.lookup([]byte{1, 2, 3, 4}))
+
+	require.NoError(t, os.WriteFile(path, []byte("9.9.9.9\n"), 0o600))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(2*time.Minute)))
+	b.reloadIfModified()
+	assert.Equal(t, "", b.lookup([]byte{1, 2, 3, 4}))
+	assert.Equal(t, "9.9.9.9", b.lookup([]byte{9, 9, 9, 9}))
+
+	_, err = newBlocklist(filepath.Join(t.TempDir(), "not-exist.txt"))
+	assert.Error(t, err)
+}
+
+func TestDetector(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	require.NoError(t, os.WriteFile(path, []byte("8.8.8.0/24\n"), 0o600))
+
+	d, err := NewDetector(common.ThreatOpt{
+		Enabled:           true,
+		PortScanThreshold: 10,
+		HostScanThreshold: 5,
+		Blocklist:         path,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, defaultWindow, d.Window())
+
+	flow := func(src, dst byte, port int32, flags uint32) *common.Flow {
+		return &common.Flow{
+			Namespace:    "ns",
+			ExporterAddr: []byte{127, 0, 0, 1},
+			SrcAddr:      []byte{10, 0, 0, src},
+			DstAddr:      []byte{10, 0, 1, dst},
+			IPProtocol:   ipProtocolTCP,
+			DstPort:      port,
+			TCPFlags:     flags,
+			Bytes:        40,
+			Packets:      1,
+		}
+	}
+
+	// 10.0.0.1 scans 20 ports of a host
+	for port := int32(1); port <= 20; port++ {
+		d.Observe(flow(1, 1, port, tcpFlagSYN))
+	}
+	// 10.0.0.2 scans port 22 of 6 hosts
+	for dst := byte(1); dst <= 6; dst++ {
+		d.Observe(flow(2, dst, 22, 0))
+	}
+	// 10.0.0.3 is a server answering clients, not counted
+	for port := int32(30000); port < 30020; port++ {
+		d.Observe(flow(3, 1, port, tcpFlagSYN|tcpFlagACK))
+	}
+	// 10.0.0.4 sends to blocked 8.8.8.8
+	blocked := flow(4, 1, 53, 0)
+	blocked.DstAddr = []byte{8, 8, 8, 8}
+	d.Observe(blocked)
+	d.Observe(blocked)
+
+	indicators := d.Flush(time.Now())
+	sort.Slice(indicators, func(i, j int) bool { return indicators[i].Type < indicators[j].Type })
+	require.Len(t, indicators, 3)
+
+	assert.Equal(t, TypeBlocklist, indicators[0].Type)
+	assert.Equal(t, []byte{10, 0, 0, 4}, indicators[0].SrcAddr)
+	assert.Equal(t, []byte{8, 8, 8, 8}, indicators[0].DstAddr)
+	assert.Equal(t, "8.8.8.0/24", indicators[0].Blocked)
+	assert.Equal(t, uint64(2), indicators[0].Flows)
+	assert.Equal(t, uint64(80), indicators[0].Bytes)
+
+	assert.Equal(t, TypeHostScan, indicators[1].Type)
+	assert.Equal(t, []byte{10, 0, 0, 2}, indicators[1].SrcAddr)
+	assert.Equal(t, 6, indicators[1].DistinctHosts)
+	assert.Equal(t, 1, indicators[1].DistinctPorts)
+
+	assert.Equal(t, TypePortScan, indicators[2].Type)
+	assert.Equal(t, []byte{10, 0, 0, 1}, indicators[2].SrcAddr)
+	assert.Equal(t, 20, indicators[2].DistinctPorts)
+	assert.Equal(t, "ns", indicators[2].Namespace)
+
+	// new window
+	assert.Empty(t, d.Flush(time.Now()))
+
+	var nilDetector *Detector
+	nilDetector.Observe(flow(1, 1, 1, 0))
+	assert.Nil(t, nilDetector.Flush(time.Now()))
+}
@@ -339,6 +339,13 @@ func (ipt *Input) SampleMeasurement() []inputs.Measurement {
 		&processMeasurement{},
 		&tablespaceMeasurement{},
 		&systemMeasurement{},
+		&asmMeasurement{},
+		&dataguardMeasurement{},
+		&archiveDestMeasurement{},
+		&dataguardEventMeasurement{},
+		&waitClassMeasurement{},
+		&topSQLMeasurement{},
+		&healthMeasurement{},
 	}
 }
 
@@ -14,6 +14,15 @@ const (
 	oracleProcess    = "oracle_process"
 	oracleTablespace = "oracle_tablespace"
 	oracleSystem     = "oracle_system"
+	oracleASM        = "oracle_asm"
+
+	oracleDataguard      = "oracle_dataguard"
+	oracleArchiveDest    = "oracle_archive_dest"
+	oracleDataguardEvent = "oracle_dataguard_event"
+
+	oracleWaitClass = "oracle_wait_class"
+	oracleTopSQL    = "oracle_top_sql"
+	oracleHealth    = "oracle_health"
 )
 
 type processMeasurement struct {
@@ -52,6 +61,7 @@ func (m *processMeasurement) Info() *inputs.MeasurementInfo {
 			"oracle_service": &inputs.TagInfo{Desc: "Server service"},
 			"pdb_name":       &inputs.TagInfo{Desc: "PDB name"},
 			"program":        &inputs.TagInfo{Desc: "Program in progress"},
+			"inst_id":        &inputs.TagInfo{Desc: "Instance number of RAC, only collected by the external collector"},
 		},
 	}
 }
@@ -82,6 +92,12 @@ func (m *tablespaceMeasurement) Info() *inputs.MeasurementInfo {
 			"off_use":    &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.SizeByte, Desc: "Total space consumed by the Tablespace,in database blocks"},
 			"ts_size":    &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.SizeByte, Desc: "Table space size"},
 			"used_space": &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.SizeByte, Desc: "Used space"},
+
+			"allocated_space":     &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.SizeByte, Desc: "Allocated size of datafiles. Only collected by the external collector"},
+			"max_size":            &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.SizeByte, Desc: "Max size of datafiles with autoextend. Only collected by the external collector"},
+			"autoextend_headroom": &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.SizeByte, Desc: "Size datafiles could still autoextend. Only collected by the external collector"},
+			"growth_rate":         &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.SizeByte, Desc: "Average growth of used space per day in the last 7 days. Only collected by the external collector"},
+			"days_until_full":     &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.DurationDay, Desc: "Days until used space reaches the max size at the growth rate, not reported if not growing. Only collected by the external collector"},
 		},
 		Tags: map[string]interface{}{
 			"host":            &inputs.TagInfo{Desc: "Host name"},
@@ -145,6 +161,7 @@ func (m *systemMeasurement) Info() *inputs.MeasurementInfo {
 			"rows_per_sort":             &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.NCount, Desc: "Rows per sort"},
 			"service_response_time":     &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.TimestampSec, Desc: "Service response time"},
 			"session_count":             &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.NCount, Desc: "Session count"},
+			"active_session_count":      &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.NCount, Desc: "Active session count of the PDB, only collected in CDB"},
 			"session_limit_usage":       &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.Percent, Desc: "Session limit usage"},
 			"shared_pool_free":          &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.Percent, Desc: "Shared pool free memory %"},
 			"soft_parse_ratio":          &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.Percent, Desc: "Soft parse ratio"},
@@ -157,6 +174,273 @@ func (m *systemMeasurement) Info() *inputs.MeasurementInfo {
 			"oracle_server":  &inputs.TagInfo{Desc: "Server addr"},
 			"oracle_service": &inputs.TagInfo{Desc: "Server service"},
 			"pdb_name":       &inputs.TagInfo{Desc: "PDB name"},
+			"inst_id":        &inputs.TagInfo{Desc: "Instance number of RAC, only collected by the external collector"},
+		},
+	}
+}
+
+type asmMeasurement struct {
+	name   string
+	tags   map[string]string
+	fields map[string]interface{}
+	ts     int64
+}
+
+// Point implement MeasurementV2.
+func (m *asmMeasurement) Point() *point.Point {
+	opts := point.DefaultMetricOptions()
+	opts = append(opts, point.WithTimestamp(m.ts))
+
+	return point.NewPointV2(m.name,
+		append(point.NewTags(m.tags), point.NewKVs(m.fields)...),
+		opts...)
+}
+
+// https://docs.oracle.com/en/database/oracle/oracle-database/19/refrn/V-ASM_DISKGROUP.html
+//
+//nolint:lll
+func (m *asmMeasurement) Info() *inputs.MeasurementInfo {
+	return &inputs.MeasurementInfo{
+		Name: oracleASM,
+		Desc: "Only collected by the external collector when the instance uses ASM.",
+		Fields: map[string]interface{}{
+			"total_space":                &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.SizeByte, Desc: "Total capacity of the disk group"},
+			"free_space":                 &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.SizeByte, Desc: "Unused capacity of the disk group"},
+			"usable_file_space":          &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.SizeByte, Desc: "Free space that is available for new files, after mirroring"},
+			"required_mirror_free_space": &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.SizeByte, Desc: "Space required to restore full redundancy after the worst failure"},
+			"used_percent":               &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.Percent, Desc: "Used space in percent of the disk group"},
+			"disks":                      &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.NCount, Desc: "Number of disks in the disk group"},
+			"offline_disks":              &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.NCount, Desc: "Number of offline disks in the disk group"},
+			"read_errors":                &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Count, Unit: inputs.NCount, Desc: "Total failed I/O read requests of disks in the disk group"},
+			"write_errors":               &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Count, Unit: inputs.NCount, Desc: "Total failed I/O write requests of disks in the disk group"},
+			"rebalance_operations":       &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.NCount, Desc: "Number of running rebalance operations"},
+			"rebalance_est_minutes":      &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.DurationMinute, Desc: "Estimated time to complete the rebalance operations"},
+		},
+		Tags: map[string]interface{}{
+			"host":           &inputs.TagInfo{Desc: "Host name"},
+			"oracle_server":  &inputs.TagInfo{Desc: "Server addr"},
+			"oracle_service": &inputs.TagInfo{Desc: "Server service"},
+			"diskgroup_name": &inputs.TagInfo{Desc: "Disk group name"},
+			"state":          &inputs.TagInfo{Desc: "State of the disk group, such as `MOUNTED`, `CONNECTED`"},
+			"type":           &inputs.TagInfo{Desc: "Redundancy type of the disk group, such as `EXTERN`, `NORMAL`, `HIGH`"},
+		},
+	}
+}
+
+type dataguardMeasurement struct {
+	name   string
+	tags   map[string]string
+	fields map[string]interface{}
+	ts     int64
+}
+
+// Point implement MeasurementV2.
+func (m *dataguardMeasurement) Point() *point.Point {
+	opts := point.DefaultMetricOptions()
+	opts = append(opts, point.WithTimestamp(m.ts))
+
+	return point.NewPointV2(m.name,
+		append(point.NewTags(m.tags), point.NewKVs(m.fields)...),
+		opts...)
+}
+
+// https://docs.oracle.com/en/database/oracle/oracle-database/19/refrn/V-DATAGUARD_STATS.html
+//
+//nolint:lll
+func (m *dataguardMeasurement) Info() *inputs.MeasurementInfo {
+	return &inputs.MeasurementInfo{
+		Name: oracleDataguard,
+		Desc: "Only collected by the external collector on standby databases.",
+		Fields: map[string]interface{}{
+			"transport_lag":         &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.DurationSecond, Desc: "How much redo data generated by the primary is not yet available on the standby"},
+			"apply_lag":             &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.DurationSecond, Desc: "How far the standby is behind the primary"},
+			"apply_finish_time":     &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.DurationSecond, Desc: "Estimated time to finish applying all received redo data"},
+			"archive_gap_sequences": &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.NCount, Desc: "Number of archived log sequences missing on the standby"},
+		},
+		Tags: map[string]interface{}{
+			"host":           &inputs.TagInfo{Desc: "Host name"},
+			"oracle_server":  &inputs.TagInfo{Desc: "Server addr"},
+			"oracle_service": &inputs.TagInfo{Desc: "Server service"},
+			"database_role":  &inputs.TagInfo{Desc: "Role of the database, such as `PHYSICAL STANDBY`"},
+			"db_unique_name": &inputs.TagInfo{Desc: "Unique database name"},
+		},
+	}
+}
+
+type archiveDestMeasurement struct {
+	name   string
+	tags   map[string]string
+	fields map[string]interface{}
+	ts     int64
+}
+
+// Point implement MeasurementV2.
+func (m *archiveDestMeasurement) Point() *point.Point {
+	opts := point.DefaultMetricOptions()
+	opts = append(opts, point.WithTimestamp(m.ts))
+
+	return point.NewPointV2(m.name,
+		append(point.NewTags(m.tags), point.NewKVs(m.fields)...),
+		opts...)
+}
+
+// https://docs.oracle.com/en/database/oracle/oracle-database/19/refrn/V-ARCHIVE_DEST_STATUS.html
+//
+//nolint:lll
+func (m *archiveDestMeasurement) Info() *inputs.MeasurementInfo {
+	return &inputs.MeasurementInfo{
+		Name: oracleArchiveDest,
+		Desc: "Only collected by the external collector, for active standby destinations.",
+		Fields: map[string]interface{}{
+			"error":        &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.UnknownUnit, Desc: "Whether the destination has transport error(1) or not(0)"},
+			"archived_seq": &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.UnknownUnit, Desc: "Current archived redo log sequence number"},
+			"applied_seq":  &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.UnknownUnit, Desc: "Current applied redo log sequence number on the standby"},
+			"sequence_gap": &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.NCount, Desc: "Archived but not applied redo log sequences"},
+		},
+		Tags: map[string]interface{}{
+			"host":           &inputs.TagInfo{Desc: "Host name"},
+			"oracle_server":  &inputs.TagInfo{Desc: "Server addr"},
+			"oracle_service": &inputs.TagInfo{Desc: "Server service"},
+			"dest_id":        &inputs.TagInfo{Desc: "Destination ID"},
+			"dest_name":      &inputs.TagInfo{Desc: "Destination name"},
+			"destination":    &inputs.TagInfo{Desc: "Destination of the redo data, such as the net service name of the standby"},
+			"status":         &inputs.TagInfo{Desc: "Status of the destination, such as `VALID`, `ERROR`"},
+			"gap_status":     &inputs.TagInfo{Desc: "Redo gap status, such as `NO GAP`, `RESOLVABLE GAP`"},
+		},
+	}
+}
+
+type dataguardEventMeasurement struct{}
+
+// Point implement MeasurementV2.
+func (m *dataguardEventMeasurement) Point() *point.Point {
+	return nil
+}
+
+//nolint:lll
+func (m *dataguardEventMeasurement) Info() *inputs.MeasurementInfo {
+	return &inputs.MeasurementInfo{
+		Name: oracleDataguardEvent,
+		Type: "keyevent",
+		Desc: "Reported by the external collector when transport error of a standby destination occurred, changed or recovered.",
+		Fields: map[string]interface{}{
+			"df_title":   &inputs.FieldInfo{DataType: inputs.String, Type: inputs.Gauge, Unit: inputs.UnknownUnit, Desc: "Title of the event"},
+			"df_message": &inputs.FieldInfo{DataType: inputs.String, Type: inputs.Gauge, Unit: inputs.UnknownUnit, Desc: "Message of the event, with the transport error"},
+			"df_status":  &inputs.FieldInfo{DataType: inputs.String, Type: inputs.Gauge, Unit: inputs.UnknownUnit, Desc: "Status of the event, `error` or `ok`"},
+		},
+		Tags: map[string]interface{}{
+			"host":           &inputs.TagInfo{Desc: "Host name"},
+			"oracle_server":  &inputs.TagInfo{Desc: "Server addr"},
+			"oracle_service": &inputs.TagInfo{Desc: "Server service"},
+			"dest_name":      &inputs.TagInfo{Desc: "Destination name"},
+			"destination":    &inputs.TagInfo{Desc: "Destination of the redo data"},
+		},
+	}
+}
+
+type waitClassMeasurement struct {
+	name   string
+	tags   map[string]string
+	fields map[string]interface{}
+	ts     int64
+}
+
+// Point implement MeasurementV2.
+func (m *waitClassMeasurement) Point() *point.Point {
+	opts := point.DefaultMetricOptions()
+	opts = append(opts, point.WithTimestamp(m.ts))
+
+	return point.NewPointV2(m.name,
+		append(point.NewTags(m.tags), point.NewKVs(m.fields)...),
+		opts...)
+}
+
+//nolint:lll
+func (m *waitClassMeasurement) Info() *inputs.MeasurementInfo {
+	return &inputs.MeasurementInfo{
+		Name: oracleWaitClass,
+		Desc: "Only collected by the external collector with `--ash-top` set, sampled from `v$session` each interval.",
+		Fields: map[string]interface{}{
+			"active_sessions": &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.NCount, Desc: "Number of active user sessions in the wait class"},
+		},
+		Tags: map[string]interface{}{
+			"host":           &inputs.TagInfo{Desc: "Host name"},
+			"oracle_server":  &inputs.TagInfo{Desc: "Server addr"},
+			"oracle_service": &inputs.TagInfo{Desc: "Server service"},
+			"inst_id":        &inputs.TagInfo{Desc: "Instance number of RAC"},
+			"wait_class":     &inputs.TagInfo{Desc: "Wait class of the sessions, `ON CPU` for sessions not waiting"},
+		},
+	}
+}
+
+type topSQLMeasurement struct {
+	name   string
+	tags   map[string]string
+	fields map[string]interface{}
+	ts     int64
+}
+
+// Point implement MeasurementV2.
+func (m *topSQLMeasurement) Point() *point.Point {
+	opts := point.DefaultMetricOptions()
+	opts = append(opts, point.WithTimestamp(m.ts))
+
+	return point.NewPointV2(m.name,
+		append(point.NewTags(m.tags), point.NewKVs(m.fields)...),
+		opts...)
+}
+
+//nolint:lll
+func (m *topSQLMeasurement) Info() *inputs.MeasurementInfo {
+	return &inputs.MeasurementInfo{
+		Name: oracleTopSQL,
+		Desc: "Only collected by the external collector with `--ash-top` set, the top N SQL by active sessions sampled from `v$session` each interval.",
+		Fields: map[string]interface{}{
+			"active_sessions":  &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.NCount, Desc: "Number of active sessions running the SQL"},
+			"on_cpu_sessions":  &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.NCount, Desc: "Number of sessions running the SQL on CPU"},
+			"waiting_sessions": &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.NCount, Desc: "Number of sessions running the SQL in waiting"},
+		},
+		Tags: map[string]interface{}{
+			"host":           &inputs.TagInfo{Desc: "Host name"},
+			"oracle_server":  &inputs.TagInfo{Desc: "Server addr"},
+			"oracle_service": &inputs.TagInfo{Desc: "Server service"},
+			"inst_id":        &inputs.TagInfo{Desc: "Instance number of RAC"},
+			"sql_id":         &inputs.TagInfo{Desc: "SQL identifier"},
+			"top_wait_class": &inputs.TagInfo{Desc: "Wait class that most sessions of the SQL in"},
+		},
+	}
+}
+
+type healthMeasurement struct {
+	name   string
+	tags   map[string]string
+	fields map[string]interface{}
+	ts     int64
+}
+
+// Point implement MeasurementV2.
+func (m *healthMeasurement) Point() *point.Point {
+	opts := point.DefaultMetricOptions()
+	opts = append(opts, point.WithTimestamp(m.ts))
+
+	return point.NewPointV2(m.name,
+		append(point.NewTags(m.tags), point.NewKVs(m.fields)...),
+		opts...)
+}
+
+//nolint:lll
+func (m *healthMeasurement) Info() *inputs.MeasurementInfo {
+	return &inputs.MeasurementInfo{
+		Name: oracleHealth,
+		Desc: "Only collected by the external collector, reported even if the database is unreachable.",
+		Fields: map[string]interface{}{
+			"connected":            &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.UnknownUnit, Desc: "Whether the database is connected(1) or not(0)"},
+			"since_last_connected": &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.DurationSecond, Desc: "Time since the last successful connection"},
+		},
+		Tags: map[string]interface{}{
+			"host":           &inputs.TagInfo{Desc: "Host name"},
+			"oracle_server":  &inputs.TagInfo{Desc: "Server addr"},
+			"oracle_service": &inputs.TagInfo{Desc: "Server service"},
 		},
 	}
 }
@@ -24,6 +24,7 @@ import (
 
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/config"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/datakit"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/goroutine"
 	dkio "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/metrics"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs"
@@ -94,37 +95,20 @@ func (ipt *Input) Run() {
 	tick := time.NewTicker(ipt.ObjectInterval.Duration)
 	defer tick.Stop()
 	if ipt.OpenMetric {
-		g := datakit.G("inputs_process")
-		g.Go(func(ctx context.Context) error {
-			ipt.MetricInterval.Duration = config.ProtectedInterval(minMetricInterval,
-				maxMetricInterval,
-				ipt.MetricInterval.Duration)
-
-			procRecorder := newProcRecorder()
-			tick := time.NewTicker(ipt.MetricInterval.Duration)
-			defer tick.Stop()
-
-			lastTS := time.Now()
-			for {
-				processList := ipt.getProcesses(true)
-				tn := time.Now().UTC()
-
-				ipt.WriteMetric(processList, procRecorder, tn, lastTS.UnixNano())
-				procRecorder.flush(processList, tn)
-				select {
-				case tt := <-tick.C:
-					nextts := inputs.AlignTimeMillSec(tt, lastTS.UnixMilli(), ipt.MetricInterval.Duration.Milliseconds())
-					lastTS = time.UnixMilli(nextts)
-				case <-datakit.Exit.Wait():
-					l.Info("process write metric exit")
-					return nil
-
-				case <-ipt.semStop.Wait():
-					l.Info("process write metric return")
-					return nil
-				}
-			}
-		})
+		ipt.MetricInterval.Duration = config.ProtectedInterval(minMetricInterval,
+			maxMetricInterval,
+			ipt.MetricInterval.Duration)
+
+		procRecorder := newProcRecorder()
+
+		g := datakit.GStop("inputs_process", ipt.semStop)
+		g.Every(ipt.MetricInterval.Duration, func(_ context.Context, tick time.Time) {
+			processList := ipt.getProcesses(true)
+			tn := time.Now().UTC()
+
+			ipt.WriteMetric(processList, procRecorder, tn, tick.UnixNano())
+			procRecorder.flush(processList, tn)
+		}, goroutine.WithImmediate())
 	}
 
 	procRecorder := newProcRecorder()
@@ -0,0 +1,220 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package prom
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	iprom "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/prom"
+)
+
+const (
+	defaultRefreshInterval = time.Minute
+
+	labelAddress     = "__address__"
+	labelScheme      = "__scheme__"
+	labelMetricsPath = "__metrics_path__"
+	labelParamPrefix = "__param_"
+	labelMetaPrefix  = "__meta_"
+)
+
+// Discovery discover the targets to scrape from the file_sd JSON files or
+// Kubernetes, the targets are relabeled by relabel_configs.
+type Discovery struct {
+	RefreshInterval time.Duration   `toml:"refresh_interval"`
+	Files           []string        `toml:"files"`
+	Kubernetes      []*KubernetesSD `toml:"kubernetes"`
+}
+
+// discoverer get the label sets of the targets, such as
+// {__address__="10.0.0.1:9100", __meta_kubernetes_pod_name="node-exporter-xxx"}.
+type discoverer interface {
+	name() string
+	discover() ([]map[string]string, error)
+}
+
+type target struct {
+	url  string
+	tags map[string]string // labels not prefixed with __
+}
+
+type serviceDiscovery struct {
+	discoverers []discoverer
+	relabels    []*iprom.RelabelConfig
+	interval    time.Duration
+
+	last        map[string][]map[string]string // the last succeeded result of the discoverers
+	lastRefresh time.Time
+	targets     []*target
+}
+
+func newServiceDiscovery(d *Discovery, relabels []*iprom.RelabelConfig) (*serviceDiscovery, error) {
+	for _, c := range relabels {
+		if err := c.Init(); err != nil {
+			return nil, err
+		}
+	}
+
+	sd := &serviceDiscovery{
+		relabels: relabels,
+		interval: d.RefreshInterval,
+		last:     map[string][]map[string]string{},
+	}
+
+	if sd.interval <= 0 {
+		sd.interval = defaultRefreshInterval
+	}
+
+	if len(d.Files) > 0 {
+		sd.discoverers = append(sd.discoverers, &fileDiscoverer{patterns: d.Files})
+	}
+
+	for idx, k := range d.Kubernetes {
+		x, err := newKubernetesDiscoverer(k, idx)
+		if err != nil {
+			return nil, err
+		}
+		sd.discoverers = append(sd.discoverers, x)
+	}
+
+	return sd, nil
+}
+
+// refresh the targets if the refresh interval passed, true returned if
+// refreshed. The discoverer failed keeps its last targets.
+func (sd *serviceDiscovery) refresh(now time.Time) (bool, []error) {
+	if !sd.lastRefresh.IsZero() && now.Sub(sd.lastRefresh) < sd.interval {
+		return false, nil
+	}
+	sd.lastRefresh = now
+
+	var errs []error
+	for _, d := range sd.discoverers {
+		lbs, err := d.discover()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("discovery %s: %w", d.name(), err))
+			continue
+		}
+		sd.last[d.name()] = lbs
+	}
+
+	var (
+		targets []*target
+		exists  = map[string]bool{}
+	)
+
+	for _, d := range sd.discoverers {
+		for _, lbs := range sd.last[d.name()] {
+			t := sd.toTarget(lbs)
+			if t == nil || exists[t.url] {
+				continue
+			}
+			exists[t.url] = true
+			targets = append(targets, t)
+		}
+	}
+
+	sort.Slice(targets, func(i, j int) bool { return targets[i].url < targets[j].url })
+	sd.targets = targets
+
+	return true, errs
+}
+
+// toTarget relabel the labels and build the URL, nil returned if dropped.
+func (sd *serviceDiscovery) toTarget(lbs map[string]string) *target {
+	x := copyLabels(lbs)
+
+	if _, ok := x[labelScheme]; !ok {
+		x[labelScheme] = "http"
+	}
+	if _, ok := x[labelMetricsPath]; !ok {
+		x[labelMetricsPath] = "/metrics"
+	}
+
+	if !iprom.Relabel(x, sd.relabels) {
+		return nil
+	}
+
+	addr := x[labelAddress]
+	if addr == "" || strings.Contains(addr, "/") {
+		return nil
+	}
+
+	u := &url.URL{Scheme: x[labelScheme], Host: addr, Path: x[labelMetricsPath]}
+
+	params := url.Values{}
+	tags := map[string]string{}
+	for k, v := range x {
+		switch {
+		case strings.HasPrefix(k, labelParamPrefix):
+			params.Set(strings.TrimPrefix(k, labelParamPrefix), v)
+		case !strings.HasPrefix(k, "__"):
+			tags[k] = v
+		}
+	}
+	u.RawQuery = params.Encode()
+
+	return &target{url: u.String(), tags: tags}
+}
+
+// fileDiscoverer read the targets from file_sd JSON files, such as
+//
+//	[{"targets": ["10.0.0.1:9100", "10.0.0.2:9100"], "labels": {"env": "prod"}}]
+type fileDiscoverer struct {
+	patterns []string
+}
+
+type fileTargetGroup struct {
+	Targets []string          `json:"targets"`
+	Labels  map[string]string `json:"labels"`
+}
+
+func (*fileDiscoverer) name() string { return "file" }
+
+func (d *fileDiscoverer) discover() ([]map[string]string, error) {
+	var res []map[string]string
+
+	for _, pattern := range d.patterns {
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, f := range files {
+			data, err := os.ReadFile(filepath.Clean(f))
+			if err != nil {
+				return nil, err
+			}
+
+			var groups []fileTargetGroup
+			if err := json.Unmarshal(data, &groups); err != nil {
+				return nil, fmt.Errorf("invalid file_sd %s: %w", f, err)
+			}
+
+			for _, g := range groups {
+				for _, t := range g.Targets {
+					lbs := map[string]string{
+						labelAddress:                 t,
+						labelMetaPrefix + "filepath": f,
+					}
+					for k, v := range g.Labels {
+						lbs[k] = v
+					}
+					res = append(res, lbs)
+				}
+			}
+		}
+	}
+
+	return res, nil
+}
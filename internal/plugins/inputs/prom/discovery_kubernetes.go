@@ -0,0 +1,308 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package prom
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/kubernetes/client"
+	apicorev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	rolePod       = "pod"
+	roleEndpoints = "endpoints"
+
+	k8sMeta = labelMetaPrefix + "kubernetes_"
+)
+
+// KubernetesSD discover the pods or the endpoints of the services, the meta
+// labels are the same as the kubernetes_sd_config of Prometheus, such as
+// __meta_kubernetes_pod_annotation_prometheus_io_scrape.
+type KubernetesSD struct {
+	Role          string   `toml:"role"`       // pod or endpoints
+	Namespaces    []string `toml:"namespaces"` // all namespaces if empty
+	LabelSelector string   `toml:"label_selector"`
+	FieldSelector string   `toml:"field_selector"`
+	NodeLocal     bool     `toml:"node_local"` // only the targets on the node of the Datakit
+}
+
+type kubernetesDiscoverer struct {
+	cfg      *KubernetesSD
+	id       string
+	client   client.Client
+	nodeName string
+}
+
+func newKubernetesDiscoverer(cfg *KubernetesSD, idx int) (*kubernetesDiscoverer, error) {
+	switch cfg.Role {
+	case rolePod, roleEndpoints:
+	default:
+		return nil, fmt.Errorf("unknown kubernetes role %q, only %s/%s supported", cfg.Role, rolePod, roleEndpoints)
+	}
+
+	d := &kubernetesDiscoverer{cfg: cfg, id: fmt.Sprintf("kubernetes/%s/%d", cfg.Role, idx)}
+
+	if cfg.NodeLocal {
+		for _, env := range []string{"ENV_K8S_NODE_NAME", "NODE_NAME"} {
+			if d.nodeName = os.Getenv(env); d.nodeName != "" {
+				break
+			}
+		}
+		if d.nodeName == "" {
+			return nil, fmt.Errorf("node_local requires ENV_K8S_NODE_NAME environment")
+		}
+	}
+
+	return d, nil
+}
+
+func (d *kubernetesDiscoverer) name() string { return d.id }
+
+func (d *kubernetesDiscoverer) discover() ([]map[string]string, error) {
+	if d.client == nil {
+		cli, err := client.NewKubernetesClientInCluster()
+		if err != nil {
+			return nil, err
+		}
+		d.client = cli
+	}
+
+	namespaces := d.cfg.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{client.NAMESPACE}
+	}
+
+	var res []map[string]string
+	for _, ns := range namespaces {
+		var (
+			x   []map[string]string
+			err error
+		)
+
+		if d.cfg.Role == rolePod {
+			x, err = d.discoverPods(ns)
+		} else {
+			x, err = d.discoverEndpoints(ns)
+		}
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, x...)
+	}
+
+	return res, nil
+}
+
+func (d *kubernetesDiscoverer) listOptions() metav1.ListOptions {
+	opt := metav1.ListOptions{
+		ResourceVersion: "0",
+		LabelSelector:   d.cfg.LabelSelector,
+		FieldSelector:   d.cfg.FieldSelector,
+	}
+
+	if d.cfg.Role == rolePod && d.nodeName != "" {
+		if opt.FieldSelector != "" {
+			opt.FieldSelector += ","
+		}
+		opt.FieldSelector += "spec.nodeName=" + d.nodeName
+	}
+
+	return opt
+}
+
+func (d *kubernetesDiscoverer) discoverPods(ns string) ([]map[string]string, error) {
+	list, err := d.client.GetPods(ns).List(context.Background(), d.listOptions())
+	if err != nil {
+		return nil, fmt.Errorf("list pods of namespace %q: %w", ns, err)
+	}
+
+	var res []map[string]string
+	for idx := range list.Items {
+		res = append(res, podTargets(&list.Items[idx])...)
+	}
+	return res, nil
+}
+
+func (d *kubernetesDiscoverer) discoverEndpoints(ns string) ([]map[string]string, error) {
+	list, err := d.client.GetEndpoints(ns).List(context.Background(), d.listOptions())
+	if err != nil {
+		return nil, fmt.Errorf("list endpoints of namespace %q: %w", ns, err)
+	}
+
+	// the labels/annotations of the service are used in most cases, such as
+	// prometheus.io/scrape on the service.
+	svcs, err := d.client.GetServices(ns).List(context.Background(), metav1.ListOptions{ResourceVersion: "0"})
+	if err != nil {
+		return nil, fmt.Errorf("list services of namespace %q: %w", ns, err)
+	}
+
+	services := map[string]*apicorev1.Service{}
+	for idx := range svcs.Items {
+		svc := &svcs.Items[idx]
+		services[svc.Namespace+"/"+svc.Name] = svc
+	}
+
+	var res []map[string]string
+	for idx := range list.Items {
+		ep := &list.Items[idx]
+		res = append(res, endpointsTargets(ep, services[ep.Namespace+"/"+ep.Name], d.nodeName)...)
+	}
+	return res, nil
+}
+
+// podTargets get one target for each container port of the pod, or one
+// target of the pod IP if no port declared.
+func podTargets(pod *apicorev1.Pod) []map[string]string {
+	if pod.Status.PodIP == "" {
+		return nil
+	}
+
+	base := map[string]string{
+		k8sMeta + "namespace":     pod.Namespace,
+		k8sMeta + "pod_name":      pod.Name,
+		k8sMeta + "pod_ip":        pod.Status.PodIP,
+		k8sMeta + "pod_node_name": pod.Spec.NodeName,
+		k8sMeta + "pod_host_ip":   pod.Status.HostIP,
+		k8sMeta + "pod_phase":     string(pod.Status.Phase),
+		k8sMeta + "pod_ready":     podReady(pod),
+		k8sMeta + "pod_uid":       string(pod.UID),
+	}
+
+	if ref := metav1.GetControllerOf(pod); ref != nil {
+		base[k8sMeta+"pod_controller_kind"] = ref.Kind
+		base[k8sMeta+"pod_controller_name"] = ref.Name
+	}
+
+	addObjectMeta(base, "pod", pod.Labels, pod.Annotations)
+
+	var res []map[string]string
+	for _, c := range pod.Spec.Containers {
+		for _, port := range c.Ports {
+			lbs := copyLabels(base)
+			lbs[labelAddress] = net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(int(port.ContainerPort)))
+			lbs[k8sMeta+"pod_container_name"] = c.Name
+			lbs[k8sMeta+"pod_container_image"] = c.Image
+			lbs[k8sMeta+"pod_container_port_name"] = port.Name
+			lbs[k8sMeta+"pod_container_port_number"] = strconv.Itoa(int(port.ContainerPort))
+			lbs[k8sMeta+"pod_container_port_protocol"] = string(port.Protocol)
+			res = append(res, lbs)
+		}
+	}
+
+	if len(res) == 0 {
+		base[labelAddress] = pod.Status.PodIP
+		res = append(res, base)
+	}
+
+	return res
+}
+
+// endpointsTargets get one target for each address and port of the
+// endpoints, the addresses not on the node skipped if nodeName not empty.
+func endpointsTargets(ep *apicorev1.Endpoints, svc *apicorev1.Service, nodeName string) []map[string]string {
+	base := map[string]string{
+		k8sMeta + "namespace":      ep.Namespace,
+		k8sMeta + "endpoints_name": ep.Name,
+	}
+	addObjectMeta(base, "endpoints", ep.Labels, ep.Annotations)
+
+	if svc != nil {
+		base[k8sMeta+"service_name"] = svc.Name
+		addObjectMeta(base, "service", svc.Labels, svc.Annotations)
+	}
+
+	var res []map[string]string
+	for _, subset := range ep.Subsets {
+		add := func(addr apicorev1.EndpointAddress, ready string) {
+			if nodeName != "" && (addr.NodeName == nil || *addr.NodeName != nodeName) {
+				return
+			}
+
+			for _, port := range subset.Ports {
+				lbs := copyLabels(base)
+				lbs[labelAddress] = net.JoinHostPort(addr.IP, strconv.Itoa(int(port.Port)))
+				lbs[k8sMeta+"endpoint_ready"] = ready
+				lbs[k8sMeta+"endpoint_port_name"] = port.Name
+				lbs[k8sMeta+"endpoint_port_protocol"] = string(port.Protocol)
+				lbs[k8sMeta+"endpoint_hostname"] = addr.Hostname
+
+				if addr.NodeName != nil {
+					lbs[k8sMeta+"endpoint_node_name"] = *addr.NodeName
+				}
+
+				if ref := addr.TargetRef; ref != nil {
+					lbs[k8sMeta+"endpoint_address_target_kind"] = ref.Kind
+					lbs[k8sMeta+"endpoint_address_target_name"] = ref.Name
+					if ref.Kind == "Pod" {
+						lbs[k8sMeta+"pod_name"] = ref.Name
+					}
+				}
+
+				res = append(res, lbs)
+			}
+		}
+
+		for _, addr := range subset.Addresses {
+			add(addr, "true")
+		}
+		for _, addr := range subset.NotReadyAddresses {
+			add(addr, "false")
+		}
+	}
+
+	return res
+}
+
+// addObjectMeta add the labels and annotations of the object, such as
+// __meta_kubernetes_pod_label_app and __meta_kubernetes_pod_labelpresent_app.
+func addObjectMeta(lbs map[string]string, kind string, labels, annotations map[string]string) {
+	for k, v := range labels {
+		name := sanitizeLabelName(k)
+		lbs[k8sMeta+kind+"_label_"+name] = v
+		lbs[k8sMeta+kind+"_labelpresent_"+name] = "true"
+	}
+
+	for k, v := range annotations {
+		name := sanitizeLabelName(k)
+		lbs[k8sMeta+kind+"_annotation_"+name] = v
+		lbs[k8sMeta+kind+"_annotationpresent_"+name] = "true"
+	}
+}
+
+func podReady(pod *apicorev1.Pod) string {
+	for _, c := range pod.Status.Conditions {
+		if c.Type == apicorev1.PodReady {
+			return strings.ToLower(string(c.Status))
+		}
+	}
+	return "unknown"
+}
+
+// sanitizeLabelName replace the invalid characters with _, such as
+// prometheus.io/scrape to prometheus_io_scrape.
+func sanitizeLabelName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+func copyLabels(lbs map[string]string) map[string]string {
+	x := make(map[string]string, len(lbs)+8)
+	for k, v := range lbs {
+		x[k] = v
+	}
+	return x
+}
@@ -0,0 +1,228 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package prom
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	T "testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apicorev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	iprom "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/prom"
+)
+
+func TestFileDiscovery(t *T.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "# TYPE node_load1 gauge\nnode_load1{path=%q,module=%q} 0.5\n", r.URL.Path, r.URL.Query().Get("module"))
+	}))
+	defer srv.Close()
+
+	host := srv.Listener.Addr().String()
+	dir := t.TempDir()
+	sdFile := filepath.Join(dir, "targets.json")
+
+	writeSD := func(body string) {
+		require.NoError(t, os.WriteFile(sdFile, []byte(body), 0o600))
+	}
+
+	writeSD(fmt.Sprintf(`[
+  {"targets": [%q], "labels": {"env": "prod", "__metrics_path__": "/node", "__param_module": "cpu"}},
+  {"targets": ["10.0.0.1:9100"], "labels": {"env": "test"}}
+]`, host))
+
+	inp := NewProm()
+	inp.StreamSize = 0
+	inp.Tags = map[string]string{"env": "default", "team": "sre"}
+	inp.Discovery = &Discovery{Files: []string{filepath.Join(dir, "*.json")}}
+	inp.RelabelConfigs = []*iprom.RelabelConfig{
+		{SourceLabels: []string{"env"}, Regex: "test", Action: "drop"},
+		{SourceLabels: []string{"__meta_filepath"}, Regex: ".*/(.*)", TargetLabel: "sd_file"},
+	}
+	inp.Tagger = &taggerMock{}
+	inp.tryInit()
+	require.True(t, inp.isInitialized)
+
+	inp.refreshTargets()
+
+	u := "http://" + host + "/node?module=cpu"
+	require.Equal(t, []string{u}, inp.targetURLs())
+
+	pts, err := inp.collectFormSource(u)
+	require.NoError(t, err)
+	require.Len(t, pts, 1)
+
+	tags := pts[0].Tags()
+	assert.Equal(t, "prod", tags.Get("env").GetS()) // overwrite the configured tags
+	assert.Equal(t, "sre", tags.Get("team").GetS())
+	assert.Equal(t, "targets.json", tags.Get("sd_file").GetS())
+	assert.Equal(t, host, tags.Get("instance").GetS())
+	assert.Equal(t, "/node", tags.Get("path").GetS())
+	assert.Equal(t, "cpu", tags.Get("module").GetS())
+
+	t.Run("not-refreshed-within-interval", func(t *T.T) {
+		writeSD(`[]`)
+		inp.refreshTargets()
+		assert.Equal(t, []string{u}, inp.targetURLs())
+	})
+
+	t.Run("keep-last-on-error", func(t *T.T) {
+		writeSD(`invalid`)
+		inp.sd.lastRefresh = time.Time{}
+		inp.refreshTargets()
+		assert.Equal(t, []string{u}, inp.targetURLs())
+	})
+
+	t.Run("vanished", func(t *T.T) {
+		writeSD(`[]`)
+		inp.sd.lastRefresh = time.Time{}
+		inp.setUpState(u)
+		inp.refreshTargets()
+
+		assert.Empty(t, inp.targetURLs())
+		assert.NotContains(t, inp.urlTags, u)
+		assert.NotContains(t, inp.upStates, u)
+	})
+}
+
+func TestKubernetesTargets(t *T.T) {
+	// rules on prometheus.io/* annotations
+	relabels := []*iprom.RelabelConfig{
+		{SourceLabels: []string{"__meta_kubernetes_pod_annotation_prometheus_io_scrape"}, Regex: "true", Action: "keep"},
+		{SourceLabels: []string{"__meta_kubernetes_pod_annotation_prometheus_io_path"}, Regex: "(.+)", TargetLabel: "__metrics_path__"},
+		{
+			SourceLabels: []string{"__address__", "__meta_kubernetes_pod_annotation_prometheus_io_port"},
+			Regex:        `([^:]+)(?::\d+)?;(\d+)`,
+			Replacement:  "${1}:${2}",
+			TargetLabel:  "__address__",
+		},
+		{Regex: "__meta_kubernetes_pod_label_(.+)", Action: "labelmap"},
+		{SourceLabels: []string{"__meta_kubernetes_namespace"}, TargetLabel: "namespace"},
+		{SourceLabels: []string{"__meta_kubernetes_pod_name"}, TargetLabel: "pod"},
+	}
+
+	sd, err := newServiceDiscovery(&Discovery{}, relabels)
+	require.NoError(t, err)
+
+	newPod := func(name string, annotations map[string]string) *apicorev1.Pod {
+		return &apicorev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   "default",
+				Labels:      map[string]string{"app.kubernetes.io/name": "nginx"},
+				Annotations: annotations,
+			},
+			Spec: apicorev1.PodSpec{
+				NodeName: "node-a",
+				Containers: []apicorev1.Container{{
+					Name: "nginx",
+					Ports: []apicorev1.ContainerPort{
+						{Name: "http", ContainerPort: 80, Protocol: apicorev1.ProtocolTCP},
+						{Name: "metrics", ContainerPort: 9113, Protocol: apicorev1.ProtocolTCP},
+					},
+				}},
+			},
+			Status: apicorev1.PodStatus{PodIP: "10.0.0.1", Phase: apicorev1.PodRunning},
+		}
+	}
+
+	t.Run("pod", func(t *T.T) {
+		lbs := podTargets(newPod("nginx-0", map[string]string{
+			"prometheus.io/scrape": "true",
+			"prometheus.io/port":   "9113",
+			"prometheus.io/path":   "/stub",
+		}))
+		require.Len(t, lbs, 2)
+		assert.Equal(t, "10.0.0.1:80", lbs[0][labelAddress])
+		assert.Equal(t, "metrics", lbs[1]["__meta_kubernetes_pod_container_port_name"])
+		assert.Equal(t, "true", lbs[0]["__meta_kubernetes_pod_labelpresent_app_kubernetes_io_name"])
+
+		for _, x := range lbs {
+			tg := sd.toTarget(x)
+			require.NotNil(t, tg)
+			assert.Equal(t, "http://10.0.0.1:9113/stub", tg.url)
+			assert.Equal(t, map[string]string{
+				"app_kubernetes_io_name": "nginx",
+				"namespace":              "default",
+				"pod":                    "nginx-0",
+			}, tg.tags)
+		}
+
+		for _, x := range podTargets(newPod("nginx-1", nil)) {
+			assert.Nil(t, sd.toTarget(x))
+		}
+
+		pending := newPod("nginx-2", nil)
+		pending.Status.PodIP = ""
+		assert.Empty(t, podTargets(pending))
+	})
+
+	t.Run("endpoints", func(t *T.T) {
+		nodeA, nodeB := "node-a", "node-b"
+		ep := &apicorev1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "default"},
+			Subsets: []apicorev1.EndpointSubset{{
+				Addresses: []apicorev1.EndpointAddress{
+					{IP: "10.0.0.1", NodeName: &nodeA, TargetRef: &apicorev1.ObjectReference{Kind: "Pod", Name: "nginx-0"}},
+					{IP: "10.0.0.2", NodeName: &nodeB},
+				},
+				NotReadyAddresses: []apicorev1.EndpointAddress{{IP: "10.0.0.3", NodeName: &nodeA}},
+				Ports:             []apicorev1.EndpointPort{{Name: "metrics", Port: 9113}},
+			}},
+		}
+		svc := &apicorev1.Service{ObjectMeta: metav1.ObjectMeta{
+			Name:        "nginx",
+			Namespace:   "default",
+			Annotations: map[string]string{"prometheus.io/scrape": "true"},
+		}}
+
+		lbs := endpointsTargets(ep, svc, "")
+		require.Len(t, lbs, 3)
+
+		lbs = endpointsTargets(ep, svc, nodeA)
+		require.Len(t, lbs, 2)
+
+		assert.Equal(t, "10.0.0.1:9113", lbs[0][labelAddress])
+		assert.Equal(t, "true", lbs[0]["__meta_kubernetes_endpoint_ready"])
+		assert.Equal(t, "nginx-0", lbs[0]["__meta_kubernetes_pod_name"])
+		assert.Equal(t, "true", lbs[0]["__meta_kubernetes_service_annotation_prometheus_io_scrape"])
+		assert.Equal(t, "nginx", lbs[0]["__meta_kubernetes_service_name"])
+
+		assert.Equal(t, "10.0.0.3:9113", lbs[1][labelAddress])
+		assert.Equal(t, "false", lbs[1]["__meta_kubernetes_endpoint_ready"])
+	})
+
+	t.Run("invalid-role", func(t *T.T) {
+		_, err := newServiceDiscovery(&Discovery{Kubernetes: []*KubernetesSD{{Role: "node"}}}, nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestTargetURL(t *T.T) {
+	sd, err := newServiceDiscovery(&Discovery{}, nil)
+	require.NoError(t, err)
+
+	tg := sd.toTarget(map[string]string{labelAddress: "10.0.0.1:9100", labelScheme: "https", "__param_target": "a b"})
+	require.NotNil(t, tg)
+
+	u, err := url.Parse(tg.url)
+	require.NoError(t, err)
+	assert.Equal(t, "https", u.Scheme)
+	assert.Equal(t, "/metrics", u.Path)
+	assert.Equal(t, "a b", u.Query().Get("target"))
+	assert.Empty(t, tg.tags)
+
+	assert.Nil(t, sd.toTarget(map[string]string{labelAddress: ""}))
+	assert.Nil(t, sd.toTarget(map[string]string{labelAddress: "http://10.0.0.1:9100"}))
+}
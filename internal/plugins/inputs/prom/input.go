@@ -47,6 +47,8 @@ type Input struct {
 	URL                    string       `toml:"url,omitempty"` // Deprecated
 	URLs                   []string     `toml:"urls"`
 	StreamSize             int          `toml:"stream_size"`
+	ScrapeProtocols        []string     `toml:"scrape_protocols"`
+	KeepExemplars          bool         `toml:"keep_exemplars"`
 	IgnoreReqErr           bool         `toml:"ignore_req_err"`
 	MetricTypes            []string     `toml:"metric_types"`
 	MetricNameFilter       []string     `toml:"metric_name_filter"`
@@ -78,6 +80,9 @@ type Input struct {
 
 	Auth map[string]string `toml:"auth"`
 
+	Discovery      *Discovery             `toml:"discovery"`
+	RelabelConfigs []*iprom.RelabelConfig `toml:"relabel_configs"`
+
 	pm     *iprom.Prom
 	Feeder dkio.Feeder
 
@@ -95,6 +100,9 @@ type Input struct {
 
 	urlTags map[string]urlTags
 
+	sd         *serviceDiscovery
+	discovered []string // URLs of the discovered targets
+
 	// Input holds logger because prom have different types of instances.
 	l *logger.Logger
 
@@ -176,7 +184,8 @@ func (i *Input) collect() error {
 	}
 
 	i.start = time.Now()
-	i.l.Debugf("collect URLs %v", i.URLs)
+	i.refreshTargets()
+	i.l.Debugf("collect URLs %v, discovered %v", i.URLs, i.discovered)
 
 	// If Output is configured, data is written to local file specified by Output.
 	// Data will no more be written to datakit io.
@@ -217,7 +226,7 @@ func (i *Input) collectFormURLs() error {
 		return fmt.Errorf("i.pm is nil")
 	}
 
-	for _, u := range i.URLs {
+	for _, u := range i.targetURLs() {
 		i.setUpState(u)
 		pts, err := i.collectFormSource(u)
 		if err != nil {
@@ -314,7 +323,7 @@ func (i *Input) WriteMetricText2File() error {
 			return err
 		}
 	}
-	for _, u := range i.URLs {
+	for _, u := range i.targetURLs() {
 		if err := i.pm.WriteMetricText2File(u); err != nil {
 			return err
 		}
@@ -355,6 +364,102 @@ func (i *Input) Resume() error {
 	}
 }
 
+func (i *Input) setURLTags(u string, uu *url.URL, tags map[string]string) {
+	var globalTags map[string]string
+	if i.Election {
+		globalTags = i.Tagger.ElectionTags()
+		i.l.Debugf("add global election tags %q", globalTags)
+	} else {
+		globalTags = i.Tagger.HostTags()
+		i.l.Debugf("add global host tags %q", globalTags)
+	}
+
+	temp := inputs.MergeTags(globalTags, tags, u)
+	// Add extra `instance` tag, from url
+	if !i.DisableInstanceTag {
+		if _, ok := temp["instance"]; !ok {
+			temp["instance"] = uu.Host
+		}
+	}
+	tempTags := urlTags{}
+	for k, v := range temp {
+		tempTags = append(tempTags, struct {
+			key, value string
+		}{key: k, value: v})
+	}
+	i.urlTags[u] = tempTags
+}
+
+// targetURLs get the configured URLs and the discovered ones.
+func (i *Input) targetURLs() []string {
+	if len(i.discovered) == 0 {
+		return i.URLs
+	}
+	return append(i.URLs[:len(i.URLs):len(i.URLs)], i.discovered...)
+}
+
+// refreshTargets refresh the discovered targets, the tags of the target are
+// added to its points, which override the configured tags.
+func (i *Input) refreshTargets() {
+	if i.sd == nil {
+		return
+	}
+
+	refreshed, errs := i.sd.refresh(time.Now())
+	for _, err := range errs {
+		i.l.Warnf("refresh targets: %s", err)
+		i.Feeder.FeedLastError(err.Error(),
+			metrics.WithLastErrorInput(inputName),
+			metrics.WithLastErrorSource(inputName+"/"+i.Source),
+		)
+	}
+
+	if !refreshed {
+		return
+	}
+
+	static := map[string]bool{}
+	for _, u := range i.URLs {
+		static[u] = true
+	}
+
+	// clear the vanished targets
+	for _, u := range i.discovered {
+		if !static[u] {
+			delete(i.urlTags, u)
+			delete(i.upStates, u)
+		}
+	}
+
+	discovered := make([]string, 0, len(i.sd.targets))
+	for _, t := range i.sd.targets {
+		if static[t.url] {
+			continue
+		}
+
+		uu, err := url.Parse(t.url)
+		if err != nil {
+			continue
+		}
+
+		tags := make(map[string]string, len(i.Tags)+len(t.tags))
+		for k, v := range i.Tags {
+			tags[k] = v
+		}
+		for k, v := range t.tags {
+			tags[k] = v
+		}
+
+		i.setURLTags(t.url, uu, tags)
+		discovered = append(discovered, t.url)
+	}
+
+	if len(discovered) != len(i.discovered) {
+		i.l.Infof("discovered %d targets", len(discovered))
+	}
+	i.discovered = discovered
+}
+
 type promHandleCallback func([]*point.Point) error
 
 func (i *Input) defaultHandleCallback() promHandleCallback {
@@ -418,30 +523,15 @@ func (i *Input) Init() error {
 			return err
 		}
 		i.urls = append(i.urls, uu)
+		i.setURLTags(u, uu, i.Tags)
+	}
 
-		var globalTags map[string]string
-		if i.Election {
-			globalTags = i.Tagger.ElectionTags()
-			i.l.Infof("add global election tags %q", globalTags)
-		} else {
-			globalTags = i.Tagger.HostTags()
-			i.l.Infof("add global host tags %q", globalTags)
-		}
-
-		temp := inputs.MergeTags(globalTags, i.Tags, u)
-		// Add extra `instance` tag, from url
-		if !i.DisableInstanceTag {
-			if _, ok := temp["instance"]; !ok {
-				temp["instance"] = uu.Host
-			}
-		}
-		tempTags := urlTags{}
-		for k, v := range temp {
-			tempTags = append(tempTags, struct {
-				key, value string
-			}{key: k, value: v})
+	if i.Discovery != nil {
+		sd, err := newServiceDiscovery(i.Discovery, i.RelabelConfigs)
+		if err != nil {
+			return err
 		}
-		i.urlTags[u] = tempTags
+		i.sd = sd
 	}
 
 	if i.StreamSize > 0 && i.callbackFunc == nil { // set callback on streamming-mode
@@ -477,6 +567,8 @@ func (i *Input) Init() error {
 		iprom.WithDisableInfoTag(i.DisableInfoTag),
 		iprom.WithMaxBatchCallback(i.StreamSize, i.callbackFunc),
 		iprom.WithAuth(i.Auth),
+		iprom.WithScrapeProtocols(i.ScrapeProtocols),
+		iprom.WithKeepExemplars(i.KeepExemplars),
 	}
 
 	pm, err := iprom.NewProm(opts...)
@@ -501,7 +593,9 @@ func NewProm() *Input {
 		Timeout:     time.Second * 30,
 		StreamSize:  1,
 		Election:    true,
-		Tags:        make(map[string]string),
+
+		ScrapeProtocols: iprom.DefaultScrapeProtocols,
+		Tags:            make(map[string]string),
 
 		urlTags: map[string]urlTags{},
 
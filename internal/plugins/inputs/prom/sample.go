@@ -12,9 +12,20 @@ const sampleCfg = `
 
   ## Stream Size. 
   ## The source stream segmentation size, (defaults to 1).
-  ## 0 source stream undivided. 
+  ## The payload is parsed line by line and fed every stream_size metric families,
+  ## huge families are split on every 1000 samples, so the memory is bounded.
+  ## 0 source stream undivided(the whole payload is parsed before feeding).
   # stream_size = 1
 
+  ## Scrape protocols sent within the Accept header, the former preferred.
+  ## Optional: PrometheusProto, OpenMetricsText1.0.0, OpenMetricsText0.0.1, PrometheusText0.0.4
+  ## Put PrometheusProto first to collect the native histograms.
+  # scrape_protocols = ["OpenMetricsText1.0.0", "OpenMetricsText0.0.1", "PrometheusText0.0.4"]
+
+  ## Collect the exemplars(such as the trace_id of the sample) of OpenMetrics/protobuf as
+  ## extra points with field suffix _exemplar, the exemplar labels are added as tags.
+  # keep_exemplars = false
+
   ## Unix Domain Socket URL. Using socket to request data when not empty.
   uds_path = ""
 
@@ -136,6 +147,48 @@ const sampleCfg = `
     enable = false
     service = "service_name"
 
+  ## Discover the targets from file_sd JSON files or Kubernetes, the discovered
+  ## targets are scraped along with the urls.
+  # [inputs.prom.discovery]
+    ## (Optional) Refresh interval of the targets: (defaults to "1m").
+    # refresh_interval = "1m"
+
+    ## file_sd JSON files, glob supported, such as:
+    ##   [{"targets": ["10.0.0.1:9100"], "labels": {"env": "prod"}}]
+    # files = ["/usr/local/datakit/conf.d/prom/targets/*.json"]
+
+    ## Kubernetes discovery, role is pod or endpoints.
+    # [[inputs.prom.discovery.kubernetes]]
+      # role           = "pod"
+      # namespaces     = []
+      # label_selector = ""
+      # field_selector = ""
+      ## Only the targets on the node of the Datakit(requires ENV_K8S_NODE_NAME).
+      # node_local = false
+
+  ## Relabel the discovered targets, same as the relabel_configs of Prometheus.
+  ## Labels prefixed with __ are removed after relabeling, the others are added as tags.
+  ## Example: scrape the pods annotated with prometheus.io/scrape = "true".
+  # [[inputs.prom.relabel_configs]]
+    # source_labels = ["__meta_kubernetes_pod_annotation_prometheus_io_scrape"]
+    # regex         = "true"
+    # action        = "keep"
+
+  # [[inputs.prom.relabel_configs]]
+    # source_labels = ["__meta_kubernetes_pod_annotation_prometheus_io_path"]
+    # regex         = "(.+)"
+    # target_label  = "__metrics_path__"
+
+  # [[inputs.prom.relabel_configs]]
+    # source_labels = ["__address__", "__meta_kubernetes_pod_annotation_prometheus_io_port"]
+    # regex         = '([^:]+)(?::\d+)?;(\d+)'
+    # replacement   = "${1}:${2}"
+    # target_label  = "__address__"
+
+  # [[inputs.prom.relabel_configs]]
+    # source_labels = ["__meta_kubernetes_pod_name"]
+    # target_label  = "pod"
+
   ## Customize tags.
   # [inputs.prom.tags]
     # some_tag = "some_value"
@@ -0,0 +1,138 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package redis
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/datakit"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/goroutine"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs"
+)
+
+// splitHostPort split host like `r1:6379` into host and port, port
+// of the input used if not specified.
+func splitHostPort(hostport string, defaultPort int) (string, int, error) {
+	host, portStr, err := net.SplitHostPort(hostport)
+	if err != nil {
+		// no port in address
+		if _, _, err2 := net.SplitHostPort(hostport + ":0"); err2 == nil {
+			return hostport, defaultPort, nil
+		}
+		return "", 0, fmt.Errorf("invalid host %q: %w", hostport, err)
+	}
+
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid port of host %q: %w", hostport, err)
+	}
+
+	return host, port, nil
+}
+
+// hostInputs create an input for each host of `hosts`, all of them share the
+// configure of ipt, and got their own collecting state.
+func (ipt *Input) hostInputs() ([]*Input, error) {
+	var res []*Input
+
+	for _, hp := range ipt.Hosts {
+		host, port, err := splitHostPort(hp, ipt.Port)
+		if err != nil {
+			return nil, err
+		}
+
+		child := *ipt
+		child.Host = host
+		child.Port = port
+		child.Hosts = nil
+		child.HostTags = nil
+
+		child.Tags = make(map[string]string, len(ipt.Tags))
+		for k, v := range ipt.Tags {
+			child.Tags[k] = v
+		}
+		for k, v := range ipt.HostTags[hp] {
+			child.Tags[k] = v
+		}
+
+		if ipt.TLSClientConfig != nil {
+			tlsConf := *ipt.TLSClientConfig
+			child.TLSClientConfig = &tlsConf
+		}
+
+		if ipt.BigKeyScan != nil {
+			bks := *ipt.BigKeyScan
+			child.BigKeyScan = &bks
+		}
+
+		child.DBS = append([]int(nil), ipt.DBS...)
+		child.keyDBS = nil
+		child.Log = nil // logging collected by the input itself
+
+		child.pauseCh = make(chan bool, inputs.ElectionPauseChannelLength)
+		child.latencyLastTime = map[string]time.Time{}
+		child.latencyHistoryLastTime = map[string]time.Time{}
+		child.persistenceStates = map[string]*persistenceState{}
+		child.commandStatLast = nil
+		child.lastReplOffset = nil
+		child.cpuUsage = redisCPUUsage{}
+		child.clusterNodes = nil
+		child.client = nil
+		child.isInitialized = false
+		child.collectors = nil
+		child.hashMap = nil
+		child.LastCustomerObject = nil
+
+		res = append(res, &child)
+	}
+
+	return res, nil
+}
+
+// runHosts run collectors of all hosts in parallel, and dispatch
+// pause/resume to them.
+func (ipt *Input) runHosts() {
+	children, err := ipt.hostInputs()
+	if err != nil {
+		l.Errorf("hostInputs: %s", err)
+		return
+	}
+
+	g := goroutine.NewGroup(goroutine.Option{Name: "inputs_redis_hosts"})
+	for _, child := range children {
+		child := child
+		l.Infof("start collecting redis %s:%d", child.Host, child.Port)
+		g.Go(func(ctx context.Context) error {
+			child.Run()
+			return nil
+		})
+	}
+
+	for {
+		select {
+		case <-datakit.Exit.Wait():
+			l.Info("redis hosts exit")
+			return
+
+		case <-ipt.semStop.Wait():
+			l.Info("redis hosts return")
+			return
+
+		case pause := <-ipt.pauseCh:
+			for _, child := range children {
+				select {
+				case child.pauseCh <- pause:
+				default:
+					l.Warnf("pause channel of %s:%d full, ignored", child.Host, child.Port)
+				}
+			}
+		}
+	}
+}
@@ -8,6 +8,7 @@ package redis
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"os"
 	"strconv"
@@ -63,12 +64,15 @@ type Input struct {
 	Service        string `toml:"service"`
 	Addr           string `toml:"-"`
 	Port           int    `toml:"port"`
+
+	Hosts    []string                     `toml:"hosts"`     // collect from multiple hosts with the same config
+	HostTags map[string]map[string]string `toml:"host_tags"` // extra tags of each host in Hosts
 	*dknet.TLSClientConfig
-	TLSOpen            bool   `toml:"tls_open"`             // Deprecated
-	CacertFile         string `toml:"tls_ca"`               // Deprecated (use TLSConf.CaCerts)
-	CertFile           string `toml:"tls_cert"`             // Deprecated (use TLSConf.Cert)
-	KeyFile            string `toml:"tls_key"`              // Deprecated (use TLSConf.CertKey)
-	InsecureSkipVerify bool   `toml:"insecure_skip_verify"` // Deprecated (use TLSConf.InsecureSkipVerify)
+	TLSOpen            bool   `toml:"tls_open"`             // Deprecated, TLS enabled if any of tls_xxx set
+	CacertFile         string `toml:"tls_ca"`               // same as TLSConf.CaCerts
+	CertFile           string `toml:"tls_cert"`             // same as TLSConf.Cert
+	KeyFile            string `toml:"tls_key"`              // same as TLSConf.CertKey
+	InsecureSkipVerify bool   `toml:"insecure_skip_verify"` // same as TLSConf.InsecureSkipVerify
 
 	DB                 int           `toml:"db"`
 	SocketTimeout      int           `toml:"socket_timeout"`
@@ -76,7 +80,9 @@ type Input struct {
 	Interval           time.Duration `toml:"interval"`
 	WarnOnMissingKeys  bool          `toml:"warn_on_missing_keys"`
 	CommandStats       bool          `toml:"command_stats"`
+	CommandStatsDelta  bool          `toml:"command_stats_delta"`
 	LatencyPercentiles bool          `toml:"latency_percentiles"`
+	MemoryDoctor       bool          `toml:"memory_doctor"`
 	Slowlog            bool          `toml:"slow_log"`
 	AllSlowLog         bool          `toml:"all_slow_log"`
 	RedisCliPath       string        `toml:"redis_cli_path"`
@@ -85,6 +91,10 @@ type Input struct {
 	KeyInterval        time.Duration `toml:"key_interval"`
 	KeyTimeout         time.Duration `toml:"key_timeout"`
 	KeyScanSleep       string        `toml:"key_scan_sleep"`
+	BigKeyScan         *bigKeyScan   `toml:"bigkey_scan"`
+	ClusterMode        bool          `toml:"cluster_mode"`
+
+	RDBSaveAgeThreshold time.Duration `toml:"rdb_save_age_threshold"`
 
 	Version            string
 	Uptime             int
@@ -110,16 +120,31 @@ type Input struct {
 	collectors []func() ([]*point.Point, error)
 
 	client        *redis.Client
+	tlsConfig     *tls.Config
 	isInitialized bool
 
+	clusterNodes map[string]*clusterNode // cluster nodes keyed by address
+
 	Election        bool `toml:"election"`
 	pause           bool
 	pauseCh         chan bool
 	hashMap         [][16]byte
 	latencyLastTime map[string]time.Time
+	// last sample time of LATENCY HISTORY for each event
+	latencyHistoryLastTime map[string]time.Time
+	latencyHistorySince    time.Time // time of the first run
+	// cached result of latency-monitor-threshold
+	latencyMonitorOn        bool
+	latencyMonitorCheckedAt time.Time
 	// a pointer, set value in m.lastCollect.*=...
 	cpuUsage redisCPUUsage
 
+	lastReplOffset *replOffset
+
+	commandStatLast map[string]*commandStatSample // last command stats keyed by server/method
+
+	persistenceStates map[string]*persistenceState // persistence health keyed by server
+
 	semStop *cliutils.Sem // start stop signal
 
 	startUpUnix int64
@@ -149,31 +174,20 @@ func (ipt *Input) initCfg() error {
 
 	ipt.Addr = fmt.Sprintf("%s:%d", ipt.Host, ipt.Port)
 
-	ipt.TLSClientConfig = dknet.MergeTLSConfig(
-		ipt.TLSClientConfig,
-		[]string{ipt.CacertFile},
-		ipt.CertFile,
-		ipt.KeyFile,
-		ipt.TLSOpen,
-		ipt.InsecureSkipVerify,
-	)
-
 	if ipt.RedisCliPath == "" {
 		ipt.RedisCliPath = "redis-cli"
 	}
 
-	tlsCfg, err := ipt.TLSClientConfig.TLSConfigWithBase64()
-	if err != nil {
+	if err := ipt.initTLS(); err != nil {
 		return err
 	}
 
-	client := redis.NewClient(&redis.Options{
-		Addr:      ipt.Addr,
-		TLSConfig: tlsCfg,
-		Username:  ipt.Username,
-		Password:  ipt.Password, // no password set
-		DB:        ipt.DB,       // use default DB
-	})
+	if ipt.ClusterMode && ipt.DB != 0 {
+		l.Warnf("db %d ignored on cluster_mode, redis cluster only supports db 0", ipt.DB)
+		ipt.DB = 0
+	}
+
+	client := ipt.newClient(ipt.Addr, ipt.DB)
 
 	if ipt.SlowlogMaxLen == 0 {
 		ipt.SlowlogMaxLen = 128
@@ -196,6 +210,71 @@ func (ipt *Input) initCfg() error {
 	return nil
 }
 
+// initTLS merge tls_xxx options into TLSClientConfig, and build the TLS
+// config used by redis client. Managed Redis(such as Azure Cache, AWS
+// ElastiCache with in-transit encryption) only need TLS enabled.
+func (ipt *Input) initTLS() error {
+	if (ipt.CertFile == "") != (ipt.KeyFile == "") {
+		return fmt.Errorf("tls_cert and tls_key should be set together")
+	}
+
+	tlsOpen := ipt.TLSOpen ||
+		ipt.CacertFile != "" ||
+		ipt.CertFile != "" ||
+		ipt.InsecureSkipVerify
+
+	ipt.TLSClientConfig = dknet.MergeTLSConfig(
+		ipt.TLSClientConfig,
+		[]string{ipt.CacertFile},
+		ipt.CertFile,
+		ipt.KeyFile,
+		tlsOpen,
+		ipt.InsecureSkipVerify,
+	)
+
+	if ipt.TLSClientConfig == nil {
+		ipt.tlsConfig = nil
+		return nil
+	}
+
+	var err error
+	ipt.tlsConfig, err = ipt.TLSClientConfig.TLSConfigWithBase64()
+	return err
+}
+
+// redisCliTLSArgs returns TLS arguments of redis-cli used by hotkey and bigkey.
+func (ipt *Input) redisCliTLSArgs() []string {
+	if ipt.TLSClientConfig == nil {
+		return nil
+	}
+
+	args := []string{"--tls"}
+	if ipt.TLSClientConfig.Cert != "" && ipt.TLSClientConfig.CertKey != "" {
+		args = append(args, "--cert", ipt.TLSClientConfig.Cert, "--key", ipt.TLSClientConfig.CertKey)
+	}
+	if len(ipt.TLSClientConfig.CaCerts) > 0 {
+		args = append(args, "--cacert", ipt.TLSClientConfig.CaCerts[0])
+	}
+	if ipt.TLSClientConfig.InsecureSkipVerify {
+		args = append(args, "--insecure")
+	}
+	if ipt.TLSClientConfig.ServerName != "" {
+		args = append(args, "--sni", ipt.TLSClientConfig.ServerName)
+	}
+
+	return args
+}
+
+func (ipt *Input) newClient(addr string, db int) *redis.Client {
+	return redis.NewClient(&redis.Options{
+		Addr:      addr,
+		TLSConfig: ipt.tlsConfig,
+		Username:  ipt.Username,
+		Password:  ipt.Password, // no password set
+		DB:        db,
+	})
+}
+
 func (ipt *Input) tryInit() {
 	if ipt.isInitialized {
 		return
@@ -216,6 +295,7 @@ func (ipt *Input) tryInit() {
 		ipt.collectCommandMeasurement,
 		ipt.collectDBMeasurement,
 		ipt.collectReplicaMeasurement,
+		ipt.collectMemoryMeasurement,
 		ipt.collectCustomerObjectMeasurement,
 	}
 	// check if cluster
@@ -260,6 +340,15 @@ func (ipt *Input) Collect() error {
 		return fmt.Errorf("un initialized")
 	}
 
+	if ipt.ClusterMode {
+		if err := ipt.refreshClusterNodes(); err != nil {
+			l.Errorf("refreshClusterNodes: %s", err)
+			ipt.feeder.FeedLastError(err.Error(),
+				metrics.WithLastErrorInput(inputName),
+			)
+		}
+	}
+
 	for idx, f := range ipt.collectors {
 		pts, err := f()
 		if err != nil {
@@ -295,6 +384,23 @@ func (ipt *Input) Collect() error {
 			}
 		}
 	}
+	if pts, err := ipt.collectPersistenceEvents(); err != nil {
+		l.Errorf("collectPersistenceEvents: %s", err)
+		ipt.feeder.FeedLastError(err.Error(),
+			metrics.WithLastErrorInput(inputName),
+		)
+	} else if len(pts) > 0 {
+		if err := ipt.feeder.FeedV2(point.KeyEvent, pts,
+			dkio.WithElection(ipt.Election),
+			dkio.WithInputName(redisPersistence)); err != nil {
+			ipt.feeder.FeedLastError(err.Error(),
+				metrics.WithLastErrorInput(inputName),
+				metrics.WithLastErrorCategory(point.KeyEvent),
+			)
+			l.Errorf("feed persistence events: %s", err)
+		}
+	}
+
 	if ipt.Slowlog {
 		if err := ipt.getSlowData(); err != nil {
 			return err
@@ -317,6 +423,19 @@ func (ipt *Input) Collect() error {
 }
 
 func (ipt *Input) collectInfoMeasurement() ([]*point.Point, error) {
+	if ipt.ClusterMode {
+		return ipt.collectFromClusterNodes(func(n *clusterNode) ([]*point.Point, error) {
+			m := &infoMeasurement{
+				cli:                n.cli,
+				name:               redisInfoM,
+				tags:               ipt.copyMergedTags(),
+				lastCollect:        &n.cpuUsage,
+				latencyPercentiles: ipt.LatencyPercentiles,
+			}
+			return m.getData(ipt.alignTS)
+		})
+	}
+
 	m := &infoMeasurement{
 		cli:                ipt.client,
 		tags:               make(map[string]string),
@@ -326,14 +445,19 @@ func (ipt *Input) collectInfoMeasurement() ([]*point.Point, error) {
 
 	m.name = redisInfoM
 
-	m.tags = map[string]string{}
-	for k, v := range ipt.mergedTags {
-		m.tags[k] = v
-	}
+	m.tags = ipt.copyMergedTags()
 
 	return m.getData(ipt.alignTS)
 }
 
+func (ipt *Input) copyMergedTags() map[string]string {
+	tags := make(map[string]string, len(ipt.mergedTags))
+	for k, v := range ipt.mergedTags {
+		tags[k] = v
+	}
+	return tags
+}
+
 func (ipt *Input) collectClientMeasurement() ([]*point.Point, error) {
 	ctx := context.Background()
 	list, err := ipt.client.ClientList(ctx).Result()
@@ -342,18 +466,37 @@ func (ipt *Input) collectClientMeasurement() ([]*point.Point, error) {
 		return nil, err
 	}
 
-	return ipt.parseClientData(list)
+	pts, err := ipt.parseClientData(list)
+	if err != nil {
+		return nil, err
+	}
+
+	pts = append(pts, ipt.parseClientsSummary(list)...)
+	pts = append(pts, ipt.collectACLSummary()...)
+
+	return pts, nil
 }
 
 func (ipt *Input) collectCommandMeasurement() ([]*point.Point, error) {
 	ctx := context.Background()
+
+	if ipt.ClusterMode {
+		return ipt.collectFromClusterNodes(func(n *clusterNode) ([]*point.Point, error) {
+			list, err := n.cli.Info(ctx, "commandstats").Result()
+			if err != nil {
+				return nil, err
+			}
+			return ipt.parseCommandData(n.addr, list)
+		})
+	}
+
 	list, err := ipt.client.Info(ctx, "commandstats").Result()
 	if err != nil {
 		l.Error("command stats error,", err)
 		return nil, err
 	}
 
-	return ipt.parseCommandData(list)
+	return ipt.parseCommandData(ipt.Addr, list)
 }
 
 func (ipt *Input) collectCustomerObjectMeasurement() ([]*point.Point, error) {
@@ -425,6 +568,11 @@ func (ipt *Input) RunPipeline() {
 }
 
 func (ipt *Input) Run() {
+	if len(ipt.Hosts) > 0 {
+		ipt.runHosts()
+		return
+	}
+
 	ipt.setup()
 
 	if ipt.TLSClientConfig != nil && (ipt.TLSClientConfig.CertBase64 != "" || ipt.TLSClientConfig.CertKeyBase64 != "") {
@@ -453,7 +601,7 @@ func (ipt *Input) Run() {
 	if ipt.Hotkey {
 		ipt.goroutineHotkey(ctxKey)
 	}
-	if ipt.BigKey {
+	if ipt.BigKey || ipt.bigKeyScanEnabled() {
 		ipt.goroutineBigKey(ctxKey)
 	}
 
@@ -516,7 +664,11 @@ func (ipt *Input) setup() {
 	if ipt.DB != -1 && !IsSlicesHave(ipt.DBS, ipt.DB) {
 		ipt.DBS = append(ipt.DBS, ipt.DB)
 	}
-	if len(ipt.DBS) < 1 && (ipt.Hotkey || ipt.BigKey) {
+	if ipt.bigKeyScanEnabled() {
+		ipt.BigKeyScan.setDefault()
+	}
+
+	if len(ipt.DBS) < 1 && (ipt.Hotkey || ipt.BigKey || ipt.bigKeyScanEnabled()) {
 		l.Errorf("dbs is nil in redis.conf, example: dbs=[0]")
 	}
 
@@ -528,6 +680,8 @@ func (ipt *Input) exit() {
 		ipt.tail.Close()
 		l.Info("redis log exit")
 	}
+
+	ipt.closeClusterNodes()
 }
 
 func (ipt *Input) Terminate() {
@@ -547,12 +701,16 @@ func (*Input) SampleMeasurement() []inputs.Measurement {
 		&bigKeyMeasurement{},
 		&hotkeyMeasurement{},
 		&clientMeasurement{},
+		&clientsMeasurement{},
 		&clusterMeasurement{},
 		&commandMeasurement{},
 		&dbMeasurement{},
 		&infoMeasurement{},
 		&replicaMeasurement{},
 		&latencyMeasurement{},
+		&memoryMeasurement{},
+		&memoryDoctorMeasurement{},
+		&persistenceMeasurement{},
 		&slowlogMeasurement{},
 	}
 }
@@ -586,18 +744,20 @@ func defaultInput() *Input {
 	getClusterFieldMap()
 
 	return &Input{
-		Timeout:         "10s",
-		pauseCh:         make(chan bool, inputs.ElectionPauseChannelLength),
-		DB:              -1,
-		Tags:            make(map[string]string),
-		KeyInterval:     defaultKeyInterval,
-		KeyTimeout:      defaultKeyInterval,
-		KeyScanSleep:    defaultKeyScanSleep,
-		latencyLastTime: map[string]time.Time{},
-		semStop:         cliutils.NewSem(),
-		Election:        true,
-		feeder:          dkio.DefaultFeeder(),
-		tagger:          datakit.DefaultGlobalTagger(),
+		Timeout:                "10s",
+		pauseCh:                make(chan bool, inputs.ElectionPauseChannelLength),
+		DB:                     -1,
+		Tags:                   make(map[string]string),
+		KeyInterval:            defaultKeyInterval,
+		KeyTimeout:             defaultKeyInterval,
+		KeyScanSleep:           defaultKeyScanSleep,
+		latencyLastTime:        map[string]time.Time{},
+		latencyHistoryLastTime: map[string]time.Time{},
+		persistenceStates:      map[string]*persistenceState{},
+		semStop:                cliutils.NewSem(),
+		Election:               true,
+		feeder:                 dkio.DefaultFeeder(),
+		tagger:                 datakit.DefaultGlobalTagger(),
 	}
 }
 
@@ -5,6 +5,16 @@
 
 package redis
 
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	dknet "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/net"
+)
+
 var redisInfo = `
 # Server
 redis_version:5.0.4
@@ -462,3 +472,73 @@ func TestCollect(t *testing.T) {
 		}
 	})
 }*/
+
+func TestInitTLS(t *testing.T) {
+	t.Run("no-tls", func(t *testing.T) {
+		ipt := defaultInput()
+		require.NoError(t, ipt.initTLS())
+		assert.Nil(t, ipt.tlsConfig)
+		assert.Nil(t, ipt.redisCliTLSArgs())
+	})
+
+	t.Run("insecure-without-tls-open", func(t *testing.T) {
+		ipt := defaultInput()
+		ipt.InsecureSkipVerify = true
+		require.NoError(t, ipt.initTLS())
+		require.NotNil(t, ipt.tlsConfig)
+		assert.True(t, ipt.tlsConfig.InsecureSkipVerify)
+		assert.Equal(t, []string{"--tls", "--insecure"}, ipt.redisCliTLSArgs())
+	})
+
+	t.Run("cert-without-key", func(t *testing.T) {
+		ipt := defaultInput()
+		ipt.CertFile = "/opt/tls/redis.crt"
+		assert.Error(t, ipt.initTLS())
+	})
+
+	t.Run("tls-conf", func(t *testing.T) {
+		ipt := defaultInput()
+		ipt.TLSClientConfig = &dknet.TLSClientConfig{InsecureSkipVerify: true, ServerName: "redis.example.com"}
+		require.NoError(t, ipt.initTLS())
+		require.NotNil(t, ipt.tlsConfig)
+		assert.Equal(t, "redis.example.com", ipt.tlsConfig.ServerName)
+		assert.Equal(t, []string{"--tls", "--insecure", "--sni", "redis.example.com"}, ipt.redisCliTLSArgs())
+	})
+}
+
+func TestHostInputs(t *testing.T) {
+	ipt := defaultInput()
+	ipt.Port = 6379
+	ipt.Tags = map[string]string{"foo": "bar"}
+	ipt.Hosts = []string{"r1:6380", "r2", "10.0.0.1:6379"}
+	ipt.HostTags = map[string]map[string]string{
+		"r1:6380": {"zone": "a"},
+		"r2":      {"zone": "b", "foo": "override"},
+	}
+
+	children, err := ipt.hostInputs()
+	require.NoError(t, err)
+	require.Len(t, children, 3)
+
+	assert.Equal(t, "r1", children[0].Host)
+	assert.Equal(t, 6380, children[0].Port)
+	assert.Equal(t, map[string]string{"foo": "bar", "zone": "a"}, children[0].Tags)
+
+	assert.Equal(t, "r2", children[1].Host)
+	assert.Equal(t, 6379, children[1].Port)
+	assert.Equal(t, map[string]string{"foo": "override", "zone": "b"}, children[1].Tags)
+
+	assert.Equal(t, "10.0.0.1", children[2].Host)
+	assert.Equal(t, map[string]string{"foo": "bar"}, children[2].Tags)
+
+	// children got their own state
+	children[0].latencyLastTime["fork"] = time.Now()
+	assert.Empty(t, children[1].latencyLastTime)
+	assert.Empty(t, ipt.latencyLastTime)
+	assert.NotEqual(t, children[0].pauseCh, children[1].pauseCh)
+	assert.Empty(t, children[0].Hosts)
+
+	ipt.Hosts = []string{"r1:port"}
+	_, err = ipt.hostInputs()
+	assert.Error(t, err)
+}
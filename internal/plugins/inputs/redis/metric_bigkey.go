@@ -23,16 +23,20 @@ import (
 )
 
 const (
-	redisHotkey      = "redis_hotkey"
-	redisBigkey      = "redis_bigkey"
-	redisClient      = "redis_client"
-	redisCluster     = "redis_cluster"
-	redisCommandStat = "redis_command_stat"
-	redisDB          = "redis_db"
-	redisLatency     = "redis_latency"
-	redisInfoM       = "redis_info"
-	redisReplica     = "redis_replica"
-	redisSlowlog     = "redis_slowlog"
+	redisHotkey       = "redis_hotkey"
+	redisBigkey       = "redis_bigkey"
+	redisClient       = "redis_client"
+	redisClients      = "redis_clients"
+	redisPersistence  = "redis_persistence"
+	redisCluster      = "redis_cluster"
+	redisCommandStat  = "redis_command_stat"
+	redisDB           = "redis_db"
+	redisLatency      = "redis_latency"
+	redisMemoryStat   = "redis_memory_stat"
+	redisMemoryDoctor = "redis_memory_doctor"
+	redisInfoM        = "redis_info"
+	redisReplica      = "redis_replica"
+	redisSlowlog      = "redis_slowlog"
 )
 
 type bigKeyMeasurement struct{}
@@ -45,14 +49,16 @@ func (m *bigKeyMeasurement) Info() *inputs.MeasurementInfo {
 		Fields: map[string]interface{}{
 			"value_length": &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.UnknownUnit, Desc: "Key length."},
 			"keys_sampled": &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.UnknownUnit, Desc: "Sampled keys in the key space."},
+			"memory_usage": &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.SizeByte, Desc: "Memory usage of the key, only collected by `bigkey_scan`."},
 		},
 		Tags: map[string]interface{}{
 			"host":         &inputs.TagInfo{Desc: "Hostname."},
 			"server":       &inputs.TagInfo{Desc: "Server addr."},
 			"service_name": &inputs.TagInfo{Desc: "Service name."},
-			"db_name":      &inputs.TagInfo{Desc: "DB name."},
+			"db_name":      &inputs.TagInfo{Desc: "DB name, such as `0`, or `db0` for the points of `bigkey_scan`."},
 			"key":          &inputs.TagInfo{Desc: "Key name."},
 			"key_type":     &inputs.TagInfo{Desc: "Key type."},
+			"key_prefix":   &inputs.TagInfo{Desc: "Key prefix, only collected by `bigkey_scan`."},
 		},
 	}
 }
@@ -186,14 +192,25 @@ func (ipt *Input) goroutineBigKey(ctxKey context.Context) {
 
 func (ipt *Input) scanBigKey(ctxKey context.Context) error {
 	for _, db := range ipt.keyDBS {
-		data, err := ipt.getBigData(ctxKey, db)
-		if err != nil {
-			return err
+		var pts []*point.Point
+
+		if ipt.BigKey {
+			data, err := ipt.getBigData(ctxKey, db)
+			if err != nil {
+				return err
+			}
+
+			if pts, err = ipt.parseBigData(data, db); err != nil {
+				return err
+			}
 		}
 
-		pts, err := ipt.parseBigData(data, db)
-		if err != nil {
-			return err
+		if ipt.bigKeyScanEnabled() {
+			scanPts, err := ipt.sampleBigKeys(ctxKey, db)
+			if err != nil {
+				return err
+			}
+			pts = append(pts, scanPts...)
 		}
 
 		if len(pts) > 0 {
@@ -225,14 +242,7 @@ func (ipt *Input) getBigData(ctxKey context.Context, db int) (string, error) {
 		args = append(args, "-a", ipt.Password)
 	}
 
-	if ipt.TLSClientConfig != nil {
-		args = append(args, "--tls")
-		args = append(args, "--cert", ipt.TLSClientConfig.Cert)
-		args = append(args, "--key", ipt.TLSClientConfig.CertKey)
-		if len(ipt.TLSClientConfig.CaCerts) > 0 {
-			args = append(args, "--cacert", ipt.TLSClientConfig.CaCerts[0])
-		}
-	}
+	args = append(args, ipt.redisCliTLSArgs()...)
 
 	//nolint:gosec
 	c := exec.CommandContext(ctx, args[0], args[1:]...)
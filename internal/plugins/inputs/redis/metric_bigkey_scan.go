@@ -0,0 +1,195 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package redis
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	defaultBigKeySampleKeys    = 10000
+	defaultBigKeyTopN          = 10
+	defaultBigKeyPrefixSep     = ":"
+	defaultBigKeyKeysPerSecond = 1000
+	bigKeyScanBatch            = 100
+	noKeyPrefix                = "no_prefix"
+)
+
+// bigKeyScan config sampled big key scan without redis-cli, it
+// use SCAN + TYPE + MEMORY USAGE to find top-N biggest keys per key prefix.
+type bigKeyScan struct {
+	Enable          bool   `toml:"enable"`
+	SampleKeys      int    `toml:"sample_keys"`
+	TopN            int    `toml:"top_n"`
+	PrefixSeparator string `toml:"prefix_separator"`
+	KeysPerSecond   int    `toml:"keys_per_second"`
+}
+
+func (s *bigKeyScan) setDefault() {
+	if s.SampleKeys <= 0 {
+		s.SampleKeys = defaultBigKeySampleKeys
+	}
+	if s.TopN <= 0 {
+		s.TopN = defaultBigKeyTopN
+	}
+	if s.PrefixSeparator == "" {
+		s.PrefixSeparator = defaultBigKeyPrefixSep
+	}
+	if s.KeysPerSecond <= 0 {
+		s.KeysPerSecond = defaultBigKeyKeysPerSecond
+	}
+}
+
+func (ipt *Input) bigKeyScanEnabled() bool {
+	return ipt.BigKeyScan != nil && ipt.BigKeyScan.Enable
+}
+
+type sampledKey struct {
+	key, keyType, prefix string
+	memory               int64
+}
+
+func keyPrefix(key, sep string) string {
+	if idx := strings.Index(key, sep); idx > 0 {
+		return key[:idx]
+	}
+	return noKeyPrefix
+}
+
+// topNBigKeys group keys by prefix, and keep the biggest n keys of each prefix.
+func topNBigKeys(keys []*sampledKey, n int) map[string][]*sampledKey {
+	res := map[string][]*sampledKey{}
+	for _, k := range keys {
+		res[k.prefix] = append(res[k.prefix], k)
+	}
+
+	for prefix, arr := range res {
+		sort.Slice(arr, func(i, j int) bool {
+			return arr[i].memory > arr[j].memory
+		})
+		if len(arr) > n {
+			arr = arr[:n]
+		}
+		res[prefix] = arr
+	}
+
+	return res
+}
+
+// sampleBigKeys scan keys of db with rate limit, and build top-N big key points per key prefix.
+func (ipt *Input) sampleBigKeys(ctxKey context.Context, db int) ([]*point.Point, error) {
+	cfg := ipt.BigKeyScan
+
+	ctx, cancel := context.WithTimeout(ctxKey, ipt.KeyTimeout)
+	defer cancel()
+
+	cli := ipt.newClient(ipt.Addr, db)
+	defer cli.Close() //nolint:errcheck
+
+	var (
+		cursor  uint64
+		sampled []*sampledKey
+	)
+
+	for {
+		keys, next, err := cli.Scan(ctx, cursor, "", bigKeyScanBatch).Result()
+		if err != nil {
+			return nil, fmt.Errorf("scan db %d: %w", db, err)
+		}
+
+		arr, err := sampleKeys(ctx, cli, keys, cfg.PrefixSeparator)
+		if err != nil {
+			return nil, err
+		}
+		sampled = append(sampled, arr...)
+
+		cursor = next
+		if cursor == 0 || len(sampled) >= cfg.SampleKeys {
+			break
+		}
+
+		// rate limit on SCAN/TYPE/MEMORY USAGE
+		select {
+		case <-ctx.Done():
+			l.Warnf("sample big keys of db %d timeout, %d keys sampled", db, len(sampled))
+			return ipt.buildBigKeyScanPoints(db, sampled), nil
+		case <-time.After(time.Duration(len(keys)) * time.Second / time.Duration(cfg.KeysPerSecond)):
+		}
+	}
+
+	return ipt.buildBigKeyScanPoints(db, sampled), nil
+}
+
+func sampleKeys(ctx context.Context, cli *redis.Client, keys []string, sep string) ([]*sampledKey, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	pipe := cli.Pipeline()
+	typeCmds := make([]*redis.StatusCmd, 0, len(keys))
+	memCmds := make([]*redis.IntCmd, 0, len(keys))
+	for _, k := range keys {
+		typeCmds = append(typeCmds, pipe.Type(ctx, k))
+		memCmds = append(memCmds, pipe.MemoryUsage(ctx, k))
+	}
+
+	// key may be deleted during scan, so errors of single command are ignored.
+	if _, err := pipe.Exec(ctx); err != nil && ctx.Err() != nil {
+		return nil, err
+	}
+
+	res := make([]*sampledKey, 0, len(keys))
+	for i, k := range keys {
+		mem, err := memCmds[i].Result()
+		if err != nil {
+			continue
+		}
+
+		res = append(res, &sampledKey{
+			key:     k,
+			keyType: typeCmds[i].Val(),
+			prefix:  keyPrefix(k, sep),
+			memory:  mem,
+		})
+	}
+
+	return res, nil
+}
+
+func (ipt *Input) buildBigKeyScanPoints(db int, sampled []*sampledKey) []*point.Point {
+	collectCache := []*point.Point{}
+	opts := point.DefaultLoggingOptions()
+	opts = append(opts, point.WithTime(time.Now()))
+
+	for prefix, keys := range topNBigKeys(sampled, ipt.BigKeyScan.TopN) {
+		for _, sk := range keys {
+			var kvs point.KVs
+			kvs = kvs.AddTag("db_name", "db"+strconv.Itoa(db))
+			kvs = kvs.AddTag("key", sk.key)
+			kvs = kvs.AddTag("key_type", sk.keyType)
+			kvs = kvs.AddTag("key_prefix", prefix)
+			kvs = kvs.Add("memory_usage", sk.memory, false, false)
+			kvs = kvs.Add("keys_sampled", len(sampled), false, false)
+			kvs = kvs.Add("message", fmt.Sprintf("big key key: %s key_type: %s memory_usage: %d", sk.key, sk.keyType, sk.memory), false, false)
+
+			for k, v := range ipt.mergedTags {
+				kvs = kvs.AddTag(k, v)
+			}
+
+			collectCache = append(collectCache, point.NewPointV2(redisBigkey, kvs, opts...))
+		}
+	}
+
+	return collectCache
+}
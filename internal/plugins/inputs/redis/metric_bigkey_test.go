@@ -233,3 +233,44 @@ Biggest string found '"wo19"' has 358 bytes
 0 sets with 0 members (00.00% of keys, avg size 0.00)
 0 zsets with 0 members (00.00% of keys, avg size 0.00)
 `
+
+func TestTopNBigKeys(t *testing.T) {
+	sep := ":"
+	keys := []*sampledKey{
+		{key: "user:1", keyType: "hash", prefix: keyPrefix("user:1", sep), memory: 100},
+		{key: "user:2", keyType: "hash", prefix: keyPrefix("user:2", sep), memory: 300},
+		{key: "user:3", keyType: "hash", prefix: keyPrefix("user:3", sep), memory: 200},
+		{key: "order:1", keyType: "string", prefix: keyPrefix("order:1", sep), memory: 50},
+		{key: "counter", keyType: "string", prefix: keyPrefix("counter", sep), memory: 10},
+	}
+
+	res := topNBigKeys(keys, 2)
+	assert.Len(t, res, 3)
+
+	assert.Len(t, res["user"], 2)
+	assert.Equal(t, "user:2", res["user"][0].key)
+	assert.Equal(t, "user:3", res["user"][1].key)
+
+	assert.Len(t, res["order"], 1)
+	assert.Len(t, res[noKeyPrefix], 1)
+	assert.Equal(t, "counter", res[noKeyPrefix][0].key)
+}
+
+func TestInput_buildBigKeyScanPoints(t *testing.T) {
+	ipt := &Input{
+		BigKeyScan: &bigKeyScan{TopN: 1},
+		mergedTags: map[string]string{"for": "bar"},
+	}
+
+	pts := ipt.buildBigKeyScanPoints(2, []*sampledKey{
+		{key: "user:1", keyType: "hash", prefix: "user", memory: 100},
+		{key: "user:2", keyType: "hash", prefix: "user", memory: 300},
+	})
+	assert.Len(t, pts, 1)
+
+	tags := pts[0].Tags()
+	assert.Equal(t, "db2", tags.Get("db_name").GetS())
+	assert.Equal(t, "user:2", tags.Get("key").GetS())
+	assert.Equal(t, "bar", tags.Get("for").GetS())
+	assert.Equal(t, int64(300), pts[0].Get("memory_usage"))
+}
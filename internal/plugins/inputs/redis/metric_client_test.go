@@ -105,3 +105,42 @@ func mockClient01() string {
 	return `id=4 addr=127.0.0.1:33294 laddr=127.0.0.1:6379 fd=12 name= age=26441 idle=24342 flags=N db=0 sub=0 psub=0 ssub=0 multi=-1 qbuf=0 qbuf-free=0 argv-mem=0 multi-mem=0 rbs=1024 rbp=0 obl=0 oll=0 omem=0 tot-mem=1800 events=r cmd=slowlog|get user=default redir=-1 resp=2
 	id=16 addr=172.17.0.1:41942 laddr=172.17.0.2:6379 fd=13 name= age=253 idle=0 flags=N db=0 sub=0 psub=0 ssub=0 multi=-1 qbuf=26 qbuf-free=20448 argv-mem=10 multi-mem=0 rbs=1024 rbp=1024 obl=0 oll=0 omem=0 tot-mem=22298 events=r cmd=client|list user=default redir=-1 resp=2`
 }
+
+func TestInput_parseClientsSummary(t *testing.T) {
+	ipt := &Input{
+		Host:   "localhost",
+		Tags:   map[string]string{"foo": "bar"},
+		tagger: testutils.DefaultMockTagger(),
+	}
+	ipt.setup()
+
+	list := `id=3 addr=127.0.0.1:57224 fd=8 name= age=30 idle=10 flags=N db=0 tot-mem=100 user=app
+id=4 addr=127.0.0.1:57225 fd=9 name= age=7200 idle=3700 flags=N db=0 tot-mem=200 user=app
+id=5 addr=127.0.0.1:57226 fd=10 name= age=100000 idle=0 flags=S db=0 tot-mem=300 user=default
+id=6 addr=127.0.0.1:57227 fd=11 name= age=120 idle=1 flags=N db=0 tot-mem=50`
+
+	pts := ipt.parseClientsSummary(list)
+
+	gotStr := []string{}
+	for _, v := range pts {
+		s := v.LineProto()
+		s = s[:strings.LastIndex(s, " ")]
+		gotStr = append(gotStr, s)
+	}
+	sort.Strings(gotStr)
+
+	assert.Equal(t, []string{
+		"redis_clients,flags=N,foo=bar,host=HOST,user=app age_1h_to_1d=1i,age_1m_to_1h=0i,age_gt_1d=0i,age_lt_1m=1i,clients=2i,idle_gt_1h=1i,max_idle=3700i,tot_mem=300i",
+		"redis_clients,flags=N,foo=bar,host=HOST,user=unknown age_1h_to_1d=0i,age_1m_to_1h=1i,age_gt_1d=0i,age_lt_1m=0i,clients=1i,idle_gt_1h=0i,max_idle=1i,tot_mem=50i",
+		"redis_clients,flags=S,foo=bar,host=HOST,user=default age_1h_to_1d=0i,age_1m_to_1h=0i,age_gt_1d=1i,age_lt_1m=0i,clients=1i,idle_gt_1h=0i,max_idle=0i,tot_mem=300i",
+	}, gotStr)
+
+	pt := ipt.buildACLPoint("datakit", []string{
+		"user default on nopass ~* &* +@all",
+		"user datakit on #d74ff0ee8da3b9806b18c877dbf29bbde50b5bd8e4dad7a3a725000feb82e8f1 ~* +@read",
+		"user legacy off ~* +@all",
+	})
+	assert.Equal(t, int64(3), pt.Get("acl_users"))
+	assert.Equal(t, int64(2), pt.Get("acl_enabled_users"))
+	assert.Equal(t, "datakit", pt.GetTag("whoami"))
+}
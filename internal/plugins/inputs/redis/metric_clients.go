@@ -0,0 +1,225 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package redis
+
+import (
+	"bufio"
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/GuanceCloud/cliutils/point"
+
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs"
+)
+
+type clientsMeasurement struct{}
+
+//nolint:lll
+func (m *clientsMeasurement) Info() *inputs.MeasurementInfo {
+	return &inputs.MeasurementInfo{
+		Name: redisClients,
+		Type: "metric",
+		Desc: "Connected clients summarized by ACL user and client flags, and ACL users count.",
+		Fields: map[string]interface{}{
+			"clients":           &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.NCount, Desc: "Number of connected clients."},
+			"age_lt_1m":         &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.NCount, Desc: "Number of clients connected less than 1 minute."},
+			"age_1m_to_1h":      &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.NCount, Desc: "Number of clients connected between 1 minute and 1 hour."},
+			"age_1h_to_1d":      &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.NCount, Desc: "Number of clients connected between 1 hour and 1 day."},
+			"age_gt_1d":         &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.NCount, Desc: "Number of clients connected more than 1 day."},
+			"idle_gt_1h":        &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.NCount, Desc: "Number of clients idle more than 1 hour."},
+			"max_idle":          &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.DurationSecond, Desc: "Max idle time of the clients."},
+			"tot_mem":           &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.SizeByte, Desc: "Total memory consumed by the clients."},
+			"acl_users":         &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.NCount, Desc: "Number of ACL users, from `ACL LIST`. Redis 6.0+."},
+			"acl_enabled_users": &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.NCount, Desc: "Number of enabled(`on`) ACL users, from `ACL LIST`. Redis 6.0+."},
+		},
+		Tags: map[string]interface{}{
+			"host":         &inputs.TagInfo{Desc: "Hostname."},
+			"server":       &inputs.TagInfo{Desc: "Server addr."},
+			"service_name": &inputs.TagInfo{Desc: "Service name."},
+			"user":         &inputs.TagInfo{Desc: "The authenticated ACL user of the clients, `unknown` before Redis 6.0."},
+			"flags":        &inputs.TagInfo{Desc: "Client flags, such as `N`(normal), `S`(replica), `P`(pubsub)."},
+			"whoami":       &inputs.TagInfo{Desc: "The user DataKit connected with, from `ACL WHOAMI`, only for the ACL users count."},
+		},
+	}
+}
+
+type clientsSummary struct {
+	clients, ageLt1m, age1mTo1h, age1hTo1d, ageGt1d, idleGt1h int64
+	maxIdle, totMem                                           int64
+}
+
+// parseClientsSummary summarize `CLIENT LIST` by user and flags.
+//
+// example data:
+// id=3 addr=127.0.0.1:57224 laddr=127.0.0.1:6379 fd=8 name= age=30 idle=10 flags=N db=0 ... tot-mem=20496 ... user=default.
+func (ipt *Input) parseClientsSummary(list string) []*point.Point {
+	type groupKey struct{ user, flags string }
+
+	groups := map[groupKey]*clientsSummary{}
+
+	scanner := bufio.NewScanner(strings.NewReader(list))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+
+		var (
+			key               = groupKey{user: "unknown", flags: "unknown"}
+			age, idle, totMem int64
+		)
+
+		for _, part := range strings.Split(line, " ") {
+			kv := strings.SplitN(part, "=", 2)
+			if len(kv) != 2 {
+				continue
+			}
+
+			switch kv[0] {
+			case "user":
+				if kv[1] != "" {
+					key.user = kv[1]
+				}
+			case "flags":
+				if kv[1] != "" {
+					key.flags = kv[1]
+				}
+			case "age":
+				age, _ = strconv.ParseInt(kv[1], 10, 64)
+			case "idle":
+				idle, _ = strconv.ParseInt(kv[1], 10, 64)
+			case "tot-mem":
+				totMem, _ = strconv.ParseInt(kv[1], 10, 64)
+			}
+		}
+
+		s, ok := groups[key]
+		if !ok {
+			s = &clientsSummary{}
+			groups[key] = s
+		}
+
+		s.clients++
+		switch {
+		case age < 60:
+			s.ageLt1m++
+		case age < 3600:
+			s.age1mTo1h++
+		case age < 86400:
+			s.age1hTo1d++
+		default:
+			s.ageGt1d++
+		}
+
+		if idle >= 3600 {
+			s.idleGt1h++
+		}
+		if idle > s.maxIdle {
+			s.maxIdle = idle
+		}
+		s.totMem += totMem
+	}
+
+	keys := make([]groupKey, 0, len(groups))
+	for k := range groups {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].user != keys[j].user {
+			return keys[i].user < keys[j].user
+		}
+		return keys[i].flags < keys[j].flags
+	})
+
+	collectCache := []*point.Point{}
+	opts := append(point.DefaultMetricOptions(), point.WithTimestamp(ipt.alignTS))
+
+	for _, gk := range keys {
+		s := groups[gk]
+
+		var kvs point.KVs
+		kvs = kvs.AddTag("user", gk.user).
+			AddTag("flags", gk.flags).
+			Add("clients", s.clients, false, false).
+			Add("age_lt_1m", s.ageLt1m, false, false).
+			Add("age_1m_to_1h", s.age1mTo1h, false, false).
+			Add("age_1h_to_1d", s.age1hTo1d, false, false).
+			Add("age_gt_1d", s.ageGt1d, false, false).
+			Add("idle_gt_1h", s.idleGt1h, false, false).
+			Add("max_idle", s.maxIdle, false, false).
+			Add("tot_mem", s.totMem, false, false)
+
+		for k, v := range ipt.mergedTags {
+			kvs = kvs.AddTag(k, v)
+		}
+
+		collectCache = append(collectCache, point.NewPointV2(redisClients, kvs, opts...))
+	}
+
+	return collectCache
+}
+
+// collectACLSummary collect ACL users count, ACL commands not available before Redis 6.0.
+func (ipt *Input) collectACLSummary() []*point.Point {
+	ctx := context.Background()
+
+	res, err := ipt.client.Do(ctx, "acl", "list").Result()
+	if err != nil {
+		l.Debugf("acl list: %s, ignored", err)
+		return nil
+	}
+
+	users, ok := res.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	whoami, err := ipt.client.Do(ctx, "acl", "whoami").Text()
+	if err != nil {
+		l.Debugf("acl whoami: %s, ignored", err)
+		whoami = "unknown"
+	}
+
+	list := make([]string, 0, len(users))
+	for _, u := range users {
+		if s, ok := u.(string); ok {
+			list = append(list, s)
+		}
+	}
+
+	return []*point.Point{ipt.buildACLPoint(whoami, list)}
+}
+
+// buildACLPoint build point from `ACL LIST` reply.
+//
+// example data:
+// user default on nopass ~* &* +@all
+// user monitor off #d74ff0ee8da3b9806b18c877dbf29bbde50b5bd8e4dad7a3a725000feb82e8f1 ~* +@read.
+func (ipt *Input) buildACLPoint(whoami string, users []string) *point.Point {
+	var enabled int64
+	for _, u := range users {
+		for _, rule := range strings.Fields(u) {
+			if rule == "on" {
+				enabled++
+				break
+			}
+		}
+	}
+
+	var kvs point.KVs
+	kvs = kvs.AddTag("whoami", whoami).
+		Add("acl_users", int64(len(users)), false, false).
+		Add("acl_enabled_users", enabled, false, false)
+
+	for k, v := range ipt.mergedTags {
+		kvs = kvs.AddTag(k, v)
+	}
+
+	return point.NewPointV2(redisClients, kvs,
+		append(point.DefaultMetricOptions(), point.WithTimestamp(ipt.alignTS))...)
+}
@@ -0,0 +1,226 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package redis
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/go-redis/redis/v8"
+)
+
+// clusterNode is a node of redis cluster discovered by `CLUSTER NODES`.
+type clusterNode struct {
+	id       string
+	addr     string
+	role     string // master or slave
+	masterID string
+	slots    []string
+
+	cli      *redis.Client
+	cpuUsage redisCPUUsage
+}
+
+func (n *clusterNode) tags() map[string]string {
+	tags := map[string]string{
+		"server":    n.addr,
+		"node_id":   n.id,
+		"node_role": n.role,
+	}
+
+	if len(n.slots) > 0 {
+		tags["slots"] = strings.Join(n.slots, ",")
+	}
+
+	return tags
+}
+
+// parseClusterNodes parse output of `CLUSTER NODES`.
+//
+// see also: https://redis.io/commands/cluster-nodes/
+//
+// example data:
+//
+// 07c37dfeb235213a872192d90877d0cd55635b91 127.0.0.1:30004@31004 slave e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca 0 1426238317239 4 connected
+// 67ed2db8d677e59ec4a4cefb06858cf2a1a89fa1 127.0.0.1:30002@31002,hostname2 master - 0 1426238316232 2 connected 5461-10922
+// e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca 127.0.0.1:30001@31001 myself,master - 0 0 1 connected 0-5460 [5461->-67ed2db8d677e59ec4a4cefb06858cf2a1a89fa1].
+func parseClusterNodes(list string) []*clusterNode {
+	var nodes []*clusterNode
+
+	scanner := bufio.NewScanner(strings.NewReader(list))
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) < 8 {
+			continue
+		}
+
+		flags := strings.Split(parts[2], ",")
+		role := ""
+		skip := false
+		for _, f := range flags {
+			switch f {
+			case "master", "slave":
+				role = f
+			case "fail", "noaddr", "handshake":
+				skip = true
+			}
+		}
+
+		if skip || role == "" {
+			l.Debugf("skip cluster node %q with flags %q", parts[0], parts[2])
+			continue
+		}
+
+		// ip:port@cport[,hostname]
+		addr := parts[1]
+		if idx := strings.IndexAny(addr, "@,"); idx > 0 {
+			addr = addr[:idx]
+		}
+
+		node := &clusterNode{
+			id:   parts[0],
+			addr: addr,
+			role: role,
+		}
+
+		if parts[3] != "-" {
+			node.masterID = parts[3]
+		}
+
+		for _, slot := range parts[8:] {
+			// ignore importing/migrating slots like [5461->-67ed2db...]
+			if strings.HasPrefix(slot, "[") {
+				continue
+			}
+			node.slots = append(node.slots, slot)
+		}
+
+		nodes = append(nodes, node)
+	}
+
+	return nodes
+}
+
+// applyClusterSlots set the slot ranges of each node(both master and its
+// replicas) from `CLUSTER SLOTS`, the node matched by ID or address(ID not
+// returned before Redis 4.0).
+func applyClusterSlots(nodes []*clusterNode, slots []redis.ClusterSlot) {
+	byID := make(map[string]*clusterNode, len(nodes))
+	byAddr := make(map[string]*clusterNode, len(nodes))
+	for _, n := range nodes {
+		n.slots = nil
+		byID[n.id] = n
+		byAddr[n.addr] = n
+	}
+
+	for _, s := range slots {
+		r := strconv.Itoa(s.Start)
+		if s.End != s.Start {
+			r += "-" + strconv.Itoa(s.End)
+		}
+
+		for _, sn := range s.Nodes {
+			n, ok := byID[sn.ID]
+			if !ok {
+				n, ok = byAddr[sn.Addr]
+			}
+			if ok {
+				n.slots = append(n.slots, r)
+			}
+		}
+	}
+}
+
+// refreshClusterNodes discovers all nodes of the cluster, create clients for new
+// nodes and close clients of nodes that left the cluster.
+func (ipt *Input) refreshClusterNodes() error {
+	ctx := context.Background()
+	list, err := ipt.client.ClusterNodes(ctx).Result()
+	if err != nil {
+		return fmt.Errorf("cluster nodes: %w", err)
+	}
+
+	nodes := parseClusterNodes(list)
+
+	// CLUSTER SLOTS got the slots served by the replicas too
+	if slots, err := ipt.client.ClusterSlots(ctx).Result(); err != nil {
+		l.Warnf("cluster slots: %s, use slots from cluster nodes", err)
+	} else {
+		applyClusterSlots(nodes, slots)
+	}
+
+	current := make(map[string]*clusterNode, len(nodes))
+
+	for _, n := range nodes {
+		if old, ok := ipt.clusterNodes[n.addr]; ok {
+			// keep client and CPU usage of known node, topology may changed.
+			old.id, old.role, old.masterID, old.slots = n.id, n.role, n.masterID, n.slots
+			current[n.addr] = old
+			continue
+		}
+
+		n.cli = ipt.newClient(n.addr, 0) // redis cluster only supports db 0
+		current[n.addr] = n
+		l.Infof("found redis cluster node %s(%s) at %s", n.id, n.role, n.addr)
+	}
+
+	for addr, n := range ipt.clusterNodes {
+		if _, ok := current[addr]; !ok {
+			l.Infof("redis cluster node %s(%s) at %s removed", n.id, n.role, n.addr)
+			if err := n.cli.Close(); err != nil {
+				l.Warnf("close client of %s: %s", n.addr, err)
+			}
+		}
+	}
+
+	ipt.clusterNodes = current
+
+	return nil
+}
+
+// collectFromClusterNodes run fn on every node of the cluster, and
+// tag the collected points with node info.
+func (ipt *Input) collectFromClusterNodes(fn func(n *clusterNode) ([]*point.Point, error)) ([]*point.Point, error) {
+	var (
+		res  []*point.Point
+		errs []string
+	)
+
+	for _, n := range ipt.clusterNodes {
+		pts, err := fn(n)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", n.addr, err))
+			continue
+		}
+
+		for _, pt := range pts {
+			for k, v := range n.tags() {
+				pt.MustAddTag(k, v)
+			}
+		}
+
+		res = append(res, pts...)
+	}
+
+	if len(errs) > 0 {
+		return res, fmt.Errorf("collect from cluster nodes: %s", strings.Join(errs, "; "))
+	}
+
+	return res, nil
+}
+
+func (ipt *Input) closeClusterNodes() {
+	for _, n := range ipt.clusterNodes {
+		if err := n.cli.Close(); err != nil {
+			l.Warnf("close client of %s: %s", n.addr, err)
+		}
+	}
+	ipt.clusterNodes = nil
+}
@@ -10,6 +10,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/go-redis/redis/v8"
 	"github.com/stretchr/testify/assert"
 
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/datakit"
@@ -113,3 +114,49 @@ cluster_stats_messages_received:1483968
 total_cluster_links_buffer_limit_exceeded:0
 cluster_stats_messages_auth-ack_sent:0
 `
+
+func TestParseClusterNodes(t *testing.T) {
+	nodes := parseClusterNodes(mockClusterNodes01)
+	assert.Len(t, nodes, 3)
+
+	assert.Equal(t, "07c37dfeb235213a872192d90877d0cd55635b91", nodes[0].id)
+	assert.Equal(t, "127.0.0.1:30004", nodes[0].addr)
+	assert.Equal(t, "slave", nodes[0].role)
+	assert.Equal(t, "e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca", nodes[0].masterID)
+	assert.Empty(t, nodes[0].slots)
+
+	assert.Equal(t, "127.0.0.1:30002", nodes[1].addr)
+	assert.Equal(t, "master", nodes[1].role)
+	assert.Equal(t, []string{"5461-10922"}, nodes[1].slots)
+
+	assert.Equal(t, "127.0.0.1:30001", nodes[2].addr)
+	assert.Equal(t, []string{"0-5460", "12000"}, nodes[2].slots)
+
+	tags := nodes[2].tags()
+	assert.Equal(t, "master", tags["node_role"])
+	assert.Equal(t, "0-5460,12000", tags["slots"])
+	assert.Equal(t, "127.0.0.1:30001", tags["server"])
+}
+
+func TestApplyClusterSlots(t *testing.T) {
+	nodes := parseClusterNodes(mockClusterNodes01)
+
+	applyClusterSlots(nodes, []redis.ClusterSlot{
+		{Start: 0, End: 5460, Nodes: []redis.ClusterNode{
+			{ID: "e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca", Addr: "127.0.0.1:30001"},
+			{ID: "07c37dfeb235213a872192d90877d0cd55635b91", Addr: "127.0.0.1:30004"},
+		}},
+		{Start: 5461, End: 10922, Nodes: []redis.ClusterNode{{Addr: "127.0.0.1:30002"}}}, // no ID before Redis 4.0
+		{Start: 12000, End: 12000, Nodes: []redis.ClusterNode{{ID: "e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca"}}},
+	})
+
+	assert.Equal(t, []string{"0-5460"}, nodes[0].slots) // replica
+	assert.Equal(t, []string{"5461-10922"}, nodes[1].slots)
+	assert.Equal(t, []string{"0-5460", "12000"}, nodes[2].slots)
+}
+
+var mockClusterNodes01 = `07c37dfeb235213a872192d90877d0cd55635b91 127.0.0.1:30004@31004 slave e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca 0 1426238317239 4 connected
+67ed2db8d677e59ec4a4cefb06858cf2a1a89fa1 127.0.0.1:30002@31002,hostname2 master - 0 1426238316232 2 connected 5461-10922
+292f8b365bb7edb5e285caf0b7e6ddc7265d2f4f 127.0.0.1:30003@31003 master,fail - 0 1426238318243 3 disconnected 10923-11999
+e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca 127.0.0.1:30001@31001 myself,master - 0 0 1 connected 0-5460 12000 [5461->-67ed2db8d677e59ec4a4cefb06858cf2a1a89fa1]
+`
@@ -9,6 +9,7 @@ import (
 	"bufio"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/GuanceCloud/cliutils/point"
 
@@ -30,17 +31,89 @@ func (m *commandMeasurement) Info() *inputs.MeasurementInfo {
 			"usec_per_call":  &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.DurationUS, Desc: "The average CPU consumed per command execution."},
 			"rejected_calls": &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.NCount, Desc: "The number of rejected calls (errors prior command execution)."},
 			"failed_calls":   &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.NCount, Desc: "The number of failed calls (errors within the command execution)."},
+
+			// delta mode, only collected when `command_stats_delta` enabled
+			"calls_delta":          &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.NCount, Desc: "The number of calls since last collect, only collected when `command_stats_delta` enabled."},
+			"usec_delta":           &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.DurationUS, Desc: "The CPU time consumed since last collect, only collected when `command_stats_delta` enabled."},
+			"rejected_calls_delta": &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.NCount, Desc: "The number of rejected calls since last collect, only collected when `command_stats_delta` enabled."},
+			"failed_calls_delta":   &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.NCount, Desc: "The number of failed calls since last collect, only collected when `command_stats_delta` enabled."},
+			"calls_per_sec":        &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Rate, Unit: inputs.RequestsPerSec, Desc: "The calls per second since last collect, only collected when `command_stats_delta` enabled."},
+			"usec_per_call_delta":  &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.DurationUS, Desc: "The average CPU consumed per command execution since last collect, only collected when `command_stats_delta` enabled."},
 		},
 		Tags: map[string]interface{}{
 			"host":         &inputs.TagInfo{Desc: "Hostname"},
 			"method":       &inputs.TagInfo{Desc: "Command type"},
 			"server":       &inputs.TagInfo{Desc: "Server addr"},
 			"service_name": &inputs.TagInfo{Desc: "Service name"},
+			"node_id":      &inputs.TagInfo{Desc: "Cluster node ID, only collected when `cluster_mode` enabled"},
+			"node_role":    &inputs.TagInfo{Desc: "Cluster node role(`master/slave`), only collected when `cluster_mode` enabled"},
+			"slots":        &inputs.TagInfo{Desc: "Slot ranges served by the cluster node, only collected when `cluster_mode` enabled"},
 		},
 	}
 }
 
-func (ipt *Input) parseCommandData(list string) ([]*point.Point, error) {
+// commandStatSample is the cumulative counters of a command on last collect.
+type commandStatSample struct {
+	values map[string]float64
+	ts     int64 // unix nano
+}
+
+var commandStatCounters = []string{"calls", "usec", "rejected_calls", "failed_calls"}
+
+// addCommandDelta add per-interval delta and rate of command counters against the
+// previous sample of the same server and command.
+func (ipt *Input) addCommandDelta(server, method string, kvs point.KVs) point.KVs {
+	if ipt.commandStatLast == nil {
+		ipt.commandStatLast = map[string]*commandStatSample{}
+	}
+
+	cur := &commandStatSample{values: map[string]float64{}, ts: ipt.alignTS}
+	for _, k := range commandStatCounters {
+		if f := kvs.Get(k); f != nil {
+			cur.values[k] = f.GetF()
+		}
+	}
+
+	key := server + "/" + method
+	last := ipt.commandStatLast[key]
+	ipt.commandStatLast[key] = cur
+
+	if last == nil || cur.ts <= last.ts {
+		return kvs
+	}
+
+	deltas := map[string]float64{}
+	for k, v := range cur.values {
+		lastV, ok := last.values[k]
+		if !ok {
+			continue
+		}
+
+		// counter reset by CONFIG RESETSTAT or restart
+		if v < lastV {
+			return kvs
+		}
+		deltas[k] = v - lastV
+	}
+
+	for _, k := range commandStatCounters {
+		if d, ok := deltas[k]; ok {
+			kvs = kvs.Add(k+"_delta", d, false, false)
+		}
+	}
+
+	if calls, ok := deltas["calls"]; ok {
+		kvs = kvs.Add("calls_per_sec", calls/(float64(cur.ts-last.ts)/float64(time.Second)), false, false)
+
+		if usec, ok := deltas["usec"]; ok && calls > 0 {
+			kvs = kvs.Add("usec_per_call_delta", usec/calls, false, false)
+		}
+	}
+
+	return kvs
+}
+
+func (ipt *Input) parseCommandData(server, list string) ([]*point.Point, error) {
 	var (
 		collectCache = []*point.Point{}
 		rdr          = strings.NewReader(list)
@@ -85,6 +158,10 @@ func (ipt *Input) parseCommandData(list string) ([]*point.Point, error) {
 		}
 
 		if kvs.FieldCount() > 0 {
+			if ipt.CommandStatsDelta {
+				kvs = ipt.addCommandDelta(server, parts[0], kvs)
+			}
+
 			for k, v := range ipt.mergedTags {
 				kvs = kvs.AddTag(k, v)
 			}
@@ -91,7 +91,7 @@ cmdstat_command|docs:calls=2,usec=4112,usec_per_call=2056.00,rejected_calls=0,fa
 
 			ipt.setup()
 
-			got, err := ipt.parseCommandData(tt.args.list)
+			got, err := ipt.parseCommandData("localhost:6379", tt.args.list)
 			require.NoError(t, err)
 
 			gotStr := []string{}
@@ -125,7 +125,7 @@ cmdstat_client|list:calls=1,usec=25,usec_per_call=25.00,rejected_calls,failed_ca
 
 		ipt.setup()
 
-		got, err := ipt.parseCommandData(badCommand)
+		got, err := ipt.parseCommandData("localhost:6379", badCommand)
 		for _, pt := range got {
 			pt.SetTime(time.Unix(0, 123))
 		}
@@ -143,3 +143,41 @@ cmdstat_client|list:calls=1,usec=25,usec_per_call=25.00,rejected_calls,failed_ca
 		)
 	})
 }
+
+func TestInput_parseCommandDataDelta(t *T.T) {
+	ipt := &Input{
+		Host:              "localhost",
+		CommandStatsDelta: true,
+		tagger:            testutils.DefaultMockTagger(),
+	}
+	ipt.setup()
+
+	ipt.alignTS = 10 * int64(time.Second)
+	got, err := ipt.parseCommandData("localhost:6379", "cmdstat_get:calls=100,usec=1000,usec_per_call=10.00,rejected_calls=0,failed_calls=1")
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Nil(t, got[0].Get("calls_delta"), "no delta on first collect")
+
+	ipt.alignTS = 20 * int64(time.Second)
+	got, err = ipt.parseCommandData("localhost:6379", "cmdstat_get:calls=150,usec=2000,usec_per_call=13.33,rejected_calls=0,failed_calls=3")
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, 50.0, got[0].Get("calls_delta"))
+	assert.Equal(t, 1000.0, got[0].Get("usec_delta"))
+	assert.Equal(t, 0.0, got[0].Get("rejected_calls_delta"))
+	assert.Equal(t, 2.0, got[0].Get("failed_calls_delta"))
+	assert.Equal(t, 5.0, got[0].Get("calls_per_sec"))
+	assert.Equal(t, 20.0, got[0].Get("usec_per_call_delta"))
+
+	// other server got its own sample.
+	got, err = ipt.parseCommandData("localhost:6380", "cmdstat_get:calls=1,usec=10,usec_per_call=10.00,rejected_calls=0,failed_calls=0")
+	require.NoError(t, err)
+	assert.Nil(t, got[0].Get("calls_delta"))
+
+	// counter reset
+	ipt.alignTS = 30 * int64(time.Second)
+	got, err = ipt.parseCommandData("localhost:6379", "cmdstat_get:calls=10,usec=100,usec_per_call=10.00,rejected_calls=0,failed_calls=0")
+	require.NoError(t, err)
+	assert.Nil(t, got[0].Get("calls_delta"))
+	assert.Equal(t, 10.0, got[0].Get("calls"))
+}
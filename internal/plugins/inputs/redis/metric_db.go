@@ -27,7 +27,7 @@ func (m *dbMeasurement) Info() *inputs.MeasurementInfo {
 			"avg_ttl": &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Desc: "Average ttl."},
 		},
 		Tags: map[string]interface{}{
-			"db":           &inputs.TagInfo{Desc: "DB name."},
+			"db_name":      &inputs.TagInfo{Desc: "DB name, such as `db0`."},
 			"host":         &inputs.TagInfo{Desc: "Hostname."},
 			"server":       &inputs.TagInfo{Desc: "Server addr."},
 			"service_name": &inputs.TagInfo{Desc: "Service name."},
@@ -70,6 +70,9 @@ func (ipt *Input) parseDBData(list string) ([]*point.Point, error) {
 			continue
 		}
 		db := parts[0]
+		if !strings.HasPrefix(db, "db") {
+			continue
+		}
 		dbIndex, err := strconv.Atoi(db[2:])
 		if err != nil {
 			return collectCache, err
@@ -80,6 +83,9 @@ func (ipt *Input) parseDBData(list string) ([]*point.Point, error) {
 		itemStrs := strings.Split(parts[1], ",")
 		for _, itemStr := range itemStrs {
 			item := strings.Split(itemStr, "=")
+			if len(item) != 2 {
+				continue
+			}
 
 			f, err := strconv.ParseFloat(item[1], 64)
 			if err != nil {
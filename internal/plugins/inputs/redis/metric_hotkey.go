@@ -116,14 +116,7 @@ func (ipt *Input) getHotData(ctxKey context.Context, db int) (string, error) {
 		args = append(args, "-a", ipt.Password)
 	}
 
-	if ipt.TLSClientConfig != nil {
-		args = append(args, "--tls")
-		args = append(args, "--cert", ipt.TLSClientConfig.Cert)
-		args = append(args, "--key", ipt.TLSClientConfig.CertKey)
-		if len(ipt.TLSClientConfig.CaCerts) > 0 {
-			args = append(args, "--cacert", ipt.TLSClientConfig.CaCerts[0])
-		}
-	}
+	args = append(args, ipt.redisCliTLSArgs()...)
 
 	//nolint:gosec
 	c := exec.CommandContext(ctx, args[0], args[1:]...)
@@ -12,11 +12,16 @@ import (
 	"time"
 
 	"github.com/GuanceCloud/cliutils/point"
+	"github.com/spf13/cast"
 
 	dkio "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs"
 )
 
+// check latency-monitor-threshold rarely, the CONFIG command may be
+// renamed or disabled on managed Redis.
+const latencyMonitorCheckInterval = 10 * time.Minute
+
 type latencyMeasurement struct{}
 
 //nolint:lll,funlen
@@ -27,7 +32,7 @@ func (m *latencyMeasurement) Info() *inputs.MeasurementInfo {
 		Fields: map[string]interface{}{
 			"occur_time":    &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.TimestampSec, Desc: "Unix timestamp of the latest latency spike for the event."},
 			"cost_time":     &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.DurationMS, Desc: "Latest event latency in millisecond."},
-			"max_cost_time": &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.DurationMS, Desc: "All-time maximum latency for this event."},
+			"max_cost_time": &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.DurationMS, Desc: "All-time maximum latency for this event. Not available for samples from LATENCY HISTORY."},
 			"event_name":    &inputs.FieldInfo{DataType: inputs.String, Type: inputs.Gauge, Unit: inputs.UnknownUnit, Desc: "Event name."},
 		},
 		Tags: map[string]interface{}{
@@ -39,12 +44,34 @@ func (m *latencyMeasurement) Info() *inputs.MeasurementInfo {
 
 func (ipt *Input) getLatencyData() error {
 	ctx := context.Background()
+
+	// LATENCY HISTORY samples before the first run are not reported
+	if ipt.latencyHistorySince.IsZero() {
+		ipt.latencyHistorySince = time.Now()
+	}
+
+	if !ipt.latencyMonitorEnabled(ctx) {
+		l.Debugf("latency-monitor-threshold is 0, skip latency events")
+		return nil
+	}
+
 	list := ipt.client.Do(ctx, "latency", "latest").String()
 	pts, err := ipt.parseLatencyData(list)
 	if err != nil {
 		return err
 	}
 
+	for event := range ipt.latencyLastTime {
+		res, err := ipt.client.Do(ctx, "latency", "history", event).Result()
+		if err != nil {
+			l.Warnf("latency history %s: %s", event, err)
+			continue
+		}
+		if samples, ok := res.([]interface{}); ok {
+			pts = append(pts, ipt.parseLatencyHistory(event, samples)...)
+		}
+	}
+
 	if len(pts) > 0 {
 		if err := ipt.feeder.FeedV2(point.Logging, pts,
 			dkio.WithElection(ipt.Election),
@@ -56,6 +83,96 @@ func (ipt *Input) getLatencyData() error {
 	return nil
 }
 
+// latencyMonitorEnabled check if latency-monitor-threshold set on the server,
+// the result cached for latencyMonitorCheckInterval. If CONFIG command not
+// available(such as cloud Redis), consider it enabled.
+func (ipt *Input) latencyMonitorEnabled(ctx context.Context) bool {
+	if !ipt.latencyMonitorCheckedAt.IsZero() && time.Since(ipt.latencyMonitorCheckedAt) < latencyMonitorCheckInterval {
+		return ipt.latencyMonitorOn
+	}
+
+	ipt.latencyMonitorCheckedAt = time.Now()
+	ipt.latencyMonitorOn = true
+
+	res, err := ipt.client.ConfigGet(ctx, "latency-monitor-threshold").Result()
+	if err != nil {
+		l.Debugf("config get latency-monitor-threshold: %s, consider it enabled", err)
+		return true
+	}
+
+	if len(res) == 2 {
+		ipt.latencyMonitorOn = cast.ToString(res[1]) != "0"
+	}
+
+	return ipt.latencyMonitorOn
+}
+
+// parseLatencyHistory parse reply of `LATENCY HISTORY <event>`.
+//
+// example data:
+// [[1699346170 120] [1699346177 250]]
+//
+// The sample same as LATENCY LATEST has been reported, skip it. For the
+// event first seen, samples before the first run are skipped, so the stored
+// history not backfilled on start.
+func (ipt *Input) parseLatencyHistory(event string, samples []interface{}) []*point.Point {
+	last, ok := ipt.latencyHistoryLastTime[event]
+	if !ok {
+		last = ipt.latencyHistorySince
+	}
+
+	var (
+		collectCache = []*point.Point{}
+		latest       = ipt.latencyLastTime[event]
+		newLast      = last
+	)
+
+	for _, sample := range samples {
+		arr, ok := sample.([]interface{})
+		if !ok || len(arr) != 2 {
+			continue
+		}
+
+		startTime, err := cast.ToInt64E(arr[0])
+		if err != nil {
+			continue
+		}
+		cost, err := cast.ToInt64E(arr[1])
+		if err != nil {
+			continue
+		}
+
+		ts := time.Unix(startTime, 0)
+		if !ts.After(last) {
+			continue
+		}
+		if ts.After(newLast) {
+			newLast = ts
+		}
+		if ts.Equal(latest) {
+			continue
+		}
+
+		var kvs point.KVs
+		kvs = kvs.AddTag("server_addr", ipt.Addr)
+		kvs = kvs.Add("event_name", event, false, false)
+		kvs = kvs.Add("occur_time", startTime, false, false)
+		kvs = kvs.Add("cost_time", cost, false, false)
+		kvs = kvs.Add("message", event+" cost time "+strconv.FormatInt(cost, 10)+"ms", false, false)
+
+		for k, v := range ipt.mergedTags {
+			kvs = kvs.AddTag(k, v)
+		}
+
+		opts := append(point.DefaultLoggingOptions(), point.WithTime(ts))
+		collectCache = append(collectCache, point.NewPointV2(redisLatency, kvs, opts...))
+	}
+
+	ipt.latencyHistoryLastTime[event] = newLast
+
+	return collectCache
+}
+
 // example data:
 // "latency latest: [[command 1699346177 250 1000] [xxxxx 1699346178 251 1001]...]".
 func (ipt *Input) parseLatencyData(list string) ([]*point.Point, error) {
@@ -6,6 +6,7 @@
 package redis
 
 import (
+	"context"
 	"sort"
 	"strings"
 	"testing"
@@ -121,3 +122,56 @@ func TestInput_parseLatencyData(t *testing.T) {
 		})
 	}
 }
+
+func TestInput_parseLatencyHistory(t *testing.T) {
+	ipt := &Input{
+		Host:                   "localhost",
+		Tags:                   map[string]string{"foo": "bar"},
+		tagger:                 testutils.DefaultMockTagger(),
+		latencyLastTime:        map[string]time.Time{"command": time.Unix(1699346177, 0)},
+		latencyHistoryLastTime: map[string]time.Time{},
+	}
+
+	ipt.setup()
+
+	samples := []interface{}{
+		[]interface{}{int64(1699346170), int64(120)},
+		[]interface{}{int64(1699346175), int64(130)},
+		[]interface{}{int64(1699346177), int64(250)}, // same as LATENCY LATEST
+		[]interface{}{"bad"},
+	}
+
+	got := ipt.parseLatencyHistory("command", samples)
+
+	gotStr := []string{}
+	for _, v := range got {
+		s := v.LineProto()
+		s = s[:strings.LastIndex(s, " ")]
+		gotStr = append(gotStr, s)
+	}
+	sort.Strings(gotStr)
+
+	assert.Equal(t, []string{
+		"redis_latency,foo=bar,host=HOST cost_time=120i,event_name=\"command\",message=\"command cost time 120ms\",occur_time=1699346170i,status=\"unknown\"",
+		"redis_latency,foo=bar,host=HOST cost_time=130i,event_name=\"command\",message=\"command cost time 130ms\",occur_time=1699346175i,status=\"unknown\"",
+	}, gotStr)
+
+	// samples already reported are skipped
+	assert.Empty(t, ipt.parseLatencyHistory("command", samples))
+
+	// samples of new event before the first run are skipped
+	ipt.latencyHistorySince = time.Unix(1699346172, 0)
+	got = ipt.parseLatencyHistory("new-event", samples)
+	assert.Len(t, got, 2)
+	assert.Equal(t, int64(1699346175), got[0].Get("occur_time"))
+	assert.Equal(t, time.Unix(1699346177, 0), ipt.latencyHistoryLastTime["new-event"])
+}
+
+func TestInput_latencyMonitorEnabled(t *testing.T) {
+	// cached result used, the client not touched
+	ipt := &Input{latencyMonitorCheckedAt: time.Now(), latencyMonitorOn: false}
+	assert.False(t, ipt.latencyMonitorEnabled(context.Background()))
+
+	ipt.latencyMonitorOn = true
+	assert.True(t, ipt.latencyMonitorEnabled(context.Background()))
+}
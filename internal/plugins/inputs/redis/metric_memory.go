@@ -0,0 +1,220 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package redis
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/go-redis/redis/v8"
+	"github.com/spf13/cast"
+
+	dkio "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/metrics"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs"
+)
+
+type memoryMeasurement struct{}
+
+// see also: https://redis.io/commands/memory-stats/
+//
+//nolint:lll
+func (m *memoryMeasurement) Info() *inputs.MeasurementInfo {
+	return &inputs.MeasurementInfo{
+		Name: redisMemoryStat,
+		Type: "metric",
+		Desc: "Memory usage breakdown from `MEMORY STATS`, Redis 4.0+.",
+		Fields: map[string]interface{}{
+			"peak_allocated":                &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.SizeByte, Desc: "Peak memory consumed by Redis."},
+			"total_allocated":               &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.SizeByte, Desc: "Total number of bytes allocated by Redis using its allocator."},
+			"startup_allocated":             &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.SizeByte, Desc: "Initial amount of memory consumed by Redis at startup."},
+			"replication_backlog":           &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.SizeByte, Desc: "Size of the replication backlog."},
+			"clients_slaves":                &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.SizeByte, Desc: "Total size of all replicas overheads (output and query buffers, connection contexts)."},
+			"clients_normal":                &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.SizeByte, Desc: "Total size of all clients overheads (output and query buffers, connection contexts)."},
+			"cluster_links":                 &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.SizeByte, Desc: "Memory usage by cluster links. Added in Redis 7.0."},
+			"aof_buffer":                    &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.SizeByte, Desc: "Total size of the current and rewrite AOF buffers."},
+			"lua_caches":                    &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.SizeByte, Desc: "Total size of Lua scripts' caches."},
+			"functions_caches":              &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.SizeByte, Desc: "Total size of Function scripts' caches. Added in Redis 7.0."},
+			"overhead_total":                &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.SizeByte, Desc: "The sum of all overheads."},
+			"keys_count":                    &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.NCount, Desc: "Total number of keys stored across all databases in the server."},
+			"keys_bytes_per_key":            &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.SizeByte, Desc: "The ratio between net memory usage (`total_allocated` minus `startup_allocated`) and `keys_count`."},
+			"dataset_bytes":                 &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.SizeByte, Desc: "The size in bytes of the dataset, i.e. `overhead_total` subtracted from `total_allocated`."},
+			"dataset_percentage":            &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.Percent, Desc: "The percentage of `dataset_bytes` out of the net memory usage."},
+			"peak_percentage":               &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.Percent, Desc: "The percentage of `total_allocated` out of `peak_allocated`."},
+			"allocator_allocated":           &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.SizeByte, Desc: "Total bytes allocated form the allocator."},
+			"allocator_active":              &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.SizeByte, Desc: "Total bytes in the allocator active pages."},
+			"allocator_resident":            &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.SizeByte, Desc: "Total bytes resident (RSS) in the allocator."},
+			"allocator_fragmentation_ratio": &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.NCount, Desc: "Ratio between `allocator_active` and `allocator_allocated`."},
+			"allocator_fragmentation_bytes": &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.SizeByte, Desc: "Delta between `allocator_active` and `allocator_allocated`."},
+			"allocator_rss_ratio":           &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.NCount, Desc: "Ratio between `allocator_resident` and `allocator_active`."},
+			"allocator_rss_bytes":           &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.SizeByte, Desc: "Delta between `allocator_resident` and `allocator_active`."},
+			"rss_overhead_ratio":            &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.NCount, Desc: "Ratio between RSS (from the process) and `allocator_resident`."},
+			"rss_overhead_bytes":            &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.SizeByte, Desc: "Delta between RSS (from the process) and `allocator_resident`."},
+			"fragmentation":                 &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.NCount, Desc: "Ratio between RSS (from the process) and `total_allocated`."},
+			"fragmentation_bytes":           &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.SizeByte, Desc: "Delta between RSS (from the process) and `total_allocated`."},
+			"overhead_hashtable_main":       &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.SizeByte, Desc: "Main dictionary overhead of the database, only collected with tag `db_name`."},
+			"overhead_hashtable_expires":    &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.SizeByte, Desc: "Expires dictionary overhead of the database, only collected with tag `db_name`."},
+		},
+		Tags: map[string]interface{}{
+			"host":         &inputs.TagInfo{Desc: "Hostname."},
+			"server":       &inputs.TagInfo{Desc: "Server addr."},
+			"service_name": &inputs.TagInfo{Desc: "Service name."},
+			"db_name":      &inputs.TagInfo{Desc: "Database name, such as `db0`, only for the per-database overheads."},
+			"node_id":      &inputs.TagInfo{Desc: "Cluster node ID, only collected when `cluster_mode` enabled."},
+			"node_role":    &inputs.TagInfo{Desc: "Cluster node role(`master/slave`), only collected when `cluster_mode` enabled."},
+			"slots":        &inputs.TagInfo{Desc: "Slot ranges served by the cluster node, only collected when `cluster_mode` enabled."},
+		},
+	}
+}
+
+type memoryDoctorMeasurement struct{}
+
+//nolint:lll
+func (m *memoryDoctorMeasurement) Info() *inputs.MeasurementInfo {
+	return &inputs.MeasurementInfo{
+		Name: redisMemoryDoctor,
+		Type: "logging",
+		Desc: "Report of `MEMORY DOCTOR`, only collected when `memory_doctor` enabled.",
+		Fields: map[string]interface{}{
+			"message": &inputs.FieldInfo{DataType: inputs.String, Type: inputs.UnknownType, Unit: inputs.UnknownUnit, Desc: "Report of memory problems."},
+		},
+		Tags: map[string]interface{}{
+			"host":         &inputs.TagInfo{Desc: "Hostname."},
+			"server":       &inputs.TagInfo{Desc: "Server addr."},
+			"service_name": &inputs.TagInfo{Desc: "Service name."},
+		},
+	}
+}
+
+// memoryStatKey convert key of MEMORY STATS to field name, such as
+// `allocator-fragmentation.ratio` to `allocator_fragmentation_ratio`.
+func memoryStatKey(k string) string {
+	return strings.NewReplacer(".", "_", "-", "_").Replace(k)
+}
+
+// parseMemoryStats parse reply of `MEMORY STATS`, which is a flat array of
+// key-value, per-database overheads are nested as `db.N`.
+//
+// example data:
+// 1) "peak.allocated" 2) (integer) 1131456 ... 23) "db.0" 24) 1) "overhead.hashtable.main" 2) (integer) 72 ...
+func (ipt *Input) parseMemoryStats(res []interface{}) []*point.Point {
+	collectCache := []*point.Point{}
+	opts := append(point.DefaultMetricOptions(), point.WithTimestamp(ipt.alignTS))
+
+	var kvs point.KVs
+	for i := 0; i+1 < len(res); i += 2 {
+		key, ok := res[i].(string)
+		if !ok {
+			continue
+		}
+
+		if sub, ok := res[i+1].([]interface{}); ok {
+			if !strings.HasPrefix(key, "db.") {
+				continue
+			}
+
+			var dbKVs point.KVs
+			dbKVs = dbKVs.AddTag("db_name", "db"+strings.TrimPrefix(key, "db."))
+			for j := 0; j+1 < len(sub); j += 2 {
+				subKey, ok := sub[j].(string)
+				if !ok {
+					continue
+				}
+				if f, err := cast.ToInt64E(sub[j+1]); err == nil {
+					dbKVs = dbKVs.Add(memoryStatKey(subKey), f, false, false)
+				}
+			}
+
+			if dbKVs.FieldCount() > 0 {
+				for k, v := range ipt.mergedTags {
+					dbKVs = dbKVs.AddTag(k, v)
+				}
+				collectCache = append(collectCache, point.NewPointV2(redisMemoryStat, dbKVs, opts...))
+			}
+			continue
+		}
+
+		switch v := res[i+1].(type) {
+		case int64:
+			kvs = kvs.Add(memoryStatKey(key), v, false, false)
+		case string: // double reply is a bulk string in RESP2
+			if f, err := cast.ToFloat64E(v); err == nil {
+				kvs = kvs.Add(memoryStatKey(key), f, false, false)
+			}
+		}
+	}
+
+	if kvs.FieldCount() > 0 {
+		for k, v := range ipt.mergedTags {
+			kvs = kvs.AddTag(k, v)
+		}
+		collectCache = append(collectCache, point.NewPointV2(redisMemoryStat, kvs, opts...))
+	}
+
+	return collectCache
+}
+
+func (ipt *Input) memoryStatsOf(ctx context.Context, cli *redis.Client) ([]*point.Point, error) {
+	res, err := cli.Do(ctx, "memory", "stats").Result()
+	if err != nil {
+		// MEMORY STATS not available before Redis 4.0.
+		l.Debugf("memory stats: %s, ignored", err)
+		return nil, nil
+	}
+
+	arr, ok := res.([]interface{})
+	if !ok {
+		return nil, nil
+	}
+
+	return ipt.parseMemoryStats(arr), nil
+}
+
+func (ipt *Input) collectMemoryMeasurement() ([]*point.Point, error) {
+	ctx := context.Background()
+
+	if ipt.MemoryDoctor {
+		ipt.collectMemoryDoctor(ctx)
+	}
+
+	if ipt.ClusterMode {
+		return ipt.collectFromClusterNodes(func(n *clusterNode) ([]*point.Point, error) {
+			return ipt.memoryStatsOf(ctx, n.cli)
+		})
+	}
+
+	return ipt.memoryStatsOf(ctx, ipt.client)
+}
+
+func (ipt *Input) collectMemoryDoctor(ctx context.Context) {
+	report, err := ipt.client.Do(ctx, "memory", "doctor").Text()
+	if err != nil {
+		l.Debugf("memory doctor: %s, ignored", err)
+		return
+	}
+
+	var kvs point.KVs
+	kvs = kvs.Add("message", report, false, false)
+	for k, v := range ipt.mergedTags {
+		kvs = kvs.AddTag(k, v)
+	}
+
+	pts := []*point.Point{
+		point.NewPointV2(redisMemoryDoctor, kvs, append(point.DefaultLoggingOptions(), point.WithTime(time.Now()))...),
+	}
+
+	if err := ipt.feeder.FeedV2(point.Logging, pts,
+		dkio.WithElection(ipt.Election),
+		dkio.WithInputName(redisMemoryDoctor)); err != nil {
+		ipt.feeder.FeedLastError(err.Error(),
+			metrics.WithLastErrorInput(inputName),
+			metrics.WithLastErrorCategory(point.Logging),
+		)
+		l.Errorf("feed memory doctor: %s", err)
+	}
+}
@@ -0,0 +1,56 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package redis
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/testutils"
+)
+
+func TestInput_parseMemoryStats(t *testing.T) {
+	ipt := &Input{
+		Host:   "localhost",
+		Tags:   map[string]string{"foo": "bar"},
+		tagger: testutils.DefaultMockTagger(),
+	}
+	ipt.setup()
+
+	res := []interface{}{
+		"peak.allocated", int64(1131456),
+		"total.allocated", int64(1086408),
+		"replication.backlog", int64(0),
+		"clients.slaves", int64(0),
+		"clients.normal", int64(1800),
+		"db.0", []interface{}{
+			"overhead.hashtable.main", int64(72),
+			"overhead.hashtable.expires", int64(0),
+		},
+		"keys.count", int64(3),
+		"dataset.percentage", "99.11",
+		"allocator-fragmentation.ratio", "1.2",
+		"allocator-fragmentation.bytes", int64(341824),
+		"unknown.nested", []interface{}{"a", int64(1)},
+	}
+
+	pts := ipt.parseMemoryStats(res)
+
+	got := []string{}
+	for _, pt := range pts {
+		s := pt.LineProto()
+		got = append(got, s[:strings.LastIndex(s, " ")])
+	}
+	sort.Strings(got)
+
+	assert.Equal(t, []string{
+		"redis_memory_stat,db_name=db0,foo=bar,host=HOST overhead_hashtable_expires=0i,overhead_hashtable_main=72i",
+		"redis_memory_stat,foo=bar,host=HOST allocator_fragmentation_bytes=341824i,allocator_fragmentation_ratio=1.2,clients_normal=1800i,clients_slaves=0i,dataset_percentage=99.11,keys_count=3i,peak_allocated=1131456i,replication_backlog=0i,total_allocated=1086408i",
+	}, got)
+}
@@ -0,0 +1,187 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package redis
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/go-redis/redis/v8"
+
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs"
+)
+
+const (
+	persistenceEventRDBBgsave  = "rdb_bgsave"
+	persistenceEventAOFWrite   = "aof_write"
+	persistenceEventRDBSaveAge = "rdb_save_age"
+)
+
+type persistenceMeasurement struct{}
+
+//nolint:lll
+func (m *persistenceMeasurement) Info() *inputs.MeasurementInfo {
+	return &inputs.MeasurementInfo{
+		Name: redisPersistence,
+		Type: "keyevent",
+		Desc: "Key events on RDB/AOF persistence failure and recovery, from INFO PERSISTENCE.",
+		Fields: map[string]interface{}{
+			"df_title":          &inputs.FieldInfo{DataType: inputs.String, Type: inputs.UnknownType, Unit: inputs.UnknownUnit, Desc: "Event title."},
+			"df_message":        &inputs.FieldInfo{DataType: inputs.String, Type: inputs.UnknownType, Unit: inputs.UnknownUnit, Desc: "Event detail."},
+			"df_status":         &inputs.FieldInfo{DataType: inputs.String, Type: inputs.UnknownType, Unit: inputs.UnknownUnit, Desc: "Event status, `error/warning` on failure and `ok` on recovery."},
+			"rdb_last_save_age": &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.DurationSecond, Desc: "Seconds since the last successful RDB save."},
+			"rdb_changes":       &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Unit: inputs.NCount, Desc: "Number of changes since the last RDB save."},
+		},
+		Tags: map[string]interface{}{
+			"host":         &inputs.TagInfo{Desc: "Hostname."},
+			"server":       &inputs.TagInfo{Desc: "Server addr."},
+			"service_name": &inputs.TagInfo{Desc: "Service name."},
+			"event":        &inputs.TagInfo{Desc: "Event type, `rdb_bgsave/aof_write/rdb_save_age`."},
+		},
+	}
+}
+
+// persistenceState is the last persistence health of a redis server,
+// events are emitted only when the state changed.
+type persistenceState struct {
+	rdbErr, aofErr, rdbStale bool
+}
+
+// parsePersistence parse output of `INFO PERSISTENCE` to key-values.
+func parsePersistence(info string) map[string]string {
+	res := map[string]string{}
+
+	scanner := bufio.NewScanner(strings.NewReader(info))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		res[parts[0]] = strings.TrimSpace(parts[1])
+	}
+
+	return res
+}
+
+func (ipt *Input) collectPersistenceEvents() ([]*point.Point, error) {
+	ctx := context.Background()
+
+	if ipt.ClusterMode {
+		var res []*point.Point
+		for _, n := range ipt.clusterNodes {
+			pts, err := ipt.persistenceEventsOf(ctx, n.cli, n.addr)
+			if err != nil {
+				return res, err
+			}
+			res = append(res, pts...)
+		}
+		return res, nil
+	}
+
+	return ipt.persistenceEventsOf(ctx, ipt.client, ipt.Addr)
+}
+
+func (ipt *Input) persistenceEventsOf(ctx context.Context, cli *redis.Client, server string) ([]*point.Point, error) {
+	info, err := cli.Info(ctx, "persistence").Result()
+	if err != nil {
+		return nil, fmt.Errorf("info persistence of %s: %w", server, err)
+	}
+
+	return ipt.buildPersistenceEvents(server, parsePersistence(info), time.Now()), nil
+}
+
+// buildPersistenceEvents compare persistence status with last collect, and build
+// key events when RDB/AOF failed, recovered or the last RDB save is out of date.
+func (ipt *Input) buildPersistenceEvents(server string, info map[string]string, now time.Time) []*point.Point {
+	last, ok := ipt.persistenceStates[server]
+	if !ok {
+		last = &persistenceState{}
+		ipt.persistenceStates[server] = last
+	}
+
+	var pts []*point.Point
+
+	if status, ok := info["rdb_last_bgsave_status"]; ok {
+		isErr := status == "err"
+		if isErr != last.rdbErr {
+			pts = append(pts, ipt.newPersistenceEvent(server, persistenceEventRDBBgsave, isErr, "error",
+				"Redis RDB background save failed",
+				fmt.Sprintf("rdb_last_bgsave_status of %s is %s", server, status), nil))
+		}
+		last.rdbErr = isErr
+	}
+
+	// aof_last_write_status is always ok when AOF disabled.
+	if status, ok := info["aof_last_write_status"]; ok {
+		isErr := status == "err"
+		if isErr != last.aofErr {
+			pts = append(pts, ipt.newPersistenceEvent(server, persistenceEventAOFWrite, isErr, "error",
+				"Redis AOF write failed",
+				fmt.Sprintf("aof_last_write_status of %s is %s", server, status), nil))
+		}
+		last.aofErr = isErr
+	}
+
+	if ipt.RDBSaveAgeThreshold > 0 {
+		saveTime, err1 := strconv.ParseInt(info["rdb_last_save_time"], 10, 64)
+		changes, err2 := strconv.ParseInt(info["rdb_changes_since_last_save"], 10, 64)
+		if err1 == nil && err2 == nil {
+			age := now.Unix() - saveTime
+			// no need to save if nothing changed.
+			isStale := changes > 0 && age > int64(ipt.RDBSaveAgeThreshold/time.Second)
+			if isStale != last.rdbStale {
+				pts = append(pts, ipt.newPersistenceEvent(server, persistenceEventRDBSaveAge, isStale, "warning",
+					"Redis RDB save out of date",
+					fmt.Sprintf("last successful RDB save of %s is %ds ago with %d changes, threshold %s",
+						server, age, changes, ipt.RDBSaveAgeThreshold),
+					map[string]int64{"rdb_last_save_age": age, "rdb_changes": changes}))
+			}
+			last.rdbStale = isStale
+		}
+	}
+
+	return pts
+}
+
+func (ipt *Input) newPersistenceEvent(server, event string, failed bool, status, title, message string,
+	fields map[string]int64,
+) *point.Point {
+	if !failed {
+		status = "ok"
+		title = "[Recovered] " + title
+	}
+
+	var kvs point.KVs
+	kvs = kvs.AddTag("server", server).
+		AddTag("event", event).
+		Add("df_title", title, false, false).
+		Add("df_message", message, false, false).
+		Add("df_status", status, false, false)
+
+	for k, v := range fields {
+		kvs = kvs.Add(k, v, false, false)
+	}
+
+	for k, v := range ipt.mergedTags {
+		if k == "server" { // cluster node may differ from the configured one
+			continue
+		}
+		kvs = kvs.AddTag(k, v)
+	}
+
+	return point.NewPointV2(redisPersistence, kvs,
+		append(point.DefaultLoggingOptions(), point.WithTimestamp(ipt.alignTS))...)
+}
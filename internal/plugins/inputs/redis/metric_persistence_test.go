@@ -0,0 +1,99 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package redis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/testutils"
+)
+
+func TestInput_buildPersistenceEvents(t *testing.T) {
+	ipt := defaultInput()
+	ipt.Host = "localhost"
+	ipt.Tags = map[string]string{"foo": "bar"}
+	ipt.Election = false
+	ipt.tagger = testutils.DefaultMockTagger()
+	ipt.RDBSaveAgeThreshold = time.Hour
+	ipt.setup()
+
+	now := time.Unix(1698651554, 0)
+
+	ok := map[string]string{
+		"rdb_last_bgsave_status":      "ok",
+		"aof_last_write_status":       "ok",
+		"rdb_last_save_time":          "1698651000",
+		"rdb_changes_since_last_save": "10",
+	}
+
+	// healthy on first collect, no event.
+	pts := ipt.buildPersistenceEvents("localhost:6379", ok, now)
+	assert.Len(t, pts, 0)
+
+	// RDB bgsave failed.
+	failed := map[string]string{
+		"rdb_last_bgsave_status":      "err",
+		"aof_last_write_status":       "ok",
+		"rdb_last_save_time":          "1698651000",
+		"rdb_changes_since_last_save": "10",
+	}
+	pts = ipt.buildPersistenceEvents("localhost:6379", failed, now)
+	if assert.Len(t, pts, 1) {
+		assert.Equal(t, persistenceEventRDBBgsave, pts[0].GetTag("event"))
+		assert.Equal(t, "error", pts[0].Get("df_status"))
+		assert.Equal(t, "bar", pts[0].GetTag("foo"))
+		assert.Equal(t, "localhost:6379", pts[0].GetTag("server"))
+	}
+
+	// still failed, no duplicated event.
+	pts = ipt.buildPersistenceEvents("localhost:6379", failed, now)
+	assert.Len(t, pts, 0)
+
+	// recovered, but last save is out of date.
+	pts = ipt.buildPersistenceEvents("localhost:6379", ok, now.Add(2*time.Hour))
+	if assert.Len(t, pts, 2) {
+		assert.Equal(t, persistenceEventRDBBgsave, pts[0].GetTag("event"))
+		assert.Equal(t, "ok", pts[0].Get("df_status"))
+
+		assert.Equal(t, persistenceEventRDBSaveAge, pts[1].GetTag("event"))
+		assert.Equal(t, "warning", pts[1].Get("df_status"))
+		assert.Equal(t, int64(2*3600+554), pts[1].Get("rdb_last_save_age"))
+	}
+
+	// nothing changed since last save, no need to save.
+	pts = ipt.buildPersistenceEvents("localhost:6379", map[string]string{
+		"rdb_last_save_time":          "1698651000",
+		"rdb_changes_since_last_save": "0",
+	}, now.Add(3*time.Hour))
+	if assert.Len(t, pts, 1) {
+		assert.Equal(t, persistenceEventRDBSaveAge, pts[0].GetTag("event"))
+		assert.Equal(t, "ok", pts[0].Get("df_status"))
+	}
+
+	// AOF failed on another server.
+	pts = ipt.buildPersistenceEvents("localhost:6380", map[string]string{"aof_last_write_status": "err"}, now)
+	if assert.Len(t, pts, 1) {
+		assert.Equal(t, persistenceEventAOFWrite, pts[0].GetTag("event"))
+		assert.Equal(t, "localhost:6380", pts[0].GetTag("server"))
+	}
+}
+
+func TestParsePersistence(t *testing.T) {
+	res := parsePersistence(`# Persistence
+loading:0
+rdb_changes_since_last_save:0
+rdb_last_save_time:1698651554
+rdb_last_bgsave_status:ok
+aof_last_write_status:err
+`)
+
+	assert.Equal(t, "ok", res["rdb_last_bgsave_status"])
+	assert.Equal(t, "err", res["aof_last_write_status"])
+	assert.Equal(t, "1698651554", res["rdb_last_save_time"])
+	assert.NotContains(t, res, "# Persistence")
+}
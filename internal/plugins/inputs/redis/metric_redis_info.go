@@ -308,6 +308,9 @@ func (m *infoMeasurement) Info() *inputs.MeasurementInfo {
 			"maxmemory_policy": &inputs.TagInfo{Desc: "The value of the maxmemory-policy configuration directive."},
 			"run_id":           &inputs.TagInfo{Desc: "Random value identifying the Redis server (to be used by Sentinel and Cluster)."},
 			"process_id":       &inputs.TagInfo{Desc: "Process ID of the Redis server."},
+			"node_id":          &inputs.TagInfo{Desc: "Cluster node ID, only collected when `cluster_mode` enabled."},
+			"node_role":        &inputs.TagInfo{Desc: "Cluster node role(`master/slave`), only collected when `cluster_mode` enabled."},
+			"slots":            &inputs.TagInfo{Desc: "Slot ranges served by the cluster node, only collected when `cluster_mode` enabled."},
 		},
 	}
 }
@@ -13,6 +13,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/GuanceCloud/cliutils/point"
 
@@ -33,6 +34,8 @@ func (m *replicaMeasurement) Info() *inputs.MeasurementInfo {
 			"master_link_status":             &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Desc: "Status of the link (up/down), `1` for up, `0` for down, only collected for slave redis."},
 			"slave_offset":                   &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Desc: "Slave offset, only collected for master redis."},
 			"slave_lag":                      &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Gauge, Desc: "Slave lag, only collected for master redis."},
+			"repl_lag_bytes":                 &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.SizeByte, Desc: "Replication lag in bytes(`master_repl_offset - slave_offset`), only collected for master redis."},
+			"repl_lag_seconds":               &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.DurationSecond, Desc: "Replication lag in seconds. For master redis, it's estimated by `repl_lag_bytes` and the growth rate of `master_repl_offset`, for slave redis, it's `master_link_down_since_seconds` when the link is down."},
 		},
 		Tags: map[string]interface{}{
 			"host":         &inputs.TagInfo{Desc: "Hostname."},
@@ -96,12 +99,50 @@ var slaveMatch = regexp.MustCompile(`^slave\d+`)
 // repl_backlog_first_byte_offset:0
 // repl_backlog_histlen:0
 
+// replOffset is the master_repl_offset of last collect, used to
+// calculate growth rate of replication offset.
+type replOffset struct {
+	offset float64
+	ts     int64 // unix nano
+}
+
+// replOffsetRate returns growth rate(bytes/second) of master_repl_offset since last collect.
+func (ipt *Input) replOffsetRate(offset float64) (rate float64, ok bool) {
+	last := ipt.lastReplOffset
+	ipt.lastReplOffset = &replOffset{offset: offset, ts: ipt.alignTS}
+
+	if last == nil || ipt.alignTS <= last.ts || offset < last.offset {
+		return 0, false
+	}
+
+	return (offset - last.offset) / (float64(ipt.alignTS-last.ts) / float64(time.Second)), true
+}
+
+func findMasterReplOffset(list string) (float64, bool) {
+	scanner := bufio.NewScanner(strings.NewReader(list))
+	for scanner.Scan() {
+		const prefix = "master_repl_offset:"
+		if line := scanner.Text(); strings.HasPrefix(line, prefix) {
+			f, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimPrefix(line, prefix)), 64)
+			return f, err == nil
+		}
+	}
+	return 0, false
+}
+
 func (ipt *Input) parseReplicaData(list string) ([]*point.Point, error) {
 	collectCache := []*point.Point{}
 	opts := point.DefaultMetricOptions()
 	opts = append(opts, point.WithTimestamp(ipt.alignTS))
 	var masterIP, masterPort string
 
+	masterOffset, hasMasterOffset := findMasterReplOffset(list)
+	var offsetRate float64
+	var hasRate bool
+	if hasMasterOffset {
+		offsetRate, hasRate = ipt.replOffsetRate(masterOffset)
+	}
+
 	rdr := strings.NewReader(list)
 	scanner := bufio.NewScanner(rdr)
 	for scanner.Scan() {
@@ -146,6 +187,11 @@ func (ipt *Input) parseReplicaData(list string) ([]*point.Point, error) {
 					continue
 				}
 				kvs = kvs.Add(key, float, false, false)
+
+				// slave redis, link down and lag at least such seconds
+				if key == "master_link_down_since_seconds" && float >= 0 {
+					kvs = kvs.Add("repl_lag_seconds", float, false, false)
+				}
 			}
 		}
 		// special key for slave data
@@ -157,6 +203,21 @@ func (ipt *Input) parseReplicaData(list string) ([]*point.Point, error) {
 			kvs = kvs.AddTag("slave_state", state)
 			kvs = kvs.Add("slave_offset", offset, false, false)
 			kvs = kvs.Add("slave_lag", lag, false, false)
+
+			if hasMasterOffset {
+				lagBytes := masterOffset - offset
+				if lagBytes < 0 {
+					lagBytes = 0
+				}
+				kvs = kvs.Add("repl_lag_bytes", lagBytes, false, false)
+
+				switch {
+				case lagBytes == 0:
+					kvs = kvs.Add("repl_lag_seconds", float64(0), false, false)
+				case hasRate && offsetRate > 0:
+					kvs = kvs.Add("repl_lag_seconds", lagBytes/offsetRate, false, false)
+				}
+			}
 		}
 
 		if masterIP != "" && masterPort != "" {
@@ -9,6 +9,7 @@ import (
 	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -46,8 +47,8 @@ func TestInput_parseReplicData(t *testing.T) {
 			},
 			want: []string{
 				"redis_replica,foo=bar,host=HOST master_repl_offset=4056",
-				"redis_replica,foo=bar,host=HOST,slave_addr=127.0.0.1:6379,slave_id=1,slave_state=online slave_lag=0,slave_offset=4046",
-				"redis_replica,foo=bar,host=HOST,slave_addr=127.0.0.1:6380,slave_id=0,slave_state=online slave_lag=0,slave_offset=4046",
+				"redis_replica,foo=bar,host=HOST,slave_addr=127.0.0.1:6379,slave_id=1,slave_state=online repl_lag_bytes=10,slave_lag=0,slave_offset=4046",
+				"redis_replica,foo=bar,host=HOST,slave_addr=127.0.0.1:6380,slave_id=0,slave_state=online repl_lag_bytes=10,slave_lag=0,slave_offset=4046",
 			},
 			wantErr: false,
 		},
@@ -64,8 +65,8 @@ func TestInput_parseReplicData(t *testing.T) {
 			},
 			want: []string{
 				"redis_replica,election=TRUE,foo=bar master_repl_offset=4056",
-				"redis_replica,election=TRUE,foo=bar,slave_addr=127.0.0.1:6379,slave_id=1,slave_state=online slave_lag=0,slave_offset=4046",
-				"redis_replica,election=TRUE,foo=bar,slave_addr=127.0.0.1:6380,slave_id=0,slave_state=online slave_lag=0,slave_offset=4046",
+				"redis_replica,election=TRUE,foo=bar,slave_addr=127.0.0.1:6379,slave_id=1,slave_state=online repl_lag_bytes=10,slave_lag=0,slave_offset=4046",
+				"redis_replica,election=TRUE,foo=bar,slave_addr=127.0.0.1:6380,slave_id=0,slave_state=online repl_lag_bytes=10,slave_lag=0,slave_offset=4046",
 			},
 
 			wantErr: false,
@@ -149,3 +150,52 @@ repl_backlog_size:1048576
 repl_backlog_first_byte_offset:0
 repl_backlog_histlen:0
 `
+
+func TestInput_parseReplicaLag(t *testing.T) {
+	ipt := &Input{
+		Host:   "localhost",
+		Tags:   map[string]string{"foo": "bar"},
+		tagger: testutils.DefaultMockTagger(),
+	}
+	ipt.setup()
+
+	ipt.alignTS = 10 * int64(time.Second)
+	_, err := ipt.parseReplicaData(strings.ReplaceAll(mockReplicaData01, "master_repl_offset:4056", "master_repl_offset:4036"))
+	assert.NoError(t, err)
+
+	// master_repl_offset grows 20 bytes in 2 seconds, slaves lag 10 bytes.
+	ipt.alignTS = 12 * int64(time.Second)
+	pts, err := ipt.parseReplicaData(mockReplicaData01)
+	assert.NoError(t, err)
+
+	n := 0
+	for _, pt := range pts {
+		if pt.GetTag("slave_id") == "" {
+			continue
+		}
+		n++
+		assert.Equal(t, float64(10), pt.Get("repl_lag_bytes"))
+		assert.Equal(t, float64(1), pt.Get("repl_lag_seconds"))
+	}
+	assert.Equal(t, 2, n)
+
+	// slave redis with link down
+	pts, err = ipt.parseReplicaData(`# Replication
+role:slave
+master_host:127.0.0.1
+master_port:6380
+master_link_status:down
+master_link_down_since_seconds:30
+master_repl_offset:0
+`)
+	assert.NoError(t, err)
+
+	found := false
+	for _, pt := range pts {
+		if v := pt.Get("repl_lag_seconds"); v != nil {
+			found = true
+			assert.Equal(t, float64(30), v)
+		}
+	}
+	assert.True(t, found)
+}
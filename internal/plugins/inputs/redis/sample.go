@@ -11,10 +11,22 @@ const (
   host = "localhost"
   port = 6379
 
+  ## Collect from multiple hosts with the same config, host and port above are ignored if set.
+  ## Port of the input used if not specified in host.
+  ## Extra tags of each host can be set in [inputs.redis.host_tags].
+  # hosts = ["r1:6379", "r2:6379"]
+
   ## TLS connection config, redis-cli version must up 6.0+
   ## These tls configuration files should be the same as the ones used on the server. 
   ## See also: https://redis.io/docs/latest/operate/oss_and_stack/management/security/encryption/
-  # insecure_skip_verify = true
+  ## TLS is enabled if any of tls_ca/tls_cert/insecure_skip_verify set, for managed Redis
+  ## (Azure Cache for Redis, AWS ElastiCache in-transit encryption) setting tls_ca is enough.
+  # tls_ca = "/opt/tls/ca.crt"
+  ## tls_cert and tls_key are required for mutual-TLS.
+  # tls_cert = "/opt/tls/redis.crt"
+  # tls_key = "/opt/tls/redis.key"
+  # insecure_skip_verify = false
+  ## Or use the TLS config below.
   # ca_certs = ["/opt/tls/ca.crt"]
   # cert = "/opt/tls/redis.crt"
   # cert_key = "/opt/tls/redis.key"
@@ -52,6 +64,20 @@ const (
   ## If you collet bigkey, set this to true
   # bigkey = false
 
+  ## Sampled big key scan without redis-cli, use rate-limited SCAN + TYPE + MEMORY USAGE
+  ## to report top-N biggest keys for each key prefix. The db_name tag of these points
+  ## is like db0(same as redis_db), while it's 0 for the bigkey above.
+  # [inputs.redis.bigkey_scan]
+  #   enable = false
+  #   ## Max keys sampled in each db per scan.
+  #   sample_keys = 10000
+  #   ## Report the biggest top_n keys of each key prefix.
+  #   top_n = 10
+  #   ## Key prefix is the part before the first prefix_separator.
+  #   prefix_separator = ":"
+  #   ## Rate limit of keys sampled per second.
+  #   keys_per_second = 1000
+
   ## @param key_interval - number - optional - default: 5m
   ## Interval of collet hotkey & bigkey
   # key_interval = "5m"
@@ -91,10 +117,30 @@ const (
   ## Collect INFO COMMANDSTATS output as metrics.
   # command_stats = false
 
+  ## @param command_stats_delta - boolean - optional - default: false
+  ## Besides the cumulative counters, add per-interval deltas(calls_delta/usec_delta/...) and
+  ## calls_per_sec to redis_command_stat, computed against the previous collect.
+  # command_stats_delta = false
+
   ## @param latency_percentiles - boolean - optional - default: false
   ## Collect INFO LATENCYSTATS output as metrics.
   # latency_percentiles = false
 
+  ## @param memory_doctor - boolean - optional - default: false
+  ## Collect report of MEMORY DOCTOR as logging redis_memory_doctor. MEMORY STATS is always collected.
+  # memory_doctor = false
+
+  ## @param cluster_mode - boolean - optional - default: false
+  ## Discover all nodes of Redis Cluster via CLUSTER NODES/SLOTS, and collect INFO and
+  ## INFO COMMANDSTATS from every node. Points are tagged with node_id, node_role and slots.
+  ## Redis Cluster only supports db 0, the db configured is ignored.
+  # cluster_mode = false
+
+  ## @param rdb_save_age_threshold - duration - optional - default: 0s
+  ## Emit a key event when the last successful RDB save is older than the threshold
+  ## and there are unsaved changes. Failures of RDB bgsave/AOF write always emit key events.
+  # rdb_save_age_threshold = "24h"
+
   ## Set true to enable election
   election = true
 
@@ -116,6 +162,11 @@ const (
   ## regexp link: https://golang.org/pkg/regexp/syntax/#hdr-Syntax
   #match = '''^\S.*'''
 
+  ## Extra tags of each host in hosts.
+  # [inputs.redis.host_tags]
+  #   "r1:6379" = { zone = "zone-a" }
+  #   "r2:6379" = { zone = "zone-b" }
+
   [inputs.redis.tags]
   # some_tag = "some_value"
   # more_tag = "some_other_value"
@@ -30,7 +30,13 @@ import (
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/strarr"
 )
 
-const intelVID = "0x8086"
+const (
+	intelVID = "0x8086"
+
+	// maxConcurrentDevices limit the concurrent smartctl/nvme commands on the
+	// hosts with lots of devices.
+	maxConcurrentDevices = 8
+)
 
 var (
 	defSmartCmd     = "smartctl"
@@ -277,7 +283,12 @@ func (ipt *Input) getCustomerTags() map[string]string {
 func (ipt *Input) getAttributes(devices []string) error {
 	start := time.Now()
 
-	g := goroutine.NewGroup(goroutine.Option{Name: "inputs_smart"})
+	g := goroutine.NewGroup(goroutine.Option{
+		Name:           "inputs_smart",
+		MaxConcurrent:  maxConcurrentDevices,
+		MaxQueued:      len(devices),
+		StuckThreshold: ipt.Interval.Duration,
+	})
 	for _, device := range devices {
 		func(device string) {
 			g.Go(func(ctx context.Context) error {
@@ -310,7 +321,12 @@ func (ipt *Input) getVendorNVMeAttributes(devices []string) error {
 	start := time.Now()
 	nvmeDevices := getDeviceInfoForNVMeDisks(devices, ipt.NvmePath, ipt.Timeout.Duration, ipt.UseSudo)
 
-	g := goroutine.NewGroup(goroutine.Option{Name: "inputs_smart"})
+	g := goroutine.NewGroup(goroutine.Option{
+		Name:           "inputs_smart",
+		MaxConcurrent:  maxConcurrentDevices,
+		MaxQueued:      len(nvmeDevices),
+		StuckThreshold: ipt.Interval.Duration,
+	})
 	for _, device := range nvmeDevices {
 		if strarr.Contains(ipt.EnableExtensions, "auto-on") {
 			if device.vendorID == intelVID {
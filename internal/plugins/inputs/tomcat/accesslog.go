@@ -0,0 +1,227 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package tomcat
+
+import (
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/datakit"
+	dkio "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/statsd"
+)
+
+const (
+	TomcatAccess = "tomcat_access"
+
+	unknownVhost = "unknown"
+)
+
+type TomcatAccessM struct{}
+
+//nolint:lll
+func (m *TomcatAccessM) Info() *inputs.MeasurementInfo {
+	return &inputs.MeasurementInfo{
+		Name: TomcatAccess,
+		Type: "metric",
+		Desc: "Request count and latency aggregated from access log, enabled by `access_log_metrics` of `[inputs.tomcat.log]`.",
+		Fields: map[string]interface{}{
+			"request_count": newFielInfoCount("Number of requests in the interval."),
+			"bytes_sent":    &inputs.FieldInfo{DataType: inputs.Int, Type: inputs.Count, Unit: inputs.SizeByte, Desc: "Bytes sent in the interval."},
+			"latency_avg":   &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.DurationMS, Desc: "Average latency of requests, only if `%D` in access log pattern."},
+			"latency_p50":   &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.DurationMS, Desc: "P50 latency of requests, only if `%D` in access log pattern."},
+			"latency_p90":   &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.DurationMS, Desc: "P90 latency of requests, only if `%D` in access log pattern."},
+			"latency_p99":   &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.DurationMS, Desc: "P99 latency of requests, only if `%D` in access log pattern."},
+			"latency_max":   &inputs.FieldInfo{DataType: inputs.Float, Type: inputs.Gauge, Unit: inputs.DurationMS, Desc: "Max latency of requests, only if `%D` in access log pattern."},
+		},
+		Tags: map[string]interface{}{
+			"vhost":        inputs.NewTagInfo("Virtual host, from `%v` of access log pattern or prefix of the log file name."),
+			"status_class": inputs.NewTagInfo("Class of HTTP status code, `1xx/2xx/3xx/4xx/5xx`."),
+			"host":         inputs.NewTagInfo("System hostname."),
+		},
+	}
+}
+
+// accessLogRe match access log of pattern `%h %l %u %t "%r" %s %b`(the
+// default `common` pattern), `%D` and `%v` may be appended.
+var accessLogRe = regexp.MustCompile(`^\S+ \S+ \S+ \[[^\]]+\] "[^"]*" (\d{3}) (\S+)(?: (\d+))?(?: (\S+))?`)
+
+type accessKey struct {
+	vhost       string
+	statusClass string
+	pathTags    string // encoded tags from log file path
+}
+
+type accessStat struct {
+	tags    map[string]string
+	count   int64
+	bytes   int64
+	latency *statsd.RunningStats
+}
+
+// accessAggregator aggregate access log of all files between 2 flushes.
+type accessAggregator struct {
+	mu          sync.Mutex
+	stats       map[accessKey]*accessStat
+	latencyUnit time.Duration
+}
+
+func newAccessAggregator(latencyUnit string) *accessAggregator {
+	unit := time.Millisecond
+	if latencyUnit == "us" { // %D of Tomcat 10 is in microseconds
+		unit = time.Microsecond
+	}
+
+	return &accessAggregator{
+		stats:       map[accessKey]*accessStat{},
+		latencyUnit: unit,
+	}
+}
+
+// vhostOfFile get vhost from log file name like `localhost_access_log.2024-01-01.txt`.
+func vhostOfFile(file string) string {
+	base := filepath.Base(file)
+	if idx := strings.Index(base, "_access_log"); idx > 0 {
+		return base[:idx]
+	}
+	return unknownVhost
+}
+
+// add parse the access log line and aggregate it, false returned if the
+// line is not an access log. Lines with different tags of file path are
+// aggregated separately.
+func (a *accessAggregator) add(file, line string, pathTags map[string]string) bool {
+	m := accessLogRe.FindStringSubmatch(line)
+	if m == nil {
+		return false
+	}
+
+	key := accessKey{vhost: m[4], statusClass: m[1][:1] + "xx", pathTags: tagsKey(pathTags)}
+	if key.vhost == "" || key.vhost == "-" {
+		key.vhost = vhostOfFile(file)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	st, ok := a.stats[key]
+	if !ok {
+		st = &accessStat{tags: pathTags}
+		a.stats[key] = st
+	}
+
+	st.count++
+	if n, err := strconv.ParseInt(m[2], 10, 64); err == nil { // `-` if nothing sent
+		st.bytes += n
+	}
+
+	if m[3] != "" {
+		if n, err := strconv.ParseInt(m[3], 10, 64); err == nil {
+			if st.latency == nil {
+				st.latency = &statsd.RunningStats{}
+			}
+			st.latency.AddValue(float64(time.Duration(n)*a.latencyUnit) / float64(time.Millisecond))
+		}
+	}
+
+	return true
+}
+
+// flush build points of aggregated access log and reset the aggregator.
+func (a *accessAggregator) flush(tags map[string]string, ptTS int64) []*point.Point {
+	a.mu.Lock()
+	stats := a.stats
+	a.stats = map[accessKey]*accessStat{}
+	a.mu.Unlock()
+
+	keys := make([]accessKey, 0, len(stats))
+	for k := range stats {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].vhost != keys[j].vhost {
+			return keys[i].vhost < keys[j].vhost
+		}
+		if keys[i].pathTags != keys[j].pathTags {
+			return keys[i].pathTags < keys[j].pathTags
+		}
+		return keys[i].statusClass < keys[j].statusClass
+	})
+
+	opts := append(point.DefaultMetricOptions(), point.WithTimestamp(ptTS))
+	pts := make([]*point.Point, 0, len(keys))
+
+	for _, k := range keys {
+		st := stats[k]
+
+		var kvs point.KVs
+		kvs = kvs.AddTag("vhost", k.vhost).
+			AddTag("status_class", k.statusClass).
+			Add("request_count", st.count, false, false).
+			Add("bytes_sent", st.bytes, false, false)
+
+		if st.latency != nil {
+			kvs = kvs.Add("latency_avg", st.latency.Mean(), false, false).
+				Add("latency_p50", st.latency.Percentile(50), false, false).
+				Add("latency_p90", st.latency.Percentile(90), false, false).
+				Add("latency_p99", st.latency.Percentile(99), false, false).
+				Add("latency_max", st.latency.Upper(), false, false)
+		}
+
+		for tk, tv := range st.tags {
+			kvs = kvs.AddTag(tk, tv)
+		}
+
+		for tk, tv := range tags {
+			kvs = kvs.AddTag(tk, tv)
+		}
+
+		pts = append(pts, point.NewPointV2(TomcatAccess, kvs, opts...))
+	}
+
+	return pts
+}
+
+func (ipt *Input) runAccessLogMetrics() {
+	interval, err := time.ParseDuration(ipt.Interval)
+	if err != nil {
+		interval = defaultInterval
+	}
+
+	tags := inputs.MergeTags(ipt.Tagger.HostTags(), ipt.Tags, "")
+
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+
+	start := time.Now()
+	for {
+		select {
+		case <-datakit.Exit.Wait():
+			return
+
+		case <-ipt.SemStop.Wait():
+			return
+
+		case tt := <-tick.C:
+			start = time.UnixMilli(inputs.AlignTimeMillSec(tt, start.UnixMilli(), interval.Milliseconds()))
+
+			if pts := ipt.accessAgg.flush(tags, start.UnixNano()); len(pts) > 0 {
+				if err := ipt.Feeder.FeedV2(point.Metric, pts,
+					dkio.WithInputName(inputName+"/access_log")); err != nil {
+					l.Errorf("feed access log metrics: %s", err)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,83 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package tomcat
+
+import (
+	"testing"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	dkio "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io"
+)
+
+func TestAccessAggregator(t *testing.T) {
+	agg := newAccessAggregator("")
+
+	lines := []struct {
+		file, line string
+		ok         bool
+	}{
+		{"/logs/localhost_access_log.2024-01-01.txt", `127.0.0.1 - - [24/Feb/2015:15:57:10 +0530] "GET / HTTP/1.1" 200 2066`, true},
+		{"/logs/localhost_access_log.2024-01-01.txt", `127.0.0.1 - - [24/Feb/2015:15:57:10 +0530] "GET /a HTTP/1.1" 201 100`, true},
+		{"/logs/localhost_access_log.2024-01-01.txt", `127.0.0.1 - - [24/Feb/2015:15:57:10 +0530] "GET /b HTTP/1.1" 404 -`, true},
+		{"/logs/access.log", `127.0.0.1 - admin [24/Feb/2015:15:57:10 +0530] "GET / HTTP/1.1" 500 10 30 www.example.com`, true},
+		{"/logs/access.log", `127.0.0.1 - admin [24/Feb/2015:15:57:10 +0530] "GET / HTTP/1.1" 500 10 10 www.example.com`, true},
+		{"/logs/catalina.out", `06-Sep-2021 22:33:30.513 INFO [main] org.apache.catalina.startup.VersionLoggerListener.log Command line argument: -Xmx256m`, false},
+	}
+
+	for _, x := range lines {
+		assert.Equal(t, x.ok, agg.add(x.file, x.line, nil), x.line)
+	}
+
+	pts := agg.flush(map[string]string{"host": "HOST"}, 0)
+	require.Len(t, pts, 3)
+
+	// sorted by vhost and status class
+	assert.Equal(t, "localhost", pts[0].Get("vhost"))
+	assert.Equal(t, "2xx", pts[0].Get("status_class"))
+	assert.Equal(t, int64(2), pts[0].Get("request_count"))
+	assert.Equal(t, int64(2166), pts[0].Get("bytes_sent"))
+	assert.Nil(t, pts[0].Get("latency_p50"))
+
+	assert.Equal(t, "4xx", pts[1].Get("status_class"))
+	assert.Equal(t, int64(0), pts[1].Get("bytes_sent"))
+
+	assert.Equal(t, "www.example.com", pts[2].Get("vhost"))
+	assert.Equal(t, "HOST", pts[2].Get("host"))
+	assert.Equal(t, 20.0, pts[2].Get("latency_avg"))
+	assert.Equal(t, 30.0, pts[2].Get("latency_max"))
+	assert.Equal(t, 30.0, pts[2].Get("latency_p99"))
+
+	// reset after flush
+	assert.Len(t, agg.flush(nil, 0), 0)
+}
+
+func TestAccessLogFeeder(t *testing.T) {
+	newLog := func(msg string) *point.Point {
+		var kvs point.KVs
+		kvs = kvs.Add("message", msg, false, false).Add("filepath", "/logs/localhost_access_log.txt", false, false)
+		return point.NewPointV2(inputName, kvs, point.DefaultLoggingOptions()...)
+	}
+
+	feeder := dkio.NewMockedFeeder()
+	f := &logFeeder{Feeder: feeder, agg: newAccessAggregator("us"), drop: true}
+
+	require.NoError(t, f.FeedV2(point.Logging, []*point.Point{
+		newLog(`127.0.0.1 - - [24/Feb/2015:15:57:10 +0530] "GET / HTTP/1.1" 200 2066 1500`),
+		newLog(`06-Sep-2021 22:33:30.513 INFO [main] org.apache.catalina.startup.VersionLoggerListener.log`),
+	}))
+
+	pts, err := feeder.AnyPoints()
+	require.NoError(t, err)
+	require.Len(t, pts, 1)
+	assert.Contains(t, pts[0].Get("message"), "VersionLoggerListener")
+
+	res := f.agg.flush(nil, 0)
+	require.Len(t, res, 1)
+	assert.Equal(t, 1.5, res[0].Get("latency_max"))
+}
@@ -0,0 +1,323 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package tomcat
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/config"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/datakit"
+	dkio "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/metrics"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/jolokia"
+)
+
+const (
+	defaultInterval        = 10 * time.Second
+	defaultResponseTimeout = 5 * time.Second
+)
+
+// exporterRule map metrics of JMX exporter(default format, no rules configured)
+// to Tomcat measurements. In default format, MBean
+// `Catalina:type=ThreadPool,name="http-nio-8080"` attribute `currentThreadsBusy`
+// exported as `Catalina_ThreadPool_currentThreadsBusy{name="\"http-nio-8080\""}`.
+type exporterRule struct {
+	prefix      string
+	measurement string
+	tags        map[string]string // label of JMX exporter -> tag
+	fields      map[string]*inputs.FieldInfo
+}
+
+func newExporterRule(prefix string, m inputs.Measurement, tags map[string]string) *exporterRule {
+	r := &exporterRule{
+		prefix:      strings.ToLower(prefix),
+		measurement: m.Info().Name,
+		tags:        tags,
+		fields:      map[string]*inputs.FieldInfo{},
+	}
+
+	for k, v := range m.Info().Fields {
+		if fi, ok := v.(*inputs.FieldInfo); ok {
+			r.fields[k] = fi
+		}
+	}
+
+	return r
+}
+
+var exporterRules = []*exporterRule{
+	newExporterRule("Catalina_GlobalRequestProcessor_", &TomcatGlobalRequestProcessorM{},
+		map[string]string{"name": "name"}),
+	newExporterRule("Catalina_ThreadPool_", &TomcatThreadPoolM{},
+		map[string]string{"name": "name"}),
+	newExporterRule("Catalina_JspMonitor_", &TomcatJspMonitorM{},
+		map[string]string{"J2EEApplication": "J2EEApplication", "J2EEServer": "J2EEServer", "WebModule": "WebModule"}),
+	newExporterRule("Catalina_Servlet_", &TomcatServletM{},
+		map[string]string{"name": "name", "J2EEApplication": "J2EEApplication", "J2EEServer": "J2EEServer", "WebModule": "WebModule"}),
+	newExporterRule("Catalina_WebResourceRoot_", &TomcatCacheM{},
+		map[string]string{"context": "tomcat_context", "host": "tomcat_host"}),
+	newExporterRule("Catalina_Manager_", &TomcatSessionM{},
+		map[string]string{"context": "tomcat_context", "host": "tomcat_host"}),
+	newExporterRule("Catalina_DataSource_", &TomcatDataSourceM{},
+		map[string]string{"name": "tomcat_name", "context": "tomcat_context", "host": "tomcat_host"}),
+}
+
+// matchExporterRule find rule and the Tomcat field name of the metric, case
+// ignored for JMX exporter with `lowercaseOutputName` enabled.
+func matchExporterRule(name string) (*exporterRule, string) {
+	lower := strings.ToLower(name)
+	for _, r := range exporterRules {
+		if !strings.HasPrefix(lower, r.prefix) {
+			continue
+		}
+
+		attr := lower[len(r.prefix):]
+		for field := range r.fields {
+			if strings.ToLower(field) == attr {
+				return r, field
+			}
+		}
+	}
+
+	return nil, ""
+}
+
+type exporterPoint struct {
+	measurement string
+	tags        map[string]string
+	fields      map[string]interface{}
+}
+
+func promValue(m *dto.Metric) (float64, bool) {
+	switch {
+	case m.GetGauge() != nil:
+		return m.GetGauge().GetValue(), true
+	case m.GetCounter() != nil:
+		return m.GetCounter().GetValue(), true
+	case m.GetUntyped() != nil:
+		return m.GetUntyped().GetValue(), true
+	default:
+		return 0, false
+	}
+}
+
+// parseExporterMetrics convert metrics of JMX exporter to Tomcat points,
+// metrics with the same measurement and tags are merged into one point.
+func parseExporterMetrics(r io.Reader) ([]*exporterPoint, error) {
+	var parser expfmt.TextParser
+	mfs, err := parser.TextToMetricFamilies(r)
+	if err != nil {
+		return nil, err
+	}
+
+	res := map[string]*exporterPoint{}
+
+	for name, mf := range mfs {
+		rule, field := matchExporterRule(name)
+		if rule == nil {
+			continue
+		}
+
+		for _, m := range mf.GetMetric() {
+			v, ok := promValue(m)
+			if !ok {
+				continue
+			}
+
+			tags := map[string]string{}
+			for _, lp := range m.GetLabel() {
+				if tag, ok := rule.tags[lp.GetName()]; ok {
+					// quoted value of MBean key property, such as "http-nio-8080"
+					tags[tag] = strings.Trim(lp.GetValue(), `"`)
+				}
+			}
+
+			key := rule.measurement + tagsKey(tags)
+			pt, ok := res[key]
+			if !ok {
+				pt = &exporterPoint{measurement: rule.measurement, tags: tags, fields: map[string]interface{}{}}
+				res[key] = pt
+			}
+
+			if rule.fields[field].DataType == inputs.Int {
+				pt.fields[field] = int64(v)
+			} else {
+				pt.fields[field] = v
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(res))
+	for k := range res {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pts := make([]*exporterPoint, 0, len(keys))
+	for _, k := range keys {
+		pts = append(pts, res[k])
+	}
+
+	return pts, nil
+}
+
+func tagsKey(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	for _, k := range keys {
+		sb.WriteString("," + k + "=" + tags[k])
+	}
+	return sb.String()
+}
+
+func (ipt *Input) initExporterClient() error {
+	tlsConfig, err := ipt.ClientConfig.TLSConfig()
+	if err != nil {
+		return err
+	}
+
+	timeout := ipt.ResponseTimeout
+	if timeout <= 0 {
+		timeout = defaultResponseTimeout
+	}
+
+	ipt.httpClient = &http.Client{
+		Timeout:   timeout,
+		Transport: &http.Transport{TLSClientConfig: tlsConfig},
+	}
+
+	return nil
+}
+
+func (ipt *Input) gatherExporter(u string, ptTS int64) ([]*point.Point, error) {
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if ipt.Username != "" || ipt.Password != "" {
+		req.SetBasicAuth(ipt.Username, ipt.Password)
+	}
+
+	resp, err := ipt.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("response from %q has status code %d", u, resp.StatusCode)
+	}
+
+	eps, err := parseExporterMetrics(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("parse metrics from %q: %w", u, err)
+	}
+
+	opts := append(point.DefaultMetricOptions(), point.WithTimestamp(ptTS))
+	pts := make([]*point.Point, 0, len(eps))
+
+	for _, ep := range eps {
+		ep.tags["jmx_exporter_url"] = u
+
+		var tags map[string]string
+		if ipt.Election {
+			tags = inputs.MergeTagsWrapper(ep.tags, ipt.Tagger.ElectionTags(), ipt.Tags, u)
+		} else {
+			tags = inputs.MergeTagsWrapper(ep.tags, ipt.Tagger.HostTags(), ipt.Tags, u)
+		}
+
+		pts = append(pts, point.NewPointV2(ep.measurement,
+			append(point.NewTags(tags), point.NewKVs(ep.fields)...), opts...))
+	}
+
+	return pts, nil
+}
+
+// collectExporter scrape all JMX exporters.
+func (ipt *Input) collectExporter(ptTS int64) []*point.Point {
+	var pts []*point.Point
+
+	for _, u := range ipt.JMXExporterURLs {
+		res, err := ipt.gatherExporter(u, ptTS)
+		if err != nil {
+			l.Errorf("gatherExporter: %s", err)
+			ipt.Feeder.FeedLastError(err.Error(),
+				metrics.WithLastErrorInput(inputName),
+				metrics.WithLastErrorCategory(point.Metric),
+			)
+			continue
+		}
+		pts = append(pts, res...)
+	}
+
+	return pts
+}
+
+func (ipt *Input) runExporter() {
+	if err := ipt.initExporterClient(); err != nil {
+		l.Errorf("initExporterClient: %s", err)
+		return
+	}
+
+	interval, err := time.ParseDuration(ipt.Interval)
+	if err != nil {
+		l.Warnf("invalid interval %q, use default %s", ipt.Interval, defaultInterval)
+		interval = defaultInterval
+	}
+	interval = config.ProtectedInterval(jolokia.MinGatherInterval, jolokia.MaxGatherInterval, interval)
+
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+
+	start := time.Now()
+	for {
+		if ipt.pause {
+			l.Debugf("not leader, skipped")
+		} else {
+			collectStart := time.Now()
+			if pts := ipt.collectExporter(start.UnixNano()); len(pts) > 0 {
+				if err := ipt.Feeder.FeedV2(point.Metric, pts,
+					dkio.WithCollectCost(time.Since(collectStart)),
+					dkio.WithElection(ipt.Election),
+					dkio.WithInputName(inputName)); err != nil {
+					l.Errorf("feed measurement: %s", err)
+				}
+			}
+		}
+
+		select {
+		case <-datakit.Exit.Wait():
+			l.Info("tomcat exit")
+			return
+
+		case <-ipt.SemStop.Wait():
+			l.Info("tomcat return")
+			return
+
+		case tt := <-tick.C:
+			start = time.UnixMilli(inputs.AlignTimeMillSec(tt, start.UnixMilli(), interval.Milliseconds()))
+
+		case ipt.pause = <-ipt.pauseCh:
+			// nil
+		}
+	}
+}
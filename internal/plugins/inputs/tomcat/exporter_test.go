@@ -0,0 +1,92 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package tomcat
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/testutils"
+)
+
+const exporterMetrics = `
+# HELP Catalina_GlobalRequestProcessor_requestCount Number of requests processed
+# TYPE Catalina_GlobalRequestProcessor_requestCount untyped
+Catalina_GlobalRequestProcessor_requestCount{name="\"http-nio-8080\"",} 12.0
+Catalina_GlobalRequestProcessor_bytesSent{name="\"http-nio-8080\"",} 2048.0
+Catalina_GlobalRequestProcessor_unknownAttr{name="\"http-nio-8080\"",} 1.0
+catalina_threadpool_maxthreads{name="\"http-nio-8080\"",} 200.0
+catalina_threadpool_currentthreadsbusy{name="\"http-nio-8080\"",} 3.0
+Catalina_WebResourceRoot_hitCount{context="/manager",host="localhost",} 7.0
+Catalina_Manager_activeSessions{context="/manager",host="localhost",} 2.0
+Catalina_Manager_rejectedSessions{context="/manager",host="localhost",} 0.0
+Catalina_DataSource_numActive{class="javax.sql.DataSource",context="/app",host="localhost",name="\"jdbc/TestDB\"",} 4.0
+Catalina_DataSource_waitCount{class="javax.sql.DataSource",context="/app",host="localhost",name="\"jdbc/TestDB\"",} 1.0
+jvm_memory_bytes_used{area="heap",} 1.0E8
+`
+
+func TestParseExporterMetrics(t *testing.T) {
+	eps, err := parseExporterMetrics(strings.NewReader(exporterMetrics))
+	require.NoError(t, err)
+	require.Len(t, eps, 5)
+
+	got := map[string]*exporterPoint{}
+	for _, ep := range eps {
+		got[ep.measurement] = ep
+	}
+
+	grp := got[TomcatGlobalRequestProcessor]
+	require.NotNil(t, grp)
+	assert.Equal(t, map[string]string{"name": "http-nio-8080"}, grp.tags)
+	assert.Equal(t, map[string]interface{}{"requestCount": int64(12), "bytesSent": int64(2048)}, grp.fields)
+
+	tp := got[TomcatThreadPool]
+	require.NotNil(t, tp)
+	assert.Equal(t, 200.0, tp.fields["maxThreads"])
+	assert.Equal(t, int64(3), tp.fields["currentThreadsBusy"])
+
+	cache := got[TomcatCache]
+	require.NotNil(t, cache)
+	assert.Equal(t, map[string]string{"tomcat_context": "/manager", "tomcat_host": "localhost"}, cache.tags)
+	assert.Equal(t, int64(7), cache.fields["hitCount"])
+
+	session := got[TomcatSession]
+	require.NotNil(t, session)
+	assert.Equal(t, map[string]string{"tomcat_context": "/manager", "tomcat_host": "localhost"}, session.tags)
+	assert.Equal(t, map[string]interface{}{"activeSessions": int64(2), "rejectedSessions": int64(0)}, session.fields)
+
+	ds := got[TomcatDataSource]
+	require.NotNil(t, ds)
+	assert.Equal(t, map[string]string{"tomcat_name": "jdbc/TestDB", "tomcat_context": "/app", "tomcat_host": "localhost"}, ds.tags)
+	assert.Equal(t, map[string]interface{}{"numActive": int64(4), "waitCount": int64(1)}, ds.fields)
+}
+
+func TestGatherExporter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, exporterMetrics)
+	}))
+	defer srv.Close()
+
+	ipt := defaultInput()
+	ipt.Tagger = testutils.DefaultMockTagger()
+	ipt.Tags = map[string]string{"foo": "bar"}
+	require.NoError(t, ipt.initExporterClient())
+
+	pts, err := ipt.gatherExporter(srv.URL, 0)
+	require.NoError(t, err)
+	require.Len(t, pts, 5)
+
+	for _, pt := range pts {
+		assert.Equal(t, srv.URL, pt.Get("jmx_exporter_url"))
+		assert.Equal(t, "bar", pt.Get("foo"))
+	}
+}
@@ -7,10 +7,18 @@
 package tomcat
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
 	"github.com/GuanceCloud/cliutils"
 	"github.com/GuanceCloud/cliutils/logger"
 
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/config"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/datakit"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/goroutine"
+	dkio "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs/jolokia"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/tailer"
@@ -28,12 +36,25 @@ type tomcatlog struct {
 	IgnoreStatus      []string `toml:"ignore"`
 	CharacterEncoding string   `toml:"character_encoding"`
 	MultilineMatch    string   `toml:"multiline_match"`
+
+	PathTagRegex         string `toml:"path_tag_regex"`
+	AccessLogMetrics     bool   `toml:"access_log_metrics"`
+	DropAccessLog        bool   `toml:"drop_access_log"`
+	AccessLogLatencyUnit string `toml:"access_log_latency_unit"`
 }
 
 type Input struct {
 	jolokia.JolokiaAgent
-	Log  *tomcatlog        `toml:"log"`
-	Tags map[string]string `toml:"tags"`
+	JMXExporterURLs []string          `toml:"jmx_exporter_urls"`
+	Log             *tomcatlog        `toml:"log"`
+	Tags            map[string]string `toml:"tags"`
+
+	httpClient *http.Client
+	pause      bool
+	pauseCh    chan bool
+
+	tail      *tailer.Tailer
+	accessAgg *accessAggregator
 }
 
 func (*Input) Catalog() string {
@@ -55,6 +76,9 @@ func (*Input) SampleMeasurement() []inputs.Measurement {
 		&TomcatThreadPoolM{},
 		&TomcatServletM{},
 		&TomcatCacheM{},
+		&TomcatSessionM{},
+		&TomcatDataSourceM{},
+		&TomcatAccessM{},
 		&TomcatM{},
 	}
 }
@@ -88,14 +112,102 @@ func (ipt *Input) GetPipeline() []tailer.Option {
 }
 
 func (ipt *Input) RunPipeline() {
-	l.Error("Collecting Tomcat in Jolokia way is deprecated. Exiting...")
+	if ipt.Log == nil || len(ipt.Log.Files) == 0 {
+		return
+	}
+
+	opts := []tailer.Option{
+		tailer.WithSource(inputName),
+		tailer.WithService(inputName),
+		tailer.WithPipeline(ipt.Log.Pipeline),
+		tailer.WithIgnoreStatus(ipt.Log.IgnoreStatus),
+		tailer.WithCharacterEncoding(ipt.Log.CharacterEncoding),
+		tailer.WithMultilinePatterns([]string{ipt.Log.MultilineMatch}),
+		tailer.WithGlobalTags(inputs.MergeTags(ipt.Tagger.HostTags(), ipt.Tags, "")),
+		tailer.EnableDebugFields(config.Cfg.EnableDebugFields),
+	}
+
+	g := goroutine.NewGroup(goroutine.Option{Name: "inputs_tomcat"})
+
+	feeder := &logFeeder{Feeder: ipt.Feeder, drop: ipt.Log.DropAccessLog}
+
+	if ipt.Log.PathTagRegex != "" {
+		tagger, err := newPathTagger(ipt.Log.PathTagRegex)
+		if err != nil {
+			l.Errorf("invalid path_tag_regex %q: %s", ipt.Log.PathTagRegex, err)
+			return
+		}
+		feeder.tagger = tagger
+	}
+
+	if ipt.Log.AccessLogMetrics {
+		ipt.accessAgg = newAccessAggregator(ipt.Log.AccessLogLatencyUnit)
+		feeder.agg = ipt.accessAgg
+
+		g.Go(func(ctx context.Context) error {
+			ipt.runAccessLogMetrics()
+			return nil
+		})
+	}
+
+	if feeder.tagger != nil || feeder.agg != nil {
+		opts = append(opts, tailer.WithFeeder(feeder))
+	}
+
+	var err error
+	ipt.tail, err = tailer.NewTailer(ipt.Log.Files, opts...)
+	if err != nil {
+		l.Error(err)
+		return
+	}
+
+	g.Go(func(ctx context.Context) error {
+		ipt.tail.Start()
+		return nil
+	})
 }
 
 func (ipt *Input) Run() {
-	l.Error("Collecting Tomcat in Jolokia way is deprecated. Exiting...")
+	l = logger.SLogger(inputName)
+
+	if len(ipt.JMXExporterURLs) == 0 {
+		l.Error("Collecting Tomcat in Jolokia way is deprecated, set jmx_exporter_urls to collect from JMX exporter. Exiting...")
+		return
+	}
+
+	ipt.runExporter()
+}
+
+func (ipt *Input) Pause() error {
+	tick := time.NewTicker(inputs.ElectionPauseTimeout)
+	defer tick.Stop()
+	select {
+	case ipt.pauseCh <- true:
+		return nil
+	case <-tick.C:
+		return fmt.Errorf("pause %s failed", inputName)
+	}
+}
+
+func (ipt *Input) Resume() error {
+	tick := time.NewTicker(inputs.ElectionResumeTimeout)
+	defer tick.Stop()
+	select {
+	case ipt.pauseCh <- false:
+		return nil
+	case <-tick.C:
+		return fmt.Errorf("resume %s failed", inputName)
+	}
+}
+
+func (ipt *Input) ElectionEnabled() bool {
+	return ipt.Election
 }
 
 func (ipt *Input) Terminate() {
+	if ipt.tail != nil {
+		ipt.tail.Close()
+	}
 	if ipt.SemStop != nil { // nolint:typecheck
 		ipt.SemStop.Close() // nolint:typecheck
 	}
@@ -104,9 +216,13 @@ func (ipt *Input) Terminate() {
 func defaultInput() *Input {
 	return &Input{
 		JolokiaAgent: jolokia.JolokiaAgent{
-			SemStop: cliutils.NewSem(),
-			Tagger:  datakit.DefaultGlobalTagger(),
+			Interval: defaultInterval.String(),
+			Election: true,
+			SemStop:  cliutils.NewSem(),
+			Feeder:   dkio.DefaultFeeder(),
+			Tagger:   datakit.DefaultGlobalTagger(),
 		},
+		pauseCh: make(chan bool, inputs.ElectionPauseChannelLength),
 	}
 }
 
@@ -0,0 +1,97 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package tomcat
+
+import (
+	"regexp"
+	"sync"
+
+	"github.com/GuanceCloud/cliutils/point"
+
+	dkio "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io"
+)
+
+// pathTagger extract tags from log file path by named capture groups of
+// regexp, such as `instance` of `/opt/(?P<instance>[^/]+)/logs/`.
+type pathTagger struct {
+	re    *regexp.Regexp
+	mu    sync.Mutex
+	cache map[string]map[string]string
+}
+
+func newPathTagger(pattern string) (*pathTagger, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pathTagger{re: re, cache: map[string]map[string]string{}}, nil
+}
+
+func (pt *pathTagger) tags(file string) map[string]string {
+	if pt == nil {
+		return nil
+	}
+
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+
+	if tags, ok := pt.cache[file]; ok {
+		return tags
+	}
+
+	var tags map[string]string
+	if m := pt.re.FindStringSubmatch(file); m != nil {
+		tags = map[string]string{}
+		for i, name := range pt.re.SubexpNames() {
+			if i > 0 && name != "" && m[i] != "" {
+				tags[name] = m[i]
+			}
+		}
+	}
+
+	pt.cache[file] = tags
+	return tags
+}
+
+// logFeeder process logs fed by tailer before feeding to IO: tags of file path
+// added, and access logs aggregated into metrics, dropped if drop is true.
+type logFeeder struct {
+	dkio.Feeder
+	tagger *pathTagger       // nil if path_tag_regex not set
+	agg    *accessAggregator // nil if access_log_metrics disabled
+	drop   bool
+}
+
+func (f *logFeeder) FeedV2(category point.Category, pts []*point.Point, opts ...dkio.FeedOption) error {
+	if category != point.Logging {
+		return f.Feeder.FeedV2(category, pts, opts...)
+	}
+
+	res := pts[:0]
+	for _, pt := range pts {
+		file, _ := pt.Get("filepath").(string)
+		tags := f.tagger.tags(file)
+		for k, v := range tags {
+			pt.MustAddTag(k, v)
+		}
+
+		if f.agg != nil {
+			msg, _ := pt.Get("message").(string)
+			if f.agg.add(file, msg, tags) && f.drop {
+				continue
+			}
+		}
+
+		res = append(res, pt)
+	}
+
+	if len(res) == 0 {
+		return nil
+	}
+
+	return f.Feeder.FeedV2(category, res, opts...)
+}
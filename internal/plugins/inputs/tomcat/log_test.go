@@ -0,0 +1,63 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package tomcat
+
+import (
+	"testing"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	dkio "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io"
+)
+
+func TestPathTagger(t *testing.T) {
+	pt, err := newPathTagger(`/opt/(?P<instance>[^/]+)/(?P<unused>x)?logs/`)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{"instance": "app1"}, pt.tags("/opt/app1/logs/catalina.out"))
+	assert.Nil(t, pt.tags("/var/log/catalina.out"))
+
+	_, err = newPathTagger(`(?P<instance>`)
+	assert.Error(t, err)
+}
+
+func TestLogFeederPathTags(t *testing.T) {
+	newLog := func(file, msg string) *point.Point {
+		var kvs point.KVs
+		kvs = kvs.Add("message", msg, false, false).Add("filepath", file, false, false)
+		return point.NewPointV2(inputName, kvs, point.DefaultLoggingOptions()...)
+	}
+
+	tagger, err := newPathTagger(`/opt/(?P<instance>[^/]+)/logs/`)
+	require.NoError(t, err)
+
+	feeder := dkio.NewMockedFeeder()
+	f := &logFeeder{Feeder: feeder, tagger: tagger, agg: newAccessAggregator("")}
+
+	access := `127.0.0.1 - - [24/Feb/2015:15:57:10 +0530] "GET / HTTP/1.1" 200 2066`
+	require.NoError(t, f.FeedV2(point.Logging, []*point.Point{
+		newLog("/opt/app1/logs/localhost_access_log.txt", access),
+		newLog("/opt/app2/logs/localhost_access_log.txt", access),
+		newLog("/opt/app2/logs/localhost_access_log.txt", access),
+		newLog("/var/log/catalina.out", "06-Sep-2021 22:33:30.513 INFO [main] started"),
+	}))
+
+	pts, err := feeder.AnyPoints()
+	require.NoError(t, err)
+	require.Len(t, pts, 4)
+	assert.Equal(t, "app1", pts[0].Get("instance"))
+	assert.Equal(t, "app2", pts[1].Get("instance"))
+	assert.Nil(t, pts[3].Get("instance"))
+
+	res := f.agg.flush(nil, 0)
+	require.Len(t, res, 2)
+	assert.Equal(t, "app1", res[0].Get("instance"))
+	assert.Equal(t, int64(1), res[0].Get("request_count"))
+	assert.Equal(t, "app2", res[1].Get("instance"))
+	assert.Equal(t, int64(2), res[1].Get("request_count"))
+}
@@ -18,6 +18,8 @@ const (
 	TomcatThreadPool             = "tomcat_thread_pool"
 	TomcatServlet                = "tomcat_servlet"
 	TomcatCache                  = "tomcat_cache"
+	TomcatSession                = "tomcat_session"
+	TomcatDataSource             = "tomcat_data_source"
 )
 
 type measurement struct {
@@ -48,6 +50,10 @@ type TomcatServletM struct{ measurement }
 
 type TomcatCacheM struct{ measurement }
 
+type TomcatSessionM struct{ measurement }
+
+type TomcatDataSourceM struct{ measurement }
+
 ////////////////////////////////////////////////////////////////////////////////
 
 // Point implement MeasurementV2.
@@ -74,6 +80,7 @@ func (m *TomcatGlobalRequestProcessorM) Info() *inputs.MeasurementInfo {
 		Tags: map[string]interface{}{
 			"name":              inputs.NewTagInfo("Protocol handler name."),
 			"jolokia_agent_url": inputs.NewTagInfo("Jolokia agent url."),
+			"jmx_exporter_url":  inputs.NewTagInfo("JMX exporter url."),
 			"host":              inputs.NewTagInfo("System hostname."),
 		},
 	}
@@ -105,6 +112,7 @@ func (m *TomcatJspMonitorM) Info() *inputs.MeasurementInfo {
 			"J2EEServer":        inputs.NewTagInfo("J2EE Servers."),
 			"WebModule":         inputs.NewTagInfo("Web Module."),
 			"jolokia_agent_url": inputs.NewTagInfo("Jolokia agent url."),
+			"jmx_exporter_url":  inputs.NewTagInfo("JMX exporter url."),
 			"host":              inputs.NewTagInfo("System hostname."),
 		},
 	}
@@ -134,6 +142,7 @@ func (m *TomcatThreadPoolM) Info() *inputs.MeasurementInfo {
 		Tags: map[string]interface{}{
 			"name":              inputs.NewTagInfo("Protocol handler name."),
 			"jolokia_agent_url": inputs.NewTagInfo("Jolokia agent url."),
+			"jmx_exporter_url":  inputs.NewTagInfo("JMX exporter url."),
 			"host":              inputs.NewTagInfo("System hostname."),
 		},
 	}
@@ -166,6 +175,7 @@ func (m *TomcatServletM) Info() *inputs.MeasurementInfo {
 			"WebModule":         inputs.NewTagInfo("Web Module."),
 			"host":              inputs.NewTagInfo("System hostname."),
 			"jolokia_agent_url": inputs.NewTagInfo("Jolokia agent url."),
+			"jmx_exporter_url":  inputs.NewTagInfo("JMX exporter url."),
 			"name":              inputs.NewTagInfo("Name"),
 		},
 	}
@@ -196,6 +206,77 @@ func (m *TomcatCacheM) Info() *inputs.MeasurementInfo {
 			"tomcat_host":       inputs.NewTagInfo("Tomcat host."),
 			"host":              inputs.NewTagInfo("System hostname."),
 			"jolokia_agent_url": inputs.NewTagInfo("Jolokia agent url."),
+			"jmx_exporter_url":  inputs.NewTagInfo("JMX exporter url."),
+		},
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Point implement MeasurementV2.
+func (m *TomcatSessionM) Point() *point.Point {
+	opts := point.DefaultMetricOptions()
+	opts = append(opts, point.WithTime(m.ts), m.opt)
+
+	return point.NewPointV2(m.name,
+		append(point.NewTags(m.tags), point.NewKVs(m.fields)...),
+		opts...)
+}
+
+//nolint:lll
+func (m *TomcatSessionM) Info() *inputs.MeasurementInfo {
+	return &inputs.MeasurementInfo{
+		Name: TomcatSession,
+		Desc: "Session manager of each context, from MBean `Catalina:type=Manager,host=*,context=*`.",
+		Fields: map[string]interface{}{
+			"activeSessions":   newFielInfoCount("Number of active sessions at this moment."),
+			"expiredSessions":  newFielInfoCount("Number of sessions that expired."),
+			"rejectedSessions": newFielInfoCount("Number of sessions rejected due to maxActive being reached."),
+			"sessionCounter":   newFielInfoCount("Total number of sessions created by this manager."),
+			"maxActive":        newFielInfoCount("Maximum number of active sessions so far."),
+		},
+		Tags: map[string]interface{}{
+			"tomcat_context":    inputs.NewTagInfo("Tomcat context."),
+			"tomcat_host":       inputs.NewTagInfo("Tomcat host."),
+			"host":              inputs.NewTagInfo("System hostname."),
+			"jolokia_agent_url": inputs.NewTagInfo("Jolokia agent url."),
+			"jmx_exporter_url":  inputs.NewTagInfo("JMX exporter url."),
+		},
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Point implement MeasurementV2.
+func (m *TomcatDataSourceM) Point() *point.Point {
+	opts := point.DefaultMetricOptions()
+	opts = append(opts, point.WithTime(m.ts), m.opt)
+
+	return point.NewPointV2(m.name,
+		append(point.NewTags(m.tags), point.NewKVs(m.fields)...),
+		opts...)
+}
+
+//nolint:lll
+func (m *TomcatDataSourceM) Info() *inputs.MeasurementInfo {
+	return &inputs.MeasurementInfo{
+		Name: TomcatDataSource,
+		Desc: "Connection pool of each JNDI datasource, from MBean `Catalina:type=DataSource,host=*,context=*,class=javax.sql.DataSource,name=*`.",
+		Fields: map[string]interface{}{
+			"numActive": newFielInfoCount("Number of connections in use."),
+			"numIdle":   newFielInfoCount("Number of idle connections."),
+			"waitCount": newFielInfoCount("Number of threads waiting for a connection, only for the Tomcat JDBC pool."),
+			"maxActive": newFielInfoCount("Maximum number of active connections, only for the Tomcat JDBC pool."),
+			"maxTotal":  newFielInfoCount("Maximum number of active connections, only for the DBCP2 pool."),
+			"maxIdle":   newFielInfoCount("Maximum number of idle connections."),
+		},
+		Tags: map[string]interface{}{
+			"tomcat_name":       inputs.NewTagInfo("JNDI name of the datasource."),
+			"tomcat_context":    inputs.NewTagInfo("Tomcat context."),
+			"tomcat_host":       inputs.NewTagInfo("Tomcat host."),
+			"host":              inputs.NewTagInfo("System hostname."),
+			"jolokia_agent_url": inputs.NewTagInfo("Jolokia agent url."),
+			"jmx_exporter_url":  inputs.NewTagInfo("JMX exporter url."),
 		},
 	}
 }
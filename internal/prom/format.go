@@ -0,0 +1,307 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package prom
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/GuanceCloud/cliutils/point"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// The scrape protocols, same as the scrape_protocols of Prometheus.
+const (
+	ProtocolPrometheusProto      = "PrometheusProto"
+	ProtocolPrometheusText004    = "PrometheusText0.0.4"
+	ProtocolOpenMetricsText001   = "OpenMetricsText0.0.1"
+	ProtocolOpenMetricsText100   = "OpenMetricsText1.0.0"
+	openMetricsMediaType         = "application/openmetrics-text"
+	protobufMediaType            = "application/vnd.google.protobuf"
+	protobufMetricFamilyProtocol = "io.prometheus.client.MetricFamily"
+)
+
+var (
+	scrapeProtocolHeaders = map[string]string{
+		ProtocolPrometheusProto:    protobufMediaType + ";proto=" + protobufMetricFamilyProtocol + ";encoding=delimited",
+		ProtocolPrometheusText004:  "text/plain;version=0.0.4",
+		ProtocolOpenMetricsText001: openMetricsMediaType + ";version=0.0.1",
+		ProtocolOpenMetricsText100: openMetricsMediaType + ";version=1.0.0",
+	}
+
+	// DefaultScrapeProtocols prefer the OpenMetrics text, the protobuf should
+	// be the first for native histograms.
+	DefaultScrapeProtocols = []string{
+		ProtocolOpenMetricsText100,
+		ProtocolOpenMetricsText001,
+		ProtocolPrometheusText004,
+	}
+
+	// OpenMetrics types not defined within dto.MetricType.
+	openMetricsTypes = map[string]dto.MetricType{
+		"unknown":        dto.MetricType_UNTYPED,
+		"stateset":       dto.MetricType_GAUGE,
+		"gaugehistogram": dto.MetricType_GAUGE_HISTOGRAM,
+	}
+)
+
+type format int
+
+const (
+	formatText format = iota
+	formatOpenMetrics
+	formatProtobuf
+)
+
+// acceptHeader build the Accept header on the protocols, the former
+// preferred, such as:
+//
+//	application/openmetrics-text;version=1.0.0;q=0.5,text/plain;version=0.0.4;q=0.4,*/*;q=0.3
+func acceptHeader(protocols []string) (string, error) {
+	var (
+		vals   []string
+		weight = len(scrapeProtocolHeaders) + 1
+	)
+
+	for _, x := range protocols {
+		h, ok := scrapeProtocolHeaders[x]
+		if !ok {
+			return "", fmt.Errorf("unknown scrape protocol %q", x)
+		}
+
+		vals = append(vals, fmt.Sprintf("%s;q=0.%d", h, weight))
+		weight--
+	}
+
+	return strings.Join(append(vals, fmt.Sprintf("*/*;q=0.%d", weight)), ","), nil
+}
+
+// responseFormat get the format of the response body on the Content-Type,
+// the unknown ones are parsed as the Prometheus text.
+func responseFormat(h http.Header) format {
+	mediaType, params, err := mime.ParseMediaType(h.Get("Content-Type"))
+	if err != nil {
+		return formatText
+	}
+
+	switch mediaType {
+	case openMetricsMediaType:
+		return formatOpenMetrics
+	case protobufMediaType:
+		if params["proto"] == protobufMetricFamilyProtocol && params["encoding"] == "delimited" {
+			return formatProtobuf
+		}
+	}
+
+	return formatText
+}
+
+func (p *Prom) processFormat(body io.Reader, u string, f format) ([]*point.Point, error) {
+	switch f {
+	case formatOpenMetrics:
+		return p.openMetrics2Points(body, u)
+	case formatProtobuf:
+		return p.protobuf2Points(body, u)
+	default:
+		return p.ProcessMetrics(body, u)
+	}
+}
+
+func (p *Prom) resetScrape() {
+	p.ptCount = 0
+	for k := range p.InfoTags {
+		delete(p.InfoTags, k)
+	}
+}
+
+// batcher pass the points to the batch callback, or collect them all if
+// not streaming.
+func (p *Prom) batcher() (callback func([]*point.Point) error, batch int, collected func() []*point.Point) {
+	if p.opt.batchCallback != nil {
+		return p.opt.batchCallback, p.opt.streamSize, func() []*point.Point { return nil }
+	}
+
+	var res []*point.Point
+	return func(pts []*point.Point) error {
+		res = append(res, pts...)
+		return nil
+	}, math.MaxInt, func() []*point.Point { return res }
+}
+
+func (p *Prom) openMetrics2Points(body io.Reader, u string) ([]*point.Point, error) {
+	p.resetScrape()
+
+	sp := newStreamParser(p, u)
+	sp.om = true
+
+	var collected func() []*point.Point
+	sp.callback, sp.batch, collected = p.batcher()
+
+	if err := sp.parse(body); err != nil {
+		return nil, err
+	}
+
+	return collected(), nil
+}
+
+// protobuf2Points decode the length-delimited protobuf families one by one,
+// so only one family buffered.
+func (p *Prom) protobuf2Points(body io.Reader, u string) ([]*point.Point, error) {
+	p.resetScrape()
+
+	var (
+		dec                        = expfmt.NewDecoder(body, expfmt.FmtProtoDelim)
+		callback, batch, collected = p.batcher()
+		pts                        []*point.Point
+		families                   int
+	)
+
+	for {
+		mf := &dto.MetricFamily{}
+		if err := dec.Decode(mf); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("decode protobuf: %w", err)
+		}
+
+		x, err := p.MetricFamilies2points(map[string]*dto.MetricFamily{mf.GetName(): mf}, u)
+		if err != nil {
+			return nil, err
+		}
+
+		pts = append(pts, x...)
+		if families++; families >= batch || len(pts) >= streamMaxSamples {
+			if err := callback(pts); err != nil {
+				return nil, err
+			}
+			pts, families = nil, 0
+		}
+	}
+
+	if len(pts) > 0 {
+		if err := callback(pts); err != nil {
+			return nil, err
+		}
+	}
+
+	return collected(), nil
+}
+
+// histogramBuckets get the buckets of the classic histogram, or the native
+// histogram converted to the cumulative buckets on upper bound, so both of
+// them are collected as the same fields.
+func histogramBuckets(h *dto.Histogram) []*dto.Bucket {
+	if len(h.GetBucket()) > 0 || h.Schema == nil {
+		return h.GetBucket()
+	}
+
+	var (
+		res   []*dto.Bucket
+		cum   float64
+		base  = math.Pow(2, math.Pow(2, -float64(h.GetSchema()))) // bucket i is (base^(i-1), base^i]
+		zeros = float64(h.GetZeroCount())
+	)
+
+	if x := h.GetZeroCountFloat(); x > 0 {
+		zeros = x
+	}
+
+	add := func(upper, count float64) {
+		cum += count
+		res = append(res, &dto.Bucket{UpperBound: &upper, CumulativeCount: uint64Ptr(uint64(cum))})
+	}
+
+	// negative buckets from the most negative one, bucket i is [-base^i, -base^(i-1))
+	neg := nativeBuckets(h.GetNegativeSpan(), h.GetNegativeDelta(), h.GetNegativeCount())
+	for i := len(neg) - 1; i >= 0; i-- {
+		add(-math.Pow(base, float64(neg[i].index-1)), neg[i].count)
+	}
+
+	add(h.GetZeroThreshold(), zeros)
+
+	for _, b := range nativeBuckets(h.GetPositiveSpan(), h.GetPositiveDelta(), h.GetPositiveCount()) {
+		add(math.Pow(base, float64(b.index)), b.count)
+	}
+
+	inf := math.Inf(1)
+	return append(res, &dto.Bucket{UpperBound: &inf, CumulativeCount: uint64Ptr(uint64(histogramCount(h)))})
+}
+
+type nativeBucket struct {
+	index int32
+	count float64
+}
+
+// nativeBuckets expand the spans of buckets, the counts are deltas(int
+// histogram) or absolute(float histogram).
+func nativeBuckets(spans []*dto.BucketSpan, deltas []int64, counts []float64) []nativeBucket {
+	var (
+		res []nativeBucket
+		idx int32
+		n   int
+		cur int64
+	)
+
+	for i, s := range spans {
+		if i == 0 {
+			idx = s.GetOffset()
+		} else {
+			idx += s.GetOffset()
+		}
+
+		for j := uint32(0); j < s.GetLength(); j++ {
+			var c float64
+			switch {
+			case n < len(deltas):
+				cur += deltas[n]
+				c = float64(cur)
+			case n < len(counts):
+				c = counts[n]
+			}
+
+			res = append(res, nativeBucket{index: idx, count: c})
+			idx++
+			n++
+		}
+	}
+
+	return res
+}
+
+func histogramCount(h *dto.Histogram) float64 {
+	if x := h.GetSampleCountFloat(); x > 0 {
+		return x
+	}
+	return float64(h.GetSampleCount())
+}
+
+func uint64Ptr(x uint64) *uint64 { return &x }
+
+// exemplarPoint build the point of the exemplar, such as the trace ID of the
+// sample, the exemplar labels are added as tags.
+func (p *Prom) exemplarPoint(measurement, field string, kvs point.KVs, e *dto.Exemplar, opts []point.Option) *point.Point {
+	tags := make(point.KVs, 0, len(kvs)+len(e.GetLabel())+1)
+	tags = append(tags, kvs...) // do not touch the tags of the sample
+
+	for _, l := range e.GetLabel() {
+		tags = tags.AddTag(l.GetName(), l.GetValue())
+	}
+
+	tags = tags.Add(field+"_exemplar", e.GetValue(), false, false)
+
+	if e.Timestamp != nil {
+		opts = append(opts[:len(opts):len(opts)], point.WithTime(e.GetTimestamp().AsTime()))
+	}
+
+	return point.NewPointV2(measurement, tags, opts...)
+}
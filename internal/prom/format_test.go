@@ -0,0 +1,182 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package prom
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	T "testing"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/matttproud/golang_protobuf_extensions/pbutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestAcceptHeader(t *T.T) {
+	h, err := acceptHeader(DefaultScrapeProtocols)
+	require.NoError(t, err)
+	assert.Equal(t, "application/openmetrics-text;version=1.0.0;q=0.5,"+
+		"application/openmetrics-text;version=0.0.1;q=0.4,"+
+		"text/plain;version=0.0.4;q=0.3,*/*;q=0.2", h)
+
+	_, err = acceptHeader([]string{"PrometheusText"})
+	assert.Error(t, err)
+
+	for ct, f := range map[string]format{
+		"": formatText,
+		"text/plain; version=0.0.4; charset=utf-8":                                                     formatText,
+		"application/openmetrics-text; version=1.0.0; charset=utf-8":                                   formatOpenMetrics,
+		"application/vnd.google.protobuf; proto=io.prometheus.client.MetricFamily; encoding=delimited": formatProtobuf,
+		"application/vnd.google.protobuf; proto=io.prometheus.client.MetricFamily; encoding=text":      formatText,
+	} {
+		assert.Equal(t, f, responseFormat(http.Header{"Content-Type": []string{ct}}), ct)
+	}
+}
+
+const openMetricsBody = `# TYPE http_requests counter
+# UNIT http_requests requests
+# HELP http_requests Total requests.
+http_requests_total{code="200"} 17 1520879607.789 # {trace_id="KOO5S4vxi0o"} 0.67 1520879602.5
+http_requests_created{code="200"} 1520430000.123
+# TYPE rpc_duration_seconds histogram
+rpc_duration_seconds_bucket{le="0.1"} 1 # {trace_id="oHg5SJYRHA0",span_id="abc"} 0.05
+rpc_duration_seconds_bucket{le="+Inf"} 2
+rpc_duration_seconds_sum 0.3
+rpc_duration_seconds_count 2
+rpc_duration_seconds_created 1520430000.123
+# TYPE queue_size gaugehistogram
+queue_size_bucket{le="+Inf"} 1
+queue_size_gcount 1
+queue_size_gsum 1
+# TYPE build unknown
+build 1
+# EOF
+`
+
+func TestOpenMetrics(t *T.T) {
+	t.Run("exemplars-dropped", func(t *T.T) {
+		p, err := NewProm(WithTimestamp(1))
+		require.NoError(t, err)
+
+		pts, err := p.processFormat(bytes.NewBufferString(openMetricsBody+"invalid after EOF"), "", formatOpenMetrics)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{
+			`build build=1 1`,
+			`http,code=200 requests_total=17 1`,
+			`rpc duration_seconds_count=2,duration_seconds_sum=0.3 1`,
+			`rpc,le=+Inf duration_seconds_bucket=2 1`,
+			`rpc,le=0.1 duration_seconds_bucket=1 1`,
+		}, lineProtos(pts))
+	})
+
+	t.Run("exemplars-kept-streaming", func(t *T.T) {
+		var pts []*point.Point
+		p, err := NewProm(WithTimestamp(1), WithKeepExemplars(true), WithMaxBatchCallback(1, func(x []*point.Point) error {
+			pts = append(pts, x...)
+			return nil
+		}))
+		require.NoError(t, err)
+
+		_, err = p.processFormat(bytes.NewBufferString(openMetricsBody), "", formatOpenMetrics)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{
+			`build build=1 1`,
+			`http,code=200 requests_total=17 1`,
+			`http,code=200,trace_id=KOO5S4vxi0o requests_total_exemplar=0.67 1520879602500000000`,
+			`rpc duration_seconds_count=2,duration_seconds_sum=0.3 1`,
+			`rpc,le=+Inf duration_seconds_bucket=2 1`,
+			`rpc,le=0.1 duration_seconds_bucket=1 1`,
+			`rpc,le=0.1,span_id=abc,trace_id=oHg5SJYRHA0 duration_seconds_bucket_exemplar=0.05 1`,
+		}, lineProtos(pts))
+	})
+}
+
+func protobufBody(t *T.T, mfs ...*dto.MetricFamily) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	for _, mf := range mfs {
+		_, err := pbutil.WriteDelimited(&buf, mf)
+		require.NoError(t, err)
+	}
+	return buf.Bytes()
+}
+
+func TestProtobuf(t *T.T) {
+	body := protobufBody(t,
+		&dto.MetricFamily{
+			Name: proto.String("http_requests_total"),
+			Type: dto.MetricType_COUNTER.Enum(),
+			Metric: []*dto.Metric{{
+				Label: []*dto.LabelPair{{Name: proto.String("code"), Value: proto.String("200")}},
+				Counter: &dto.Counter{
+					Value: proto.Float64(17),
+					Exemplar: &dto.Exemplar{
+						Label: []*dto.LabelPair{{Name: proto.String("trace_id"), Value: proto.String("KOO5S4vxi0o")}},
+						Value: proto.Float64(0.67),
+					},
+				},
+			}},
+		},
+		&dto.MetricFamily{
+			Name: proto.String("rpc_duration_seconds"),
+			Type: dto.MetricType_HISTOGRAM.Enum(),
+			Metric: []*dto.Metric{{
+				Histogram: &dto.Histogram{
+					SampleCount:   proto.Uint64(7),
+					SampleSum:     proto.Float64(10),
+					Schema:        proto.Int32(0),
+					ZeroThreshold: proto.Float64(0.001),
+					ZeroCount:     proto.Uint64(1),
+					NegativeSpan:  []*dto.BucketSpan{{Offset: proto.Int32(1), Length: proto.Uint32(1)}},
+					NegativeDelta: []int64{2},
+					PositiveSpan: []*dto.BucketSpan{
+						{Offset: proto.Int32(0), Length: proto.Uint32(2)},
+						{Offset: proto.Int32(1), Length: proto.Uint32(1)},
+					},
+					PositiveDelta: []int64{1, 1, -1},
+				},
+			}},
+		},
+	)
+
+	var accept string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accept = r.Header.Get("Accept")
+		w.Header().Set("Content-Type", "application/vnd.google.protobuf; proto=io.prometheus.client.MetricFamily; encoding=delimited")
+		w.Write(body) //nolint:errcheck,gosec
+	}))
+	defer ts.Close()
+
+	p, err := NewProm(WithTimestamp(1), WithKeepExemplars(true),
+		WithScrapeProtocols([]string{ProtocolPrometheusProto, ProtocolPrometheusText004}))
+	require.NoError(t, err)
+
+	pts, err := p.CollectFromHTTPV2(ts.URL)
+	require.NoError(t, err)
+
+	assert.Equal(t, "application/vnd.google.protobuf;proto=io.prometheus.client.MetricFamily;encoding=delimited;q=0.5,"+
+		"text/plain;version=0.0.4;q=0.4,*/*;q=0.3", accept)
+
+	// native buckets(schema 0): [-2,-1):2, zero:1, (0.5,1]:1, (1,2]:2, (4,8]:1
+	assert.Equal(t, []string{
+		`http,code=200 requests_total=17 1`,
+		`http,code=200,trace_id=KOO5S4vxi0o requests_total_exemplar=0.67 1`,
+		`rpc duration_seconds_count=7,duration_seconds_sum=10 1`,
+		`rpc,le=+Inf duration_seconds_bucket=7 1`,
+		`rpc,le=-1 duration_seconds_bucket=2 1`,
+		`rpc,le=0.001 duration_seconds_bucket=3 1`,
+		`rpc,le=1 duration_seconds_bucket=4 1`,
+		`rpc,le=2 duration_seconds_bucket=6 1`,
+		`rpc,le=8 duration_seconds_bucket=7 1`,
+	}, lineProtos(pts))
+}
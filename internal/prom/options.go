@@ -53,6 +53,9 @@ type option struct {
 	streamSize    int
 	ptts          int64
 
+	scrapeProtocols []string
+	keepExemplars   bool
+
 	l *logger.Logger
 }
 
@@ -210,3 +213,13 @@ func WithMaxBatchCallback(i int, f func([]*point.Point) error) PromOption {
 	}
 }
 func WithLogger(l *logger.Logger) PromOption { return func(opt *option) { opt.l = l } }
+
+// WithScrapeProtocols set the Accept header on the protocols(such as
+// ProtocolOpenMetricsText100), the former preferred. The response is decoded
+// on its Content-Type.
+func WithScrapeProtocols(arr []string) PromOption {
+	return func(opt *option) { opt.scrapeProtocols = arr }
+}
+
+// WithKeepExemplars collect the OpenMetrics/protobuf exemplars as points.
+func WithKeepExemplars(b bool) PromOption { return func(opt *option) { opt.keepExemplars = b } }
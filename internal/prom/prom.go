@@ -22,7 +22,6 @@ import (
 	"time"
 
 	"github.com/GuanceCloud/cliutils/point"
-	dto "github.com/prometheus/client_model/go"
 	"github.com/prometheus/common/expfmt"
 
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/datakit"
@@ -62,6 +61,7 @@ type Prom struct {
 	opt      *option
 	client   *http.Client
 	parser   expfmt.TextParser
+	accept   string
 	InfoTags map[string]string
 	ptCount  int
 }
@@ -74,17 +74,12 @@ func NewProm(opts ...PromOption) (*Prom, error) {
 
 	p := Prom{opt: opt, InfoTags: make(map[string]string)}
 
-	var f expfmt.BatchCallback = func(mf map[string]*dto.MetricFamily) error {
-		pts, err := p.MetricFamilies2points(mf, "")
+	if len(opt.scrapeProtocols) > 0 {
+		accept, err := acceptHeader(opt.scrapeProtocols)
 		if err != nil {
-			return err
+			return nil, err
 		}
-
-		return p.opt.batchCallback(pts)
-	}
-	if opt.streamSize > 0 && opt.batchCallback != nil {
-		parse := expfmt.NewTextParser(expfmt.WithBatchCallback(opt.streamSize, f))
-		p.parser = *parse
+		p.accept = accept
 	}
 
 	cliopts := httpcli.NewOptions()
@@ -134,6 +129,12 @@ func (p *Prom) GetReq(url string) (*http.Request, error) {
 	} else {
 		req, err = http.NewRequest("GET", url, nil)
 	}
+	if err != nil {
+		return nil, err
+	}
+	if p.accept != "" {
+		req.Header.Set("Accept", p.accept)
+	}
 	for k, v := range p.opt.httpHeaders {
 		req.Header.Set(k, v)
 	}
@@ -181,7 +182,7 @@ func (p *Prom) CollectFromHTTPV2(u string, opts ...PromOption) ([]*point.Point,
 
 	// A agent used to count bytes.
 	wCounter := &writeCounter{}
-	pts, err := p.ProcessMetrics(io.TeeReader(resp.Body, wCounter), u)
+	pts, err := p.processFormat(io.TeeReader(resp.Body, wCounter), u, responseFormat(resp.Header))
 	if err != nil {
 		return nil, err
 	}
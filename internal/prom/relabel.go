@@ -0,0 +1,177 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package prom
+
+import (
+	"crypto/md5" //nolint:gosec
+	"encoding/binary"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// The relabel actions, same as the relabel_config of Prometheus.
+const (
+	RelabelReplace   = "replace"
+	RelabelKeep      = "keep"
+	RelabelDrop      = "drop"
+	RelabelHashMod   = "hashmod"
+	RelabelLabelMap  = "labelmap"
+	RelabelLabelDrop = "labeldrop"
+	RelabelLabelKeep = "labelkeep"
+	RelabelLowercase = "lowercase"
+	RelabelUppercase = "uppercase"
+)
+
+// RelabelConfig rewrite the labels of the target before scraping, or drop the
+// target, the labels prefixed with __ are removed after relabeling.
+type RelabelConfig struct {
+	SourceLabels []string `toml:"source_labels" json:"source_labels"`
+	Separator    string   `toml:"separator" json:"separator"` // defaults to ;
+	Regex        string   `toml:"regex" json:"regex"`         // defaults to (.*), anchored on both ends
+	Modulus      uint64   `toml:"modulus" json:"modulus"`     // for hashmod
+	TargetLabel  string   `toml:"target_label" json:"target_label"`
+	Replacement  string   `toml:"replacement" json:"replacement"` // defaults to $1
+	Action       string   `toml:"action" json:"action"`           // defaults to replace
+
+	re *regexp.Regexp
+}
+
+// Init set the defaults and check the rule.
+func (c *RelabelConfig) Init() error {
+	if c.Separator == "" {
+		c.Separator = ";"
+	}
+	if c.Regex == "" {
+		c.Regex = "(.*)"
+	}
+	if c.Replacement == "" {
+		c.Replacement = "$1"
+	}
+	if c.Action == "" {
+		c.Action = RelabelReplace
+	}
+	c.Action = strings.ToLower(c.Action)
+
+	re, err := regexp.Compile("^(?:" + c.Regex + ")$")
+	if err != nil {
+		return fmt.Errorf("invalid relabel regex %q: %w", c.Regex, err)
+	}
+	c.re = re
+
+	switch c.Action {
+	case RelabelReplace, RelabelLowercase, RelabelUppercase:
+		if c.TargetLabel == "" {
+			return fmt.Errorf("relabel action %s requires target_label", c.Action)
+		}
+	case RelabelHashMod:
+		if c.TargetLabel == "" || c.Modulus == 0 {
+			return fmt.Errorf("relabel action %s requires target_label and modulus", c.Action)
+		}
+	case RelabelKeep, RelabelDrop, RelabelLabelMap, RelabelLabelDrop, RelabelLabelKeep:
+	default:
+		return fmt.Errorf("unknown relabel action %q", c.Action)
+	}
+
+	return nil
+}
+
+// Relabel apply the rules to the labels in order, false returned if the
+// target dropped. The labels modified in place, the rules not Init() are
+// initialized here and ignored on error.
+func Relabel(lbs map[string]string, cfgs []*RelabelConfig) bool {
+	for _, c := range cfgs {
+		if c.re == nil {
+			if err := c.Init(); err != nil {
+				continue
+			}
+		}
+
+		if !c.apply(lbs) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c *RelabelConfig) apply(lbs map[string]string) bool {
+	vals := make([]string, 0, len(c.SourceLabels))
+	for _, name := range c.SourceLabels {
+		vals = append(vals, lbs[name])
+	}
+	val := strings.Join(vals, c.Separator)
+
+	switch c.Action {
+	case RelabelKeep:
+		return c.re.MatchString(val)
+
+	case RelabelDrop:
+		return !c.re.MatchString(val)
+
+	case RelabelReplace:
+		idx := c.re.FindStringSubmatchIndex(val)
+		if idx == nil {
+			break
+		}
+
+		target := string(c.re.ExpandString(nil, c.TargetLabel, val, idx))
+		if !isValidLabelName(target) {
+			break
+		}
+
+		if res := string(c.re.ExpandString(nil, c.Replacement, val, idx)); res == "" {
+			delete(lbs, target)
+		} else {
+			lbs[target] = res
+		}
+
+	case RelabelLowercase:
+		lbs[c.TargetLabel] = strings.ToLower(val)
+
+	case RelabelUppercase:
+		lbs[c.TargetLabel] = strings.ToUpper(val)
+
+	case RelabelHashMod:
+		sum := md5.Sum([]byte(val)) //nolint:gosec
+		lbs[c.TargetLabel] = fmt.Sprintf("%d", binary.BigEndian.Uint64(sum[8:])%c.Modulus)
+
+	case RelabelLabelMap:
+		for _, name := range sortedLabelNames(lbs) {
+			if c.re.MatchString(name) {
+				lbs[c.re.ReplaceAllString(name, c.Replacement)] = lbs[name]
+			}
+		}
+
+	case RelabelLabelDrop:
+		for name := range lbs {
+			if c.re.MatchString(name) {
+				delete(lbs, name)
+			}
+		}
+
+	case RelabelLabelKeep:
+		for name := range lbs {
+			if !c.re.MatchString(name) {
+				delete(lbs, name)
+			}
+		}
+	}
+
+	return true
+}
+
+// sortedLabelNames get the names before the labels modified, and keep the
+// result stable if names conflicted after mapping.
+func sortedLabelNames(lbs map[string]string) []string {
+	names := make([]string, 0, len(lbs))
+	for name := range lbs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
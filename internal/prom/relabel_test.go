@@ -0,0 +1,111 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package prom
+
+import (
+	T "testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelabel(t *T.T) {
+	cases := []struct {
+		name   string
+		cfgs   []*RelabelConfig
+		in     map[string]string
+		expect map[string]string // nil if dropped
+	}{
+		{
+			name: "replace-address-port",
+			cfgs: []*RelabelConfig{{
+				SourceLabels: []string{"__address__", "port"},
+				Regex:        `([^:]+)(?::\d+)?;(\d+)`,
+				Replacement:  "${1}:${2}",
+				TargetLabel:  "__address__",
+			}},
+			in:     map[string]string{"__address__": "10.0.0.1:80", "port": "9100"},
+			expect: map[string]string{"__address__": "10.0.0.1:9100", "port": "9100"},
+		},
+		{
+			name: "replace-not-matched",
+			cfgs: []*RelabelConfig{{
+				SourceLabels: []string{"path"},
+				Regex:        "(.+)",
+				TargetLabel:  "__metrics_path__",
+			}},
+			in:     map[string]string{"__metrics_path__": "/metrics"},
+			expect: map[string]string{"__metrics_path__": "/metrics"},
+		},
+		{
+			name: "keep-and-drop",
+			cfgs: []*RelabelConfig{
+				{SourceLabels: []string{"scrape"}, Regex: "true", Action: "keep"},
+				{SourceLabels: []string{"env"}, Regex: "test|dev", Action: "drop"},
+			},
+			in:     map[string]string{"scrape": "true", "env": "prod"},
+			expect: map[string]string{"scrape": "true", "env": "prod"},
+		},
+		{
+			name:   "keep-not-matched",
+			cfgs:   []*RelabelConfig{{SourceLabels: []string{"scrape"}, Regex: "true", Action: "Keep"}},
+			in:     map[string]string{"scrape": "false"},
+			expect: nil,
+		},
+		{
+			name: "labelmap-and-labeldrop",
+			cfgs: []*RelabelConfig{
+				{Regex: "__meta_pod_label_(.+)", Action: "labelmap"},
+				{Regex: "__meta_.*", Action: "labeldrop"},
+			},
+			in:     map[string]string{"__meta_pod_label_app": "nginx", "__meta_pod_name": "nginx-0", "__address__": "a:1"},
+			expect: map[string]string{"app": "nginx", "__address__": "a:1"},
+		},
+		{
+			name:   "labelkeep",
+			cfgs:   []*RelabelConfig{{Regex: "__address__|app", Action: "labelkeep"}},
+			in:     map[string]string{"app": "nginx", "pod": "nginx-0", "__address__": "a:1"},
+			expect: map[string]string{"app": "nginx", "__address__": "a:1"},
+		},
+		{
+			name: "hashmod-and-lowercase",
+			cfgs: []*RelabelConfig{
+				{SourceLabels: []string{"__address__"}, Modulus: 1, TargetLabel: "shard", Action: "hashmod"},
+				{SourceLabels: []string{"env"}, TargetLabel: "env", Action: "lowercase"},
+			},
+			in:     map[string]string{"__address__": "a:1", "env": "PROD"},
+			expect: map[string]string{"__address__": "a:1", "env": "prod", "shard": "0"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *T.T) {
+			for _, c := range tc.cfgs {
+				require.NoError(t, c.Init())
+			}
+
+			keep := Relabel(tc.in, tc.cfgs)
+			if tc.expect == nil {
+				assert.False(t, keep)
+				return
+			}
+
+			assert.True(t, keep)
+			assert.Equal(t, tc.expect, tc.in)
+		})
+	}
+
+	t.Run("invalid", func(t *T.T) {
+		for _, c := range []*RelabelConfig{
+			{Regex: "(", TargetLabel: "x"},
+			{Action: "replace"},
+			{Action: "hashmod", TargetLabel: "x"},
+			{Action: "unknown"},
+		} {
+			assert.Error(t, c.Init(), "%+#v", c)
+		}
+	})
+}
@@ -0,0 +1,573 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package prom
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// streamMaxSamples is the max samples of the counter/gauge/untyped family
+// buffered before converted to points, and the max points buffered before
+// the batch callback.
+const streamMaxSamples = 1000
+
+// streamParser parse the text exposition format line by line, and convert the
+// samples to points incrementally. Only the samples of the current family are
+// buffered(chunked on streamMaxSamples for counter/gauge/untyped), so the
+// memory is bounded no matter how large the payload is, such as the federate
+// or cadvisor endpoints.
+//
+// The points are passed to the batch callback once streamSize families
+// converted, or streamMaxSamples points buffered.
+//
+// With om enabled, the payload is parsed as OpenMetrics text: the counters are
+// named with suffix _total(same as the Prometheus text), the _created samples
+// and gauge histograms are dropped, and the exemplars are kept if
+// keepExemplars enabled.
+type streamParser struct {
+	p        *Prom
+	u        string
+	om       bool
+	callback func([]*point.Point) error
+	batch    int // families on each callback
+
+	line int
+	eof  bool // OpenMetrics # EOF
+
+	// current family
+	mf      *dto.MetricFamily
+	skip    bool                   // family filtered, see filterMetricFamilies()
+	series  map[string]*dto.Metric // on label signature, for summary/histogram
+	samples int                    // samples fed to current family
+
+	families int
+	pts      []*point.Point
+}
+
+func newStreamParser(p *Prom, u string) *streamParser {
+	return &streamParser{p: p, u: u, callback: p.opt.batchCallback, batch: p.opt.streamSize}
+}
+
+func (sp *streamParser) parse(in io.Reader) error {
+	r := bufio.NewReader(in)
+
+	for !sp.eof {
+		line, err := r.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+
+		if len(line) > 0 {
+			sp.line++
+			if e := sp.parseLine(strings.TrimSpace(line)); e != nil {
+				return fmt.Errorf("text format parsing error in line %d: %w", sp.line, e)
+			}
+		}
+
+		if err != nil { // EOF
+			break
+		}
+	}
+
+	if err := sp.endFamily(); err != nil {
+		return err
+	}
+
+	return sp.flush()
+}
+
+func (sp *streamParser) parseLine(line string) error {
+	switch {
+	case line == "":
+		return nil
+	case line[0] == '#':
+		return sp.parseComment(line)
+	default:
+		return sp.parseSample(line)
+	}
+}
+
+// parseComment start a new family on TYPE, other comments(such as HELP) are
+// ignored.
+func (sp *streamParser) parseComment(line string) error {
+	fields := strings.Fields(line[1:])
+	if sp.om && len(fields) == 1 && fields[0] == "EOF" {
+		sp.eof = true
+		return nil
+	}
+
+	if len(fields) < 3 || fields[0] != "TYPE" {
+		return nil
+	}
+
+	typ, ok := dto.MetricType_value[strings.ToUpper(fields[2])]
+	if sp.om {
+		if x, exist := openMetricsTypes[fields[2]]; exist {
+			typ, ok = int32(x), true
+		}
+	}
+
+	if !ok {
+		return fmt.Errorf("unknown metric type %q", fields[2])
+	}
+
+	name := fields[1]
+	if dto.MetricType(typ) == dto.MetricType_INFO && !strings.HasSuffix(name, "_info") {
+		name += "_info"
+	}
+
+	if sp.om && dto.MetricType(typ) == dto.MetricType_COUNTER && !strings.HasSuffix(name, "_total") {
+		name += "_total"
+	}
+
+	if err := sp.endFamily(); err != nil {
+		return err
+	}
+
+	sp.startFamily(name, dto.MetricType(typ))
+	return nil
+}
+
+func (sp *streamParser) startFamily(name string, typ dto.MetricType) {
+	sp.mf = &dto.MetricFamily{Name: &name, Type: typ.Enum()}
+	sp.skip = !sp.p.validMetricName(name) || !sp.p.validMetricType(typ)
+	sp.series = nil
+	sp.samples = 0
+}
+
+// familyOf get the sample belongs to current family, and the suffix of the
+// sample name on summary/histogram.
+func (sp *streamParser) familyOf(name string) (suffix string, ok bool) {
+	if sp.mf == nil {
+		return "", false
+	}
+
+	base := sp.mf.GetName()
+	if name == base {
+		return "", true
+	}
+
+	if sp.om && sp.mf.GetType() == dto.MetricType_COUNTER {
+		base = strings.TrimSuffix(base, "_total")
+	}
+
+	if !strings.HasPrefix(name, base) {
+		return "", false
+	}
+
+	suffix = name[len(base):]
+	switch sp.mf.GetType() { //nolint:exhaustive
+	case dto.MetricType_SUMMARY:
+		ok = suffix == "_sum" || suffix == "_count"
+	case dto.MetricType_HISTOGRAM:
+		ok = suffix == "_sum" || suffix == "_count" || suffix == "_bucket"
+	case dto.MetricType_GAUGE_HISTOGRAM:
+		ok = suffix == "_gsum" || suffix == "_gcount" || suffix == "_bucket"
+	}
+
+	if sp.om && suffix == "_created" {
+		ok = true
+	}
+
+	return suffix, ok
+}
+
+func (sp *streamParser) parseSample(line string) error {
+	name, labels, value, ts, exemplar, err := splitSample(line, sp.om)
+	if err != nil {
+		return err
+	}
+
+	var e *dto.Exemplar
+	if sp.om && sp.p.opt.keepExemplars && exemplar != "" {
+		if e, err = parseExemplar(exemplar); err != nil {
+			return err
+		}
+	}
+
+	suffix, ok := sp.familyOf(name)
+	if !ok { // samples without TYPE are untyped
+		if err := sp.endFamily(); err != nil {
+			return err
+		}
+		sp.startFamily(name, dto.MetricType_UNTYPED)
+	}
+
+	sp.samples++
+	if sp.skip || suffix == "_created" || sp.mf.GetType() == dto.MetricType_GAUGE_HISTOGRAM {
+		return nil
+	}
+
+	switch sp.mf.GetType() { //nolint:exhaustive
+	case dto.MetricType_SUMMARY, dto.MetricType_HISTOGRAM:
+		return sp.addSeriesSample(suffix, labels, value, ts, e)
+
+	default:
+		m := &dto.Metric{Label: labels, TimestampMs: ts}
+		switch sp.mf.GetType() { //nolint:exhaustive
+		case dto.MetricType_COUNTER:
+			m.Counter = &dto.Counter{Value: &value, Exemplar: e}
+		case dto.MetricType_GAUGE:
+			m.Gauge = &dto.Gauge{Value: &value}
+		default: // untyped and info
+			m.Untyped = &dto.Untyped{Value: &value}
+		}
+
+		sp.mf.Metric = append(sp.mf.Metric, m)
+
+		// info family is small and should be applied before other families,
+		// do not chunk it.
+		if sp.mf.GetType() != dto.MetricType_INFO && len(sp.mf.Metric) >= streamMaxSamples {
+			return sp.convert()
+		}
+	}
+
+	return nil
+}
+
+// addSeriesSample add the sample to the summary/histogram series, the series
+// are kept until the family end, because the _sum/_count may come after all
+// the buckets/quantiles. Same as expfmt, the timestamp of the series is the
+// one of its last sample.
+func (sp *streamParser) addSeriesSample(suffix string, labels []*dto.LabelPair, value float64,
+	ts *int64, e *dto.Exemplar,
+) error {
+	var (
+		isHistogram = sp.mf.GetType() == dto.MetricType_HISTOGRAM
+		bound       = math.NaN()
+		kept        = labels[:0:0]
+	)
+
+	for _, l := range labels {
+		switch {
+		case isHistogram && suffix == "_bucket" && l.GetName() == "le",
+			!isHistogram && suffix == "" && l.GetName() == "quantile":
+			x, err := strconv.ParseFloat(l.GetValue(), 64)
+			if err != nil {
+				return fmt.Errorf("expected float as value for %q label, got %q", l.GetName(), l.GetValue())
+			}
+			bound = x
+		default:
+			kept = append(kept, l)
+		}
+	}
+
+	sig := labelSignature(kept)
+	m, ok := sp.series[sig]
+	if !ok {
+		m = &dto.Metric{Label: kept}
+		if isHistogram {
+			m.Histogram = &dto.Histogram{}
+		} else {
+			m.Summary = &dto.Summary{}
+		}
+
+		if sp.series == nil {
+			sp.series = map[string]*dto.Metric{}
+		}
+		sp.series[sig] = m
+		sp.mf.Metric = append(sp.mf.Metric, m)
+	}
+
+	if ts != nil {
+		m.TimestampMs = ts
+	}
+
+	cnt := uint64(value)
+
+	switch {
+	case suffix == "_count" && isHistogram:
+		m.Histogram.SampleCount = &cnt
+	case suffix == "_sum" && isHistogram:
+		m.Histogram.SampleSum = &value
+	case suffix == "_count":
+		m.Summary.SampleCount = &cnt
+	case suffix == "_sum":
+		m.Summary.SampleSum = &value
+	case math.IsNaN(bound): // bucket/quantile without le/quantile label
+	case isHistogram:
+		m.Histogram.Bucket = append(m.Histogram.Bucket, &dto.Bucket{UpperBound: &bound, CumulativeCount: &cnt, Exemplar: e})
+	default:
+		m.Summary.Quantile = append(m.Summary.Quantile, &dto.Quantile{Quantile: &bound, Value: &value})
+	}
+
+	return nil
+}
+
+// convert the buffered samples of current family to points.
+func (sp *streamParser) convert() error {
+	if sp.mf == nil || len(sp.mf.Metric) == 0 {
+		return nil
+	}
+
+	pts, err := sp.p.MetricFamilies2points(map[string]*dto.MetricFamily{sp.mf.GetName(): sp.mf}, sp.u)
+	if err != nil {
+		return err
+	}
+
+	sp.mf.Metric = nil
+	sp.series = nil
+	sp.pts = append(sp.pts, pts...)
+
+	if len(sp.pts) >= streamMaxSamples {
+		return sp.flush()
+	}
+
+	return nil
+}
+
+func (sp *streamParser) endFamily() error {
+	if sp.mf == nil {
+		return nil
+	}
+
+	if err := sp.convert(); err != nil {
+		return err
+	}
+
+	if sp.samples > 0 {
+		sp.families++
+	}
+	sp.mf = nil
+
+	if sp.families >= sp.batch {
+		return sp.flush()
+	}
+
+	return nil
+}
+
+func (sp *streamParser) flush() error {
+	sp.families = 0
+
+	if len(sp.pts) == 0 {
+		return nil
+	}
+
+	pts := sp.pts
+	sp.pts = nil
+
+	return sp.callback(pts)
+}
+
+// splitSample split the sample line like
+// `name{k="v",...} value [timestamp] [# exemplar]`, the timestamp is in
+// milliseconds, or in seconds(float) for OpenMetrics, nil returned if absent.
+func splitSample(line string, om bool) (name string, labels []*dto.LabelPair,
+	value float64, ts *int64, exemplar string, err error,
+) {
+	i := strings.IndexAny(line, "{ \t")
+	if i < 0 {
+		return "", nil, 0, nil, "", fmt.Errorf("expected value after metric %q", line)
+	}
+
+	name, line = line[:i], line[i:]
+	if !isValidMetricName(name) {
+		return "", nil, 0, nil, "", fmt.Errorf("invalid metric name %q", name)
+	}
+
+	if line[0] == '{' {
+		if labels, line, err = splitLabels(line[1:]); err != nil {
+			return "", nil, 0, nil, "", err
+		}
+	}
+
+	if j := strings.IndexByte(line, '#'); j >= 0 {
+		line, exemplar = line[:j], strings.TrimSpace(line[j+1:])
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return "", nil, 0, nil, "", fmt.Errorf("expected value after metric %q", name)
+	}
+
+	value, err = strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return "", nil, 0, nil, "", fmt.Errorf("expected float as value, got %q", fields[0])
+	}
+
+	if len(fields) > 1 {
+		if ts, err = parseSampleTimestamp(fields[1], om); err != nil {
+			return "", nil, 0, nil, "", err
+		}
+	}
+
+	return name, labels, value, ts, exemplar, nil
+}
+
+func parseSampleTimestamp(s string, om bool) (*int64, error) {
+	var ms int64
+
+	if om {
+		sec, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("expected float as timestamp, got %q", s)
+		}
+		ms = int64(math.Round(sec * 1000))
+	} else {
+		x, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("expected integer as timestamp, got %q", s)
+		}
+		ms = x
+	}
+
+	return &ms, nil
+}
+
+// parseExemplar parse the OpenMetrics exemplar like
+// `{trace_id="KOO5S4vxi0o"} 0.67 1520879602.29`.
+func parseExemplar(s string) (*dto.Exemplar, error) {
+	if s == "" || s[0] != '{' {
+		return nil, fmt.Errorf("expected '{' at start of exemplar, got %q", s)
+	}
+
+	labels, rest, err := splitLabels(s[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("expected value of exemplar")
+	}
+
+	v, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return nil, fmt.Errorf("expected float as exemplar value, got %q", fields[0])
+	}
+
+	e := &dto.Exemplar{Label: labels, Value: &v}
+
+	if len(fields) > 1 {
+		ts, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("expected float as exemplar timestamp, got %q", fields[1])
+		}
+
+		sec, frac := math.Modf(ts)
+		e.Timestamp = timestamppb.New(time.Unix(int64(sec), int64(frac*1e9)))
+	}
+
+	return e, nil
+}
+
+// splitLabels parse the labels after '{', the rest after '}' returned.
+func splitLabels(s string) (labels []*dto.LabelPair, rest string, err error) {
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if s == "" {
+			return nil, "", fmt.Errorf("unexpected end of labels")
+		}
+
+		if s[0] == '}' {
+			return labels, s[1:], nil
+		}
+
+		i := strings.IndexByte(s, '=')
+		if i <= 0 {
+			return nil, "", fmt.Errorf("expected label name in %q", s)
+		}
+
+		lname := strings.TrimSpace(s[:i])
+		if !isValidLabelName(lname) {
+			return nil, "", fmt.Errorf("invalid label name %q", lname)
+		}
+
+		s = strings.TrimLeft(s[i+1:], " \t")
+		if s == "" || s[0] != '"' {
+			return nil, "", fmt.Errorf("expected '\"' at start of label value of %q", lname)
+		}
+
+		var lvalue string
+		if lvalue, s, err = unquoteLabelValue(s[1:]); err != nil {
+			return nil, "", err
+		}
+
+		labels = append(labels, &dto.LabelPair{Name: &lname, Value: &lvalue})
+
+		s = strings.TrimLeft(s, " \t")
+		if s != "" && s[0] == ',' {
+			s = s[1:]
+		}
+	}
+}
+
+// unquoteLabelValue read the label value until the closing '"', escaping
+// '\\', '\"' and '\n'.
+func unquoteLabelValue(s string) (value, rest string, err error) {
+	j := strings.IndexAny(s, `"\`)
+	if j >= 0 && s[j] == '"' { // fast path: no escaping
+		return s[:j], s[j+1:], nil
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"':
+			return sb.String(), s[i+1:], nil
+		case '\\':
+			if i++; i == len(s) {
+				return "", "", fmt.Errorf("unexpected end of label value")
+			}
+
+			switch s[i] {
+			case '\\', '"':
+				sb.WriteByte(s[i])
+			case 'n':
+				sb.WriteByte('\n')
+			default:
+				return "", "", fmt.Errorf("invalid escape sequence '\\%c'", s[i])
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+
+	return "", "", fmt.Errorf("unexpected end of label value")
+}
+
+func labelSignature(labels []*dto.LabelPair) string {
+	arr := make([]string, 0, len(labels))
+	for _, l := range labels {
+		arr = append(arr, l.GetName()+"\xff"+l.GetValue())
+	}
+	sort.Strings(arr)
+
+	return strings.Join(arr, "\xfe")
+}
+
+func isValidMetricName(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c == '_' || c == ':' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (i > 0 && c >= '0' && c <= '9')) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func isValidLabelName(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (i > 0 && c >= '0' && c <= '9')) {
+			return false
+		}
+	}
+	return s != ""
+}
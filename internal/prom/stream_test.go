@@ -0,0 +1,218 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package prom
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	T "testing"
+
+	"github.com/GuanceCloud/cliutils/point"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func streamCollect(t *T.T, body string, opts ...PromOption) (batches [][]*point.Point, err error) {
+	t.Helper()
+
+	p, err := NewProm(append(opts, WithTimestamp(1), WithMaxBatchCallback(1, func(pts []*point.Point) error {
+		batches = append(batches, pts)
+		return nil
+	}))...)
+	require.NoError(t, err)
+
+	_, err = p.ProcessMetrics(strings.NewReader(body), "")
+	return batches, err
+}
+
+func lineProtos(pts []*point.Point) []string {
+	var arr []string
+	for _, pt := range pts {
+		arr = append(arr, pt.LineProto())
+	}
+	sort.Strings(arr)
+	return arr
+}
+
+// streamFamily parse the body of single family, and get the family before
+// converted to points.
+func streamFamily(t *T.T, body string, om bool) *dto.MetricFamily {
+	t.Helper()
+
+	p, err := NewProm()
+	require.NoError(t, err)
+
+	sp := newStreamParser(p, "")
+	sp.om = om
+	for _, line := range strings.Split(strings.TrimSpace(body), "\n") {
+		require.NoError(t, sp.parseLine(strings.TrimSpace(line)), line)
+	}
+
+	require.NotNil(t, sp.mf)
+	return sp.mf
+}
+
+// timestamps get the timestamp(or -1 if absent) of the metrics on the label
+// signature.
+func timestamps(mf *dto.MetricFamily) map[string]int64 {
+	res := map[string]int64{}
+	for _, m := range mf.GetMetric() {
+		ts := int64(-1)
+		if m.TimestampMs != nil {
+			ts = m.GetTimestampMs()
+		}
+		res[labelSignature(m.GetLabel())] = ts
+	}
+	return res
+}
+
+func TestStreamParserTimestamp(t *T.T) {
+	for _, body := range []string{
+		`# TYPE node_load1 gauge
+node_load1{cpu="0"} 0.5 1700000000123
+node_load1{cpu="1"} 0.6
+node_load1{cpu="2"} 0.7 0`,
+
+		`# TYPE http_requests_total counter
+http_requests_total{code="200"} 1027 1395066363000
+http_requests_total{code="400"} 3 1395066363000`,
+
+		`node_untyped 1 -1700000000000`,
+
+		`# TYPE rpc_duration_seconds summary
+rpc_duration_seconds{quantile="0.5",service="a"} 4773 1700000000000
+rpc_duration_seconds{quantile="0.9",service="a"} 9001 1700000000000
+rpc_duration_seconds_sum{service="a"} 1.7560473e+07 1700000000000
+rpc_duration_seconds_count{service="a"} 2693 1700000001000
+rpc_duration_seconds_sum{service="b"} 1
+rpc_duration_seconds_count{service="b"} 1`,
+
+		`# TYPE http_request_duration_seconds histogram
+http_request_duration_seconds_bucket{le="0.1"} 1 1700000000000
+http_request_duration_seconds_bucket{le="+Inf"} 2 1700000000000
+http_request_duration_seconds_sum 0.5 1700000000000
+http_request_duration_seconds_count 2 1700000002000`,
+	} {
+		expect, err := (&expfmt.TextParser{}).TextToMetricFamilies(strings.NewReader(body + "\n"))
+		require.NoError(t, err)
+		require.Len(t, expect, 1)
+
+		got := streamFamily(t, body, false)
+		assert.Equal(t, timestamps(expect[got.GetName()]), timestamps(got), body)
+	}
+
+	t.Run("open-metrics", func(t *T.T) {
+		mf := streamFamily(t, `# TYPE node_load1 gauge
+node_load1{cpu="0"} 0.5 1700000000.123
+node_load1{cpu="1"} 0.6`, true)
+
+		assert.Equal(t, map[string]int64{
+			labelSignature([]*dto.LabelPair{{Name: proto.String("cpu"), Value: proto.String("0")}}): 1700000000123,
+			labelSignature([]*dto.LabelPair{{Name: proto.String("cpu"), Value: proto.String("1")}}): -1,
+		}, timestamps(mf))
+	})
+
+	t.Run("invalid", func(t *T.T) {
+		_, err := streamCollect(t, "node_load1 0.5 1700000000.123\n")
+		assert.Error(t, err)
+	})
+}
+
+func TestStreamParser(t *T.T) {
+	t.Run("same-as-non-stream", func(t *T.T) {
+		data, err := os.ReadFile("_test/istio.txt")
+		require.NoError(t, err)
+
+		p, err := NewProm(WithTimestamp(1), WithDisableInfoTag(true))
+		require.NoError(t, err)
+		expect, err := p.ProcessMetrics(strings.NewReader(string(data)), "")
+		require.NoError(t, err)
+
+		batches, err := streamCollect(t, string(data), WithDisableInfoTag(true))
+		require.NoError(t, err)
+
+		var got []*point.Point
+		for _, b := range batches {
+			got = append(got, b...)
+		}
+
+		assert.Equal(t, lineProtos(expect), lineProtos(got))
+	})
+
+	t.Run("chunk-large-family", func(t *T.T) {
+		var sb strings.Builder
+		sb.WriteString("# TYPE container_cpu_usage_seconds_total counter\n")
+		for i := 0; i < 3*streamMaxSamples+10; i++ {
+			fmt.Fprintf(&sb, "container_cpu_usage_seconds_total{id=\"%d\"} %d\n", i, i)
+		}
+
+		batches, err := streamCollect(t, sb.String())
+		require.NoError(t, err)
+		require.Len(t, batches, 4)
+
+		n := 0
+		for _, b := range batches {
+			assert.LessOrEqual(t, len(b), streamMaxSamples)
+			n += len(b)
+		}
+		assert.Equal(t, 3*streamMaxSamples+10, n)
+	})
+
+	t.Run("histogram-and-escape", func(t *T.T) {
+		body := `# TYPE http_request_duration_seconds histogram
+http_request_duration_seconds_bucket{le="0.1",path="/a\"b\\c"} 1
+http_request_duration_seconds_bucket{path="/a\"b\\c",le="+Inf",} 2
+http_request_duration_seconds_bucket{le="+Inf",path="/x"} 3
+http_request_duration_seconds_sum{path="/a\"b\\c"} 0.5
+http_request_duration_seconds_count{path="/a\"b\\c"} 2 1700000000000
+node_load1 0.5
+`
+		batches, err := streamCollect(t, body)
+		require.NoError(t, err)
+		require.Len(t, batches, 2)
+
+		assert.Equal(t, []string{
+			`http,le=+Inf,path=/a"b\c request_duration_seconds_bucket=2 1`,
+			`http,le=+Inf,path=/x request_duration_seconds_bucket=3 1`,
+			`http,le=0.1,path=/a"b\c request_duration_seconds_bucket=1 1`,
+			`http,path=/a"b\c request_duration_seconds_count=2,request_duration_seconds_sum=0.5 1`,
+			`http,path=/x request_duration_seconds_count=0,request_duration_seconds_sum=0 1`,
+		}, lineProtos(batches[0]))
+		assert.Equal(t, []string{`node load1=0.5 1`}, lineProtos(batches[1]))
+	})
+
+	t.Run("filtered", func(t *T.T) {
+		body := `# TYPE go_goroutines gauge
+go_goroutines 10
+# TYPE node_load1 gauge
+node_load1 0.5
+`
+		batches, err := streamCollect(t, body, WithMetricNameFilterIgnore([]string{"^go_"}))
+		require.NoError(t, err)
+		require.Len(t, batches, 1)
+		assert.Equal(t, []string{`node load1=0.5 1`}, lineProtos(batches[0]))
+	})
+
+	t.Run("invalid", func(t *T.T) {
+		for _, body := range []string{
+			"node_load1\n",
+			"node_load1 abc\n",
+			`node_load1{a="b} 1`,
+			`node_load1{a=b} 1`,
+			`node_load1{1a="b"} 1`,
+			"# TYPE node_load1 unknown\n",
+		} {
+			_, err := streamCollect(t, "up 1\n"+body)
+			assert.Error(t, err, body)
+			assert.Contains(t, err.Error(), "line 2", body)
+		}
+	})
+}
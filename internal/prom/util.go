@@ -287,10 +287,7 @@ func (p *Prom) filterMetricFamilies(metricFamilies map[string]*dto.MetricFamily)
 
 // text2Metrics converts raw prometheus metric text to line protocol point.
 func (p *Prom) text2Metrics(in io.Reader, u string) (pts []*point.Point, lastErr error) {
-	p.ptCount = 0
-	for k := range p.InfoTags {
-		delete(p.InfoTags, k)
-	}
+	p.resetScrape()
 
 	if p.opt.batchCallback != nil {
 		return p.text2MetricsBatch(in, u)
@@ -300,8 +297,7 @@ func (p *Prom) text2Metrics(in io.Reader, u string) (pts []*point.Point, lastErr
 }
 
 func (p *Prom) text2MetricsBatch(in io.Reader, u string) (pts []*point.Point, lastErr error) {
-	err := p.parser.StreamingParse(in)
-	return nil, err
+	return nil, newStreamParser(p, u).parse(in)
 }
 
 func (p *Prom) text2MetricsNoBatch(in io.Reader, u string) (pts []*point.Point, lastErr error) {
@@ -339,6 +335,10 @@ func (p *Prom) MetricFamilies2points(metricFamilies map[string]*dto.MetricFamily
 				kvs := p.getTags(m.GetLabel(), measurementName, u)
 
 				kvs = p.filterIgnoreTagKV(kvs)
+				if e := m.GetCounter().GetExemplar(); e != nil && p.opt.keepExemplars {
+					pts = append(pts, p.exemplarPoint(measurementName, fieldName, kvs, e, opts))
+				}
+
 				kvs = kvs.Add(fieldName, v, false, false)
 
 				if p.opt.asLogging != nil && p.opt.asLogging.Enable {
@@ -380,7 +380,7 @@ func (p *Prom) MetricFamilies2points(metricFamilies map[string]*dto.MetricFamily
 		case dto.MetricType_HISTOGRAM:
 			for _, m := range value.GetMetric() {
 				kvs := p.filterIgnoreTagKV(p.getTags(m.GetLabel(), measurementName, u))
-				kvs = kvs.Add(fieldName+"_count", float64(m.GetHistogram().GetSampleCount()), false, false)
+				kvs = kvs.Add(fieldName+"_count", histogramCount(m.GetHistogram()), false, false)
 				kvs = kvs.Add(fieldName+"_sum", m.GetHistogram().GetSampleSum(), false, false)
 
 				if p.opt.asLogging != nil && p.opt.asLogging.Enable {
@@ -389,8 +389,12 @@ func (p *Prom) MetricFamilies2points(metricFamilies map[string]*dto.MetricFamily
 
 				pts = append(pts, point.NewPointV2(measurementName, kvs, opts...))
 
-				for _, b := range m.GetHistogram().GetBucket() {
+				for _, b := range histogramBuckets(m.GetHistogram()) {
 					kvs := p.filterIgnoreTagKV(p.getTagsWithLE(m.GetLabel(), measurementName, b))
+					if e := b.GetExemplar(); e != nil && p.opt.keepExemplars {
+						pts = append(pts, p.exemplarPoint(measurementName, fieldName+"_bucket", kvs, e, opts))
+					}
+
 					kvs = kvs.Add(fieldName+"_bucket", float64(b.GetCumulativeCount()), false, false)
 					if p.opt.asLogging != nil && p.opt.asLogging.Enable {
 						kvs = kvs.Add("status", statusInfo, false, false)